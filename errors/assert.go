@@ -0,0 +1,71 @@
+package errors
+
+import (
+	"fmt"
+	"sync/atomic"
+)
+
+// AssertMode sets what a failed Assert or AssertNoErr does, see SetAssertMode.
+type AssertMode int32
+
+const (
+	// AssertPanic panics with the assertion error, it is the default.
+	AssertPanic AssertMode = iota
+	// AssertWarn writes the assertion error as a warning and continues.
+	AssertWarn
+	// AssertOff ignores failed assertions.
+	AssertOff
+)
+
+// AssertionError is the error matched by the errors of failed assertions, e.g.
+// errors.Is(recovered.(error), errors.AssertionError).
+var AssertionError = Error("assertion failed")
+
+// assertMode is the AssertMode set by SetAssertMode, it is accessed atomically.
+var assertMode int32
+
+// SetAssertMode sets what a failed assertion does, e.g. AssertWarn in production builds
+// keeps the invariant checks without crashing the process.
+func SetAssertMode(mode AssertMode) {
+	atomic.StoreInt32(&assertMode, int32(mode))
+}
+
+// Assert checks the internal invariant cond, e.g.
+//
+//	errors.Assert(used >= 0, "used must never be negative, got %d", used)
+//
+// If cond is false it fails with an error matching AssertionError, with the message
+// "assertion failed: <formatted message>" and the stack trace of the caller, which is
+// handled according to the AssertMode.
+func Assert(cond bool, format string, args ...any) {
+	if cond || AssertMode(atomic.LoadInt32(&assertMode)) == AssertOff {
+		return
+	}
+	failAssertion(&iErr{
+		errs:      []error{AssertionError, Error("assertion failed: " + fmt.Sprintf(format, args...))},
+		argErrNum: 1,
+		Tracer:    GetTrace(3),
+	})
+}
+
+// AssertNoErr checks that err is nil like Assert, the error of the failed assertion has
+// the message "assertion failed: <msg>, err: <err>" and wraps err.
+func AssertNoErr(err error, msg string) {
+	if err == nil || AssertMode(atomic.LoadInt32(&assertMode)) == AssertOff {
+		return
+	}
+	failAssertion(&iErr{
+		errs:      []error{AssertionError, err, Error("assertion failed: " + msg + ", err: " + err.Error())},
+		argErrNum: 2,
+		Tracer:    GetTrace(3),
+	})
+}
+
+// failAssertion handles the error of a failed assertion according to the AssertMode.
+func failAssertion(err error) {
+	if AssertMode(atomic.LoadInt32(&assertMode)) == AssertWarn {
+		Warning(err)
+		return
+	}
+	panic(err)
+}
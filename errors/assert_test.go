@@ -0,0 +1,86 @@
+package errors
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// recoverAssertion calls fn and returns the error it panics with, or nil.
+func recoverAssertion(fn func()) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = r.(error)
+		}
+	}()
+	fn()
+	return nil
+}
+
+func TestAssert(t *testing.T) {
+	defer SetAssertMode(AssertPanic)
+
+	cases := []struct {
+		name string
+		fn   func()
+		msg  string
+		is   error
+	}{
+		{
+			"assert",
+			func() { Assert(-1 >= 0, "used must never be negative, got %d", -1) },
+			"assertion failed: used must never be negative, got -1",
+			AssertionError,
+		},
+		{
+			"assert no args",
+			func() { Assert(false, "writer must be non-nil") },
+			"assertion failed: writer must be non-nil",
+			AssertionError,
+		},
+		{
+			"assert no error",
+			func() { AssertNoErr(os.ErrNotExist, "config must exist") },
+			"assertion failed: config must exist, err: file does not exist",
+			os.ErrNotExist,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			SetAssertMode(AssertPanic)
+			err := recoverAssertion(c.fn)
+			require.EqualError(t, err, c.msg)
+			require.ErrorIs(t, err, AssertionError)
+			require.ErrorIs(t, err, c.is)
+			frames := StackTrace(err)
+			require.NotEmpty(t, frames)
+			require.True(t, strings.HasPrefix(frames[0].Function, "github.com/stkali/utility/errors.TestAssert.func"), frames[0].Function)
+
+			var out bytes.Buffer
+			SetWarningOutput(&out)
+			defer SetWarningOutput(os.Stderr)
+			SetAssertMode(AssertWarn)
+			require.NoError(t, recoverAssertion(c.fn))
+			require.Contains(t, out.String(), c.msg)
+
+			out.Reset()
+			SetAssertMode(AssertOff)
+			require.NoError(t, recoverAssertion(c.fn))
+			require.Empty(t, out.String())
+		})
+	}
+}
+
+func TestAssertHolds(t *testing.T) {
+	defer SetAssertMode(AssertPanic)
+	for _, mode := range []AssertMode{AssertPanic, AssertWarn, AssertOff} {
+		SetAssertMode(mode)
+		require.NoError(t, recoverAssertion(func() {
+			Assert(true, "never fails")
+			AssertNoErr(nil, "never fails")
+		}))
+	}
+}
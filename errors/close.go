@@ -0,0 +1,52 @@
+package errors
+
+import "io"
+
+// DeferClose closes c and joins a close failure into *errp, it is meant to be deferred
+// by a function with a named error result:
+//
+//	func write(name string) (err error) {
+//		fd, err := os.Create(name)
+//		if err != nil {
+//			return err
+//		}
+//		defer errors.DeferClose(&err, fd)
+//		...
+//	}
+//
+// An error already stored in *errp is kept, so both are matched by Is. With a nil errp
+// the close failure is written as a warning.
+func DeferClose(errp *error, c io.Closer) {
+	if c == nil {
+		return
+	}
+	err := c.Close()
+	if err == nil {
+		return
+	}
+	err = Wrap(err, "failed to close")
+	if errp == nil {
+		Warning(err)
+		return
+	}
+	if *errp == nil {
+		*errp = err
+		return
+	}
+	*errp = Join(*errp, err)
+}
+
+// CloseAll closes all the closers, keeps going after a failure and returns the failures
+// joined into one error, or nil if all of them succeed. nil closers are skipped.
+func CloseAll(closers ...io.Closer) error {
+	var c Collector
+	for _, closer := range closers {
+		if closer == nil {
+			continue
+		}
+		if err := closer.Close(); err != nil {
+			c.Add(Wrap(err, "failed to close"))
+		}
+	}
+	return c.Err()
+}
@@ -0,0 +1,81 @@
+package errors
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// testCloser is an io.Closer returning err and counting the calls.
+type testCloser struct {
+	err   error
+	calls int
+}
+
+func (c *testCloser) Close() error {
+	c.calls++
+	return c.err
+}
+
+func TestDeferClose(t *testing.T) {
+	readErr := Error("failed to read")
+	cases := []struct {
+		name     string
+		closeErr error
+		fnErr    error
+		is       []error
+		msg      string
+	}{
+		{"no error", nil, nil, nil, ""},
+		{"close error", os.ErrClosed, nil, []error{os.ErrClosed}, "failed to close, err: file already closed"},
+		{"function error", nil, readErr, []error{readErr}, "failed to read"},
+		{"both", os.ErrClosed, readErr, []error{readErr, os.ErrClosed}, "failed to read\nfailed to close, err: file already closed"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			closer := &testCloser{err: c.closeErr}
+			fn := func() (err error) {
+				defer DeferClose(&err, closer)
+				return c.fnErr
+			}
+			err := fn()
+			require.Equal(t, 1, closer.calls)
+			if c.msg == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.EqualError(t, err, c.msg)
+			for _, target := range c.is {
+				require.ErrorIs(t, err, target)
+			}
+		})
+	}
+}
+
+func TestDeferCloseWarning(t *testing.T) {
+	var out bytes.Buffer
+	SetWarningOutput(&out)
+	defer SetWarningOutput(os.Stderr)
+
+	DeferClose(nil, &testCloser{})
+	DeferClose(nil, nil)
+	require.Empty(t, out.String())
+	DeferClose(nil, &testCloser{err: os.ErrInvalid})
+	require.Equal(t, "warning: failed to close, err: invalid argument\n", out.String())
+}
+
+func TestCloseAll(t *testing.T) {
+	require.NoError(t, CloseAll())
+	closers := []*testCloser{{}, {err: os.ErrClosed}, {}, {err: os.ErrInvalid}}
+	err := CloseAll(closers[0], closers[1], nil, closers[2], closers[3])
+	require.ErrorIs(t, err, os.ErrClosed)
+	require.ErrorIs(t, err, os.ErrInvalid)
+	require.EqualError(t, err, "failed to close, err: file already closed\nfailed to close, err: invalid argument")
+	for _, c := range closers {
+		require.Equal(t, 1, c.calls)
+	}
+	require.NoError(t, CloseAll(io.NopCloser(nil)))
+}
@@ -0,0 +1,142 @@
+package errors
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+)
+
+// Code is the category of an error, for branching on the kind of failure rather than the
+// identity of an error, and mapping it to an exit status or a HTTP status.
+type Code int
+
+const (
+	// Unknown is the code of an error without code.
+	Unknown Code = iota
+	NotFound
+	PermissionDenied
+	InvalidInput
+	Unavailable
+	Timeout
+	Internal
+)
+
+var codeNames = [...]string{
+	Unknown:          "Unknown",
+	NotFound:         "NotFound",
+	PermissionDenied: "PermissionDenied",
+	InvalidInput:     "InvalidInput",
+	Unavailable:      "Unavailable",
+	Timeout:          "Timeout",
+	Internal:         "Internal",
+}
+
+// String implements fmt.Stringer.
+func (c Code) String() string {
+	if c >= 0 && int(c) < len(codeNames) {
+		return codeNames[c]
+	}
+	return fmt.Sprintf("Code(%d)", int(c))
+}
+
+// ExitStatus returns the exit status of the code, following the BSD sysexits.h
+// conventions, e.g. 66 (EX_NOINPUT) for NotFound. It returns 1 for Unknown.
+func (c Code) ExitStatus() int {
+	switch c {
+	case NotFound:
+		return 66 // EX_NOINPUT
+	case PermissionDenied:
+		return 77 // EX_NOPERM
+	case InvalidInput:
+		return 65 // EX_DATAERR
+	case Unavailable:
+		return 69 // EX_UNAVAILABLE
+	case Timeout:
+		return 75 // EX_TEMPFAIL
+	case Internal:
+		return 70 // EX_SOFTWARE
+	}
+	return 1
+}
+
+// HTTPStatus returns the HTTP status of the code, 500 for Unknown and Internal.
+// The values are spelled out to not import net/http in every program using errors.
+func (c Code) HTTPStatus() int {
+	switch c {
+	case NotFound:
+		return 404 // Not Found
+	case PermissionDenied:
+		return 403 // Forbidden
+	case InvalidInput:
+		return 400 // Bad Request
+	case Unavailable:
+		return 503 // Service Unavailable
+	case Timeout:
+		return 504 // Gateway Timeout
+	}
+	return 500 // Internal Server Error
+}
+
+// codeError is an error annotated with a code by WithCode.
+type codeError struct {
+	err  error
+	code Code
+}
+
+// Error returns the message of the annotated error.
+func (c *codeError) Error() string {
+	return c.err.Error()
+}
+
+// Unwrap returns the annotated error.
+func (c *codeError) Unwrap() error {
+	return c.err
+}
+
+// Format formats the annotated error, so that %v keeps its trace.
+func (c *codeError) Format(f fmt.State, verb rune) {
+	if formatter, ok := c.err.(fmt.Formatter); ok {
+		formatter.Format(f, verb)
+		return
+	}
+	switch verb {
+	case 'q':
+		_, _ = fmt.Fprintf(f, "%q", c.err.Error())
+	default:
+		_, _ = io.WriteString(f, c.err.Error())
+	}
+}
+
+// WithCode returns err annotated with code, see CodeOf. It returns nil if err is nil.
+func WithCode(err error, code Code) error {
+	if err == nil {
+		return nil
+	}
+	return &codeError{err: err, code: code}
+}
+
+// CodeOf returns the code of err: the first code set by WithCode in the chain of err,
+// otherwise the code of the well-known errors of the standard library, e.g. NotFound for
+// os.ErrNotExist, Timeout for context.DeadlineExceeded. It returns Unknown if err has no
+// code or is nil.
+func CodeOf(err error) Code {
+	if err == nil {
+		return Unknown
+	}
+	var c *codeError
+	if As(err, &c) {
+		return c.code
+	}
+	switch {
+	case Is(err, os.ErrNotExist):
+		return NotFound
+	case Is(err, os.ErrPermission):
+		return PermissionDenied
+	case Is(err, os.ErrInvalid):
+		return InvalidInput
+	case Is(err, context.DeadlineExceeded), Is(err, os.ErrDeadlineExceeded):
+		return Timeout
+	}
+	return Unknown
+}
@@ -0,0 +1,78 @@
+package errors
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCodeOf(t *testing.T) {
+	_, statErr := os.Stat("not-existed-file")
+	cases := []struct {
+		name   string
+		err    error
+		expect Code
+	}{
+		{"nil", nil, Unknown},
+		{"plain", Error("plain"), Unknown},
+		{"with code", WithCode(Error("plain"), Unavailable), Unavailable},
+		{"wrapped", fmt.Errorf("ctx: %w", WithCode(New("x"), InvalidInput)), InvalidInput},
+		{"in iErr", Newf("failed, err: %s", WithCode(New("x"), Internal)), Internal},
+		{"first code wins", WithCode(WithCode(os.ErrNotExist, Internal), Unavailable), Unavailable},
+		{"code overrides stdlib", WithCode(os.ErrNotExist, Internal), Internal},
+		{"not exist", statErr, NotFound},
+		{"wrapped not exist", Newf("failed to stat, err: %s", statErr), NotFound},
+		{"permission", os.ErrPermission, PermissionDenied},
+		{"invalid", os.ErrInvalid, InvalidInput},
+		{"deadline", fmt.Errorf("call: %w", context.DeadlineExceeded), Timeout},
+		{"os deadline", os.ErrDeadlineExceeded, Timeout},
+		{"joined", Join(Error("x"), os.ErrPermission), PermissionDenied},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			require.Equal(t, c.expect, CodeOf(c.err))
+		})
+	}
+}
+
+func TestWithCode(t *testing.T) {
+	require.Nil(t, WithCode(nil, NotFound))
+
+	inner := New("inner")
+	err := WithCode(inner, NotFound)
+	require.True(t, Is(err, inner))
+	require.Equal(t, "inner", err.Error())
+	require.Equal(t, "inner", fmt.Sprintf("%s", err))
+	require.Equal(t, `"inner"`, fmt.Sprintf("%q", err))
+	require.True(t, regxMatchErrorTrace.MatchString(fmt.Sprintf("%v", err)))
+	require.Equal(t, "plain", fmt.Sprintf("%v", WithCode(Error("plain"), NotFound)))
+}
+
+func TestCodeStatus(t *testing.T) {
+	cases := []struct {
+		code Code
+		name string
+		exit int
+		http int
+	}{
+		{Unknown, "Unknown", 1, http.StatusInternalServerError},
+		{NotFound, "NotFound", 66, http.StatusNotFound},
+		{PermissionDenied, "PermissionDenied", 77, http.StatusForbidden},
+		{InvalidInput, "InvalidInput", 65, http.StatusBadRequest},
+		{Unavailable, "Unavailable", 69, http.StatusServiceUnavailable},
+		{Timeout, "Timeout", 75, http.StatusGatewayTimeout},
+		{Internal, "Internal", 70, http.StatusInternalServerError},
+		{Code(100), "Code(100)", 1, http.StatusInternalServerError},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			require.Equal(t, c.name, c.code.String())
+			require.Equal(t, c.exit, c.code.ExitStatus())
+			require.Equal(t, c.http, c.code.HTTPStatus())
+		})
+	}
+}
@@ -0,0 +1,41 @@
+package errors
+
+import "sync"
+
+// Collector collects errors to report them all at once, for the operations that keep
+// going after a failure, e.g. deleting a list of files. It is safe for concurrent use
+// and its zero value is ready to use.
+type Collector struct {
+	mtx  sync.Mutex
+	errs []error
+}
+
+// Add adds err to the collector, a nil err is ignored.
+func (c *Collector) Add(err error) {
+	if err == nil {
+		return
+	}
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	c.errs = append(c.errs, err)
+}
+
+// Addf adds an error created by Newf with format and a to the collector.
+func (c *Collector) Addf(format string, a ...any) {
+	c.Add(Newf(format, a...))
+}
+
+// Len returns the number of collected errors.
+func (c *Collector) Len() int {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	return len(c.errs)
+}
+
+// Err returns the collected errors joined by Join, one per line, or nil if no error
+// was collected.
+func (c *Collector) Err() error {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	return Join(c.errs...)
+}
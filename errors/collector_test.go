@@ -0,0 +1,44 @@
+package errors
+
+import (
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCollector(t *testing.T) {
+	var c Collector
+	require.NoError(t, c.Err())
+	require.Equal(t, 0, c.Len())
+
+	c.Add(nil)
+	require.NoError(t, c.Err())
+
+	c.Add(os.ErrNotExist)
+	c.Addf("failed to remove file %q, err: %s", "a.log", os.ErrPermission)
+	require.Equal(t, 2, c.Len())
+	err := c.Err()
+	require.Error(t, err)
+	require.Equal(t, "file does not exist\nfailed to remove file \"a.log\", err: permission denied", err.Error())
+	require.True(t, Is(err, os.ErrNotExist))
+	require.True(t, Is(err, os.ErrPermission))
+}
+
+func TestCollectorConcurrent(t *testing.T) {
+	var c Collector
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				c.Addf("error %d-%d", i, j)
+			}
+		}(i)
+	}
+	wg.Wait()
+	require.Equal(t, 1000, c.Len())
+	require.Len(t, c.Err().(interface{ Unwrap() []error }).Unwrap(), 1000)
+}
@@ -59,8 +59,8 @@ func Newf(format string, a ...any) error {
 			err.argErrNum++
 		}
 		if err.Tracer == nil {
-			if v, ok := a[i].(*iErr); ok {
-				err.Tracer = v.Tracer
+			if e, ok := a[i].(error); ok {
+				err.Tracer = tracerOf(e)
 			}
 		}
 	}
@@ -80,6 +80,21 @@ func Newf(format string, a ...any) error {
 	return err
 }
 
+// WithStack returns err with the stack trace of the caller, or err itself if it already
+// has a stack trace so that the innermost one is kept. It returns nil if err is nil.
+func WithStack(err error) error {
+	if err == nil {
+		return nil
+	}
+	if tracerOf(err) != nil {
+		return err
+	}
+	return &iErr{
+		errs:   []error{err},
+		Tracer: GetTrace(3),
+	}
+}
+
 // Unwrap returns the list of errors wrapped by iErr.
 func (i *iErr) Unwrap() []error {
 	return i.errs
@@ -95,6 +110,16 @@ func (i *iErr) Is(err error) bool {
 	return false
 }
 
+// As finds the first error in the error chain that matches target, see errors.As.
+func (i *iErr) As(target any) bool {
+	for _, e := range i.errs {
+		if As(e, target) {
+			return true
+		}
+	}
+	return false
+}
+
 // Error returns a formatted string of the errors after skipping the first argErrNum errors.
 func (i *iErr) Error() string {
 	var b []byte
@@ -110,15 +135,36 @@ func (i *iErr) Error() string {
 // Format implements the fmt.Formatter interface.
 // %s %q will print error string.
 // %v will print error string with trace stack information.
+// %+v will print error string and its fields (see WithFields) followed by the frames of
+// the stack trace, one "function\n\tfile:line" per frame.
 func (i *iErr) Format(f fmt.State, verb rune) {
+	formatError(f, verb, i, i.Tracer)
+}
+
+// formatError formats err with the stack trace tracer, which may be nil, see iErr.Format.
+func formatError(f fmt.State, verb rune, err error, tracer Tracer) {
+	msg := err.Error()
 	switch verb {
 	case 'v':
-		_, _ = fmt.Fprintf(f, "Error: %s\n", i.Error())
-		i.Traceback(f)
+		if f.Flag('+') {
+			_, _ = io.WriteString(f, msg)
+			writeFields(f, Fields(err))
+			if tracer != nil {
+				for _, frame := range frames(tracer) {
+					_, _ = fmt.Fprintf(f, "\n%s\n\t%s:%d", frame.Function, frame.File, frame.Line)
+				}
+			}
+			return
+		}
+		_, _ = fmt.Fprintf(f, "Error: %s\n", msg)
+		// the errors joined by Join may have no trace
+		if tracer != nil {
+			tracer.Traceback(f)
+		}
 	case 'q':
-		_, _ = fmt.Fprintf(f, "%q", i.Error())
+		_, _ = fmt.Fprintf(f, "%q", msg)
 	default:
-		_, _ = io.WriteString(f, i.Error())
+		_, _ = io.WriteString(f, msg)
 	}
 }
 
@@ -151,10 +197,8 @@ func Join(errs ...error) error {
 		errs: make([]error, 0, errCount),
 	}
 	for i := 0; i < length; i++ {
-		if newErr.Tracer == nil {
-			if v, ok := errs[i].(*iErr); ok {
-				newErr.Tracer = v.Tracer
-			}
+		if newErr.Tracer == nil && errs[i] != nil {
+			newErr.Tracer = tracerOf(errs[i])
 		}
 		if errs[i] != nil {
 			newErr.errs = append(newErr.errs, errs[i])
@@ -176,3 +176,15 @@ func TestJoinErrorMethod(t *testing.T) {
 		})
 	}
 }
+
+func TestJoinAs(t *testing.T) {
+	_, statErr := os.Stat("not-existed-file")
+	err := Join(New("err1"), statErr)
+	var pathErr *os.PathError
+	require.True(t, As(err, &pathErr))
+	require.Equal(t, "not-existed-file", pathErr.Path)
+	require.True(t, Is(err, os.ErrNotExist))
+
+	var numErr *os.SyscallError
+	require.False(t, As(err, &numErr))
+}
@@ -5,6 +5,9 @@ import (
 	"io"
 	"os"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 )
 
 var (
@@ -18,6 +21,9 @@ var (
 	// exitHook is a function hook that gets called before the program exits due to an error.
 	// It is provided the error message and a tracer.
 	exitHook ExitHook = nil
+
+	// verboseExit is set by SetVerboseExit, it is accessed atomically.
+	verboseExit int32
 )
 
 // ExitHook defines the signature of a function that can be set as a hook to execute before
@@ -44,6 +50,91 @@ func SetExitHook(hook ExitHook) {
 	exitHook = hook
 }
 
+// SetExitFunc sets the function called by Exit, Exitf and CheckErr to exit the program,
+// e.g. a fake recording the code in tests. nil restores os.Exit.
+func SetExitFunc(fn func(code int)) {
+	if fn == nil {
+		fn = os.Exit
+	}
+	osExit = fn
+}
+
+var (
+	exitMtx sync.Mutex
+	// exitHooks are the functions registered by OnExit.
+	exitHooks []func()
+	// exitHookTimeout is the time each exit hook is given to return.
+	exitHookTimeout = 5 * time.Second
+)
+
+// OnExit registers fn to run before Exit, Exitf and CheckErr exit the program, e.g. to
+// flush and close files. The hooks run once, in the reverse order of registration.
+// A hook that panics is reported as a warning, and a hook that doesn't return within the
+// timeout (see SetExitHookTimeout) is abandoned so that the program still exits.
+//
+// The hooks don't run when the program exits by calling os.Exit directly, returning from
+// main or an unrecovered panic, and os.Exit doesn't run the deferred functions either,
+// so the cleanup that must survive Exit belongs in a hook.
+func OnExit(fn func()) {
+	if fn == nil {
+		return
+	}
+	exitMtx.Lock()
+	defer exitMtx.Unlock()
+	exitHooks = append(exitHooks, fn)
+}
+
+// SetExitHookTimeout sets the time each OnExit hook is given to return, default is 5s.
+// timeout <= 0 waits for the hooks without limit.
+func SetExitHookTimeout(timeout time.Duration) {
+	exitMtx.Lock()
+	defer exitMtx.Unlock()
+	exitHookTimeout = timeout
+}
+
+// runExitHooks runs the registered OnExit hooks and unregisters them.
+func runExitHooks() {
+	exitMtx.Lock()
+	hooks, timeout := exitHooks, exitHookTimeout
+	exitHooks = nil
+	exitMtx.Unlock()
+	for i := len(hooks) - 1; i >= 0; i-- {
+		runExitHook(hooks[i], timeout)
+	}
+}
+
+// runExitHook runs hook and waits for it to return within timeout.
+func runExitHook(hook func(), timeout time.Duration) {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		defer func() {
+			if r := recover(); r != nil {
+				Warningf("exit hook panicked: %v", r)
+			}
+		}()
+		hook()
+	}()
+	if timeout <= 0 {
+		<-done
+		return
+	}
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case <-done:
+	case <-timer.C:
+		Warningf("exit hook did not return within %s", timeout)
+	}
+}
+
+// exit runs the exit hooks, flushes the suppressed warnings and exits the program with code.
+func exit(code int) {
+	runExitHooks()
+	FlushWarnings()
+	osExit(code)
+}
+
 // Exit allows customizing the function used to exit behavior of the program,
 // which is used in tests containing the os.Exit code.
 // defaults to os.Exit.
@@ -51,7 +142,7 @@ func Exit(code int) {
 	if exitHook != nil {
 		exitHook(code, "", GetTrace(3))
 	}
-	osExit(code)
+	exit(code)
 }
 
 // Exitf prints a formatted error message to the error output, calls the exit hook (if set),
@@ -70,7 +161,7 @@ func Exitf(code int, format string, args ...any) {
 	if exitHook != nil {
 		exitHook(code, msg, GetTrace(3))
 	}
-	osExit(code)
+	exit(code)
 }
 
 // CheckErr prints an error message with the set prefix to stderr and exits the program with code 1
@@ -96,5 +187,127 @@ func CheckErr(err any) {
 		}
 		exitHook(1, msg, tracer)
 	}
-	osExit(1)
+	exit(1)
+}
+
+// Check reports a non-nil err as a warning instead of exiting like CheckErr, and returns
+// whether err is nil, e.g.
+//
+//	if !errors.Check(err, "failed to load %s", name) {
+//		return
+//	}
+//
+// The warning is err wrapped with the formatted message, or err itself if format is empty.
+func Check(err error, format string, args ...any) bool {
+	if err == nil {
+		return true
+	}
+	if format != "" {
+		err = Wrapf(err, format, args...)
+	}
+	Warning(err)
+	return false
+}
+
+// CheckFatalIf exits the program like CheckErr if cond is true and err is not nil, for
+// the few failures the program can't recover from.
+func CheckFatalIf(cond bool, err error) {
+	if cond && err != nil {
+		CheckErr(err)
+	}
+}
+
+// exitCodeError is an error annotated with an exit status by WithExitCode.
+type exitCodeError struct {
+	err  error
+	code int
+}
+
+// Error returns the message of the annotated error.
+func (e *exitCodeError) Error() string {
+	return e.err.Error()
+}
+
+// Unwrap returns the annotated error.
+func (e *exitCodeError) Unwrap() error {
+	return e.err
+}
+
+// Format formats the annotated error, so that %v keeps its trace.
+func (e *exitCodeError) Format(f fmt.State, verb rune) {
+	if formatter, ok := e.err.(fmt.Formatter); ok {
+		formatter.Format(f, verb)
+		return
+	}
+	switch verb {
+	case 'q':
+		_, _ = fmt.Fprintf(f, "%q", e.err.Error())
+	default:
+		_, _ = io.WriteString(f, e.err.Error())
+	}
+}
+
+// WithExitCode returns err annotated with the exit status code used by ExitWith.
+// It returns nil if err is nil.
+func WithExitCode(err error, code int) error {
+	if err == nil {
+		return nil
+	}
+	return &exitCodeError{err: err, code: code}
+}
+
+// exitCodeOf returns the exit status of err, see ExitWith.
+func exitCodeOf(err error) int {
+	if err == nil {
+		return 0
+	}
+	var e *exitCodeError
+	if As(err, &e) {
+		return e.code
+	}
+	return CodeOf(err).ExitStatus()
+}
+
+// SetVerboseExit sets whether ExitWith prints the error with its fields and stack trace
+// (the %+v format) instead of its message only.
+func SetVerboseExit(verbose bool) {
+	var v int32
+	if verbose {
+		v = 1
+	}
+	atomic.StoreInt32(&verboseExit, v)
+}
+
+// ExitWith exits the program with the exit status picked from err: 0 if err is nil,
+// the code set by WithExitCode, otherwise the ExitStatus of the Code of err, which is 1
+// for an error without code, e.g.
+//
+//	func main() {
+//		errors.ExitWith(run())
+//	}
+//
+// A non-nil err is printed with the set prefix to the error output first, like CheckErr.
+func ExitWith(err error) {
+	code := exitCodeOf(err)
+	if err == nil {
+		exit(code)
+		return
+	}
+	format := "%s"
+	if atomic.LoadInt32(&verboseExit) == 1 {
+		format = "%+v"
+	}
+	msg := fmt.Sprintf(format, err)
+	if errPrefix != "" {
+		msg = errPrefix + ": " + msg
+	}
+	_, _ = fmt.Fprintln(errOutput, msg)
+	if exitHook != nil {
+		tracer := tracerOf(err)
+		if tracer == nil {
+			tracer = GetTrace(3)
+		}
+		exitHook(code, msg, tracer)
+	}
+	exit(code)
 }
@@ -3,10 +3,12 @@ package errors
 import (
 	"bytes"
 	"fmt"
-	"github.com/stkali/utility/lib"
 	"github.com/stretchr/testify/require"
 	"math/rand"
+	"os"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestExit(t *testing.T) {
@@ -50,7 +52,7 @@ func TestExitf(t *testing.T) {
 		SetErrPrefix("")
 		SetErrOutput(buf)
 		wantCode = rand.Intn(255)
-		wantMessage = lib.RandInternalString(8, 24)
+		wantMessage = randMessage(8, 24)
 		Exitf(wantCode, wantMessage)
 		require.Equal(t, wantCode, actualCode)
 		require.Equal(t, wantMessage, buf.String())
@@ -60,7 +62,7 @@ func TestExitf(t *testing.T) {
 		prefix := "test prefix"
 		SetErrPrefix("test prefix")
 		wantCode = rand.Intn(255)
-		wantMessage = lib.RandInternalString(8, 24)
+		wantMessage = randMessage(8, 24)
 		Exitf(wantCode, wantMessage)
 		require.Equal(t, wantCode, actualCode)
 		require.Equal(t, fmt.Sprintf("%s: %s", prefix, wantMessage), buf.String())
@@ -78,7 +80,7 @@ func TestExitf(t *testing.T) {
 		})
 		SetErrOutput(buf)
 		wantCode = rand.Intn(255)
-		wantMessage = lib.RandInternalString(8, 24)
+		wantMessage = randMessage(8, 24)
 		Exitf(wantCode, wantMessage)
 		require.Equal(t, wantCode, actualCode)
 		require.Equal(t, wantMessage, actualMessage)
@@ -159,7 +161,7 @@ func TestSetExitHook(t *testing.T) {
 	defer func() {
 		SetExitHook(originHook)
 	}()
-	wantMsg := lib.RandInternalString(8, 16)
+	wantMsg := randMessage(8, 16)
 	wantTracer := GetTrace(3)
 	wantExitCode := 100
 	var actualMsg string
@@ -223,3 +225,170 @@ func TestSetErrPrefixf(t *testing.T) {
 	prefix := fmt.Sprintf("%s err", "program")
 	require.Equal(t, errPrefix, prefix)
 }
+
+// fakeExit sets a fake exit function recording the codes for the test.
+func fakeExit(t *testing.T) *[]int {
+	var codes []int
+	SetExitFunc(func(code int) {
+		codes = append(codes, code)
+	})
+	t.Cleanup(func() { SetExitFunc(nil) })
+	return &codes
+}
+
+func TestSetExitFunc(t *testing.T) {
+	codes := fakeExit(t)
+	buf := &bytes.Buffer{}
+	originOutput := errOutput
+	SetErrOutput(buf)
+	defer SetErrOutput(originOutput)
+
+	Exit(3)
+	Exitf(4, "failed")
+	CheckErr("failed")
+	require.Equal(t, []int{3, 4, 1}, *codes)
+}
+
+func TestOnExit(t *testing.T) {
+	codes := fakeExit(t)
+	var order []string
+	OnExit(func() { order = append(order, "first") })
+	OnExit(func() { order = append(order, "second") })
+	OnExit(nil)
+	OnExit(func() {
+		require.Empty(t, *codes, "hooks run before exiting")
+		order = append(order, "third")
+	})
+
+	Exit(2)
+	require.Equal(t, []string{"third", "second", "first"}, order)
+	require.Equal(t, []int{2}, *codes)
+
+	// the hooks run once
+	Exit(0)
+	require.Len(t, order, 3)
+	require.Equal(t, []int{2, 0}, *codes)
+}
+
+func TestOnExitPanicAndTimeout(t *testing.T) {
+	codes := fakeExit(t)
+	out := &bytes.Buffer{}
+	SetWarningOutput(out)
+	SetWarningPrefix("")
+	SetExitHookTimeout(50 * time.Millisecond)
+	defer SetExitHookTimeout(5 * time.Second)
+
+	ran := false
+	block := make(chan struct{})
+	defer close(block)
+	OnExit(func() { ran = true })
+	OnExit(func() { <-block })
+	OnExit(func() { panic("boom") })
+
+	start := time.Now()
+	Exit(1)
+	require.Less(t, time.Since(start), time.Second)
+	require.True(t, ran, "the hooks after a failing one still run")
+	require.Equal(t, []int{1}, *codes)
+	require.Contains(t, out.String(), "warning: exit hook panicked: boom")
+	require.Contains(t, out.String(), "warning: exit hook did not return within 50ms")
+}
+
+func TestCheck(t *testing.T) {
+	var out bytes.Buffer
+	SetWarningOutput(&out)
+	defer SetWarningOutput(os.Stderr)
+	SetWarningPrefix("")
+
+	require.True(t, Check(nil, "failed to load %s", "config"))
+	require.Empty(t, out.String())
+
+	require.False(t, Check(os.ErrNotExist, "failed to load %s", "config"))
+	require.Equal(t, "warning: failed to load config, err: file does not exist\n", out.String())
+	out.Reset()
+	require.False(t, Check(os.ErrClosed, ""))
+	require.Equal(t, "warning: file already closed\n", out.String())
+}
+
+func TestCheckFatalIf(t *testing.T) {
+	codes := fakeExit(t)
+	buf := &bytes.Buffer{}
+	originOutput := errOutput
+	SetErrOutput(buf)
+	defer SetErrOutput(originOutput)
+
+	CheckFatalIf(false, os.ErrNotExist)
+	CheckFatalIf(true, nil)
+	require.Empty(t, *codes)
+	CheckFatalIf(true, os.ErrNotExist)
+	require.Equal(t, []int{1}, *codes)
+	require.Contains(t, buf.String(), "file does not exist")
+}
+
+func TestExitWith(t *testing.T) {
+	buf := &bytes.Buffer{}
+	originOutput := errOutput
+	SetErrOutput(buf)
+	defer SetErrOutput(originOutput)
+	SetErrPrefix("cli")
+	defer SetErrPrefix("occurred error")
+
+	cases := []struct {
+		name   string
+		err    error
+		code   int
+		output string
+	}{
+		{"nil", nil, 0, ""},
+		{"no code", Error("failed"), 1, "cli: failed\n"},
+		{"code", WithCode(Error("bad flag"), InvalidInput), 65, "cli: bad flag\n"},
+		{"std code", Wrap(os.ErrNotExist, "failed to load"), 66, "cli: failed to load, err: file does not exist\n"},
+		{"exit code", WithExitCode(Error("usage"), 2), 2, "cli: usage\n"},
+		{"exit code over code", Wrap(WithExitCode(WithCode(Error("busy"), Unavailable), 3), "failed"), 3, "cli: failed, err: busy\n"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			buf.Reset()
+			codes := fakeExit(t)
+			ExitWith(c.err)
+			require.Equal(t, []int{c.code}, *codes)
+			require.Equal(t, c.output, buf.String())
+		})
+	}
+	require.Nil(t, WithExitCode(nil, 2))
+}
+
+func TestExitWithVerbose(t *testing.T) {
+	buf := &bytes.Buffer{}
+	originOutput := errOutput
+	SetErrOutput(buf)
+	defer SetErrOutput(originOutput)
+	SetErrPrefix("")
+	defer SetErrPrefix("occurred error")
+	SetVerboseExit(true)
+	defer SetVerboseExit(false)
+
+	codes := fakeExit(t)
+	var hookCode int
+	SetExitHook(func(code int, msg string, tracer Tracer) {
+		hookCode = code
+		require.NotNil(t, tracer)
+	})
+	defer SetExitHook(nil)
+	ExitWith(WithExitCode(WithFields(New("failed"), "file", "a.log"), 4))
+	require.Equal(t, []int{4}, *codes)
+	require.Equal(t, 4, hookCode)
+	lines := strings.Split(buf.String(), "\n")
+	require.Equal(t, "failed file=a.log", lines[0])
+	require.Equal(t, "github.com/stkali/utility/errors.TestExitWithVerbose", lines[1])
+}
+
+// randMessage returns a random message of [min, max) letters, lib can't be imported
+// because it imports this package.
+func randMessage(min, max int) string {
+	b := make([]byte, min+rand.Intn(max-min))
+	for i := range b {
+		b[i] = byte('a' + rand.Intn(26))
+	}
+	return string(b)
+}
@@ -0,0 +1,106 @@
+package errors
+
+import (
+	"fmt"
+	"io"
+	"sort"
+)
+
+// badKey is the key of a value in WithFields without a string key, like log/slog.
+const badKey = "!BADKEY"
+
+// fieldsError is an error annotated with key-value fields by WithFields.
+type fieldsError struct {
+	err    error
+	fields map[string]any
+}
+
+// Error returns the message of the annotated error, the fields are printed by %+v.
+func (e *fieldsError) Error() string {
+	return e.err.Error()
+}
+
+// Unwrap returns the annotated error.
+func (e *fieldsError) Unwrap() error {
+	return e.err
+}
+
+// Format implements the fmt.Formatter interface like the errors created by New.
+func (e *fieldsError) Format(f fmt.State, verb rune) {
+	formatError(f, verb, e, tracerOf(e.err))
+}
+
+// WithFields returns err annotated with the key-value pairs kv, e.g.
+//
+//	errors.WithFields(err, "file", name, "attempt", 3)
+//
+// The pairs are read like log/slog: a value without a string key, e.g. the last element
+// of an odd-length kv, gets the key "!BADKEY". The message of err is unchanged, the
+// fields are printed by %+v and returned by Fields. It returns nil if err is nil.
+func WithFields(err error, kv ...any) error {
+	if err == nil {
+		return nil
+	}
+	if len(kv) == 0 {
+		return err
+	}
+	fields := make(map[string]any, (len(kv)+1)/2)
+	for i := 0; i < len(kv); i++ {
+		key, ok := kv[i].(string)
+		if !ok || i == len(kv)-1 {
+			fields[badKey] = kv[i]
+			continue
+		}
+		fields[key] = kv[i+1]
+		i++
+	}
+	return &fieldsError{err: err, fields: fields}
+}
+
+// Fields returns the fields attached by WithFields to err and the errors it wraps, the
+// innermost field wins when a key is set more than once. It returns nil if there is none.
+func Fields(err error) map[string]any {
+	var fields map[string]any
+	// walk from the outermost error to the innermost one, so that inner fields overwrite
+	var walk func(err error)
+	walk = func(err error) {
+		for err != nil {
+			if e, ok := err.(*fieldsError); ok {
+				if fields == nil {
+					fields = make(map[string]any, len(e.fields))
+				}
+				for k, v := range e.fields {
+					fields[k] = v
+				}
+			}
+			switch u := err.(type) {
+			case interface{ Unwrap() error }:
+				err = u.Unwrap()
+			case interface{ Unwrap() []error }:
+				for _, e := range u.Unwrap() {
+					walk(e)
+				}
+				return
+			default:
+				return
+			}
+		}
+	}
+	walk(err)
+	return fields
+}
+
+// writeFields writes the fields sorted by key as " key=value" pairs.
+func writeFields(w io.Writer, fields map[string]any) {
+	if len(fields) == 0 {
+		return
+	}
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		_, _ = fmt.Fprintf(w, " %s=%v", k, fields[k])
+	}
+}
@@ -0,0 +1,70 @@
+package errors
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithFields(t *testing.T) {
+	require.Nil(t, WithFields(nil, "file", "a.log"))
+	require.Equal(t, os.ErrNotExist, WithFields(os.ErrNotExist))
+
+	err := WithFields(os.ErrNotExist, "file", "a.log", "attempt", 3)
+	require.Equal(t, os.ErrNotExist.Error(), err.Error())
+	require.ErrorIs(t, err, os.ErrNotExist)
+	require.Equal(t, map[string]any{"file": "a.log", "attempt": 3}, Fields(err))
+
+	cases := []struct {
+		name   string
+		kv     []any
+		fields map[string]any
+	}{
+		{"odd length", []any{"file", "a.log", "attempt"}, map[string]any{"file": "a.log", badKey: "attempt"}},
+		{"non string key", []any{1, "file", "a.log"}, map[string]any{badKey: 1, "file": "a.log"}},
+		{"single value", []any{"file"}, map[string]any{badKey: "file"}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			require.Equal(t, c.fields, Fields(WithFields(os.ErrNotExist, c.kv...)))
+		})
+	}
+}
+
+func TestFields(t *testing.T) {
+	require.Nil(t, Fields(nil))
+	require.Nil(t, Fields(os.ErrNotExist))
+
+	// three levels of wrapping, the innermost field wins
+	err := WithFields(os.ErrNotExist, "file", "a.log", "op", "remove")
+	err = WithFields(Wrap(err, "failed to remove backup file"), "op", "cleanup", "attempt", 2)
+	err = WithFields(fmt.Errorf("rotate: %w", err), "attempt", 3, "folder", "logs")
+	require.Equal(t, map[string]any{
+		"file":    "a.log",
+		"op":      "remove",
+		"attempt": 2,
+		"folder":  "logs",
+	}, Fields(err))
+	require.ErrorIs(t, err, os.ErrNotExist)
+
+	// the fields of joined errors
+	joined := Join(WithFields(os.ErrClosed, "file", "b.log"), WithFields(os.ErrExist, "op", "create"))
+	require.Equal(t, map[string]any{"file": "b.log", "op": "create"}, Fields(Wrap(joined, "outer")))
+}
+
+func TestFieldsFormat(t *testing.T) {
+	err := WithFields(New("failed to remove"), "op", "remove", "file", "a.log")
+	require.Equal(t, "failed to remove", fmt.Sprintf("%s", err))
+	require.Equal(t, `"failed to remove"`, fmt.Sprintf("%q", err))
+	lines := strings.Split(fmt.Sprintf("%+v", err), "\n")
+	require.Equal(t, "failed to remove file=a.log op=remove", lines[0])
+	require.Equal(t, "github.com/stkali/utility/errors.TestFieldsFormat", lines[1])
+
+	// fields of wrapped errors are printed by the outer error
+	err = Wrap(err, "failed to tidy")
+	lines = strings.Split(fmt.Sprintf("%+v", err), "\n")
+	require.Equal(t, "failed to tidy, err: failed to remove file=a.log op=remove", lines[0])
+}
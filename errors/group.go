@@ -0,0 +1,112 @@
+package errors
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// defaultGroupLimit is the number of messages a Group keeps by default.
+const defaultGroupLimit = 5
+
+// Group collects the warnings of a background task, e.g. a cleanup run, and writes them
+// as one summarized warning on Flush instead of one warning each. It's safe for
+// concurrent use.
+type Group struct {
+	label string
+	mtx   sync.Mutex
+	limit int
+	msgs  []string
+	count int
+}
+
+// NewGroup returns an empty Group whose summary starts with label.
+func NewGroup(label string) *Group {
+	return &Group{label: label, limit: defaultGroupLimit}
+}
+
+// SetLimit sets the number of representative messages written by Flush, the others
+// are only counted. The default is 5.
+func (g *Group) SetLimit(n int) {
+	if n < 0 {
+		n = 0
+	}
+	g.mtx.Lock()
+	defer g.mtx.Unlock()
+	g.limit = n
+}
+
+// Add adds the warning err to the group, a nil err is ignored.
+func (g *Group) Add(err error) {
+	if err == nil {
+		return
+	}
+	g.add(err.Error())
+}
+
+// Warnf adds a formatted warning to the group.
+func (g *Group) Warnf(format string, a ...any) {
+	g.add(fmt.Sprintf(format, a...))
+}
+
+func (g *Group) add(msg string) {
+	g.mtx.Lock()
+	defer g.mtx.Unlock()
+	g.count++
+	if len(g.msgs) < g.limit {
+		g.msgs = append(g.msgs, msg)
+	}
+}
+
+// Len returns the number of warnings added since the last Flush.
+func (g *Group) Len() int {
+	g.mtx.Lock()
+	defer g.mtx.Unlock()
+	return g.count
+}
+
+// Flush writes the warnings added since the last Flush as one warning and empties the
+// group, it does nothing if the group is empty. The warning lists the label, the count
+// and the first messages, e.g.
+//
+//	rotate: cleanup of app.log: 7 warnings
+//		failed to remove backup file, err: ...
+//		...
+//		+2 more
+//
+// A warning handler receives it with the fields "label" and "count", see Fields.
+func (g *Group) Flush() {
+	g.FlushCtx(context.Background())
+}
+
+// FlushCtx is like Flush, but writes the warning with WarningCtx, so that it's collected
+// by the Scope carried by ctx.
+func (g *Group) FlushCtx(ctx context.Context) {
+	g.mtx.Lock()
+	msgs, count := g.msgs, g.count
+	g.msgs, g.count = nil, 0
+	g.mtx.Unlock()
+	if count == 0 {
+		return
+	}
+
+	var b strings.Builder
+	b.WriteString(g.label)
+	b.WriteString(": ")
+	b.WriteString(strconv.Itoa(count))
+	if count == 1 {
+		b.WriteString(" warning")
+	} else {
+		b.WriteString(" warnings")
+	}
+	for _, msg := range msgs {
+		b.WriteString("\n\t")
+		b.WriteString(msg)
+	}
+	if more := count - len(msgs); more > 0 {
+		_, _ = fmt.Fprintf(&b, "\n\t+%d more", more)
+	}
+	WarningCtx(ctx, WithFields(Error(b.String()), "label", g.label, "count", count))
+}
@@ -0,0 +1,102 @@
+package errors
+
+import (
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGroup(t *testing.T) {
+	cases := []struct {
+		name  string
+		limit int
+		add   func(g *Group)
+		msgs  []string
+	}{
+		{
+			"empty",
+			defaultGroupLimit,
+			func(g *Group) { g.Add(nil) },
+			nil,
+		},
+		{
+			"single",
+			defaultGroupLimit,
+			func(g *Group) { g.Add(os.ErrNotExist) },
+			[]string{"cleanup: 1 warning\n\tfile does not exist"},
+		},
+		{
+			"several",
+			defaultGroupLimit,
+			func(g *Group) {
+				g.Warnf("failed to remove %q", "a.log")
+				g.Add(os.ErrClosed)
+			},
+			[]string{"cleanup: 2 warnings\n\tfailed to remove \"a.log\"\n\tfile already closed"},
+		},
+		{
+			"more than limit",
+			2,
+			func(g *Group) {
+				for i := 0; i < 5; i++ {
+					g.Warnf("warning %d", i)
+				}
+			},
+			[]string{"cleanup: 5 warnings\n\twarning 0\n\twarning 1\n\t+3 more"},
+		},
+		{
+			"no message",
+			0,
+			func(g *Group) {
+				g.Warnf("warning")
+				g.Warnf("warning")
+			},
+			[]string{"cleanup: 2 warnings\n\t+2 more"},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			g := NewGroup("cleanup")
+			g.SetLimit(c.limit)
+			c.add(g)
+			require.Equal(t, c.msgs, CaptureWarnings(g.Flush))
+			// the group is empty after Flush
+			require.Equal(t, 0, g.Len())
+			require.Empty(t, CaptureWarnings(g.Flush))
+		})
+	}
+}
+
+func TestGroupFields(t *testing.T) {
+	var warnings []error
+	SetWarningHandler(func(level WarnLevel, err error) { warnings = append(warnings, err) })
+	defer SetWarningHandler(nil)
+
+	g := NewGroup("cleanup")
+	g.Warnf("failed")
+	g.Flush()
+	require.Len(t, warnings, 1)
+	require.Equal(t, map[string]any{"label": "cleanup", "count": 1}, Fields(warnings[0]))
+}
+
+func TestGroupConcurrent(t *testing.T) {
+	g := NewGroup("cleanup")
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				g.Warnf("warning %d-%d", i, j)
+			}
+		}(i)
+	}
+	wg.Wait()
+	require.Equal(t, 800, g.Len())
+	msgs := CaptureWarnings(g.Flush)
+	require.Len(t, msgs, 1)
+	require.Contains(t, msgs[0], "cleanup: 800 warnings\n")
+	require.Contains(t, msgs[0], "\n\t+795 more")
+}
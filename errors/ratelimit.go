@@ -0,0 +1,230 @@
+package errors
+
+import (
+	"container/list"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// maxRecentWarnings bounds the number of messages remembered by the rate limit.
+const maxRecentWarnings = 256
+
+// warningNow returns the current time, it is replaced in tests.
+var warningNow = time.Now
+
+// warningAfterFunc schedules the summaries of the expired windows, it is replaced in tests.
+var warningAfterFunc = time.AfterFunc
+
+// limiter is the rate limit of the warnings, see SetWarningRateLimit.
+var limiter = &warningLimiter{}
+
+// warningKey identifies the identical warnings, a notice and a warning with the same
+// message are different.
+type warningKey struct {
+	level WarnLevel
+	msg   string
+}
+
+// warningSummary is the message reporting the suppressed occurrences of a warning,
+// delivered at its level.
+type warningSummary struct {
+	level WarnLevel
+	msg   string
+}
+
+// deliverSummaries delivers the summaries of the rate limit.
+func deliverSummaries(summaries []warningSummary) {
+	for _, summary := range summaries {
+		deliver(summary.level, summary.msg, nil)
+	}
+}
+
+// recentWarning is a message seen by the rate limit.
+type recentWarning struct {
+	key warningKey
+	// sentinel is the innermost error of the warning, the warnings matching it with Is
+	// are identical whatever their messages.
+	sentinel error
+	// start is when the message was last delivered, it starts the window.
+	start time.Time
+	// suppressed is the number of times the message was suppressed since start.
+	suppressed int
+	// last is the message last suppressed.
+	last string
+}
+
+// warningLimiter suppresses the identical warnings within a window, it remembers the
+// most recent messages in a LRU list.
+type warningLimiter struct {
+	mtx    sync.Mutex
+	window time.Duration
+	// recent is the list of *recentWarning, the front is the most recently seen.
+	recent *list.List
+	index  map[warningKey]*list.Element
+	// last is the last delivered message.
+	last warningKey
+	// timer delivers the summaries of the expired windows, nil if none is pending.
+	timer *time.Timer
+}
+
+// summary returns the message reporting the suppressed occurrences of w.
+func (w *recentWarning) summary() warningSummary {
+	return warningSummary{
+		level: w.key.level,
+		msg:   fmt.Sprintf("last message repeated %d times: %s", w.suppressed, w.last),
+	}
+}
+
+// flush appends the summary of w to summaries if w has suppressed occurrences.
+func (w *recentWarning) flush(summaries []warningSummary) []warningSummary {
+	if w.suppressed > 0 {
+		summaries = append(summaries, w.summary())
+		w.suppressed = 0
+	}
+	return summaries
+}
+
+// sentinel returns the innermost error of the chain of err, nil if err is nil.
+func sentinel(err error) error {
+	for err != nil {
+		next := Unwrap(err)
+		if next == nil {
+			return err
+		}
+		err = next
+	}
+	return nil
+}
+
+// lookup returns the warning identical to msg of level, or to err by Is-identity.
+func (l *warningLimiter) lookup(level WarnLevel, msg string, err error) *list.Element {
+	if elem := l.index[warningKey{level: level, msg: msg}]; elem != nil || err == nil {
+		return elem
+	}
+	for elem := l.recent.Front(); elem != nil; elem = elem.Next() {
+		w := elem.Value.(*recentWarning)
+		if w.key.level == level && w.sentinel != nil && Is(err, w.sentinel) {
+			return elem
+		}
+	}
+	return nil
+}
+
+// allow reports whether msg of level is delivered, and returns the summaries to deliver
+// first. err is the warning, if any, matched with Is against the earlier ones.
+func (l *warningLimiter) allow(level WarnLevel, msg string, err error) (summaries []warningSummary, ok bool) {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	if l.window <= 0 {
+		return nil, true
+	}
+	now := warningNow()
+	key := warningKey{level: level, msg: msg}
+	elem := l.lookup(level, msg, err)
+	if elem != nil {
+		l.recent.MoveToFront(elem)
+		w := elem.Value.(*recentWarning)
+		key = w.key
+		if now.Sub(w.start) < l.window {
+			w.suppressed++
+			w.last = msg
+			if l.timer == nil {
+				l.timer = warningAfterFunc(w.start.Add(l.window).Sub(now), l.expire)
+			}
+			return nil, false
+		}
+		summaries = w.flush(summaries)
+		w.start = now
+	}
+	// a different message ends the repetitions of the last one
+	if l.last != key {
+		if last := l.index[l.last]; last != nil {
+			summaries = last.Value.(*recentWarning).flush(summaries)
+		}
+		l.last = key
+	}
+	if elem == nil {
+		l.index[key] = l.recent.PushFront(&recentWarning{key: key, sentinel: sentinel(err), start: now})
+		if l.recent.Len() > maxRecentWarnings {
+			oldest := l.recent.Remove(l.recent.Back()).(*recentWarning)
+			delete(l.index, oldest.key)
+			summaries = oldest.flush(summaries)
+		}
+	}
+	return summaries, true
+}
+
+// expire delivers the summaries of the messages whose window expired, and schedules
+// itself again for the earliest window still running with suppressed occurrences.
+func (l *warningLimiter) expire() {
+	l.mtx.Lock()
+	l.timer = nil
+	if l.recent == nil {
+		l.mtx.Unlock()
+		return
+	}
+	now := warningNow()
+	var (
+		summaries []warningSummary
+		next      time.Duration
+	)
+	for elem := l.recent.Back(); elem != nil; elem = elem.Prev() {
+		w := elem.Value.(*recentWarning)
+		if w.suppressed == 0 {
+			continue
+		}
+		if remain := w.start.Add(l.window).Sub(now); remain > 0 {
+			if next == 0 || remain < next {
+				next = remain
+			}
+			continue
+		}
+		summaries = w.flush(summaries)
+	}
+	if next > 0 {
+		l.timer = warningAfterFunc(next, l.expire)
+	}
+	l.mtx.Unlock()
+	deliverSummaries(summaries)
+}
+
+// flush returns the summaries of all the suppressed messages.
+func (l *warningLimiter) flush() (summaries []warningSummary) {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	if l.recent == nil {
+		return nil
+	}
+	for elem := l.recent.Back(); elem != nil; elem = elem.Prev() {
+		summaries = elem.Value.(*recentWarning).flush(summaries)
+	}
+	return summaries
+}
+
+// SetWarningRateLimit suppresses the warnings identical to one delivered less than
+// perMessage ago: the same message at the same level, or an error matching with Is the
+// innermost error of the earlier warning, e.g. the same *os.PathError errno for other
+// files. The suppressed warnings are counted and reported by a "last message repeated N
+// times" warning when the window expires, a different warning is delivered, or
+// FlushWarnings is called. The most recent 256 messages are remembered.
+// perMessage <= 0 disables the rate limit, which is the default.
+func SetWarningRateLimit(perMessage time.Duration) {
+	deliverSummaries(limiter.flush())
+	limiter.mtx.Lock()
+	defer limiter.mtx.Unlock()
+	if limiter.timer != nil {
+		limiter.timer.Stop()
+		limiter.timer = nil
+	}
+	limiter.window = perMessage
+	limiter.recent = list.New()
+	limiter.index = make(map[warningKey]*list.Element)
+	limiter.last = warningKey{}
+}
+
+// FlushWarnings delivers the counts of the warnings suppressed by the rate limit, it is
+// called by Exit, Exitf and CheckErr before exiting.
+func FlushWarnings() {
+	deliverSummaries(limiter.flush())
+}
@@ -0,0 +1,226 @@
+package errors
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// setFakeWarningClock sets the clock of the rate limit to a fake one for the test.
+func setFakeWarningClock(t *testing.T) *time.Time {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	warningNow = func() time.Time { return now }
+	t.Cleanup(func() { warningNow = time.Now })
+	return &now
+}
+
+func setWarningRateLimit(t *testing.T, perMessage time.Duration) *bytes.Buffer {
+	out := &bytes.Buffer{}
+	SetWarningOutput(out)
+	SetWarningPrefix("")
+	SetWarningRateLimit(perMessage)
+	t.Cleanup(func() { SetWarningRateLimit(0) })
+	return out
+}
+
+func TestWarningRateLimit(t *testing.T) {
+	now := setFakeWarningClock(t)
+	out := setWarningRateLimit(t, time.Minute)
+
+	for i := 0; i < 5; i++ {
+		Warningf("failed to remove file %q", "a.log")
+	}
+	require.Equal(t, "warning: failed to remove file \"a.log\"\n", out.String())
+
+	// a different message reports the repetitions of the last one
+	out.Reset()
+	Warning("other")
+	require.Equal(t, "warning: last message repeated 4 times: failed to remove file \"a.log\"\nwarning: other\n", out.String())
+
+	// still within the window of the first message
+	out.Reset()
+	Warningf("failed to remove file %q", "a.log")
+	Warningf("failed to remove file %q", "a.log")
+	require.Empty(t, out.String())
+
+	// the window expired
+	*now = now.Add(time.Minute)
+	Warningf("failed to remove file %q", "a.log")
+	require.Equal(t, "warning: last message repeated 2 times: failed to remove file \"a.log\"\n"+
+		"warning: failed to remove file \"a.log\"\n", out.String())
+
+	out.Reset()
+	Warning("other")
+	require.Equal(t, "warning: other\n", out.String(), "no repetition to report")
+}
+
+func TestWarningRateLimitTagged(t *testing.T) {
+	setFakeWarningClock(t)
+	out := setWarningRateLimit(t, time.Minute)
+	var received []error
+	SetWarningHandler(func(level WarnLevel, err error) {
+		received = append(received, err)
+	})
+	defer SetWarningHandler(nil)
+
+	for i := 0; i < 3; i++ {
+		Warningt("rotate", Error("disk full"))
+	}
+	FlushWarnings()
+	require.Empty(t, out.String())
+	require.Len(t, received, 2)
+	require.EqualError(t, received[0], "rotate: disk full")
+	require.EqualError(t, received[1], "last message repeated 2 times: rotate: disk full")
+}
+
+func TestWarningRateLimitSentinel(t *testing.T) {
+	setFakeWarningClock(t)
+	out := setWarningRateLimit(t, time.Minute)
+
+	// the same sentinel error with other messages
+	for _, name := range []string{"a.log", "b.log", "c.log"} {
+		Warning(&os.PathError{Op: "remove", Path: name, Err: os.ErrPermission})
+	}
+	Warningt("rotate", &os.PathError{Op: "remove", Path: "d.log", Err: os.ErrPermission})
+	// an error of another sentinel
+	Warning(&os.PathError{Op: "remove", Path: "a.log", Err: os.ErrNotExist})
+	require.Equal(t, "warning: remove a.log: permission denied\n"+
+		"warning: last message repeated 3 times: rotate: remove d.log: permission denied\n"+
+		"warning: remove a.log: file does not exist\n", out.String())
+
+	// the fresh errors of New never match each other
+	out.Reset()
+	Warning(New("failed"))
+	Warning(New("other"))
+	require.Equal(t, "warning: failed\nwarning: other\n", out.String())
+}
+
+func TestWarningRateLimitExpiry(t *testing.T) {
+	now := setFakeWarningClock(t)
+	var (
+		scheduled []time.Duration
+		expire    func()
+	)
+	warningAfterFunc = func(d time.Duration, f func()) *time.Timer {
+		scheduled = append(scheduled, d)
+		expire = f
+		return time.NewTimer(time.Hour)
+	}
+	defer func() { warningAfterFunc = time.AfterFunc }()
+	out := setWarningRateLimit(t, time.Minute)
+
+	Warning("same")
+	*now = now.Add(5 * time.Second)
+	Warning("other")
+	*now = now.Add(5 * time.Second)
+	Warning("same")
+	*now = now.Add(10 * time.Second)
+	Warning("other")
+	Warning("other")
+	require.Equal(t, []time.Duration{50 * time.Second}, scheduled)
+
+	// the window of "same" expired, the one of "other" is still running
+	out.Reset()
+	*now = now.Add(40 * time.Second)
+	expire()
+	require.Equal(t, "warning: last message repeated 1 times: same\n", out.String())
+	require.Equal(t, []time.Duration{50 * time.Second, 5 * time.Second}, scheduled)
+
+	out.Reset()
+	*now = now.Add(5 * time.Second)
+	expire()
+	require.Equal(t, "warning: last message repeated 2 times: other\n", out.String())
+	require.Len(t, scheduled, 2, "nothing left to report")
+}
+
+func TestWarningRateLimitBounded(t *testing.T) {
+	setFakeWarningClock(t)
+	out := setWarningRateLimit(t, time.Minute)
+	for i := 0; i < maxRecentWarnings*2; i++ {
+		Warningf("warning %d", i)
+	}
+	require.Equal(t, maxRecentWarnings, limiter.recent.Len())
+	require.Len(t, limiter.index, maxRecentWarnings)
+	// the evicted message is delivered again
+	out.Reset()
+	Warningf("warning %d", 0)
+	require.Equal(t, "warning: warning 0\n", out.String())
+}
+
+func TestWarningRateLimitDisabled(t *testing.T) {
+	out := setWarningRateLimit(t, 0)
+	for i := 0; i < 3; i++ {
+		Warning("same")
+	}
+	require.Equal(t, strings.Repeat("warning: same\n", 3), out.String())
+}
+
+func TestFlushWarningsOnExit(t *testing.T) {
+	setFakeWarningClock(t)
+	out := setWarningRateLimit(t, time.Minute)
+	originExit := osExit
+	defer func() { osExit = originExit }()
+	osExit = func(code int) {}
+
+	Warning("same")
+	Warning("same")
+	Exit(3)
+	require.Equal(t, "warning: same\nwarning: last message repeated 1 times: same\n", out.String())
+	out.Reset()
+	FlushWarnings()
+	require.Empty(t, out.String())
+}
+
+func TestWarningRateLimitConcurrent(t *testing.T) {
+	setWarningRateLimit(t, time.Hour)
+	var mtx sync.Mutex
+	total := 0
+	SetWarningHandler(func(level WarnLevel, err error) {
+		mtx.Lock()
+		defer mtx.Unlock()
+		var n int
+		if _, scanErr := fmt.Sscanf(err.Error(), "last message repeated %d times", &n); scanErr == nil {
+			total += n
+		} else {
+			total++
+		}
+	})
+	defer SetWarningHandler(nil)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 500; j++ {
+				Warningf("warning %d", j%(i+1))
+			}
+		}(i)
+	}
+	wg.Wait()
+	FlushWarnings()
+	// every warning is either delivered or counted in a summary
+	require.Equal(t, 8*500, total)
+}
+
+func TestWarningRateLimitLevels(t *testing.T) {
+	setFakeWarningClock(t)
+	out := setWarningRateLimit(t, time.Minute)
+
+	// a notice and a warning with the same message are limited separately
+	for i := 0; i < 3; i++ {
+		Notice("same")
+		Warning("same")
+	}
+	FlushWarnings()
+	require.Equal(t, "notice: same\n"+
+		"warning: same\n"+
+		"notice: last message repeated 2 times: same\n"+
+		"warning: last message repeated 2 times: same\n", out.String())
+}
@@ -0,0 +1,96 @@
+package errors
+
+import "fmt"
+
+// PanicError is the error built by Recover from a recovered panic.
+type PanicError struct {
+	// Value is the value passed to panic.
+	Value any
+	// Tracer is the stack trace of the panicking goroutine.
+	Tracer
+}
+
+// Error returns "panic: <value>".
+func (p *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", p.Value)
+}
+
+// Unwrap returns the panic value if it is an error, so that Is and As see through it.
+func (p *PanicError) Unwrap() error {
+	if err, ok := p.Value.(error); ok {
+		return err
+	}
+	return nil
+}
+
+// Format implements the fmt.Formatter interface like the errors created by New.
+func (p *PanicError) Format(f fmt.State, verb rune) {
+	formatError(f, verb, p, p.Tracer)
+}
+
+// Recover converts a panic into an error stored in *errp, it must be deferred directly:
+//
+//	func load() (err error) {
+//		defer errors.Recover(&err)
+//		...
+//	}
+//
+// The error is a *PanicError holding the panic value and the stack trace of the panic,
+// it wraps the value if that is an error. An error already stored in *errp is joined
+// with it. Nothing happens if the function doesn't panic, and with a nil errp the panic
+// is reported as a warning.
+//
+// panic(nil) is converted only when the runtime reports it as a *runtime.PanicNilError
+// (Go 1.21 and later with a go.mod of go 1.21 or later), otherwise recover can't tell it
+// apart from no panic and *errp is left unchanged. Safely converts it in any case.
+func Recover(errp *error) {
+	if r := recover(); r != nil {
+		setPanic(errp, r)
+	}
+}
+
+// Safely calls fn and returns its error, a panic of fn is returned as a *PanicError,
+// see Recover. A panic(nil) that recover doesn't report is detected because fn didn't
+// return, and is returned as a *PanicError with a nil Value.
+func Safely(fn func() error) (err error) {
+	returned := false
+	defer func() {
+		// runtime.Goexit also skips the return, but then err is never seen by the caller
+		if r := recover(); r != nil || !returned {
+			setPanic(&err, r)
+		}
+	}()
+	err = fn()
+	returned = true
+	return err
+}
+
+// PanicErrorOf returns the *PanicError of the value r recovered by a deferred function
+// handling the panic itself instead of deferring Recover, e.g. to log it. skip is the
+// number of calls between the deferred function and PanicErrorOf, 0 if it calls
+// PanicErrorOf directly, so that the stack trace starts where the panic happened.
+func PanicErrorOf(r any, skip int) *PanicError {
+	return newPanicError(r, skip+1)
+}
+
+// newPanicError returns the *PanicError of the recovered value r, skip is the number of
+// calls between the deferred function that recovered r and newPanicError.
+func newPanicError(r any, skip int) *PanicError {
+	// Callers, GetTrace, newPanicError, the skipped calls and the deferred function
+	return &PanicError{Value: r, Tracer: GetTrace(skip + 4)}
+}
+
+// setPanic stores the *PanicError of the recovered value r in *errp, it must be called
+// by the deferred function that recovered r.
+func setPanic(errp *error, r any) {
+	err := newPanicError(r, 1)
+	if errp == nil {
+		Warning(err)
+		return
+	}
+	if *errp != nil {
+		*errp = Join(*errp, err)
+		return
+	}
+	*errp = err
+}
@@ -0,0 +1,114 @@
+package errors
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type panicValue struct {
+	name string
+	code int
+}
+
+func TestSafely(t *testing.T) {
+	_, statErr := os.Stat("not-existed-file")
+	cases := []struct {
+		name  string
+		value any
+		msg   string
+		is    error
+	}{
+		{"error", statErr, "panic: " + statErr.Error(), os.ErrNotExist},
+		{"string", "boom", "panic: boom", nil},
+		{"struct", panicValue{"disk", 2}, "panic: {disk 2}", nil},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			err := Safely(func() error {
+				panic(c.value)
+			})
+			require.EqualError(t, err, c.msg)
+			var p *PanicError
+			require.True(t, As(err, &p))
+			require.Equal(t, c.value, p.Value)
+			if c.is != nil {
+				require.ErrorIs(t, err, c.is)
+			} else {
+				require.Nil(t, Unwrap(err))
+			}
+			// the stack trace points to the panicking function
+			frames := StackTrace(err)
+			require.NotEmpty(t, frames)
+			require.True(t, strings.HasPrefix(frames[0].Function, "github.com/stkali/utility/errors.TestSafely"), frames[0].Function)
+			require.Contains(t, fmt.Sprintf("%+v", err), "recover_test.go")
+		})
+	}
+}
+
+func TestSafelyPanicNil(t *testing.T) {
+	// the value is a *runtime.PanicNilError if the runtime reports panic(nil) as one,
+	// otherwise Safely synthesizes the error with a nil value
+	err := Safely(func() error {
+		panic(nil)
+	})
+	var p *PanicError
+	require.ErrorAs(t, err, &p)
+	if p.Value != nil {
+		// a *runtime.PanicNilError, named only since Go 1.21
+		var runtimeErr runtime.Error
+		require.ErrorAs(t, err, &runtimeErr)
+		require.Contains(t, runtimeErr.Error(), "nil")
+	} else {
+		require.EqualError(t, err, "panic: <nil>")
+	}
+	require.NotEmpty(t, StackTrace(err))
+}
+
+func TestSafelyNoPanic(t *testing.T) {
+	require.NoError(t, Safely(func() error { return nil }))
+	err := Error("failed")
+	require.Equal(t, err, Safely(func() error { return err }))
+}
+
+func TestRecover(t *testing.T) {
+	cause := Error("failed to close")
+	fn := func() (err error) {
+		defer Recover(&err)
+		defer func() {
+			err = cause
+		}()
+		panic("boom")
+	}
+	err := fn()
+	require.ErrorIs(t, err, cause)
+	var p *PanicError
+	require.ErrorAs(t, err, &p)
+	require.Equal(t, "boom", p.Value)
+}
+
+func TestPanicErrorOf(t *testing.T) {
+	var err *PanicError
+	logPanic := func(r any) {
+		err = PanicErrorOf(r, 1)
+	}
+	func() {
+		defer func() {
+			if r := recover(); r != nil {
+				logPanic(r)
+			}
+		}()
+		panic("boom")
+	}()
+	require.EqualError(t, err, "panic: boom")
+	// the stack trace starts where the panic happened
+	frames := StackTrace(err)
+	require.NotEmpty(t, frames)
+	require.True(t, strings.HasPrefix(frames[0].Function, "github.com/stkali/utility/errors.TestPanicErrorOf"), frames[0].Function)
+	// and not in logPanic
+	require.NotContains(t, frames[0].Function, "func1")
+}
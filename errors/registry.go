@@ -0,0 +1,95 @@
+package errors
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// RegisteredError is a sentinel error registered with its code by Register.
+type RegisteredError struct {
+	Code string
+	Err  error
+}
+
+var (
+	registryMtx sync.RWMutex
+	// registered keeps the registered errors in registration order, CodeString matches
+	// them in that order.
+	registered []RegisteredError
+	// registeredCodes indexes registered by code.
+	registeredCodes = map[string]error{}
+)
+
+// Register registers the sentinel error err with the stable machine-readable code, e.g.
+// "rotate.bad_perm", it is meant to be called at package init:
+//
+//	func init() {
+//		errors.Register("rotate.bad_perm", ModePermissionError)
+//	}
+//
+// It panics if code is empty, err is nil or code is already registered.
+func Register(code string, err error) {
+	if code == "" || err == nil {
+		panic(fmt.Sprintf("errors: invalid registration of code %q with error %v", code, err))
+	}
+	registryMtx.Lock()
+	defer registryMtx.Unlock()
+	if prev, ok := registeredCodes[code]; ok {
+		panic(fmt.Sprintf("errors: code %q is already registered with error %q", code, prev))
+	}
+	registeredCodes[code] = err
+	registered = append(registered, RegisteredError{Code: code, Err: err})
+}
+
+// unregister removes the error registered with code, e.g. to clean up after a test.
+func unregister(code string) {
+	registryMtx.Lock()
+	defer registryMtx.Unlock()
+	if _, ok := registeredCodes[code]; !ok {
+		return
+	}
+	delete(registeredCodes, code)
+	for i := range registered {
+		if registered[i].Code == code {
+			registered = append(registered[:i], registered[i+1:]...)
+			break
+		}
+	}
+}
+
+// Lookup returns the error registered with code, or nil if there is none.
+func Lookup(code string) error {
+	registryMtx.RLock()
+	defer registryMtx.RUnlock()
+	return registeredCodes[code]
+}
+
+// CodeString returns the code of the first registered error that err matches with Is,
+// or an empty string if there is none.
+func CodeString(err error) string {
+	if err == nil {
+		return ""
+	}
+	registryMtx.RLock()
+	defer registryMtx.RUnlock()
+	for _, r := range registered {
+		if Is(err, r.Err) {
+			return r.Code
+		}
+	}
+	return ""
+}
+
+// AllRegistered returns the registered errors sorted by code, e.g. to generate a table
+// of the error codes for documentation.
+func AllRegistered() []RegisteredError {
+	registryMtx.RLock()
+	all := make([]RegisteredError, len(registered))
+	copy(all, registered)
+	registryMtx.RUnlock()
+	sort.Slice(all, func(i, j int) bool {
+		return all[i].Code < all[j].Code
+	})
+	return all
+}
@@ -0,0 +1,95 @@
+package errors
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	testRegisteredError = Error("test registered error")
+	testOtherError      = WithCode(Error("test other error"), InvalidInput)
+)
+
+func init() {
+	Register("errors.test_registered", testRegisteredError)
+	Register("errors.test_other", testOtherError)
+}
+
+func TestRegister(t *testing.T) {
+	require.Equal(t, testRegisteredError, Lookup("errors.test_registered"))
+	require.Nil(t, Lookup("errors.not_registered"))
+
+	cases := []struct {
+		name string
+		err  error
+		code string
+	}{
+		{"nil", nil, ""},
+		{"sentinel", testRegisteredError, "errors.test_registered"},
+		{"wrapped", Wrap(testOtherError, "failed to rotate"), "errors.test_other"},
+		{"fmt wrapped", fmt.Errorf("rotate: %w", testRegisteredError), "errors.test_registered"},
+		{"not registered", os.ErrNotExist, ""},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			require.Equal(t, c.code, CodeString(c.err))
+		})
+	}
+}
+
+func TestRegisterPanics(t *testing.T) {
+	require.PanicsWithValue(t,
+		`errors: code "errors.test_registered" is already registered with error "test registered error"`,
+		func() { Register("errors.test_registered", os.ErrNotExist) })
+	require.Panics(t, func() { Register("", os.ErrNotExist) })
+	require.Panics(t, func() { Register("errors.test_nil", nil) })
+	require.Nil(t, Lookup("errors.test_nil"))
+}
+
+func TestAllRegistered(t *testing.T) {
+	all := AllRegistered()
+	for i := 1; i < len(all); i++ {
+		require.Less(t, all[i-1].Code, all[i].Code)
+	}
+	require.Contains(t, all, RegisteredError{Code: "errors.test_other", Err: testOtherError})
+}
+
+func TestLookupConcurrent(t *testing.T) {
+	t.Cleanup(func() {
+		for i := 0; i < 8; i++ {
+			unregister(fmt.Sprintf("errors.test_concurrent_%d", i))
+		}
+	})
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				require.Equal(t, testRegisteredError, Lookup("errors.test_registered"))
+				require.Equal(t, "errors.test_other", CodeString(testOtherError))
+				if j == 0 {
+					Register(fmt.Sprintf("errors.test_concurrent_%d", i), Error("concurrent"))
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+	require.NotNil(t, Lookup("errors.test_concurrent_7"))
+}
+
+func TestUnregister(t *testing.T) {
+	err := Error("unregistered")
+	Register("errors.test_unregister", err)
+	require.Equal(t, "errors.test_unregister", CodeString(err))
+	unregister("errors.test_unregister")
+	require.Nil(t, Lookup("errors.test_unregister"))
+	require.Empty(t, CodeString(err))
+	require.NotContains(t, AllRegistered(), RegisteredError{Code: "errors.test_unregister", Err: err})
+	// unknown codes are ignored
+	unregister("errors.test_unregister")
+}
@@ -0,0 +1,75 @@
+package errors
+
+import (
+	"context"
+	"sync"
+)
+
+// scopeKey is the context key of the scope carried by the context of Scope.
+type scopeKey struct{}
+
+// warningScope collects the warnings of a Scope.
+type warningScope struct {
+	mtx      sync.Mutex
+	warnings []error
+	// closed is set when Scope returns, the later warnings go to the warning output.
+	closed bool
+}
+
+// add collects err and reports whether the scope is still open.
+func (s *warningScope) add(err error) bool {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	if s.closed {
+		return false
+	}
+	s.warnings = append(s.warnings, err)
+	return true
+}
+
+// close closes the scope and returns the collected warnings.
+func (s *warningScope) close() []error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	s.closed = true
+	return s.warnings
+}
+
+// Scope runs fn with a copy of ctx carrying a new scope and returns its error with the
+// warnings passed meanwhile to WarningCtx, NoticeCtx or Group.FlushCtx with that context,
+// by any goroutine, instead of delivering them to the warning output or the warning
+// handler. The notices are collected too, unless they are dropped by SetMinWarnLevel.
+// The warnings written without the context, or after Scope returns, e.g. by a goroutine
+// started by fn, are delivered as usual. Scope calls can be nested, a warning is
+// collected by the innermost scope of its context.
+//
+//	err, warnings := errors.Scope(ctx, f.CleanBackups)
+func Scope(ctx context.Context, fn func(ctx context.Context) error) (err error, warnings []error) {
+	s := &warningScope{}
+	defer func() {
+		warnings = s.close()
+	}()
+	return fn(context.WithValue(ctx, scopeKey{}, s)), nil
+}
+
+// WarningCtx writes the warning err like Warning, but it's collected by the Scope carried
+// by ctx. A nil err is ignored.
+func WarningCtx(ctx context.Context, err error) {
+	warnCtx(ctx, WarningLevel, err)
+}
+
+// NoticeCtx is like WarningCtx, but writes a message of NoticeLevel.
+func NoticeCtx(ctx context.Context, err error) {
+	warnCtx(ctx, NoticeLevel, err)
+}
+
+// warnCtx implements WarningCtx and NoticeCtx.
+func warnCtx(ctx context.Context, level WarnLevel, err error) {
+	if !warnEnabled(level) || err == nil {
+		return
+	}
+	if s, _ := ctx.Value(scopeKey{}).(*warningScope); s != nil && s.add(err) {
+		return
+	}
+	warn(level, []any{err})
+}
@@ -0,0 +1,121 @@
+package errors
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestScope(t *testing.T) {
+	var out bytes.Buffer
+	SetWarningOutput(&out)
+	defer SetWarningOutput(os.Stderr)
+
+	failure := Error("failure")
+	err, warnings := Scope(context.Background(), func(ctx context.Context) error {
+		WarningCtx(ctx, Error("first"))
+		WarningCtx(ctx, &TaggedError{Tag: "rotate", Err: os.ErrNotExist})
+		NoticeCtx(ctx, Error("notice"))
+		NoticeCtx(ctx, nil)
+		g := NewGroup("cleanup")
+		g.Warnf("failed")
+		g.FlushCtx(ctx)
+		// the warnings without the context aren't in the scope
+		Warning("escaped")
+		return failure
+	})
+	require.Equal(t, failure, err)
+	require.Len(t, warnings, 4)
+	require.EqualError(t, warnings[0], "first")
+	require.ErrorIs(t, warnings[1], os.ErrNotExist)
+	require.EqualError(t, warnings[2], "notice")
+	require.Equal(t, map[string]any{"label": "cleanup", "count": 1}, Fields(warnings[3]))
+	require.Equal(t, "warning: escaped\n", out.String())
+
+	err, warnings = Scope(context.Background(), func(ctx context.Context) error { return nil })
+	require.NoError(t, err)
+	require.Empty(t, warnings)
+
+	// without a scope the context doesn't change the warnings
+	out.Reset()
+	NoticeCtx(context.Background(), Error("plain"))
+	require.Equal(t, "notice: plain\n", out.String())
+}
+
+func TestScopeNested(t *testing.T) {
+	_, outer := Scope(context.Background(), func(ctx context.Context) error {
+		WarningCtx(ctx, Error("outer"))
+		_, inner := Scope(ctx, func(ctx context.Context) error {
+			WarningCtx(ctx, Error("inner"))
+			return nil
+		})
+		require.Len(t, inner, 1)
+		require.EqualError(t, inner[0], "inner")
+		WarningCtx(ctx, Error("outer again"))
+		return nil
+	})
+	require.Len(t, outer, 2)
+	require.EqualError(t, outer[0], "outer")
+	require.EqualError(t, outer[1], "outer again")
+}
+
+func TestScopeGoroutines(t *testing.T) {
+	var out bytes.Buffer
+	SetWarningOutput(&out)
+	defer SetWarningOutput(os.Stderr)
+
+	var escaped context.Context
+	_, warnings := Scope(context.Background(), func(ctx context.Context) error {
+		escaped = ctx
+		var wg sync.WaitGroup
+		for i := 0; i < 4; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				WarningCtx(ctx, Error("with context"))
+				g := NewGroup("cleanup")
+				g.Warnf("failed")
+				g.FlushCtx(ctx)
+			}()
+		}
+		wg.Wait()
+		return nil
+	})
+	require.Len(t, warnings, 8)
+	require.Empty(t, out.String())
+
+	// the scope is closed when Scope returns
+	WarningCtx(escaped, Error("late"))
+	require.Equal(t, "warning: late\n", out.String())
+}
+
+func TestScopeMinLevel(t *testing.T) {
+	SetMinWarnLevel(WarningLevel)
+	defer SetMinWarnLevel(NoticeLevel)
+	_, warnings := Scope(context.Background(), func(ctx context.Context) error {
+		NoticeCtx(ctx, Error("dropped"))
+		WarningCtx(ctx, Error("kept"))
+		return nil
+	})
+	require.Len(t, warnings, 1)
+	require.EqualError(t, warnings[0], "kept")
+}
+
+func TestScopePanic(t *testing.T) {
+	var escaped context.Context
+	require.PanicsWithValue(t, "boom", func() {
+		_, _ = Scope(context.Background(), func(ctx context.Context) error {
+			escaped = ctx
+			panic("boom")
+		})
+	})
+	var out bytes.Buffer
+	SetWarningOutput(&out)
+	defer SetWarningOutput(os.Stderr)
+	WarningCtx(escaped, Error("after panic"))
+	require.Equal(t, "warning: after panic\n", out.String())
+}
@@ -0,0 +1,90 @@
+package errors
+
+// temporary is implemented by the errors telling whether they are worth retrying, e.g.
+// the markers of MarkTemporary, net.Error and syscall.Errno.
+type temporary interface {
+	Temporary() bool
+}
+
+// timeout is implemented by the errors telling whether they are a timeout, e.g.
+// net.Error and context.DeadlineExceeded.
+type timeout interface {
+	Timeout() bool
+}
+
+// permanent is implemented by the errors telling whether they must not be retried, e.g.
+// the markers of MarkPermanent and lib.Permanent.
+type permanent interface {
+	Permanent() bool
+}
+
+// retryMarker marks an error as temporary or permanent.
+type retryMarker struct {
+	err       error
+	temporary bool
+}
+
+// Error returns the message of the marked error.
+func (m *retryMarker) Error() string {
+	return m.err.Error()
+}
+
+// Unwrap returns the marked error.
+func (m *retryMarker) Unwrap() error {
+	return m.err
+}
+
+// Temporary reports whether the error is marked temporary.
+func (m *retryMarker) Temporary() bool {
+	return m.temporary
+}
+
+// Permanent reports whether the error is marked permanent.
+func (m *retryMarker) Permanent() bool {
+	return !m.temporary
+}
+
+// MarkTemporary marks err as worth retrying, see IsTemporary. It returns nil if err is nil.
+func MarkTemporary(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &retryMarker{err: err, temporary: true}
+}
+
+// MarkPermanent marks err as not worth retrying, see IsPermanent, it also stops
+// lib.Retry. It returns nil if err is nil.
+func MarkPermanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &retryMarker{err: err, temporary: false}
+}
+
+// IsTemporary reports whether err is worth retrying: the first error of the chain having
+// a Temporary method decides, which includes the markers of MarkTemporary and
+// MarkPermanent, net.Error and syscall.Errno (e.g. EAGAIN and EINTR); a net.Error also
+// counts as temporary if it is a timeout. Otherwise err is temporary if it is a timeout,
+// e.g. context.DeadlineExceeded.
+func IsTemporary(err error) bool {
+	if err == nil {
+		return false
+	}
+	var t temporary
+	if As(err, &t) {
+		if to, ok := t.(timeout); ok && to.Timeout() {
+			return true
+		}
+		return t.Temporary()
+	}
+	var to timeout
+	return As(err, &to) && to.Timeout()
+}
+
+// IsPermanent reports whether err is marked permanent by MarkPermanent or lib.Permanent,
+// the first marker of the chain decides. An error without marker is neither temporary nor
+// permanent for sure, so both IsTemporary and IsPermanent may return false.
+func IsPermanent(err error) bool {
+	var p permanent
+	return err != nil && As(err, &p) && p.Permanent()
+}
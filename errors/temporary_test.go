@@ -0,0 +1,66 @@
+package errors
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// netError is a net.Error for the tests.
+type netError struct {
+	timeout, temporary bool
+}
+
+func (e *netError) Error() string   { return "net error" }
+func (e *netError) Timeout() bool   { return e.timeout }
+func (e *netError) Temporary() bool { return e.temporary }
+
+var _ net.Error = (*netError)(nil)
+
+func TestIsTemporary(t *testing.T) {
+	cases := []struct {
+		name      string
+		err       error
+		temporary bool
+		permanent bool
+	}{
+		{"nil", nil, false, false},
+		{"plain", Error("plain"), false, false},
+		{"not exist", os.ErrNotExist, false, false},
+		{"marked temporary", MarkTemporary(Error("busy")), true, false},
+		{"marked permanent", MarkPermanent(Error("bad input")), false, true},
+		{"deep temporary", Wrap(fmt.Errorf("call: %w", Newf("x, err: %s", MarkTemporary(os.ErrClosed))), "outer"), true, false},
+		{"deep permanent", Wrap(fmt.Errorf("call: %w", Join(Error("x"), MarkPermanent(os.ErrClosed))), "outer"), false, true},
+		{"outer marker wins", MarkPermanent(MarkTemporary(Error("x"))), false, true},
+		{"outer temporary wins", MarkTemporary(MarkPermanent(Error("x"))), true, false},
+		{"permanent over timeout", MarkPermanent(context.DeadlineExceeded), false, true},
+		{"eagain", Wrap(syscall.EAGAIN, "read"), true, false},
+		{"eintr", &os.PathError{Op: "read", Path: "x", Err: syscall.EINTR}, true, false},
+		{"enoent", &os.PathError{Op: "open", Path: "x", Err: syscall.ENOENT}, false, false},
+		{"net timeout", Wrap(&netError{timeout: true}, "dial"), true, false},
+		{"net temporary", &netError{temporary: true}, true, false},
+		{"net error", &netError{}, false, false},
+		{"deadline", fmt.Errorf("call: %w", context.DeadlineExceeded), true, false},
+		{"canceled", context.Canceled, false, false},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			require.Equal(t, c.temporary, IsTemporary(c.err))
+			require.Equal(t, c.permanent, IsPermanent(c.err))
+		})
+	}
+}
+
+func TestMarkTemporary(t *testing.T) {
+	require.Nil(t, MarkTemporary(nil))
+	require.Nil(t, MarkPermanent(nil))
+	err := MarkTemporary(os.ErrClosed)
+	require.True(t, Is(err, os.ErrClosed))
+	require.Equal(t, os.ErrClosed.Error(), err.Error())
+	require.Equal(t, os.ErrClosed, Unwrap(MarkPermanent(os.ErrClosed)))
+}
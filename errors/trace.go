@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"io"
 	"runtime"
+	"strings"
+	"sync/atomic"
 )
 
 // Tracer is an interface that represents a stack trace.
@@ -62,12 +64,66 @@ func defaultFrameHandle(frame runtime.Frame) {
 	_, _ = fmt.Fprintf(errOutput, "         %s:%d\n", frame.File, frame.Line)
 }
 
+// stacksDisabled is set by DisableStacks, it is accessed atomically.
+var stacksDisabled int32
+
+// DisableStacks disables capturing stack traces when creating errors, for hot paths where
+// the cost of runtime.Callers matters. The errors then have an empty stack trace.
+func DisableStacks() {
+	atomic.StoreInt32(&stacksDisabled, 1)
+}
+
+// EnableStacks enables capturing stack traces again after DisableStacks.
+func EnableStacks() {
+	atomic.StoreInt32(&stacksDisabled, 0)
+}
+
 // GetTrace captures the current goroutine's stack trace, skipping the specified number of frames.
 // It returns a Tracer interface that can be used to print or manipulate the stack trace.
+// Only the program counters are captured, they are symbolized when the trace is printed.
 func GetTrace(skip int) Tracer {
-	pcs := make(trace, depth, depth)
+	if atomic.LoadInt32(&stacksDisabled) == 1 {
+		return trace(nil)
+	}
+	var pcs [depth]uintptr
 	count := runtime.Callers(skip, pcs[:])
-	return pcs[:count]
+	return append(make(trace, 0, count), pcs[:count]...)
+}
+
+// Frame is a symbolized frame of a stack trace.
+type Frame struct {
+	Function string
+	File     string
+	Line     int
+}
+
+// String returns the frame as "function file:line".
+func (f Frame) String() string {
+	return fmt.Sprintf("%s %s:%d", f.Function, f.File, f.Line)
+}
+
+// frames returns the symbolized frames of tc without the frames of the runtime
+// package, e.g. runtime.goexit.
+func frames(tc Tracer) []Frame {
+	var ret []Frame
+	tc.RangeFrames(func(frame runtime.Frame) {
+		if strings.HasPrefix(frame.Function, "runtime.") {
+			return
+		}
+		ret = append(ret, Frame{Function: frame.Function, File: frame.File, Line: frame.Line})
+	})
+	return ret
+}
+
+// StackTrace returns the stack trace captured when err was created by New, Newf, Wrap or
+// WithStack, it looks through the errors wrapped by err, e.g. by fmt.Errorf with %w.
+// It returns nil if err has no stack trace or stacks are disabled.
+func StackTrace(err error) []Frame {
+	tracer := tracerOf(err)
+	if tracer == nil {
+		return nil
+	}
+	return frames(tracer)
 }
 
 // Traceback writes the traceback information of the caller to the specified io.Writer.
@@ -3,8 +3,10 @@ package errors
 import (
 	"bytes"
 	"fmt"
+	"os"
 	"regexp"
 	"runtime"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -76,3 +78,83 @@ func TestGetTraceback(t *testing.T) {
 	traceback := GetTraceback()
 	checkTracebackFormat(t, traceback)
 }
+
+func newTestError() error {
+	return Newf("failed to open %q", "x.log")
+}
+
+func TestStackTraceFrames(t *testing.T) {
+	err := newTestError()
+	frames := StackTrace(err)
+	require.NotEmpty(t, frames)
+	require.Equal(t, "github.com/stkali/utility/errors.newTestError", frames[0].Function)
+	require.Equal(t, "github.com/stkali/utility/errors.TestStackTraceFrames", frames[1].Function)
+	require.Contains(t, frames[0].File, "trace_test.go")
+	for _, frame := range frames {
+		require.NotContains(t, frame.Function, "runtime.")
+	}
+
+	// wrapping keeps the innermost stack trace
+	wrapped := Newf("failed to rotate, err: %s", err)
+	require.Equal(t, frames, StackTrace(wrapped))
+	require.Equal(t, frames, StackTrace(fmt.Errorf("context: %w", wrapped)))
+	require.Equal(t, frames, StackTrace(WithStack(wrapped)))
+
+	require.Nil(t, StackTrace(nil))
+	require.Nil(t, StackTrace(os.ErrNotExist))
+	require.Nil(t, StackTrace(Join(os.ErrNotExist)))
+}
+
+func TestWithStack(t *testing.T) {
+	require.Nil(t, WithStack(nil))
+	err := WithStack(os.ErrNotExist)
+	require.True(t, Is(err, os.ErrNotExist))
+	require.Equal(t, os.ErrNotExist.Error(), err.Error())
+	frames := StackTrace(err)
+	require.NotEmpty(t, frames)
+	require.Equal(t, "github.com/stkali/utility/errors.TestWithStack", frames[0].Function)
+}
+
+func TestFormatPlusV(t *testing.T) {
+	err := newTestError()
+	// %s and %v are unchanged
+	require.Equal(t, `failed to open "x.log"`, fmt.Sprintf("%s", err))
+	require.True(t, regxMatchErrorTrace.MatchString(fmt.Sprintf("%v", err)))
+
+	lines := strings.Split(fmt.Sprintf("%+v", err), "\n")
+	require.Equal(t, `failed to open "x.log"`, lines[0])
+	require.Equal(t, "github.com/stkali/utility/errors.newTestError", lines[1])
+	require.Regexp(t, `^\t.*trace_test\.go:\d+$`, lines[2])
+
+	// joined errors without trace
+	require.Equal(t, "file does not exist", fmt.Sprintf("%+v", Join(os.ErrNotExist)))
+	require.Equal(t, "Error: file does not exist\n", fmt.Sprintf("%v", Join(os.ErrNotExist)))
+}
+
+func TestDisableStacks(t *testing.T) {
+	DisableStacks()
+	defer EnableStacks()
+	err := Newf("no stack")
+	require.Nil(t, StackTrace(err))
+	require.Equal(t, "no stack", fmt.Sprintf("%+v", err))
+	require.Equal(t, "no stack", err.Error())
+
+	EnableStacks()
+	require.NotEmpty(t, StackTrace(New("stack")))
+}
+
+func BenchmarkNewf(b *testing.B) {
+	err := os.ErrNotExist
+	b.Run("stacks", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			_ = Newf("failed, err: %s", err)
+		}
+	})
+	b.Run("no stacks", func(b *testing.B) {
+		DisableStacks()
+		defer EnableStacks()
+		for i := 0; i < b.N; i++ {
+			_ = Newf("failed, err: %s", err)
+		}
+	})
+}
@@ -4,88 +4,296 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 )
 
 var (
-	// disableWarning is a global flag that controls whether warnings are disabled.
-	disableWarning bool
+	// disableWarning is a global flag that controls whether warnings are disabled,
+	// it is accessed atomically.
+	disableWarning int32
 
-	// warningPrefix is the prefix used for warning messages.
-	warningPrefix = "warning"
+	// warningMtx guards warningPrefix, warningOutput and captured, and serializes the
+	// writes to warningOutput, so that they can be swapped while warnings are written.
+	warningMtx sync.Mutex
+
+	// warningPrefix is the prefix used for warning messages, it's written before the level.
+	warningPrefix = ""
 
 	// warningOutput is the io.Writer where warning messages are sent by default.
 	// It is set to os.Stderr initially.
 	warningOutput io.Writer = os.Stderr
+
+	// captured collects the warnings while CaptureWarnings runs, nil otherwise.
+	captured *[]string
+)
+
+// WarnLevel is the level of a warning, see Notice and Warning.
+type WarnLevel int32
+
+const (
+	// NoticeLevel is the level of the informational messages, e.g. the number of files
+	// deleted by a cleanup.
+	NoticeLevel WarnLevel = iota
+	// WarningLevel is the level of the messages needing the attention of an operator,
+	// e.g. a file that can't be deleted.
+	WarningLevel
 )
 
+// String returns the label of the level written before the warning messages.
+func (l WarnLevel) String() string {
+	switch l {
+	case NoticeLevel:
+		return "notice"
+	case WarningLevel:
+		return "warning"
+	default:
+		return "level(" + strconv.Itoa(int(l)) + ")"
+	}
+}
+
+// minWarnLevel is the lowest level delivered, see SetMinWarnLevel, it is accessed atomically.
+var minWarnLevel int32
+
+// SetMinWarnLevel drops the warnings below level, e.g. SetMinWarnLevel(WarningLevel)
+// drops the notices. The default is NoticeLevel which delivers all of them.
+// It's safe to call concurrently with the warning functions.
+func SetMinWarnLevel(level WarnLevel) {
+	atomic.StoreInt32(&minWarnLevel, int32(level))
+}
+
+// warnEnabled reports whether the warnings of level are delivered.
+func warnEnabled(level WarnLevel) bool {
+	return !warningDisabled() && int32(level) >= atomic.LoadInt32(&minWarnLevel)
+}
+
 // DisableWarning disables the global warning mechanism.
 // After calling this function, no warnings will be output.
 func DisableWarning() {
-	disableWarning = true
+	atomic.StoreInt32(&disableWarning, 1)
+}
+
+// warningDisabled reports whether DisableWarning was called.
+func warningDisabled() bool {
+	return atomic.LoadInt32(&disableWarning) == 1
 }
 
 // SetWarningOutput sets the output destination for warning messages.
 // The provided io.Writer will be used to write warning messages.
+// It's safe to call concurrently with the warning functions.
 func SetWarningOutput(output io.Writer) {
+	warningMtx.Lock()
+	defer warningMtx.Unlock()
 	warningOutput = output
 }
 
-// SetWarningPrefix sets the prefix used for warning messages.
-// This prefix will be prepended to all warning messages.
+// SetWarningPrefix sets the prefix used for warning messages, e.g. the name of the
+// application. It's written before the level, "<prefix>: warning: <message>".
 func SetWarningPrefix(prefix string) {
+	warningMtx.Lock()
+	defer warningMtx.Unlock()
 	warningPrefix = prefix
 }
 
 // SetWarningPrefixf is a formatted version of SetWarningPrefix.
 // It allows setting the prefix using a format string and arguments.
 func SetWarningPrefixf(s string, args ...any) {
-	warningPrefix = fmt.Sprintf(s, args...)
+	SetWarningPrefix(fmt.Sprintf(s, args...))
 }
 
-// warn is an internal function that writes a warning message to the specified output.
-// It handles formatting and prefixing the message.
-func warn(format *string, a ...any) {
+// CaptureWarnings runs fn and returns the messages of the warnings delivered meanwhile,
+// without the prefix and the level, instead of writing them to the warning output or the warning
+// handler, which are restored when fn returns. It gives tests a race-free way to assert
+// on warnings, including the ones of goroutines started by fn that warn before it returns.
+func CaptureWarnings(fn func()) []string {
+	var msgs []string
+	warningMtx.Lock()
+	prev := captured
+	captured = &msgs
+	warningMtx.Unlock()
+	defer func() {
+		warningMtx.Lock()
+		captured = prev
+		warningMtx.Unlock()
+	}()
+	fn()
+	warningMtx.Lock()
+	defer warningMtx.Unlock()
+	return append([]string(nil), msgs...)
+}
 
-	if warningPrefix != "" {
-		_, _ = io.WriteString(warningOutput, warningPrefix)
-		_, _ = io.WriteString(warningOutput, ": ")
+// capture appends msg to the captured warnings and reports whether CaptureWarnings runs.
+func capture(msg string) bool {
+	warningMtx.Lock()
+	defer warningMtx.Unlock()
+	if captured == nil {
+		return false
+	}
+	*captured = append(*captured, msg)
+	return true
+}
+
+// warningText formats the arguments of Warning, separated by ", ".
+func warningText(a []any) string {
+	var b strings.Builder
+	for index := range a {
+		if index != 0 {
+			b.WriteString(", ")
+		}
+		if e, ok := a[index].(error); ok {
+			b.WriteString(e.Error())
+		} else {
+			_, _ = fmt.Fprint(&b, a[index])
+		}
 	}
-	if format == nil {
-		var n any
-		for index := range a {
-			if index != 0 {
-				_, _ = fmt.Fprint(warningOutput, n)
-				_, _ = io.WriteString(warningOutput, ", ")
-			}
-			if e, ok := a[index].(error); ok {
-				n = e.Error()
-			} else {
-				n = a[index]
-			}
+	return b.String()
+}
+
+// deliver collects msg if CaptureWarnings runs, otherwise it sends the warning to the
+// warning handler if set, or writes msg to the warning output. err is the warning passed to the handler, Error(msg) if nil.
+func deliver(level WarnLevel, msg string, err error) {
+	if capture(msg) {
+		return
+	}
+	if handler := loadWarningHandler(); handler != nil {
+		if err == nil {
+			err = Error(msg)
 		}
-		_, _ = fmt.Fprint(warningOutput, n)
-	} else {
-		_, _ = fmt.Fprintf(warningOutput, *format, a...)
+		handler(level, err)
+		return
 	}
-	_, _ = warningOutput.Write([]byte{'\n'})
+	writeWarning(level, msg)
+}
+
+// emit delivers a warning unless it is suppressed by the rate limit, see deliver.
+func emit(level WarnLevel, msg string, err error) {
+	summaries, ok := limiter.allow(level, msg, err)
+	deliverSummaries(summaries)
+	if ok {
+		deliver(level, msg, err)
+	}
+}
+
+// writeWarning writes msg to the warning output after the prefix and the level, e.g.
+// "warning: <msg>" or "app: notice: <msg>".
+func writeWarning(level WarnLevel, msg string) {
+	warningMtx.Lock()
+	defer warningMtx.Unlock()
+	msg = level.String() + ": " + msg
+	if warningPrefix != "" {
+		msg = warningPrefix + ": " + msg
+	}
+	_, _ = io.WriteString(warningOutput, msg+"\n")
+}
+
+// TaggedError is a warning tagged with the component it comes from, see Warningt.
+type TaggedError struct {
+	Tag string
+	Err error
+}
+
+// Error returns the message of Err prefixed with Tag.
+func (t *TaggedError) Error() string {
+	return t.Tag + ": " + t.Err.Error()
+}
+
+// Unwrap returns Err.
+func (t *TaggedError) Unwrap() error {
+	return t.Err
+}
+
+// warningHandlerBox wraps the warning handler since an atomic.Value can't store nil.
+type warningHandlerBox struct {
+	handler func(level WarnLevel, err error)
+}
+
+// warningHandler holds a warningHandlerBox, see SetWarningHandler.
+var warningHandler atomic.Value
+
+// SetWarningHandler sets the handler receiving the warnings as error values with their
+// level instead of writing them to the warning output, e.g. to send them to a telemetry
+// system. Warning passes a single error argument as is, Warningf passes the error
+// created by Newf, and Warningt passes a *TaggedError, and so do Notice, Noticef and
+// Noticet. A nil handler restores the warning output.
+// It's safe to call concurrently with the warning functions.
+func SetWarningHandler(handler func(level WarnLevel, err error)) {
+	warningHandler.Store(warningHandlerBox{handler: handler})
+}
+
+// loadWarningHandler returns the warning handler, or nil if not set.
+func loadWarningHandler() func(level WarnLevel, err error) {
+	box, _ := warningHandler.Load().(warningHandlerBox)
+	return box.handler
 }
 
 // Warning writes a warning message to the specified output.
 // It ignores warnings if the warning mechanism is disabled, or if no parameters are provided.
 func Warning(a ...any) {
-	// Check if warnings are disabled or no parameters are provided
-	if disableWarning || a == nil || (len(a) == 1 && a[0] == nil) {
-		return
-	}
-	warn(nil, a...)
+	warn(WarningLevel, a)
 }
 
 // Warningf writes a formatted warning message to the specified output.
 // It accepts a format string and corresponding parameters, and outputs the formatted message as a warning.
 // It does not output the warning if the warning mechanism is disabled.
 func Warningf(format string, a ...any) {
-	if disableWarning {
+	warnf(WarningLevel, format, a)
+}
+
+// Warningt writes the warning err tagged with the component it comes from, e.g.
+// "warning: rotate: failed to remove file". A nil err is ignored.
+func Warningt(tag string, err error) {
+	warnt(WarningLevel, tag, err)
+}
+
+// Notice is like Warning, but writes an informational message of NoticeLevel, e.g.
+// "notice: cleanup removed 3 backups".
+func Notice(a ...any) {
+	warn(NoticeLevel, a)
+}
+
+// Noticef is like Warningf, but writes a message of NoticeLevel.
+func Noticef(format string, a ...any) {
+	warnf(NoticeLevel, format, a)
+}
+
+// Noticet is like Warningt, but writes a message of NoticeLevel.
+func Noticet(tag string, err error) {
+	warnt(NoticeLevel, tag, err)
+}
+
+// warn implements Warning and Notice.
+func warn(level WarnLevel, a []any) {
+	// Check if warnings are disabled or no parameters are provided
+	if !warnEnabled(level) || a == nil || (len(a) == 1 && a[0] == nil) {
+		return
+	}
+	var err error
+	if e, ok := a[0].(error); ok && len(a) == 1 {
+		err = e
+	}
+	emit(level, warningText(a), err)
+}
+
+// warnf implements Warningf and Noticef.
+func warnf(level WarnLevel, format string, a []any) {
+	if !warnEnabled(level) {
+		return
+	}
+	if loadWarningHandler() != nil {
+		err := Newf(format, a...)
+		emit(level, err.Error(), err)
+		return
+	}
+	emit(level, fmt.Sprintf(format, a...), nil)
+}
+
+// warnt implements Warningt and Noticet.
+func warnt(level WarnLevel, tag string, err error) {
+	if !warnEnabled(level) || err == nil {
 		return
 	}
-	warn(&format, a...)
+	tagged := &TaggedError{Tag: tag, Err: err}
+	emit(level, tagged.Error(), tagged)
 }
@@ -3,6 +3,9 @@ package errors
 import (
 	"bytes"
 	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -19,50 +22,50 @@ func TestWarning(t *testing.T) {
 		{
 			"no prefix",
 			[]any{"this is warning"},
-			"this is warning\n",
+			"warning: this is warning\n",
 			"",
 		},
 		{
 			"prefix",
 			[]any{"this is warning"},
-			"prefix: this is warning\n",
+			"prefix: warning: this is warning\n",
 			"prefix",
 		},
 		{
 			"type int",
 			[]any{100},
 			"warning: 100\n",
-			"warning",
+			"",
 		},
 		{
 			"type point",
 			[]any{&struct{}{}},
 			"warning: &{}\n",
-			"warning",
+			"",
 		},
 		{
 			"type 2 point",
 			[]any{&struct{}{}, nil},
 			"warning: &{}, <nil>\n",
-			"warning",
+			"",
 		},
 		{
 			"error with traceback",
 			[]any{New("this is error")},
-			"prefix: this is error\n",
+			"prefix: warning: this is error\n",
 			"prefix",
 		},
 		{
 			"nil(s)",
 			[]any{nil, nil, nil},
 			"warning: <nil>, <nil>, <nil>\n",
-			"warning",
+			"",
 		},
 		{
 			"start nil",
 			[]any{nil, "this is warning"},
 			"warning: <nil>, this is warning\n",
-			"warning",
+			"",
 		},
 	}
 	for _, c := range cases {
@@ -81,7 +84,7 @@ func TestDisableWarning(t *testing.T) {
 	SetWarningOutput(&out)
 	DisableWarning()
 	defer func() {
-		disableWarning = false
+		atomic.StoreInt32(&disableWarning, 0)
 	}()
 	Warning("test warning string")
 	require.Equal(t, out.String(), "")
@@ -117,7 +120,7 @@ func TestWarningf(t *testing.T) {
 			SetWarningPrefix(c.prefix)
 			Warningf(c.format, c.args...)
 			payload := fmt.Sprintf(c.format, c.args...)
-			expect := fmt.Sprintf("%s: %s\n", c.prefix, payload)
+			expect := fmt.Sprintf("%s: warning: %s\n", c.prefix, payload)
 			actual := out.String()
 			require.Equal(t, expect, actual)
 		})
@@ -125,12 +128,209 @@ func TestWarningf(t *testing.T) {
 
 }
 
+func TestSetWarningPrefix(t *testing.T) {
+	var out bytes.Buffer
+	SetWarningOutput(&out)
+	defer SetWarningOutput(os.Stderr)
+	defer SetWarningPrefix("")
+
+	// the level is written after the prefix, see CHANGELOG.md
+	SetWarningPrefix("app")
+	Warning("disk almost full")
+	Notice("started")
+	SetWarningPrefix("")
+	Warning("disk almost full")
+	require.Equal(t, "app: warning: disk almost full\n"+
+		"app: notice: started\n"+
+		"warning: disk almost full\n", out.String())
+}
+
 func TestSetWarningPrefixf(t *testing.T) {
 
 	SetWarningPrefixf("%s warnings", "name")
+	defer SetWarningPrefix("")
 	writer := &bytes.Buffer{}
 	SetWarningOutput(writer)
 	warningMsg := "this is warning message"
 	Warning(warningMsg)
-	require.Equal(t, fmt.Sprintf("name warnings: %s\n", warningMsg), writer.String())
+	require.Equal(t, fmt.Sprintf("name warnings: warning: %s\n", warningMsg), writer.String())
+}
+
+func TestSetWarningHandler(t *testing.T) {
+	var out bytes.Buffer
+	SetWarningOutput(&out)
+	var received []error
+	var levels []WarnLevel
+	SetWarningHandler(func(level WarnLevel, err error) {
+		received = append(received, err)
+		levels = append(levels, level)
+	})
+	defer SetWarningHandler(nil)
+
+	Noticet("rotate", os.ErrExist)
+	Warning(os.ErrNotExist)
+	Warning("text", 1)
+	Warningf("failed to open, err: %s", os.ErrPermission)
+	Warningt("rotate", os.ErrClosed)
+	Warning(nil)
+	Warningt("rotate", nil)
+	require.Empty(t, out.String())
+
+	require.Equal(t, []WarnLevel{NoticeLevel, WarningLevel, WarningLevel, WarningLevel, WarningLevel}, levels)
+	require.ErrorIs(t, received[0], os.ErrExist)
+	received = received[1:]
+	require.Len(t, received, 4)
+	require.Equal(t, os.ErrNotExist, received[0])
+	require.EqualError(t, received[1], "text, 1")
+	require.ErrorIs(t, received[2], os.ErrPermission)
+	require.EqualError(t, received[2], "failed to open, err: permission denied")
+	require.ErrorIs(t, received[3], os.ErrClosed)
+	var tagged *TaggedError
+	require.ErrorAs(t, received[3], &tagged)
+	require.Equal(t, "rotate", tagged.Tag)
+
+	// nil restores the warning output
+	SetWarningHandler(nil)
+	SetWarningPrefix("")
+	Warningt("rotate", os.ErrClosed)
+	require.Equal(t, "warning: rotate: file already closed\n", out.String())
+}
+
+func TestNotice(t *testing.T) {
+	var out bytes.Buffer
+	SetWarningOutput(&out)
+	defer SetWarningOutput(os.Stderr)
+
+	Notice("cleanup removed 3 backups")
+	Noticef("%d files left", 2)
+	Noticet("rotate", Error("cleanup removed 1 backup"))
+	Notice(nil)
+	Noticet("rotate", nil)
+	Warning("disk almost full")
+	require.Equal(t, "notice: cleanup removed 3 backups\n"+
+		"notice: 2 files left\n"+
+		"notice: rotate: cleanup removed 1 backup\n"+
+		"warning: disk almost full\n", out.String())
+
+	out.Reset()
+	SetWarningPrefix("app")
+	defer SetWarningPrefix("")
+	Notice("started")
+	Warning("stopped")
+	require.Equal(t, "app: notice: started\napp: warning: stopped\n", out.String())
+
+	require.Equal(t, "notice", NoticeLevel.String())
+	require.Equal(t, "warning", WarningLevel.String())
+	require.Equal(t, "level(7)", WarnLevel(7).String())
+}
+
+func TestSetMinWarnLevel(t *testing.T) {
+	var out bytes.Buffer
+	SetWarningOutput(&out)
+	defer SetWarningOutput(os.Stderr)
+	SetMinWarnLevel(WarningLevel)
+	defer SetMinWarnLevel(NoticeLevel)
+
+	Notice("dropped")
+	Noticef("dropped %d", 1)
+	Noticet("rotate", Error("dropped"))
+	Warning("kept")
+	require.Equal(t, "warning: kept\n", out.String())
+	// the filtered notices don't reach the capture and the handler either
+	require.Equal(t, []string{"kept"}, CaptureWarnings(func() {
+		Notice("dropped")
+		Warning("kept")
+	}))
+	var levels []WarnLevel
+	SetWarningHandler(func(level WarnLevel, err error) { levels = append(levels, level) })
+	defer SetWarningHandler(nil)
+	Noticef("dropped")
+	Warningf("kept")
+	require.Equal(t, []WarnLevel{WarningLevel}, levels)
+
+	SetMinWarnLevel(NoticeLevel)
+	Noticef("kept")
+	require.Equal(t, []WarnLevel{WarningLevel, NoticeLevel}, levels)
+}
+
+func TestWarningHandlerConcurrent(t *testing.T) {
+	var count int64
+	handler := func(level WarnLevel, err error) {
+		atomic.AddInt64(&count, 1)
+	}
+	SetWarningHandler(handler)
+	defer SetWarningHandler(nil)
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				Warningf("warning %d", j)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				SetWarningHandler(handler)
+			}
+		}()
+	}
+	wg.Wait()
+	require.Equal(t, int64(800), atomic.LoadInt64(&count))
+}
+
+func TestCaptureWarnings(t *testing.T) {
+	var out bytes.Buffer
+	SetWarningOutput(&out)
+	defer SetWarningOutput(os.Stderr)
+
+	msgs := CaptureWarnings(func() {
+		Warning("first")
+		Warningf("second %d", 2)
+		Warningt("rotate", Error("third"))
+		// nested captures restore the outer one
+		require.Equal(t, []string{"inner"}, CaptureWarnings(func() {
+			Warning("inner")
+		}))
+		Warning(Join(Error("multi"), Error("line")))
+	})
+	require.Equal(t, []string{"first", "second 2", "rotate: third", "multi\nline"}, msgs)
+	require.Empty(t, out.String())
+
+	// the handler is bypassed while capturing
+	var handled int
+	SetWarningHandler(func(level WarnLevel, err error) { handled++ })
+	defer SetWarningHandler(nil)
+	require.Equal(t, []string{"handled"}, CaptureWarnings(func() { Warning("handled") }))
+	require.Equal(t, 0, handled)
+	Warning("restored")
+	require.Equal(t, 1, handled)
+}
+
+func TestWarningOutputConcurrent(t *testing.T) {
+	defer SetWarningOutput(os.Stderr)
+	var wg sync.WaitGroup
+	stop := make(chan struct{})
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+					Warningf("concurrent warning %d", i)
+				}
+			}
+		}(i)
+	}
+	for i := 0; i < 100; i++ {
+		SetWarningOutput(&bytes.Buffer{})
+		SetWarningPrefixf("warning %d", i)
+	}
+	close(stop)
+	wg.Wait()
+	SetWarningPrefix("")
 }
@@ -0,0 +1,74 @@
+package errors
+
+import "fmt"
+
+// wrapError is an error annotated with a message by Wrap.
+type wrapError struct {
+	msg string
+	err error
+	Tracer
+}
+
+// Error returns the message followed by the message of the wrapped error, in the
+// "failed to do something, err: reason" style of the package.
+func (w *wrapError) Error() string {
+	return w.msg + ", err: " + w.err.Error()
+}
+
+// Unwrap returns the wrapped error.
+func (w *wrapError) Unwrap() error {
+	return w.err
+}
+
+// Format implements the fmt.Formatter interface like the errors created by New.
+func (w *wrapError) Format(f fmt.State, verb rune) {
+	formatError(f, verb, w, w.Tracer)
+}
+
+// tracerOf returns the stack trace of err, or nil if it has none.
+func tracerOf(err error) Tracer {
+	var e *iErr
+	if As(err, &e) && e.Tracer != nil {
+		return e.Tracer
+	}
+	var w *wrapError
+	if As(err, &w) && w.Tracer != nil {
+		return w.Tracer
+	}
+	var p *PanicError
+	if As(err, &p) && p.Tracer != nil {
+		return p.Tracer
+	}
+	return nil
+}
+
+// Wrap returns an error with the message "msg, err: <err>" that wraps err, so that
+// Is, As and Unwrap keep working on it, e.g. after
+//
+//	err = errors.Wrap(err, "failed to open config")
+//
+// errors.Is(err, os.ErrNotExist) still reports whether the file is missing. The stack
+// trace of err is kept if it has one, otherwise the trace of the caller is captured.
+// It returns nil if err is nil.
+func Wrap(err error, msg string) error {
+	if err == nil {
+		return nil
+	}
+	tracer := tracerOf(err)
+	if tracer == nil {
+		tracer = GetTrace(3)
+	}
+	return &wrapError{msg: msg, err: err, Tracer: tracer}
+}
+
+// Wrapf is like Wrap with a formatted message.
+func Wrapf(err error, format string, a ...any) error {
+	if err == nil {
+		return nil
+	}
+	tracer := tracerOf(err)
+	if tracer == nil {
+		tracer = GetTrace(3)
+	}
+	return &wrapError{msg: fmt.Sprintf(format, a...), err: err, Tracer: tracer}
+}
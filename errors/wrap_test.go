@@ -0,0 +1,48 @@
+package errors
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWrap(t *testing.T) {
+	require.Nil(t, Wrap(nil, "msg"))
+	require.Nil(t, Wrapf(nil, "msg %d", 1))
+
+	_, statErr := os.Stat("not-existed-file")
+	err := Wrap(statErr, "failed to load config")
+	require.Equal(t, "failed to load config, err: "+statErr.Error(), err.Error())
+	require.True(t, Is(err, os.ErrNotExist))
+	require.Equal(t, statErr, Unwrap(err))
+	var pathErr *os.PathError
+	require.True(t, As(err, &pathErr))
+
+	// two levels of wrapping
+	err = Wrapf(err, "failed to start %q", "server")
+	require.Equal(t, `failed to start "server", err: failed to load config, err: `+statErr.Error(), err.Error())
+	require.True(t, Is(err, os.ErrNotExist))
+	require.True(t, Is(fmt.Errorf("main: %w", err), os.ErrNotExist))
+	require.Equal(t, NotFound, CodeOf(err))
+}
+
+func TestWrapStackTrace(t *testing.T) {
+	inner := newTestError()
+	frames := StackTrace(inner)
+	// the innermost stack trace is kept
+	require.Equal(t, frames, StackTrace(Wrap(inner, "outer")))
+	require.Equal(t, frames, StackTrace(Wrap(Wrapf(inner, "middle %d", 1), "outer")))
+	require.Equal(t, frames, StackTrace(Newf("newf, err: %s", Wrap(inner, "outer"))))
+
+	// the trace of the caller for an error without trace
+	err := Wrap(os.ErrNotExist, "outer")
+	require.Equal(t, "github.com/stkali/utility/errors.TestWrapStackTrace", StackTrace(err)[0].Function)
+	require.Equal(t, StackTrace(err), StackTrace(WithStack(err)))
+
+	require.Equal(t, "outer, err: file does not exist", fmt.Sprintf("%s", err))
+	require.Equal(t, `"outer, err: file does not exist"`, fmt.Sprintf("%q", err))
+	require.True(t, regxMatchErrorTrace.MatchString(fmt.Sprintf("%v", err)))
+	require.Regexp(t, `^outer, err: file does not exist\ngithub.com/stkali/utility/errors.TestWrapStackTrace\n`, fmt.Sprintf("%+v", err))
+}
@@ -0,0 +1,57 @@
+package lib
+
+import (
+	"fmt"
+	"strings"
+)
+
+// boolWords are the words accepted by ParseBool, in lower case.
+var boolWords = map[string]bool{
+	"1": true, "t": true, "true": true, "y": true, "yes": true, "on": true, "enabled": true,
+	"0": false, "f": false, "false": false, "n": false, "no": false, "off": false, "disabled": false,
+}
+
+// ParseBool parses a boolean like strconv.ParseBool, with the extended vocabulary found in
+// configurations: 1/0, t/f, true/false, y/n, yes/no, on/off and enabled/disabled. The
+// words are case-insensitive and the surrounding whitespaces are ignored.
+func ParseBool(s string) (bool, error) {
+	if b, ok := boolWords[strings.ToLower(strings.TrimSpace(s))]; ok {
+		return b, nil
+	}
+	return false, fmt.Errorf("invalid boolean %q, accepted values are "+
+		"1/0, t/f, true/false, y/n, yes/no, on/off and enabled/disabled", s)
+}
+
+// BoolStyle selects the words written by FormatBool.
+type BoolStyle int
+
+const (
+	// TrueFalse writes "true" or "false".
+	TrueFalse BoolStyle = iota
+	// YesNo writes "yes" or "no".
+	YesNo
+	// OnOff writes "on" or "off".
+	OnOff
+	// EnabledDisabled writes "enabled" or "disabled".
+	EnabledDisabled
+	// OneZero writes "1" or "0".
+	OneZero
+)
+
+// boolStyleWords are the true and false words of the styles.
+var boolStyleWords = [...][2]string{
+	TrueFalse:       {"true", "false"},
+	YesNo:           {"yes", "no"},
+	OnOff:           {"on", "off"},
+	EnabledDisabled: {"enabled", "disabled"},
+	OneZero:         {"1", "0"},
+}
+
+// FormatBool returns the word of b in style, an unknown style is TrueFalse. The result
+// can be parsed by ParseBool.
+func FormatBool(b bool, style BoolStyle) string {
+	if style < 0 || int(style) >= len(boolStyleWords) {
+		style = TrueFalse
+	}
+	return boolStyleWords[style][If(b, 0, 1)]
+}
@@ -0,0 +1,66 @@
+package lib
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseBool(t *testing.T) {
+	cases := []struct {
+		Text   string
+		Expect bool
+	}{
+		{"1", true}, {"0", false},
+		{"t", true}, {"f", false},
+		{"T", true}, {"F", false},
+		{"true", true}, {"false", false},
+		{"TRUE", true}, {"False", false},
+		{"y", true}, {"n", false},
+		{"Y", true}, {"N", false},
+		{"yes", true}, {"no", false},
+		{"Yes", true}, {"NO", false},
+		{"on", true}, {"off", false},
+		{"ON", true}, {"Off", false},
+		{"enabled", true}, {"disabled", false},
+		{"Enabled", true}, {"DISABLED", false},
+		{"  yes\t", true}, {"\noff ", false},
+	}
+	for _, c := range cases {
+		t.Run(c.Text, func(t *testing.T) {
+			b, err := ParseBool(c.Text)
+			require.NoError(t, err)
+			require.Equal(t, c.Expect, b)
+		})
+	}
+
+	for _, text := range []string{"", " ", "2", "-1", "yess", "enable", "o", "truefalse", "y es"} {
+		_, err := ParseBool(text)
+		require.Error(t, err, text)
+		require.Contains(t, err.Error(), "enabled/disabled")
+	}
+}
+
+func TestFormatBool(t *testing.T) {
+	cases := []struct {
+		Style       BoolStyle
+		True, False string
+	}{
+		{TrueFalse, "true", "false"},
+		{YesNo, "yes", "no"},
+		{OnOff, "on", "off"},
+		{EnabledDisabled, "enabled", "disabled"},
+		{OneZero, "1", "0"},
+		{BoolStyle(-1), "true", "false"},
+		{BoolStyle(100), "true", "false"},
+	}
+	for _, c := range cases {
+		require.Equal(t, c.True, FormatBool(true, c.Style))
+		require.Equal(t, c.False, FormatBool(false, c.Style))
+		for _, b := range []bool{true, false} {
+			parsed, err := ParseBool(FormatBool(b, c.Style))
+			require.NoError(t, err)
+			require.Equal(t, b, parsed)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package lib
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// splitWords splits s into words for the case conversions. The runes other than letters
+// and digits separate the words and are dropped, a word also starts at an upper case
+// letter following a lower case letter or a digit ("camelCase", "v2Beta"), and at the
+// last upper case letter of an acronym followed by a lower case letter ("HTTPServer").
+// The digits stick to the word they follow ("JSON2XMLParser" is JSON2, XML and Parser).
+func splitWords(s string) []string {
+	runes := []rune(s)
+	var words []string
+	start := -1
+	for i, r := range runes {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			if start >= 0 {
+				words = append(words, string(runes[start:i]))
+				start = -1
+			}
+			continue
+		}
+		if start < 0 {
+			start = i
+			continue
+		}
+		if unicode.IsUpper(r) {
+			prev := runes[i-1]
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) ||
+				(unicode.IsUpper(prev) && i+1 < len(runes) && unicode.IsLower(runes[i+1])) {
+				words = append(words, string(runes[start:i]))
+				start = i
+			}
+		}
+	}
+	if start >= 0 {
+		words = append(words, string(runes[start:]))
+	}
+	return words
+}
+
+// joinWords converts each word of s with convert and joins them with sep.
+func joinWords(s string, sep string, convert func(i int, word string) string) string {
+	words := splitWords(s)
+	sb := GetBuilder()
+	defer PutBuilder(sb)
+	for i, word := range words {
+		if i > 0 {
+			sb.WriteString(sep)
+		}
+		sb.WriteString(convert(i, word))
+	}
+	return sb.String()
+}
+
+// title returns word with its first rune in upper case and the others in lower case.
+func title(word string) string {
+	r, size := utf8.DecodeRuneInString(word)
+	return string(unicode.ToUpper(r)) + strings.ToLower(word[size:])
+}
+
+// ToSnake converts s to snake_case, e.g. "HTTPServer" to "http_server".
+func ToSnake(s string) string {
+	return joinWords(s, "_", func(_ int, word string) string {
+		return strings.ToLower(word)
+	})
+}
+
+// ToScreamingSnake converts s to SCREAMING_SNAKE_CASE, e.g. "HTTPServer" to "HTTP_SERVER".
+func ToScreamingSnake(s string) string {
+	return joinWords(s, "_", func(_ int, word string) string {
+		return strings.ToUpper(word)
+	})
+}
+
+// ToKebab converts s to kebab-case, e.g. "HTTPServer" to "http-server".
+func ToKebab(s string) string {
+	return joinWords(s, "-", func(_ int, word string) string {
+		return strings.ToLower(word)
+	})
+}
+
+// ToCamel converts s to camelCase, e.g. "http_server" to "httpServer". The acronyms
+// are not preserved, "HTTPServer" gives "httpServer".
+func ToCamel(s string) string {
+	return joinWords(s, "", func(i int, word string) string {
+		if i == 0 {
+			return strings.ToLower(word)
+		}
+		return title(word)
+	})
+}
+
+// ToPascal converts s to PascalCase, e.g. "http_server" to "HttpServer". The acronyms
+// are not preserved, "HTTPServer" gives "HttpServer".
+func ToPascal(s string) string {
+	return joinWords(s, "", func(_ int, word string) string {
+		return title(word)
+	})
+}
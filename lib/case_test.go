@@ -0,0 +1,55 @@
+package lib
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCaseConversion(t *testing.T) {
+	cases := []struct {
+		Text           string
+		Snake          string
+		ScreamingSnake string
+		Kebab          string
+		Camel          string
+		Pascal         string
+	}{
+		{"", "", "", "", "", ""},
+		{"word", "word", "WORD", "word", "word", "Word"},
+		{"HTTPServer", "http_server", "HTTP_SERVER", "http-server", "httpServer", "HttpServer"},
+		{"v2Beta", "v2_beta", "V2_BETA", "v2-beta", "v2Beta", "V2Beta"},
+		{"JSON2XMLParser", "json2_xml_parser", "JSON2_XML_PARSER", "json2-xml-parser", "json2XmlParser", "Json2XmlParser"},
+		{"__already__snake__", "already_snake", "ALREADY_SNAKE", "already-snake", "alreadySnake", "AlreadySnake"},
+		{"kebab-case-key", "kebab_case_key", "KEBAB_CASE_KEY", "kebab-case-key", "kebabCaseKey", "KebabCaseKey"},
+		{"camelCaseKey", "camel_case_key", "CAMEL_CASE_KEY", "camel-case-key", "camelCaseKey", "CamelCaseKey"},
+		{"MAX_BACKUP_SIZE", "max_backup_size", "MAX_BACKUP_SIZE", "max-backup-size", "maxBackupSize", "MaxBackupSize"},
+		{"log.file path", "log_file_path", "LOG_FILE_PATH", "log-file-path", "logFilePath", "LogFilePath"},
+		{"userID", "user_id", "USER_ID", "user-id", "userId", "UserId"},
+		{"ID", "id", "ID", "id", "id", "Id"},
+		{"A", "a", "A", "a", "a", "A"},
+		{"123", "123", "123", "123", "123", "123"},
+		{"--__  ", "", "", "", "", ""},
+		{"ÉcoleNormale", "école_normale", "ÉCOLE_NORMALE", "école-normale", "écoleNormale", "ÉcoleNormale"},
+		{"straßeName", "straße_name", "STRAßE_NAME", "straße-name", "straßeName", "StraßeName"},
+		{"日本語Text", "日本語text", "日本語TEXT", "日本語text", "日本語text", "日本語text"},
+	}
+	for _, c := range cases {
+		t.Run(c.Text, func(t *testing.T) {
+			require.Equal(t, c.Snake, ToSnake(c.Text))
+			require.Equal(t, c.ScreamingSnake, ToScreamingSnake(c.Text))
+			require.Equal(t, c.Kebab, ToKebab(c.Text))
+			require.Equal(t, c.Camel, ToCamel(c.Text))
+			require.Equal(t, c.Pascal, ToPascal(c.Text))
+		})
+	}
+}
+
+func TestCaseRoundTrip(t *testing.T) {
+	// words of a single letter are ambiguous, "a_b_c" gives "ABC" in PascalCase
+	for _, snake := range []string{"http_server", "v2_beta", "json2_xml_parser", "user_id", "école_normale"} {
+		for _, convert := range []func(string) string{ToCamel, ToPascal, ToKebab, ToScreamingSnake, ToSnake} {
+			require.Equal(t, snake, ToSnake(convert(snake)))
+		}
+	}
+}
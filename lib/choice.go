@@ -0,0 +1,143 @@
+package lib
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+)
+
+var InvalidWeightsError = errors.New("invalid weights")
+
+// Shuffle shuffles items in place with the Fisher-Yates algorithm and math/rand.
+func Shuffle[T any](items []T) {
+	for i := len(items) - 1; i > 0; i-- {
+		j := rand.Intn(i + 1)
+		items[i], items[j] = items[j], items[i]
+	}
+}
+
+// Sample returns k items chosen randomly from items without replacement, in a random
+// order. items is not modified, k is capped to len(items) and Sample returns an empty
+// slice for k <= 0. It uses reservoir sampling so only k items are copied.
+func Sample[T any](items []T, k int) []T {
+	k = Clamp(k, 0, len(items))
+	ret := make([]T, k)
+	copy(ret, items)
+	for i := k; i < len(items); i++ {
+		if j := rand.Intn(i + 1); j < k {
+			ret[j] = items[i]
+		}
+	}
+	// the reservoir keeps the order of the first items
+	Shuffle(ret)
+	return ret
+}
+
+// validateWeights checks that weights matches n items, has no negative, NaN or
+// infinite value, and that their sum is positive. It returns the sum of weights.
+func validateWeights(n int, weights []float64) (float64, error) {
+	if n != len(weights) {
+		return 0, fmt.Errorf("%w: %d weights for %d items", InvalidWeightsError, len(weights), n)
+	}
+	total := 0.0
+	for i, w := range weights {
+		if w < 0 || math.IsNaN(w) || math.IsInf(w, 0) {
+			return 0, fmt.Errorf("%w: weight %v at index %d", InvalidWeightsError, w, i)
+		}
+		total += w
+	}
+	if total <= 0 || math.IsInf(total, 0) {
+		return 0, fmt.Errorf("%w: sum of weights is %v", InvalidWeightsError, total)
+	}
+	return total, nil
+}
+
+// WeightedChoice returns one of items chosen randomly, items[i] is chosen with the
+// probability weights[i]/sum(weights). It scans the weights, for repeated draws from
+// the same items use a WeightedPicker.
+func WeightedChoice[T any](items []T, weights []float64) (T, error) {
+	total, err := validateWeights(len(items), weights)
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	r := rand.Float64() * total
+	last := 0
+	for i, w := range weights {
+		if w == 0 {
+			continue
+		}
+		if r < w {
+			return items[i], nil
+		}
+		r -= w
+		last = i
+	}
+	// rounding errors of the subtractions
+	return items[last], nil
+}
+
+// WeightedPicker picks items randomly according to their weights in constant time with
+// Vose's alias method. It's safe for concurrent use.
+type WeightedPicker[T any] struct {
+	items []T
+	prob  []float64
+	alias []int
+}
+
+// NewWeightedPicker returns a WeightedPicker of items, items[i] is picked with the
+// probability weights[i]/sum(weights). Building it takes O(n).
+func NewWeightedPicker[T any](items []T, weights []float64) (*WeightedPicker[T], error) {
+	total, err := validateWeights(len(items), weights)
+	if err != nil {
+		return nil, err
+	}
+	n := len(items)
+	p := &WeightedPicker[T]{
+		items: append([]T(nil), items...),
+		prob:  make([]float64, n),
+		alias: make([]int, n),
+	}
+	// scale the weights so their mean is 1, then pair every small column with a
+	// large one which gives away its excess
+	scaled := make([]float64, n)
+	var small, large []int
+	for i, w := range weights {
+		scaled[i] = w * float64(n) / total
+		if scaled[i] < 1 {
+			small = append(small, i)
+		} else {
+			large = append(large, i)
+		}
+	}
+	for len(small) > 0 && len(large) > 0 {
+		s, l := small[len(small)-1], large[len(large)-1]
+		small = small[:len(small)-1]
+		p.prob[s], p.alias[s] = scaled[s], l
+		scaled[l] -= 1 - scaled[s]
+		if scaled[l] < 1 {
+			large = large[:len(large)-1]
+			small = append(small, l)
+		}
+	}
+	// what remains is 1 up to rounding errors
+	for _, i := range append(small, large...) {
+		p.prob[i], p.alias[i] = 1, i
+	}
+	return p, nil
+}
+
+// Pick returns a random item.
+func (p *WeightedPicker[T]) Pick() T {
+	i := rand.Intn(len(p.items))
+	if rand.Float64() < p.prob[i] {
+		return p.items[i]
+	}
+	return p.items[p.alias[i]]
+}
+
+// Len returns the number of items.
+func (p *WeightedPicker[T]) Len() int {
+	return len(p.items)
+}
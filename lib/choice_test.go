@@ -0,0 +1,155 @@
+package lib
+
+import (
+	"math"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestShuffle(t *testing.T) {
+	Shuffle([]int(nil))
+	items := make([]int, 100)
+	for i := range items {
+		items[i] = i
+	}
+	shuffled := append([]int(nil), items...)
+	Shuffle(shuffled)
+	require.NotEqual(t, items, shuffled)
+	sort.Ints(shuffled)
+	require.Equal(t, items, shuffled)
+
+	// every permutation of 3 items is equally likely
+	counts := make(map[[3]int]int)
+	for i := 0; i < 6000; i++ {
+		p := []int{0, 1, 2}
+		Shuffle(p)
+		counts[[3]int{p[0], p[1], p[2]}]++
+	}
+	require.Len(t, counts, 6)
+	for _, count := range counts {
+		require.InDelta(t, 1000, count, 150)
+	}
+}
+
+func TestSample(t *testing.T) {
+	items := []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}
+	require.Equal(t, []int{}, Sample(items, 0))
+	require.Equal(t, []int{}, Sample(items, -1))
+	require.Equal(t, []int{}, Sample([]int(nil), 3))
+
+	all := Sample(items, 20)
+	sort.Ints(all)
+	require.Equal(t, items, all)
+
+	counts := make([]int, len(items))
+	for i := 0; i < 10000; i++ {
+		sample := Sample(items, 3)
+		require.Len(t, sample, 3)
+		seen := make(map[int]bool)
+		for _, v := range sample {
+			require.False(t, seen[v], "sampled twice")
+			seen[v] = true
+			counts[v]++
+		}
+	}
+	// every item is in 3/10 of the samples
+	for _, count := range counts {
+		require.InDelta(t, 3000, count, 300)
+	}
+	require.Equal(t, []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}, items)
+}
+
+func TestWeightedChoice(t *testing.T) {
+	errCases := []struct {
+		Name    string
+		Items   []string
+		Weights []float64
+	}{
+		{"length mismatch", []string{"a", "b"}, []float64{1}},
+		{"empty", nil, nil},
+		{"negative", []string{"a", "b"}, []float64{1, -1}},
+		{"nan", []string{"a"}, []float64{math.NaN()}},
+		{"inf", []string{"a"}, []float64{math.Inf(1)}},
+		{"zero sum", []string{"a", "b"}, []float64{0, 0}},
+		{"sum overflows", []string{"a", "b"}, []float64{math.MaxFloat64, math.MaxFloat64}},
+	}
+	for _, c := range errCases {
+		t.Run(c.Name, func(t *testing.T) {
+			_, err := WeightedChoice(c.Items, c.Weights)
+			require.ErrorIs(t, err, InvalidWeightsError)
+			_, err = NewWeightedPicker(c.Items, c.Weights)
+			require.ErrorIs(t, err, InvalidWeightsError)
+		})
+	}
+
+	for i := 0; i < 100; i++ {
+		item, err := WeightedChoice([]string{"a", "b", "c"}, []float64{0, 1, 0})
+		require.NoError(t, err)
+		require.Equal(t, "b", item)
+	}
+}
+
+// chiSquare returns the chi-square statistic of counts against weights.
+func chiSquare(counts []int, weights []float64, n int) float64 {
+	total := Sum(weights)
+	stat := 0.0
+	for i, w := range weights {
+		expected := float64(n) * w / total
+		if expected == 0 {
+			continue
+		}
+		d := float64(counts[i]) - expected
+		stat += d * d / expected
+	}
+	return stat
+}
+
+func TestWeightedDistribution(t *testing.T) {
+	const n = 100000
+	weights := []float64{1, 2, 3, 0, 4, 0.5, 9.5}
+	items := []int{0, 1, 2, 3, 4, 5, 6}
+	picker, err := NewWeightedPicker(items, weights)
+	require.NoError(t, err)
+	require.Equal(t, len(items), picker.Len())
+
+	draws := map[string]func() int{
+		"choice": func() int {
+			item, err := WeightedChoice(items, weights)
+			require.NoError(t, err)
+			return item
+		},
+		"picker": picker.Pick,
+	}
+	for name, draw := range draws {
+		t.Run(name, func(t *testing.T) {
+			counts := make([]int, len(items))
+			for i := 0; i < n; i++ {
+				counts[draw()]++
+			}
+			require.Zero(t, counts[3], "zero weight was picked")
+			// 5 degrees of freedom, the critical value at p=0.001 is 20.5
+			require.Less(t, chiSquare(counts, weights, n), 20.5, counts)
+		})
+	}
+}
+
+func BenchmarkWeightedChoice(b *testing.B) {
+	items := make([]int, 100)
+	weights := make([]float64, 100)
+	for i := range items {
+		items[i], weights[i] = i, float64(i+1)
+	}
+	b.Run("choice", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			_, _ = WeightedChoice(items, weights)
+		}
+	})
+	b.Run("picker", func(b *testing.B) {
+		picker, _ := NewWeightedPicker(items, weights)
+		for i := 0; i < b.N; i++ {
+			picker.Pick()
+		}
+	})
+}
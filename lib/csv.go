@@ -0,0 +1,246 @@
+package lib
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	BareQuoteError     = errors.New("bare quote in field")
+	InvalidEscapeError = errors.New("invalid escape sequence")
+)
+
+// csvNeedsQuote reports whether s has to be quoted to be a CSV field.
+func csvNeedsQuote(s string) bool {
+	return strings.IndexAny(s, ",\"\r\n") >= 0
+}
+
+// QuoteCSVField returns s as a RFC 4180 field: s is enclosed in double quotes and its
+// quotes are doubled when it contains a comma, a quote, a CR or a LF, otherwise s is
+// returned as is.
+func QuoteCSVField(s string) string {
+	if !csvNeedsQuote(s) {
+		return s
+	}
+	sb := GetBuilder()
+	defer PutBuilder(sb)
+	writeCSVField(sb, s)
+	return sb.String()
+}
+
+func writeCSVField(sb *strings.Builder, s string) {
+	if !csvNeedsQuote(s) {
+		sb.WriteString(s)
+		return
+	}
+	sb.WriteByte('"')
+	for {
+		i := strings.IndexByte(s, '"')
+		if i < 0 {
+			break
+		}
+		sb.WriteString(s[:i+1])
+		sb.WriteByte('"')
+		s = s[i+1:]
+	}
+	sb.WriteString(s)
+	sb.WriteByte('"')
+}
+
+// JoinCSV quotes the fields with QuoteCSVField and joins them with commas into a CSV
+// record without a line terminator.
+func JoinCSV(fields []string) string {
+	switch len(fields) {
+	case 0:
+		return ""
+	case 1:
+		return QuoteCSVField(fields[0])
+	}
+	sb := GetBuilder()
+	defer PutBuilder(sb)
+	sb.Grow(joinedLen(fields))
+	for i, field := range fields {
+		if i > 0 {
+			sb.WriteByte(',')
+		}
+		writeCSVField(sb, field)
+	}
+	return sb.String()
+}
+
+// joinedLen returns the length of the fields joined by a separator, before escaping.
+func joinedLen(fields []string) int {
+	n := len(fields) - 1
+	for _, field := range fields {
+		n += len(field)
+	}
+	return n
+}
+
+// trimEOL removes a trailing "\n" or "\r\n" from s.
+func trimEOL(s string) string {
+	if strings.HasSuffix(s, "\n") {
+		s = s[:len(s)-1]
+		return strings.TrimSuffix(s, "\r")
+	}
+	return s
+}
+
+// SplitCSVLine splits a RFC 4180 record into its fields, it's the reverse of JoinCSV.
+// Quoted fields may contain commas, doubled quotes and line breaks, a trailing line
+// terminator is ignored. The unquoted fields are substrings of line.
+func SplitCSVLine(line string) ([]string, error) {
+	fields := make([]string, 0, strings.Count(line, ",")+1)
+	for offset := 0; ; {
+		if len(line) == 0 || line[0] != '"' {
+			field, rest, found := strings.Cut(line, ",")
+			if !found {
+				field = trimEOL(field)
+			}
+			if i := strings.IndexByte(field, '"'); i >= 0 {
+				return nil, fmt.Errorf("%w at offset %d", BareQuoteError, offset+i)
+			}
+			fields = append(fields, field)
+			if !found {
+				return fields, nil
+			}
+			offset += len(field) + 1
+			line = rest
+			continue
+		}
+		field, n, err := unquoteCSVField(line)
+		if err != nil {
+			return nil, fmt.Errorf("%w at offset %d", err, offset+n)
+		}
+		fields = append(fields, field)
+		offset += n
+		line = line[n:]
+		switch {
+		case trimEOL(line) == "":
+			return fields, nil
+		case line[0] != ',':
+			return nil, fmt.Errorf("%w at offset %d", BareQuoteError, offset-1)
+		}
+		offset++
+		line = line[1:]
+	}
+}
+
+// unquoteCSVField unquotes the quoted field at the start of s and returns the length
+// of the consumed text, or the offset of the error.
+func unquoteCSVField(s string) (string, int, error) {
+	i := strings.IndexByte(s[1:], '"') + 1
+	if i == 0 {
+		return "", 0, UnterminatedQuoteError
+	}
+	if i+1 >= len(s) || s[i+1] != '"' {
+		// fast path without doubled quotes
+		return s[1:i], i + 1, nil
+	}
+	sb := GetBuilder()
+	defer PutBuilder(sb)
+	start := 1
+	for {
+		// s[i] is a quote, either doubled or closing
+		if i+1 < len(s) && s[i+1] == '"' {
+			sb.WriteString(s[start : i+1])
+			start = i + 2
+		} else {
+			sb.WriteString(s[start:i])
+			return sb.String(), i + 1, nil
+		}
+		j := strings.IndexByte(s[start:], '"')
+		if j < 0 {
+			return "", 0, UnterminatedQuoteError
+		}
+		i = start + j
+	}
+}
+
+// tsvEscaper escapes the characters that can't appear in a TSV field.
+var tsvEscaper = strings.NewReplacer(`\`, `\\`, "\t", `\t`, "\n", `\n`, "\r", `\r`)
+
+// QuoteTSVField escapes the backslashes, tabs, LFs and CRs of s with backslashes,
+// e.g. "a\tb" becomes `a\tb`, so the field can be joined with tabs.
+func QuoteTSVField(s string) string {
+	if strings.IndexAny(s, "\\\t\n\r") < 0 {
+		return s
+	}
+	return tsvEscaper.Replace(s)
+}
+
+// JoinTSV escapes the fields with QuoteTSVField and joins them with tabs into a TSV
+// record without a line terminator.
+func JoinTSV(fields []string) string {
+	switch len(fields) {
+	case 0:
+		return ""
+	case 1:
+		return QuoteTSVField(fields[0])
+	}
+	sb := GetBuilder()
+	defer PutBuilder(sb)
+	sb.Grow(joinedLen(fields))
+	for i, field := range fields {
+		if i > 0 {
+			sb.WriteByte('\t')
+		}
+		if strings.IndexAny(field, "\\\t\n\r") < 0 {
+			sb.WriteString(field)
+		} else {
+			_, _ = tsvEscaper.WriteString(sb, field)
+		}
+	}
+	return sb.String()
+}
+
+// SplitTSVLine splits a TSV record by tabs and unescapes the fields, it's the reverse
+// of JoinTSV. A trailing line terminator is ignored.
+func SplitTSVLine(line string) ([]string, error) {
+	line = trimEOL(line)
+	fields := make([]string, 0, strings.Count(line, "\t")+1)
+	for {
+		field, rest, found := strings.Cut(line, "\t")
+		unescaped, err := unescapeTSVField(field)
+		if err != nil {
+			return nil, fmt.Errorf("%w in field %d", err, len(fields)+1)
+		}
+		fields = append(fields, unescaped)
+		if !found {
+			return fields, nil
+		}
+		line = rest
+	}
+}
+
+func unescapeTSVField(s string) (string, error) {
+	i := strings.IndexByte(s, '\\')
+	if i < 0 {
+		return s, nil
+	}
+	sb := GetBuilder()
+	defer PutBuilder(sb)
+	for i >= 0 {
+		sb.WriteString(s[:i])
+		if i+1 == len(s) {
+			return "", TrailingEscapeError
+		}
+		switch s[i+1] {
+		case '\\':
+			sb.WriteByte('\\')
+		case 't':
+			sb.WriteByte('\t')
+		case 'n':
+			sb.WriteByte('\n')
+		case 'r':
+			sb.WriteByte('\r')
+		default:
+			return "", fmt.Errorf("%w %q", InvalidEscapeError, s[i:i+2])
+		}
+		s = s[i+2:]
+		i = strings.IndexByte(s, '\\')
+	}
+	sb.WriteString(s)
+	return sb.String(), nil
+}
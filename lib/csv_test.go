@@ -0,0 +1,172 @@
+package lib
+
+import (
+	"encoding/csv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestQuoteCSVField(t *testing.T) {
+	cases := []struct {
+		Name   string
+		Field  string
+		Expect string
+	}{
+		{"plain", "abc", "abc"},
+		{"empty", "", ""},
+		{"space", " a b ", " a b "},
+		{"comma", "a,b", `"a,b"`},
+		{"quote", `say "hi"`, `"say ""hi"""`},
+		{"only quote", `"`, `""""`},
+		{"newline", "a\nb", "\"a\nb\""},
+		{"carriage return", "a\rb", "\"a\rb\""},
+	}
+	for _, c := range cases {
+		t.Run(c.Name, func(t *testing.T) {
+			require.Equal(t, c.Expect, QuoteCSVField(c.Field))
+		})
+	}
+}
+
+func TestJoinCSV(t *testing.T) {
+	require.Equal(t, "", JoinCSV(nil))
+	require.Equal(t, `a,"b,c",,"d""e"`, JoinCSV([]string{"a", "b,c", "", `d"e`}))
+
+	// the output agrees with encoding/csv
+	fields := []string{"a", "b,c", `d"e`, "f\ng", ""}
+	var sb strings.Builder
+	w := csv.NewWriter(&sb)
+	require.NoError(t, w.Write(fields))
+	w.Flush()
+	require.Equal(t, sb.String(), JoinCSV(fields)+"\n")
+}
+
+func TestSplitCSVLine(t *testing.T) {
+	cases := []struct {
+		Name   string
+		Line   string
+		Expect []string
+	}{
+		{"empty", "", []string{""}},
+		{"plain", "a,b,c", []string{"a", "b", "c"}},
+		{"empty fields", ",a,,", []string{"", "a", "", ""}},
+		{"quoted", `"a,b",c`, []string{"a,b", "c"}},
+		{"doubled quotes", `"say ""hi""",""""`, []string{`say "hi"`, `"`}},
+		{"empty quoted", `"",x`, []string{"", "x"}},
+		{"line break", "\"a\r\nb\",c", []string{"a\r\nb", "c"}},
+		{"trailing lf", "a,b\n", []string{"a", "b"}},
+		{"trailing crlf", "a,\"b\"\r\n", []string{"a", "b"}},
+		{"spaces", " a , b ", []string{" a ", " b "}},
+	}
+	for _, c := range cases {
+		t.Run(c.Name, func(t *testing.T) {
+			fields, err := SplitCSVLine(c.Line)
+			require.NoError(t, err)
+			require.Equal(t, c.Expect, fields)
+		})
+	}
+
+	errCases := []struct {
+		Name   string
+		Line   string
+		Err    error
+		Offset string
+	}{
+		{"unterminated", `a,"bc`, UnterminatedQuoteError, "offset 2"},
+		{"unterminated doubled", `"a""b`, UnterminatedQuoteError, "offset 0"},
+		{"bare quote", `a,b"c`, BareQuoteError, "offset 3"},
+		{"text after quote", `"a"b,c`, BareQuoteError, "offset 2"},
+	}
+	for _, c := range errCases {
+		t.Run(c.Name, func(t *testing.T) {
+			_, err := SplitCSVLine(c.Line)
+			require.ErrorIs(t, err, c.Err)
+			require.Contains(t, err.Error(), c.Offset)
+		})
+	}
+}
+
+func TestTSV(t *testing.T) {
+	require.Equal(t, "abc", QuoteTSVField("abc"))
+	require.Equal(t, `a\tb\nc\rd\\e`, QuoteTSVField("a\tb\nc\rd\\e"))
+	require.Equal(t, "", JoinTSV(nil))
+	require.Equal(t, "a\t\tb\\tc\t\\\\", JoinTSV([]string{"a", "", "b\tc", `\`}))
+
+	fields, err := SplitTSVLine("a\t\tb\\tc\t\\\\\n")
+	require.NoError(t, err)
+	require.Equal(t, []string{"a", "", "b\tc", `\`}, fields)
+	fields, err = SplitTSVLine("")
+	require.NoError(t, err)
+	require.Equal(t, []string{""}, fields)
+
+	_, err = SplitTSVLine("a\tb\\")
+	require.ErrorIs(t, err, TrailingEscapeError)
+	require.Contains(t, err.Error(), "field 2")
+	_, err = SplitTSVLine(`a\x`)
+	require.ErrorIs(t, err, InvalidEscapeError)
+}
+
+func TestSplitCSVLineAllocs(t *testing.T) {
+	// unquoted fields are substrings of the line, only the slice is allocated
+	allocs := testing.AllocsPerRun(100, func() {
+		_, _ = SplitCSVLine(`2024-01-01,INFO,"quoted, field",message`)
+	})
+	require.Equal(t, 1.0, allocs)
+}
+
+func FuzzCSVRoundTrip(f *testing.F) {
+	f.Add("a", "b,c", `"d"`)
+	f.Add("", "\r\n", "")
+	f.Add(`""`, ",", "\n")
+	f.Fuzz(func(t *testing.T, a, b, c string) {
+		fields := []string{a, b, c}
+		parsed, err := SplitCSVLine(JoinCSV(fields))
+		require.NoError(t, err)
+		require.Equal(t, fields, parsed)
+	})
+}
+
+func FuzzTSVRoundTrip(f *testing.F) {
+	f.Add("a", "b\tc", `\d`)
+	f.Add("", "\r\n", "\\t")
+	f.Fuzz(func(t *testing.T, a, b, c string) {
+		fields := []string{a, b, c}
+		parsed, err := SplitTSVLine(JoinTSV(fields))
+		require.NoError(t, err)
+		require.Equal(t, fields, parsed)
+	})
+}
+
+var csvBenchmarkFields = []string{"2024-01-01T00:00:00Z", "INFO", "user said \"hello, world\"", "42", ""}
+
+func BenchmarkJoinCSV(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		JoinCSV(csvBenchmarkFields)
+	}
+}
+
+func BenchmarkSplitCSVLine(b *testing.B) {
+	line := JoinCSV(csvBenchmarkFields)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_, _ = SplitCSVLine(line)
+	}
+}
+
+func BenchmarkJoinTSV(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		JoinTSV(csvBenchmarkFields)
+	}
+}
+
+func BenchmarkSplitTSVLine(b *testing.B) {
+	line := JoinTSV(csvBenchmarkFields)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_, _ = SplitTSVLine(line)
+	}
+}
@@ -0,0 +1,130 @@
+package lib
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// durationUnits are the units written by FormatDuration, from the largest.
+var durationUnits = []struct {
+	unit time.Duration
+	name string
+}{
+	{Day, "d"},
+	{time.Hour, "h"},
+	{time.Minute, "m"},
+	{time.Second, "s"},
+	{time.Millisecond, "ms"},
+	{time.Microsecond, "us"},
+	{time.Nanosecond, "ns"},
+}
+
+// ParseDuration parses a duration string like time.ParseDuration, and additionally
+// accepts the units "d" (24h) and "w" (7d), e.g. "7d", "2w" or "1w3d12h30m".
+func ParseDuration(s string) (time.Duration, error) {
+	orig := s
+	neg := false
+	if s != "" && (s[0] == '-' || s[0] == '+') {
+		neg = s[0] == '-'
+		s = s[1:]
+	}
+	if s == "0" {
+		return 0, nil
+	}
+	if s == "" {
+		return 0, fmt.Errorf("invalid duration: %q", orig)
+	}
+	// the magnitude of math.MinInt64 exceeds math.MaxInt64 by one, see FormatDuration
+	limit := uint64(math.MaxInt64)
+	if neg {
+		limit++
+	}
+	var total uint64
+	for s != "" {
+		// a segment is a number followed by a unit
+		i := 0
+		for i < len(s) && (s[i] == '.' || ('0' <= s[i] && s[i] <= '9')) {
+			i++
+		}
+		j := i
+		for j < len(s) && s[j] != '.' && (s[j] < '0' || s[j] > '9') {
+			j++
+		}
+		if i == 0 || j == i {
+			return 0, fmt.Errorf("invalid duration: %q", orig)
+		}
+		number, unit := s[:i], s[i:j]
+		s = s[j:]
+
+		var d time.Duration
+		var err error
+		switch unit {
+		case "d", "w":
+			// parse as hours to keep the exact fraction handling of time.ParseDuration
+			multiple := time.Duration(24)
+			if unit == "w" {
+				multiple = 24 * 7
+			}
+			if d, err = time.ParseDuration(number + "h"); err == nil {
+				if d > math.MaxInt64/multiple {
+					return 0, fmt.Errorf("invalid duration: %q, overflow", orig)
+				}
+				d *= multiple
+			}
+		default:
+			d, err = time.ParseDuration(number + unit)
+		}
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration: %q", orig)
+		}
+		if uint64(d) > limit-total {
+			return 0, fmt.Errorf("invalid duration: %q, overflow", orig)
+		}
+		total += uint64(d)
+	}
+	if neg {
+		return -time.Duration(total), nil
+	}
+	return time.Duration(total), nil
+}
+
+// FormatDuration returns a compact representation of d using the units d, h, m,
+// s, ms, us and ns, omitting the zero components, e.g. "3d4h" instead of "76h0m0s".
+// The zero duration is "0s". The result can be parsed by ParseDuration.
+func FormatDuration(d time.Duration) string {
+	return FormatDurationN(d, 0)
+}
+
+// FormatDurationN is like FormatDuration, but keeps at most n of the most significant
+// components, the rest is truncated, e.g. FormatDurationN(26*time.Hour+30*time.Minute+5*time.Second, 2)
+// returns "1d2h30m" truncated to "1d2h". n <= 0 means no limit.
+func FormatDurationN(d time.Duration, n int) string {
+	if d == 0 {
+		return "0s"
+	}
+	var sb strings.Builder
+	// math.MinInt64 cannot be negated, work on the unsigned magnitude
+	u := uint64(d)
+	if d < 0 {
+		sb.WriteByte('-')
+		u = -u
+	}
+	written := 0
+	for _, du := range durationUnits {
+		if n > 0 && written == n {
+			break
+		}
+		unit := uint64(du.unit)
+		if count := u / unit; count > 0 {
+			fmt.Fprintf(&sb, "%d%s", count, du.name)
+			u -= count * unit
+			written++
+		} else if written > 0 && n > 0 {
+			// a skipped component still counts toward the precision
+			written++
+		}
+	}
+	return sb.String()
+}
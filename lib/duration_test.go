@@ -0,0 +1,106 @@
+package lib
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDuration(t *testing.T) {
+	cases := []struct {
+		Name   string
+		Text   string
+		Expect time.Duration
+	}{
+		{"zero", "0", 0},
+		{"zero unit", "0s", 0},
+		{"standard", "1h30m", 90 * time.Minute},
+		{"day", "7d", 7 * Day},
+		{"week", "2w", 2 * Week},
+		{"combination", "1w3d12h", Week + 3*Day + 12*time.Hour},
+		{"fraction day", "1.5d", 36 * time.Hour},
+		{"negative", "-3d4h", -(3*Day + 4*time.Hour)},
+		{"positive sign", "+1d", Day},
+		{"sub second", "1s500ms", 1500 * time.Millisecond},
+	}
+	for _, c := range cases {
+		t.Run(c.Name, func(t *testing.T) {
+			d, err := ParseDuration(c.Text)
+			require.NoError(t, err)
+			require.Equal(t, c.Expect, d)
+		})
+	}
+
+	t.Run("invalid", func(t *testing.T) {
+		for _, text := range []string{"", "-", "d", "3", "1x", "1d2", "1..5d", "99999999w"} {
+			_, err := ParseDuration(text)
+			require.Error(t, err, text)
+		}
+	})
+}
+
+func TestFormatDuration(t *testing.T) {
+	cases := []struct {
+		Name     string
+		Duration time.Duration
+		Expect   string
+	}{
+		{"zero", 0, "0s"},
+		{"hours", 76 * time.Hour, "3d4h"},
+		{"week", Week, "7d"},
+		{"minutes", 90 * time.Minute, "1h30m"},
+		{"sub second", 1500 * time.Millisecond, "1s500ms"},
+		{"nanosecond", time.Nanosecond, "1ns"},
+		{"negative", -(Day + time.Second), "-1d1s"},
+		{"min", math.MinInt64, "-106751d23h47m16s854ms775us808ns"},
+	}
+	for _, c := range cases {
+		t.Run(c.Name, func(t *testing.T) {
+			require.Equal(t, c.Expect, FormatDuration(c.Duration))
+		})
+	}
+}
+
+func TestFormatDurationN(t *testing.T) {
+	d := 26*time.Hour + 30*time.Minute + 5*time.Second
+	require.Equal(t, "1d2h30m5s", FormatDurationN(d, 0))
+	require.Equal(t, "1d", FormatDurationN(d, 1))
+	require.Equal(t, "1d2h", FormatDurationN(d, 2))
+	require.Equal(t, "1d2h30m", FormatDurationN(d, 3))
+	// the zero hours component counts toward the precision
+	require.Equal(t, "1d", FormatDurationN(Day+30*time.Minute, 2))
+}
+
+func TestDurationRoundTrip(t *testing.T) {
+	for _, d := range []time.Duration{
+		0,
+		time.Nanosecond,
+		-time.Hour,
+		3*Day + 4*time.Hour,
+		2*Week + 5*time.Minute + 7*time.Millisecond,
+		math.MaxInt64,
+		-math.MaxInt64,
+		math.MinInt64,
+	} {
+		parsed, err := ParseDuration(FormatDuration(d))
+		require.NoError(t, err)
+		require.Equal(t, d, parsed)
+	}
+	// one past math.MinInt64
+	_, err := ParseDuration("-106751d23h47m16s854ms775us809ns")
+	require.Error(t, err)
+}
+
+func FuzzDurationRoundTrip(f *testing.F) {
+	for _, seed := range []int64{0, 1, -1, int64(Day + time.Second), int64(-Week), math.MaxInt64, -math.MaxInt64, math.MinInt64} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, n int64) {
+		d := time.Duration(n)
+		parsed, err := ParseDuration(FormatDuration(d))
+		require.NoError(t, err)
+		require.Equal(t, d, parsed)
+	})
+}
@@ -0,0 +1,176 @@
+package lib
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+var EnvNotSetError = errors.New("environment variable is not set")
+
+// EnvLookup reads environment variables whose names start with a prefix, see EnvPrefix.
+// An unset or empty variable gives the default value, while a variable set to an invalid
+// value gives an error, so that a typo is not silently replaced with the default.
+type EnvLookup struct {
+	prefix string
+}
+
+// EnvPrefix returns an EnvLookup reading the variables named prefix+key, e.g.
+// EnvPrefix("APP_").Int("PORT", 80) reads APP_PORT.
+func EnvPrefix(prefix string) EnvLookup {
+	return EnvLookup{prefix: prefix}
+}
+
+// Key returns the name of the variable of key.
+func (e EnvLookup) Key(key string) string {
+	return e.prefix + key
+}
+
+// lookup returns the value of key, ok is false if it is unset or empty.
+func (e EnvLookup) lookup(key string) (value string, ok bool) {
+	value = strings.TrimSpace(os.Getenv(e.Key(key)))
+	return value, value != ""
+}
+
+// invalid returns the error of an invalid value of key.
+func (e EnvLookup) invalid(key, value string, err error) error {
+	return fmt.Errorf("invalid value %q of environment variable %s, err: %w", value, e.Key(key), err)
+}
+
+// Get returns the value of key, or def if it is unset or empty.
+func (e EnvLookup) Get(key, def string) string {
+	if value, ok := e.lookup(key); ok {
+		return value
+	}
+	return def
+}
+
+// Required returns the value of key, or EnvNotSetError if it is unset or empty.
+func (e EnvLookup) Required(key string) (string, error) {
+	if value, ok := e.lookup(key); ok {
+		return value, nil
+	}
+	return "", fmt.Errorf("%w: %s", EnvNotSetError, e.Key(key))
+}
+
+// Int returns the value of key as an int.
+func (e EnvLookup) Int(key string, def int) (int, error) {
+	value, ok := e.lookup(key)
+	if !ok {
+		return def, nil
+	}
+	i, err := AtoiE(value)
+	if err != nil {
+		return 0, withKey(err, e.Key(key))
+	}
+	return i, nil
+}
+
+// Int64 returns the value of key as an int64.
+func (e EnvLookup) Int64(key string, def int64) (int64, error) {
+	value, ok := e.lookup(key)
+	if !ok {
+		return def, nil
+	}
+	i, err := ParseInt64E(value)
+	if err != nil {
+		return 0, withKey(err, e.Key(key))
+	}
+	return i, nil
+}
+
+// Float returns the value of key as a float64.
+func (e EnvLookup) Float(key string, def float64) (float64, error) {
+	value, ok := e.lookup(key)
+	if !ok {
+		return def, nil
+	}
+	f, err := ParseFloatE(value)
+	if err != nil {
+		return 0, withKey(err, e.Key(key))
+	}
+	return f, nil
+}
+
+// Bool returns the value of key parsed by ParseBool, e.g. "yes" or "off".
+func (e EnvLookup) Bool(key string, def bool) (bool, error) {
+	value, ok := e.lookup(key)
+	if !ok {
+		return def, nil
+	}
+	b, err := ParseBool(value)
+	if err != nil {
+		return false, e.invalid(key, value, err)
+	}
+	return b, nil
+}
+
+// Duration returns the value of key parsed by ParseDuration, e.g. "7d" or "1h30m".
+func (e EnvLookup) Duration(key string, def time.Duration) (time.Duration, error) {
+	value, ok := e.lookup(key)
+	if !ok {
+		return def, nil
+	}
+	d, err := ParseDuration(value)
+	if err != nil {
+		return 0, e.invalid(key, value, err)
+	}
+	return d, nil
+}
+
+// Size returns the value of key parsed by String2Size, e.g. "10 MB".
+func (e EnvLookup) Size(key string, def int64) (int64, error) {
+	value, ok := e.lookup(key)
+	if !ok {
+		return def, nil
+	}
+	size, err := String2Size(value)
+	if err != nil {
+		return 0, e.invalid(key, value, err)
+	}
+	return size, nil
+}
+
+// Env returns the value of the environment variable key, or def if it is unset or empty.
+func Env(key, def string) string {
+	return EnvLookup{}.Get(key, def)
+}
+
+// EnvRequired returns the value of the environment variable key, or EnvNotSetError if
+// it is unset or empty.
+func EnvRequired(key string) (string, error) {
+	return EnvLookup{}.Required(key)
+}
+
+// EnvInt returns the environment variable key as an int, def if it is unset or empty,
+// and an error if it is invalid.
+func EnvInt(key string, def int) (int, error) {
+	return EnvLookup{}.Int(key, def)
+}
+
+// EnvInt64 returns the environment variable key as an int64, see EnvInt.
+func EnvInt64(key string, def int64) (int64, error) {
+	return EnvLookup{}.Int64(key, def)
+}
+
+// EnvFloat returns the environment variable key as a float64, see EnvInt.
+func EnvFloat(key string, def float64) (float64, error) {
+	return EnvLookup{}.Float(key, def)
+}
+
+// EnvBool returns the environment variable key as a bool, see EnvLookup.Bool.
+func EnvBool(key string, def bool) (bool, error) {
+	return EnvLookup{}.Bool(key, def)
+}
+
+// EnvDuration returns the environment variable key parsed by ParseDuration, see EnvInt.
+func EnvDuration(key string, def time.Duration) (time.Duration, error) {
+	return EnvLookup{}.Duration(key, def)
+}
+
+// EnvSize returns the environment variable key parsed by String2Size, see EnvInt.
+func EnvSize(key string, def int64) (int64, error) {
+	return EnvLookup{}.Size(key, def)
+}
@@ -0,0 +1,124 @@
+package lib
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+const envKey = "UTILITY_TEST_ENV"
+
+func TestEnv(t *testing.T) {
+	require.Equal(t, "def", Env(envKey, "def"))
+	t.Setenv(envKey, "")
+	require.Equal(t, "def", Env(envKey, "def"))
+	t.Setenv(envKey, " value ")
+	require.Equal(t, "value", Env(envKey, "def"))
+}
+
+func TestEnvRequired(t *testing.T) {
+	_, err := EnvRequired(envKey)
+	require.ErrorIs(t, err, EnvNotSetError)
+	require.Contains(t, err.Error(), envKey)
+	t.Setenv(envKey, "value")
+	value, err := EnvRequired(envKey)
+	require.NoError(t, err)
+	require.Equal(t, "value", value)
+}
+
+func TestEnvInt(t *testing.T) {
+	i, err := EnvInt(envKey, 3)
+	require.NoError(t, err)
+	require.Equal(t, 3, i)
+	i64, err := EnvInt64(envKey, 3)
+	require.NoError(t, err)
+	require.Equal(t, int64(3), i64)
+
+	t.Setenv(envKey, "42")
+	i, err = EnvInt(envKey, 3)
+	require.NoError(t, err)
+	require.Equal(t, 42, i)
+	i64, err = EnvInt64(envKey, 3)
+	require.NoError(t, err)
+	require.Equal(t, int64(42), i64)
+
+	t.Setenv(envKey, "4x2")
+	_, err = EnvInt(envKey, 3)
+	require.ErrorIs(t, err, InvalidNumberError)
+	require.EqualError(t, err, `invalid integer "4x2" for key `+envKey)
+	_, err = EnvInt64(envKey, 3)
+	require.ErrorIs(t, err, InvalidNumberError)
+}
+
+func TestEnvFloat(t *testing.T) {
+	f, err := EnvFloat(envKey, 0.5)
+	require.NoError(t, err)
+	require.Equal(t, 0.5, f)
+	t.Setenv(envKey, "2.25")
+	f, err = EnvFloat(envKey, 0.5)
+	require.NoError(t, err)
+	require.Equal(t, 2.25, f)
+	t.Setenv(envKey, "2,25")
+	_, err = EnvFloat(envKey, 0.5)
+	require.EqualError(t, err, `invalid float "2,25" for key `+envKey)
+}
+
+func TestEnvBool(t *testing.T) {
+	b, err := EnvBool(envKey, true)
+	require.NoError(t, err)
+	require.True(t, b)
+	for value, expect := range map[string]bool{
+		"1": true, "TRUE": true, "Yes": true,
+		"0": false, "false": false, "NO": false,
+		"on": true, "Disabled": false,
+	} {
+		t.Setenv(envKey, value)
+		b, err = EnvBool(envKey, !expect)
+		require.NoError(t, err)
+		require.Equal(t, expect, b, value)
+	}
+	t.Setenv(envKey, "maybe")
+	_, err = EnvBool(envKey, true)
+	require.Error(t, err)
+}
+
+func TestEnvDuration(t *testing.T) {
+	d, err := EnvDuration(envKey, time.Hour)
+	require.NoError(t, err)
+	require.Equal(t, time.Hour, d)
+	t.Setenv(envKey, "1w2d")
+	d, err = EnvDuration(envKey, time.Hour)
+	require.NoError(t, err)
+	require.Equal(t, 9*Day, d)
+	t.Setenv(envKey, "7 days")
+	_, err = EnvDuration(envKey, time.Hour)
+	require.Error(t, err)
+}
+
+func TestEnvSize(t *testing.T) {
+	size, err := EnvSize(envKey, KB)
+	require.NoError(t, err)
+	require.Equal(t, KB, size)
+	t.Setenv(envKey, "10 MB")
+	size, err = EnvSize(envKey, KB)
+	require.NoError(t, err)
+	require.Equal(t, 10*MB, size)
+	t.Setenv(envKey, "10 XB")
+	_, err = EnvSize(envKey, KB)
+	require.Error(t, err)
+}
+
+func TestEnvPrefix(t *testing.T) {
+	env := EnvPrefix("UTILITY_TEST_")
+	require.Equal(t, "UTILITY_TEST_PORT", env.Key("PORT"))
+	t.Setenv("UTILITY_TEST_PORT", "8080")
+	t.Setenv("UTILITY_TEST_NAME", "app")
+	port, err := env.Int("PORT", 80)
+	require.NoError(t, err)
+	require.Equal(t, 8080, port)
+	require.Equal(t, "app", env.Get("NAME", ""))
+	_, err = env.Required("MISSING")
+	require.ErrorIs(t, err, EnvNotSetError)
+	require.Contains(t, err.Error(), "UTILITY_TEST_MISSING")
+}
@@ -0,0 +1,100 @@
+package lib
+
+import (
+	"flag"
+	"fmt"
+	"time"
+)
+
+const (
+	// sizeUnitsHelp lists the units accepted by SizeFlag.
+	sizeUnitsHelp = "B, K/KB/KiB, M/MB/MiB, G/GB/GiB, T/TB/TiB, P/PB/PiB, E/EB/EiB (powers of 1024, case-insensitive), e.g. 256MB"
+	// durationUnitsHelp lists the units accepted by DurationFlag.
+	durationUnitsHelp = "ns, us, ms, s, m, h, d (24h), w (7d), e.g. 7d or 1h30m"
+)
+
+// SizeFlag is a size in bytes implementing flag.Value and encoding.TextUnmarshaler,
+// it's parsed by String2Size, e.g. "256MB" or "1.5 GiB", and formatted as the largest
+// unit that represents it exactly, e.g. "256 MB" or "1025 B".
+type SizeFlag int64
+
+// Set implements flag.Value.
+func (s *SizeFlag) Set(text string) error {
+	size, err := String2Size(text)
+	if err != nil {
+		return fmt.Errorf("invalid size %q, the accepted units are %s", text, sizeUnitsHelp)
+	}
+	*s = SizeFlag(size)
+	return nil
+}
+
+// String implements flag.Value, the result can be parsed by Set.
+func (s *SizeFlag) String() string {
+	if s == nil {
+		return "0 B"
+	}
+	size := int64(*s)
+	index := 0
+	for size != 0 && size%1024 == 0 && index < len(sizeUnitPrefixes) {
+		size /= 1024
+		index++
+	}
+	return fmt.Sprintf("%d %s", size, UnitJEDEC.label(index))
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (s *SizeFlag) UnmarshalText(text []byte) error {
+	return s.Set(string(text))
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (s SizeFlag) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// DurationFlag is a duration implementing flag.Value and encoding.TextUnmarshaler,
+// it's parsed by ParseDuration, e.g. "7d" or "1w12h", and formatted by FormatDuration.
+type DurationFlag time.Duration
+
+// Set implements flag.Value.
+func (d *DurationFlag) Set(text string) error {
+	duration, err := ParseDuration(text)
+	if err != nil {
+		return fmt.Errorf("invalid duration %q, the accepted units are %s", text, durationUnitsHelp)
+	}
+	*d = DurationFlag(duration)
+	return nil
+}
+
+// String implements flag.Value, the result can be parsed by Set.
+func (d *DurationFlag) String() string {
+	if d == nil {
+		return "0s"
+	}
+	return FormatDuration(time.Duration(*d))
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (d *DurationFlag) UnmarshalText(text []byte) error {
+	return d.Set(string(text))
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (d DurationFlag) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// SizeValue sets *p to def and returns a flag.Value setting *p, it binds a flag to an
+// existing field such as rotate.Option.MaxSize:
+//
+//	flag.Var(lib.SizeValue(&option.MaxSize, lib.GB), "max-size", "rotate the file at this size")
+func SizeValue(p *int64, def int64) flag.Value {
+	*p = def
+	return (*SizeFlag)(p)
+}
+
+// DurationValue is like SizeValue, but for a duration such as rotate.Option.MaxAge.
+func DurationValue(p *time.Duration, def time.Duration) flag.Value {
+	*p = def
+	return (*DurationFlag)(p)
+}
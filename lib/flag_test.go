@@ -0,0 +1,135 @@
+package lib
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSizeFlag(t *testing.T) {
+	cases := []struct {
+		text   string
+		size   int64
+		string string
+	}{
+		{"0", 0, "0 B"},
+		{"1025", 1025, "1025 B"},
+		{"256MB", 256 * MB, "256 MB"},
+		{"1.5 GiB", 1536 * MB, "1536 MB"},
+		{"1024k", MB, "1 MB"},
+		{"2 EB", 2 * EB, "2 EB"},
+	}
+	for _, c := range cases {
+		t.Run(c.text, func(t *testing.T) {
+			var s SizeFlag
+			require.NoError(t, s.Set(c.text))
+			require.Equal(t, SizeFlag(c.size), s)
+			require.Equal(t, c.string, s.String())
+			// round trip
+			var again SizeFlag
+			require.NoError(t, again.Set(s.String()))
+			require.Equal(t, s, again)
+		})
+	}
+
+	var s SizeFlag = 7
+	for _, text := range []string{"1 XB", "-1 KB", "MB"} {
+		err := s.Set(text)
+		require.ErrorContains(t, err, fmt.Sprintf("%q", text))
+		require.ErrorContains(t, err, "K/KB/KiB")
+	}
+	require.Equal(t, SizeFlag(7), s)
+	require.Equal(t, "0 B", (*SizeFlag)(nil).String())
+}
+
+func TestDurationFlag(t *testing.T) {
+	cases := []struct {
+		text     string
+		duration time.Duration
+		string   string
+	}{
+		{"0", 0, "0s"},
+		{"7d", 7 * Day, "7d"},
+		{"1w12h", 7*Day + 12*time.Hour, "7d12h"},
+		{"90m", 90 * time.Minute, "1h30m"},
+		{"1.5s", 1500 * time.Millisecond, "1s500ms"},
+	}
+	for _, c := range cases {
+		t.Run(c.text, func(t *testing.T) {
+			var d DurationFlag
+			require.NoError(t, d.Set(c.text))
+			require.Equal(t, DurationFlag(c.duration), d)
+			require.Equal(t, c.string, d.String())
+			var again DurationFlag
+			require.NoError(t, again.Set(d.String()))
+			require.Equal(t, d, again)
+		})
+	}
+
+	var d DurationFlag
+	err := d.Set("7 days")
+	require.ErrorContains(t, err, `"7 days"`)
+	require.ErrorContains(t, err, "d (24h), w (7d)")
+	require.Equal(t, "0s", (*DurationFlag)(nil).String())
+}
+
+func TestFlagText(t *testing.T) {
+	var config struct {
+		MaxSize   SizeFlag     `json:"max_size"`
+		Retention DurationFlag `json:"retention"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(`{"max_size":"256MB","retention":"2w"}`), &config))
+	require.Equal(t, SizeFlag(256*MB), config.MaxSize)
+	require.Equal(t, DurationFlag(14*Day), config.Retention)
+
+	data, err := json.Marshal(config)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"max_size":"256 MB","retention":"14d"}`, string(data))
+
+	err = json.Unmarshal([]byte(`{"max_size":"lots"}`), &config)
+	require.ErrorContains(t, err, "accepted units")
+}
+
+func TestSizeValue(t *testing.T) {
+	var option struct {
+		MaxSize int64
+		MaxAge  time.Duration
+	}
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	fs.Var(SizeValue(&option.MaxSize, GB), "max-size", "")
+	fs.Var(DurationValue(&option.MaxAge, Day), "max-age", "")
+	require.Equal(t, GB, option.MaxSize)
+	require.Equal(t, Day, option.MaxAge)
+	require.Equal(t, "1 GB", fs.Lookup("max-size").DefValue)
+	require.Equal(t, "1d", fs.Lookup("max-age").DefValue)
+
+	require.NoError(t, fs.Parse([]string{"-max-size", "10MB", "-max-age=30d"}))
+	require.Equal(t, 10*MB, option.MaxSize)
+	require.Equal(t, 30*Day, option.MaxAge)
+
+	err := fs.Parse([]string{"-max-size", "ten"})
+	require.ErrorContains(t, err, "accepted units")
+}
+
+func ExampleSizeFlag() {
+	var (
+		maxSize   SizeFlag = SizeFlag(GB)
+		retention DurationFlag
+	)
+	fs := flag.NewFlagSet("app", flag.ExitOnError)
+	fs.Var(&maxSize, "max-size", "rotate the log file at this size")
+	fs.Var(&retention, "retention", "remove the backups older than this")
+	_ = fs.Parse([]string{"-max-size", "256MB", "-retention", "7d"})
+
+	fmt.Println(int64(maxSize), time.Duration(retention))
+	fmt.Println(maxSize.String(), retention.String())
+	// Output:
+	// 268435456 168h0m0s
+	// 256 MB 7d
+}
@@ -0,0 +1,125 @@
+package lib
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/stkali/utility/errors"
+)
+
+// groupError holds the errors of a GoGroup, errors.Is and errors.As match any of them.
+type groupError []error
+
+func (g groupError) Error() string {
+	msgs := make([]string, len(g))
+	for i, err := range g {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "\n")
+}
+
+func (g groupError) Is(target error) bool {
+	for _, err := range g {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+func (g groupError) As(target any) bool {
+	for _, err := range g {
+		if errors.As(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// SafeGo runs fn in a new goroutine, a panic of fn is recovered and passed to onPanic
+// with the stack trace instead of crashing the process, see errors.Recover. A nil
+// onPanic prints the panic to os.Stderr.
+func SafeGo(fn func(), onPanic func(recovered any, stack []byte)) {
+	go func() {
+		var p *errors.PanicError
+		if !errors.As(safeCall(fn), &p) {
+			return
+		}
+		stack := []byte(p.Tracer.String())
+		if onPanic == nil {
+			_, _ = fmt.Fprintf(os.Stderr, "panic: %v\n%s", p.Value, stack)
+			return
+		}
+		onPanic(p.Value, stack)
+	}()
+}
+
+// safeCall calls fn, a panic is returned as an *errors.PanicError.
+func safeCall(fn func()) (err error) {
+	defer errors.Recover(&err)
+	fn()
+	return nil
+}
+
+// GoGroup runs functions in goroutines and collects their errors, a panic is
+// converted to an *errors.PanicError, see errors.Safely. The zero GoGroup is ready to use and has no limit
+// of concurrency.
+type GoGroup struct {
+	wg   sync.WaitGroup
+	mtx  sync.Mutex
+	errs []error
+	sem  chan struct{}
+}
+
+// SetLimit limits the number of functions running at once to n, n <= 0 removes the
+// limit. It must not be called while functions are running.
+func (g *GoGroup) SetLimit(n int) {
+	if n <= 0 {
+		g.sem = nil
+		return
+	}
+	g.sem = make(chan struct{}, n)
+}
+
+// Go runs fn in a new goroutine, it blocks while the limit of concurrency is reached.
+func (g *GoGroup) Go(fn func() error) {
+	if g.sem != nil {
+		g.sem <- struct{}{}
+	}
+	g.wg.Add(1)
+	go func() {
+		defer func() {
+			if g.sem != nil {
+				<-g.sem
+			}
+			g.wg.Done()
+		}()
+		g.record(errors.Safely(fn))
+	}()
+}
+
+// record keeps err if it is not nil.
+func (g *GoGroup) record(err error) {
+	if err == nil {
+		return
+	}
+	g.mtx.Lock()
+	g.errs = append(g.errs, err)
+	g.mtx.Unlock()
+}
+
+// Wait blocks until all functions have returned, and returns their errors joined
+// in the order they occurred, nil if all succeeded.
+func (g *GoGroup) Wait() error {
+	g.wg.Wait()
+	g.mtx.Lock()
+	defer g.mtx.Unlock()
+	errs := g.errs
+	g.errs = nil
+	if len(errs) == 0 {
+		return nil
+	}
+	return groupError(errs)
+}
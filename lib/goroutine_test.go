@@ -0,0 +1,94 @@
+package lib
+
+import (
+	"io/fs"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stkali/utility/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSafeGo(t *testing.T) {
+	t.Run("panic", func(t *testing.T) {
+		done := make(chan struct{})
+		var recovered any
+		var stack []byte
+		SafeGo(func() {
+			panic("boom")
+		}, func(r any, s []byte) {
+			recovered, stack = r, s
+			close(done)
+		})
+		<-done
+		require.Equal(t, "boom", recovered)
+		require.Contains(t, string(stack), "TestSafeGo")
+	})
+	t.Run("no panic", func(t *testing.T) {
+		done := make(chan struct{})
+		SafeGo(func() {
+			close(done)
+		}, func(any, []byte) {
+			t.Error("unexpected panic")
+		})
+		<-done
+	})
+}
+
+func TestGoGroup(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		var g GoGroup
+		var count int32
+		for i := 0; i < 10; i++ {
+			g.Go(func() error {
+				atomic.AddInt32(&count, 1)
+				return nil
+			})
+		}
+		require.NoError(t, g.Wait())
+		require.Equal(t, int32(10), count)
+	})
+	t.Run("errors", func(t *testing.T) {
+		var g GoGroup
+		g.Go(func() error { return fs.ErrNotExist })
+		g.Go(func() error { return nil })
+		g.Go(func() error { return fs.ErrPermission })
+		err := g.Wait()
+		require.ErrorIs(t, err, fs.ErrNotExist)
+		require.ErrorIs(t, err, fs.ErrPermission)
+		// the errors are reset by Wait
+		require.NoError(t, g.Wait())
+	})
+	t.Run("panic", func(t *testing.T) {
+		var g GoGroup
+		g.Go(func() error { panic(fs.ErrClosed) })
+		err := g.Wait()
+		var panicErr *errors.PanicError
+		require.True(t, errors.As(err, &panicErr))
+		require.Equal(t, fs.ErrClosed, panicErr.Value)
+		require.NotEmpty(t, panicErr.Tracer.String())
+		require.ErrorIs(t, err, fs.ErrClosed)
+	})
+	t.Run("limit", func(t *testing.T) {
+		var g GoGroup
+		g.SetLimit(3)
+		var running, peak int32
+		for i := 0; i < 20; i++ {
+			g.Go(func() error {
+				n := atomic.AddInt32(&running, 1)
+				for {
+					p := atomic.LoadInt32(&peak)
+					if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+						break
+					}
+				}
+				time.Sleep(5 * time.Millisecond)
+				atomic.AddInt32(&running, -1)
+				return nil
+			})
+		}
+		require.NoError(t, g.Wait())
+		require.Equal(t, int32(3), peak)
+	})
+}
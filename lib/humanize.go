@@ -0,0 +1,128 @@
+package lib
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// compactUnits are the suffixes of Compact, from the largest.
+var compactUnits = []struct {
+	suffix string
+	value  int64
+}{
+	{"T", 1e12},
+	{"B", 1e9},
+	{"M", 1e6},
+	{"K", 1e3},
+}
+
+// insertCommas inserts a comma every 3 digits of the integer digits, a leading sign is kept.
+func insertCommas(digits string) string {
+	sign := ""
+	if digits != "" && (digits[0] == '-' || digits[0] == '+') {
+		sign, digits = digits[:1], digits[1:]
+	}
+	if len(digits) <= 3 {
+		return sign + digits
+	}
+	sb := GetBuilder()
+	defer PutBuilder(sb)
+	sb.WriteString(sign)
+	head := len(digits) % 3
+	if head == 0 {
+		head = 3
+	}
+	sb.WriteString(digits[:head])
+	for i := head; i < len(digits); i += 3 {
+		sb.WriteByte(',')
+		sb.WriteString(digits[i : i+3])
+	}
+	return sb.String()
+}
+
+// Comma returns n with thousands separators, e.g. 1234567 gives "1,234,567".
+func Comma(n int64) string {
+	return insertCommas(strconv.FormatInt(n, 10))
+}
+
+// CommaFloat returns f with prec decimals and thousands separators, e.g.
+// CommaFloat(1234.567, 2) gives "1,234.57". NaN and infinities are formatted as is.
+func CommaFloat(f float64, prec int) string {
+	text := strconv.FormatFloat(f, 'f', prec, 64)
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return text
+	}
+	integer, fraction := text, ""
+	if i := strings.IndexByte(text, '.'); i >= 0 {
+		integer, fraction = text[:i], text[i:]
+	}
+	return insertCommas(integer) + fraction
+}
+
+// Compact returns n in a short form with one decimal and a K (thousand), M (million),
+// B (billion) or T (trillion) suffix, e.g. 1234567 gives "1.2M". Numbers under a
+// thousand are returned as is.
+func Compact(n int64) string {
+	sign := ""
+	// math.MinInt64 cannot be negated, work on the unsigned magnitude
+	u := uint64(n)
+	if n < 0 {
+		sign, u = "-", -u
+	}
+	if u < 1000 {
+		return sign + strconv.FormatUint(u, 10)
+	}
+	for i, unit := range compactUnits {
+		if u < uint64(unit.value) {
+			continue
+		}
+		value := math.Round(float64(u)/float64(unit.value)*10) / 10
+		// 999950 rounds to 1000.0K, use the larger unit instead
+		if value >= 1000 && i > 0 {
+			unit = compactUnits[i-1]
+			value = math.Round(float64(u)/float64(unit.value)*10) / 10
+		}
+		return sign + strconv.FormatFloat(value, 'f', 1, 64) + unit.suffix
+	}
+	return sign + strconv.FormatUint(u, 10)
+}
+
+// ParseCompact parses a number written by Compact or Comma, e.g. "1.2M", "-3k" or
+// "1,234". The suffixes K, M, B and T are case-insensitive, the result is rounded to
+// the nearest integer.
+func ParseCompact(s string) (int64, error) {
+	text := strings.ReplaceAll(strings.TrimSpace(s), ",", "")
+	if text == "" {
+		return 0, fmt.Errorf("invalid compact number: %q", s)
+	}
+	multiple := int64(1)
+	last := text[len(text)-1]
+	for _, unit := range compactUnits {
+		if last == unit.suffix[0] || last == unit.suffix[0]+'a'-'A' {
+			multiple = unit.value
+			text = text[:len(text)-1]
+			break
+		}
+	}
+	if text == "" || text[len(text)-1] == ' ' {
+		return 0, fmt.Errorf("invalid compact number: %q", s)
+	}
+	// integers are parsed exactly, float64 can't represent all of them
+	if i, err := strconv.ParseInt(text, 10, 64); err == nil {
+		if (i > 0 && i > math.MaxInt64/multiple) || (i < 0 && i < math.MinInt64/multiple) {
+			return 0, fmt.Errorf("compact number overflows int64: %q", s)
+		}
+		return i * multiple, nil
+	}
+	f, err := strconv.ParseFloat(text, 64)
+	if err != nil || math.IsNaN(f) || math.IsInf(f, 0) || strings.ContainsAny(text, "xXpP_") {
+		return 0, fmt.Errorf("invalid compact number: %q", s)
+	}
+	f = math.Round(f * float64(multiple))
+	if f >= math.MaxInt64 || f < math.MinInt64 {
+		return 0, fmt.Errorf("compact number overflows int64: %q", s)
+	}
+	return int64(f), nil
+}
@@ -0,0 +1,128 @@
+package lib
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestComma(t *testing.T) {
+	cases := []struct {
+		Number int64
+		Expect string
+	}{
+		{0, "0"},
+		{999, "999"},
+		{1000, "1,000"},
+		{-1000, "-1,000"},
+		{1234567, "1,234,567"},
+		{-123456, "-123,456"},
+		{math.MaxInt64, "9,223,372,036,854,775,807"},
+		{math.MinInt64, "-9,223,372,036,854,775,808"},
+	}
+	for _, c := range cases {
+		t.Run(c.Expect, func(t *testing.T) {
+			require.Equal(t, c.Expect, Comma(c.Number))
+			n, err := ParseCompact(c.Expect)
+			require.NoError(t, err)
+			require.Equal(t, c.Number, n)
+		})
+	}
+}
+
+func TestCommaFloat(t *testing.T) {
+	require.Equal(t, "1,234.57", CommaFloat(1234.567, 2))
+	require.Equal(t, "-1,234,567.0", CommaFloat(-1234567, 1))
+	require.Equal(t, "0.500", CommaFloat(0.5, 3))
+	require.Equal(t, "1,000", CommaFloat(999.9, 0))
+	require.Equal(t, "NaN", CommaFloat(math.NaN(), 2))
+	require.Equal(t, "+Inf", CommaFloat(math.Inf(1), 2))
+}
+
+func TestCompact(t *testing.T) {
+	cases := []struct {
+		Number int64
+		Expect string
+	}{
+		{0, "0"},
+		{999, "999"},
+		{-999, "-999"},
+		{1000, "1.0K"},
+		{1234, "1.2K"},
+		{999949, "999.9K"},
+		{999950, "1.0M"},
+		{1234567, "1.2M"},
+		{-1250000, "-1.3M"},
+		{3e9, "3.0B"},
+		{7.5e12, "7.5T"},
+		{math.MaxInt64, "9223372.0T"},
+		{math.MinInt64, "-9223372.0T"},
+	}
+	for _, c := range cases {
+		t.Run(c.Expect, func(t *testing.T) {
+			require.Equal(t, c.Expect, Compact(c.Number))
+		})
+	}
+}
+
+func TestParseCompact(t *testing.T) {
+	cases := []struct {
+		Text   string
+		Expect int64
+	}{
+		{"0", 0},
+		{"42", 42},
+		{"-42", -42},
+		{"1.2M", 1200000},
+		{"1.2m", 1200000},
+		{" 3k ", 3000},
+		{"-1.5B", -1500000000},
+		{"2T", 2e12},
+		{"0.0005K", 1},
+		{"9223372036854775807", math.MaxInt64},
+	}
+	for _, c := range cases {
+		t.Run(c.Text, func(t *testing.T) {
+			n, err := ParseCompact(c.Text)
+			require.NoError(t, err)
+			require.Equal(t, c.Expect, n)
+		})
+	}
+
+	for _, text := range []string{"", "K", "1 K", "abc", "1.2X", "NaN", "inf", "0x10", "9223372036854775808", "10000000T", "1e30"} {
+		_, err := ParseCompact(text)
+		require.Error(t, err, text)
+	}
+}
+
+func TestCompactRoundTrip(t *testing.T) {
+	for _, n := range []int64{0, 1, -1, 999, 1000, 1049, 123456, -98765432, 5e11, 4e15, math.MaxInt64 / 2} {
+		parsed, err := ParseCompact(Compact(n))
+		require.NoError(t, err)
+		// Compact keeps 1 decimal of the unit, the error is at most 5%
+		require.InDelta(t, float64(n), float64(parsed), math.Abs(float64(n))*0.05+1)
+	}
+}
+
+func FuzzParseCompact(f *testing.F) {
+	for _, seed := range []string{"0", "1.2M", "-3k", "1,234", "9223372036854775807", "1e3", ".5K", "--1"} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, text string) {
+		n, err := ParseCompact(text)
+		if err != nil {
+			return
+		}
+		parsed, err := ParseCompact(Comma(n))
+		require.NoError(t, err)
+		require.Equal(t, n, parsed)
+		if n != math.MinInt64 && (n > math.MaxInt64/2 || n < math.MinInt64/2) {
+			// the rounded compact form may overflow near the limits
+			return
+		}
+		parsed, err = ParseCompact(Compact(n))
+		require.NoError(t, err)
+		require.InDelta(t, float64(n), float64(parsed), math.Abs(float64(n))*0.05+1)
+	})
+}
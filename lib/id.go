@@ -0,0 +1,148 @@
+package lib
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+)
+
+const (
+	// idTimeWidth is the number of base62 digits of the millisecond timestamp of NewID,
+	// 62^8 milliseconds cover about 6900 years.
+	idTimeWidth = 8
+	// idRandWidth is the number of random base62 digits of NewID, about 71 bits.
+	idRandWidth = 12
+)
+
+// idGenerator keeps the last ID to make NewID monotonic.
+var idGenerator struct {
+	sync.Mutex
+	ms     int64
+	random [idRandWidth]byte
+}
+
+// idNow is the clock of NewID.
+var idNow = time.Now
+
+// NewID returns a 20 characters ID made of a base62 millisecond timestamp followed by
+// 12 random base62 digits, like a ULID. The IDs sort lexicographically in the order of
+// creation: IDs generated within the same millisecond, or while the clock goes back,
+// increment the random part of the previous one.
+func NewID() string {
+	g := &idGenerator
+	g.Lock()
+	defer g.Unlock()
+	ms := idNow().UnixMilli()
+	if ms > g.ms {
+		g.ms = ms
+		randomDigits(g.random[:])
+	} else if !incrementDigits(g.random[:]) {
+		// the random part overflowed, borrow the next millisecond
+		g.ms++
+		randomDigits(g.random[:])
+	}
+	var id [idTimeWidth + idRandWidth]byte
+	for i, t := idTimeWidth-1, g.ms; i >= 0; i-- {
+		id[i] = Base62[t%62]
+		t /= 62
+	}
+	for i, d := range g.random {
+		id[idTimeWidth+i] = Base62[d]
+	}
+	return string(id[:])
+}
+
+// randomDigits fills digits with random base62 digit values, the first one is kept
+// below 31 to leave room for the increments.
+func randomDigits(digits []byte) {
+	text, err := SecureRandString(len(digits), Base62)
+	if err != nil {
+		text = RandStringCharset(len(digits), Base62)
+	}
+	for i := range digits {
+		d, _ := base62Digit(text[i])
+		digits[i] = byte(d)
+	}
+	digits[0] %= 31
+}
+
+// incrementDigits adds 1 to the base62 number of digits, it returns false on overflow.
+func incrementDigits(digits []byte) bool {
+	for i := len(digits) - 1; i >= 0; i-- {
+		if digits[i] < 61 {
+			digits[i]++
+			return true
+		}
+		digits[i] = 0
+	}
+	return false
+}
+
+// base62Big is 62 as a big.Int.
+var base62Big = big.NewInt(62)
+
+// Base62Encode encodes b with the digits 0-9, A-Z and a-z, each leading zero byte is
+// encoded as a leading '0' so that Base62Decode restores b exactly.
+func Base62Encode(b []byte) string {
+	zeros := 0
+	for zeros < len(b) && b[zeros] == 0 {
+		zeros++
+	}
+	n := new(big.Int).SetBytes(b[zeros:])
+	var digits []byte
+	mod := new(big.Int)
+	for n.Sign() > 0 {
+		n.DivMod(n, base62Big, mod)
+		digits = append(digits, Base62[mod.Int64()])
+	}
+	for i := 0; i < zeros; i++ {
+		digits = append(digits, '0')
+	}
+	Reverse(digits)
+	return string(digits)
+}
+
+// Base62Decode decodes a string encoded by Base62Encode.
+func Base62Decode(s string) ([]byte, error) {
+	zeros := 0
+	for zeros < len(s) && s[zeros] == '0' {
+		zeros++
+	}
+	n := new(big.Int)
+	for i := zeros; i < len(s); i++ {
+		d, ok := base62Digit(s[i])
+		if !ok {
+			return nil, fmt.Errorf("invalid base62 character %q at %d", s[i], i)
+		}
+		n.Mul(n, base62Big)
+		n.Add(n, big.NewInt(d))
+	}
+	return append(make([]byte, zeros), n.Bytes()...), nil
+}
+
+// base62Digit returns the value of the base62 digit c.
+func base62Digit(c byte) (int64, bool) {
+	switch {
+	case c >= '0' && c <= '9':
+		return int64(c - '0'), true
+	case c >= 'A' && c <= 'Z':
+		return int64(c-'A') + 10, true
+	case c >= 'a' && c <= 'z':
+		return int64(c-'a') + 36, true
+	}
+	return 0, false
+}
+
+// ShortHash returns the first n hex characters of the sha256 of s, n <= 0 or n > 64
+// returns the whole 64 characters.
+func ShortHash(s string, n int) string {
+	sum := sha256.Sum256([]byte(s))
+	text := hex.EncodeToString(sum[:])
+	if n <= 0 || n > len(text) {
+		return text
+	}
+	return text[:n]
+}
@@ -0,0 +1,121 @@
+package lib
+
+import (
+	"bytes"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewID(t *testing.T) {
+	id := NewID()
+	require.Len(t, id, 20)
+	for _, c := range id {
+		require.Contains(t, Base62, string(c))
+	}
+	require.NotEqual(t, id, NewID())
+}
+
+func TestNewIDMonotonic(t *testing.T) {
+	origin := idNow
+	defer func() { idNow = origin }()
+	now := time.Unix(1700000000, 0)
+	idNow = func() time.Time { return now }
+
+	// the same millisecond
+	ids := make([]string, 1000)
+	for i := range ids {
+		ids[i] = NewID()
+	}
+	require.True(t, sort.StringsAreSorted(ids))
+
+	// the clock goes back
+	now = now.Add(-time.Second)
+	back := NewID()
+	require.Greater(t, back, ids[len(ids)-1])
+
+	// a later millisecond
+	now = now.Add(time.Hour)
+	later := NewID()
+	require.Greater(t, later, back)
+}
+
+func TestNewIDConcurrent(t *testing.T) {
+	var mtx sync.Mutex
+	var wg sync.WaitGroup
+	seen := make(map[string]struct{})
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			previous := ""
+			for j := 0; j < 1000; j++ {
+				id := NewID()
+				// the IDs of a goroutine are created in order
+				if id <= previous {
+					t.Errorf("%s is not greater than %s", id, previous)
+				}
+				previous = id
+				mtx.Lock()
+				seen[id] = struct{}{}
+				mtx.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	require.Len(t, seen, 8000)
+}
+
+func TestIncrementDigits(t *testing.T) {
+	digits := []byte{0, 61, 61}
+	require.True(t, incrementDigits(digits))
+	require.Equal(t, []byte{1, 0, 0}, digits)
+	digits = []byte{61, 61}
+	require.False(t, incrementDigits(digits))
+	require.Equal(t, []byte{0, 0}, digits)
+}
+
+func TestBase62(t *testing.T) {
+	cases := []struct {
+		Name   string
+		Bytes  []byte
+		Expect string
+	}{
+		{"empty", []byte{}, ""},
+		{"zero", []byte{0}, "0"},
+		{"leading zeros", []byte{0, 0, 1}, "001"},
+		{"one byte", []byte{61}, "z"},
+		{"carry", []byte{62}, "10"},
+		{"text", []byte("hello"), "7tQLFHz"},
+	}
+	for _, c := range cases {
+		t.Run(c.Name, func(t *testing.T) {
+			text := Base62Encode(c.Bytes)
+			require.Equal(t, c.Expect, text)
+			b, err := Base62Decode(text)
+			require.NoError(t, err)
+			require.True(t, bytes.Equal(c.Bytes, b))
+		})
+	}
+
+	for i := 0; i < 100; i++ {
+		b := RandBytes(i)
+		decoded, err := Base62Decode(Base62Encode(b))
+		require.NoError(t, err)
+		require.True(t, bytes.Equal(b, decoded))
+	}
+
+	_, err := Base62Decode("abc-")
+	require.Error(t, err)
+}
+
+func TestShortHash(t *testing.T) {
+	full := "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"
+	require.Equal(t, full, ShortHash("hello", 0))
+	require.Equal(t, full, ShortHash("hello", 100))
+	require.Equal(t, "2cf24dba", ShortHash("hello", 8))
+	require.Equal(t, "e3b0c442", ShortHash("", 8))
+}
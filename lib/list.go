@@ -0,0 +1,99 @@
+package lib
+
+import (
+	"errors"
+	"flag"
+	"strings"
+	"unicode"
+)
+
+var (
+	UnterminatedQuoteError = errors.New("unterminated quote")
+	TrailingEscapeError    = errors.New("trailing backslash")
+)
+
+// SplitAndTrim splits s by sep, trims the whitespaces of the parts and drops the empty
+// ones, e.g. SplitAndTrim(" a, ,b ,", ",") returns ["a" "b"]. It never returns nil.
+func SplitAndTrim(s, sep string) []string {
+	parts := strings.Split(s, sep)
+	ret := make([]string, 0, len(parts))
+	for _, part := range parts {
+		if part = strings.TrimSpace(part); part != "" {
+			ret = append(ret, part)
+		}
+	}
+	return ret
+}
+
+// SplitQuoted splits s into words separated by whitespaces like a shell: the double quotes
+// group words, a backslash escapes the next character, and "" is an empty word. e.g.
+// SplitQuoted(`a "b c" d\ e`) returns ["a" "b c" "d e"].
+func SplitQuoted(s string) ([]string, error) {
+	words := []string{}
+	var word strings.Builder
+	inWord, quoted, escaped := false, false, false
+	for _, r := range s {
+		switch {
+		case escaped:
+			word.WriteRune(r)
+			escaped = false
+		case r == '\\':
+			inWord, escaped = true, true
+		case r == '"':
+			inWord, quoted = true, !quoted
+		case !quoted && unicode.IsSpace(r):
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			inWord = true
+			word.WriteRune(r)
+		}
+	}
+	switch {
+	case escaped:
+		return nil, TrailingEscapeError
+	case quoted:
+		return nil, UnterminatedQuoteError
+	case inWord:
+		words = append(words, word.String())
+	}
+	return words, nil
+}
+
+// JoinNonEmpty joins the non-empty parts with sep.
+func JoinNonEmpty(sep string, parts ...string) string {
+	return strings.Join(Filter(parts, func(part string) bool {
+		return part != ""
+	}), sep)
+}
+
+// StringList is a flag.Value collecting strings, the flag may be repeated and its values
+// may be comma-separated: "-tag a -tag b,c" gives ["a" "b" "c"], e.g.
+//
+//	var tags lib.StringList
+//	flag.Var(&tags, "tag", "tags of the build")
+type StringList []string
+
+var _ flag.Getter = (*StringList)(nil)
+
+// String implements flag.Value.
+func (l *StringList) String() string {
+	if l == nil {
+		return ""
+	}
+	return strings.Join(*l, ",")
+}
+
+// Set implements flag.Value, it appends the comma-separated values of value.
+func (l *StringList) Set(value string) error {
+	*l = append(*l, SplitAndTrim(value, ",")...)
+	return nil
+}
+
+// Get implements flag.Getter.
+func (l *StringList) Get() any {
+	return []string(*l)
+}
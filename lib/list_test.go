@@ -0,0 +1,84 @@
+package lib
+
+import (
+	"flag"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitAndTrim(t *testing.T) {
+	cases := []struct {
+		Name   string
+		Text   string
+		Sep    string
+		Expect []string
+	}{
+		{"empty", "", ",", []string{}},
+		{"single", "a", ",", []string{"a"}},
+		{"spaces", " a , b ,c ", ",", []string{"a", "b", "c"}},
+		{"trailing separators", "a,b,,", ",", []string{"a", "b"}},
+		{"only separators", ",, ,", ",", []string{}},
+		{"multi-char separator", "a::b:: c", "::", []string{"a", "b", "c"}},
+	}
+	for _, c := range cases {
+		t.Run(c.Name, func(t *testing.T) {
+			require.Equal(t, c.Expect, SplitAndTrim(c.Text, c.Sep))
+		})
+	}
+}
+
+func TestSplitQuoted(t *testing.T) {
+	cases := []struct {
+		Name   string
+		Text   string
+		Expect []string
+	}{
+		{"empty", "", []string{}},
+		{"blank", "  \t ", []string{}},
+		{"words", "a b  c", []string{"a", "b", "c"}},
+		{"quoted", `a "b c" d`, []string{"a", "b c", "d"}},
+		{"quoted separator", `"a,b" "c d"`, []string{"a,b", "c d"}},
+		{"adjacent quote", `pre"fix suf"fix`, []string{"prefix suffix"}},
+		{"empty quoted", `a "" b`, []string{"a", "", "b"}},
+		{"escaped space", `d\ e`, []string{"d e"}},
+		{"escaped quote", `say \"hi\"`, []string{"say", `"hi"`}},
+		{"escape in quotes", `"a \" b"`, []string{`a " b`}},
+		{"escaped backslash", `a\\b`, []string{`a\b`}},
+		{"unicode", "日本 \"語 文\"", []string{"日本", "語 文"}},
+	}
+	for _, c := range cases {
+		t.Run(c.Name, func(t *testing.T) {
+			words, err := SplitQuoted(c.Text)
+			require.NoError(t, err)
+			require.Equal(t, c.Expect, words)
+		})
+	}
+
+	_, err := SplitQuoted(`a "b c`)
+	require.ErrorIs(t, err, UnterminatedQuoteError)
+	_, err = SplitQuoted(`a b\`)
+	require.ErrorIs(t, err, TrailingEscapeError)
+}
+
+func TestJoinNonEmpty(t *testing.T) {
+	require.Equal(t, "", JoinNonEmpty(","))
+	require.Equal(t, "", JoinNonEmpty(",", "", ""))
+	require.Equal(t, "a,c", JoinNonEmpty(",", "a", "", "c"))
+	require.Equal(t, "a c", JoinNonEmpty(" ", "", "a", "c", ""))
+}
+
+func TestStringList(t *testing.T) {
+	var tags StringList
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	fs.Var(&tags, "tag", "tags")
+	require.NoError(t, fs.Parse([]string{"-tag", "a", "-tag", "b, c", "-tag", ",", "-tag=d"}))
+	require.Equal(t, StringList{"a", "b", "c", "d"}, tags)
+	require.Equal(t, "a,b,c,d", tags.String())
+	require.Equal(t, []string{"a", "b", "c", "d"}, tags.Get())
+
+	var empty *StringList
+	require.Equal(t, "", empty.String())
+}
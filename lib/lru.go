@@ -0,0 +1,222 @@
+package lib
+
+import (
+	"sync"
+	"time"
+)
+
+// lruEntry is an entry of LRU, it is linked in the recency list.
+type lruEntry[K comparable, V any] struct {
+	key        K
+	value      V
+	expires    time.Time
+	prev, next *lruEntry[K, V]
+}
+
+// lruCall is an in-flight computation of GetOrCompute.
+type lruCall[V any] struct {
+	done  chan struct{}
+	value V
+	err   error
+}
+
+// LRU is a least recently used cache with optional per-entry TTL, it is safe for concurrent use.
+// The entries are kept in a map and linked in a doubly-linked list from the most to the
+// least recently used, without extra allocation.
+type LRU[K comparable, V any] struct {
+	mtx      sync.Mutex
+	capacity int
+	items    map[K]*lruEntry[K, V]
+	// head is the most recently used entry and tail the least recently used
+	head, tail *lruEntry[K, V]
+	calls      map[K]*lruCall[V]
+	onEvict    func(key K, value V)
+	now        func() time.Time
+}
+
+// NewLRU returns an LRU holding at most capacity entries, capacity <= 0 means no limit.
+func NewLRU[K comparable, V any](capacity int) *LRU[K, V] {
+	size := capacity
+	if size < 0 {
+		size = 0
+	}
+	return &LRU[K, V]{
+		capacity: capacity,
+		items:    make(map[K]*lruEntry[K, V], size),
+		calls:    make(map[K]*lruCall[V]),
+		now:      time.Now,
+	}
+}
+
+// SetOnEvict sets the function called with the entries dropped because the cache is
+// full or they have expired, it is not called for Delete and overwritten entries.
+// fn is called without holding the lock of the cache.
+func (l *LRU[K, V]) SetOnEvict(fn func(key K, value V)) {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	l.onEvict = fn
+}
+
+// Get returns the value of key and marks it as the most recently used.
+func (l *LRU[K, V]) Get(key K) (V, bool) {
+	l.mtx.Lock()
+	value, ok, expired := l.get(key)
+	onEvict := l.onEvict
+	l.mtx.Unlock()
+	l.evict(onEvict, expired)
+	return value, ok
+}
+
+// get looks up key with the lock held, an expired entry is removed and returned.
+func (l *LRU[K, V]) get(key K) (value V, ok bool, expired *lruEntry[K, V]) {
+	e, ok := l.items[key]
+	if !ok {
+		return value, false, nil
+	}
+	if !e.expires.IsZero() && !l.now().Before(e.expires) {
+		l.remove(e)
+		return value, false, e
+	}
+	l.moveToFront(e)
+	return e.value, true, nil
+}
+
+// Put sets the value of key without expiration.
+func (l *LRU[K, V]) Put(key K, value V) {
+	l.PutTTL(key, value, 0)
+}
+
+// PutTTL sets the value of key, it expires after ttl, ttl <= 0 means never.
+// The least recently used entry is evicted if the cache is full.
+func (l *LRU[K, V]) PutTTL(key K, value V, ttl time.Duration) {
+	l.mtx.Lock()
+	evicted := l.put(key, value, ttl)
+	onEvict := l.onEvict
+	l.mtx.Unlock()
+	l.evict(onEvict, evicted)
+}
+
+// put sets the value of key with the lock held, and returns the evicted entry.
+func (l *LRU[K, V]) put(key K, value V, ttl time.Duration) (evicted *lruEntry[K, V]) {
+	var expires time.Time
+	if ttl > 0 {
+		expires = l.now().Add(ttl)
+	}
+	if e, ok := l.items[key]; ok {
+		e.value, e.expires = value, expires
+		l.moveToFront(e)
+		return nil
+	}
+	if l.capacity > 0 && len(l.items) >= l.capacity {
+		evicted = l.tail
+		l.remove(evicted)
+	}
+	e := &lruEntry[K, V]{key: key, value: value, expires: expires}
+	l.items[key] = e
+	l.pushFront(e)
+	return evicted
+}
+
+// Delete removes key, it reports whether key was present.
+func (l *LRU[K, V]) Delete(key K) bool {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	e, ok := l.items[key]
+	if ok {
+		l.remove(e)
+	}
+	return ok
+}
+
+// Len returns the number of entries, including the expired ones not removed yet.
+func (l *LRU[K, V]) Len() int {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	return len(l.items)
+}
+
+// GetOrCompute returns the value of key, computing and storing it with fn on a miss.
+// Concurrent misses of the same key wait for a single call of fn and share its result,
+// the value is not stored if fn fails.
+func (l *LRU[K, V]) GetOrCompute(key K, fn func() (V, error)) (V, error) {
+	l.mtx.Lock()
+	value, ok, expired := l.get(key)
+	onEvict := l.onEvict
+	if ok {
+		l.mtx.Unlock()
+		return value, nil
+	}
+	if call, ok := l.calls[key]; ok {
+		l.mtx.Unlock()
+		l.evict(onEvict, expired)
+		<-call.done
+		return call.value, call.err
+	}
+	call := &lruCall[V]{done: make(chan struct{})}
+	l.calls[key] = call
+	l.mtx.Unlock()
+	l.evict(onEvict, expired)
+
+	defer func() {
+		l.mtx.Lock()
+		delete(l.calls, key)
+		var evicted *lruEntry[K, V]
+		if call.err == nil {
+			evicted = l.put(key, call.value, 0)
+		}
+		onEvict := l.onEvict
+		l.mtx.Unlock()
+		close(call.done)
+		l.evict(onEvict, evicted)
+	}()
+	call.value, call.err = fn()
+	return call.value, call.err
+}
+
+// evict calls onEvict with e if both are set.
+func (l *LRU[K, V]) evict(onEvict func(key K, value V), e *lruEntry[K, V]) {
+	if e != nil && onEvict != nil {
+		onEvict(e.key, e.value)
+	}
+}
+
+// pushFront links e as the most recently used entry.
+func (l *LRU[K, V]) pushFront(e *lruEntry[K, V]) {
+	e.prev, e.next = nil, l.head
+	if l.head != nil {
+		l.head.prev = e
+	}
+	l.head = e
+	if l.tail == nil {
+		l.tail = e
+	}
+}
+
+// unlink removes e from the recency list.
+func (l *LRU[K, V]) unlink(e *lruEntry[K, V]) {
+	if e.prev != nil {
+		e.prev.next = e.next
+	} else {
+		l.head = e.next
+	}
+	if e.next != nil {
+		e.next.prev = e.prev
+	} else {
+		l.tail = e.prev
+	}
+	e.prev, e.next = nil, nil
+}
+
+// moveToFront marks e as the most recently used entry.
+func (l *LRU[K, V]) moveToFront(e *lruEntry[K, V]) {
+	if l.head != e {
+		l.unlink(e)
+		l.pushFront(e)
+	}
+}
+
+// remove drops e from the cache.
+func (l *LRU[K, V]) remove(e *lruEntry[K, V]) {
+	l.unlink(e)
+	delete(l.items, e.key)
+}
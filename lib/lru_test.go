@@ -0,0 +1,157 @@
+package lib
+
+import (
+	"errors"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLRU(t *testing.T) {
+	l := NewLRU[string, int](2)
+	var evicted []string
+	l.SetOnEvict(func(key string, value int) {
+		evicted = append(evicted, key)
+	})
+
+	_, ok := l.Get("a")
+	require.False(t, ok)
+	l.Put("a", 1)
+	l.Put("b", 2)
+	require.Equal(t, 2, l.Len())
+
+	// "a" becomes the most recently used, "b" is evicted
+	v, ok := l.Get("a")
+	require.True(t, ok)
+	require.Equal(t, 1, v)
+	l.Put("c", 3)
+	require.Equal(t, []string{"b"}, evicted)
+	_, ok = l.Get("b")
+	require.False(t, ok)
+
+	// overwriting refreshes without eviction
+	l.Put("a", 10)
+	v, _ = l.Get("a")
+	require.Equal(t, 10, v)
+	require.Equal(t, 2, l.Len())
+
+	require.True(t, l.Delete("a"))
+	require.False(t, l.Delete("a"))
+	require.Equal(t, 1, l.Len())
+	require.Equal(t, []string{"b"}, evicted)
+}
+
+func TestLRUUnlimited(t *testing.T) {
+	l := NewLRU[int, int](0)
+	for i := 0; i < 1000; i++ {
+		l.Put(i, i)
+	}
+	require.Equal(t, 1000, l.Len())
+}
+
+func TestLRUTTL(t *testing.T) {
+	l := NewLRU[string, int](10)
+	now := time.Unix(0, 0)
+	l.now = func() time.Time { return now }
+	var evicted []string
+	l.SetOnEvict(func(key string, value int) {
+		evicted = append(evicted, key)
+	})
+
+	l.PutTTL("short", 1, time.Second)
+	l.PutTTL("long", 2, time.Minute)
+	l.Put("forever", 3)
+
+	now = now.Add(999 * time.Millisecond)
+	_, ok := l.Get("short")
+	require.True(t, ok)
+
+	now = now.Add(time.Millisecond)
+	_, ok = l.Get("short")
+	require.False(t, ok)
+	require.Equal(t, []string{"short"}, evicted)
+	require.Equal(t, 2, l.Len())
+
+	now = now.Add(time.Hour)
+	_, ok = l.Get("long")
+	require.False(t, ok)
+	v, ok := l.Get("forever")
+	require.True(t, ok)
+	require.Equal(t, 3, v)
+
+	// an expired entry is computed again
+	l.PutTTL("computed", 1, time.Second)
+	now = now.Add(time.Second)
+	v, err := l.GetOrCompute("computed", func() (int, error) { return 5, nil })
+	require.NoError(t, err)
+	require.Equal(t, 5, v)
+}
+
+func TestLRUGetOrCompute(t *testing.T) {
+	l := NewLRU[string, int](10)
+
+	t.Run("single flight", func(t *testing.T) {
+		var calls int32
+		release := make(chan struct{})
+		var wg sync.WaitGroup
+		results := make([]int, 10)
+		for i := range results {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				v, err := l.GetOrCompute("key", func() (int, error) {
+					atomic.AddInt32(&calls, 1)
+					<-release
+					return 42, nil
+				})
+				require.NoError(t, err)
+				results[i] = v
+			}(i)
+		}
+		time.Sleep(20 * time.Millisecond)
+		close(release)
+		wg.Wait()
+		require.Equal(t, int32(1), calls)
+		for _, v := range results {
+			require.Equal(t, 42, v)
+		}
+		v, ok := l.Get("key")
+		require.True(t, ok)
+		require.Equal(t, 42, v)
+	})
+	t.Run("error", func(t *testing.T) {
+		errCompute := errors.New("compute error")
+		_, err := l.GetOrCompute("failed", func() (int, error) { return 0, errCompute })
+		require.ErrorIs(t, err, errCompute)
+		_, ok := l.Get("failed")
+		require.False(t, ok)
+	})
+}
+
+func BenchmarkLRUGetHit(b *testing.B) {
+	l := NewLRU[int, int](1024)
+	for i := 0; i < 1024; i++ {
+		l.Put(i, i)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		l.Get(i & 1023)
+	}
+}
+
+func BenchmarkLRUGetMiss(b *testing.B) {
+	l := NewLRU[string, int](1024)
+	for i := 0; i < 1024; i++ {
+		l.Put(strconv.Itoa(i), i)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		l.Get("missing")
+	}
+}
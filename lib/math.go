@@ -1,5 +1,30 @@
 package lib
 
+import "fmt"
+
+// Signed is a constraint that permits any signed integer type.
+type Signed interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64
+}
+
+// Unsigned is a constraint that permits any unsigned integer type.
+type Unsigned interface {
+	~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr
+}
+
+// Float is a constraint that permits any floating-point type.
+type Float interface {
+	~float32 | ~float64
+}
+
+// Real is a constraint that permits any integer or floating-point type.
+type Real interface {
+	Signed | Unsigned | Float
+}
+
+// Ordered is a constraint that permits any type supporting the operators < <= >= >.
+type Ordered = Number
+
 type Number interface {
 	~int | ~int8 | ~int16 | ~int32 | ~int64 |
 		~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr |
@@ -34,3 +59,70 @@ func Min[T Number](values ...T) (ret T) {
 	}
 	return
 }
+
+// MinOf returns the smallest of first and rest, unlike Min it needs at least one value.
+func MinOf[T Ordered](first T, rest ...T) T {
+	for _, v := range rest {
+		if v < first {
+			first = v
+		}
+	}
+	return first
+}
+
+// MaxOf returns the largest of first and rest, unlike Max it needs at least one value.
+func MaxOf[T Ordered](first T, rest ...T) T {
+	for _, v := range rest {
+		if v > first {
+			first = v
+		}
+	}
+	return first
+}
+
+// Clamp returns v bounded to [lo, hi], lo and hi are swapped if lo > hi.
+func Clamp[T Ordered](v, lo, hi T) T {
+	if lo > hi {
+		lo, hi = hi, lo
+	}
+	switch {
+	case v < lo:
+		return lo
+	case v > hi:
+		return hi
+	}
+	return v
+}
+
+// Abs returns the absolute value of v. It panics for the smallest value of a signed
+// integer type, e.g. math.MinInt64, whose absolute value doesn't fit in the type.
+func Abs[T Signed | Float](v T) T {
+	if v >= 0 {
+		return v
+	}
+	if -v < 0 {
+		panic(fmt.Sprintf("lib.Abs: absolute value of %v overflows %T", v, v))
+	}
+	return -v
+}
+
+// Sum returns the sum of values, 0 for an empty slice. Integers may overflow.
+func Sum[T Real](values []T) (ret T) {
+	for _, v := range values {
+		ret += v
+	}
+	return
+}
+
+// Mean returns the arithmetic mean of values, 0 for an empty slice.
+// The values are summed as float64 to avoid integer overflow.
+func Mean[T Real](values []T) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sum := 0.0
+	for _, v := range values {
+		sum += float64(v)
+	}
+	return sum / float64(len(values))
+}
@@ -2,6 +2,7 @@ package lib
 
 import (
 	"github.com/stretchr/testify/require"
+	"math"
 	"testing"
 )
 
@@ -43,3 +44,54 @@ func TestMin(t *testing.T) {
 	// empty
 	require.Equal(t, 0, Min([]int{}...))
 }
+
+func TestMinOfMaxOf(t *testing.T) {
+	require.Equal(t, 3, MinOf(3))
+	require.Equal(t, 3, MaxOf(3))
+	require.Equal(t, -2, MinOf(3, -2, 7))
+	require.Equal(t, 7, MaxOf(3, -2, 7))
+	require.Equal(t, 0.5, MinOf(1.5, 0.5))
+	require.Equal(t, "b", MaxOf("a", "b"))
+}
+
+func TestClamp(t *testing.T) {
+	require.Equal(t, 5, Clamp(5, 0, 10))
+	require.Equal(t, 0, Clamp(-5, 0, 10))
+	require.Equal(t, 10, Clamp(15, 0, 10))
+	// lo and hi are swapped
+	require.Equal(t, 10, Clamp(15, 10, 0))
+	require.Equal(t, 0, Clamp(-1, 10, 0))
+	require.Equal(t, 1.5, Clamp(1.5, 1.0, 2.0))
+	require.Equal(t, 3, Clamp(9, 3, 3))
+}
+
+func TestAbs(t *testing.T) {
+	require.Equal(t, 3, Abs(-3))
+	require.Equal(t, 3, Abs(3))
+	require.Equal(t, 0, Abs(0))
+	require.Equal(t, 2.5, Abs(-2.5))
+	require.Equal(t, int64(math.MaxInt64), Abs(int64(-math.MaxInt64)))
+	require.Equal(t, math.Inf(1), Abs(math.Inf(-1)))
+	require.PanicsWithValue(t, "lib.Abs: absolute value of -9223372036854775808 overflows int64", func() {
+		Abs(int64(math.MinInt64))
+	})
+	require.Panics(t, func() {
+		Abs(int8(math.MinInt8))
+	})
+}
+
+func TestSum(t *testing.T) {
+	require.Equal(t, 0, Sum([]int{}))
+	require.Equal(t, 0, Sum[int](nil))
+	require.Equal(t, 6, Sum([]int{1, 2, 3}))
+	require.Equal(t, uint8(255), Sum([]uint8{200, 55}))
+	require.InDelta(t, 0.6, Sum([]float64{0.1, 0.2, 0.3}), 1e-9)
+}
+
+func TestMean(t *testing.T) {
+	require.Equal(t, 0.0, Mean([]int{}))
+	require.Equal(t, 0.0, Mean[float64](nil))
+	require.Equal(t, 2.0, Mean([]int{1, 2, 3}))
+	require.Equal(t, 2.5, Mean([]int{2, 3}))
+	require.Equal(t, float64(math.MaxInt64), Mean([]int64{math.MaxInt64, math.MaxInt64}))
+}
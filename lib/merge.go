@@ -0,0 +1,164 @@
+package lib
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// SliceStrategy selects how MergeMaps merges two slices at the same key.
+type SliceStrategy int
+
+const (
+	// SliceReplace replaces the slice of dst by the slice of src.
+	SliceReplace SliceStrategy = iota
+	// SliceAppend appends the items of src to the slice of dst.
+	SliceAppend
+	// SliceUniqueAppend appends the items of src missing from the slice of dst.
+	SliceUniqueAppend
+)
+
+// defaultMergeDepth is the depth of MergeMaps when MergeOption.MaxDepth is 0.
+const defaultMergeDepth = 32
+
+// MergeOption configures MergeMaps.
+type MergeOption struct {
+	// Slices is the strategy of slices of the same type at the same key.
+	Slices SliceStrategy
+	// NilDeletes makes a nil value of src delete the key from dst, by default
+	// a nil value is set like any other value.
+	NilDeletes bool
+	// MaxDepth(default: 32) is the depth of nested maps beyond which the maps are not
+	// merged anymore but replaced by src, it protects from cyclic maps.
+	MaxDepth int
+}
+
+// MergeMaps returns dst deeply merged with src, the values of src win. The nested
+// map[string]any at the same key are merged recursively, the slices of the same type are
+// merged according to opt.Slices, and otherwise, including a map and a scalar at the same
+// key, the value of src replaces the value of dst. dst and src are not modified, but the
+// result shares the values which didn't need to be merged.
+func MergeMaps(dst, src map[string]any, opt MergeOption) map[string]any {
+	if opt.MaxDepth <= 0 {
+		opt.MaxDepth = defaultMergeDepth
+	}
+	return mergeMaps(dst, src, &opt, 1)
+}
+
+// mergeMaps merges src into a copy of dst at depth.
+func mergeMaps(dst, src map[string]any, opt *MergeOption, depth int) map[string]any {
+	ret := make(map[string]any, len(dst)+len(src))
+	for k, v := range dst {
+		ret[k] = v
+	}
+	for k, v := range src {
+		if v == nil && opt.NilDeletes {
+			delete(ret, k)
+			continue
+		}
+		current, ok := ret[k]
+		if !ok {
+			ret[k] = v
+			continue
+		}
+		ret[k] = mergeValue(current, v, opt, depth)
+	}
+	return ret
+}
+
+// mergeValue returns the merge of the values of dst and src at the same key.
+func mergeValue(dst, src any, opt *MergeOption, depth int) any {
+	if dm, ok := dst.(map[string]any); ok {
+		if sm, ok := src.(map[string]any); ok && depth < opt.MaxDepth {
+			return mergeMaps(dm, sm, opt, depth+1)
+		}
+		return src
+	}
+	if opt.Slices == SliceReplace {
+		return src
+	}
+	dv, sv := reflect.ValueOf(dst), reflect.ValueOf(src)
+	if dv.Kind() != reflect.Slice || sv.Kind() != reflect.Slice || dv.Type() != sv.Type() {
+		return src
+	}
+	ret := reflect.MakeSlice(dv.Type(), 0, dv.Len()+sv.Len())
+	ret = reflect.AppendSlice(ret, dv)
+	for i := 0; i < sv.Len(); i++ {
+		item := sv.Index(i)
+		if opt.Slices == SliceUniqueAppend && containsValue(ret, item) {
+			continue
+		}
+		ret = reflect.Append(ret, item)
+	}
+	return ret.Interface()
+}
+
+// containsValue reports whether the slice s holds a value deeply equal to v.
+func containsValue(s, v reflect.Value) bool {
+	for i := 0; i < s.Len(); i++ {
+		if reflect.DeepEqual(s.Index(i).Interface(), v.Interface()) {
+			return true
+		}
+	}
+	return false
+}
+
+var InvalidMergeError = errors.New("invalid merge arguments")
+
+// MergeInto merges the exported fields of the struct src into the struct dst points to,
+// src may be a struct or a pointer to a struct of the same type. The non-zero fields of
+// src win, the nested structs are merged field by field, and the fields tagged
+// `merge:"skip"` are left unchanged, e.g. a default configuration overridden by the
+// fields set by the user:
+//
+//	cfg := defaultConfig
+//	err := lib.MergeInto(&cfg, userConfig)
+func MergeInto(dst any, src any) error {
+	dv := reflect.ValueOf(dst)
+	if dv.Kind() != reflect.Pointer || dv.IsNil() || dv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("%w: dst must be a non-nil pointer to a struct, got %T", InvalidMergeError, dst)
+	}
+	sv := reflect.ValueOf(src)
+	if sv.Kind() == reflect.Pointer {
+		if sv.IsNil() {
+			return nil
+		}
+		sv = sv.Elem()
+	}
+	if sv.Type() != dv.Elem().Type() {
+		return fmt.Errorf("%w: src must be a %s, got %T", InvalidMergeError, dv.Elem().Type(), src)
+	}
+	mergeStruct(dv.Elem(), sv)
+	return nil
+}
+
+// mergeStruct sets the non-zero exported fields of src to dst.
+func mergeStruct(dst, src reflect.Value) {
+	typ := dst.Type()
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if !field.IsExported() || field.Tag.Get("merge") == "skip" {
+			continue
+		}
+		sf := src.Field(i)
+		if sf.IsZero() {
+			continue
+		}
+		if field.Type.Kind() == reflect.Struct && hasExportedField(field.Type) {
+			mergeStruct(dst.Field(i), sf)
+			continue
+		}
+		dst.Field(i).Set(sf)
+	}
+}
+
+// hasExportedField reports whether the struct type has an exported field, the structs
+// without, e.g. time.Time, are merged as a whole.
+func hasExportedField(typ reflect.Type) bool {
+	for i := 0; i < typ.NumField(); i++ {
+		if typ.Field(i).IsExported() {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,176 @@
+package lib
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMergeMaps(t *testing.T) {
+	dst := map[string]any{
+		"name": "default",
+		"port": 80,
+		"log": map[string]any{
+			"level": "info",
+			"file":  "app.log",
+		},
+		"tags":  []any{"a", "b"},
+		"keep":  true,
+		"shape": map[string]any{"x": 1},
+	}
+	src := map[string]any{
+		"port": 8080,
+		"log": map[string]any{
+			"level": "debug",
+			"rotate": map[string]any{
+				"size": "10MB",
+			},
+		},
+		"tags":  []any{"b", "c"},
+		"shape": "circle",
+		"extra": nil,
+	}
+
+	t.Run("replace", func(t *testing.T) {
+		merged := MergeMaps(dst, src, MergeOption{})
+		require.Equal(t, map[string]any{
+			"name": "default",
+			"port": 8080,
+			"log": map[string]any{
+				"level":  "debug",
+				"file":   "app.log",
+				"rotate": map[string]any{"size": "10MB"},
+			},
+			"tags":  []any{"b", "c"},
+			"keep":  true,
+			"shape": "circle",
+			"extra": nil,
+		}, merged)
+		// the inputs are not modified
+		require.Equal(t, "info", dst["log"].(map[string]any)["level"])
+		require.Equal(t, []any{"a", "b"}, dst["tags"])
+	})
+	t.Run("append", func(t *testing.T) {
+		merged := MergeMaps(dst, src, MergeOption{Slices: SliceAppend})
+		require.Equal(t, []any{"a", "b", "b", "c"}, merged["tags"])
+		require.Equal(t, []any{"a", "b"}, dst["tags"])
+	})
+	t.Run("unique append", func(t *testing.T) {
+		merged := MergeMaps(dst, src, MergeOption{Slices: SliceUniqueAppend})
+		require.Equal(t, []any{"a", "b", "c"}, merged["tags"])
+		merged = MergeMaps(
+			map[string]any{"ports": []int{80, 443}},
+			map[string]any{"ports": []int{443, 8080}},
+			MergeOption{Slices: SliceUniqueAppend},
+		)
+		require.Equal(t, []int{80, 443, 8080}, merged["ports"])
+	})
+	t.Run("slice type conflict", func(t *testing.T) {
+		merged := MergeMaps(
+			map[string]any{"ports": []int{80}},
+			map[string]any{"ports": []string{"http"}},
+			MergeOption{Slices: SliceAppend},
+		)
+		require.Equal(t, []string{"http"}, merged["ports"])
+	})
+	t.Run("map replaced by scalar and back", func(t *testing.T) {
+		merged := MergeMaps(map[string]any{"a": 1}, map[string]any{"a": map[string]any{"b": 2}}, MergeOption{})
+		require.Equal(t, map[string]any{"a": map[string]any{"b": 2}}, merged)
+	})
+	t.Run("nil deletes", func(t *testing.T) {
+		merged := MergeMaps(
+			map[string]any{"a": 1, "b": map[string]any{"c": 2, "d": 3}},
+			map[string]any{"a": nil, "b": map[string]any{"c": nil}, "e": nil},
+			MergeOption{NilDeletes: true},
+		)
+		require.Equal(t, map[string]any{"b": map[string]any{"d": 3}}, merged)
+	})
+	t.Run("nil maps", func(t *testing.T) {
+		require.Equal(t, map[string]any{}, MergeMaps(nil, nil, MergeOption{}))
+		require.Equal(t, map[string]any{"a": 1}, MergeMaps(nil, map[string]any{"a": 1}, MergeOption{}))
+		require.Equal(t, map[string]any{"a": 1}, MergeMaps(map[string]any{"a": 1}, nil, MergeOption{}))
+	})
+	t.Run("cycle", func(t *testing.T) {
+		cyclicDst := map[string]any{}
+		cyclicDst["self"] = cyclicDst
+		cyclicSrc := map[string]any{"value": 1}
+		cyclicSrc["self"] = cyclicSrc
+		merged := MergeMaps(cyclicDst, cyclicSrc, MergeOption{MaxDepth: 5})
+		require.Equal(t, 1, merged["value"])
+		// the nested maps are merged up to the max depth, then src wins
+		m := merged
+		for depth := 1; depth < 5; depth++ {
+			m = m["self"].(map[string]any)
+			require.NotEqual(t, reflect.ValueOf(cyclicSrc).Pointer(), reflect.ValueOf(m).Pointer())
+		}
+		require.Equal(t, reflect.ValueOf(cyclicSrc).Pointer(), reflect.ValueOf(m["self"]).Pointer())
+	})
+}
+
+type mergeLog struct {
+	Level string
+	File  string
+}
+
+type mergeConfig struct {
+	Name    string
+	Port    int
+	Debug   bool
+	Timeout time.Duration
+	Start   time.Time
+	Log     mergeLog
+	Tags    []string
+	Secret  string `merge:"skip"`
+	Limit   *int
+	private int
+}
+
+func TestMergeInto(t *testing.T) {
+	limit := 10
+	start := time.Unix(1700000000, 0)
+	dst := mergeConfig{
+		Name:    "default",
+		Port:    80,
+		Timeout: time.Second,
+		Log:     mergeLog{Level: "info", File: "app.log"},
+		Tags:    []string{"a"},
+		Secret:  "keep",
+		private: 1,
+	}
+	src := mergeConfig{
+		Port:    8080,
+		Debug:   true,
+		Start:   start,
+		Log:     mergeLog{Level: "debug"},
+		Secret:  "ignored",
+		Limit:   &limit,
+		private: 2,
+	}
+	require.NoError(t, MergeInto(&dst, src))
+	require.Equal(t, mergeConfig{
+		Name:    "default",
+		Port:    8080,
+		Debug:   true,
+		Timeout: time.Second,
+		Start:   start,
+		Log:     mergeLog{Level: "debug", File: "app.log"},
+		Tags:    []string{"a"},
+		Secret:  "keep",
+		Limit:   &limit,
+		private: 1,
+	}, dst)
+
+	// a pointer src
+	require.NoError(t, MergeInto(&dst, &mergeConfig{Name: "pointer"}))
+	require.Equal(t, "pointer", dst.Name)
+	require.NoError(t, MergeInto(&dst, (*mergeConfig)(nil)))
+	require.Equal(t, "pointer", dst.Name)
+
+	require.ErrorIs(t, MergeInto(dst, src), InvalidMergeError)
+	require.ErrorIs(t, MergeInto((*mergeConfig)(nil), src), InvalidMergeError)
+	require.ErrorIs(t, MergeInto(&dst, mergeLog{}), InvalidMergeError)
+	n := 1
+	require.ErrorIs(t, MergeInto(&n, 2), InvalidMergeError)
+}
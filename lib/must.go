@@ -0,0 +1,25 @@
+package lib
+
+import "fmt"
+
+// Must returns v, it panics if err is not nil. The panic value is an error
+// wrapping err, so a recover handler can inspect it with errors.Is and errors.As.
+// It is meant for initialization code, e.g.
+//
+//	file := lib.Must(rotate.NewRotatingFile(path))
+func Must[T any](v T, err error) T {
+	Must0(err)
+	return v
+}
+
+// Must0 panics with an error wrapping err if err is not nil.
+func Must0(err error) {
+	if err != nil {
+		panic(fmt.Errorf("must: %w", err))
+	}
+}
+
+// Ensure returns v, or fallback if v is the zero value.
+func Ensure[T comparable](v T, fallback T) T {
+	return If(IsZero(v), fallback, v)
+}
@@ -0,0 +1,56 @@
+package lib
+
+import (
+	"errors"
+	"io/fs"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// recoverError calls fn and returns the recovered panic value as an error.
+func recoverError(t *testing.T, fn func()) (err error) {
+	defer func() {
+		r := recover()
+		require.NotNil(t, r)
+		var ok bool
+		err, ok = r.(error)
+		require.True(t, ok)
+	}()
+	fn()
+	return nil
+}
+
+func TestMust(t *testing.T) {
+	require.Equal(t, 1, Must(1, nil))
+	require.Equal(t, "text", Must("text", nil))
+
+	err := recoverError(t, func() {
+		Must(0, fs.ErrNotExist)
+	})
+	require.ErrorIs(t, err, fs.ErrNotExist)
+	require.Equal(t, fs.ErrNotExist, errors.Unwrap(err))
+}
+
+func TestMust0(t *testing.T) {
+	require.NotPanics(t, func() {
+		Must0(nil)
+	})
+	err := recoverError(t, func() {
+		Must0(&fs.PathError{Op: "open", Path: "file", Err: fs.ErrPermission})
+	})
+	require.ErrorIs(t, err, fs.ErrPermission)
+	var pathErr *fs.PathError
+	require.True(t, errors.As(err, &pathErr))
+	require.Equal(t, "file", pathErr.Path)
+}
+
+func TestEnsure(t *testing.T) {
+	require.Equal(t, 3, Ensure(0, 3))
+	require.Equal(t, 1, Ensure(1, 3))
+	require.Equal(t, "default", Ensure("", "default"))
+	require.Equal(t, "value", Ensure("value", "default"))
+	var p *int
+	v := 1
+	require.Equal(t, &v, Ensure(p, &v))
+}
@@ -0,0 +1,197 @@
+package lib
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// Option is an optional value of T, either Some value or None.
+// The zero Option is None.
+type Option[T any] struct {
+	value T
+	ok    bool
+}
+
+// Some returns an Option holding v.
+func Some[T any](v T) Option[T] {
+	return Option[T]{value: v, ok: true}
+}
+
+// None returns an empty Option.
+func None[T any]() Option[T] {
+	return Option[T]{}
+}
+
+// IsSome reports whether o holds a value.
+func (o Option[T]) IsSome() bool {
+	return o.ok
+}
+
+// IsNone reports whether o is empty.
+func (o Option[T]) IsNone() bool {
+	return !o.ok
+}
+
+// Get returns the value of o and whether o holds one.
+func (o Option[T]) Get() (T, bool) {
+	return o.value, o.ok
+}
+
+// GetOr returns the value of o, or def if o is None.
+func (o Option[T]) GetOr(def T) T {
+	if o.ok {
+		return o.value
+	}
+	return def
+}
+
+// Map returns Some of fn applied to the value of o, or None if o is None.
+// See MapOption to change the type of the value.
+func (o Option[T]) Map(fn func(T) T) Option[T] {
+	return MapOption(o, fn)
+}
+
+// MapOption returns Some of fn applied to the value of o, or None if o is None.
+func MapOption[T, U any](o Option[T], fn func(T) U) Option[U] {
+	if !o.ok {
+		return None[U]()
+	}
+	return Some(fn(o.value))
+}
+
+// String implements fmt.Stringer, it returns "Some(value)" or "None".
+func (o Option[T]) String() string {
+	if !o.ok {
+		return "None"
+	}
+	return fmt.Sprintf("Some(%v)", o.value)
+}
+
+// MarshalJSON implements json.Marshaler, None is null and Some is its value.
+func (o Option[T]) MarshalJSON() ([]byte, error) {
+	if !o.ok {
+		return []byte("null"), nil
+	}
+	return json.Marshal(o.value)
+}
+
+// UnmarshalJSON implements json.Unmarshaler, null is None and any other value is Some.
+func (o *Option[T]) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(bytes.TrimSpace(data), []byte("null")) {
+		*o = None[T]()
+		return nil
+	}
+	var v T
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	*o = Some(v)
+	return nil
+}
+
+// Result is either a value of T or an error, e.g. to send the outcome of a task through
+// a channel. The zero Result is Ok with the zero value.
+type Result[T any] struct {
+	value T
+	err   error
+}
+
+// Ok returns a successful Result holding v.
+func Ok[T any](v T) Result[T] {
+	return Result[T]{value: v}
+}
+
+// Err returns a failed Result holding err.
+func Err[T any](err error) Result[T] {
+	return Result[T]{err: err}
+}
+
+// ResultOf returns a Result from the values returned by a function, e.g.
+// lib.ResultOf(os.ReadFile(file)).
+func ResultOf[T any](v T, err error) Result[T] {
+	if err != nil {
+		return Err[T](err)
+	}
+	return Ok(v)
+}
+
+// IsOk reports whether r is successful.
+func (r Result[T]) IsOk() bool {
+	return r.err == nil
+}
+
+// Err returns the error of r, nil if r is successful.
+func (r Result[T]) Err() error {
+	return r.err
+}
+
+// Get returns the value and the error of r.
+func (r Result[T]) Get() (T, error) {
+	return r.value, r.err
+}
+
+// Unwrap returns the value of r, it panics with the error of r if it failed.
+func (r Result[T]) Unwrap() T {
+	return Must(r.value, r.err)
+}
+
+// UnwrapOr returns the value of r, or def if r failed.
+func (r Result[T]) UnwrapOr(def T) T {
+	if r.err != nil {
+		return def
+	}
+	return r.value
+}
+
+// MapErr returns r with its error replaced by fn applied to it, a successful
+// r is returned as is.
+func (r Result[T]) MapErr(fn func(error) error) Result[T] {
+	if r.err == nil {
+		return r
+	}
+	return Err[T](fn(r.err))
+}
+
+// MapResult returns Ok of fn applied to the value of r, or the error of r if it failed.
+func MapResult[T, U any](r Result[T], fn func(T) U) Result[U] {
+	if r.err != nil {
+		return Err[U](r.err)
+	}
+	return Ok(fn(r.value))
+}
+
+// resultJSON is the JSON representation of Result.
+type resultJSON[T any] struct {
+	Value *T      `json:"value,omitempty"`
+	Error *string `json:"error,omitempty"`
+}
+
+// MarshalJSON implements json.Marshaler, a successful Result is {"value": value} and
+// a failed one is {"error": "message"}.
+func (r Result[T]) MarshalJSON() ([]byte, error) {
+	if r.err != nil {
+		msg := r.err.Error()
+		return json.Marshal(resultJSON[T]{Error: &msg})
+	}
+	return json.Marshal(resultJSON[T]{Value: &r.value})
+}
+
+// UnmarshalJSON implements json.Unmarshaler, the error is restored as a plain error
+// holding the message.
+func (r *Result[T]) UnmarshalJSON(data []byte) error {
+	var v resultJSON[T]
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	switch {
+	case v.Error != nil:
+		*r = Err[T](errors.New(*v.Error))
+	case v.Value != nil:
+		*r = Ok(*v.Value)
+	default:
+		*r = Ok(Zero[T]())
+	}
+	return nil
+}
@@ -0,0 +1,140 @@
+package lib
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestOption(t *testing.T) {
+	some := Some(3)
+	require.True(t, some.IsSome())
+	require.False(t, some.IsNone())
+	v, ok := some.Get()
+	require.True(t, ok)
+	require.Equal(t, 3, v)
+	require.Equal(t, 3, some.GetOr(5))
+	require.Equal(t, Some(6), some.Map(func(v int) int { return v * 2 }))
+	require.Equal(t, Some("3"), MapOption(some, strconv.Itoa))
+	require.Equal(t, "Some(3)", some.String())
+
+	var none Option[int]
+	require.Equal(t, None[int](), none)
+	require.False(t, none.IsSome())
+	require.True(t, none.IsNone())
+	_, ok = none.Get()
+	require.False(t, ok)
+	require.Equal(t, 5, none.GetOr(5))
+	require.Equal(t, none, none.Map(func(v int) int { return v * 2 }))
+	require.Equal(t, None[string](), MapOption(none, strconv.Itoa))
+	require.Equal(t, "None", none.String())
+}
+
+func TestOptionJSON(t *testing.T) {
+	type config struct {
+		Name Option[string] `json:"name"`
+		Port Option[int]    `json:"port"`
+	}
+	data, err := json.Marshal(config{Name: Some("app")})
+	require.NoError(t, err)
+	require.Equal(t, `{"name":"app","port":null}`, string(data))
+
+	var c config
+	require.NoError(t, json.Unmarshal([]byte(`{"name":null,"port":8080}`), &c))
+	require.Equal(t, config{Port: Some(8080)}, c)
+	require.NoError(t, json.Unmarshal([]byte(`{}`), &c))
+	require.Error(t, json.Unmarshal([]byte(`{"port":"x"}`), &c))
+}
+
+func TestResult(t *testing.T) {
+	ok := Ok(3)
+	require.True(t, ok.IsOk())
+	require.NoError(t, ok.Err())
+	v, err := ok.Get()
+	require.NoError(t, err)
+	require.Equal(t, 3, v)
+	require.Equal(t, 3, ok.Unwrap())
+	require.Equal(t, 3, ok.UnwrapOr(5))
+	require.Equal(t, ok, ok.MapErr(func(err error) error { return fs.ErrClosed }))
+	require.Equal(t, Ok("3"), MapResult(ok, strconv.Itoa))
+
+	failed := Err[int](fs.ErrNotExist)
+	require.False(t, failed.IsOk())
+	require.ErrorIs(t, failed.Err(), fs.ErrNotExist)
+	_, err = failed.Get()
+	require.ErrorIs(t, err, fs.ErrNotExist)
+	require.Equal(t, 5, failed.UnwrapOr(5))
+	require.Panics(t, func() { failed.Unwrap() })
+	wrapped := failed.MapErr(func(err error) error { return fmt.Errorf("config: %w", err) })
+	require.ErrorIs(t, wrapped.Err(), fs.ErrNotExist)
+	require.Equal(t, "config: file does not exist", wrapped.Err().Error())
+	require.ErrorIs(t, MapResult(failed, strconv.Itoa).Err(), fs.ErrNotExist)
+
+	require.Equal(t, Ok(1), ResultOf(1, nil))
+	require.Equal(t, Err[int](fs.ErrClosed), ResultOf(1, fs.ErrClosed))
+}
+
+func TestResultJSON(t *testing.T) {
+	data, err := json.Marshal([]Result[int]{Ok(1), Err[int](errors.New("failed")), Ok(0)})
+	require.NoError(t, err)
+	require.Equal(t, `[{"value":1},{"error":"failed"},{"value":0}]`, string(data))
+
+	var results []Result[int]
+	require.NoError(t, json.Unmarshal(data, &results))
+	require.Len(t, results, 3)
+	require.Equal(t, 1, results[0].Unwrap())
+	require.EqualError(t, results[1].Err(), "failed")
+	require.Equal(t, 0, results[2].Unwrap())
+	require.Error(t, json.Unmarshal([]byte(`{"value":"x"}`), &results[0]))
+}
+
+// ExampleResult shows a worker pool sending the outcome of each task through a
+// single channel of Result.
+func ExampleResult() {
+	tasks := []string{"1", "2", "x", "4"}
+	results := make(chan Result[int], len(tasks))
+	var g GoGroup
+	g.SetLimit(2)
+	for _, task := range tasks {
+		task := task
+		g.Go(func() error {
+			results <- ResultOf(strconv.Atoi(task))
+			return nil
+		})
+	}
+	_ = g.Wait()
+	close(results)
+
+	sum, failed := 0, 0
+	for r := range results {
+		if v, err := r.Get(); err != nil {
+			failed++
+		} else {
+			sum += v
+		}
+	}
+	fmt.Println(sum, failed)
+	// Output: 7 1
+}
+
+// ExampleOption shows an optional setting overriding a default.
+func ExampleOption() {
+	settings := map[string]Option[int]{"retries": Some(5), "timeout": None[int]()}
+	names := make([]string, 0, len(settings))
+	for name := range settings {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Println(name, settings[name].GetOr(3))
+	}
+	// Output:
+	// retries 5
+	// timeout 3
+}
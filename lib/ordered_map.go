@@ -0,0 +1,206 @@
+package lib
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// orderedEntry is an entry of OrderedMap, it is linked in the insertion order.
+type orderedEntry[K comparable, V any] struct {
+	key        K
+	value      V
+	prev, next *orderedEntry[K, V]
+}
+
+// OrderedMap is a map keeping the insertion order of its keys. The entries are kept in
+// a map and linked in a doubly-linked list, so all operations are O(1) except the
+// iterations. The zero OrderedMap is ready to use. It is not safe for concurrent use.
+type OrderedMap[K comparable, V any] struct {
+	items      map[K]*orderedEntry[K, V]
+	head, tail *orderedEntry[K, V]
+	moveToBack bool
+}
+
+// NewOrderedMap returns an empty OrderedMap.
+func NewOrderedMap[K comparable, V any]() *OrderedMap[K, V] {
+	return &OrderedMap[K, V]{items: make(map[K]*orderedEntry[K, V])}
+}
+
+// SetMoveToBack sets whether Set moves an existing key to the back, by default
+// it keeps its position.
+func (m *OrderedMap[K, V]) SetMoveToBack(enabled bool) {
+	m.moveToBack = enabled
+}
+
+// Set sets the value of key, a new key is appended to the back.
+func (m *OrderedMap[K, V]) Set(key K, value V) {
+	if m.items == nil {
+		m.items = make(map[K]*orderedEntry[K, V])
+	}
+	if e, ok := m.items[key]; ok {
+		e.value = value
+		if m.moveToBack && m.tail != e {
+			m.unlink(e)
+			m.pushBack(e)
+		}
+		return
+	}
+	e := &orderedEntry[K, V]{key: key, value: value}
+	m.items[key] = e
+	m.pushBack(e)
+}
+
+// Get returns the value of key.
+func (m *OrderedMap[K, V]) Get(key K) (V, bool) {
+	if e, ok := m.items[key]; ok {
+		return e.value, true
+	}
+	var zero V
+	return zero, false
+}
+
+// Delete removes key, it reports whether key was present.
+func (m *OrderedMap[K, V]) Delete(key K) bool {
+	e, ok := m.items[key]
+	if ok {
+		m.unlink(e)
+		delete(m.items, key)
+	}
+	return ok
+}
+
+// Len returns the number of keys.
+func (m *OrderedMap[K, V]) Len() int {
+	return len(m.items)
+}
+
+// Keys returns the keys in order.
+func (m *OrderedMap[K, V]) Keys() []K {
+	keys := make([]K, 0, len(m.items))
+	for e := m.head; e != nil; e = e.next {
+		keys = append(keys, e.key)
+	}
+	return keys
+}
+
+// Values returns the values in the order of their keys.
+func (m *OrderedMap[K, V]) Values() []V {
+	values := make([]V, 0, len(m.items))
+	for e := m.head; e != nil; e = e.next {
+		values = append(values, e.value)
+	}
+	return values
+}
+
+// Range calls fn for each key and value in order until fn returns false. fn may
+// delete the current key.
+func (m *OrderedMap[K, V]) Range(fn func(key K, value V) bool) {
+	for e := m.head; e != nil; {
+		next := e.next
+		if !fn(e.key, e.value) {
+			return
+		}
+		e = next
+	}
+}
+
+// pushBack links e as the last entry.
+func (m *OrderedMap[K, V]) pushBack(e *orderedEntry[K, V]) {
+	e.prev, e.next = m.tail, nil
+	if m.tail != nil {
+		m.tail.next = e
+	} else {
+		m.head = e
+	}
+	m.tail = e
+}
+
+// unlink removes e from the order.
+func (m *OrderedMap[K, V]) unlink(e *orderedEntry[K, V]) {
+	if e.prev != nil {
+		e.prev.next = e.next
+	} else {
+		m.head = e.next
+	}
+	if e.next != nil {
+		e.next.prev = e.prev
+	} else {
+		m.tail = e.prev
+	}
+	e.prev, e.next = nil, nil
+}
+
+// MarshalJSON implements json.Marshaler, it writes a JSON object with the keys in order.
+// The keys are written as strings: strings and encoding.TextMarshaler as they are encoded
+// by encoding/json, and the other keys, e.g. numbers, from their JSON encoding.
+func (m *OrderedMap[K, V]) MarshalJSON() ([]byte, error) {
+	buf := &bytes.Buffer{}
+	buf.WriteByte('{')
+	for e := m.head; e != nil; e = e.next {
+		if e != m.head {
+			buf.WriteByte(',')
+		}
+		key, err := json.Marshal(e.key)
+		if err != nil {
+			return nil, err
+		}
+		if len(key) == 0 || key[0] != '"' {
+			if key, err = json.Marshal(string(key)); err != nil {
+				return nil, err
+			}
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		value, err := json.Marshal(e.value)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler, it reads a JSON object and keeps the order
+// of its keys, a duplicated key keeps its first position and its last value.
+func (m *OrderedMap[K, V]) UnmarshalJSON(data []byte) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	token, err := decoder.Token()
+	if err != nil {
+		return err
+	}
+	if token != json.Delim('{') {
+		return fmt.Errorf("invalid JSON object: %s", data)
+	}
+	items := NewOrderedMap[K, V]()
+	for decoder.More() {
+		token, err = decoder.Token()
+		if err != nil {
+			return err
+		}
+		text, _ := token.(string)
+		quoted, err := json.Marshal(text)
+		if err != nil {
+			return err
+		}
+		var key K
+		// a string key is decoded as a JSON string, e.g. for strings and encoding.TextUnmarshaler,
+		// or else as the JSON value it holds, e.g. for numbers
+		if err = json.Unmarshal(quoted, &key); err != nil {
+			if err = json.Unmarshal([]byte(text), &key); err != nil {
+				return fmt.Errorf("invalid key %q, err: %s", text, err)
+			}
+		}
+		var value V
+		if err = decoder.Decode(&value); err != nil {
+			return err
+		}
+		items.Set(key, value)
+	}
+	if _, err = decoder.Token(); err != nil {
+		return err
+	}
+	m.items, m.head, m.tail = items.items, items.head, items.tail
+	return nil
+}
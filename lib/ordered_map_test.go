@@ -0,0 +1,148 @@
+package lib
+
+import (
+	"encoding/json"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestOrderedMap(t *testing.T) {
+	var m OrderedMap[string, int]
+	require.Equal(t, 0, m.Len())
+	require.Equal(t, []string{}, m.Keys())
+	_, ok := m.Get("a")
+	require.False(t, ok)
+	require.False(t, m.Delete("a"))
+
+	m.Set("c", 3)
+	m.Set("a", 1)
+	m.Set("b", 2)
+	require.Equal(t, []string{"c", "a", "b"}, m.Keys())
+	require.Equal(t, []int{3, 1, 2}, m.Values())
+
+	// an existing key keeps its position by default
+	m.Set("c", 30)
+	require.Equal(t, []string{"c", "a", "b"}, m.Keys())
+	v, ok := m.Get("c")
+	require.True(t, ok)
+	require.Equal(t, 30, v)
+
+	m.SetMoveToBack(true)
+	m.Set("c", 300)
+	require.Equal(t, []string{"a", "b", "c"}, m.Keys())
+	m.Set("c", 3000)
+	require.Equal(t, []string{"a", "b", "c"}, m.Keys())
+
+	require.True(t, m.Delete("b"))
+	require.Equal(t, []string{"a", "c"}, m.Keys())
+	require.True(t, m.Delete("a"))
+	require.True(t, m.Delete("c"))
+	require.Equal(t, 0, m.Len())
+	m.Set("d", 4)
+	require.Equal(t, []string{"d"}, m.Keys())
+}
+
+func TestOrderedMapRange(t *testing.T) {
+	m := NewOrderedMap[int, string]()
+	for i := 0; i < 5; i++ {
+		m.Set(i, strconv.Itoa(i))
+	}
+	var keys []int
+	m.Range(func(key int, value string) bool {
+		keys = append(keys, key)
+		// deleting the current key is allowed
+		m.Delete(key)
+		return key < 2
+	})
+	require.Equal(t, []int{0, 1, 2}, keys)
+	require.Equal(t, []int{3, 4}, m.Keys())
+}
+
+func TestOrderedMapJSON(t *testing.T) {
+	m := NewOrderedMap[string, any]()
+	m.Set("zeta", 1)
+	m.Set("alpha", "text")
+	m.Set("mid", []int{1, 2})
+	data, err := json.Marshal(m)
+	require.NoError(t, err)
+	require.Equal(t, `{"zeta":1,"alpha":"text","mid":[1,2]}`, string(data))
+
+	parsed := NewOrderedMap[string, json.RawMessage]()
+	require.NoError(t, json.Unmarshal([]byte(`{"b": 1, "a": {"x": 1}, "c": null, "b": 2}`), parsed))
+	require.Equal(t, []string{"b", "a", "c"}, parsed.Keys())
+	v, _ := parsed.Get("b")
+	require.Equal(t, "2", string(v))
+
+	t.Run("empty", func(t *testing.T) {
+		var empty OrderedMap[string, int]
+		data, err := json.Marshal(&empty)
+		require.NoError(t, err)
+		require.Equal(t, "{}", string(data))
+		require.NoError(t, json.Unmarshal([]byte(`{}`), &empty))
+		require.Equal(t, 0, empty.Len())
+	})
+	t.Run("number keys", func(t *testing.T) {
+		m := NewOrderedMap[int, string]()
+		m.Set(10, "ten")
+		m.Set(-2, "minus two")
+		data, err := json.Marshal(m)
+		require.NoError(t, err)
+		require.Equal(t, `{"10":"ten","-2":"minus two"}`, string(data))
+		parsed := NewOrderedMap[int, string]()
+		require.NoError(t, json.Unmarshal(data, parsed))
+		require.Equal(t, []int{10, -2}, parsed.Keys())
+	})
+	t.Run("invalid", func(t *testing.T) {
+		m := NewOrderedMap[int, string]()
+		require.Error(t, json.Unmarshal([]byte(`[1]`), m))
+		require.Error(t, json.Unmarshal([]byte(`{"x": "y"}`), m))
+		require.Error(t, json.Unmarshal([]byte(`{"1": 2}`), m))
+	})
+}
+
+// sliceMap is the plain map and slice approach OrderedMap is compared with.
+type sliceMap struct {
+	keys  []int
+	items map[int]int
+}
+
+func (s *sliceMap) Delete(key int) {
+	delete(s.items, key)
+	for i, k := range s.keys {
+		if k == key {
+			s.keys = append(s.keys[:i], s.keys[i+1:]...)
+			return
+		}
+	}
+}
+
+func BenchmarkOrderedMapDelete(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		m := NewOrderedMap[int, int]()
+		for j := 0; j < 1000; j++ {
+			m.Set(j, j)
+		}
+		b.StartTimer()
+		for j := 0; j < 1000; j++ {
+			m.Delete(j * 7 % 1000)
+		}
+	}
+}
+
+func BenchmarkSliceMapDelete(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		m := &sliceMap{items: make(map[int]int)}
+		for j := 0; j < 1000; j++ {
+			m.keys = append(m.keys, j)
+			m.items[j] = j
+		}
+		b.StartTimer()
+		for j := 0; j < 1000; j++ {
+			m.Delete(j * 7 % 1000)
+		}
+	}
+}
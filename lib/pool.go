@@ -0,0 +1,124 @@
+package lib
+
+import (
+	"io"
+	"math/bits"
+	"strings"
+	"sync"
+)
+
+const (
+	// minPoolShift is the capacity of the smallest bucket of BytesPool, 64 B.
+	minPoolShift = 6
+	// defaultPoolShift is the capacity of the largest bucket of the default BytesPool, 16 MiB.
+	defaultPoolShift = 24
+	// maxPoolShift is the largest bucket BytesPool supports, 1 GiB.
+	maxPoolShift = 30
+	// maxBuilderCap is the largest capacity of a strings.Builder kept by PutBuilder.
+	maxBuilderCap = 64 * 1024
+	// copyBufferSize is the size of the buffer used by CopyBuffer.
+	copyBufferSize = 32 * 1024
+)
+
+// BytesPool is a pool of byte slices bucketed by power-of-two capacity, so a small
+// request never pins a huge buffer. The zero BytesPool is ready to use and keeps
+// buffers up to 16 MiB. It is safe for concurrent use.
+type BytesPool struct {
+	maxShift int
+	buckets  [maxPoolShift - minPoolShift + 1]sync.Pool
+}
+
+// NewBytesPool returns a BytesPool keeping buffers up to maxSize bytes, rounded up to
+// a power of two, between 64 B and 1 GiB.
+func NewBytesPool(maxSize int) *BytesPool {
+	return &BytesPool{maxShift: Clamp(bucketShift(maxSize), minPoolShift, maxPoolShift)}
+}
+
+// bucketShift returns the smallest shift with 1<<shift >= size.
+func bucketShift(size int) int {
+	if size <= 1 {
+		return 0
+	}
+	return bits.Len(uint(size - 1))
+}
+
+// limit returns the shift of the largest bucket.
+func (p *BytesPool) limit() int {
+	if p.maxShift == 0 {
+		return defaultPoolShift
+	}
+	return p.maxShift
+}
+
+// Get returns a buffer of length size, its capacity is size rounded up to a power of two.
+// The content of the buffer is undefined. A pointer is returned to let Put store it
+// without allocation, the buffer should be given back with Put when done.
+func (p *BytesPool) Get(size int) *[]byte {
+	if size < 0 {
+		size = 0
+	}
+	shift := MaxOf(bucketShift(size), minPoolShift)
+	if shift > p.limit() {
+		b := make([]byte, size)
+		return &b
+	}
+	if v := p.buckets[shift-minPoolShift].Get(); v != nil {
+		b := v.(*[]byte)
+		*b = (*b)[:size]
+		return b
+	}
+	b := make([]byte, size, 1<<shift)
+	return &b
+}
+
+// Put gives back a buffer to the pool, it is dropped if its capacity is too small or
+// too large to be pooled. The buffer must not be used after Put.
+func (p *BytesPool) Put(b *[]byte) {
+	if b == nil {
+		return
+	}
+	c := cap(*b)
+	if c < 1<<minPoolShift {
+		return
+	}
+	// the largest bucket whose buffers fit in b
+	shift := bits.Len(uint(c)) - 1
+	if shift > p.limit() {
+		return
+	}
+	*b = (*b)[:0]
+	p.buckets[shift-minPoolShift].Put(b)
+}
+
+// defaultBytesPool is the BytesPool used by CopyBuffer.
+var defaultBytesPool BytesPool
+
+var builderPool = sync.Pool{
+	New: func() any {
+		return &strings.Builder{}
+	},
+}
+
+// GetBuilder returns an empty strings.Builder from a pool, it should be given back
+// with PutBuilder when done.
+func GetBuilder() *strings.Builder {
+	return builderPool.Get().(*strings.Builder)
+}
+
+// PutBuilder resets sb and gives it back to the pool, a builder that has grown
+// beyond 64 KiB is dropped. sb must not be used after PutBuilder, the strings it
+// built remain valid since strings.Builder never reuses a buffer it has returned.
+func PutBuilder(sb *strings.Builder) {
+	if sb == nil || sb.Cap() > maxBuilderCap {
+		return
+	}
+	sb.Reset()
+	builderPool.Put(sb)
+}
+
+// CopyBuffer is like io.Copy, but the intermediate buffer is taken from a pool.
+func CopyBuffer(dst io.Writer, src io.Reader) (int64, error) {
+	buf := defaultBytesPool.Get(copyBufferSize)
+	defer defaultBytesPool.Put(buf)
+	return io.CopyBuffer(dst, src, *buf)
+}
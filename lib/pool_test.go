@@ -0,0 +1,134 @@
+package lib
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBytesPool(t *testing.T) {
+	var p BytesPool
+	cases := []struct {
+		Size int
+		Cap  int
+	}{
+		{0, 64},
+		{1, 64},
+		{64, 64},
+		{65, 128},
+		{1000, 1024},
+		{1 << 20, 1 << 20},
+	}
+	for _, c := range cases {
+		b := p.Get(c.Size)
+		require.Len(t, *b, c.Size)
+		require.Equal(t, c.Cap, cap(*b))
+		p.Put(b)
+	}
+
+	// buffers larger than the limit are not pooled
+	b := p.Get(1<<defaultPoolShift + 1)
+	require.Len(t, *b, 1<<defaultPoolShift+1)
+	require.Equal(t, 1<<defaultPoolShift+1, cap(*b))
+	p.Put(b)
+
+	// a buffer is put in the largest bucket it fits in
+	small := NewBytesPool(1024)
+	buf := make([]byte, 10, 1000)
+	small.Put(&buf)
+	for i := 0; i < 10; i++ {
+		got := small.Get(512)
+		require.GreaterOrEqual(t, cap(*got), 512)
+	}
+	require.NotPanics(t, func() {
+		small.Put(nil)
+		tiny := make([]byte, 10)
+		small.Put(&tiny)
+		huge := make([]byte, 4096)
+		small.Put(&huge)
+	})
+}
+
+func TestBytesPoolConcurrent(t *testing.T) {
+	p := NewBytesPool(1 << 16)
+	var wg sync.WaitGroup
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				size := (i*1000 + j) % (1 << 16)
+				b := p.Get(size)
+				if len(*b) != size {
+					t.Errorf("expected length %d, got %d", size, len(*b))
+				}
+				for k := range *b {
+					(*b)[k] = byte(i)
+				}
+				p.Put(b)
+			}
+		}(i)
+	}
+	wg.Wait()
+}
+
+func TestBuilderPool(t *testing.T) {
+	sb := GetBuilder()
+	require.Equal(t, 0, sb.Len())
+	sb.WriteString("hello")
+	s := sb.String()
+	PutBuilder(sb)
+	require.Equal(t, "hello", s)
+
+	sb = GetBuilder()
+	require.Equal(t, 0, sb.Len())
+	sb.Grow(maxBuilderCap + 1)
+	require.NotPanics(t, func() {
+		PutBuilder(sb)
+		PutBuilder(nil)
+	})
+}
+
+// plainReader and plainWriter hide ReadFrom and WriteTo to force the use of the buffer.
+type plainReader struct{ io.Reader }
+type plainWriter struct{ io.Writer }
+
+func TestCopyBuffer(t *testing.T) {
+	data := bytes.Repeat([]byte("rotate"), 20000)
+	var dst bytes.Buffer
+	n, err := CopyBuffer(plainWriter{&dst}, plainReader{bytes.NewReader(data)})
+	require.NoError(t, err)
+	require.Equal(t, int64(len(data)), n)
+	require.Equal(t, data, dst.Bytes())
+
+	_, err = CopyBuffer(plainWriter{&dst}, plainReader{iotestErrReader{}})
+	require.ErrorIs(t, err, io.ErrUnexpectedEOF)
+}
+
+type iotestErrReader struct{}
+
+func (iotestErrReader) Read([]byte) (int, error) {
+	return 0, io.ErrUnexpectedEOF
+}
+
+// rotationData simulates a rotated file copied to its backup.
+var rotationData = strings.Repeat("2024-01-01 00:00:00 INFO rotate line\n", 8192)
+
+func BenchmarkCopyBufferPooled(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_, _ = CopyBuffer(plainWriter{io.Discard}, plainReader{strings.NewReader(rotationData)})
+	}
+}
+
+func BenchmarkCopyBufferMake(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		buf := make([]byte, copyBufferSize)
+		_, _ = io.CopyBuffer(plainWriter{io.Discard}, plainReader{strings.NewReader(rotationData)}, buf)
+	}
+}
@@ -1,6 +1,8 @@
 package lib
 
 import (
+	crand "crypto/rand"
+	"encoding/binary"
 	"errors"
 	"fmt"
 	"math/rand"
@@ -32,6 +34,90 @@ func RandString(n int) string {
 	return string(b)
 }
 
+// Predefined charsets for RandStringCharset and SecureRandString.
+const (
+	Alphanumeric = letterBytes + "0123456789"
+	Hex          = "0123456789abcdef"
+	Base62       = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+	URLSafe      = Base62 + "-_"
+)
+
+var InvalidCharsetError = errors.New("invalid charset, must contain 1 to 256 bytes")
+
+// RandStringCharset returns a random string of length n consisting of bytes of charset.
+// It uses math/rand and must not be used for secrets, see SecureRandString.
+// An empty string is returned if n <= 0 or the charset is empty.
+func RandStringCharset(n int, charset string) string {
+	if n <= 0 || charset == "" {
+		return ""
+	}
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = charset[rand.Intn(len(charset))]
+	}
+	return string(b)
+}
+
+// SecureRandString returns a random string of length n consisting of bytes of charset,
+// read from crypto/rand. Every byte of charset is equally likely: the random bytes that
+// would bias the selection are rejected instead of reduced modulo the charset length.
+func SecureRandString(n int, charset string) (string, error) {
+	if charset == "" || len(charset) > 256 {
+		return "", InvalidCharsetError
+	}
+	if n <= 0 {
+		return "", nil
+	}
+	// the smallest all 1-bits mask covering the indices of the charset
+	mask := 1
+	for mask < len(charset) {
+		mask <<= 1
+	}
+	mask--
+	b := make([]byte, n)
+	// more than a half of the random bytes is accepted, read a bit more than needed
+	buf := make([]byte, n+n/2+8)
+	for i := 0; i < n; {
+		if _, err := crand.Read(buf); err != nil {
+			return "", fmt.Errorf("failed to read random bytes, err: %s", err)
+		}
+		for _, r := range buf {
+			if idx := int(r) & mask; idx < len(charset) {
+				b[i] = charset[idx]
+				if i++; i == n {
+					break
+				}
+			}
+		}
+	}
+	return string(b), nil
+}
+
+// RandBytes returns n random bytes generated by math/rand.
+// It must not be used for secrets, see SecureRandBytes.
+func RandBytes(n int) []byte {
+	if n <= 0 {
+		return []byte{}
+	}
+	b := make([]byte, n+7)
+	for i := 0; i < n; i += 8 {
+		binary.LittleEndian.PutUint64(b[i:], rand.Uint64())
+	}
+	return b[:n:n]
+}
+
+// SecureRandBytes returns n random bytes read from crypto/rand.
+func SecureRandBytes(n int) ([]byte, error) {
+	if n <= 0 {
+		return []byte{}, nil
+	}
+	b := make([]byte, n)
+	if _, err := crand.Read(b); err != nil {
+		return nil, fmt.Errorf("failed to read random bytes, err: %s", err)
+	}
+	return b, nil
+}
+
 // RandInternalString returns a random string of length between min and max, consisting
 // of visible ASCII characters only
 func RandInternalString(min, max int) string {
@@ -26,6 +26,73 @@ func TestRandString(t *testing.T) {
 	})
 }
 
+func TestRandStringCharset(t *testing.T) {
+	for _, charset := range []string{Alphanumeric, Hex, Base62, URLSafe, "x"} {
+		str := RandStringCharset(64, charset)
+		require.Len(t, str, 64)
+		for _, r := range str {
+			require.True(t, strings.ContainsRune(charset, r))
+		}
+	}
+	require.Equal(t, "", RandStringCharset(0, Hex))
+	require.Equal(t, "", RandStringCharset(10, ""))
+}
+
+func TestSecureRandString(t *testing.T) {
+	t.Run("charset", func(t *testing.T) {
+		for _, charset := range []string{Alphanumeric, Hex, Base62, URLSafe, "x"} {
+			str, err := SecureRandString(100, charset)
+			require.NoError(t, err)
+			require.Len(t, str, 100)
+			for _, r := range str {
+				require.True(t, strings.ContainsRune(charset, r))
+			}
+		}
+	})
+	t.Run("distribution", func(t *testing.T) {
+		// 3 symbols would be biased by a plain modulo of a byte
+		str, err := SecureRandString(30000, "abc")
+		require.NoError(t, err)
+		for _, r := range "abc" {
+			require.InDelta(t, 10000, strings.Count(str, string(r)), 600)
+		}
+	})
+	t.Run("invalid", func(t *testing.T) {
+		_, err := SecureRandString(10, "")
+		require.ErrorIs(t, err, InvalidCharsetError)
+		_, err = SecureRandString(10, strings.Repeat("a", 257))
+		require.ErrorIs(t, err, InvalidCharsetError)
+		str, err := SecureRandString(0, Hex)
+		require.NoError(t, err)
+		require.Equal(t, "", str)
+	})
+}
+
+func TestRandBytes(t *testing.T) {
+	for _, n := range []int{0, 1, 7, 8, 9, 100} {
+		require.Len(t, RandBytes(n), n)
+		b, err := SecureRandBytes(n)
+		require.NoError(t, err)
+		require.Len(t, b, n)
+	}
+	require.NotEqual(t, RandBytes(32), RandBytes(32))
+	b1, _ := SecureRandBytes(32)
+	b2, _ := SecureRandBytes(32)
+	require.NotEqual(t, b1, b2)
+}
+
+func BenchmarkRandStringCharset(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		RandStringCharset(32, Alphanumeric)
+	}
+}
+
+func BenchmarkSecureRandString(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		_, _ = SecureRandString(32, Alphanumeric)
+	}
+}
+
 func TestRandIntervalString(t *testing.T) {
 	for i := 0; i < 10; i++ {
 		min := rand.Intn(1024)
@@ -0,0 +1,134 @@
+package lib
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sync"
+	"time"
+)
+
+var RateLimitExceededError = errors.New("rate limit cannot be satisfied")
+
+// RateLimiter is a token bucket rate limiter: the bucket holds up to burst tokens and is
+// refilled with rate tokens per second, each event consumes a token. The tokens are
+// computed on demand, there is no background goroutine. It is safe for concurrent use.
+type RateLimiter struct {
+	mtx    sync.Mutex
+	rate   float64
+	burst  int
+	tokens float64
+	last   time.Time
+	now    func() time.Time
+}
+
+// NewRateLimiter returns a RateLimiter allowing rate events per second with bursts of
+// at most burst events, it starts with a full bucket. A rate <= 0 allows only the initial
+// burst and math.Inf(1) allows all events.
+func NewRateLimiter(rate float64, burst int) *RateLimiter {
+	if burst < 0 {
+		burst = 0
+	}
+	return &RateLimiter{
+		rate:   rate,
+		burst:  burst,
+		tokens: float64(burst),
+		last:   time.Now(),
+		now:    time.Now,
+	}
+}
+
+// Rate returns the number of events allowed per second.
+func (r *RateLimiter) Rate() float64 {
+	return r.rate
+}
+
+// Burst returns the maximum number of events allowed at once.
+func (r *RateLimiter) Burst() int {
+	return r.burst
+}
+
+// refill adds the tokens earned since the last refill, up to burst.
+func (r *RateLimiter) refill(now time.Time) {
+	if elapsed := now.Sub(r.last); elapsed > 0 && r.rate > 0 {
+		r.tokens = math.Min(float64(r.burst), r.tokens+elapsed.Seconds()*r.rate)
+	}
+	r.last = now
+}
+
+// Allow reports whether an event may happen now, and consumes a token if so.
+func (r *RateLimiter) Allow() bool {
+	return r.AllowN(1)
+}
+
+// AllowN reports whether n events may happen now, and consumes n tokens if so.
+func (r *RateLimiter) AllowN(n int) bool {
+	if math.IsInf(r.rate, 1) || n <= 0 {
+		return true
+	}
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	r.refill(r.now())
+	if r.tokens < float64(n) {
+		return false
+	}
+	r.tokens -= float64(n)
+	return true
+}
+
+// Reserve consumes a token and returns how long to wait before the event may happen.
+// ok is false, and nothing is consumed, if the event can never happen: the burst is 0
+// or the bucket is empty without refill.
+func (r *RateLimiter) Reserve() (delay time.Duration, ok bool) {
+	if math.IsInf(r.rate, 1) {
+		return 0, true
+	}
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	r.refill(r.now())
+	if r.burst < 1 || (r.rate <= 0 && r.tokens < 1) {
+		return 0, false
+	}
+	r.tokens--
+	if r.tokens >= 0 {
+		return 0, true
+	}
+	return time.Duration(-r.tokens / r.rate * float64(time.Second)), true
+}
+
+// cancel gives back a reserved token.
+func (r *RateLimiter) cancel() {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	r.refill(r.now())
+	r.tokens = math.Min(float64(r.burst), r.tokens+1)
+}
+
+// Wait blocks until an event may happen, it returns RateLimitExceededError if the event
+// can never happen or would happen after the deadline of ctx, and the context error if
+// ctx is done while waiting.
+func (r *RateLimiter) Wait(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	delay, ok := r.Reserve()
+	if !ok {
+		return RateLimitExceededError
+	}
+	if delay == 0 {
+		return nil
+	}
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < delay {
+		r.cancel()
+		return RateLimitExceededError
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		r.cancel()
+		return ctx.Err()
+	}
+}
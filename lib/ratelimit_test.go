@@ -0,0 +1,127 @@
+package lib
+
+import (
+	"context"
+	"math"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// fakeClock returns a limiter whose clock only moves through the returned advance func.
+func fakeClock(r *RateLimiter) (advance func(d time.Duration)) {
+	now := time.Unix(0, 0)
+	r.now = func() time.Time { return now }
+	r.last = now
+	return func(d time.Duration) { now = now.Add(d) }
+}
+
+func TestRateLimiterBurst(t *testing.T) {
+	r := NewRateLimiter(1, 3)
+	advance := fakeClock(r)
+	for i := 0; i < 3; i++ {
+		require.True(t, r.Allow())
+	}
+	require.False(t, r.Allow())
+	advance(time.Second)
+	require.True(t, r.Allow())
+	require.False(t, r.Allow())
+
+	advance(2 * time.Second)
+	require.False(t, r.AllowN(3))
+	require.True(t, r.AllowN(2))
+	require.True(t, r.AllowN(0))
+}
+
+func TestRateLimiterIdleRefill(t *testing.T) {
+	r := NewRateLimiter(10, 5)
+	advance := fakeClock(r)
+	require.True(t, r.AllowN(5))
+	// a long idle time refills up to the burst only
+	advance(time.Hour)
+	require.True(t, r.AllowN(5))
+	require.False(t, r.Allow())
+}
+
+func TestRateLimiterFractionalRate(t *testing.T) {
+	r := NewRateLimiter(0.5, 1)
+	advance := fakeClock(r)
+	require.True(t, r.Allow())
+	advance(time.Second)
+	require.False(t, r.Allow())
+	advance(time.Second)
+	require.True(t, r.Allow())
+
+	delay, ok := r.Reserve()
+	require.True(t, ok)
+	require.Equal(t, 2*time.Second, delay)
+	delay, ok = r.Reserve()
+	require.True(t, ok)
+	require.Equal(t, 4*time.Second, delay)
+}
+
+func TestRateLimiterLimits(t *testing.T) {
+	r := NewRateLimiter(0, 1)
+	require.True(t, r.Allow())
+	require.False(t, r.Allow())
+	_, ok := r.Reserve()
+	require.False(t, ok)
+	require.ErrorIs(t, r.Wait(context.Background()), RateLimitExceededError)
+
+	r = NewRateLimiter(10, 0)
+	require.False(t, r.Allow())
+	_, ok = r.Reserve()
+	require.False(t, ok)
+
+	r = NewRateLimiter(math.Inf(1), 0)
+	for i := 0; i < 100; i++ {
+		require.True(t, r.Allow())
+	}
+}
+
+func TestRateLimiterWait(t *testing.T) {
+	r := NewRateLimiter(20, 1)
+	require.NoError(t, r.Wait(context.Background()))
+	start := time.Now()
+	require.NoError(t, r.Wait(context.Background()))
+	require.GreaterOrEqual(t, time.Since(start), 40*time.Millisecond)
+
+	t.Run("deadline", func(t *testing.T) {
+		r := NewRateLimiter(0.01, 1)
+		require.True(t, r.Allow())
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		require.ErrorIs(t, r.Wait(ctx), RateLimitExceededError)
+	})
+	t.Run("canceled", func(t *testing.T) {
+		r := NewRateLimiter(0.01, 1)
+		advance := fakeClock(r)
+		require.True(t, r.Allow())
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(20*time.Millisecond, cancel)
+		require.ErrorIs(t, r.Wait(ctx), context.Canceled)
+		// the canceled reservation is given back
+		advance(100 * time.Second)
+		require.True(t, r.Allow())
+	})
+}
+
+func TestRateLimiterConcurrent(t *testing.T) {
+	r := NewRateLimiter(1, 50)
+	fakeClock(r)
+	var wg sync.WaitGroup
+	allowed := make(chan struct{}, 100)
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if r.Allow() {
+				allowed <- struct{}{}
+			}
+		}()
+	}
+	wg.Wait()
+	require.Len(t, allowed, 50)
+}
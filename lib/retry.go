@@ -0,0 +1,197 @@
+package lib
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"time"
+)
+
+// Backoff computes the delay to wait before retrying after the attempt-th failed attempt,
+// attempt starts at 1.
+type Backoff interface {
+	Next(attempt int) time.Duration
+}
+
+// Fixed waits the same delay between all attempts.
+type Fixed time.Duration
+
+// Next implements Backoff.
+func (f Fixed) Next(int) time.Duration {
+	return time.Duration(f)
+}
+
+// Linear waits Initial after the first attempt and Step more after each next one,
+// up to Max if Max > 0.
+type Linear struct {
+	Initial time.Duration
+	Step    time.Duration
+	Max     time.Duration
+}
+
+// Next implements Backoff.
+func (l Linear) Next(attempt int) time.Duration {
+	if attempt < 1 {
+		attempt = 1
+	}
+	d := l.Initial + time.Duration(attempt-1)*l.Step
+	if l.Max > 0 && d > l.Max {
+		return l.Max
+	}
+	return d
+}
+
+// Exponential waits Initial after the first attempt, and Factor(default: 2) times
+// longer after each next one, up to Max if Max > 0. With Jitter in (0, 1], the delay
+// is randomized in [d*(1-Jitter), d*(1+Jitter)] to spread the retries of concurrent callers.
+type Exponential struct {
+	Initial time.Duration
+	Max     time.Duration
+	Factor  float64
+	Jitter  float64
+}
+
+// Next implements Backoff.
+func (e Exponential) Next(attempt int) time.Duration {
+	if attempt < 1 {
+		attempt = 1
+	}
+	factor := e.Factor
+	if factor <= 1 {
+		factor = 2
+	}
+	d := float64(e.Initial) * math.Pow(factor, float64(attempt-1))
+	if e.Max > 0 && d > float64(e.Max) {
+		d = float64(e.Max)
+	}
+	if jitter := Min(e.Jitter, 1); jitter > 0 {
+		d += d * jitter * (2*rand.Float64() - 1)
+	}
+	if d >= math.MaxInt64 {
+		return math.MaxInt64
+	}
+	return time.Duration(d)
+}
+
+// permanentError marks an error that must not be retried.
+type permanentError struct {
+	err error
+}
+
+func (p *permanentError) Error() string {
+	return p.err.Error()
+}
+
+func (p *permanentError) Unwrap() error {
+	return p.err
+}
+
+// Permanent implements the convention shared with errors.MarkPermanent.
+func (p *permanentError) Permanent() bool {
+	return true
+}
+
+// Temporary implements the convention shared with errors.MarkTemporary.
+func (p *permanentError) Temporary() bool {
+	return false
+}
+
+// permanent is implemented by the errors marked permanent, by Permanent or by
+// errors.MarkPermanent, a marker closer to the top of the chain wins.
+type permanent interface {
+	Permanent() bool
+}
+
+// Permanent wraps err to stop Retry immediately, nil stays nil. Retry also stops on the
+// errors marked by errors.MarkPermanent.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+// RetryError is returned by Retry when fn never succeeded.
+type RetryError struct {
+	// Attempts is the number of times fn has been called.
+	Attempts int
+	// Err is the error of the last attempt, nil if fn has never been called.
+	Err error
+	// Ctx is the error of the context if it stopped the retries.
+	Ctx error
+}
+
+func (r *RetryError) Error() string {
+	switch {
+	case r.Ctx == nil:
+		return fmt.Sprintf("failed after %d attempts, err: %s", r.Attempts, r.Err)
+	case r.Err == nil:
+		return fmt.Sprintf("stopped after %d attempts, err: %s", r.Attempts, r.Ctx)
+	default:
+		return fmt.Sprintf("stopped after %d attempts, err: %s, last err: %s", r.Attempts, r.Ctx, r.Err)
+	}
+}
+
+// Unwrap returns the error of the last attempt.
+func (r *RetryError) Unwrap() error {
+	return r.Err
+}
+
+// Is reports whether the context error matches target, the last error of fn is
+// matched through Unwrap.
+func (r *RetryError) Is(target error) bool {
+	return r.Ctx != nil && errors.Is(r.Ctx, target)
+}
+
+// Retry calls fn until it succeeds, at most attempts times, waiting the delay computed by
+// strategy between the attempts; attempts <= 0 retries until success and a nil strategy
+// retries without delay. It stops early when fn returns an error wrapped by Permanent or
+// when ctx is done, including while waiting. The returned error is a *RetryError wrapping
+// the last error of fn.
+func Retry(ctx context.Context, attempts int, strategy Backoff, fn func() error) error {
+	_, err := RetryValue(ctx, attempts, strategy, func() (struct{}, error) {
+		return struct{}{}, fn()
+	})
+	return err
+}
+
+// RetryValue is like Retry for functions returning a value, the value of the
+// successful attempt is returned.
+func RetryValue[T any](ctx context.Context, attempts int, strategy Backoff, fn func() (T, error)) (T, error) {
+	var zero T
+	var last error
+	for attempt := 1; ; attempt++ {
+		if err := ctx.Err(); err != nil {
+			return zero, &RetryError{Attempts: attempt - 1, Err: last, Ctx: err}
+		}
+		value, err := fn()
+		if err == nil {
+			return value, nil
+		}
+		last = err
+		var marker permanent
+		if errors.As(err, &marker) && marker.Permanent() {
+			if p, ok := marker.(*permanentError); ok {
+				err = p.err
+			}
+			return zero, &RetryError{Attempts: attempt, Err: err}
+		}
+		if attempts > 0 && attempt >= attempts {
+			return zero, &RetryError{Attempts: attempt, Err: err}
+		}
+		if strategy == nil {
+			continue
+		}
+		if delay := strategy.Next(attempt); delay > 0 {
+			timer := time.NewTimer(delay)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return zero, &RetryError{Attempts: attempt, Err: err, Ctx: ctx.Err()}
+			case <-timer.C:
+			}
+		}
+	}
+}
@@ -0,0 +1,166 @@
+package lib
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+var errRetry = errors.New("retry error")
+
+// permanentMarker marks its error permanent unless it is nil.
+type permanentMarker struct {
+	error
+}
+
+func (p permanentMarker) Error() string {
+	if p.error == nil {
+		return "not permanent"
+	}
+	return p.error.Error()
+}
+
+func (p permanentMarker) Unwrap() error { return p.error }
+
+func (p permanentMarker) Permanent() bool { return p.error != nil }
+
+func TestBackoff(t *testing.T) {
+	t.Run("fixed", func(t *testing.T) {
+		for attempt := 1; attempt < 5; attempt++ {
+			require.Equal(t, time.Second, Fixed(time.Second).Next(attempt))
+		}
+	})
+	t.Run("linear", func(t *testing.T) {
+		l := Linear{Initial: time.Second, Step: 2 * time.Second, Max: 6 * time.Second}
+		require.Equal(t, time.Second, l.Next(1))
+		require.Equal(t, 3*time.Second, l.Next(2))
+		require.Equal(t, 5*time.Second, l.Next(3))
+		require.Equal(t, 6*time.Second, l.Next(4))
+	})
+	t.Run("exponential", func(t *testing.T) {
+		e := Exponential{Initial: time.Second, Max: 5 * time.Second}
+		require.Equal(t, time.Second, e.Next(1))
+		require.Equal(t, 2*time.Second, e.Next(2))
+		require.Equal(t, 4*time.Second, e.Next(3))
+		require.Equal(t, 5*time.Second, e.Next(4))
+		require.Equal(t, 5*time.Second, e.Next(1000))
+		e.Factor = 3
+		require.Equal(t, 9*time.Second/3, e.Next(2))
+	})
+	t.Run("jitter", func(t *testing.T) {
+		e := Exponential{Initial: time.Second, Max: 8 * time.Second, Jitter: 0.25}
+		for attempt := 1; attempt < 6; attempt++ {
+			base := Exponential{Initial: time.Second, Max: 8 * time.Second}.Next(attempt)
+			for i := 0; i < 100; i++ {
+				d := e.Next(attempt)
+				require.GreaterOrEqual(t, d, base*3/4)
+				require.LessOrEqual(t, d, base*5/4)
+			}
+		}
+	})
+}
+
+func TestRetry(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		calls := 0
+		err := Retry(context.Background(), 5, Fixed(time.Millisecond), func() error {
+			calls++
+			if calls < 3 {
+				return errRetry
+			}
+			return nil
+		})
+		require.NoError(t, err)
+		require.Equal(t, 3, calls)
+	})
+	t.Run("exhausted", func(t *testing.T) {
+		calls := 0
+		err := Retry(context.Background(), 3, nil, func() error {
+			calls++
+			return errRetry
+		})
+		require.ErrorIs(t, err, errRetry)
+		var retryErr *RetryError
+		require.True(t, errors.As(err, &retryErr))
+		require.Equal(t, 3, retryErr.Attempts)
+		require.Equal(t, 3, calls)
+	})
+	t.Run("permanent", func(t *testing.T) {
+		calls := 0
+		err := Retry(context.Background(), 5, nil, func() error {
+			calls++
+			return Permanent(errRetry)
+		})
+		require.ErrorIs(t, err, errRetry)
+		require.Equal(t, 1, calls)
+		require.Equal(t, "failed after 1 attempts, err: retry error", err.Error())
+		require.Nil(t, Permanent(nil))
+	})
+	t.Run("permanent marker", func(t *testing.T) {
+		// errors.MarkPermanent marks errors with the same method
+		calls := 0
+		marked := permanentMarker{errRetry}
+		err := Retry(context.Background(), 5, nil, func() error {
+			calls++
+			return fmt.Errorf("call: %w", marked)
+		})
+		require.ErrorIs(t, err, errRetry)
+		require.Equal(t, 1, calls)
+
+		calls = 0
+		_ = Retry(context.Background(), 3, nil, func() error {
+			calls++
+			return permanentMarker{}
+		})
+		require.Equal(t, 3, calls, "Permanent() false is retried")
+	})
+	t.Run("canceled while waiting", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(50*time.Millisecond, cancel)
+		calls := 0
+		start := time.Now()
+		err := Retry(ctx, 0, Fixed(time.Hour), func() error {
+			calls++
+			return errRetry
+		})
+		require.Less(t, time.Since(start), time.Minute)
+		require.ErrorIs(t, err, context.Canceled)
+		require.ErrorIs(t, err, errRetry)
+		require.Equal(t, 1, calls)
+	})
+	t.Run("done before the first attempt", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		err := Retry(ctx, 3, nil, func() error {
+			t.Fatal("fn must not be called")
+			return nil
+		})
+		require.ErrorIs(t, err, context.Canceled)
+		var retryErr *RetryError
+		require.True(t, errors.As(err, &retryErr))
+		require.Equal(t, 0, retryErr.Attempts)
+	})
+}
+
+func TestRetryValue(t *testing.T) {
+	calls := 0
+	value, err := RetryValue(context.Background(), 3, nil, func() (int, error) {
+		calls++
+		if calls < 2 {
+			return 0, errRetry
+		}
+		return 42, nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, 42, value)
+
+	value, err = RetryValue(context.Background(), 2, nil, func() (int, error) {
+		return 7, errRetry
+	})
+	require.ErrorIs(t, err, errRetry)
+	require.Equal(t, 0, value)
+}
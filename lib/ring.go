@@ -0,0 +1,98 @@
+package lib
+
+import "sync"
+
+// Ring is a bounded buffer keeping the last pushed items, a push to a full ring
+// overwrites the oldest item. It is not safe for concurrent use, see SyncRing.
+type Ring[T any] struct {
+	items []T
+	// start is the index of the oldest item
+	start int
+	size  int
+}
+
+// NewRing returns an empty Ring holding up to capacity items, a capacity < 1 is 1.
+func NewRing[T any](capacity int) *Ring[T] {
+	return &Ring[T]{items: make([]T, MaxOf(capacity, 1))}
+}
+
+// Push appends v, the oldest item is dropped if the ring is full.
+func (r *Ring[T]) Push(v T) {
+	if r.size < len(r.items) {
+		r.items[(r.start+r.size)%len(r.items)] = v
+		r.size++
+		return
+	}
+	r.items[r.start] = v
+	r.start = (r.start + 1) % len(r.items)
+}
+
+// Len returns the number of items.
+func (r *Ring[T]) Len() int {
+	return r.size
+}
+
+// Cap returns the maximum number of items.
+func (r *Ring[T]) Cap() int {
+	return len(r.items)
+}
+
+// Snapshot returns a copy of the items from the oldest to the newest.
+func (r *Ring[T]) Snapshot() []T {
+	ret := make([]T, r.size)
+	n := copy(ret, r.items[r.start:MinOf(r.start+r.size, len(r.items))])
+	copy(ret[n:], r.items[:r.size-n])
+	return ret
+}
+
+// Do calls fn for each item from the oldest to the newest, fn must not modify r.
+func (r *Ring[T]) Do(fn func(T)) {
+	for i := 0; i < r.size; i++ {
+		fn(r.items[(r.start+i)%len(r.items)])
+	}
+}
+
+// SyncRing is a Ring safe for concurrent use.
+type SyncRing[T any] struct {
+	mtx  sync.Mutex
+	ring *Ring[T]
+}
+
+// NewSyncRing returns an empty SyncRing holding up to capacity items, a capacity < 1 is 1.
+func NewSyncRing[T any](capacity int) *SyncRing[T] {
+	return &SyncRing[T]{ring: NewRing[T](capacity)}
+}
+
+// Push appends v, the oldest item is dropped if the ring is full.
+func (r *SyncRing[T]) Push(v T) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	r.ring.Push(v)
+}
+
+// Len returns the number of items.
+func (r *SyncRing[T]) Len() int {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	return r.ring.Len()
+}
+
+// Cap returns the maximum number of items.
+func (r *SyncRing[T]) Cap() int {
+	return r.ring.Cap()
+}
+
+// Snapshot returns a copy of the items from the oldest to the newest.
+func (r *SyncRing[T]) Snapshot() []T {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	return r.ring.Snapshot()
+}
+
+// Do calls fn for each item of a snapshot from the oldest to the newest, fn may
+// use r since the lock isn't held while calling fn.
+func (r *SyncRing[T]) Do(fn func(T)) {
+	for _, v := range r.Snapshot() {
+		fn(v)
+	}
+}
@@ -0,0 +1,93 @@
+package lib
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRing(t *testing.T) {
+	r := NewRing[int](3)
+	require.Equal(t, 0, r.Len())
+	require.Equal(t, 3, r.Cap())
+	require.Equal(t, []int{}, r.Snapshot())
+
+	r.Push(1)
+	r.Push(2)
+	require.Equal(t, []int{1, 2}, r.Snapshot())
+	r.Push(3)
+	require.Equal(t, []int{1, 2, 3}, r.Snapshot())
+	r.Push(4)
+	r.Push(5)
+	require.Equal(t, 3, r.Len())
+	require.Equal(t, []int{3, 4, 5}, r.Snapshot())
+
+	var items []int
+	r.Do(func(v int) { items = append(items, v) })
+	require.Equal(t, []int{3, 4, 5}, items)
+
+	// the snapshot is a copy
+	snapshot := r.Snapshot()
+	snapshot[0] = 100
+	require.Equal(t, []int{3, 4, 5}, r.Snapshot())
+
+	for i := 6; i < 100; i++ {
+		r.Push(i)
+		require.Equal(t, []int{i - 2, i - 1, i}, r.Snapshot())
+	}
+}
+
+func TestRingCapacity(t *testing.T) {
+	r := NewRing[string](0)
+	require.Equal(t, 1, r.Cap())
+	r.Push("a")
+	r.Push("b")
+	require.Equal(t, []string{"b"}, r.Snapshot())
+}
+
+func TestSyncRing(t *testing.T) {
+	r := NewSyncRing[int](100)
+	require.Equal(t, 100, r.Cap())
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				r.Push(i*1000 + j)
+				if j%100 == 0 {
+					_ = r.Snapshot()
+					_ = r.Len()
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+	require.Equal(t, 100, r.Len())
+
+	// the items of each goroutine stay in order
+	last := map[int]int{}
+	r.Do(func(v int) {
+		if previous, ok := last[v/1000]; ok {
+			require.Greater(t, v, previous)
+		}
+		last[v/1000] = v
+	})
+}
+
+func BenchmarkRingPush(b *testing.B) {
+	r := NewRing[int](1024)
+	for i := 0; i < b.N; i++ {
+		r.Push(i)
+	}
+}
+
+func BenchmarkSyncRingPush(b *testing.B) {
+	r := NewSyncRing[int](1024)
+	b.RunParallel(func(pb *testing.PB) {
+		for i := 0; pb.Next(); i++ {
+			r.Push(i)
+		}
+	})
+}
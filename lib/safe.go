@@ -0,0 +1,19 @@
+//go:build purego || safe
+
+package lib
+
+// sharedConversions reports whether ToString and ToBytes share the memory of their
+// argument, they copy it if built with the purego or the safe tag.
+const sharedConversions = false
+
+// ToString converts a byte slice to a string, it copies the bytes since the package is
+// built with the purego or the safe tag, see CloneString.
+func ToString(b []byte) string {
+	return string(b)
+}
+
+// ToBytes converts a string to a byte slice, it copies the string since the package is
+// built with the purego or the safe tag, see CloneBytes.
+func ToBytes(s string) []byte {
+	return []byte(s)
+}
@@ -0,0 +1,101 @@
+package lib
+
+// Chunk splits s into consecutive chunks of size elements, the last one may be shorter.
+// The chunks share the memory of s but are capped, appending to a chunk never overwrites
+// the next one. A size <= 0 returns s as a single chunk. A nil s returns nil and an
+// empty s returns an empty result.
+func Chunk[T any](s []T, size int) [][]T {
+	if s == nil {
+		return nil
+	}
+	if len(s) == 0 {
+		return [][]T{}
+	}
+	if size <= 0 || size >= len(s) {
+		return [][]T{s[:len(s):len(s)]}
+	}
+	chunks := make([][]T, 0, (len(s)+size-1)/size)
+	for start := 0; start < len(s); start += size {
+		end := start + size
+		if end > len(s) {
+			end = len(s)
+		}
+		chunks = append(chunks, s[start:end:end])
+	}
+	return chunks
+}
+
+// Unique returns a new slice holding the first occurrence of each element of s, in order.
+// A nil s returns nil.
+func Unique[T comparable](s []T) []T {
+	if s == nil {
+		return nil
+	}
+	seen := make(map[T]struct{}, len(s))
+	ret := make([]T, 0, len(s))
+	for _, v := range s {
+		if _, ok := seen[v]; !ok {
+			seen[v] = struct{}{}
+			ret = append(ret, v)
+		}
+	}
+	return ret
+}
+
+// Reverse reverses the elements of s in place.
+func Reverse[T any](s []T) {
+	for i, j := 0, len(s)-1; i < j; i, j = i+1, j-1 {
+		s[i], s[j] = s[j], s[i]
+	}
+}
+
+// Reversed returns a reversed copy of s, s is left unchanged. A nil s returns nil.
+func Reversed[T any](s []T) []T {
+	if s == nil {
+		return nil
+	}
+	ret := make([]T, len(s))
+	for i, v := range s {
+		ret[len(s)-1-i] = v
+	}
+	return ret
+}
+
+// Filter returns a new slice holding the elements of s for which keep returns true.
+// A nil s returns nil.
+func Filter[T any](s []T, keep func(T) bool) []T {
+	if s == nil {
+		return nil
+	}
+	ret := make([]T, 0, len(s))
+	for _, v := range s {
+		if keep(v) {
+			ret = append(ret, v)
+		}
+	}
+	return ret
+}
+
+// Map returns a new slice holding the results of fn applied to each element of s.
+// A nil s returns nil.
+func Map[T, U any](s []T, fn func(T) U) []U {
+	if s == nil {
+		return nil
+	}
+	ret := make([]U, len(s))
+	for i, v := range s {
+		ret[i] = fn(v)
+	}
+	return ret
+}
+
+// GroupBy groups the elements of s by the key returned by key, each group keeps the
+// order of s. The returned map is never nil, it is empty for a nil or empty s.
+func GroupBy[T any, K comparable](s []T, key func(T) K) map[K][]T {
+	groups := make(map[K][]T)
+	for _, v := range s {
+		k := key(v)
+		groups[k] = append(groups[k], v)
+	}
+	return groups
+}
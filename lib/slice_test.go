@@ -0,0 +1,87 @@
+package lib
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestChunk(t *testing.T) {
+	cases := []struct {
+		Name   string
+		Slice  []int
+		Size   int
+		Expect [][]int
+	}{
+		{"nil", nil, 2, nil},
+		{"empty", []int{}, 2, [][]int{}},
+		{"even", []int{1, 2, 3, 4}, 2, [][]int{{1, 2}, {3, 4}}},
+		{"remainder", []int{1, 2, 3, 4, 5}, 2, [][]int{{1, 2}, {3, 4}, {5}}},
+		{"larger size", []int{1, 2}, 5, [][]int{{1, 2}}},
+		{"zero size", []int{1, 2, 3}, 0, [][]int{{1, 2, 3}}},
+		{"negative size", []int{1, 2, 3}, -1, [][]int{{1, 2, 3}}},
+	}
+	for _, c := range cases {
+		t.Run(c.Name, func(t *testing.T) {
+			require.Equal(t, c.Expect, Chunk(c.Slice, c.Size))
+		})
+	}
+
+	t.Run("capped", func(t *testing.T) {
+		s := []int{1, 2, 3, 4}
+		chunks := Chunk(s, 2)
+		_ = append(chunks[0], 9)
+		require.Equal(t, []int{1, 2, 3, 4}, s)
+	})
+}
+
+func TestUnique(t *testing.T) {
+	require.Nil(t, Unique[int](nil))
+	require.Equal(t, []int{}, Unique([]int{}))
+	require.Equal(t, []int{3, 1, 2}, Unique([]int{3, 1, 3, 2, 1, 2}))
+	require.Equal(t, []string{"a", "b"}, Unique([]string{"a", "a", "b"}))
+}
+
+func TestReverse(t *testing.T) {
+	var nilSlice []int
+	Reverse(nilSlice)
+	require.Nil(t, nilSlice)
+
+	for _, s := range [][]int{{}, {1}, {1, 2}, {1, 2, 3}} {
+		expect := make([]int, len(s))
+		for i := range s {
+			expect[i] = s[len(s)-1-i]
+		}
+		origin := append([]int{}, s...)
+		require.Equal(t, expect, Reversed(s))
+		require.Equal(t, origin, s)
+		Reverse(s)
+		require.Equal(t, expect, s)
+	}
+	require.Nil(t, Reversed[int](nil))
+}
+
+func TestFilter(t *testing.T) {
+	even := func(v int) bool { return v%2 == 0 }
+	require.Nil(t, Filter(nil, even))
+	require.Equal(t, []int{}, Filter([]int{}, even))
+	require.Equal(t, []int{}, Filter([]int{1, 3}, even))
+	require.Equal(t, []int{2, 4}, Filter([]int{1, 2, 3, 4}, even))
+}
+
+func TestMap(t *testing.T) {
+	require.Nil(t, Map(nil, strconv.Itoa))
+	require.Equal(t, []string{}, Map([]int{}, strconv.Itoa))
+	require.Equal(t, []string{"1", "2"}, Map([]int{1, 2}, strconv.Itoa))
+}
+
+func TestGroupBy(t *testing.T) {
+	parity := func(v int) bool { return v%2 == 0 }
+	require.Equal(t, map[bool][]int{}, GroupBy(nil, parity))
+	require.Equal(t, map[bool][]int{}, GroupBy([]int{}, parity))
+	require.Equal(t, map[bool][]int{
+		true:  {2, 4},
+		false: {1, 3, 5},
+	}, GroupBy([]int{1, 2, 3, 4, 5}, parity))
+}
@@ -0,0 +1,150 @@
+package lib
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Lap is a named split of a Stopwatch.
+type Lap struct {
+	Name string
+	// Elapsed is the time since the previous lap, or the start for the first lap.
+	Elapsed time.Duration
+	// Total is the time since the start.
+	Total time.Duration
+}
+
+// Stopwatch measures elapsed times with the monotonic clock, it is safe for concurrent
+// use. The zero Stopwatch is stopped and ready to use.
+type Stopwatch struct {
+	mtx     sync.Mutex
+	now     func() time.Time
+	start   time.Time
+	stop    time.Time
+	last    time.Time
+	running bool
+	laps    []Lap
+}
+
+// StartStopwatch returns a running Stopwatch.
+func StartStopwatch() *Stopwatch {
+	s := &Stopwatch{}
+	s.Start()
+	return s
+}
+
+// clock returns the current time.
+func (s *Stopwatch) clock() time.Time {
+	if s.now == nil {
+		return time.Now()
+	}
+	return s.now()
+}
+
+// Start resets the laps and starts the stopwatch.
+func (s *Stopwatch) Start() {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	s.start = s.clock()
+	s.last = s.start
+	s.running = true
+	s.laps = nil
+}
+
+// Stop stops the stopwatch and returns the elapsed time.
+func (s *Stopwatch) Stop() time.Duration {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	if s.running {
+		s.stop = s.clock()
+		s.running = false
+	}
+	return s.stop.Sub(s.start)
+}
+
+// Elapsed returns the time since the start, until the stop if the stopwatch is stopped.
+func (s *Stopwatch) Elapsed() time.Duration {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	if s.running {
+		return s.clock().Sub(s.start)
+	}
+	return s.stop.Sub(s.start)
+}
+
+// Lap records and returns a lap named name, a stopped stopwatch records the time
+// of the stop.
+func (s *Stopwatch) Lap(name string) Lap {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	now := s.stop
+	if s.running {
+		now = s.clock()
+	}
+	lap := Lap{Name: name, Elapsed: now.Sub(s.last), Total: now.Sub(s.start)}
+	s.last = now
+	s.laps = append(s.laps, lap)
+	return lap
+}
+
+// Laps returns a copy of the recorded laps.
+func (s *Stopwatch) Laps() []Lap {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	return append([]Lap{}, s.laps...)
+}
+
+// Report returns a line per lap with its time and the total, followed by the
+// elapsed time, e.g.
+//
+//	open: 12ms (12ms)
+//	write: 3ms (15ms)
+//	total: 20ms
+func (s *Stopwatch) Report() string {
+	sb := GetBuilder()
+	defer PutBuilder(sb)
+	for _, lap := range s.Laps() {
+		fmt.Fprintf(sb, "%s: %s (%s)\n", lap.Name, FormatDuration(lap.Elapsed), FormatDuration(lap.Total))
+	}
+	fmt.Fprintf(sb, "total: %s", FormatDuration(s.Elapsed()))
+	return sb.String()
+}
+
+var (
+	timeItMtx sync.RWMutex
+	// timeItSink receives the measures of TimeIt, it writes a warning-like line to
+	// os.Stderr by default.
+	timeItSink = func(name string, elapsed time.Duration) {
+		_, _ = fmt.Fprintf(os.Stderr, "warning: %s took %s\n", name, FormatDuration(elapsed))
+	}
+	// timeItNow is the clock of TimeIt.
+	timeItNow = time.Now
+)
+
+// SetTimeItSink sets the function receiving the measures of TimeIt, nil discards them.
+func SetTimeItSink(sink func(name string, elapsed time.Duration)) {
+	timeItMtx.Lock()
+	defer timeItMtx.Unlock()
+	if sink == nil {
+		sink = func(string, time.Duration) {}
+	}
+	timeItSink = sink
+}
+
+// TimeIt starts measuring and returns the function sending the elapsed time to the
+// sink set by SetTimeItSink, it is meant to be deferred at the start of a function:
+//
+//	defer lib.TimeIt("rotate")()
+func TimeIt(name string) func() {
+	start := timeItNow()
+	return func() {
+		elapsed := timeItNow().Sub(start)
+		timeItMtx.RLock()
+		sink := timeItSink
+		timeItMtx.RUnlock()
+		sink(strings.TrimSpace(name), elapsed)
+	}
+}
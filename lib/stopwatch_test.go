@@ -0,0 +1,97 @@
+package lib
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// fakeStopwatch returns a Stopwatch whose clock only moves through advance.
+func fakeStopwatch() (s *Stopwatch, advance func(d time.Duration)) {
+	now := time.Unix(0, 0)
+	s = &Stopwatch{now: func() time.Time { return now }}
+	return s, func(d time.Duration) { now = now.Add(d) }
+}
+
+func TestStopwatch(t *testing.T) {
+	s, advance := fakeStopwatch()
+	require.Equal(t, time.Duration(0), s.Elapsed())
+
+	s.Start()
+	advance(10 * time.Millisecond)
+	require.Equal(t, 10*time.Millisecond, s.Elapsed())
+	require.Equal(t, Lap{Name: "open", Elapsed: 10 * time.Millisecond, Total: 10 * time.Millisecond}, s.Lap("open"))
+	advance(5 * time.Millisecond)
+	require.Equal(t, Lap{Name: "write", Elapsed: 5 * time.Millisecond, Total: 15 * time.Millisecond}, s.Lap("write"))
+	advance(5 * time.Millisecond)
+	require.Equal(t, 20*time.Millisecond, s.Stop())
+
+	// a stopped stopwatch doesn't move
+	advance(time.Second)
+	require.Equal(t, 20*time.Millisecond, s.Elapsed())
+	require.Equal(t, 20*time.Millisecond, s.Stop())
+	require.Equal(t, Lap{Name: "close", Elapsed: 5 * time.Millisecond, Total: 20 * time.Millisecond}, s.Lap("close"))
+
+	require.Len(t, s.Laps(), 3)
+	require.Equal(t, "open: 10ms (10ms)\nwrite: 5ms (15ms)\nclose: 5ms (20ms)\ntotal: 20ms", s.Report())
+
+	// Laps returns a copy
+	s.Laps()[0].Name = "changed"
+	require.Equal(t, "open", s.Laps()[0].Name)
+
+	// restart resets the laps
+	s.Start()
+	require.Empty(t, s.Laps())
+	require.Equal(t, "total: 0s", s.Report())
+}
+
+func TestStopwatchConcurrentLaps(t *testing.T) {
+	s := StartStopwatch()
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				s.Lap("lap")
+			}
+		}()
+	}
+	wg.Wait()
+	laps := s.Laps()
+	require.Len(t, laps, 800)
+	var sum time.Duration
+	for i, lap := range laps {
+		sum += lap.Elapsed
+		require.Equal(t, sum, lap.Total)
+		if i > 0 {
+			require.GreaterOrEqual(t, lap.Total, laps[i-1].Total)
+		}
+	}
+}
+
+func TestTimeIt(t *testing.T) {
+	originNow, originSink := timeItNow, timeItSink
+	defer func() {
+		timeItNow, timeItSink = originNow, originSink
+	}()
+	now := time.Unix(0, 0)
+	timeItNow = func() time.Time { return now }
+
+	var gotName string
+	var gotElapsed time.Duration
+	SetTimeItSink(func(name string, elapsed time.Duration) {
+		gotName, gotElapsed = name, elapsed
+	})
+	func() {
+		defer TimeIt("rotate")()
+		now = now.Add(1500 * time.Millisecond)
+	}()
+	require.Equal(t, "rotate", gotName)
+	require.Equal(t, 1500*time.Millisecond, gotElapsed)
+
+	SetTimeItSink(nil)
+	require.NotPanics(t, TimeIt("discarded"))
+}
@@ -0,0 +1,165 @@
+package lib
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+var InvalidNumberError = errors.New("invalid number")
+
+// NumberError is the error of the numbers parsing functions, it matches
+// InvalidNumberError with errors.Is and unwraps to strconv.ErrSyntax or strconv.ErrRange.
+type NumberError struct {
+	// Kind is the kind of number, "integer" or "float".
+	Kind string
+	// Value is the invalid text.
+	Value string
+	// Key is the name of the setting the value comes from, it may be empty.
+	Key string
+	Err error
+}
+
+func (e *NumberError) Error() string {
+	msg := "invalid " + e.Kind + " " + strconv.Quote(e.Value)
+	if e.Key != "" {
+		msg += " for key " + e.Key
+	}
+	if errors.Is(e.Err, strconv.ErrRange) {
+		msg += ": value out of range"
+	}
+	return msg
+}
+
+func (e *NumberError) Unwrap() error {
+	return e.Err
+}
+
+func (e *NumberError) Is(target error) bool {
+	return target == InvalidNumberError
+}
+
+// numberError converts the error of strconv to a *NumberError.
+func numberError(kind, value string, err error) error {
+	var numErr *strconv.NumError
+	if errors.As(err, &numErr) {
+		err = numErr.Err
+	}
+	return &NumberError{Kind: kind, Value: value, Err: err}
+}
+
+// withKey sets the key of err if it's a *NumberError.
+func withKey(err error, key string) error {
+	var numErr *NumberError
+	if errors.As(err, &numErr) {
+		numErr.Key = key
+	}
+	return err
+}
+
+// parseInt parses the integer s in base after trimming the whitespaces, base must not
+// be 0 so that underscores are rejected.
+func parseInt(s string, base, bits int) (int64, error) {
+	s = strings.TrimSpace(s)
+	i, err := strconv.ParseInt(s, base, bits)
+	if err != nil {
+		return 0, numberError("integer", s, err)
+	}
+	return i, nil
+}
+
+// AtoiE parses s as a base 10 int, leading and trailing whitespaces are ignored and a
+// sign is accepted. It returns a *NumberError if s is empty, invalid or overflows.
+func AtoiE(s string) (int, error) {
+	i, err := parseInt(s, 10, 0)
+	return int(i), err
+}
+
+// Atoi is like AtoiE, but returns def if s is empty or invalid.
+func Atoi(s string, def int) int {
+	if i, err := AtoiE(s); err == nil {
+		return i
+	}
+	return def
+}
+
+// ParseInt64E parses s as a base 10 int64, see AtoiE.
+func ParseInt64E(s string) (int64, error) {
+	return parseInt(s, 10, 64)
+}
+
+// ParseInt64 is like ParseInt64E, but returns def if s is empty or invalid.
+func ParseInt64(s string, def int64) int64 {
+	if i, err := ParseInt64E(s); err == nil {
+		return i
+	}
+	return def
+}
+
+// ParseHexIntE parses s as a base 16 int64 with an optional 0x or 0X prefix after
+// the sign, e.g. "ff", "0xFF" or "-0x1f", see AtoiE.
+func ParseHexIntE(s string) (int64, error) {
+	s = strings.TrimSpace(s)
+	digits := s
+	sign := ""
+	if digits != "" && (digits[0] == '+' || digits[0] == '-') {
+		sign, digits = digits[:1], digits[1:]
+	}
+	if len(digits) > 2 && digits[0] == '0' && (digits[1] == 'x' || digits[1] == 'X') {
+		digits = digits[2:]
+	}
+	if digits != "" && (digits[0] == '+' || digits[0] == '-') {
+		return 0, &NumberError{Kind: "integer", Value: s, Err: strconv.ErrSyntax}
+	}
+	i, err := strconv.ParseInt(sign+digits, 16, 64)
+	if err != nil {
+		return 0, numberError("integer", s, err)
+	}
+	return i, nil
+}
+
+// ParseHexInt is like ParseHexIntE, but returns def if s is empty or invalid.
+func ParseHexInt(s string, def int64) int64 {
+	if i, err := ParseHexIntE(s); err == nil {
+		return i
+	}
+	return def
+}
+
+// ParseFloatE parses s as a float64, leading and trailing whitespaces are ignored and
+// underscores are rejected.
+// It returns a *NumberError if s is empty, invalid or overflows.
+func ParseFloatE(s string) (float64, error) {
+	s = strings.TrimSpace(s)
+	if strings.IndexByte(s, '_') >= 0 {
+		// strconv accepts the underscores of the Go syntax
+		return 0, &NumberError{Kind: "float", Value: s, Err: strconv.ErrSyntax}
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, numberError("float", s, err)
+	}
+	return f, nil
+}
+
+// ParseFloat is like ParseFloatE, but returns def if s is empty or invalid.
+func ParseFloat(s string, def float64) float64 {
+	if f, err := ParseFloatE(s); err == nil {
+		return f
+	}
+	return def
+}
+
+// FormatInt returns i in base 10 padded with zeros to width characters, the sign
+// counts in the width like with fmt's %0*d, e.g. FormatInt(-5, 3) returns "-05".
+func FormatInt(i int64, width int) string {
+	s := strconv.FormatInt(i, 10)
+	if len(s) >= width {
+		return s
+	}
+	zeros := strings.Repeat("0", width-len(s))
+	if i < 0 {
+		return "-" + zeros + s[1:]
+	}
+	return zeros + s
+}
@@ -0,0 +1,126 @@
+package lib
+
+import (
+	"math"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAtoi(t *testing.T) {
+	cases := []struct {
+		Name   string
+		Text   string
+		Expect int64
+	}{
+		{"plain", "42", 42},
+		{"plus sign", "+42", 42},
+		{"negative", "-42", -42},
+		{"whitespace", " \t42\n", 42},
+		{"leading zeros", "007", 7},
+		{"max", "9223372036854775807", math.MaxInt64},
+		{"min", "-9223372036854775808", math.MinInt64},
+	}
+	for _, c := range cases {
+		t.Run(c.Name, func(t *testing.T) {
+			i, err := ParseInt64E(c.Text)
+			require.NoError(t, err)
+			require.Equal(t, c.Expect, i)
+			require.Equal(t, c.Expect, ParseInt64(c.Text, 1))
+			n, err := AtoiE(c.Text)
+			require.NoError(t, err)
+			require.Equal(t, int(c.Expect), n)
+			require.Equal(t, int(c.Expect), Atoi(c.Text, 1))
+		})
+	}
+
+	errCases := []struct {
+		Name     string
+		Text     string
+		Overflow bool
+	}{
+		{"empty", "", false},
+		{"blank", "  ", false},
+		{"underscores", "1_000", false},
+		{"hex prefix", "0x10", false},
+		{"inner space", "4 2", false},
+		{"double sign", "+-1", false},
+		{"float", "1.5", false},
+		{"overflow", "9223372036854775808", true},
+		{"underflow", "-9223372036854775809", true},
+	}
+	for _, c := range errCases {
+		t.Run(c.Name, func(t *testing.T) {
+			_, err := ParseInt64E(c.Text)
+			require.ErrorIs(t, err, InvalidNumberError)
+			if c.Overflow {
+				require.ErrorIs(t, err, strconv.ErrRange)
+				require.Contains(t, err.Error(), "out of range")
+			} else {
+				require.ErrorIs(t, err, strconv.ErrSyntax)
+			}
+			_, err = AtoiE(c.Text)
+			require.ErrorIs(t, err, InvalidNumberError)
+			require.Equal(t, int64(-1), ParseInt64(c.Text, -1))
+			require.Equal(t, -1, Atoi(c.Text, -1))
+		})
+	}
+
+	_, err := AtoiE(" abc ")
+	require.EqualError(t, err, `invalid integer "abc"`)
+}
+
+func TestParseHexInt(t *testing.T) {
+	for text, expect := range map[string]int64{
+		"ff": 255, "0xFF": 255, "0X1f": 31, "-0x10": -16, "+a": 10, " 10 ": 16, "0": 0,
+		"7fffffffffffffff": math.MaxInt64,
+	} {
+		i, err := ParseHexIntE(text)
+		require.NoError(t, err, text)
+		require.Equal(t, expect, i, text)
+		require.Equal(t, expect, ParseHexInt(text, -1), text)
+	}
+	for _, text := range []string{"", "0x", "0x-1", "-", "g", "f_f", "0x0x1", "8000000000000000"} {
+		_, err := ParseHexIntE(text)
+		require.ErrorIs(t, err, InvalidNumberError, text)
+		require.Equal(t, int64(-1), ParseHexInt(text, -1), text)
+	}
+}
+
+func TestParseFloat(t *testing.T) {
+	for text, expect := range map[string]float64{
+		"1.5": 1.5, "+2": 2, "-1e3": -1000, " .25 ": 0.25,
+	} {
+		f, err := ParseFloatE(text)
+		require.NoError(t, err, text)
+		require.Equal(t, expect, f, text)
+		require.Equal(t, expect, ParseFloat(text, -1), text)
+	}
+	for _, text := range []string{"", "1_000.5", "1.5x", "1e400"} {
+		_, err := ParseFloatE(text)
+		require.ErrorIs(t, err, InvalidNumberError, text)
+		require.Equal(t, -1.0, ParseFloat(text, -1), text)
+	}
+	_, err := ParseFloatE("1e400")
+	require.EqualError(t, err, `invalid float "1e400": value out of range`)
+}
+
+func TestFormatInt(t *testing.T) {
+	cases := []struct {
+		Value  int64
+		Width  int
+		Expect string
+	}{
+		{5, 3, "005"},
+		{-5, 3, "-05"},
+		{123, 2, "123"},
+		{0, 0, "0"},
+		{0, 4, "0000"},
+		{-123, 4, "-123"},
+		{math.MinInt64, 21, "-09223372036854775808"},
+	}
+	for _, c := range cases {
+		require.Equal(t, c.Expect, FormatInt(c.Value, c.Width))
+	}
+}
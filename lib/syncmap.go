@@ -0,0 +1,195 @@
+package lib
+
+import (
+	"fmt"
+	"hash/maphash"
+	"math"
+	"sync"
+)
+
+// defaultShards is the number of shards of a SyncMap created without NewSyncMap.
+const defaultShards = 32
+
+// syncShard is a shard of SyncMap.
+type syncShard[K comparable, V any] struct {
+	mtx   sync.RWMutex
+	items map[K]V
+}
+
+// SyncMap is a typed map safe for concurrent use. The keys are spread on shards locked
+// independently, so that operations on different keys rarely contend, and Update runs
+// atomically per key. The zero SyncMap is ready to use with 32 shards.
+type SyncMap[K comparable, V any] struct {
+	once   sync.Once
+	seed   maphash.Seed
+	shards []syncShard[K, V]
+}
+
+// NewSyncMap returns a SyncMap with shards shards, rounded up to a power of two,
+// shards <= 0 uses the default 32 shards.
+func NewSyncMap[K comparable, V any](shards int) *SyncMap[K, V] {
+	m := &SyncMap[K, V]{}
+	m.init(shards)
+	return m
+}
+
+// init allocates the shards once.
+func (m *SyncMap[K, V]) init(shards int) {
+	m.once.Do(func() {
+		if shards <= 0 {
+			shards = defaultShards
+		}
+		m.seed = maphash.MakeSeed()
+		m.shards = make([]syncShard[K, V], 1<<bucketShift(shards))
+		for i := range m.shards {
+			m.shards[i].items = make(map[K]V)
+		}
+	})
+}
+
+// shard returns the shard of key.
+func (m *SyncMap[K, V]) shard(key K) *syncShard[K, V] {
+	m.init(0)
+	if len(m.shards) == 1 {
+		return &m.shards[0]
+	}
+	return &m.shards[m.hash(key)&uint64(len(m.shards)-1)]
+}
+
+// hash returns the hash of key, the common key types are hashed directly and the
+// others from their fmt representation.
+func (m *SyncMap[K, V]) hash(key K) uint64 {
+	switch k := any(key).(type) {
+	case int:
+		return mixHash(uint64(k))
+	case int64:
+		return mixHash(uint64(k))
+	case int32:
+		return mixHash(uint64(k))
+	case uint:
+		return mixHash(uint64(k))
+	case uint64:
+		return mixHash(k)
+	case uint32:
+		return mixHash(uint64(k))
+	case uintptr:
+		return mixHash(uint64(k))
+	case float64:
+		return mixHash(math.Float64bits(k))
+	}
+	var h maphash.Hash
+	h.SetSeed(m.seed)
+	if k, ok := any(key).(string); ok {
+		_, _ = h.WriteString(k)
+	} else {
+		_, _ = fmt.Fprintf(&h, "%#v", key)
+	}
+	return h.Sum64()
+}
+
+// mixHash spreads the bits of an integer key, it's the finalizer of splitmix64.
+func mixHash(x uint64) uint64 {
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
+
+// Load returns the value of key.
+func (m *SyncMap[K, V]) Load(key K) (value V, ok bool) {
+	s := m.shard(key)
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+	value, ok = s.items[key]
+	return
+}
+
+// Store sets the value of key.
+func (m *SyncMap[K, V]) Store(key K, value V) {
+	s := m.shard(key)
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	s.items[key] = value
+}
+
+// LoadOrStore returns the value of key if present, otherwise it stores and returns value.
+// loaded reports whether the value was present.
+func (m *SyncMap[K, V]) LoadOrStore(key K, value V) (actual V, loaded bool) {
+	s := m.shard(key)
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	if actual, loaded = s.items[key]; loaded {
+		return actual, true
+	}
+	s.items[key] = value
+	return value, false
+}
+
+// Delete removes key, it reports whether key was present.
+func (m *SyncMap[K, V]) Delete(key K) bool {
+	s := m.shard(key)
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	_, ok := s.items[key]
+	delete(s.items, key)
+	return ok
+}
+
+// Update calls fn with the value of key and whether it is present, and stores the value
+// returned by fn if keep is true or deletes key otherwise, atomically: the other
+// operations on key wait for Update. fn must not use m. It returns the value returned
+// by fn and keep.
+func (m *SyncMap[K, V]) Update(key K, fn func(old V, loaded bool) (value V, keep bool)) (V, bool) {
+	s := m.shard(key)
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	old, loaded := s.items[key]
+	value, keep := fn(old, loaded)
+	if keep {
+		s.items[key] = value
+	} else {
+		delete(s.items, key)
+	}
+	return value, keep
+}
+
+// Len returns the number of keys.
+func (m *SyncMap[K, V]) Len() int {
+	m.init(0)
+	n := 0
+	for i := range m.shards {
+		s := &m.shards[i]
+		s.mtx.RLock()
+		n += len(s.items)
+		s.mtx.RUnlock()
+	}
+	return n
+}
+
+// Range calls fn for each key and value until fn returns false, in no particular order.
+// Each shard is copied before calling fn, so fn may use m, and the keys modified
+// concurrently may be seen or not.
+func (m *SyncMap[K, V]) Range(fn func(key K, value V) bool) {
+	m.init(0)
+	type pair struct {
+		key   K
+		value V
+	}
+	var pairs []pair
+	for i := range m.shards {
+		s := &m.shards[i]
+		s.mtx.RLock()
+		pairs = pairs[:0]
+		for k, v := range s.items {
+			pairs = append(pairs, pair{k, v})
+		}
+		s.mtx.RUnlock()
+		for _, p := range pairs {
+			if !fn(p.key, p.value) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,185 @@
+package lib
+
+import (
+	"sort"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSyncMap(t *testing.T) {
+	var m SyncMap[string, int]
+	_, ok := m.Load("a")
+	require.False(t, ok)
+	require.Equal(t, 0, m.Len())
+
+	m.Store("a", 1)
+	v, ok := m.Load("a")
+	require.True(t, ok)
+	require.Equal(t, 1, v)
+
+	actual, loaded := m.LoadOrStore("a", 2)
+	require.True(t, loaded)
+	require.Equal(t, 1, actual)
+	actual, loaded = m.LoadOrStore("b", 2)
+	require.False(t, loaded)
+	require.Equal(t, 2, actual)
+	require.Equal(t, 2, m.Len())
+
+	require.True(t, m.Delete("a"))
+	require.False(t, m.Delete("a"))
+	require.Equal(t, 1, m.Len())
+}
+
+func TestSyncMapUpdate(t *testing.T) {
+	m := NewSyncMap[string, []int](4)
+	appendValue := func(v int) func([]int, bool) ([]int, bool) {
+		return func(old []int, loaded bool) ([]int, bool) {
+			return append(old, v), true
+		}
+	}
+	v, keep := m.Update("k", appendValue(1))
+	require.True(t, keep)
+	require.Equal(t, []int{1}, v)
+	m.Update("k", appendValue(2))
+	v, _ = m.Load("k")
+	require.Equal(t, []int{1, 2}, v)
+
+	// returning keep false deletes the key
+	_, keep = m.Update("k", func(old []int, loaded bool) ([]int, bool) {
+		require.True(t, loaded)
+		return nil, false
+	})
+	require.False(t, keep)
+	_, ok := m.Load("k")
+	require.False(t, ok)
+}
+
+func TestSyncMapKeys(t *testing.T) {
+	type point struct{ X, Y int }
+	points := NewSyncMap[point, string](0)
+	points.Store(point{1, 2}, "a")
+	points.Store(point{2, 1}, "b")
+	v, ok := points.Load(point{1, 2})
+	require.True(t, ok)
+	require.Equal(t, "a", v)
+	require.Equal(t, 2, points.Len())
+
+	floats := NewSyncMap[float64, int](1)
+	floats.Store(1.5, 1)
+	v2, ok := floats.Load(1.5)
+	require.True(t, ok)
+	require.Equal(t, 1, v2)
+}
+
+func TestSyncMapRange(t *testing.T) {
+	m := NewSyncMap[int, int](8)
+	for i := 0; i < 100; i++ {
+		m.Store(i, i*i)
+	}
+	var keys []int
+	m.Range(func(key, value int) bool {
+		require.Equal(t, key*key, value)
+		keys = append(keys, key)
+		// fn may use the map
+		m.Delete(key)
+		return true
+	})
+	sort.Ints(keys)
+	require.Len(t, keys, 100)
+	require.Equal(t, 0, m.Len())
+
+	for i := 0; i < 100; i++ {
+		m.Store(i, i)
+	}
+	count := 0
+	m.Range(func(key, value int) bool {
+		count++
+		return count < 10
+	})
+	require.Equal(t, 10, count)
+}
+
+func TestSyncMapConcurrentUpdate(t *testing.T) {
+	m := NewSyncMap[string, int](16)
+	var wg sync.WaitGroup
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				m.Update(strconv.Itoa(j%10), func(old int, loaded bool) (int, bool) {
+					return old + 1, true
+				})
+				m.Load(strconv.Itoa(j % 10))
+			}
+		}()
+	}
+	wg.Wait()
+	total := 0
+	m.Range(func(key string, value int) bool {
+		total += value
+		return true
+	})
+	require.Equal(t, 16000, total)
+	require.Equal(t, 10, m.Len())
+}
+
+// mutexMap is the plain mutexed map SyncMap is compared with.
+type mutexMap struct {
+	mtx   sync.RWMutex
+	items map[int]int
+}
+
+// benchmarkMap runs a workload with a write every writeEvery operations.
+func benchmarkMap(b *testing.B, writeEvery int, load func(int) int, store func(int, int)) {
+	for i := 0; i < 1024; i++ {
+		store(i, i)
+	}
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for i := 0; pb.Next(); i++ {
+			if i%writeEvery == 0 {
+				store(i&1023, i)
+			} else {
+				load(i & 1023)
+			}
+		}
+	})
+}
+
+func BenchmarkSyncMap(b *testing.B) {
+	for _, workload := range []struct {
+		name       string
+		writeEvery int
+	}{{"read-heavy", 100}, {"write-heavy", 2}} {
+		b.Run(workload.name+"/SyncMap", func(b *testing.B) {
+			m := NewSyncMap[int, int](0)
+			benchmarkMap(b, workload.writeEvery, func(k int) int {
+				v, _ := m.Load(k)
+				return v
+			}, m.Store)
+		})
+		b.Run(workload.name+"/sync.Map", func(b *testing.B) {
+			var m sync.Map
+			benchmarkMap(b, workload.writeEvery, func(k int) int {
+				v, _ := m.Load(k)
+				return v.(int)
+			}, func(k, v int) { m.Store(k, v) })
+		})
+		b.Run(workload.name+"/mutex", func(b *testing.B) {
+			m := &mutexMap{items: make(map[int]int)}
+			benchmarkMap(b, workload.writeEvery, func(k int) int {
+				m.mtx.RLock()
+				defer m.mtx.RUnlock()
+				return m.items[k]
+			}, func(k, v int) {
+				m.mtx.Lock()
+				defer m.mtx.Unlock()
+				m.items[k] = v
+			})
+		})
+	}
+}
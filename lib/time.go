@@ -0,0 +1,91 @@
+package lib
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// weekStart is the time.Weekday the week buckets of TruncateTime start on.
+var weekStart = int32(time.Monday)
+
+// SetWeekStart sets the day the week buckets of TruncateTime and NextBoundary start
+// on, default is time.Monday.
+func SetWeekStart(day time.Weekday) {
+	atomic.StoreInt32(&weekStart, int32(day%7))
+}
+
+// WeekStart returns the day the week buckets start on, see SetWeekStart.
+func WeekStart() time.Weekday {
+	return time.Weekday(atomic.LoadInt32(&weekStart))
+}
+
+// wallClock returns the wall clock of t as a time in UTC, so the arithmetic on it
+// ignores the zone offset changes of t's location.
+func wallClock(t time.Time) time.Time {
+	y, mo, d := t.Date()
+	h, mi, s := t.Clock()
+	return time.Date(y, mo, d, h, mi, s, t.Nanosecond(), time.UTC)
+}
+
+// inLocation returns the time with the wall clock w in loc.
+func inLocation(w time.Time, loc *time.Location) time.Time {
+	y, mo, d := w.Date()
+	h, mi, s := w.Clock()
+	return time.Date(y, mo, d, h, mi, s, w.Nanosecond(), loc)
+}
+
+// bucketStart returns the wall clock of the start of the bucket containing the wall
+// clock w, the buckets are aligned on 0001-01-01 which is a Monday.
+func bucketStart(w time.Time, d time.Duration) time.Time {
+	if d%Week != 0 {
+		return w.Truncate(d)
+	}
+	shift := time.Duration((WeekStart()-time.Monday+7)%7) * Day
+	return w.Add(-shift).Truncate(d).Add(shift)
+}
+
+// TruncateTime returns the start of the bucket of size d containing t. Unlike
+// time.Truncate the buckets are aligned on the wall clock of t's location: a Day
+// bucket starts at local midnight even across a DST transition, a Week bucket starts
+// at midnight of WeekStart, and an hour bucket on the hour in a half-hour zone.
+// The buckets are counted from 0001-01-01, so d should divide a day or be a multiple
+// of it. TruncateTime returns t unchanged if d <= 0.
+func TruncateTime(t time.Time, d time.Duration) time.Time {
+	if d <= 0 {
+		return t
+	}
+	wall := wallClock(t)
+	start := bucketStart(wall, d)
+	ret := inLocation(start, t.Location())
+	// the wall clock is repeated when the clocks go back, the latest time with the
+	// wall clock of start that isn't after t is the start of the bucket
+	sameOffset := t.Add(-wall.Sub(start))
+	if ret.After(t) || sameOffset.After(ret) && wallClock(sameOffset).Equal(start) {
+		return sameOffset
+	}
+	return ret
+}
+
+// NextBoundary returns the start of the bucket following the one containing t, it's
+// always after t. See TruncateTime for the bucket alignment, e.g. NextBoundary with
+// Day is the next local midnight which may be 23 or 25 hours after the previous one.
+// NextBoundary returns t unchanged if d <= 0.
+func NextBoundary(t time.Time, d time.Duration) time.Time {
+	if d <= 0 {
+		return t
+	}
+	wall := wallClock(t)
+	next := bucketStart(wall, d).Add(d)
+	ret := inLocation(next, t.Location())
+	// keeping the offset of t finds the boundaries in a repeated wall clock
+	sameOffset := t.Add(next.Sub(wall))
+	if !ret.After(t) || sameOffset.Before(ret) && TruncateTime(sameOffset, d).Equal(sameOffset) {
+		return sameOffset
+	}
+	return ret
+}
+
+// UntilNextBoundary returns the duration from t to NextBoundary(t, d).
+func UntilNextBoundary(t time.Time, d time.Duration) time.Duration {
+	return NextBoundary(t, d).Sub(t)
+}
@@ -0,0 +1,115 @@
+package lib
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+	_ "time/tzdata"
+
+	"github.com/stretchr/testify/require"
+)
+
+func mustLoadLocation(t *testing.T, name string) *time.Location {
+	loc, err := time.LoadLocation(name)
+	require.NoError(t, err)
+	return loc
+}
+
+func TestTruncateTime(t *testing.T) {
+	ny := mustLoadLocation(t, "America/New_York")
+	kolkata := mustLoadLocation(t, "Asia/Kolkata")
+	date := func(loc *time.Location, mo time.Month, d, h, mi int) time.Time {
+		return time.Date(2024, mo, d, h, mi, 0, 0, loc)
+	}
+	// the clocks go back from 2024-11-03 01:59 EDT to 01:00 EST
+	firstOneThirty := date(time.UTC, time.November, 3, 5, 30).In(ny)
+	secondOneThirty := date(time.UTC, time.November, 3, 6, 30).In(ny)
+
+	cases := []struct {
+		Name     string
+		Time     time.Time
+		Duration time.Duration
+		Expect   time.Time
+		Next     time.Time
+	}{
+		{"utc quarter", date(time.UTC, time.May, 1, 10, 20), 15 * time.Minute,
+			date(time.UTC, time.May, 1, 10, 15), date(time.UTC, time.May, 1, 10, 30)},
+		{"local midnight", date(ny, time.May, 1, 22, 0), Day,
+			date(ny, time.May, 1, 0, 0), date(ny, time.May, 2, 0, 0)},
+		{"spring forward day", date(ny, time.March, 10, 15, 0), Day,
+			date(ny, time.March, 10, 0, 0), date(ny, time.March, 11, 0, 0)},
+		{"fall back day", date(ny, time.November, 3, 15, 0), Day,
+			date(ny, time.November, 3, 0, 0), date(ny, time.November, 4, 0, 0)},
+		{"spring forward 6h", date(ny, time.March, 10, 7, 30), 6 * time.Hour,
+			date(ny, time.March, 10, 6, 0), date(ny, time.March, 10, 12, 0)},
+		{"spring forward skipped hour", date(ny, time.March, 10, 1, 30), time.Hour,
+			date(ny, time.March, 10, 1, 0), date(ny, time.March, 10, 3, 0)},
+		{"first repeated hour", firstOneThirty, time.Hour,
+			firstOneThirty.Add(-30 * time.Minute), firstOneThirty.Add(30 * time.Minute)},
+		{"second repeated hour", secondOneThirty, 15 * time.Minute,
+			secondOneThirty, secondOneThirty.Add(15 * time.Minute)},
+		{"second repeated hour 20m", secondOneThirty.Add(5 * time.Minute), 20 * time.Minute,
+			secondOneThirty.Add(-10 * time.Minute), secondOneThirty.Add(10 * time.Minute)},
+		{"half hour zone", date(kolkata, time.May, 1, 10, 45), time.Hour,
+			date(kolkata, time.May, 1, 10, 0), date(kolkata, time.May, 1, 11, 0)},
+		{"week", date(ny, time.March, 13, 9, 0), Week,
+			date(ny, time.March, 11, 0, 0), date(ny, time.March, 18, 0, 0)},
+		{"week over dst", date(ny, time.March, 10, 9, 0), Week,
+			date(ny, time.March, 4, 0, 0), date(ny, time.March, 11, 0, 0)},
+		{"two days", date(time.UTC, time.January, 3, 9, 0), 2 * Day,
+			date(time.UTC, time.January, 2, 0, 0), date(time.UTC, time.January, 4, 0, 0)},
+	}
+	for _, c := range cases {
+		t.Run(c.Name, func(t *testing.T) {
+			truncated := TruncateTime(c.Time, c.Duration)
+			require.True(t, c.Expect.Equal(truncated), "%s != %s", c.Expect, truncated)
+			next := NextBoundary(c.Time, c.Duration)
+			require.True(t, c.Next.Equal(next), "%s != %s", c.Next, next)
+			require.Equal(t, c.Next.Sub(c.Time), UntilNextBoundary(c.Time, c.Duration))
+		})
+	}
+
+	now := time.Now()
+	require.Equal(t, now, TruncateTime(now, 0))
+	require.Equal(t, now, NextBoundary(now, -time.Hour))
+}
+
+func TestNextBoundaryDST(t *testing.T) {
+	ny := mustLoadLocation(t, "America/New_York")
+	spring := time.Date(2024, time.March, 10, 0, 0, 0, 0, ny)
+	require.Equal(t, 23*time.Hour, UntilNextBoundary(spring, Day))
+	fall := time.Date(2024, time.November, 3, 0, 0, 0, 0, ny)
+	require.Equal(t, 25*time.Hour, UntilNextBoundary(fall, Day))
+}
+
+func TestSetWeekStart(t *testing.T) {
+	defer SetWeekStart(WeekStart())
+	wednesday := time.Date(2024, time.March, 13, 9, 0, 0, 0, time.UTC)
+
+	SetWeekStart(time.Sunday)
+	require.Equal(t, time.Sunday, WeekStart())
+	require.Equal(t, time.Date(2024, time.March, 10, 0, 0, 0, 0, time.UTC), TruncateTime(wednesday, Week))
+	require.Equal(t, time.Date(2024, time.March, 17, 0, 0, 0, 0, time.UTC), NextBoundary(wednesday, Week))
+	// the day buckets are not affected
+	require.Equal(t, time.Date(2024, time.March, 13, 0, 0, 0, 0, time.UTC), TruncateTime(wednesday, Day))
+
+	SetWeekStart(time.Thursday)
+	require.Equal(t, time.Date(2024, time.March, 7, 0, 0, 0, 0, time.UTC), TruncateTime(wednesday, Week))
+}
+
+func TestTruncateTimeInvariants(t *testing.T) {
+	ny := mustLoadLocation(t, "America/New_York")
+	start := time.Date(2024, time.January, 1, 0, 0, 0, 0, ny).Unix()
+	durations := []time.Duration{time.Minute, 15 * time.Minute, time.Hour, 6 * time.Hour, Day, Week}
+	for i := 0; i < 10000; i++ {
+		now := time.Unix(start+rand.Int63n(int64(Year/time.Second)), 0).In(ny)
+		d := durations[rand.Intn(len(durations))]
+		truncated, next := TruncateTime(now, d), NextBoundary(now, d)
+		require.False(t, truncated.After(now), "%s %s", now, d)
+		require.True(t, next.After(now), "%s %s", now, d)
+		require.True(t, truncated.Equal(TruncateTime(truncated, d)), "%s %s", now, d)
+		require.True(t, next.Equal(NextBoundary(truncated, d)), "%s %s", now, d)
+		// a bucket is d long up to the DST offset change
+		require.InDelta(t, float64(d), float64(next.Sub(truncated)), float64(time.Hour), "%s %s", now, d)
+	}
+}
@@ -2,11 +2,11 @@ package lib
 
 import (
 	"fmt"
+	"math"
 	"strconv"
 	"strings"
 	"time"
 	"unicode"
-	"unsafe"
 )
 
 const (
@@ -21,16 +21,11 @@ const (
 
 	// Time constants.
 	Day   = 24 * time.Hour
+	Week  = 7 * Day
 	Month = 30 * Day
 	Year  = 12 * Month
 )
 
-// ToString converts a byte slice to a string.
-// The string is not copied, but the underlying memory is shared.
-func ToString(b []byte) string {
-	return *(*string)(unsafe.Pointer(&b))
-}
-
 // SliceHeader is the runtime representation of a slice.
 // references: GOROOT:go/src/reflect/value.go
 type SliceHeader struct {
@@ -46,87 +41,164 @@ type StringHeader struct {
 	Len  int
 }
 
-// ToBytes converts a string to a byte slice.
-// The string is not copied, but the underlying memory is shared.
-func ToBytes(s string) []byte {
-	// ensure the cap field is set correctly
-	sliceHeader := SliceHeader{}
-	stringHeader := (*StringHeader)(unsafe.Pointer(&s))
-	sliceHeader.Data = stringHeader.Data
-	sliceHeader.Len = stringHeader.Len
-	sliceHeader.Cap = stringHeader.Len
-	return *(*[]byte)(unsafe.Pointer(&sliceHeader))
+// CloneString returns a string holding a copy of b, it stays valid whatever
+// happens to b afterward.
+func CloneString(b []byte) string {
+	return string(b)
+}
+
+// CloneBytes returns a byte slice holding a copy of s.
+func CloneBytes(s string) []byte {
+	return []byte(s)
+}
+
+// AppendString appends s to dst without converting it to a byte slice, e.g. to build
+// an entry in a reused buffer.
+func AppendString(dst []byte, s string) []byte {
+	return append(dst, s...)
+}
+
+// AppendBytesAsString returns s followed by b with a single allocation, without
+// converting b to a string first.
+func AppendBytesAsString(s string, b []byte) string {
+	var sb strings.Builder
+	sb.Grow(len(s) + len(b))
+	sb.WriteString(s)
+	sb.Write(b)
+	return sb.String()
+}
+
+// ToBytesMutable converts a string to a byte slice that may be modified.
+// Unlike ToBytes, the bytes are always copied, since a string may be stored in
+// read-only memory and there is no way to tell it at runtime.
+func ToBytesMutable(s string) []byte {
+	return CloneBytes(s)
+}
+
+// UnitSystem selects how byte sizes are scaled and labeled.
+type UnitSystem int
+
+const (
+	// UnitJEDEC uses powers of 1024 labeled KB, MB, ..., it's the default of Size2String and String2Size.
+	UnitJEDEC UnitSystem = iota
+	// UnitIEC uses powers of 1024 labeled KiB, MiB, ...
+	UnitIEC
+	// UnitSI uses powers of 1000 labeled KB, MB, ...
+	UnitSI
+)
+
+// sizeUnitPrefixes are the prefixes of the size units, from KB to EB.
+const sizeUnitPrefixes = "KMGTPE"
+
+// base returns the factor between two consecutive units.
+func (u UnitSystem) base() int64 {
+	if u == UnitSI {
+		return 1000
+	}
+	return 1024
+}
+
+// label returns the label of the i-th unit, 0 is a byte.
+func (u UnitSystem) label(i int) string {
+	switch {
+	case i == 0:
+		return "B"
+	case u == UnitIEC:
+		return sizeUnitPrefixes[i-1:i] + "iB"
+	default:
+		return sizeUnitPrefixes[i-1:i] + "B"
+	}
 }
 
 // Size2String converts a size in bytes to a string in the format of "1024" or "1024 KB" or "1024 MB" or "1024 GB" or
 // "1024 TB" or "1024 PB" or "1024 EB".
 // The unit is chosen automatically based on the size.
 // If the size is too large to be represented in the largest unit, it is rounded to the nearest multiple of the largest unit.
-// If the size is negative or zero, it is returned as "0 B".
+// If the size is negative, an error is returned.
 func Size2String(size int64) (string, error) {
-	switch {
-	case size < 0:
+	return Size2StringOpt(size, UnitJEDEC, 2)
+}
+
+// Size2StringOpt is like Size2String, but the units are taken from the unit system
+// and the value is formatted with precision decimals, e.g.
+// Size2StringOpt(1500, UnitSI, 1) returns "1.5 KB" and Size2StringOpt(1536, UnitIEC, 2)
+// returns "1.50 KiB". Sizes smaller than one unit are always printed in bytes.
+func Size2StringOpt(size int64, unit UnitSystem, precision int) (string, error) {
+	if size < 0 {
 		return "", fmt.Errorf("size is negative: %d", size)
-	case size < KB:
+	}
+	if precision < 0 {
+		return "", fmt.Errorf("precision is negative: %d", precision)
+	}
+	base := unit.base()
+	if size < base {
 		return fmt.Sprintf("%d B", size), nil
-	case size < MB:
-		return fmt.Sprintf("%.2f KB", float64(size)/float64(KB)), nil
-	case size < GB:
-		return fmt.Sprintf("%.2f MB", float64(size)/float64(MB)), nil
-	case size < TB:
-		return fmt.Sprintf("%.2f GB", float64(size)/float64(GB)), nil
-	case size < PB:
-		return fmt.Sprintf("%.2f TB", float64(size)/float64(TB)), nil
-	case size < EB:
-		return fmt.Sprintf("%.2f PB", float64(size)/float64(PB)), nil
-	default:
-		return fmt.Sprintf("%.2f EB", float64(size)/float64(EB)), nil
 	}
+	index, power := 1, base
+	for index < len(sizeUnitPrefixes) && size/power >= base {
+		index++
+		power *= base
+	}
+	return fmt.Sprintf("%.*f %s", precision, float64(size)/float64(power), unit.label(index)), nil
 }
 
 // String2Size converts a string to a size in bytes.
 // The string should be in the format of "1024" or "1024 KB" or "1024 MB" or "1024 GB" or "1024 TB" or "1024 PB"
 // or "1024 EB".
 // The unit can be "KB", "MB", "GB", "TB", "PB", "EB", "K", "M", "G", "T", "P", "E", "KiB", "MiB", "GiB", "TiB",
-// "PiB", "EiB", or empty.
+// "PiB", "EiB", "B", or empty, and it is case-insensitive. All units are powers of 1024.
 // If the unit is empty, it is assumed to be "B".
 // If the string is invalid, an error is returned.
 func String2Size(size string) (ret int64, err error) {
-	if size == "" {
+	return String2SizeOpt(size, UnitJEDEC)
+}
+
+// String2SizeOpt is like String2Size, but the units without "i" are scaled by the
+// unit system: with UnitSI "1.5 KB" is 1500 bytes, otherwise 1536 bytes. The IEC units
+// such as "KiB" are always powers of 1024.
+func String2SizeOpt(size string, unit UnitSystem) (int64, error) {
+	text := strings.TrimSpace(size)
+	if text == "" {
 		return 0, nil
 	}
-	if size[0] == '-' {
+	if text[0] == '-' {
 		return 0, fmt.Errorf("size cannot be negative: %s", size)
 	}
-	index := strings.IndexFunc(size, func(r rune) bool {
+	index := strings.IndexFunc(text, func(r rune) bool {
 		return !unicode.IsNumber(r) && r != '.'
 	})
 	if index == -1 {
-		index = len(size)
+		index = len(text)
 	}
-	unit := strings.TrimSpace(size[index:])
-	value := strings.TrimSpace(size[:index])
+	value := strings.TrimSpace(text[:index])
+	suffix := strings.ToLower(strings.TrimSpace(text[index:]))
 	power := int64(1)
-	switch strings.ToLower(unit) {
-	case "", "byte":
-	case "kb", "k", "kib":
-		power = KB
-	case "mb", "m", "mib":
-		power = MB
-	case "gb", "g", "gib":
-		power = GB
-	case "tb", "t", "tib":
-		power = TB
-	case "pb", "p", "pib":
-		power = PB
-	case "eb", "e", "eib":
-		power = EB
+	switch suffix {
+	case "", "b", "byte", "bytes":
 	default:
-		return 0, fmt.Errorf("invalid size: %s", size)
+		exponent := strings.IndexByte(strings.ToLower(sizeUnitPrefixes), suffix[0]) + 1
+		if exponent == 0 {
+			return 0, fmt.Errorf("invalid size: %s", size)
+		}
+		base := unit.base()
+		switch suffix[1:] {
+		case "", "b":
+		case "ib":
+			base = 1024
+		default:
+			return 0, fmt.Errorf("invalid size: %s", size)
+		}
+		for ; exponent > 0; exponent-- {
+			power *= base
+		}
 	}
 	fret, err := strconv.ParseFloat(value, 64)
 	if err != nil {
 		return 0, fmt.Errorf("invalid size: %s", size)
 	}
-	return int64(fret * float64(power)), nil
+	ret := fret * float64(power)
+	if ret >= math.MaxInt64 {
+		return 0, fmt.Errorf("size overflows int64: %s", size)
+	}
+	return int64(ret), nil
 }
@@ -1,8 +1,12 @@
 package lib
 
 import (
-	"github.com/stretchr/testify/require"
+	"bytes"
+	"math"
+	"runtime/debug"
 	"testing"
+
+	"github.com/stretchr/testify/require"
 )
 
 func Test2String(t *testing.T) {
@@ -135,3 +139,187 @@ func TestString2Size(t *testing.T) {
 		require.Errorf(t, err, "invalid size ")
 	}
 }
+
+func TestSize2StringOpt(t *testing.T) {
+	cases := []struct {
+		Name      string
+		Size      int64
+		Unit      UnitSystem
+		Precision int
+		Expect    string
+	}{
+		{"bytes", 999, UnitSI, 2, "999 B"},
+		{"si kilo", 1500, UnitSI, 1, "1.5 KB"},
+		{"si giga", 2_500_000_000, UnitSI, 2, "2.50 GB"},
+		{"iec kibi", 1536, UnitIEC, 2, "1.50 KiB"},
+		{"iec mebi", 3 * MB, UnitIEC, 0, "3 MiB"},
+		{"iec bytes", 1023, UnitIEC, 2, "1023 B"},
+		{"jedec default", KB, UnitJEDEC, 2, "1.00 KB"},
+		{"jedec precision", 1126, UnitJEDEC, 3, "1.100 KB"},
+		{"largest unit", math.MaxInt64, UnitSI, 2, "9.22 EB"},
+	}
+	for _, c := range cases {
+		t.Run(c.Name, func(t *testing.T) {
+			s, err := Size2StringOpt(c.Size, c.Unit, c.Precision)
+			require.NoError(t, err)
+			require.Equal(t, c.Expect, s)
+		})
+	}
+
+	_, err := Size2StringOpt(-1, UnitSI, 2)
+	require.Error(t, err)
+	_, err = Size2StringOpt(1, UnitSI, -1)
+	require.Error(t, err)
+}
+
+func TestString2SizeOpt(t *testing.T) {
+	cases := []struct {
+		Name   string
+		Text   string
+		Unit   UnitSystem
+		Expect int64
+	}{
+		{"bytes", "12 B", UnitSI, 12},
+		{"bytes word", "12 bytes", UnitSI, 12},
+		{"si", "1 KB", UnitSI, 1000},
+		{"si fraction", "1.5GB", UnitSI, 1_500_000_000},
+		{"si short", "2m", UnitSI, 2_000_000},
+		{"si explicit iec", "1 KiB", UnitSI, 1024},
+		{"iec", "1 KB", UnitIEC, 1024},
+		{"lowercase", "1.5 mib", UnitIEC, 3 * MB / 2},
+		{"whitespace", "  10   kb  ", UnitJEDEC, 10 * KB},
+	}
+	for _, c := range cases {
+		t.Run(c.Name, func(t *testing.T) {
+			size, err := String2SizeOpt(c.Text, c.Unit)
+			require.NoError(t, err)
+			require.Equal(t, c.Expect, size)
+		})
+	}
+
+	for _, text := range []string{"-1 KB", "1 KX", "1 kibb", "1 X", "KB", "8 EiB", "10 EB"} {
+		_, err := String2SizeOpt(text, UnitIEC)
+		require.Error(t, err, text)
+	}
+}
+
+func TestSizeRoundTrip(t *testing.T) {
+	for _, unit := range []UnitSystem{UnitJEDEC, UnitIEC, UnitSI} {
+		base := unit.base()
+		for exponent, power := 0, int64(1); exponent <= len(sizeUnitPrefixes); exponent, power = exponent+1, power*base {
+			for _, factor := range []float64{1, 1.5, 3.25, 999} {
+				size := int64(factor * float64(power))
+				if exponent == len(sizeUnitPrefixes) && factor > 7 {
+					continue
+				}
+				text, err := Size2StringOpt(size, unit, 3)
+				require.NoError(t, err)
+				parsed, err := String2SizeOpt(text, unit)
+				require.NoError(t, err, text)
+				// the formatted value keeps 3 decimals of the unit
+				require.InDelta(t, float64(size), float64(parsed), float64(power)/1000+1, text)
+			}
+		}
+	}
+}
+
+func TestCloneString(t *testing.T) {
+	b := []byte("hello")
+	shared, cloned := ToString(b), CloneString(b)
+	b[0] = 'j'
+	if sharedConversions {
+		require.Equal(t, "jello", shared)
+	} else {
+		require.Equal(t, "hello", shared)
+	}
+	require.Equal(t, "hello", cloned)
+	require.Equal(t, "", CloneString(nil))
+}
+
+func TestCloneBytes(t *testing.T) {
+	s := "hello"
+	for _, clone := range []func(string) []byte{CloneBytes, ToBytesMutable} {
+		b := clone(s)
+		b[0] = 'j'
+		require.Equal(t, "jello", string(b))
+		require.Equal(t, "hello", s)
+		require.Equal(t, "hello", string(ToBytes(s)))
+	}
+	require.Equal(t, []byte{}, CloneBytes(""))
+}
+
+func TestToBytesReadOnly(t *testing.T) {
+	if !sharedConversions {
+		t.Skip("ToBytes copies the string")
+	}
+	// the result of ToBytes on a literal is read-only, writing to it faults
+	defer debug.SetPanicOnFault(debug.SetPanicOnFault(true))
+	b := ToBytes("read-only literal")
+	require.Panics(t, func() {
+		b[0] = 'R'
+	})
+	// the other helpers are unaffected
+	require.Equal(t, "read-only literal", CloneString(b))
+	require.Equal(t, "read-only literal", string(ToBytesMutable("read-only literal")))
+}
+
+func TestAppendString(t *testing.T) {
+	buf := make([]byte, 0, 16)
+	buf = AppendString(buf, "hello")
+	buf = AppendString(buf, "")
+	buf = AppendString(buf, " world")
+	require.Equal(t, "hello world", string(buf))
+	require.Equal(t, "hello", string(AppendString(nil, "hello")))
+}
+
+func TestAppendBytesAsString(t *testing.T) {
+	b := []byte(" world")
+	s := AppendBytesAsString("hello", b)
+	b[0] = '-'
+	require.Equal(t, "hello world", s)
+	require.Equal(t, "", AppendBytesAsString("", nil))
+	require.Equal(t, 1.0, testing.AllocsPerRun(10, func() {
+		_ = AppendBytesAsString("hello", b)
+	}))
+}
+
+// BenchmarkConversions compares the conversions sharing the memory, unless built with
+// the purego or the safe tag, with the copying ones.
+func BenchmarkConversions(b *testing.B) {
+	data := bytes.Repeat([]byte("x"), 256)
+	text := string(data)
+	var sinkString string
+	var sinkBytes []byte
+	b.Run("ToString", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			sinkString = ToString(data)
+		}
+	})
+	b.Run("CloneString", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			sinkString = CloneString(data)
+		}
+	})
+	b.Run("ToBytes", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			sinkBytes = ToBytes(text)
+		}
+	})
+	b.Run("CloneBytes", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			sinkBytes = CloneBytes(text)
+		}
+	})
+	b.Run("AppendString", func(b *testing.B) {
+		b.ReportAllocs()
+		buf := make([]byte, 0, len(text))
+		for i := 0; i < b.N; i++ {
+			sinkBytes = AppendString(buf[:0], text)
+		}
+	})
+	_, _ = sinkString, sinkBytes
+}
@@ -0,0 +1,33 @@
+//go:build !purego && !safe
+
+package lib
+
+import "unsafe"
+
+// sharedConversions reports whether ToString and ToBytes share the memory of their
+// argument, they copy it if built with the purego or the safe tag.
+const sharedConversions = true
+
+// ToString converts a byte slice to a string.
+// The string is not copied, but the underlying memory is shared: the bytes must not be
+// modified while the string is in use, use CloneString if the caller may mutate them.
+// It copies the bytes if built with the purego or the safe tag.
+func ToString(b []byte) string {
+	return *(*string)(unsafe.Pointer(&b))
+}
+
+// ToBytes converts a string to a byte slice.
+// The string is not copied, but the underlying memory is shared: the returned slice is
+// read-only, writing to it breaks the immutability of strings and faults with an
+// unrecoverable crash if s is a literal stored in read-only memory. Use ToBytesMutable
+// or CloneBytes when the slice may be modified or retained by the receiver.
+// It copies the string if built with the purego or the safe tag.
+func ToBytes(s string) []byte {
+	// ensure the cap field is set correctly
+	sliceHeader := SliceHeader{}
+	stringHeader := (*StringHeader)(unsafe.Pointer(&s))
+	sliceHeader.Data = stringHeader.Data
+	sliceHeader.Len = stringHeader.Len
+	sliceHeader.Cap = stringHeader.Len
+	return *(*[]byte)(unsafe.Pointer(&sliceHeader))
+}
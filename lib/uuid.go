@@ -0,0 +1,118 @@
+package lib
+
+import (
+	crand "crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync"
+)
+
+// InvalidUUIDError is returned by ParseUUID for a malformed UUID.
+var InvalidUUIDError = errors.New("invalid UUID")
+
+// uuidV7Generator keeps the millisecond and the counter of the last UUID7 to make
+// UUID7 monotonic.
+var uuidV7Generator struct {
+	sync.Mutex
+	ms      int64
+	counter uint16
+}
+
+// randomBytes fills b with random bytes from crypto/rand, math/rand if it fails.
+func randomBytes(b []byte) {
+	if _, err := crand.Read(b); err != nil {
+		_, _ = rand.Read(b)
+	}
+}
+
+// UUID4 returns a random UUID of version 4, e.g. "0b8bd9ab-3b59-4c4e-9b1c-8e34e0b0a7f2",
+// made of 122 bits from crypto/rand. Prefer UUID7 when the IDs are sorted or indexed,
+// and NewID for shorter sortable IDs.
+func UUID4() string {
+	var u [16]byte
+	randomBytes(u[:])
+	return formatUUID(setVersion(u, 4))
+}
+
+// UUID7 returns a UUID of version 7, RFC 9562: a 48-bit Unix millisecond timestamp,
+// a 12-bit counter and 62 random bits. The UUIDs sort lexicographically in the order
+// of creation: the counter starts from a random value below 2048 every millisecond and
+// is incremented by the UUIDs of the same millisecond, or while the clock goes back.
+// Prefer UUID7 for the keys of databases and logs, UUID4 when the creation time must
+// not be disclosed.
+func UUID7() string {
+	g := &uuidV7Generator
+	g.Lock()
+	ms := idNow().UnixMilli()
+	if ms > g.ms {
+		g.ms = ms
+		g.counter = uint16(rand.Intn(1 << 11))
+	} else if g.counter++; g.counter > 0xfff {
+		// the counter overflowed, borrow the next millisecond
+		g.ms++
+		g.counter = 0
+	}
+	ms, counter := g.ms, g.counter
+	g.Unlock()
+
+	var u [16]byte
+	randomBytes(u[8:])
+	for i := 5; i >= 0; i-- {
+		u[i] = byte(ms)
+		ms >>= 8
+	}
+	u[6] = byte(counter >> 8)
+	u[7] = byte(counter)
+	return formatUUID(setVersion(u, 7))
+}
+
+// setVersion sets the version and the RFC 9562 variant bits of u.
+func setVersion(u [16]byte, version byte) [16]byte {
+	u[6] = u[6]&0x0f | version<<4
+	u[8] = u[8]&0x3f | 0x80
+	return u
+}
+
+// formatUUID returns u in the canonical 8-4-4-4-12 hex form.
+func formatUUID(u [16]byte) string {
+	var b [36]byte
+	hex.Encode(b[0:8], u[0:4])
+	b[8] = '-'
+	hex.Encode(b[9:13], u[4:6])
+	b[13] = '-'
+	hex.Encode(b[14:18], u[6:8])
+	b[18] = '-'
+	hex.Encode(b[19:23], u[8:10])
+	b[23] = '-'
+	hex.Encode(b[24:], u[10:])
+	return string(b[:])
+}
+
+// ParseUUID parses a UUID in the canonical 8-4-4-4-12 hex form, case-insensitive. It
+// returns InvalidUUIDError if s is malformed, or if its version isn't 1 to 8 or its
+// variant isn't the RFC 9562 one, e.g. for the nil UUID.
+func ParseUUID(s string) ([16]byte, error) {
+	var u [16]byte
+	if len(s) != 36 || s[8] != '-' || s[13] != '-' || s[18] != '-' || s[23] != '-' {
+		return u, fmt.Errorf("%w: %q", InvalidUUIDError, s)
+	}
+	text := s[0:8] + s[9:13] + s[14:18] + s[19:23] + s[24:]
+	if _, err := hex.Decode(u[:], []byte(text)); err != nil {
+		return u, fmt.Errorf("%w: %q", InvalidUUIDError, s)
+	}
+	if version := u[6] >> 4; version < 1 || version > 8 {
+		return u, fmt.Errorf("%w: %q has the version %d", InvalidUUIDError, s, version)
+	}
+	if u[8]&0xc0 != 0x80 {
+		return u, fmt.Errorf("%w: %q has not the RFC 9562 variant", InvalidUUIDError, s)
+	}
+	return u, nil
+}
+
+// IsUUID reports whether s is a valid UUID, see ParseUUID.
+func IsUUID(s string) bool {
+	_, err := ParseUUID(s)
+	return err == nil
+}
@@ -0,0 +1,101 @@
+package lib
+
+import (
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestUUID4(t *testing.T) {
+	seen := make(map[string]bool, 100000)
+	for i := 0; i < 100000; i++ {
+		id := UUID4()
+		require.False(t, seen[id], id)
+		seen[id] = true
+	}
+	id := UUID4()
+	u, err := ParseUUID(id)
+	require.NoError(t, err)
+	require.Equal(t, byte(4), u[6]>>4)
+	require.Equal(t, byte(0x80), u[8]&0xc0)
+	require.Equal(t, byte('4'), id[14])
+}
+
+func TestUUID7(t *testing.T) {
+	ids := make([]string, 100000)
+	seen := make(map[string]bool, len(ids))
+	for i := range ids {
+		ids[i] = UUID7()
+		require.False(t, seen[ids[i]], ids[i])
+		seen[ids[i]] = true
+	}
+	require.True(t, sort.StringsAreSorted(ids))
+
+	u, err := ParseUUID(ids[0])
+	require.NoError(t, err)
+	require.Equal(t, byte(7), u[6]>>4)
+	require.Equal(t, byte(0x80), u[8]&0xc0)
+	ms := int64(0)
+	for _, b := range u[:6] {
+		ms = ms<<8 | int64(b)
+	}
+	require.InDelta(t, time.Now().UnixMilli(), ms, float64(time.Minute.Milliseconds()))
+}
+
+func TestUUID7Monotonic(t *testing.T) {
+	origin := idNow
+	defer func() { idNow = origin }()
+	now := time.Unix(1700000000, 0)
+	idNow = func() time.Time { return now }
+
+	// the same millisecond overflows the counter
+	ids := make([]string, 5000)
+	for i := range ids {
+		ids[i] = UUID7()
+	}
+	require.True(t, sort.StringsAreSorted(ids))
+
+	// the clock goes back
+	now = now.Add(-time.Second)
+	back := UUID7()
+	require.Greater(t, back, ids[len(ids)-1])
+
+	// a later millisecond
+	now = now.Add(time.Hour)
+	require.Greater(t, UUID7(), back)
+}
+
+func TestParseUUID(t *testing.T) {
+	cases := []struct {
+		name string
+		uuid string
+		err  string
+	}{
+		{"v4", "0b8bd9ab-3b59-4c4e-9b1c-8e34e0b0a7f2", ""},
+		{"v7 upper case", "018BCFE5-6800-7000-8000-000000000000", ""},
+		{"v1", "6ba7b810-9dad-11d1-80b4-00c04fd430c8", ""},
+		{"nil", "00000000-0000-0000-0000-000000000000", "has the version 0"},
+		{"version 9", "0b8bd9ab-3b59-9c4e-9b1c-8e34e0b0a7f2", "has the version 9"},
+		{"microsoft variant", "0b8bd9ab-3b59-4c4e-cb1c-8e34e0b0a7f2", "has not the RFC 9562 variant"},
+		{"no hyphens", "0b8bd9ab3b594c4e9b1c8e34e0b0a7f2", "invalid UUID"},
+		{"misplaced hyphen", "0b8bd9a-b3b59-4c4e-9b1c-8e34e0b0a7f2", "invalid UUID"},
+		{"not hex", "0b8bd9ab-3b59-4c4e-9b1c-8e34e0b0a7fz", "invalid UUID"},
+		{"empty", "", "invalid UUID"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			u, err := ParseUUID(c.uuid)
+			require.Equal(t, c.err == "", IsUUID(c.uuid))
+			if c.err != "" {
+				require.ErrorIs(t, err, InvalidUUIDError)
+				require.ErrorContains(t, err, c.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, strings.ToLower(c.uuid), formatUUID(u))
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package lib
+
+// If returns a if cond is true, b otherwise. Both a and b are evaluated, e.g.
+//
+//	unit := lib.If(n == 1, "file", "files")
+func If[T any](cond bool, a, b T) T {
+	if cond {
+		return a
+	}
+	return b
+}
+
+// Coalesce returns the first value of vals that is not the zero value, or the zero
+// value if there is none, e.g.
+//
+//	prefix := lib.Coalesce(opt.BackupPrefix, os.Getenv("BACKUP_PREFIX"), "rotating-")
+func Coalesce[T comparable](vals ...T) T {
+	var zero T
+	for _, v := range vals {
+		if v != zero {
+			return v
+		}
+	}
+	return zero
+}
+
+// Zero returns the zero value of T.
+func Zero[T any]() T {
+	var zero T
+	return zero
+}
+
+// IsZero reports whether v is the zero value of T.
+func IsZero[T comparable](v T) bool {
+	var zero T
+	return v == zero
+}
+
+// Ptr returns a pointer to a copy of v, it's handy for the optional fields of
+// literals, e.g.
+//
+//	opt := Option{Timeout: lib.Ptr(time.Second)}
+func Ptr[T any](v T) *T {
+	return &v
+}
+
+// Deref returns the value p points to, or def if p is nil.
+func Deref[T any](p *T, def T) T {
+	if p == nil {
+		return def
+	}
+	return *p
+}
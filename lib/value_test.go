@@ -0,0 +1,57 @@
+package lib
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIf(t *testing.T) {
+	require.Equal(t, "file", If(true, "file", "files"))
+	require.Equal(t, "files", If(false, "file", "files"))
+	require.Equal(t, 2, If(1 > 2, 1, 2))
+}
+
+func TestCoalesce(t *testing.T) {
+	require.Equal(t, "", Coalesce[string]())
+	require.Equal(t, "", Coalesce("", ""))
+	require.Equal(t, "b", Coalesce("", "b", "c"))
+	require.Equal(t, 3, Coalesce(0, 0, 3))
+	var p *int
+	v := 1
+	require.Equal(t, &v, Coalesce(p, &v))
+}
+
+func TestZero(t *testing.T) {
+	require.Equal(t, 0, Zero[int]())
+	require.Equal(t, "", Zero[string]())
+	require.Nil(t, Zero[*int]())
+	require.Nil(t, Zero[[]int]())
+	require.Equal(t, time.Time{}, Zero[time.Time]())
+}
+
+func TestIsZero(t *testing.T) {
+	require.True(t, IsZero(0))
+	require.True(t, IsZero(""))
+	require.True(t, IsZero(time.Duration(0)))
+	require.True(t, IsZero(struct{ A int }{}))
+	require.False(t, IsZero(1))
+	require.False(t, IsZero("a"))
+	require.False(t, IsZero(struct{ A int }{A: 1}))
+}
+
+func TestPtr(t *testing.T) {
+	v := 1
+	p := Ptr(v)
+	require.Equal(t, 1, *p)
+	*p = 2
+	require.Equal(t, 1, v)
+	require.Equal(t, time.Second, *Ptr(time.Second))
+}
+
+func TestDeref(t *testing.T) {
+	require.Equal(t, 3, Deref(nil, 3))
+	require.Equal(t, 1, Deref(Ptr(1), 3))
+	require.Equal(t, "", Deref(Ptr(""), "def"))
+}
@@ -0,0 +1,261 @@
+package lib
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var InvalidVersionError = errors.New("invalid version")
+
+// Version is a semantic version, see https://semver.org.
+type Version struct {
+	Major, Minor, Patch uint64
+	// Prerelease is the dot-separated pre-release identifiers, e.g. "rc.1".
+	Prerelease string
+	// Build is the build metadata, it's ignored by the comparisons.
+	Build string
+}
+
+// ParseVersion parses a semantic version like "v1.2.3-rc.1+build5". The leading "v" is
+// optional, and so are the minor and patch numbers, which default to 0: "v1.2" is 1.2.0.
+func ParseVersion(s string) (Version, error) {
+	v, _, err := parseVersion(s)
+	return v, err
+}
+
+// parseVersion parses s and returns the number of numeric parts specified.
+func parseVersion(s string) (v Version, parts int, err error) {
+	text := strings.TrimSpace(s)
+	if text != "" && (text[0] == 'v' || text[0] == 'V') {
+		text = text[1:]
+	}
+	if i := strings.IndexByte(text, '+'); i >= 0 {
+		text, v.Build = text[:i], text[i+1:]
+		if !validIdentifiers(v.Build, false) {
+			return Version{}, 0, fmt.Errorf("%w: %q, invalid build metadata", InvalidVersionError, s)
+		}
+	}
+	if i := strings.IndexByte(text, '-'); i >= 0 {
+		text, v.Prerelease = text[:i], text[i+1:]
+		if !validIdentifiers(v.Prerelease, true) {
+			return Version{}, 0, fmt.Errorf("%w: %q, invalid pre-release", InvalidVersionError, s)
+		}
+	}
+	numbers := strings.Split(text, ".")
+	if len(numbers) > 3 {
+		return Version{}, 0, fmt.Errorf("%w: %q", InvalidVersionError, s)
+	}
+	fields := []*uint64{&v.Major, &v.Minor, &v.Patch}
+	for i, number := range numbers {
+		if !isNumeric(number) || (len(number) > 1 && number[0] == '0') {
+			return Version{}, 0, fmt.Errorf("%w: %q", InvalidVersionError, s)
+		}
+		if *fields[i], err = strconv.ParseUint(number, 10, 64); err != nil {
+			return Version{}, 0, fmt.Errorf("%w: %q, %s", InvalidVersionError, s, err)
+		}
+	}
+	return v, len(numbers), nil
+}
+
+// isNumeric reports whether s is a non-empty string of ASCII digits.
+func isNumeric(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// validIdentifiers reports whether s is a dot-separated list of identifiers made of
+// [0-9A-Za-z-], the numeric identifiers of a pre-release have no leading zero.
+func validIdentifiers(s string, prerelease bool) bool {
+	for _, id := range strings.Split(s, ".") {
+		if id == "" {
+			return false
+		}
+		for i := 0; i < len(id); i++ {
+			c := id[i]
+			if !(c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c == '-') {
+				return false
+			}
+		}
+		if prerelease && len(id) > 1 && id[0] == '0' && isNumeric(id) {
+			return false
+		}
+	}
+	return true
+}
+
+// String returns the version in the form "1.2.3-rc.1+build5", without a leading "v".
+func (v Version) String() string {
+	s := fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+	if v.Prerelease != "" {
+		s += "-" + v.Prerelease
+	}
+	if v.Build != "" {
+		s += "+" + v.Build
+	}
+	return s
+}
+
+// Compare returns -1, 0 or 1 if v has a lower, the same or a higher precedence than
+// other. The build metadata is ignored, and a pre-release version has a lower precedence
+// than the normal version, e.g. 1.0.0-alpha < 1.0.0-alpha.1 < 1.0.0-beta < 1.0.0.
+func (v Version) Compare(other Version) int {
+	for _, pair := range [][2]uint64{{v.Major, other.Major}, {v.Minor, other.Minor}, {v.Patch, other.Patch}} {
+		if pair[0] != pair[1] {
+			return If(pair[0] < pair[1], -1, 1)
+		}
+	}
+	switch {
+	case v.Prerelease == other.Prerelease:
+		return 0
+	case v.Prerelease == "":
+		return 1
+	case other.Prerelease == "":
+		return -1
+	}
+	a, b := strings.Split(v.Prerelease, "."), strings.Split(other.Prerelease, ".")
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if c := compareIdentifier(a[i], b[i]); c != 0 {
+			return c
+		}
+	}
+	switch {
+	case len(a) < len(b):
+		return -1
+	case len(a) > len(b):
+		return 1
+	}
+	return 0
+}
+
+// compareIdentifier compares two pre-release identifiers: numeric identifiers are
+// compared numerically and have a lower precedence than alphanumeric ones, which
+// are compared lexically.
+func compareIdentifier(a, b string) int {
+	an, bn := isNumeric(a), isNumeric(b)
+	switch {
+	case an && bn:
+		if len(a) != len(b) {
+			return If(len(a) < len(b), -1, 1)
+		}
+	case an:
+		return -1
+	case bn:
+		return 1
+	}
+	return strings.Compare(a, b)
+}
+
+// LessThan reports whether v has a lower precedence than other.
+func (v Version) LessThan(other Version) bool {
+	return v.Compare(other) < 0
+}
+
+// Satisfies reports whether v matches constraint. A constraint is a list of comparisons
+// separated by commas or spaces, which must all match, and the lists may be combined with
+// "||", e.g. ">=1.2, <2.0 || >=3". The comparisons are:
+//
+//	=1.2.3, 1.2.3   equal, a missing minor or patch matches any, "1.2" is ~1.2
+//	!=1.2.3         not equal
+//	>, >=, <, <=    ordered, a missing minor or patch is 0
+//	~1.2.3          patch updates, >=1.2.3 <1.3.0; ~1 is >=1.0.0 <2.0.0
+//	^1.2.3          compatible updates, >=1.2.3 <2.0.0; ^0.2.3 is >=0.2.3 <0.3.0
+//
+// Pre-release versions are compared by precedence, they are not excluded from ranges.
+func (v Version) Satisfies(constraint string) (bool, error) {
+	if strings.TrimSpace(constraint) == "" {
+		return false, fmt.Errorf("%w: empty constraint", InvalidVersionError)
+	}
+	satisfied := false
+	for _, group := range strings.Split(constraint, "||") {
+		comparisons := strings.FieldsFunc(group, func(r rune) bool {
+			return r == ',' || r == ' ' || r == '\t'
+		})
+		if len(comparisons) == 0 {
+			return false, fmt.Errorf("%w: invalid constraint %q", InvalidVersionError, constraint)
+		}
+		matched := true
+		for _, comparison := range comparisons {
+			ok, err := v.satisfies(comparison)
+			if err != nil {
+				return false, fmt.Errorf("%w: invalid constraint %q", err, constraint)
+			}
+			matched = matched && ok
+		}
+		satisfied = satisfied || matched
+	}
+	return satisfied, nil
+}
+
+// satisfies reports whether v matches a single comparison.
+func (v Version) satisfies(comparison string) (bool, error) {
+	op := strings.TrimRight(comparison, "0123456789.vV-+abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ")
+	bound, parts, err := parseVersion(comparison[len(op):])
+	if err != nil {
+		return false, err
+	}
+	c := v.Compare(bound)
+	switch op {
+	case ">":
+		return c > 0, nil
+	case ">=":
+		return c >= 0, nil
+	case "<":
+		return c < 0, nil
+	case "<=":
+		return c <= 0, nil
+	case "!=":
+		return c != 0, nil
+	case "", "=", "==":
+		if parts == 3 {
+			return c == 0, nil
+		}
+		return v.inRange(bound, tildeUpper(bound, parts)), nil
+	case "~":
+		return v.inRange(bound, tildeUpper(bound, parts)), nil
+	case "^":
+		return v.inRange(bound, caretUpper(bound, parts)), nil
+	}
+	return false, InvalidVersionError
+}
+
+// inRange reports whether lower <= v < upper.
+func (v Version) inRange(lower, upper Version) bool {
+	return v.Compare(lower) >= 0 && v.LessThan(upper)
+}
+
+// tildeUpper returns the exclusive upper bound of ~bound: the next minor version,
+// or the next major version if only the major is specified.
+func tildeUpper(bound Version, parts int) Version {
+	if parts == 1 {
+		return lowestVersion(bound.Major+1, 0, 0)
+	}
+	return lowestVersion(bound.Major, bound.Minor+1, 0)
+}
+
+// caretUpper returns the exclusive upper bound of ^bound: the next version incrementing
+// the leftmost non-zero number specified.
+func caretUpper(bound Version, parts int) Version {
+	switch {
+	case bound.Major > 0 || parts == 1:
+		return lowestVersion(bound.Major+1, 0, 0)
+	case bound.Minor > 0 || parts == 2:
+		return lowestVersion(0, bound.Minor+1, 0)
+	default:
+		return lowestVersion(0, 0, bound.Patch+1)
+	}
+}
+
+// lowestVersion returns the lowest version of major.minor.patch, lower than all its
+// pre-releases, so that an exclusive upper bound excludes them.
+func lowestVersion(major, minor, patch uint64) Version {
+	return Version{Major: major, Minor: minor, Patch: patch, Prerelease: "0"}
+}
@@ -0,0 +1,147 @@
+package lib
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseVersion(t *testing.T) {
+	cases := []struct {
+		Name   string
+		Text   string
+		Expect Version
+	}{
+		{"full", "v1.2.3-rc.1+build5", Version{1, 2, 3, "rc.1", "build5"}},
+		{"no v", "1.2.3", Version{1, 2, 3, "", ""}},
+		{"missing patch", "v1.2", Version{1, 2, 0, "", ""}},
+		{"missing minor", "2", Version{2, 0, 0, "", ""}},
+		{"build only", "1.0.0+20130313144700", Version{1, 0, 0, "", "20130313144700"}},
+		{"hyphen in pre-release", "1.0.0-x-y-z.--", Version{1, 0, 0, "x-y-z.--", ""}},
+		{"build with leading zero", "1.0.0+001", Version{1, 0, 0, "", "001"}},
+		{"spaces", " V0.0.1 ", Version{0, 0, 1, "", ""}},
+	}
+	for _, c := range cases {
+		t.Run(c.Name, func(t *testing.T) {
+			v, err := ParseVersion(c.Text)
+			require.NoError(t, err)
+			require.Equal(t, c.Expect, v)
+		})
+	}
+
+	for _, text := range []string{
+		"", "v", "1.2.3.4", "01.2.3", "1.02.3", "1..3", "1.2.3-", "1.2.3+", "1.2.3-01",
+		"1.2.3-rc..1", "1.2.3-rc_1", "1.2.3+build!", "a.b.c", "-1.2.3", "99999999999999999999.0.0",
+	} {
+		_, err := ParseVersion(text)
+		require.ErrorIs(t, err, InvalidVersionError, text)
+	}
+}
+
+func TestVersionString(t *testing.T) {
+	for _, text := range []string{"1.2.3", "1.2.3-rc.1", "1.2.3+build", "1.2.3-rc.1+build.5"} {
+		v, err := ParseVersion("v" + text)
+		require.NoError(t, err)
+		require.Equal(t, text, v.String())
+	}
+}
+
+func TestVersionCompare(t *testing.T) {
+	// the precedence examples of the semver specification
+	ordered := []string{
+		"1.0.0-alpha",
+		"1.0.0-alpha.1",
+		"1.0.0-alpha.beta",
+		"1.0.0-beta",
+		"1.0.0-beta.2",
+		"1.0.0-beta.11",
+		"1.0.0-rc.1",
+		"1.0.0",
+		"2.0.0",
+		"2.1.0",
+		"2.1.1",
+	}
+	versions := Map(ordered, func(s string) Version {
+		v, err := ParseVersion(s)
+		require.NoError(t, err)
+		return v
+	})
+	for i := range versions {
+		for j := range versions {
+			expect := If(i < j, -1, If(i > j, 1, 0))
+			require.Equal(t, expect, versions[i].Compare(versions[j]), "%s <=> %s", ordered[i], ordered[j])
+			require.Equal(t, i < j, versions[i].LessThan(versions[j]))
+		}
+	}
+
+	// the build metadata is ignored
+	a, _ := ParseVersion("1.0.0+a")
+	b, _ := ParseVersion("1.0.0+b")
+	require.Equal(t, 0, a.Compare(b))
+}
+
+func TestVersionSatisfies(t *testing.T) {
+	cases := []struct {
+		Version    string
+		Constraint string
+		Expect     bool
+	}{
+		{"1.5.0", ">=1.2, <2.0", true},
+		{"2.0.0", ">=1.2, <2.0", false},
+		{"1.1.9", ">=1.2, <2.0", false},
+		{"2.0.0-rc.1", ">=1.2 <2.0", true},
+		{"1.2.3", "1.2.3", true},
+		{"1.2.4", "=1.2.3", false},
+		{"1.2.9", "1.2", true},
+		{"1.3.0", "==1.2", false},
+		{"1.2.4", "!=1.2.3", true},
+		{"1.2.3", ">1.2.3", false},
+		{"1.2.3", "<=1.2.3", true},
+		{"1.2.5", "~1.2.3", true},
+		{"1.3.0", "~1.2.3", false},
+		{"1.3.0-rc.1", "~1.2.3", false},
+		{"1.2.2", "~1.2.3", false},
+		{"1.9.0", "~1", true},
+		{"1.9.0", "^1.2", true},
+		{"2.0.0", "^1.2", false},
+		{"0.2.5", "^0.2.3", true},
+		{"0.3.0", "^0.2.3", false},
+		{"0.0.3", "^0.0.3", true},
+		{"0.0.4", "^0.0.3", false},
+		{"0.0.9", "^0.0", true},
+		{"3.1.0", "<2 || >=3", true},
+		{"2.5.0", "<2 || >=3", false},
+		{"1.0.0", "^v1", true},
+	}
+	for _, c := range cases {
+		t.Run(c.Version+" "+c.Constraint, func(t *testing.T) {
+			v, err := ParseVersion(c.Version)
+			require.NoError(t, err)
+			ok, err := v.Satisfies(c.Constraint)
+			require.NoError(t, err)
+			require.Equal(t, c.Expect, ok)
+		})
+	}
+
+	v := Version{Major: 1}
+	for _, constraint := range []string{"", "  ", ">=x", "=>1.0", "1.0 ||", "~>1.0", "<>1"} {
+		_, err := v.Satisfies(constraint)
+		require.ErrorIs(t, err, InvalidVersionError, constraint)
+	}
+}
+
+func FuzzParseVersion(f *testing.F) {
+	for _, seed := range []string{"v1.2.3-rc.1+build5", "1", "0.0.0-0", "1.0.0-alpha.beta", "1.2.3+001"} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, text string) {
+		v, err := ParseVersion(text)
+		if err != nil {
+			return
+		}
+		parsed, err := ParseVersion(v.String())
+		require.NoError(t, err)
+		require.Equal(t, v, parsed)
+		require.Equal(t, 0, v.Compare(parsed))
+	})
+}
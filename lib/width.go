@@ -0,0 +1,189 @@
+package lib
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// wideRanges are the ranges of East Asian wide and fullwidth characters and emoji,
+// displayed in 2 cells. It is an approximation of Unicode's EastAsianWidth covering
+// the common blocks, ambiguous characters are considered narrow.
+var wideRanges = []struct{ lo, hi rune }{
+	{0x1100, 0x115F},   // Hangul Jamo
+	{0x231A, 0x231B},   // watch, hourglass
+	{0x2329, 0x232A},   // angle brackets
+	{0x23E9, 0x23EC},   // media controls
+	{0x23F0, 0x23F0},   // alarm clock
+	{0x23F3, 0x23F3},   // hourglass
+	{0x25FD, 0x25FE},   // medium small squares
+	{0x2614, 0x2615},   // umbrella, hot beverage
+	{0x2648, 0x2653},   // zodiac
+	{0x26A1, 0x26A1},   // high voltage
+	{0x26AA, 0x26AB},   // circles
+	{0x26BD, 0x26BE},   // soccer, baseball
+	{0x26C4, 0x26C5},   // snowman, sun
+	{0x26D4, 0x26D4},   // no entry
+	{0x26EA, 0x26EA},   // church
+	{0x26F2, 0x26F5},   // fountain ... sailboat
+	{0x26FA, 0x26FD},   // tent, fuel pump
+	{0x2705, 0x2705},   // check mark
+	{0x270A, 0x270B},   // fists
+	{0x2728, 0x2728},   // sparkles
+	{0x274C, 0x274C},   // cross mark
+	{0x2753, 0x2755},   // question marks
+	{0x2757, 0x2757},   // exclamation mark
+	{0x2795, 0x2797},   // plus, minus, division
+	{0x27B0, 0x27B0},   // curly loop
+	{0x27BF, 0x27BF},   // double curly loop
+	{0x2B1B, 0x2B1C},   // large squares
+	{0x2B50, 0x2B50},   // star
+	{0x2B55, 0x2B55},   // circle
+	{0x2E80, 0x303E},   // CJK radicals, Kangxi, CJK symbols and punctuation
+	{0x3041, 0x33FF},   // Hiragana, Katakana, Bopomofo, CJK compatibility
+	{0x3400, 0x4DBF},   // CJK unified ideographs extension A
+	{0x4E00, 0x9FFF},   // CJK unified ideographs
+	{0xA000, 0xA4CF},   // Yi
+	{0xA960, 0xA97F},   // Hangul Jamo extended-A
+	{0xAC00, 0xD7A3},   // Hangul syllables
+	{0xF900, 0xFAFF},   // CJK compatibility ideographs
+	{0xFE10, 0xFE19},   // vertical forms
+	{0xFE30, 0xFE6F},   // CJK compatibility forms, small form variants
+	{0xFF00, 0xFF60},   // fullwidth forms
+	{0xFFE0, 0xFFE6},   // fullwidth signs
+	{0x16FE0, 0x18CFF}, // Tangut, Khitan
+	{0x1B000, 0x1B2FF}, // Kana supplement, Nushu
+	{0x1F004, 0x1F004}, // mahjong tile
+	{0x1F0CF, 0x1F0CF}, // playing card
+	{0x1F18E, 0x1F18E}, // AB button
+	{0x1F191, 0x1F19A}, // squared words
+	{0x1F200, 0x1F2FF}, // enclosed ideographic supplement
+	{0x1F300, 0x1F64F}, // pictographs, emoticons
+	{0x1F680, 0x1F6FF}, // transport and map symbols
+	{0x1F7E0, 0x1F7EB}, // colored circles and squares
+	{0x1F90C, 0x1F9FF}, // supplemental symbols and pictographs
+	{0x1FA70, 0x1FAFF}, // symbols and pictographs extended-A
+	{0x20000, 0x2FFFD}, // CJK unified ideographs extension B...
+	{0x30000, 0x3FFFD}, // CJK unified ideographs extension G...
+}
+
+// RuneWidth returns the number of cells r takes on a terminal: 0 for control,
+// combining and zero-width characters, 2 for wide characters and 1 otherwise.
+func RuneWidth(r rune) int {
+	switch {
+	case r < 0x20 || (r >= 0x7F && r < 0xA0):
+		return 0
+	case r < 0x300:
+		return 1
+	case unicode.In(r, unicode.Mn, unicode.Me, unicode.Cf) ||
+		(r >= 0x1160 && r <= 0x11FF): // Hangul medial vowels and final consonants
+		return 0
+	}
+	lo, hi := 0, len(wideRanges)
+	for lo < hi {
+		mid := (lo + hi) / 2
+		switch {
+		case r < wideRanges[mid].lo:
+			hi = mid
+		case r > wideRanges[mid].hi:
+			lo = mid + 1
+		default:
+			return 2
+		}
+	}
+	return 1
+}
+
+// StringWidth returns the number of cells s takes on a terminal, the sum of
+// RuneWidth of its runes.
+func StringWidth(s string) int {
+	width := 0
+	for _, r := range s {
+		width += RuneWidth(r)
+	}
+	return width
+}
+
+// Truncate returns s cut to at most max runes, ellipsis included, a multibyte character
+// is never split. ellipsis is appended if s is cut and max leaves room for it, e.g.
+// Truncate("hello world", 8, "...") returns "hello...".
+func Truncate(s string, max int, ellipsis string) string {
+	if max <= 0 {
+		return ""
+	}
+	if utf8.RuneCountInString(s) <= max {
+		return s
+	}
+	if n := utf8.RuneCountInString(ellipsis); n < max {
+		max -= n
+	} else {
+		ellipsis = ""
+	}
+	count := 0
+	for i := range s {
+		if count == max {
+			return s[:i] + ellipsis
+		}
+		count++
+	}
+	return s
+}
+
+// TruncateWidth is like Truncate, but counts the cells taken on a terminal with
+// StringWidth, a wide character which would exceed max is dropped entirely. The
+// combining characters following a kept character are kept.
+func TruncateWidth(s string, max int, ellipsis string) string {
+	if max <= 0 {
+		return ""
+	}
+	if StringWidth(s) <= max {
+		return s
+	}
+	if w := StringWidth(ellipsis); w < max {
+		max -= w
+	} else {
+		ellipsis = ""
+	}
+	width := 0
+	for i, r := range s {
+		if width += RuneWidth(r); width > max {
+			return s[:i] + ellipsis
+		}
+	}
+	return s
+}
+
+// pad returns count cells of padding, filled with padding then spaces if padding is wide.
+func pad(count int, padding rune) string {
+	if count <= 0 {
+		return ""
+	}
+	w := RuneWidth(padding)
+	if w <= 0 {
+		padding, w = ' ', 1
+	}
+	return strings.Repeat(string(padding), count/w) + strings.Repeat(" ", count%w)
+}
+
+// PadLeft returns s right-aligned in width cells, padded on the left with padding.
+// A zero-width padding is replaced by a space, and a wide padding that doesn't fit the
+// remaining cell is completed with a space. s is returned as-is if it's wider.
+func PadLeft(s string, width int, padding rune) string {
+	return pad(width-StringWidth(s), padding) + s
+}
+
+// PadRight returns s left-aligned in width cells, padded on the right with padding,
+// see PadLeft.
+func PadRight(s string, width int, padding rune) string {
+	return s + pad(width-StringWidth(s), padding)
+}
+
+// Center returns s centered in width cells, padded on both sides with padding, the
+// extra cell goes to the right. See PadLeft.
+func Center(s string, width int, padding rune) string {
+	gap := width - StringWidth(s)
+	if gap <= 0 {
+		return s
+	}
+	return pad(gap/2, padding) + s + pad(gap-gap/2, padding)
+}
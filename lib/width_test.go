@@ -0,0 +1,128 @@
+package lib
+
+import (
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRuneWidth(t *testing.T) {
+	cases := []struct {
+		Name   string
+		Rune   rune
+		Expect int
+	}{
+		{"ascii", 'a', 1},
+		{"latin", 'é', 1},
+		{"control", '\n', 0},
+		{"delete", 0x7F, 0},
+		{"combining acute", 0x301, 0},
+		{"zero width joiner", 0x200D, 0},
+		{"variation selector", 0xFE0F, 0},
+		{"cjk", '中', 2},
+		{"hiragana", 'あ', 2},
+		{"hangul", '한', 2},
+		{"fullwidth", 'Ａ', 2},
+		{"emoji", '😀', 2},
+		{"rocket", '🚀', 2},
+		{"cyrillic", 'Ж', 1},
+		{"arrow", '→', 1},
+	}
+	for _, c := range cases {
+		t.Run(c.Name, func(t *testing.T) {
+			require.Equal(t, c.Expect, RuneWidth(c.Rune))
+		})
+	}
+}
+
+func TestStringWidth(t *testing.T) {
+	require.Equal(t, 0, StringWidth(""))
+	require.Equal(t, 5, StringWidth("hello"))
+	require.Equal(t, 4, StringWidth("中文"))
+	require.Equal(t, 1, StringWidth("é"))
+	require.Equal(t, 6, StringWidth("a😀中b"))
+}
+
+func TestTruncate(t *testing.T) {
+	cases := []struct {
+		Name     string
+		Text     string
+		Max      int
+		Ellipsis string
+		Expect   string
+	}{
+		{"empty", "", 5, "...", ""},
+		{"short", "hello", 5, "...", "hello"},
+		{"ascii", "hello world", 8, "...", "hello..."},
+		{"no ellipsis", "hello world", 5, "", "hello"},
+		{"zero", "hello", 0, "...", ""},
+		{"negative", "hello", -1, "...", ""},
+		{"ellipsis too long", "hello world", 3, "...", "hel"},
+		{"multibyte", "中文字符串测试", 5, "…", "中文字符…"},
+		{"emoji", "😀😁😂🤣😃", 3, "", "😀😁😂"},
+		{"combining", "ééé", 3, "", "ée"},
+	}
+	for _, c := range cases {
+		t.Run(c.Name, func(t *testing.T) {
+			got := Truncate(c.Text, c.Max, c.Ellipsis)
+			require.Equal(t, c.Expect, got)
+			require.True(t, utf8.ValidString(got))
+		})
+	}
+}
+
+func TestTruncateWidth(t *testing.T) {
+	cases := []struct {
+		Name     string
+		Text     string
+		Max      int
+		Ellipsis string
+		Expect   string
+	}{
+		{"empty", "", 5, "...", ""},
+		{"fits", "中文", 4, "...", "中文"},
+		{"ascii", "hello world", 8, "...", "hello..."},
+		{"wide", "中文字符串", 7, "..", "中文.."},
+		{"wide dropped", "中文字符串", 5, "", "中文"},
+		{"emoji", "😀😁😂", 5, "…", "😀😁…"},
+		{"combining kept", "éééx", 3, "", "ééé"},
+		{"zero", "中文", 0, "", ""},
+		{"ellipsis too wide", "中文字符串", 2, "...", "中"},
+	}
+	for _, c := range cases {
+		t.Run(c.Name, func(t *testing.T) {
+			got := TruncateWidth(c.Text, c.Max, c.Ellipsis)
+			require.Equal(t, c.Expect, got)
+			require.True(t, utf8.ValidString(got))
+			require.LessOrEqual(t, StringWidth(got), Max(c.Max, 0))
+		})
+	}
+}
+
+func TestPad(t *testing.T) {
+	cases := []struct {
+		Name    string
+		Fn      func(string, int, rune) string
+		Text    string
+		Width   int
+		Padding rune
+		Expect  string
+	}{
+		{"left", PadLeft, "ab", 5, ' ', "   ab"},
+		{"right", PadRight, "ab", 5, '.', "ab..."},
+		{"center", Center, "ab", 7, '-', "--ab---"},
+		{"empty", PadLeft, "", 3, '*', "***"},
+		{"wide text", PadRight, "中文", 6, ' ', "中文  "},
+		{"wide padding", PadLeft, "a", 4, '中', "中 a"},
+		{"zero-width padding", PadRight, "a", 3, 0x301, "a  "},
+		{"too wide", PadLeft, "hello", 3, ' ', "hello"},
+		{"center too wide", Center, "hello", 3, ' ', "hello"},
+		{"emoji", Center, "😀", 4, '=', "=😀="},
+	}
+	for _, c := range cases {
+		t.Run(c.Name, func(t *testing.T) {
+			require.Equal(t, c.Expect, c.Fn(c.Text, c.Width, c.Padding))
+		})
+	}
+}
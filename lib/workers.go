@@ -0,0 +1,169 @@
+package lib
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"github.com/stkali/utility/errors"
+)
+
+// InvalidWorkersError is returned by Pool, PoolCollect and PoolStream when the number
+// of workers is not positive.
+var InvalidWorkersError = errors.Error("invalid number of workers, must be positive")
+
+// Pool calls fn for every input with at most workers goroutines and returns the results
+// in the order of inputs. It stops at the first failure, or when ctx is canceled, and
+// returns that error; the context passed to fn is canceled then so that the running
+// calls can return early. A panic of fn is returned as an *errors.PanicError.
+func Pool[T, R any](ctx context.Context, workers int, inputs []T, fn func(context.Context, T) (R, error)) ([]R, error) {
+	results, _, err := runPool(ctx, workers, inputs, fn, true)
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// PoolCollect is like Pool, but it keeps going after a failure: it returns the results,
+// the zero value for the failed inputs, and the errors of all failures joined in the
+// order of inputs, errors.Is and errors.As match any of them. If ctx is canceled the
+// remaining inputs are skipped and ctx.Err() is joined too.
+func PoolCollect[T, R any](ctx context.Context, workers int, inputs []T, fn func(context.Context, T) (R, error)) ([]R, error) {
+	results, errs, err := runPool(ctx, workers, inputs, fn, false)
+	if errs == nil {
+		return nil, err
+	}
+	var failures groupError
+	for _, e := range errs {
+		if e != nil {
+			failures = append(failures, e)
+		}
+	}
+	if err != nil {
+		failures = append(failures, err)
+	}
+	if len(failures) == 0 {
+		return results, nil
+	}
+	return results, failures
+}
+
+// runPool runs fn for inputs with workers goroutines, it returns the results and the
+// errors of the inputs, and the error stopping the run: ctx.Err() or, if failFast is
+// true, the first failure.
+func runPool[T, R any](ctx context.Context, workers int, inputs []T, fn func(context.Context, T) (R, error), failFast bool) ([]R, []error, error) {
+	if workers <= 0 {
+		return nil, nil, fmt.Errorf("%w: %d", InvalidWorkersError, workers)
+	}
+	parent := ctx
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var (
+		results = make([]R, len(inputs))
+		errs    = make([]error, len(inputs))
+		wg      sync.WaitGroup
+		once    sync.Once
+		first   error
+		// next is the index of the next input, it is accessed atomically
+		next int64 = -1
+	)
+	if workers > len(inputs) {
+		workers = len(inputs)
+	}
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for ctx.Err() == nil {
+				index := int(atomic.AddInt64(&next, 1))
+				if index >= len(inputs) {
+					return
+				}
+				results[index], errs[index] = callPool(ctx, fn, inputs[index])
+				if errs[index] != nil && failFast {
+					once.Do(func() {
+						first = errs[index]
+						cancel()
+					})
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if err := parent.Err(); err != nil {
+		return results, errs, err
+	}
+	return results, errs, first
+}
+
+// callPool calls fn, a panic is returned as an *errors.PanicError, see errors.Recover.
+func callPool[T, R any](ctx context.Context, fn func(context.Context, T) (R, error), input T) (result R, err error) {
+	defer errors.Recover(&err)
+	return fn(ctx, input)
+}
+
+// PoolResult is the result of an input of PoolStream.
+type PoolResult[R any] struct {
+	// Index is the position of the input in the input channel.
+	Index int
+	Value R
+	Err   error
+}
+
+// PoolStream calls fn for every input received from inputs with at most workers
+// goroutines, e.g. for an unbounded amount of work. The results are sent in the order
+// they complete, Index tells the input, and the channel is closed once inputs is closed
+// and all calls returned, or when ctx is canceled. The results must be received until
+// the channel is closed, or ctx canceled, otherwise the workers block. A panic of fn is
+// sent as an *errors.PanicError.
+func PoolStream[T, R any](ctx context.Context, workers int, inputs <-chan T, fn func(context.Context, T) (R, error)) (<-chan PoolResult[R], error) {
+	if workers <= 0 {
+		return nil, fmt.Errorf("%w: %d", InvalidWorkersError, workers)
+	}
+	type job struct {
+		index int
+		input T
+	}
+	jobs := make(chan job)
+	results := make(chan PoolResult[R], workers)
+	// the dispatcher numbers the inputs in the order they are received
+	go func() {
+		defer close(jobs)
+		for index := 0; ; index++ {
+			select {
+			case input, ok := <-inputs:
+				if !ok {
+					return
+				}
+				select {
+				case jobs <- job{index: index, input: input}:
+				case <-ctx.Done():
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range jobs {
+				value, err := callPool(ctx, fn, j.input)
+				select {
+				case results <- PoolResult[R]{Index: j.index, Value: value, Err: err}:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+	return results, nil
+}
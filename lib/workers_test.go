@@ -0,0 +1,195 @@
+package lib
+
+import (
+	"context"
+	"math/rand"
+	"sort"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stkali/utility/errors"
+	"github.com/stretchr/testify/require"
+)
+
+// square returns the square of n after a random short sleep so that the calls
+// complete out of order.
+func square(_ context.Context, n int) (int, error) {
+	time.Sleep(time.Duration(rand.Intn(100)) * time.Microsecond)
+	return n * n, nil
+}
+
+func TestPool(t *testing.T) {
+	inputs := make([]int, 200)
+	for i := range inputs {
+		inputs[i] = i
+	}
+
+	t.Run("order", func(t *testing.T) {
+		for _, workers := range []int{1, 3, 1000} {
+			results, err := Pool(context.Background(), workers, inputs, square)
+			require.NoError(t, err)
+			require.Len(t, results, len(inputs))
+			for i, r := range results {
+				require.Equal(t, i*i, r)
+			}
+		}
+		results, err := Pool(context.Background(), 2, []int{}, square)
+		require.NoError(t, err)
+		require.Empty(t, results)
+	})
+
+	t.Run("zero workers", func(t *testing.T) {
+		results, err := Pool(context.Background(), 0, inputs, square)
+		require.ErrorIs(t, err, InvalidWorkersError)
+		require.Nil(t, results)
+		_, err = PoolCollect(context.Background(), -1, inputs, square)
+		require.ErrorIs(t, err, InvalidWorkersError)
+	})
+
+	t.Run("first failure", func(t *testing.T) {
+		failure := errors.New("failure")
+		var calls int64
+		results, err := Pool(context.Background(), 2, inputs, func(ctx context.Context, n int) (int, error) {
+			atomic.AddInt64(&calls, 1)
+			if n == 10 {
+				return 0, failure
+			}
+			return n, nil
+		})
+		require.ErrorIs(t, err, failure)
+		require.Nil(t, results)
+		// the remaining inputs are skipped
+		require.Less(t, atomic.LoadInt64(&calls), int64(len(inputs)))
+	})
+
+	t.Run("cancellation", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		var calls int64
+		results, err := Pool(ctx, 4, inputs, func(ctx context.Context, n int) (int, error) {
+			if atomic.AddInt64(&calls, 1) == 4 {
+				cancel()
+			}
+			// the running calls see the cancellation
+			<-ctx.Done()
+			return n, ctx.Err()
+		})
+		require.ErrorIs(t, err, context.Canceled)
+		require.Nil(t, results)
+		require.Less(t, atomic.LoadInt64(&calls), int64(len(inputs)))
+	})
+
+	t.Run("panic", func(t *testing.T) {
+		_, err := Pool(context.Background(), 2, inputs, func(ctx context.Context, n int) (int, error) {
+			if n == 3 {
+				panic("boom")
+			}
+			return n, nil
+		})
+		var pe *errors.PanicError
+		require.ErrorAs(t, err, &pe)
+		require.Equal(t, "boom", pe.Value)
+		require.NotEmpty(t, pe.Tracer.String())
+	})
+}
+
+func TestPoolCollect(t *testing.T) {
+	odd := errors.New("odd")
+	inputs := []int{0, 1, 2, 3, 4, 5}
+	results, err := PoolCollect(context.Background(), 3, inputs, func(ctx context.Context, n int) (string, error) {
+		if n == 5 {
+			panic("five")
+		}
+		if n%2 == 1 {
+			return "", errors.New(strconv.Itoa(n) + " is odd: " + odd.Error())
+		}
+		return strconv.Itoa(n), nil
+	})
+	// the results of the failed inputs are the zero value
+	require.Equal(t, []string{"0", "", "2", "", "4", ""}, results)
+	require.Equal(t, "1 is odd: odd\n3 is odd: odd\npanic: five", err.Error())
+	var pe *errors.PanicError
+	require.ErrorAs(t, err, &pe)
+
+	squares, err := PoolCollect(context.Background(), 3, inputs, square)
+	require.NoError(t, err)
+	require.Equal(t, []int{0, 1, 4, 9, 16, 25}, squares)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	squares, err = PoolCollect(ctx, 3, inputs, square)
+	require.ErrorIs(t, err, context.Canceled)
+	require.Len(t, squares, len(inputs))
+}
+
+func TestPoolStream(t *testing.T) {
+	t.Run("all inputs", func(t *testing.T) {
+		inputs := make(chan int)
+		go func() {
+			defer close(inputs)
+			for i := 0; i < 100; i++ {
+				inputs <- i
+			}
+		}()
+		results, err := PoolStream(context.Background(), 4, inputs, square)
+		require.NoError(t, err)
+		var indexes []int
+		for r := range results {
+			require.NoError(t, r.Err)
+			require.Equal(t, r.Index*r.Index, r.Value)
+			indexes = append(indexes, r.Index)
+		}
+		require.Len(t, indexes, 100)
+		sort.Ints(indexes)
+		for i, index := range indexes {
+			require.Equal(t, i, index)
+		}
+	})
+
+	t.Run("cancellation", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		// an unbounded input
+		inputs := make(chan int)
+		go func() {
+			for i := 0; ; i++ {
+				select {
+				case inputs <- i:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+		results, err := PoolStream(ctx, 2, inputs, square)
+		require.NoError(t, err)
+		received := 0
+		for range results {
+			if received++; received == 10 {
+				cancel()
+			}
+		}
+		require.GreaterOrEqual(t, received, 10)
+	})
+
+	t.Run("panic", func(t *testing.T) {
+		inputs := make(chan int, 1)
+		inputs <- 1
+		close(inputs)
+		results, err := PoolStream(context.Background(), 1, inputs, func(ctx context.Context, n int) (int, error) {
+			panic("boom")
+		})
+		require.NoError(t, err)
+		r := <-results
+		var pe *errors.PanicError
+		require.ErrorAs(t, r.Err, &pe)
+		_, ok := <-results
+		require.False(t, ok)
+	})
+
+	t.Run("zero workers", func(t *testing.T) {
+		results, err := PoolStream(context.Background(), 0, make(chan int), square)
+		require.ErrorIs(t, err, InvalidWorkersError)
+		require.Nil(t, results)
+	})
+}
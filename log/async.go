@@ -0,0 +1,178 @@
+package log
+
+import (
+	"io"
+	"sync"
+	"sync/atomic"
+
+	"github.com/stkali/utility/errors"
+)
+
+// OverflowPolicy is what an asynchronous logger does when its queue is full, see
+// SetOverflowPolicy.
+type OverflowPolicy int32
+
+const (
+	// OverflowBlock blocks the logging goroutine until the queue has room.
+	OverflowBlock OverflowPolicy = iota
+	// OverflowDrop drops the entry and counts it in OutputStats.Dropped.
+	OverflowDrop
+)
+
+// asyncWrite is a write of an encoded entry queued by an asynchronous sink, of the entry
+// itself if w is an EntryWriter, or a flush request if flushed isn't nil.
+type asyncWrite struct {
+	sink    *sink
+	w       io.Writer
+	data    []byte
+	entry   *Entry
+	added   bool
+	flushed chan struct{}
+}
+
+// asyncQueue is the queue of the writes of the asynchronous sinks, written by a single
+// goroutine.
+type asyncQueue struct {
+	ch     chan asyncWrite
+	policy int32
+	// inWrite is set while the goroutine of the queue writes, see BridgeWarnings
+	inWrite int32
+	// mtx guards closed, the writes are queued with the read lock so that the channel
+	// isn't closed while sending
+	mtx    sync.RWMutex
+	closed bool
+	done   chan struct{}
+}
+
+// newAsyncQueue starts the goroutine writing the queue of size.
+func newAsyncQueue(size int, policy OverflowPolicy) *asyncQueue {
+	q := &asyncQueue{
+		ch:     make(chan asyncWrite, size),
+		policy: int32(policy),
+		done:   make(chan struct{}),
+	}
+	go q.run()
+	return q
+}
+
+// run writes the queued entries in order until the queue is closed.
+func (q *asyncQueue) run() {
+	defer close(q.done)
+	for w := range q.ch {
+		if w.flushed != nil {
+			close(w.flushed)
+			continue
+		}
+		// the warnings raised by the outputs aren't logged, see BridgeWarnings
+		atomic.StoreInt32(&q.inWrite, 1)
+		var err error
+		if w.entry != nil {
+			err = w.w.(EntryWriter).WriteEntry(*w.entry)
+		} else {
+			_, err = w.w.Write(w.data)
+		}
+		atomic.StoreInt32(&q.inWrite, 0)
+		if err = writeError(err, w.w, w.added); err != nil {
+			atomic.AddInt64(&w.sink.writeErrors, 1)
+			errors.Warningt(warningTag, err)
+		}
+	}
+}
+
+// push queues the write w, or drops it if the queue is full with OverflowDrop. It
+// returns false if the queue is closed, e.g. the writes of a child sharing the queue of
+// its parent become synchronous with the parent.
+func (q *asyncQueue) push(w asyncWrite) bool {
+	q.mtx.RLock()
+	defer q.mtx.RUnlock()
+	if q.closed {
+		return false
+	}
+	if OverflowPolicy(atomic.LoadInt32(&q.policy)) == OverflowDrop {
+		select {
+		case q.ch <- w:
+		default:
+			atomic.AddInt64(&w.sink.dropped, 1)
+		}
+		return true
+	}
+	q.ch <- w
+	return true
+}
+
+// flush blocks until the writes queued before are written.
+func (q *asyncQueue) flush() {
+	flushed := make(chan struct{})
+	q.mtx.RLock()
+	if q.closed {
+		q.mtx.RUnlock()
+		return
+	}
+	// the flush request is never dropped
+	q.ch <- asyncWrite{flushed: flushed}
+	q.mtx.RUnlock()
+	<-flushed
+}
+
+// close writes the queued entries and stops the goroutine.
+func (q *asyncQueue) close() {
+	q.mtx.Lock()
+	if !q.closed {
+		q.closed = true
+		close(q.ch)
+	}
+	q.mtx.Unlock()
+	<-q.done
+}
+
+// SetAsync makes the writes of the logger asynchronous: the entries are encoded by the
+// logging goroutine and written by a single goroutine through a queue of queueSize, so
+// that a slow output, e.g. a rotating file, doesn't delay the logging goroutines. The
+// entries of a goroutine are written in order. When the queue is full the logging
+// goroutine blocks, see SetOverflowPolicy.
+//
+// Flush waits for the queued entries to be written, it's called before a FATAL entry
+// exits and by the errors.OnExit hooks. queueSize <= 0 makes the writes synchronous
+// again, after the queued entries are written.
+func (l *Logger) SetAsync(queueSize int) {
+	s := l.ownSink()
+	var q *asyncQueue
+	if queueSize > 0 {
+		q = newAsyncQueue(queueSize, OverflowBlock)
+		errors.OnExit(q.flush)
+	}
+	s.mtx.Lock()
+	prev := s.async
+	if prev != nil && q != nil {
+		atomic.StoreInt32(&q.policy, atomic.LoadInt32(&prev.policy))
+	}
+	s.async = q
+	s.mtx.Unlock()
+	if prev != nil {
+		prev.close()
+	}
+}
+
+// SetOverflowPolicy sets what the logger does when the queue of SetAsync is full, default
+// is OverflowBlock. The dropped entries are counted in OutputStats.Dropped.
+func (l *Logger) SetOverflowPolicy(policy OverflowPolicy) {
+	s := l.loadSink()
+	s.mtx.Lock()
+	q := s.async
+	s.mtx.Unlock()
+	if q != nil {
+		atomic.StoreInt32(&q.policy, int32(policy))
+	}
+}
+
+// Flush blocks until the entries queued by the asynchronous writes are written, see
+// SetAsync. It returns at once if the writes are synchronous.
+func (l *Logger) Flush() {
+	s := l.loadSink()
+	s.mtx.Lock()
+	q := s.async
+	s.mtx.Unlock()
+	if q != nil {
+		q.flush()
+	}
+}
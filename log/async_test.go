@@ -0,0 +1,211 @@
+package log
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stkali/utility/errors"
+	"github.com/stretchr/testify/require"
+)
+
+// gateWriter is a syncBuffer whose writes block until the gate is opened.
+type gateWriter struct {
+	syncBuffer
+	entered chan struct{}
+	gate    chan struct{}
+}
+
+func newGateWriter() *gateWriter {
+	return &gateWriter{entered: make(chan struct{}, 100), gate: make(chan struct{})}
+}
+
+func (w *gateWriter) Write(p []byte) (int, error) {
+	w.entered <- struct{}{}
+	<-w.gate
+	return w.syncBuffer.Write(p)
+}
+
+func TestAsyncOrder(t *testing.T) {
+	var buf syncBuffer
+	l := New(WithOutput(&buf), WithFlags(0))
+	l.SetAsync(16)
+	defer l.SetAsync(0)
+	var want strings.Builder
+	for i := 0; i < 1000; i++ {
+		l.Warnw("entry", "n", i)
+		_, _ = fmt.Fprintf(&want, "[WARN ] entry n=%d\n", i)
+	}
+	l.Flush()
+	require.Equal(t, want.String(), buf.String())
+	require.Equal(t, OutputStats{Entries: 1000, Levels: [FATAL + 1]int64{WARN: 1000}}, l.Stats())
+}
+
+func TestAsyncFlush(t *testing.T) {
+	w := newGateWriter()
+	l := New(WithOutput(w), WithFlags(0))
+	l.SetAsync(4)
+	defer l.SetAsync(0)
+	l.Warn("queued")
+	<-w.entered
+	// the entry is written by the goroutine of the queue
+	require.Empty(t, w.String())
+
+	flushed := make(chan struct{})
+	go func() {
+		defer close(flushed)
+		l.Flush()
+	}()
+	close(w.gate)
+	<-flushed
+	require.Equal(t, "[WARN ] queued\n", w.String())
+}
+
+func TestAsyncDrop(t *testing.T) {
+	w := newGateWriter()
+	l := New(WithOutput(w), WithFlags(0))
+	l.SetAsync(1)
+	defer l.SetAsync(0)
+	l.SetOverflowPolicy(OverflowDrop)
+	l.Warn("written")
+	// the first entry is being written and the second one is queued
+	<-w.entered
+	for i := 0; i < 10; i++ {
+		l.Warn("dropped")
+	}
+	close(w.gate)
+	l.Flush()
+	require.Equal(t, "[WARN ] written\n[WARN ] dropped\n", w.String())
+	require.Equal(t, OutputStats{Entries: 11, Levels: [FATAL + 1]int64{WARN: 11}, Dropped: 9}, l.Stats())
+
+	// the policy is kept by the next queue
+	w = newGateWriter()
+	l.SetOutput(w)
+	l.SetAsync(1)
+	l.Warn("written")
+	<-w.entered
+	l.Warn("queued")
+	l.Warn("dropped")
+	close(w.gate)
+	l.Flush()
+	require.Equal(t, "[WARN ] written\n[WARN ] queued\n", w.String())
+	require.Equal(t, int64(10), l.Stats().Dropped)
+}
+
+func TestAsyncSync(t *testing.T) {
+	var buf syncBuffer
+	l := New(WithOutput(&buf), WithFlags(0))
+	child := l.Named("child")
+	child.SetPrefix("child ")
+	l.SetAsync(8)
+	// the child cloned the sink before, it writes synchronously
+	child.Warn("sync")
+	require.Equal(t, "child [WARN ] child: sync\n", buf.String())
+
+	grandchild := l.Named("grandchild")
+	grandchild.SetPrefix("grandchild ")
+	grandchild.Warn("async")
+	// the queued entries are written when the writes become synchronous
+	l.SetAsync(0)
+	require.Equal(t, "child [WARN ] child: sync\ngrandchild [WARN ] grandchild: async\n", buf.String())
+
+	// the grandchild shared the closed queue, it writes synchronously too
+	grandchild.Warn("sync")
+	l.Warn("sync")
+	l.Flush()
+	require.Equal(t, "child [WARN ] child: sync\ngrandchild [WARN ] grandchild: async\n"+
+		"grandchild [WARN ] grandchild: sync\n[WARN ] sync\n", buf.String())
+}
+
+func TestAsyncWriteError(t *testing.T) {
+	l := New(WithOutput(failedWriter{}), WithFlags(0))
+	l.SetAsync(8)
+	defer l.SetAsync(0)
+	warnings := errors.CaptureWarnings(func() {
+		l.Warn("lost")
+		l.Flush()
+	})
+	require.Equal(t, []string{"log: failed to write log entry, err: file already closed"}, warnings)
+	require.Equal(t, OutputStats{Entries: 1, Levels: [FATAL + 1]int64{WARN: 1}, WriteErrors: 1}, l.Stats())
+}
+
+func TestAsyncExit(t *testing.T) {
+	w := newGateWriter()
+	close(w.gate)
+	defer SetLogger(logger)
+	SetLogger(New(WithOutput(w), WithFlags(0)))
+	SetAsync(8)
+	defer SetAsync(0)
+
+	// FATAL flushes before Exit, which doesn't run the errors.OnExit hooks in the tests
+	Fatal("stopped")
+	require.Equal(t, "[FATAL] stopped\n", w.String())
+
+	// the queue is flushed by the errors.OnExit hooks
+	var codes []int
+	errors.SetExitFunc(func(code int) { codes = append(codes, code) })
+	defer errors.SetExitFunc(nil)
+	Warn("exiting")
+	errors.CaptureWarnings(func() {
+		errors.Exit(2)
+	})
+	require.Equal(t, []int{2}, codes)
+	require.Equal(t, "[FATAL] stopped\n[WARN ] exiting\n", w.String())
+}
+
+func TestAsyncConcurrent(t *testing.T) {
+	var buf syncBuffer
+	l := New(WithOutput(&buf), WithFlags(0))
+	l.SetAsync(4)
+	defer l.SetAsync(0)
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				l.Warnw("tick", "worker", i, "n", j)
+			}
+		}(i)
+	}
+	wg.Wait()
+	Flush()
+	l.Flush()
+	// the entries of each goroutine are in order
+	next := make([]int, 8)
+	for _, line := range strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n") {
+		var worker, n int
+		_, err := fmt.Sscanf(line, "[WARN ] tick worker=%d n=%d", &worker, &n)
+		require.NoError(t, err)
+		require.Equal(t, next[worker], n)
+		next[worker]++
+	}
+	require.Equal(t, []int{100, 100, 100, 100, 100, 100, 100, 100}, next)
+}
+
+func BenchmarkAsync(b *testing.B) {
+	for _, async := range []bool{false, true} {
+		b.Run(fmt.Sprintf("async=%t", async), func(b *testing.B) {
+			file, err := os.Create(filepath.Join(b.TempDir(), "app.log"))
+			require.NoError(b, err)
+			defer file.Close()
+			l := New(WithOutput(file), WithFlags(LstdFlags|Lmicroseconds))
+			if async {
+				l.SetAsync(1024)
+				defer l.SetAsync(0)
+			}
+			b.SetParallelism(16)
+			b.ReportAllocs()
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					l.Warnw("request", "path", "/api/v1/users", "status", 200)
+				}
+			})
+			l.Flush()
+		})
+	}
+}
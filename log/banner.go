@@ -0,0 +1,126 @@
+package log
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"sync/atomic"
+)
+
+// BannerOption is an option of Banner passed among its key-value pairs.
+type BannerOption int
+
+const (
+	// BannerPretty writes the banner as a block of lines instead of fields, e.g.
+	//	[INFO ] started
+	//		version:  v1.2.3
+	//		revision: 0c4a8e2
+	//		...
+	BannerPretty BannerOption = iota + 1
+)
+
+// readBuildInfo returns the build information of the binary, for testing.
+var readBuildInfo = debug.ReadBuildInfo
+
+// bannerFields returns the fields of the banner: the version and the VCS revision of
+// the main module if the build information is available, the go version, the platform,
+// the number of CPUs, the pid and the hostname.
+func bannerFields() []Field {
+	var fields []Field
+	if info, ok := readBuildInfo(); ok && info != nil {
+		if info.Main.Version != "" {
+			fields = append(fields, Field{Key: "version", Value: info.Main.Version})
+		}
+		for _, s := range info.Settings {
+			switch s.Key {
+			case "vcs.revision":
+				fields = append(fields, Field{Key: "revision", Value: s.Value})
+			case "vcs.time":
+				fields = append(fields, Field{Key: "revision_time", Value: s.Value})
+			case "vcs.modified":
+				fields = append(fields, Field{Key: "modified", Value: s.Value})
+			}
+		}
+	}
+	fields = append(fields,
+		Field{Key: "go", Value: runtime.Version()},
+		Field{Key: "platform", Value: runtime.GOOS + "/" + runtime.GOARCH},
+		Field{Key: "cpus", Value: runtime.NumCPU()},
+		Field{Key: "pid", Value: os.Getpid()},
+	)
+	if hostname, err := os.Hostname(); err == nil {
+		fields = append(fields, Field{Key: "hostname", Value: hostname})
+	}
+	return fields
+}
+
+// prettyBanner returns the message of the banner written as a block.
+func prettyBanner(fields []Field) string {
+	width := 0
+	for _, f := range fields {
+		if len(f.Key) > width {
+			width = len(f.Key)
+		}
+	}
+	var b strings.Builder
+	b.WriteString("started")
+	for _, f := range fields {
+		_, _ = fmt.Fprintf(&b, "\n\t%-*s %v", width+1, f.Key+":", resolveLazy(f.Value))
+	}
+	return b.String()
+}
+
+// banner writes the banner with the key-value pairs extra. It must be called directly by
+// the exported functions so that the call site is found.
+func (l *Logger) banner(extra []any) {
+	pretty := false
+	kv := make([]any, 0, len(extra))
+	for _, v := range extra {
+		if v == BannerPretty {
+			pretty = true
+			continue
+		}
+		kv = append(kv, v)
+	}
+	e := Entry{Time: timeNow(), Level: INFO, Name: l.name, Message: "started", Fields: l.fields}
+	e.Fields = appendFields(append(e.Fields, bannerFields()...), kv)
+	if pretty {
+		e.Message, e.Fields = prettyBanner(e.Fields), nil
+	}
+	s := l.loadSink()
+	if s.reportCaller() {
+		var ok bool
+		// banner and the exported function
+		if _, e.File, e.Line, ok = runtime.Caller(callerDepth - 1 + l.callerSkip); !ok {
+			e.File, e.Line = "???", 0
+		}
+	}
+	if !s.hasOutput() {
+		// the outputs may not be configured yet at startup
+		s.mtx.Lock()
+		s.enc.flags = int(atomic.LoadInt32(&s.flags))
+		b := encodeText(nil, &e, &s.enc, false)
+		s.mtx.Unlock()
+		_, _ = bannerOutput.Write(b)
+		return
+	}
+	l.write(&e)
+}
+
+// bannerOutput is the output of the banner of a logger without output, for testing.
+var bannerOutput io.Writer = os.Stderr
+
+// Banner writes an INFO entry "started" describing the program, with the fields:
+//   - version, revision, revision_time and modified: the version and the VCS revision
+//     of the main module, if the binary has the build information
+//   - go, platform, cpus, pid and hostname
+//
+// followed by the key-value pairs extra, e.g. a summary of the configuration. The entry
+// is written whatever the level and the sampler, to stderr if the logger has no output.
+// BannerPretty among extra writes the fields as a block of lines.
+func (l *Logger) Banner(extra ...any) {
+	l.banner(extra)
+}
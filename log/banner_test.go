@@ -0,0 +1,102 @@
+package log
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// setBuildInfo replaces the build information of the binary until the end of the test.
+func setBuildInfo(t *testing.T, info *debug.BuildInfo) {
+	old := readBuildInfo
+	readBuildInfo = func() (*debug.BuildInfo, bool) {
+		return info, info != nil
+	}
+	t.Cleanup(func() { readBuildInfo = old })
+}
+
+func TestBanner(t *testing.T) {
+	setBuildInfo(t, &debug.BuildInfo{
+		Main: debug.Module{Path: "example.com/app", Version: "v1.2.3"},
+		Settings: []debug.BuildSetting{
+			{Key: "vcs.revision", Value: "0c4a8e2"},
+			{Key: "vcs.modified", Value: "false"},
+			{Key: "-trimpath", Value: "true"},
+		},
+	})
+	hostname, _ := os.Hostname()
+	platform := runtime.GOOS + "/" + runtime.GOARCH
+
+	cases := []struct {
+		name   string
+		format Format
+		extra  []any
+		want   []string
+	}{
+		{
+			"text", TextFormat, []any{"config", "app.yaml"},
+			[]string{"[INFO ] started", "version=v1.2.3", "revision=0c4a8e2", "modified=false",
+				"platform=" + platform, "hostname=" + hostname, "config=app.yaml"},
+		},
+		{
+			"json", JSONFormat, nil,
+			[]string{`"msg":"started"`, `"version":"v1.2.3"`, `"revision":"0c4a8e2"`,
+				`"platform":"` + platform + `"`},
+		},
+		{
+			"pretty", TextFormat, []any{BannerPretty, "config", "app.yaml"},
+			[]string{"[INFO ] started\n\tversion:  v1.2.3\n\trevision: 0c4a8e2\n",
+				"\n\tconfig:   app.yaml\n"},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			// the banner is written whatever the level
+			l := New(WithOutput(&buf), WithFlags(0), WithFormat(c.format), WithLevel(ERROR))
+			l.Banner(c.extra...)
+			require.Equal(t, 1, strings.Count(buf.String(), "started"))
+			for _, want := range c.want {
+				require.Contains(t, buf.String(), want)
+			}
+			require.NotContains(t, buf.String(), "trimpath")
+		})
+	}
+}
+
+func TestBannerNoBuildInfo(t *testing.T) {
+	setBuildInfo(t, nil)
+	var buf bytes.Buffer
+	l := New(WithOutput(&buf), WithFlags(0))
+	require.NotPanics(t, func() { l.Banner() })
+	require.Contains(t, buf.String(), "[INFO ] started go="+runtime.Version())
+	require.NotContains(t, buf.String(), "version=")
+}
+
+func TestBannerNoOutput(t *testing.T) {
+	var buf bytes.Buffer
+	old := bannerOutput
+	bannerOutput = &buf
+	defer func() { bannerOutput = old }()
+
+	l := New(WithOutput(nil), WithFlags(Lshortfile))
+	l.Banner("k", "v")
+	line := lastLine()
+	require.Contains(t, buf.String(), fmt.Sprintf("banner_test.go:%d: [INFO ] started", line))
+	require.Contains(t, buf.String(), " k=v\n")
+}
+
+func TestPackageBanner(t *testing.T) {
+	var buf bytes.Buffer
+	defer SetLogger(logger)
+	SetLogger(New(WithOutput(&buf), WithFlags(Lshortfile)))
+	Banner()
+	line := lastLine()
+	require.Contains(t, buf.String(), fmt.Sprintf("banner_test.go:%d: [INFO ] started", line))
+}
@@ -0,0 +1,176 @@
+package log
+
+import (
+	"io"
+	"os"
+	"sync"
+	"sync/atomic"
+
+	"github.com/stkali/utility/errors"
+	"github.com/stkali/utility/rotate"
+)
+
+// warningFallback is the output of the warnings which can't be logged, see
+// BridgeWarnings, for testing.
+var warningFallback io.Writer = os.Stderr
+
+// warningFallbackMtx serializes the writes to warningFallback.
+var warningFallbackMtx sync.Mutex
+
+// writeWarningFallback writes the warning err of level to warningFallback like the
+// warning output of the errors package.
+func writeWarningFallback(level errors.WarnLevel, err error) {
+	warningFallbackMtx.Lock()
+	defer warningFallbackMtx.Unlock()
+	_, _ = io.WriteString(warningFallback, level.String()+": "+err.Error()+"\n")
+}
+
+// bridgeWarning is the warning handler set by BridgeWarnings.
+func bridgeWarning(level errors.WarnLevel, err error) {
+	tagged, _ := err.(*errors.TaggedError)
+	// the warnings raised while an entry is being written, e.g. by a rotating output,
+	// the failed writes of the logger, and the warnings of a rotating output, e.g. by its
+	// cleanup while it's closed, would loop or deadlock
+	if logger.loadSink().writing() || tagged != nil && (tagged.Tag == warningTag ||
+		tagged.Tag == rotate.WarningTag && logger.loadSink().rotating()) {
+		writeWarningFallback(level, err)
+		return
+	}
+	lv := WARN
+	if level == errors.NoticeLevel {
+		lv = INFO
+	}
+	if tagged != nil {
+		logWarning(lv, tagged.Err.Error(), "source", "warning", "tag", tagged.Tag)
+		return
+	}
+	logWarning(lv, err.Error(), "source", "warning")
+}
+
+// logWarning writes a warning as an entry of level lv of the default logger with the
+// fields kv. The entry has no call site, it would be the bridge instead of the origin of
+// the warning.
+func logWarning(lv Level, msg string, kv ...any) {
+	l := logger
+	if !l.Enabled(lv) {
+		return
+	}
+	e := Entry{Time: timeNow(), Level: lv, Name: l.name, Message: msg, Fields: appendFields(l.fields, kv)}
+	if !l.sampled(l.loadSink(), &e) {
+		return
+	}
+	l.write(&e)
+}
+
+// rotating reports whether the entries are written to a rotating file.
+func (s *sink) rotating() bool {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	if _, ok := s.out.(*rotate.RotatingFile); ok {
+		return true
+	}
+	for _, o := range s.outputs {
+		if _, ok := o.w.(*rotate.RotatingFile); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// BridgeWarnings sets whether the warnings of the errors package, e.g. the operational
+// warnings of rotate, are written by the default logger as entries of level WARN, and
+// the notices as entries of level INFO, with the field source=warning, and tag=<tag>
+// for errors.Warningt and errors.Noticet:
+//
+//	[WARN ] failed to remove file source=warning tag=rotate
+//	[INFO ] cleanup removed 2 backups of app.log source=warning tag=rotate
+//
+// It sets the warning handler of the errors package, see errors.SetWarningHandler, and
+// disabling it restores the warning output. The warnings raised while an entry is being
+// written, e.g. by a rotating output, the warnings of rotate when the logger writes to a
+// rotating file, and the failed writes of the logger itself are written to stderr
+// instead, so that they can't loop or deadlock. So are the warnings raised by another
+// goroutine while the default logger writes an entry. The entries have no call site.
+func BridgeWarnings(enabled bool) {
+	if enabled {
+		errors.SetWarningHandler(bridgeWarning)
+		return
+	}
+	errors.SetWarningHandler(nil)
+}
+
+// writing reports whether the sink, or the goroutine of its queue of SetAsync, is
+// writing an entry.
+func (s *sink) writing() bool {
+	// checked first, the lock may be held by this goroutine
+	if atomic.LoadInt32(&s.inWrite) != 0 {
+		return true
+	}
+	s.mtx.Lock()
+	q := s.async
+	s.mtx.Unlock()
+	return q != nil && atomic.LoadInt32(&q.inWrite) != 0
+}
+
+// warningMirror is the output mirroring the entries to the warnings of the errors
+// package, see MirrorWarnings.
+type warningMirror struct{}
+
+// Write implements io.Writer, the entries are written by WriteEntry.
+func (*warningMirror) Write(p []byte) (int, error) {
+	return len(p), nil
+}
+
+// WriteEntry writes the entry as a warning, "<message> <fields>", with the level for the
+// entries above WARN.
+func (*warningMirror) WriteEntry(e Entry) error {
+	var b []byte
+	if e.Level > WARN {
+		b = append(b, levelName(e.Level)...)
+		b = append(b, ": "...)
+	}
+	b = append(b, e.Message...)
+	b = appendTextFields(b, e.Fields)
+	errors.Warning(string(b))
+	return nil
+}
+
+// mirror is the output of MirrorWarnings, added to the default logger.
+var mirror = struct {
+	sync.Mutex
+	w      *warningMirror
+	logger *Logger
+}{}
+
+// notBridged reports whether the entry isn't a warning written by BridgeWarnings.
+func notBridged(e *Entry) bool {
+	for _, f := range e.Fields {
+		if f.Key == "source" && f.Value == "warning" {
+			return false
+		}
+	}
+	return true
+}
+
+// MirrorWarnings sets whether the entries of level WARN and above of the default logger
+// are also written as warnings of the errors package, e.g. for the older consumers of its
+// warning output:
+//
+//	warning: refused host=db-1
+//	warning: ERROR: failed to connect host=db-1
+//
+// The warnings written by BridgeWarnings aren't mirrored, and while it is enabled the
+// mirrored warnings are written to stderr, since they are raised while the entries are
+// written.
+func MirrorWarnings(enabled bool) {
+	mirror.Lock()
+	defer mirror.Unlock()
+	if mirror.w != nil {
+		mirror.logger.RemoveOutput(mirror.w)
+		mirror.w, mirror.logger = nil, nil
+	}
+	if enabled {
+		mirror.w, mirror.logger = &warningMirror{}, logger
+		logger.AddOutput(mirror.w, WithMinLevel(WARN), WithFilter(notBridged))
+	}
+}
@@ -0,0 +1,182 @@
+package log
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stkali/utility/errors"
+	"github.com/stkali/utility/rotate"
+	"github.com/stretchr/testify/require"
+)
+
+// setWarningOutputs sets a default logger writing to the first returned buffer, the
+// warning output of the errors package to the second one and the fallback of
+// BridgeWarnings to the third one until the end of the test.
+func setWarningOutputs(t *testing.T, w io.Writer) (*syncBuffer, *syncBuffer, *syncBuffer) {
+	var logged, warned, fallback syncBuffer
+	old := logger
+	SetLogger(New(WithOutput(&logged), WithFlags(0)))
+	if w != nil {
+		logger.SetOutput(w)
+	}
+	errors.SetWarningOutput(&warned)
+	warningFallback = &fallback
+	t.Cleanup(func() {
+		BridgeWarnings(false)
+		MirrorWarnings(false)
+		SetLogger(old)
+		errors.SetWarningOutput(os.Stderr)
+		warningFallback = os.Stderr
+	})
+	return &logged, &warned, &fallback
+}
+
+func TestBridgeWarnings(t *testing.T) {
+	logged, warned, fallback := setWarningOutputs(t, nil)
+	BridgeWarnings(true)
+	errors.Warning("disk almost full")
+	errors.Warningf("%d files left", 3)
+	errors.Warningt("rotate", errors.Error("failed to remove file"))
+	// the notices are entries of level INFO, below the default level
+	errors.Noticet("rotate", errors.Error("cleanup removed 2 backups"))
+	logger.SetLevel(INFO)
+	errors.Noticet("rotate", errors.Error("cleanup removed 3 backups"))
+	require.Equal(t, "[WARN ] disk almost full source=warning\n"+
+		"[WARN ] 3 files left source=warning\n"+
+		"[WARN ] failed to remove file source=warning tag=rotate\n"+
+		"[INFO ] cleanup removed 3 backups source=warning tag=rotate\n", logged.String())
+	require.Empty(t, warned.String())
+	require.Empty(t, fallback.String())
+
+	BridgeWarnings(false)
+	errors.Warning("restored")
+	require.Equal(t, "warning: restored\n", warned.String())
+	require.NotContains(t, logged.String(), "restored")
+}
+
+func TestBridgeWarningsCaller(t *testing.T) {
+	logged, _, _ := setWarningOutputs(t, nil)
+	logger.SetFlags(Lshortfile)
+	BridgeWarnings(true)
+	errors.Warning("disk almost full")
+	// the call site would be the bridge
+	require.Equal(t, "[WARN ] disk almost full source=warning\n", logged.String())
+}
+
+func TestBridgeRotatingFileClose(t *testing.T) {
+	dir := t.TempDir()
+	// the expired backups deleted by the cleanup after the rotation
+	old := time.Now().Add(-2 * time.Hour)
+	for i := 0; i < 3000; i++ {
+		backup := filepath.Join(dir, fmt.Sprintf("rotating-%04d-app.log", i))
+		require.NoError(t, os.WriteFile(backup, nil, 0o644))
+		require.NoError(t, os.Chtimes(backup, old, old))
+	}
+	file, err := rotate.NewRotatingFile(filepath.Join(dir, "app.log"), rotate.WithMaxAge(time.Hour), rotate.WithDuration(-1), rotate.WithCompressLevel(0))
+	require.NoError(t, err)
+	logged, _, fallback := setWarningOutputs(t, nil)
+	logger.AddOutput(file)
+	logger.SetLevel(INFO)
+	BridgeWarnings(true)
+
+	done := make(chan error)
+	go func() {
+		Info("before rotation")
+		if err := file.Rotate(); err != nil {
+			done <- err
+			return
+		}
+		done <- file.Close()
+	}()
+	select {
+	case err := <-done:
+		require.NoError(t, err)
+	case <-time.After(30 * time.Second):
+		t.Fatal("rotate and close are deadlocked")
+	}
+	// the notice of the cleanup isn't written to the rotating file being closed
+	require.Equal(t, "[INFO ] before rotation\n", logged.String())
+	require.Equal(t, "notice: rotate: cleanup removed 3000 backups of app.log\n", fallback.String())
+}
+
+// warningWriter is an output raising a warning on every write, like a rotating file
+// failing to remove a backup.
+type warningWriter struct {
+	bytes.Buffer
+}
+
+func (w *warningWriter) Write(p []byte) (int, error) {
+	errors.Warningt("rotate", errors.Error("failed to remove backup"))
+	return w.Buffer.Write(p)
+}
+
+func TestBridgeWarningsLoop(t *testing.T) {
+	t.Run("failed write", func(t *testing.T) {
+		logged, _, fallback := setWarningOutputs(t, failedWriter{})
+		BridgeWarnings(true)
+		Warn("lost")
+		require.Empty(t, logged.String())
+		require.Equal(t, "warning: log: failed to write log entry, err: file already closed\n", fallback.String())
+	})
+	t.Run("warning while writing", func(t *testing.T) {
+		var w warningWriter
+		_, _, fallback := setWarningOutputs(t, &w)
+		BridgeWarnings(true)
+		Warn("written")
+		require.Equal(t, "[WARN ] written\n", w.String())
+		require.Equal(t, "warning: rotate: failed to remove backup\n", fallback.String())
+	})
+	t.Run("async", func(t *testing.T) {
+		var w warningWriter
+		_, _, fallback := setWarningOutputs(t, &w)
+		logger.SetAsync(4)
+		defer logger.SetAsync(0)
+		BridgeWarnings(true)
+		Warn("queued")
+		Flush()
+		require.Equal(t, "[WARN ] queued\n", w.String())
+		require.Equal(t, "warning: rotate: failed to remove backup\n", fallback.String())
+	})
+}
+
+func TestMirrorWarnings(t *testing.T) {
+	logged, warned, fallback := setWarningOutputs(t, nil)
+	MirrorWarnings(true)
+	// enabling again doesn't mirror twice
+	MirrorWarnings(true)
+	Info("hidden")
+	Warnw("refused", "host", "db-1")
+	Errorw("failed to connect", "host", "db-1")
+	require.Equal(t, "warning: refused host=db-1\nwarning: ERROR: failed to connect host=db-1\n", warned.String())
+	require.Equal(t, "[WARN ] refused host=db-1\n[ERROR] failed to connect host=db-1\n", logged.String())
+
+	// the bridged warnings aren't mirrored back
+	BridgeWarnings(true)
+	errors.Warning("bridged")
+	require.Contains(t, logged.String(), "[WARN ] bridged source=warning\n")
+	require.NotContains(t, warned.String(), "bridged")
+	Warn("both")
+	require.Equal(t, "warning: both\n", fallback.String())
+
+	MirrorWarnings(false)
+	BridgeWarnings(false)
+	Warn("unmirrored")
+	require.NotContains(t, warned.String(), "unmirrored")
+}
+
+func BenchmarkBridgeWarnings(b *testing.B) {
+	old := logger
+	defer SetLogger(old)
+	SetLogger(New(WithOutput(io.Discard), WithFlags(0), WithLevel(INFO)))
+	BridgeWarnings(true)
+	defer BridgeWarnings(false)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		Info("entry")
+	}
+}
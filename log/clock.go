@@ -0,0 +1,68 @@
+package log
+
+import (
+	"sort"
+	"sync/atomic"
+	"time"
+)
+
+// clock holds the func() time.Time set by SetTimeFunc.
+var clock atomic.Value
+
+func init() {
+	clock.Store(time.Now)
+}
+
+// timeNow returns the current time of the entries and the samplers, see SetTimeFunc.
+func timeNow() time.Time {
+	return clock.Load().(func() time.Time)()
+}
+
+// SetTimeFunc sets the function returning the time of the entries, also used by the
+// samplers to roll their periods, e.g. to write the same entries in golden tests.
+// Default is time.Now, restored by a nil now.
+func SetTimeFunc(now func() time.Time) {
+	if now == nil {
+		now = time.Now
+	}
+	clock.Store(now)
+}
+
+// sortedFields is set by DeterministicForTest, it is accessed atomically.
+var sortedFields int32
+
+// sortFields returns the fields sorted by key, the order of the fields of the same key
+// is kept. fields isn't modified.
+func sortFields(fields []Field) []Field {
+	if sort.SliceIsSorted(fields, func(i, j int) bool { return fields[i].Key < fields[j].Key }) {
+		return fields
+	}
+	sorted := append(make([]Field, 0, len(fields)), fields...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Key < sorted[j].Key })
+	return sorted
+}
+
+// DeterministicTime is the time of the entries set by DeterministicForTest.
+var DeterministicTime = time.Date(2024, time.January, 2, 3, 4, 5, 123456000, time.UTC)
+
+// DeterministicForTest makes the entries the same on every run until the end of the test
+// t, e.g. to compare them with golden files: their time is DeterministicTime, their
+// fields are sorted by key and the levels written by the standard logger aren't colored.
+// The time function, the order of the fields and the color mode are restored by
+// t.Cleanup. The tests calling it mustn't run in parallel.
+func DeterministicForTest(t interface{ Cleanup(func()) }) {
+	prevClock := clock.Load().(func() time.Time)
+	s := logger.ownSink()
+	s.mtx.Lock()
+	prevColor := s.color
+	s.mtx.Unlock()
+	prevSorted := atomic.SwapInt32(&sortedFields, 1)
+
+	SetTimeFunc(func() time.Time { return DeterministicTime })
+	logger.SetColor(ColorNever)
+	t.Cleanup(func() {
+		SetTimeFunc(prevClock)
+		logger.SetColor(prevColor)
+		atomic.StoreInt32(&sortedFields, prevSorted)
+	})
+}
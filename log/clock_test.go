@@ -0,0 +1,49 @@
+package log
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetTimeFunc(t *testing.T) {
+	clock := setFakeClock(t)
+	var buf bytes.Buffer
+	l := New(WithOutput(&buf), WithFlags(0), WithTimeFormat(time.RFC3339))
+	l.SetSampler(SampleFirstN(1, time.Minute))
+
+	// the samplers use the time function too, no sleep is needed
+	l.Warn("refused")
+	l.Warn("refused")
+	clock.Add(time.Minute)
+	l.Warn("refused")
+	require.Equal(t, "2024-09-19T00:00:00Z [WARN ] refused\n"+
+		"2024-09-19T00:01:00Z [WARN ] suppressed 1 similar messages message=refused\n"+
+		"2024-09-19T00:01:00Z [WARN ] refused\n", buf.String())
+
+	SetTimeFunc(nil)
+	before := time.Now()
+	require.False(t, timeNow().Before(before))
+}
+
+func TestDeterministicForTest(t *testing.T) {
+	var buf bytes.Buffer
+	defer SetLogger(logger)
+	SetLogger(New(WithOutput(&buf), WithFlags(0), WithColor(ColorAlways), WithTimeFormat(time.RFC3339Nano)))
+
+	t.Run("pinned", func(t *testing.T) {
+		DeterministicForTest(t)
+		Warnw("refused", "b", 2, "a", 1, "b", 3)
+		require.Equal(t, "2024-01-02T03:04:05.123456Z [WARN ] refused a=1 b=2 b=3\n", buf.String())
+		require.Equal(t, DeterministicTime, timeNow())
+	})
+
+	// restored by the cleanup of the subtest
+	buf.Reset()
+	Warnw("refused", "b", 2, "a", 1)
+	require.Contains(t, buf.String(), "\x1b[")
+	require.Contains(t, buf.String(), "refused b=2 a=1\n")
+	require.NotEqual(t, DeterministicTime, timeNow())
+}
@@ -0,0 +1,80 @@
+package log
+
+import (
+	"io"
+	"os"
+)
+
+// ColorMode selects whether the levels of TextFormat are colored, see SetColor.
+type ColorMode int
+
+const (
+	// ColorAuto colors the levels if the output is a terminal and the NO_COLOR
+	// environment variable is empty, see https://no-color.org.
+	ColorAuto ColorMode = iota
+	// ColorAlways colors the levels of the primary output whatever it is.
+	ColorAlways
+	// ColorNever doesn't color the levels.
+	ColorNever
+)
+
+// String implements fmt.Stringer.
+func (m ColorMode) String() string {
+	switch m {
+	case ColorAuto:
+		return "auto"
+	case ColorAlways:
+		return "always"
+	case ColorNever:
+		return "never"
+	}
+	return "ColorMode(" + string(itoa(nil, int(m), -1)) + ")"
+}
+
+// levelColors are the ANSI colors of the levels.
+var levelColors = []string{
+	"\x1b[90m",   // TRACE gray
+	"\x1b[90m",   // DEBUG gray
+	"\x1b[32m",   // INFO green
+	"\x1b[33m",   // WARN yellow
+	"\x1b[31m",   // ERROR red
+	"\x1b[1;31m", // FATAL bold red
+}
+
+const colorReset = "\x1b[0m"
+
+// appendColoredLevel appends the level like Level.String, with the name colored.
+func appendColoredLevel(b []byte, lv Level) []byte {
+	if lv < TRACE || lv > FATAL {
+		return append(b, lv.String()...)
+	}
+	name := levels[lv]
+	// the separator after the brackets isn't colored
+	n := len(name) - 1
+	b = append(b, levelColors[lv]...)
+	b = append(b, name[:n]...)
+	b = append(b, colorReset...)
+	return append(b, name[n:]...)
+}
+
+// noColor reports whether the NO_COLOR environment variable disables the colors.
+func noColor() bool {
+	return os.Getenv("NO_COLOR") != ""
+}
+
+// isTerminal reports whether w is a file descriptor of a terminal.
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(interface{ Fd() uintptr })
+	return ok && isTerminalFd(f.Fd())
+}
+
+// colorEnabled reports whether the levels written to w are colored in mode.
+func colorEnabled(mode ColorMode, w io.Writer) bool {
+	switch mode {
+	case ColorAlways:
+		return true
+	case ColorAuto:
+		return !noColor() && isTerminal(w)
+	}
+	return false
+}
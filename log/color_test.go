@@ -0,0 +1,133 @@
+package log
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestColorModeString(t *testing.T) {
+	require.Equal(t, "auto", ColorAuto.String())
+	require.Equal(t, "always", ColorAlways.String())
+	require.Equal(t, "never", ColorNever.String())
+	require.Equal(t, "ColorMode(9)", ColorMode(9).String())
+}
+
+func TestColoredText(t *testing.T) {
+	cases := []struct {
+		name  string
+		level Level
+		want  string
+	}{
+		{"trace", TRACE, "\x1b[90m[TRACE]\x1b[0m sub: msg k=v\n"},
+		{"debug", DEBUG, "\x1b[90m[DEBUG]\x1b[0m sub: msg k=v\n"},
+		{"info", INFO, "\x1b[32m[INFO ]\x1b[0m sub: msg k=v\n"},
+		{"warn", WARN, "\x1b[33m[WARN ]\x1b[0m sub: msg k=v\n"},
+		{"error", ERROR, "\x1b[31m[ERROR]\x1b[0m sub: msg k=v\n"},
+		{"fatal", FATAL, "\x1b[1;31m[FATAL]\x1b[0m sub: msg k=v\n"},
+		{"unknown", Level(7), "[Level(7)]sub: msg k=v\n"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			e := Entry{Level: c.level, Name: "sub", Message: "msg", Fields: []Field{{"k", "v"}}}
+			require.Equal(t, c.want, string(encodeText(nil, &e, &encoding{}, true)))
+		})
+	}
+}
+
+func TestIsTerminal(t *testing.T) {
+	file, err := os.Create(filepath.Join(t.TempDir(), "app.log"))
+	require.NoError(t, err)
+	defer file.Close()
+	r, w, err := os.Pipe()
+	require.NoError(t, err)
+	defer r.Close()
+	defer w.Close()
+
+	require.False(t, isTerminal(&bytes.Buffer{}))
+	require.False(t, isTerminal(file))
+	require.False(t, isTerminal(w))
+}
+
+func TestSetColor(t *testing.T) {
+	cases := []struct {
+		name    string
+		mode    ColorMode
+		noColor string
+		primary string
+	}{
+		{"auto", ColorAuto, "", "[WARN ] msg\n"},
+		{"always", ColorAlways, "", "\x1b[33m[WARN ]\x1b[0m msg\n"},
+		{"always with NO_COLOR", ColorAlways, "1", "\x1b[33m[WARN ]\x1b[0m msg\n"},
+		{"never", ColorNever, "", "[WARN ] msg\n"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			t.Setenv("NO_COLOR", c.noColor)
+			var primary, added bytes.Buffer
+			l := New(WithOutput(&primary), WithFlags(0))
+			// the colors are never written to an added output which isn't a terminal
+			l.AddOutput(&added)
+			l.SetColor(c.mode)
+			l.Warn("msg")
+			require.Equal(t, c.primary, primary.String())
+			require.Equal(t, "[WARN ] msg\n", added.String())
+		})
+	}
+
+	var buf bytes.Buffer
+	defer SetLogger(logger)
+	SetLogger(New(WithOutput(&buf), WithFlags(0), WithColor(ColorAlways)))
+	SetColor(ColorNever)
+	Error("msg")
+	require.Equal(t, "[ERROR] msg\n", buf.String())
+}
+
+func TestSetTimeFormat(t *testing.T) {
+	at := time.Date(2024, 9, 19, 20, 24, 31, 0, time.UTC)
+	cases := []struct {
+		name   string
+		format Format
+		layout string
+		omit   bool
+		flags  int
+		want   string
+	}{
+		{"flags", TextFormat, "", false, LstdFlags | LUTC, "2024/09/19 20:24:31 [WARN ] msg\n"},
+		{"layout", TextFormat, time.RFC3339, false, LUTC, "2024-09-19T20:24:31Z [WARN ] msg\n"},
+		{"omit", TextFormat, time.RFC3339, true, LstdFlags, "[WARN ] msg\n"},
+		{"json flags", JSONFormat, "", false, Ltime | LUTC, `{"time":"2024-09-19T20:24:31Z","level":"WARN","msg":"msg"}` + "\n"},
+		{"json layout", JSONFormat, time.Kitchen, false, LUTC, `{"time":"8:24PM","level":"WARN","msg":"msg"}` + "\n"},
+		{"json omit", JSONFormat, "", true, LstdFlags, `{"level":"WARN","msg":"msg"}` + "\n"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			e := Entry{Time: at, Level: WARN, Message: "msg"}
+			enc := &encoding{flags: c.flags, timeFormat: c.layout, omitTime: c.omit}
+			var got []byte
+			if c.format == JSONFormat {
+				got = encodeJSON(nil, &e, enc)
+			} else {
+				got = encodeText(nil, &e, enc, false)
+			}
+			require.Equal(t, c.want, string(got))
+		})
+	}
+
+	var buf bytes.Buffer
+	defer SetLogger(logger)
+	SetLogger(New(WithOutput(&buf), WithFlags(LstdFlags), WithTimeFormat("2006")))
+	Warn("layout")
+	require.Equal(t, fmt.Sprintf("%d [WARN ] layout\n", time.Now().Year()), buf.String())
+
+	buf.Reset()
+	SetTimeFormat("")
+	SetOmitTime(true)
+	Warn("omitted")
+	require.Equal(t, "[WARN ] omitted\n", buf.String())
+}
@@ -0,0 +1,27 @@
+package log
+
+import "context"
+
+// contextKey is the key of the logger in a context.Context.
+type contextKey struct{}
+
+// IntoContext returns a copy of ctx carrying the logger l, e.g. a logger with the
+// fields of a request:
+//
+//	ctx = log.IntoContext(ctx, log.With("request_id", id, "user", user))
+//	...
+//	log.FromContext(ctx).Info("accepted")
+func IntoContext(ctx context.Context, l *Logger) context.Context {
+	return context.WithValue(ctx, contextKey{}, l)
+}
+
+// FromContext returns the logger carried by ctx, see IntoContext, or the default
+// logger if ctx carries none.
+func FromContext(ctx context.Context) *Logger {
+	if ctx != nil {
+		if l, ok := ctx.Value(contextKey{}).(*Logger); ok && l != nil {
+			return l
+		}
+	}
+	return logger
+}
@@ -0,0 +1,76 @@
+package log
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestContext(t *testing.T) {
+	l := New()
+	cases := []struct {
+		name string
+		ctx  context.Context
+		want *Logger
+	}{
+		{"nil context", nil, logger},
+		{"no logger", context.Background(), logger},
+		{"nil logger", IntoContext(context.Background(), nil), logger},
+		{"logger", IntoContext(context.Background(), l), l},
+		{"derived context", context.WithValue(IntoContext(context.Background(), l), "k", "v"), l},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			require.Same(t, c.want, FromContext(c.ctx))
+		})
+	}
+}
+
+func TestContextFields(t *testing.T) {
+	var buf bytes.Buffer
+	parent := New(WithOutput(&buf), WithFlags(0))
+	ctx := IntoContext(context.Background(), parent.With("request_id", 7))
+	handle := func(ctx context.Context) {
+		FromContext(ctx).With("user", "bob").Warn("denied")
+	}
+	handle(ctx)
+	require.Equal(t, "[WARN ] denied request_id=7 user=bob\n", buf.String())
+
+	// the logger of the context follows the later changes of its parent
+	buf.Reset()
+	parent.SetFormat(JSONFormat)
+	parent.SetLevel(ERROR)
+	handle(ctx)
+	FromContext(ctx).Error("failed")
+	require.Equal(t, `{"level":"ERROR","msg":"failed","request_id":7}`+"\n", buf.String())
+}
+
+func TestConcurrentWith(t *testing.T) {
+	var buf bytes.Buffer
+	parent := New(WithOutput(&buf), WithFlags(0)).With("service", "api")
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				// the children of the same parent don't share the appended fields
+				ctx := IntoContext(context.Background(), parent.With("worker", i))
+				FromContext(ctx).With("n", j).Warn("tick")
+			}
+		}(i)
+	}
+	wg.Wait()
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	require.Len(t, lines, 400)
+	for _, line := range lines {
+		var worker, n int
+		_, err := fmt.Sscanf(line, "[WARN ] tick service=api worker=%d n=%d", &worker, &n)
+		require.NoError(t, err, line)
+	}
+}
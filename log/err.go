@@ -0,0 +1,125 @@
+package log
+
+import (
+	"sort"
+	"strings"
+	"sync/atomic"
+
+	"github.com/stkali/utility/errors"
+)
+
+// stackOnError is set by SetStackOnError, it is accessed atomically.
+var stackOnError int32
+
+// SetStackOnError sets whether Err writes the stack trace of the error whatever the
+// level, it's written only if DEBUG is enabled otherwise.
+func SetStackOnError(stack bool) {
+	v := int32(0)
+	if stack {
+		v = 1
+	}
+	atomic.StoreInt32(&stackOnError, v)
+}
+
+// errorChain is the messages of the errors of a chain, innermost first. TextFormat
+// writes them on a line, JSONFormat as an array.
+type errorChain []string
+
+// String implements fmt.Stringer.
+func (c errorChain) String() string {
+	return strings.Join(c, " <- ")
+}
+
+// errorStack is the frames of a stack trace. TextFormat writes them on a line, JSONFormat
+// as an array.
+type errorStack []string
+
+// String implements fmt.Stringer.
+func (s errorStack) String() string {
+	return strings.Join(s, "; ")
+}
+
+// chainOf returns the messages of the errors wrapped by err, innermost first. The
+// message of each error is stripped of the message of the error it wraps, e.g.
+// errors.Wrap(os.ErrNotExist, "failed to open") gives ["file does not exist",
+// "failed to open"]. The errors adding no message, e.g. by errors.WithCode, are skipped.
+func chainOf(err error) errorChain {
+	var chain errorChain
+	for err != nil {
+		msg := err.Error()
+		next := errors.Unwrap(err)
+		if next != nil {
+			msg = strings.TrimSuffix(msg, next.Error())
+			msg = strings.TrimRight(strings.TrimSuffix(msg, ", err: "), ": ,")
+		}
+		if msg != "" {
+			chain = append(chain, msg)
+		}
+		err = next
+	}
+	for i, j := 0, len(chain)-1; i < j; i, j = i+1, j-1 {
+		chain[i], chain[j] = chain[j], chain[i]
+	}
+	return chain
+}
+
+// appendErrorFields appends the fields of err: "error" with the chain of the messages,
+// "error.code" with its code if it has one, "error.<key>" with the fields of
+// errors.WithFields, and "error.stack" with the stack trace if stack is true.
+func appendErrorFields(fields []Field, err error, stack bool) []Field {
+	fields = append(fields, Field{Key: "error", Value: chainOf(err)})
+	if code := errors.CodeOf(err); code != errors.Unknown {
+		fields = append(fields, Field{Key: "error.code", Value: code})
+	}
+	if kv := errors.Fields(err); len(kv) > 0 {
+		keys := make([]string, 0, len(kv))
+		for k := range kv {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			fields = append(fields, Field{Key: "error." + k, Value: kv[k]})
+		}
+	}
+	if stack {
+		if frames := errors.StackTrace(err); len(frames) > 0 {
+			s := make(errorStack, len(frames))
+			for i, f := range frames {
+				s[i] = f.String()
+			}
+			fields = append(fields, Field{Key: "error.stack", Value: s})
+		}
+	}
+	return fields
+}
+
+// logErr writes an entry of level ERROR for err with the message msg and the fields kv.
+// It must be called directly by the exported functions so that the call site is found
+// at callerDepth.
+func (l *Logger) logErr(err error, msg string, kv []any) {
+	if !l.Enabled(ERROR) {
+		return
+	}
+	if err != nil {
+		stack := atomic.LoadInt32(&stackOnError) != 0 || l.Enabled(DEBUG)
+		fields := appendErrorFields(make([]Field, 0, 4+len(kv)), err, stack)
+		args := make([]any, 0, len(fields)+len(kv))
+		for _, f := range fields {
+			args = append(args, f)
+		}
+		kv = append(args, kv...)
+	}
+	l.output(ERROR, msg, kv)
+}
+
+// Err writes an entry of level ERROR for err with the message msg and the key-value
+// pairs kv, e.g.
+//
+//	logger.Err(err, "failed to load config", "path", path)
+//
+// The error is written as the fields "error", the messages of its chain innermost first,
+// "error.code", "error.<key>" for the fields of errors.WithFields, and "error.stack",
+// its stack trace, if DEBUG is enabled or SetStackOnError is set.
+func (l *Logger) Err(err error, msg string, kv ...any) {
+	l.logErr(err, msg, kv)
+}
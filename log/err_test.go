@@ -0,0 +1,113 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stkali/utility/errors"
+	"github.com/stretchr/testify/require"
+)
+
+// threeLevelError returns an error wrapped twice, with a code and fields.
+func threeLevelError() error {
+	base := errors.WithCode(errors.Error("connection refused"), errors.Unavailable)
+	dial := errors.WithFields(errors.Wrap(base, "failed to dial"), "host", "db:5432")
+	return errors.Wrapf(dial, "failed to load %s", "users")
+}
+
+func TestChainOf(t *testing.T) {
+	cases := []struct {
+		name string
+		err  error
+		want errorChain
+	}{
+		{"nil", nil, nil},
+		{"single", os.ErrNotExist, errorChain{"file does not exist"}},
+		{"wrapped", errors.Wrap(os.ErrNotExist, "failed to open"), errorChain{"file does not exist", "failed to open"}},
+		{"fmt", fmt.Errorf("read config: %w", os.ErrClosed), errorChain{"file already closed", "read config"}},
+		{"three levels", threeLevelError(), errorChain{"connection refused", "failed to dial", "failed to load users"}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			require.Equal(t, c.want, chainOf(c.err))
+		})
+	}
+}
+
+func TestErr(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(WithOutput(&buf), WithFlags(0))
+	l.Err(threeLevelError(), "query failed", "table", "users")
+	require.Equal(t, `[ERROR] query failed error="connection refused <- failed to dial <- failed to load users" `+
+		"error.code=Unavailable error.host=db:5432 table=users\n", buf.String())
+
+	buf.Reset()
+	l.SetFormat(JSONFormat)
+	l.Err(threeLevelError(), "query failed")
+	require.Equal(t, `{"level":"ERROR","msg":"query failed","error":["connection refused","failed to dial","failed to load users"],`+
+		`"error.code":"Unavailable","error.host":"db:5432"}`+"\n", buf.String())
+
+	buf.Reset()
+	l.SetFormat(TextFormat)
+	l.Err(nil, "no error", "k", "v")
+	require.Equal(t, "[ERROR] no error k=v\n", buf.String())
+
+	buf.Reset()
+	l.SetLevel(FATAL)
+	l.Err(threeLevelError(), "hidden")
+	require.Empty(t, buf.String())
+}
+
+func TestErrStack(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(WithOutput(&buf), WithFlags(0), WithFormat(JSONFormat))
+	stackOf := func() []string {
+		defer buf.Reset()
+		var entry struct {
+			Stack []string `json:"error.stack"`
+		}
+		require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+		return entry.Stack
+	}
+
+	l.Err(threeLevelError(), "no stack")
+	require.Empty(t, stackOf())
+
+	// the stack is written at DEBUG
+	l.SetLevel(DEBUG)
+	l.Err(threeLevelError(), "stack")
+	stack := stackOf()
+	require.NotEmpty(t, stack)
+	require.Contains(t, stack[0], "log.threeLevelError")
+	require.Contains(t, stack[0], "err_test.go:")
+
+	// or if SetStackOnError is set
+	l.SetLevel(WARN)
+	SetStackOnError(true)
+	defer SetStackOnError(false)
+	l.Err(threeLevelError(), "stack")
+	require.NotEmpty(t, stackOf())
+
+	// an error without a stack trace
+	l.Err(os.ErrNotExist, "no stack")
+	require.Empty(t, stackOf())
+
+	l.SetFormat(TextFormat)
+	l.Err(threeLevelError(), "stack")
+	line := strings.TrimSuffix(buf.String(), "\n")
+	require.NotContains(t, line, "\n")
+	require.Contains(t, line, "error.stack=\"github.com/stkali/utility/log.threeLevelError")
+}
+
+func TestPackageErr(t *testing.T) {
+	var buf bytes.Buffer
+	defer SetLogger(logger)
+	SetLogger(New(WithOutput(&buf), WithFlags(Lshortfile)))
+	Err(os.ErrNotExist, "failed")
+	line := lastLine()
+	require.Equal(t, fmt.Sprintf("err_test.go:%d: [ERROR] failed error=\"file does not exist\" error.code=NotFound\n", line), buf.String())
+}
@@ -0,0 +1,39 @@
+package log_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/stkali/utility/lib"
+	"github.com/stkali/utility/log"
+	"github.com/stkali/utility/rotate"
+)
+
+func ExampleSetRotateFile() {
+	dir, err := os.MkdirTemp("", "example-log")
+	if err != nil {
+		panic(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// one call to log to a file rotated at 64MB keeping 3 backups
+	file := filepath.Join(dir, "app.log")
+	if err = log.SetRotateFile(file, rotate.WithMaxSize(64*lib.MB), rotate.WithBackups(3)); err != nil {
+		panic(err)
+	}
+	defer log.SetOutput(os.Stdout)
+	log.SetFlags(0)
+	log.SetLevel(log.WARN)
+	log.Warn("disk usage is high")
+
+	content, _ := os.ReadFile(file)
+	lines := strings.SplitAfter(string(content), "\n")
+	// the file starts with "log opened", followed by the pid and the version
+	fmt.Println(strings.Join(strings.Fields(lines[0])[:4], " "))
+	fmt.Print(lines[1])
+	// Output:
+	// [INFO ] log opened
+	// [WARN ] disk usage is high
+}
@@ -0,0 +1,156 @@
+package log
+
+import (
+	"os"
+	"sync"
+	"sync/atomic"
+
+	"github.com/stkali/utility/errors"
+	"github.com/stkali/utility/rotate"
+)
+
+// NoRotateFileError is returned by RotateNow when no rotating file is set by SetRotateFile.
+var NoRotateFileError = errors.Error("no rotating file is set")
+
+var (
+	rotateMtx sync.Mutex
+	// rotateFile is the rotating file set by SetRotateFile.
+	rotateFile *rotate.RotatingFile
+	// fileLoggerFiles are the rotating files of the loggers of NewFileLogger.
+	fileLoggerFiles []*rotate.RotatingFile
+	// exitHooked is set while closeRotateFiles is registered on the errors.OnExit hooks,
+	// the hooks are unregistered once they run.
+	exitHooked bool
+)
+
+// openRotateFile creates the rotating file of path and registers closeRotateFiles on the
+// errors.OnExit hooks if it isn't, so that the file is closed by errors.Exit, Exitf and
+// CheckErr.
+func openRotateFile(path string, opts ...rotate.SetOption) (*rotate.RotatingFile, error) {
+	file, err := rotate.NewRotatingFile(path, opts...)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to create rotating log file %q", path)
+	}
+	rotateMtx.Lock()
+	defer rotateMtx.Unlock()
+	if !exitHooked {
+		exitHooked = true
+		errors.OnExit(closeRotateFiles)
+	}
+	return file, nil
+}
+
+// closeRotateFiles closes the rotating file set by SetRotateFile and those of the loggers
+// of NewFileLogger, it's the errors.OnExit hook of openRotateFile.
+func closeRotateFiles() {
+	rotateMtx.Lock()
+	files := fileLoggerFiles
+	if rotateFile != nil {
+		files = append(files[:len(files):len(files)], rotateFile)
+	}
+	fileLoggerFiles = nil
+	exitHooked = false
+	rotateMtx.Unlock()
+	for _, file := range files {
+		errors.Warningt(warningTag, file.Close())
+	}
+}
+
+// markerMessage is the message of the entry starting the new rotating files, see marker.
+const markerMessage = "log opened"
+
+// marker returns the entry of l written at the start of a new rotating file: an INFO
+// entry "log opened" with the fields of l, the backup of the previous file if any, the
+// pid and the version of the main module if the binary has the build information. It's
+// encoded without the lock of the sink, the file may be rotated by a write holding it.
+func (l *Logger) marker(backup string) []byte {
+	fields := append([]Field(nil), l.fields...)
+	if backup != "" {
+		fields = append(fields, Field{Key: "previous", Value: backup})
+	}
+	fields = append(fields, Field{Key: "pid", Value: os.Getpid()})
+	if info, ok := readBuildInfo(); ok && info != nil && info.Main.Version != "" {
+		fields = append(fields, Field{Key: "version", Value: info.Main.Version})
+	}
+	if atomic.LoadInt32(&sortedFields) != 0 {
+		fields = sortFields(fields)
+	}
+	e := Entry{Time: timeNow(), Level: INFO, Name: l.name, Message: markerMessage, Fields: fields}
+	lay := l.loadSink().loadLayout()
+	if lay.format == JSONFormat {
+		return encodeJSON(nil, &e, &lay.enc)
+	}
+	return encodeText(nil, &e, &lay.enc, lay.color)
+}
+
+// withMarker returns the option writing the marker of l at the start of the new rotating
+// files, before the header set by the other options, see rotate.Option.Header.
+func withMarker(l *Logger) rotate.SetOption {
+	return func(opt *rotate.Option) error {
+		header := opt.Header
+		opt.Header = func(backup string) []byte {
+			b := l.marker(backup)
+			if header != nil {
+				b = append(b, header(backup)...)
+			}
+			return b
+		}
+		return nil
+	}
+}
+
+// SetRotateFile sets the output of the default logger to a rotating file of path, e.g.
+//
+//	if err := log.SetRotateFile("logs/app.log", rotate.WithMaxSize(64*lib.MB)); err != nil {
+//		...
+//	}
+//
+// Every new file starts with the entry "log opened" of the default logger, with its
+// fields, the backup of the previous file, the pid and the version, whatever the level,
+// the sampler and Suppress, so that each file is self-describing. The file is closed
+// when the program exits by errors.Exit, Exitf or CheckErr, see errors.OnExit. A rotating
+// file set before is closed.
+func SetRotateFile(path string, opts ...rotate.SetOption) error {
+	file, err := openRotateFile(path, append(opts[:len(opts):len(opts)], withMarker(logger))...)
+	if err != nil {
+		return err
+	}
+	rotateMtx.Lock()
+	prev := rotateFile
+	rotateFile = file
+	rotateMtx.Unlock()
+	SetOutput(file)
+	if prev != nil {
+		errors.Warningt(warningTag, prev.Close())
+	}
+	return nil
+}
+
+// NewFileLogger returns a logger writing to a rotating file of path, with the default
+// level, prefix and flags. Every new file starts with the entry "log opened" like
+// SetRotateFile. The file is closed when the program exits by errors.Exit, Exitf or
+// CheckErr.
+func NewFileLogger(path string, opts ...rotate.SetOption) (*Logger, error) {
+	l := New()
+	file, err := openRotateFile(path, append(opts[:len(opts):len(opts)], withMarker(l))...)
+	if err != nil {
+		return nil, err
+	}
+	rotateMtx.Lock()
+	fileLoggerFiles = append(fileLoggerFiles, file)
+	rotateMtx.Unlock()
+	l.SetOutput(file)
+	return l, nil
+}
+
+// RotateNow rotates the file set by SetRotateFile now, see rotate.RotatingFile.Rotate.
+// It returns NoRotateFileError if no file is set.
+func RotateNow() error {
+	rotateMtx.Lock()
+	file := rotateFile
+	rotateMtx.Unlock()
+	if file == nil {
+		return NoRotateFileError
+	}
+	return file.Rotate()
+}
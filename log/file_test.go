@@ -0,0 +1,186 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime/debug"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stkali/utility/errors"
+	"github.com/stkali/utility/rotate"
+	"github.com/stretchr/testify/require"
+)
+
+// resetRotateFile restores the output of the default logger after SetRotateFile.
+func resetRotateFile(t *testing.T) {
+	t.Cleanup(func() {
+		rotateMtx.Lock()
+		file := rotateFile
+		rotateFile = nil
+		rotateMtx.Unlock()
+		if file != nil {
+			require.NoError(t, file.Close())
+		}
+		SetOutput(os.Stdout)
+		SetFlags(defaultFlags)
+		SetLevel(defaultLevel)
+	})
+}
+
+// openedLine returns the line of the marker starting a new rotating file without build
+// information, see setBuildInfo.
+func openedLine(kv string) string {
+	return fmt.Sprintf("[INFO ] log opened %spid=%d\n", kv, os.Getpid())
+}
+
+func TestSetRotateFile(t *testing.T) {
+	// the file is closed before the directory is removed
+	file := filepath.Join(t.TempDir(), "app.log")
+	resetRotateFile(t)
+	setBuildInfo(t, nil)
+	require.ErrorIs(t, RotateNow(), NoRotateFileError)
+
+	require.NoError(t, SetRotateFile(file, rotate.WithBackups(2)))
+	SetFlags(0)
+	SetLevel(INFO)
+	Info("first segment")
+	content, err := os.ReadFile(file)
+	require.NoError(t, err)
+	require.Equal(t, openedLine("")+"[INFO ] first segment\n", string(content))
+
+	require.NoError(t, RotateNow())
+	Warn("second segment")
+	content, err = os.ReadFile(file)
+	require.NoError(t, err)
+	entries, err := os.ReadDir(filepath.Dir(file))
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	backup := filepath.Join(filepath.Dir(file), entries[1].Name())
+	require.Equal(t, openedLine("previous="+backup+" ")+"[WARN ] second segment\n", string(content))
+
+	// invalid option
+	require.ErrorIs(t, SetRotateFile(file, rotate.WithModePerm(0o001)), rotate.ModePermissionError)
+}
+
+func TestNewFileLogger(t *testing.T) {
+	setBuildInfo(t, nil)
+	file := filepath.Join(t.TempDir(), "app.log")
+	l, err := NewFileLogger(file)
+	require.NoError(t, err)
+	l.SetFlags(0)
+	l.Error("failed")
+	content, err := os.ReadFile(file)
+	require.NoError(t, err)
+	require.Equal(t, openedLine("")+"[ERROR] failed\n", string(content))
+
+	_, err = NewFileLogger(t.TempDir())
+	require.ErrorIs(t, err, rotate.NotRegularFileError)
+}
+
+func TestRotateFileExitHook(t *testing.T) {
+	dir := t.TempDir()
+	resetRotateFile(t)
+	errors.SetExitFunc(func(code int) {})
+	defer errors.SetExitFunc(nil)
+	hooked := func() (bool, int) {
+		rotateMtx.Lock()
+		defer rotateMtx.Unlock()
+		return exitHooked, len(fileLoggerFiles)
+	}
+	// the files of the previous tests
+	closeRotateFiles()
+
+	// one hook closes the rotating files, whatever the number of files opened
+	for i := 0; i < 3; i++ {
+		require.NoError(t, SetRotateFile(filepath.Join(dir, fmt.Sprintf("app%d.log", i))))
+	}
+	l, err := NewFileLogger(filepath.Join(dir, "other.log"))
+	require.NoError(t, err)
+	defer l.SetOutput(os.Stdout)
+	ok, files := hooked()
+	require.True(t, ok)
+	require.Equal(t, 1, files)
+
+	warnings := errors.CaptureWarnings(func() {
+		errors.Exit(0)
+	})
+	require.Empty(t, warnings)
+	ok, files = hooked()
+	require.False(t, ok)
+	require.Zero(t, files)
+
+	// the hook is registered again by the next file
+	_, err = NewFileLogger(filepath.Join(dir, "next.log"))
+	require.NoError(t, err)
+	ok, _ = hooked()
+	require.True(t, ok)
+	closeRotateFiles()
+}
+
+func TestRotateMarker(t *testing.T) {
+	setBuildInfo(t, &debug.BuildInfo{Main: debug.Module{Path: "example.com/app", Version: "v1.2.3"}})
+	dir := t.TempDir()
+	file := filepath.Join(dir, "app.log")
+	resetRotateFile(t)
+	defer SetLogger(logger)
+	SetLogger(New(WithFields("service", "api")))
+	require.NoError(t, SetRotateFile(file, rotate.WithMaxSize(200), rotate.WithDuration(-1), rotate.WithHeader(func(string) []byte {
+		return []byte("# header\n")
+	})))
+	SetFlags(0)
+	// the marker is written whatever the level, the sampler and Suppress
+	SetLevel(WARN)
+	SetSampler(SampleFirstN(1, time.Hour))
+	suppress(t, Matcher{Message: regexp.MustCompile(markerMessage)})
+	opened := fmt.Sprintf("[INFO ] log opened service=api pid=%d version=v1.2.3\n", os.Getpid())
+	// the second entry rotates the file
+	for i := 0; i < 2; i++ {
+		Error(strings.Repeat("x", 100))
+	}
+	content, err := os.ReadFile(file)
+	require.NoError(t, err)
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	backup := filepath.Join(dir, entries[1].Name())
+	// both segments start with the marker, followed by the header of the option
+	require.True(t, strings.HasPrefix(string(content), strings.Replace(opened, "service=api ", "service=api previous="+backup+" ", 1)+"# header\n"))
+	previous, err := os.ReadFile(backup)
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(string(previous), opened+"# header\n[ERROR] x"))
+	// the marker isn't an entry of the logger
+	require.Equal(t, int64(0), Stats().Levels[INFO])
+
+	// the layout of the logger applies
+	SetFormat(JSONFormat)
+	require.NoError(t, RotateNow())
+	content, err = os.ReadFile(file)
+	require.NoError(t, err)
+	var marker map[string]any
+	require.NoError(t, json.Unmarshal(bytes.SplitN(content, []byte("\n"), 2)[0], &marker))
+	require.Equal(t, markerMessage, marker["msg"])
+	require.Equal(t, "api", marker["service"])
+	require.Equal(t, "v1.2.3", marker["version"])
+}
+
+// failedWriter is an io.Writer that always fails.
+type failedWriter struct{}
+
+func (failedWriter) Write([]byte) (int, error) {
+	return 0, os.ErrClosed
+}
+
+func TestWriteErrorWarning(t *testing.T) {
+	resetRotateFile(t)
+	SetOutput(failedWriter{})
+	warnings := errors.CaptureWarnings(func() {
+		Error("lost entry")
+	})
+	require.Equal(t, []string{"log: failed to write log entry, err: file already closed"}, warnings)
+}
@@ -0,0 +1,415 @@
+package log
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"path/filepath"
+	"strconv"
+	"time"
+	"unicode/utf8"
+)
+
+// Format is the encoding of the log entries written to an output.
+type Format int
+
+const (
+	// TextFormat writes the entries like the standard log package, followed by the
+	// fields as key=value pairs:
+	//	2024/09/19 20:24:31 main.go:13: [WARN ] rotate: disk is full file=app.log
+	TextFormat Format = iota
+	// JSONFormat writes every entry as a JSON object on its own line:
+	//	{"time":"2024-09-19T20:24:31.123456+08:00","level":"WARN","logger":"rotate","caller":"main.go:13","msg":"disk is full","file":"app.log"}
+	// The prefix isn't written, the time is written if Ldate or Ltime is set.
+	JSONFormat
+)
+
+// String implements fmt.Stringer.
+func (f Format) String() string {
+	switch f {
+	case TextFormat:
+		return "text"
+	case JSONFormat:
+		return "json"
+	}
+	return fmt.Sprintf("Format(%d)", int(f))
+}
+
+// Field is a key-value pair attached to a log entry, see Logger.With and Logger.Infow.
+type Field struct {
+	Key   string
+	Value any
+}
+
+// Entry is a log entry.
+type Entry struct {
+	Time  time.Time
+	Level Level
+	// Name is the name of the logger, see Logger.Named.
+	Name    string
+	Message string
+	Fields  []Field
+	// File and Line are the call site, they are set only if Lshortfile or Llongfile is set.
+	File string
+	Line int
+}
+
+// badKey is the key of a value without a string key, like log/slog.
+const badKey = "!BADKEY"
+
+// appendFields appends the key-value pairs kv to fields, they are read like log/slog:
+// a value without a string key, e.g. the last element of an odd-length kv, gets the
+// key "!BADKEY". A Field in kv is appended as is.
+func appendFields(fields []Field, kv []any) []Field {
+	for i := 0; i < len(kv); i++ {
+		switch k := kv[i].(type) {
+		case Field:
+			fields = append(fields, k)
+		case string:
+			if i == len(kv)-1 {
+				fields = append(fields, Field{Key: badKey, Value: k})
+				continue
+			}
+			fields = append(fields, Field{Key: k, Value: kv[i+1]})
+			i++
+		default:
+			fields = append(fields, Field{Key: badKey, Value: k})
+		}
+	}
+	return fields
+}
+
+// encoding is the configuration of the encoding of the entries of an output.
+type encoding struct {
+	prefix string
+	flags  int
+	// timeFormat is the layout of the time, the flags select it if it's empty.
+	timeFormat string
+	// omitTime drops the time whatever the flags and the layout.
+	omitTime bool
+}
+
+// hasTime reports whether the time is written.
+func (c *encoding) hasTime() bool {
+	return !c.omitTime && (c.timeFormat != "" || c.flags&(Ldate|Ltime|Lmicroseconds) != 0)
+}
+
+// entryTime returns the time of the entry, in UTC if LUTC is set.
+func (c *encoding) entryTime(e *Entry) time.Time {
+	if c.flags&LUTC != 0 {
+		return e.Time.UTC()
+	}
+	return e.Time
+}
+
+// levelName returns the name of the level without brackets, e.g. "INFO".
+func levelName(lv Level) string {
+	if lv >= TRACE && lv <= FATAL {
+		return levelNames[lv]
+	}
+	return "Level(" + strconv.Itoa(int(lv)) + ")"
+}
+
+var levelNames = []string{"TRACE", "DEBUG", "INFO", "WARN", "ERROR", "FATAL"}
+
+// itoa appends the decimal of i to b, zero-padded to wid digits.
+func itoa(b []byte, i int, wid int) []byte {
+	var tmp [20]byte
+	bp := len(tmp) - 1
+	for i >= 10 || wid > 1 {
+		wid--
+		q := i / 10
+		tmp[bp] = byte('0' + i - q*10)
+		bp--
+		i = q
+	}
+	tmp[bp] = byte('0' + i)
+	return append(b, tmp[bp:]...)
+}
+
+// appendCaller appends the file and the line of the entry, the base name of the file
+// unless Llongfile is set without Lshortfile.
+func appendCaller(b []byte, e *Entry, flags int) []byte {
+	file := e.File
+	if flags&Lshortfile != 0 {
+		file = filepath.Base(file)
+	}
+	b = append(b, file...)
+	b = append(b, ':')
+	return itoa(b, e.Line, -1)
+}
+
+// encodeText appends the entry in TextFormat to b, with the level colored if color is
+// true.
+func encodeText(b []byte, e *Entry, c *encoding, color bool) []byte {
+	flags := c.flags
+	if flags&Lmsgprefix == 0 {
+		b = append(b, c.prefix...)
+	}
+	if c.hasTime() {
+		t := c.entryTime(e)
+		if c.timeFormat != "" {
+			b = t.AppendFormat(b, c.timeFormat)
+			b = append(b, ' ')
+		} else {
+			b = appendFlagsTime(b, t, flags)
+		}
+	}
+	if e.File != "" {
+		b = appendCaller(b, e, flags)
+		b = append(b, ": "...)
+	}
+	if flags&Lmsgprefix != 0 {
+		b = append(b, c.prefix...)
+	}
+	if color {
+		b = appendColoredLevel(b, e.Level)
+	} else {
+		b = append(b, e.Level.String()...)
+	}
+	if e.Name != "" {
+		b = append(b, e.Name...)
+		b = append(b, ": "...)
+	}
+	b = append(b, e.Message...)
+	if len(e.Fields) > 0 {
+		// the fields are written on the line of the message
+		for len(b) > 0 && b[len(b)-1] == '\n' {
+			b = b[:len(b)-1]
+		}
+		b = appendTextFields(b, e.Fields)
+	}
+	if len(b) == 0 || b[len(b)-1] != '\n' {
+		b = append(b, '\n')
+	}
+	return b
+}
+
+// appendTextFields appends the fields in TextFormat, each one preceded by a space.
+func appendTextFields(b []byte, fields []Field) []byte {
+	for _, f := range fields {
+		b = append(b, ' ')
+		b = appendTextString(b, f.Key)
+		b = append(b, '=')
+		b = appendTextValue(b, f.Value)
+	}
+	return b
+}
+
+// appendFlagsTime appends the date and the time selected by flags like the standard log
+// package.
+func appendFlagsTime(b []byte, t time.Time, flags int) []byte {
+	if flags&Ldate != 0 {
+		year, month, day := t.Date()
+		b = itoa(b, year, 4)
+		b = append(b, '/')
+		b = itoa(b, int(month), 2)
+		b = append(b, '/')
+		b = itoa(b, day, 2)
+		b = append(b, ' ')
+	}
+	if flags&(Ltime|Lmicroseconds) != 0 {
+		hour, min, sec := t.Clock()
+		b = itoa(b, hour, 2)
+		b = append(b, ':')
+		b = itoa(b, min, 2)
+		b = append(b, ':')
+		b = itoa(b, sec, 2)
+		if flags&Lmicroseconds != 0 {
+			b = append(b, '.')
+			b = itoa(b, t.Nanosecond()/1e3, 6)
+		}
+		b = append(b, ' ')
+	}
+	return b
+}
+
+// appendTextValue appends the value of a field in TextFormat.
+func appendTextValue(b []byte, v any) []byte {
+	switch v := resolveLazy(v).(type) {
+	case nil:
+		return append(b, "<nil>"...)
+	case string:
+		return appendTextString(b, v)
+	case error:
+		return appendTextString(b, v.Error())
+	case fmt.Stringer:
+		return appendTextString(b, v.String())
+	case bool:
+		return strconv.AppendBool(b, v)
+	case int:
+		return strconv.AppendInt(b, int64(v), 10)
+	case int64:
+		return strconv.AppendInt(b, v, 10)
+	case uint64:
+		return strconv.AppendUint(b, v, 10)
+	case float64:
+		return strconv.AppendFloat(b, v, 'g', -1, 64)
+	}
+	return appendTextString(b, fmt.Sprint(v))
+}
+
+// valueString returns the value of a field as a string, unquoted.
+func valueString(v any) string {
+	switch v := resolveLazy(v).(type) {
+	case string:
+		return v
+	case error:
+		return v.Error()
+	case fmt.Stringer:
+		return v.String()
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// appendTextString appends s, quoted if it is empty or contains spaces, quotes, '='
+// or non-printable characters so that the pairs can be split back.
+func appendTextString(b []byte, s string) []byte {
+	if needsQuote(s) {
+		return strconv.AppendQuote(b, s)
+	}
+	return append(b, s...)
+}
+
+func needsQuote(s string) bool {
+	if s == "" {
+		return true
+	}
+	for _, r := range s {
+		if r <= ' ' || r == '=' || r == '"' || r == utf8.RuneError || !strconv.IsPrint(r) {
+			return true
+		}
+	}
+	return false
+}
+
+// encodeJSON appends the entry in JSONFormat to b.
+func encodeJSON(b []byte, e *Entry, c *encoding) []byte {
+	flags := c.flags
+	b = append(b, '{')
+	if c.hasTime() {
+		layout := c.timeFormat
+		if layout == "" {
+			layout = time.RFC3339Nano
+		}
+		b = append(b, `"time":`...)
+		b = appendJSONString(b, c.entryTime(e).Format(layout))
+		b = append(b, ',')
+	}
+	b = append(b, `"level":`...)
+	b = appendJSONString(b, levelName(e.Level))
+	if e.Name != "" {
+		b = append(b, `,"logger":`...)
+		b = appendJSONString(b, e.Name)
+	}
+	if e.File != "" {
+		// a windows path has backslashes to escape
+		b = append(b, `,"caller":`...)
+		b = appendJSONString(b, string(appendCaller(nil, e, flags)))
+	}
+	b = append(b, `,"msg":`...)
+	b = appendJSONString(b, e.Message)
+	for _, f := range e.Fields {
+		b = append(b, ',')
+		b = appendJSONString(b, f.Key)
+		b = append(b, ':')
+		b = appendJSONValue(b, f.Value)
+	}
+	return append(b, '}', '\n')
+}
+
+// appendJSONValue appends the value of a field in JSONFormat.
+func appendJSONValue(b []byte, v any) []byte {
+	switch v := resolveLazy(v).(type) {
+	case nil:
+		return append(b, "null"...)
+	case string:
+		return appendJSONString(b, v)
+	case error:
+		return appendJSONString(b, v.Error())
+	case time.Time:
+		return appendJSONString(b, v.Format(time.RFC3339Nano))
+	case errorChain:
+		return appendJSONStrings(b, v)
+	case errorStack:
+		return appendJSONStrings(b, v)
+	case fmt.Stringer:
+		return appendJSONString(b, v.String())
+	case bool:
+		return strconv.AppendBool(b, v)
+	case int:
+		return strconv.AppendInt(b, int64(v), 10)
+	case int64:
+		return strconv.AppendInt(b, v, 10)
+	case uint64:
+		return strconv.AppendUint(b, v, 10)
+	case float64:
+		// NaN and infinities aren't valid JSON numbers
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return appendJSONString(b, strconv.FormatFloat(v, 'g', -1, 64))
+		}
+		return strconv.AppendFloat(b, v, 'g', -1, 64)
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return appendJSONString(b, fmt.Sprint(v))
+	}
+	return append(b, data...)
+}
+
+// appendJSONStrings appends ss as a JSON array of strings.
+func appendJSONStrings(b []byte, ss []string) []byte {
+	b = append(b, '[')
+	for i, s := range ss {
+		if i > 0 {
+			b = append(b, ',')
+		}
+		b = appendJSONString(b, s)
+	}
+	return append(b, ']')
+}
+
+const hex = "0123456789abcdef"
+
+// appendJSONString appends s as a JSON string, invalid UTF-8 is replaced by U+FFFD.
+func appendJSONString(b []byte, s string) []byte {
+	b = append(b, '"')
+	start := 0
+	for i := 0; i < len(s); {
+		c := s[i]
+		if c < utf8.RuneSelf {
+			if c >= 0x20 && c != '"' && c != '\\' {
+				i++
+				continue
+			}
+			b = append(b, s[start:i]...)
+			switch c {
+			case '"', '\\':
+				b = append(b, '\\', c)
+			case '\n':
+				b = append(b, '\\', 'n')
+			case '\r':
+				b = append(b, '\\', 'r')
+			case '\t':
+				b = append(b, '\\', 't')
+			default:
+				b = append(b, '\\', 'u', '0', '0', hex[c>>4], hex[c&0xf])
+			}
+			i++
+			start = i
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && size == 1 {
+			b = append(b, s[start:i]...)
+			b = append(b, "\ufffd"...)
+			i += size
+			start = i
+			continue
+		}
+		i += size
+	}
+	b = append(b, s[start:]...)
+	return append(b, '"')
+}
@@ -0,0 +1,158 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormatString(t *testing.T) {
+	require.Equal(t, "text", TextFormat.String())
+	require.Equal(t, "json", JSONFormat.String())
+	require.Equal(t, "Format(7)", Format(7).String())
+}
+
+func TestEncodeText(t *testing.T) {
+	at := time.Date(2024, 9, 19, 20, 24, 31, 123456789, time.UTC)
+	cases := []struct {
+		name   string
+		entry  Entry
+		prefix string
+		flags  int
+		want   string
+	}{
+		{
+			"plain",
+			Entry{Level: INFO, Message: "started"},
+			"", 0,
+			"[INFO ] started\n",
+		},
+		{
+			"time",
+			Entry{Time: at, Level: WARN, Message: "slow"},
+			"", LstdFlags | Lmicroseconds | LUTC,
+			"2024/09/19 20:24:31.123456 [WARN ] slow\n",
+		},
+		{
+			"caller",
+			Entry{Level: ERROR, Message: "failed", File: "/src/app/main.go", Line: 13},
+			"app: ", Lshortfile,
+			"app: main.go:13: [ERROR] failed\n",
+		},
+		{
+			"long caller and message prefix",
+			Entry{Level: ERROR, Message: "failed", File: "/src/app/main.go", Line: 13},
+			"app: ", Llongfile | Lmsgprefix,
+			"/src/app/main.go:13: app: [ERROR] failed\n",
+		},
+		{
+			"name and fields",
+			Entry{Level: DEBUG, Name: "rotate", Message: "removed\n", Fields: []Field{{"file", "a.log"}, {"n", 2}}},
+			"", 0,
+			"[DEBUG] rotate: removed file=a.log n=2\n",
+		},
+		{
+			"unknown level",
+			Entry{Level: Level(9), Message: "msg"},
+			"", 0,
+			"[Level(9)]msg\n",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			require.Equal(t, c.want, string(encodeText(nil, &c.entry, &encoding{prefix: c.prefix, flags: c.flags}, false)))
+		})
+	}
+}
+
+func TestEncodeJSON(t *testing.T) {
+	at := time.Date(2024, 9, 19, 20, 24, 31, 123456000, time.UTC)
+	cases := []struct {
+		name  string
+		entry Entry
+		flags int
+		want  string
+	}{
+		{
+			"plain",
+			Entry{Time: at, Level: INFO, Message: "started"},
+			0,
+			`{"level":"INFO","msg":"started"}`,
+		},
+		{
+			"time and caller",
+			Entry{Time: at, Level: WARN, Name: "rotate", Message: "slow", File: `C:\app\main.go`, Line: 7},
+			LstdFlags | Llongfile | LUTC,
+			`{"time":"2024-09-19T20:24:31.123456Z","level":"WARN","logger":"rotate","caller":"C:\\app\\main.go:7","msg":"slow"}`,
+		},
+		{
+			"escape",
+			Entry{Level: ERROR, Message: "a \"quoted\"\n\ttab \x01 \xff é"},
+			0,
+			`{"level":"ERROR","msg":"a \"quoted\"\n\ttab \u0001 ` + "\ufffd é" + `"}`,
+		},
+		{
+			"fields",
+			Entry{Level: INFO, Message: "msg", Fields: []Field{
+				{"nil", nil},
+				{"err", errors.New("failed")},
+				{"float", 1.5},
+				{"nan", math.NaN()},
+				{"dur", time.Second},
+				{"list", []int{1, 2}},
+				{"complex", 1 + 2i},
+			}},
+			0,
+			`{"level":"INFO","msg":"msg","nil":null,"err":"failed","float":1.5,"nan":"NaN","dur":"1s","list":[1,2],"complex":"(1+2i)"}`,
+		},
+		{
+			"unknown level",
+			Entry{Level: Level(-1), Message: "msg"},
+			0,
+			`{"level":"Level(-1)","msg":"msg"}`,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got := string(encodeJSON(nil, &c.entry, &encoding{flags: c.flags}))
+			require.True(t, json.Valid([]byte(got)), got)
+			require.Equal(t, c.want+"\n", got)
+		})
+	}
+}
+
+func TestEncodeGolden(t *testing.T) {
+	cases := []struct {
+		name string
+		opts []Option
+		want string
+	}{
+		{
+			"text", []Option{WithFlags(LstdFlags | Lmicroseconds | LUTC), WithColor(ColorAlways)},
+			"2024/01/02 03:04:05.123456 [WARN ] db: refused attempt=3 host=db-1 id=42\n" +
+				"2024/01/02 03:04:05.123456 [ERROR] db: failed error=\"connection reset\" id=42\n",
+		},
+		{
+			"json", []Option{WithFormat(JSONFormat), WithFlags(LstdFlags)},
+			`{"time":"2024-01-02T03:04:05.123456Z","level":"WARN","logger":"db","msg":"refused","attempt":3,"host":"db-1","id":42}` + "\n" +
+				`{"time":"2024-01-02T03:04:05.123456Z","level":"ERROR","logger":"db","msg":"failed","error":"connection reset","id":42}` + "\n",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			defer SetLogger(logger)
+			SetLogger(New(append(c.opts, WithOutput(&buf))...))
+			DeterministicForTest(t)
+			l := Named("db").With("id", 42)
+			l.Warnw("refused", "host", "db-1", "attempt", 3)
+			l.Errorw("failed", "error", errors.New("connection reset"))
+			require.Equal(t, c.want, buf.String())
+		})
+	}
+}
@@ -0,0 +1,153 @@
+package log
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// afterFunc calls f in its own goroutine after d and returns a function stopping the
+// call, see time.AfterFunc, for testing.
+var afterFunc = func(d time.Duration, f func()) (stop func() bool) {
+	return time.AfterFunc(d, f).Stop
+}
+
+// levelRevert is a pending revert of a level set for a duration by LevelHandler.
+type levelRevert struct {
+	stop  func() bool
+	level int32
+}
+
+// reverts holds the pending reverts by logger name, "" for the default logger.
+var reverts = struct {
+	sync.Mutex
+	m map[string]*levelRevert
+}{m: make(map[string]*levelRevert)}
+
+// swapLevel sets the level of the loggers named name, or of the default logger if
+// name is "", and returns the previous one, noLevel if the named loggers inherited it.
+func swapLevel(name string, lv int32) int32 {
+	if name == "" {
+		l := logger
+		prev := l.Level()
+		l.SetLevel(Level(lv))
+		return int32(prev)
+	}
+	return atomic.SwapInt32(namedLevel(name), lv)
+}
+
+// applyLevel sets the level of the loggers named name, or of the default logger if name
+// is "", and cancels its pending revert. If d > 0, the level is reverted after d to the
+// one preceding the first change made for a duration.
+func applyLevel(name string, lv Level, d time.Duration) {
+	reverts.Lock()
+	defer reverts.Unlock()
+	prev := swapLevel(name, int32(lv))
+	if r, ok := reverts.m[name]; ok {
+		r.stop()
+		delete(reverts.m, name)
+		prev = r.level
+	}
+	if d <= 0 {
+		return
+	}
+	r := &levelRevert{level: prev}
+	r.stop = afterFunc(d, func() {
+		reverts.Lock()
+		defer reverts.Unlock()
+		// the revert may have been replaced after its timer fired
+		if reverts.m[name] == r {
+			delete(reverts.m, name)
+			swapLevel(name, r.level)
+		}
+	})
+	reverts.m[name] = r
+}
+
+// levelState is the JSON body of the responses of LevelHandler.
+type levelState struct {
+	Level   string            `json:"level"`
+	Loggers map[string]string `json:"loggers,omitempty"`
+}
+
+// currentLevels returns the level of the default logger and the levels set for the
+// named loggers.
+func currentLevels() levelState {
+	state := levelState{Level: levelName(logger.Level())}
+	namedLevels.Range(func(key, value any) bool {
+		if lv := atomic.LoadInt32(value.(*int32)); lv != noLevel {
+			if state.Loggers == nil {
+				state.Loggers = make(map[string]string)
+			}
+			state.Loggers[key.(string)] = levelName(Level(lv))
+		}
+		return true
+	})
+	return state
+}
+
+// levelRequest is the JSON body of the PUT and POST requests of LevelHandler.
+type levelRequest struct {
+	Logger string `json:"logger"`
+	Level  string `json:"level"`
+	For    string `json:"for"`
+}
+
+// maxLevelRequest is the maximum size of the body of a request of LevelHandler.
+const maxLevelRequest = 1 << 12
+
+// LevelHandler returns a http.Handler reading and setting the levels at runtime, e.g.
+// to flip a service to DEBUG without restarting it:
+//
+//	http.Handle("/debug/log/level", log.LevelHandler())
+//
+// GET responds with the level of the default logger and the levels set for the named
+// loggers, see Logger.Named:
+//
+//	{"level":"WARN","loggers":{"rotate":"DEBUG"}}
+//
+// PUT and POST set the level of the default logger, or of the loggers named "logger",
+// and respond like GET. The level is reverted after the duration "for" if it is set,
+// a later change of the same logger cancels the revert:
+//
+//	{"logger":"rotate","level":"debug","for":"10m"}
+//
+// The handler has no authentication, it should be served on a private address.
+func LevelHandler() http.Handler {
+	return http.HandlerFunc(serveLevel)
+}
+
+// serveLevel serves the requests of LevelHandler.
+func serveLevel(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead:
+	case http.MethodPut, http.MethodPost:
+		var req levelRequest
+		if err := json.NewDecoder(io.LimitReader(r.Body, maxLevelRequest)).Decode(&req); err != nil {
+			http.Error(w, "invalid request: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		lv, err := ParseLevel(req.Level)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		var d time.Duration
+		if req.For != "" {
+			if d, err = time.ParseDuration(req.For); err != nil || d <= 0 {
+				http.Error(w, "invalid duration: "+req.For, http.StatusBadRequest)
+				return
+			}
+		}
+		applyLevel(req.Logger, lv, d)
+	default:
+		w.Header().Set("Allow", "GET, HEAD, PUT, POST")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(currentLevels())
+}
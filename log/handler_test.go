@@ -0,0 +1,163 @@
+package log
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// fakeTimer is a timer of setFakeTimers, fired by the test.
+type fakeTimer struct {
+	d       time.Duration
+	f       func()
+	stopped bool
+}
+
+// setFakeTimers replaces afterFunc until the end of the test, the timers are recorded
+// in the returned slice instead of firing.
+func setFakeTimers(t *testing.T) *[]*fakeTimer {
+	var timers []*fakeTimer
+	old := afterFunc
+	afterFunc = func(d time.Duration, f func()) func() bool {
+		timer := &fakeTimer{d: d, f: f}
+		timers = append(timers, timer)
+		return func() bool {
+			timer.stopped = true
+			return true
+		}
+	}
+	t.Cleanup(func() { afterFunc = old })
+	return &timers
+}
+
+// setTestLevels sets a default logger of level WARN and resets the levels of the
+// loggers named names until the end of the test.
+func setTestLevels(t *testing.T, names ...string) {
+	old := logger
+	SetLogger(New(WithLevel(WARN)))
+	t.Cleanup(func() {
+		SetLogger(old)
+		for _, name := range names {
+			atomic.StoreInt32(namedLevel(name), noLevel)
+		}
+	})
+}
+
+// doLevel sends a request to the level handler of srv and returns the decoded state.
+func doLevel(t *testing.T, srv *httptest.Server, method, body string) levelState {
+	req, err := http.NewRequest(method, srv.URL, strings.NewReader(body))
+	require.NoError(t, err)
+	resp, err := srv.Client().Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+	var state levelState
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&state))
+	return state
+}
+
+func TestLevelHandler(t *testing.T) {
+	setTestLevels(t, "handler", "handler.db")
+	timers := setFakeTimers(t)
+	srv := httptest.NewServer(LevelHandler())
+	defer srv.Close()
+
+	state := doLevel(t, srv, http.MethodGet, "")
+	require.Equal(t, "WARN", state.Level)
+	require.NotContains(t, state.Loggers, "handler")
+
+	// set and read back
+	doLevel(t, srv, http.MethodPut, `{"level":"info"}`)
+	state = doLevel(t, srv, http.MethodPost, `{"logger":"handler","level":"debug"}`)
+	require.Equal(t, "INFO", state.Level)
+	require.Equal(t, "DEBUG", state.Loggers["handler"])
+	require.Equal(t, INFO, logger.Level())
+	require.Equal(t, DEBUG, logger.Named("handler").Level())
+	require.Empty(t, *timers)
+
+	// timed revert of the default logger
+	state = doLevel(t, srv, http.MethodPut, `{"level":"trace","for":"10m"}`)
+	require.Equal(t, "TRACE", state.Level)
+	require.Len(t, *timers, 1)
+	require.Equal(t, 10*time.Minute, (*timers)[0].d)
+	(*timers)[0].f()
+	require.Equal(t, "INFO", doLevel(t, srv, http.MethodGet, "").Level)
+
+	// a later change cancels the revert, the level preceding the first change is restored
+	doLevel(t, srv, http.MethodPut, `{"logger":"handler.db","level":"debug","for":"1m"}`)
+	doLevel(t, srv, http.MethodPut, `{"logger":"handler.db","level":"trace","for":"5m"}`)
+	require.Len(t, *timers, 3)
+	require.True(t, (*timers)[1].stopped)
+	(*timers)[1].f()
+	require.Equal(t, TRACE, logger.Named("handler").Named("db").Level())
+	(*timers)[2].f()
+	state = doLevel(t, srv, http.MethodGet, "")
+	require.NotContains(t, state.Loggers, "handler.db")
+	require.Equal(t, DEBUG, logger.Named("handler").Named("db").Level())
+
+	// a change without duration cancels the revert
+	doLevel(t, srv, http.MethodPut, `{"logger":"handler","level":"error","for":"1h"}`)
+	doLevel(t, srv, http.MethodPut, `{"logger":"handler","level":"info"}`)
+	require.True(t, (*timers)[3].stopped)
+	(*timers)[3].f()
+	require.Equal(t, "INFO", doLevel(t, srv, http.MethodGet, "").Loggers["handler"])
+}
+
+func TestLevelHandlerInvalid(t *testing.T) {
+	setTestLevels(t)
+	cases := []struct {
+		name   string
+		method string
+		body   string
+		status int
+	}{
+		{"method", http.MethodDelete, "", http.StatusMethodNotAllowed},
+		{"json", http.MethodPut, `{"level":`, http.StatusBadRequest},
+		{"level", http.MethodPut, `{"level":"verbose"}`, http.StatusBadRequest},
+		{"duration", http.MethodPut, `{"level":"debug","for":"soon"}`, http.StatusBadRequest},
+		{"negative duration", http.MethodPost, `{"level":"debug","for":"-1m"}`, http.StatusBadRequest},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			LevelHandler().ServeHTTP(rec, httptest.NewRequest(c.method, "/", strings.NewReader(c.body)))
+			require.Equal(t, c.status, rec.Code)
+			require.Equal(t, WARN, logger.Level())
+		})
+	}
+}
+
+func TestLevelHandlerConcurrent(t *testing.T) {
+	setTestLevels(t, "handler.concurrent")
+	handler := LevelHandler()
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				body := `{"logger":"handler.concurrent","level":"debug","for":"1ms"}`
+				if j%2 == 0 {
+					body = `{"logger":"handler.concurrent","level":"info"}`
+				}
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPut, "/", strings.NewReader(body)))
+				require.Equal(t, http.StatusOK, rec.Code)
+				handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+			}
+		}()
+	}
+	wg.Wait()
+	// the last change cancels the pending revert, even if its timer already fired
+	applyLevel("handler.concurrent", ERROR, 0)
+	time.Sleep(10 * time.Millisecond)
+	require.Equal(t, ERROR, logger.Named("handler").Named("concurrent").Level())
+}
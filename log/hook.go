@@ -0,0 +1,46 @@
+package log
+
+import "sync/atomic"
+
+// callEntryHooks calls the hooks of OnEntry with the level of an entry written, unless
+// the hooks are being called, e.g. for an entry written by a hook.
+func (s *sink) callEntryHooks(lv Level) {
+	s.mtx.Lock()
+	hooks, calling := s.entryHooks, s.hooksCalling
+	s.mtx.Unlock()
+	if len(hooks) == 0 || !atomic.CompareAndSwapInt32(calling, 0, 1) {
+		return
+	}
+	defer atomic.StoreInt32(calling, 0)
+	for _, hook := range hooks {
+		hook(lv)
+	}
+}
+
+// OnEntry registers fn to be called with the level of every entry written by the logger,
+// after it is written, e.g. to export the number of entries by level to a metrics
+// system:
+//
+//	log.OnEntry(func(lv log.Level) {
+//		if lv >= log.ERROR {
+//			errorsTotal.Inc()
+//		}
+//	})
+//
+// fn is called in the goroutine logging without the logger locked, it may log but the
+// entries it writes don't call the hooks. The hooks are called by one goroutine at a
+// time, the entries written meanwhile by the other goroutines don't call them either. The hooks are shared by the children of the
+// logger like the outputs, see AddOutput.
+func (l *Logger) OnEntry(fn func(lv Level)) {
+	if fn == nil {
+		return
+	}
+	s := l.ownSink()
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	if s.hooksCalling == nil {
+		s.hooksCalling = new(int32)
+	}
+	// the slice is copied so that the hooks are called without the lock
+	s.entryHooks = append(s.entryHooks[:len(s.entryHooks):len(s.entryHooks)], fn)
+}
@@ -0,0 +1,124 @@
+package log
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stkali/utility/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOnEntry(t *testing.T) {
+	setFakeClock(t)
+	var buf bytes.Buffer
+	l := New(WithOutput(&buf), WithFlags(0), WithLevel(DEBUG))
+	var counts [FATAL + 1]int64
+	l.OnEntry(func(lv Level) {
+		counts[lv]++
+	})
+	calls := 0
+	l.OnEntry(func(Level) {
+		calls++
+	})
+
+	l.Trace("filtered")
+	l.Debug("debug")
+	for i := 0; i < 3; i++ {
+		l.Info("info")
+	}
+	l.Named("db").Warn("warn")
+	l.With("k", "v").Error("error")
+	want := [FATAL + 1]int64{DEBUG: 1, INFO: 3, WARN: 1, ERROR: 1}
+	require.Equal(t, want, counts)
+	require.Equal(t, 6, calls)
+
+	// the suppressed entries don't call the hooks
+	l.SetSampler(SampleFirstN(1, time.Second))
+	for i := 0; i < 4; i++ {
+		l.Warn("sampled")
+	}
+	l.SetOutput(failedWriter{})
+	_ = errors.CaptureWarnings(func() {
+		l.Error("lost")
+	})
+	want[WARN]++
+	want[ERROR]++
+	require.Equal(t, want, counts)
+	require.Equal(t, OutputStats{
+		Entries:     8,
+		Levels:      want,
+		WriteErrors: 1,
+		Suppressed:  3,
+	}, l.Stats())
+}
+
+func TestOnEntryReentrant(t *testing.T) {
+	var buf syncBuffer
+	l := New(WithOutput(&buf), WithFlags(0))
+	var calls int64
+	l.OnEntry(func(lv Level) {
+		atomic.AddInt64(&calls, 1)
+		// the entries of the hook don't call it again
+		l.Warnw("hooked", "level", levelName(lv))
+	})
+	for i := 0; i < 10; i++ {
+		l.Error("entry")
+	}
+	require.Equal(t, int64(10), calls)
+	require.Equal(t, 10, strings.Count(buf.String(), "[WARN ] hooked level=ERROR\n"))
+
+	// the entries written while another goroutine calls the hooks don't call them
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				l.Error("entry")
+			}
+		}()
+	}
+	wg.Wait()
+	n := atomic.LoadInt64(&calls)
+	require.LessOrEqual(t, n, int64(810))
+	require.Equal(t, int(n), strings.Count(buf.String(), "[WARN ] hooked level=ERROR\n"))
+	require.NotContains(t, buf.String(), "hooked level=WARN")
+	require.Equal(t, 810+n, l.Stats().Entries)
+}
+
+func BenchmarkOnEntry(b *testing.B) {
+	l := New(WithOutput(io.Discard), WithFlags(0), WithLevel(INFO))
+	b.Run("no hook", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			l.Info("entry")
+		}
+	})
+	l.OnEntry(func(Level) {})
+	b.Run("hook", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			l.Info("entry")
+		}
+	})
+}
+
+func TestPackageOnEntry(t *testing.T) {
+	defer SetLogger(logger)
+	SetLogger(New(WithOutput(&bytes.Buffer{}), WithLevel(INFO)))
+	var levels []Level
+	OnEntry(func(lv Level) {
+		levels = append(levels, lv)
+	})
+	OnEntry(nil)
+	Debug("filtered")
+	Info("info")
+	Errorf("error %d", 1)
+	require.Equal(t, []Level{INFO, ERROR}, levels)
+	require.Equal(t, [FATAL + 1]int64{INFO: 1, ERROR: 1}, Stats().Levels)
+}
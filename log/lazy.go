@@ -0,0 +1,72 @@
+package log
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+)
+
+// LazyValue is a value computed only if the entry is written, see Lazy.
+type LazyValue struct {
+	fn    func() any
+	once  sync.Once
+	value any
+}
+
+// Lazy returns a value computed by fn only if the entry is written, e.g.
+//
+//	log.Debugf("state: %s", log.Lazy(func() any { return dumpState() }))
+//	log.Debugw("request", "body", log.Lazy(func() any { return string(body) }))
+//
+// fn is called at most once, even if the entry is written to several outputs.
+func Lazy(fn func() any) *LazyValue {
+	return &LazyValue{fn: fn}
+}
+
+// Value returns the value computed by the function of Lazy.
+func (v *LazyValue) Value() any {
+	v.once.Do(func() {
+		if v.fn != nil {
+			v.value = v.fn()
+		}
+	})
+	return v.value
+}
+
+// String implements fmt.Stringer.
+func (v *LazyValue) String() string {
+	return fmt.Sprint(v.Value())
+}
+
+// Format implements fmt.Formatter, the value is formatted with the verb and the flags of
+// the format, e.g. %q or %5.2f.
+func (v *LazyValue) Format(f fmt.State, verb rune) {
+	_, _ = fmt.Fprintf(f, formatString(f, verb), v.Value())
+}
+
+// formatString returns the directive formatting f with verb, like fmt.FormatString of
+// go 1.20.
+func formatString(f fmt.State, verb rune) string {
+	b := []byte{'%'}
+	for _, flag := range "+-# 0" {
+		if f.Flag(int(flag)) {
+			b = append(b, byte(flag))
+		}
+	}
+	if width, ok := f.Width(); ok {
+		b = strconv.AppendInt(b, int64(width), 10)
+	}
+	if prec, ok := f.Precision(); ok {
+		b = append(b, '.')
+		b = strconv.AppendInt(b, int64(prec), 10)
+	}
+	return string(append(b, string(verb)...))
+}
+
+// resolveLazy returns the value of v if it's a LazyValue.
+func resolveLazy(v any) any {
+	if lazy, ok := v.(*LazyValue); ok {
+		return lazy.Value()
+	}
+	return v
+}
@@ -0,0 +1,135 @@
+package log
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// counter returns a function of Lazy counting its calls.
+func counter(value any) (func() any, *int) {
+	calls := 0
+	return func() any {
+		calls++
+		return value
+	}, &calls
+}
+
+func TestLazyFormat(t *testing.T) {
+	cases := []struct {
+		name   string
+		format string
+		value  any
+		want   string
+	}{
+		{"v", "%v", 42, "42"},
+		{"s", "%s", "state", "state"},
+		{"q", "%q", "state", `"state"`},
+		{"width and precision", "%6.2f", 3.14159, "  3.14"},
+		{"flags", "%+05d", 42, "+0042"},
+		{"left", "%-4d|", 7, "7   |"},
+		{"struct", "%+v", struct{ A int }{1}, "{A:1}"},
+		{"nil", "%v", nil, "<nil>"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			fn, calls := counter(c.value)
+			v := Lazy(fn)
+			require.Equal(t, c.want, fmt.Sprintf(c.format, v))
+			require.Equal(t, fmt.Sprint(c.value), v.String())
+			require.Equal(t, 1, *calls)
+		})
+	}
+	require.Nil(t, Lazy(nil).Value())
+}
+
+func TestLazyFiltered(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(WithOutput(&buf), WithFlags(0), WithLevel(INFO))
+	fn, calls := counter("dump")
+	l.Debugf("state: %s", Lazy(fn))
+	l.Debugw("state", "dump", Lazy(fn))
+	l.Named("sub").With("k", "v").Trace(Lazy(fn))
+	require.Equal(t, 0, *calls)
+	require.Empty(t, buf.String())
+
+	fnCalls := 0
+	l.DebugFn(func() (string, []any) {
+		fnCalls++
+		return "state", nil
+	})
+	l.TraceFn(func() (string, []any) {
+		fnCalls++
+		return "state", nil
+	})
+	l.DebugFn(nil)
+	require.Equal(t, 0, fnCalls)
+	require.Empty(t, buf.String())
+}
+
+func TestLazyOnce(t *testing.T) {
+	var primary, text, json bytes.Buffer
+	l := New(WithOutput(&primary), WithFlags(0), WithLevel(TRACE))
+	l.AddOutput(&text)
+	l.AddOutput(&json, WithOutputFormat(JSONFormat))
+	fn, calls := counter("dump")
+	l.Debugw("state", "dump", Lazy(fn))
+	require.Equal(t, 1, *calls)
+	require.Equal(t, "[DEBUG] state dump=dump\n", primary.String())
+	require.Equal(t, "[DEBUG] state dump=dump\n", text.String())
+	require.Equal(t, `{"level":"DEBUG","msg":"state","dump":"dump"}`+"\n", json.String())
+
+	primary.Reset()
+	fnCalls := 0
+	l.DebugFn(func() (string, []any) {
+		fnCalls++
+		return "state", []any{"n", 1}
+	})
+	require.Equal(t, 1, fnCalls)
+	require.Equal(t, "[DEBUG] state n=1\n", primary.String())
+
+	defer SetLogger(logger)
+	SetLogger(New(WithOutput(&primary), WithFlags(Lshortfile), WithLevel(TRACE)))
+	primary.Reset()
+	TraceFn(func() (string, []any) { return "trace", nil })
+	line := lastLine()
+	DebugFn(func() (string, []any) { return "debug", nil })
+	require.Equal(t, fmt.Sprintf("lazy_test.go:%d: [TRACE] trace\nlazy_test.go:%d: [DEBUG] debug\n", line, line+2), primary.String())
+}
+
+func TestDisabledNoAlloc(t *testing.T) {
+	l := New(WithOutput(&countingWriter{}), WithLevel(INFO))
+	allocs := testing.AllocsPerRun(100, func() {
+		l.DebugFn(func() (string, []any) {
+			return "state", []any{"dump", "x"}
+		})
+	})
+	require.Zero(t, allocs)
+}
+
+func BenchmarkDisabled(b *testing.B) {
+	l := New(WithOutput(&countingWriter{}), WithLevel(INFO))
+	dump := func() any { return fmt.Sprint(make([]int, 100)) }
+	b.Run("Debugf", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			l.Debugf("state: %s", dump())
+		}
+	})
+	b.Run("Lazy", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			l.Debugf("state: %s", Lazy(dump))
+		}
+	})
+	b.Run("DebugFn", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			l.DebugFn(func() (string, []any) {
+				return "state", []any{"dump", dump()}
+			})
+		}
+	})
+}
@@ -11,11 +11,16 @@ package log
 import (
 	"fmt"
 	"io"
-	"log"
-	"os"
+	"net/http"
+	"strconv"
 	"strings"
+
+	"github.com/stkali/utility/errors"
 )
 
+// warningTag tags the warnings of the package, see errors.Warningt.
+const warningTag = "log"
+
 const (
 	TRACE Level = iota
 	DEBUG
@@ -36,7 +41,16 @@ const (
 	LstdFlags     = Ldate | Ltime // initial values for the standard logger
 )
 
-var Exit = os.Exit
+// InvalidLevelError is returned by ParseLevel for a string that isn't a level.
+var InvalidLevelError = errors.WithCode(errors.Error("invalid level"), errors.InvalidInput)
+
+func init() {
+	errors.Register("log.invalid_level", InvalidLevelError)
+}
+
+// Exit is called with code 1 after an entry of level FATAL is written, default is
+// errors.Exit so that the errors.OnExit hooks run, e.g. to close the rotating file.
+var Exit = errors.Exit
 
 type Level int
 
@@ -77,22 +91,43 @@ func ToLevelWithDefault(level any, def Level) Level {
 // string2Level returns Level when the paramter `level` lower is a standard level string else
 // defaultLevel (WARN)
 func string2Level(level string) Level {
-	switch strings.ToLower(level) {
+	if lv, ok := levelOfName(level); ok {
+		return lv
+	}
+	return defaultLevel
+}
+
+// levelOfName returns the level named name, case-insensitively.
+func levelOfName(name string) (Level, bool) {
+	switch strings.ToLower(name) {
 	case "trace":
-		return TRACE
+		return TRACE, true
 	case "debug":
-		return DEBUG
+		return DEBUG, true
 	case "info":
-		return INFO
+		return INFO, true
 	case "warning", "warn":
-		return WARN
+		return WARN, true
 	case "error", "err":
-		return ERROR
+		return ERROR, true
 	case "fatal":
-		return FATAL
+		return FATAL, true
 	default:
-		return defaultLevel
+		return 0, false
+	}
+}
+
+// ParseLevel parses a level name, case-insensitively, or the number of a level, e.g.
+// "debug", "WARN", "warning" or "4". It returns InvalidLevelError for other strings.
+func ParseLevel(s string) (Level, error) {
+	name := strings.TrimSpace(s)
+	if lv, ok := levelOfName(name); ok {
+		return lv, nil
+	}
+	if n, err := strconv.Atoi(name); err == nil && Level(n) >= TRACE && Level(n) <= FATAL {
+		return Level(n), nil
 	}
+	return 0, errors.Newf("%v: %q", InvalidLevelError, s)
 }
 
 var (
@@ -104,212 +139,272 @@ var (
 		"[ERROR] ",
 		"[FATAL] ",
 	}
-	defaultFlags  = log.LstdFlags | log.Lshortfile | log.Lmicroseconds
+	defaultFlags  = LstdFlags | Lshortfile | Lmicroseconds
 	defaultPrefix = ""
 	defaultLevel  = WARN
 )
 
-// Logger is a logger interface that provides logging function with levels.
-type Logger interface {
-	Trace(args ...any)
-	Debug(args ...any)
-	Info(args ...any)
-	Warn(args ...any)
-	Error(args ...any)
-	Fatal(args ...any)
-	Tracef(format string, args ...any)
-	Debugf(format string, args ...any)
-	Infof(format string, args ...any)
-	Warnf(format string, args ...any)
-	Errorf(format string, args ...any)
-	Fatalf(format string, args ...any)
-	SetLevel(Level)
-	SetOutput(io.Writer)
-	SetPrefix(prefix string)
-	SetFlags(flag int)
-}
-
-type defaultLogger struct {
-	stdLog *log.Logger
-	level  Level
-}
-
-func (l *defaultLogger) SetPrefix(prefix string) {
-	l.stdLog.SetPrefix(prefix)
-}
-
-func (l *defaultLogger) SetFlags(flag int) {
-	l.stdLog.SetFlags(flag)
-}
-
-func (l *defaultLogger) SetOutput(w io.Writer) {
-	l.stdLog.SetOutput(w)
-}
-
-func (l *defaultLogger) SetLevel(lv Level) {
-	l.level = lv
-}
+// logger is the default logger used by the package-level functions.
+var logger = New()
 
-func (l *defaultLogger) logf(lv Level, format *string, args ...any) {
-	if lv < l.level {
-		return
-	}
-	msg := lv.String()
-	if format != nil {
-		msg += fmt.Sprintf(*format, args...)
-	} else {
-		msg += fmt.Sprint(args...)
-	}
-	_ = l.stdLog.Output(4, msg)
-	if lv == FATAL {
-		Exit(1)
-	}
+// SetFlags sets the output flags for the standard logger.
+// The flag bits are Ldate, Ltime, and so on.
+func SetFlags(flag int) {
+	logger.SetFlags(flag)
 }
 
-func (l *defaultLogger) Fatal(args ...any) {
-	l.logf(FATAL, nil, args...)
+// SetPrefix sets the output prefix for the standard logger.
+func SetPrefix(prefix string) {
+	logger.SetPrefix(prefix)
 }
 
-func (l *defaultLogger) Error(args ...any) {
-	l.logf(ERROR, nil, args...)
+// SetOutput sets the output destination for the standard logger.
+func SetOutput(w io.Writer) {
+	logger.SetOutput(w)
 }
 
-func (l *defaultLogger) Warn(args ...any) {
-	l.logf(WARN, nil, args...)
+// SetMaxEntrySize limits the size of the entries of the standard logger, see
+// Logger.SetMaxEntrySize.
+func SetMaxEntrySize(bytes int) {
+	logger.SetMaxEntrySize(bytes)
 }
 
-func (l *defaultLogger) Info(args ...any) {
-	l.logf(INFO, nil, args...)
+// SetReportCaller sets whether the call site is written with the entries of the
+// standard logger, see Logger.SetReportCaller.
+func SetReportCaller(report bool) {
+	logger.SetReportCaller(report)
 }
 
-func (l *defaultLogger) Debug(args ...any) {
-	l.logf(DEBUG, nil, args...)
+// AddOutput adds the output w receiving the entries of the standard logger too, see
+// Logger.AddOutput.
+func AddOutput(w io.Writer, opts ...OutputOption) {
+	logger.AddOutput(w, opts...)
 }
 
-func (l *defaultLogger) Trace(args ...any) {
-	l.logf(TRACE, nil, args...)
+// RemoveOutput removes the output w added by AddOutput, see Logger.RemoveOutput.
+func RemoveOutput(w io.Writer) bool {
+	return logger.RemoveOutput(w)
 }
 
-func (l *defaultLogger) Fatalf(format string, args ...any) {
-	l.logf(FATAL, &format, args...)
+// Stats returns the counters of the entries written by the standard logger.
+func Stats() OutputStats {
+	return logger.Stats()
 }
 
-func (l *defaultLogger) Errorf(format string, args ...any) {
-	l.logf(ERROR, &format, args...)
+// OnEntry registers fn to be called with the level of every entry written by the
+// standard logger, see Logger.OnEntry.
+func OnEntry(fn func(lv Level)) {
+	logger.OnEntry(fn)
 }
 
-func (l *defaultLogger) Warnf(format string, args ...any) {
-	l.logf(WARN, &format, args...)
+// SetColor sets whether the levels of the standard logger are colored, see
+// Logger.SetColor.
+func SetColor(mode ColorMode) {
+	logger.SetColor(mode)
 }
 
-func (l *defaultLogger) Infof(format string, args ...any) {
-	l.logf(INFO, &format, args...)
+// SetTimeFormat sets the layout of the time of the entries of the standard logger, see
+// Logger.SetTimeFormat.
+func SetTimeFormat(layout string) {
+	logger.SetTimeFormat(layout)
 }
 
-func (l *defaultLogger) Debugf(format string, args ...any) {
-	l.logf(DEBUG, &format, args...)
+// SetOmitTime sets whether the time is dropped from the entries of the standard logger.
+func SetOmitTime(omit bool) {
+	logger.SetOmitTime(omit)
 }
 
-func (l *defaultLogger) Tracef(format string, args ...any) {
-	l.logf(TRACE, &format, args...)
+// SetSampler sets the sampler of the standard logger, see Logger.SetSampler.
+func SetSampler(sampler Sampler) {
+	logger.SetSampler(sampler)
 }
 
-var logger Logger = &defaultLogger{
-	level:  WARN,
-	stdLog: log.New(os.Stdout, defaultPrefix, defaultFlags),
+// SetAsync makes the writes of the standard logger asynchronous, see Logger.SetAsync.
+func SetAsync(queueSize int) {
+	logger.SetAsync(queueSize)
 }
 
-// SetFlags sets the output flags for the standard logger.
-// The flag bits are Ldate, Ltime, and so on.
-func SetFlags(flag int) {
-	logger.SetFlags(flag)
+// SetOverflowPolicy sets what the standard logger does when the queue of SetAsync is full.
+func SetOverflowPolicy(policy OverflowPolicy) {
+	logger.SetOverflowPolicy(policy)
 }
 
-// SetPrefix sets the output prefix for the standard logger.
-func SetPrefix(prefix string) {
-	logger.SetPrefix(prefix)
+// Flush blocks until the entries queued by the standard logger are written.
+func Flush() {
+	logger.Flush()
 }
 
-// SetOutput sets the output destination for the standard logger.
-func SetOutput(w io.Writer) {
-	logger.SetOutput(w)
+// SetFormat sets the format of the entries of the standard logger, see Format.
+func SetFormat(format Format) {
+	logger.SetFormat(format)
 }
 
 // SetLevel sets the level of logs below which logs wid not be output.
 // The default log level is defaultLevel.
-// Note that this method is not concurrent-safe.
 func SetLevel(lv any) {
 	logger.SetLevel(ToLevel(lv))
 }
 
-// DefaultLogger return the default logger for kitex.
-func DefaultLogger() Logger {
+// OnLevelChange registers fn to be called when the level of the standard logger is
+// changed, see Logger.OnLevelChange.
+func OnLevelChange(fn func(old, new Level)) {
+	logger.OnLevelChange(fn)
+}
+
+// DefaultLogger return the default logger.
+func DefaultLogger() *Logger {
 	return logger
 }
 
 // SetLogger sets the default logger.
 // Note that this method is not concurrent-safe and must not be caded
 // after the use of DefaultLogger and global functions in this package.
-func SetLogger(l Logger) {
+func SetLogger(l *Logger) {
 	logger = l
 }
 
+// With returns a child logger of the default logger writing the key-value pairs kv
+// with every entry, see Logger.With.
+func With(kv ...any) *Logger {
+	return logger.With(kv...)
+}
+
+// Named returns a child logger of the default logger for the subsystem name, see
+// Logger.Named.
+func Named(name string) *Logger {
+	return logger.Named(name)
+}
+
+// WriterAt returns a writer writing every line as an entry of level lv of the default
+// logger named name, see Logger.WriterAt.
+func WriterAt(lv Level, name string) io.WriteCloser {
+	return logger.WriterAt(lv, name)
+}
+
+// The package-level functions call logf themselves rather than the methods of the
+// default logger so that the call site is found at callerDepth.
+
 // Fatal cads the default logger's Fatal method and then os.Exit(1).
 func Fatal(args ...any) {
-	logger.Fatal(args...)
+	logger.logf(FATAL, nil, args, nil)
 }
 
 // Error cads the default logger's Error method.
 func Error(args ...any) {
-	logger.Error(args...)
+	logger.logf(ERROR, nil, args, nil)
 }
 
 // Warn cads the default logger's Warn method.
 func Warn(args ...any) {
-	logger.Warn(args...)
+	logger.logf(WARN, nil, args, nil)
 }
 
 // Info cads the default logger's Info method.
 func Info(args ...any) {
-	logger.Info(args...)
+	logger.logf(INFO, nil, args, nil)
 }
 
 // Debug cads the default logger's Debug method.
 func Debug(args ...any) {
-	logger.Debug(args...)
+	logger.logf(DEBUG, nil, args, nil)
 }
 
 // Trace cads the default logger's Trace method.
 func Trace(args ...any) {
-	logger.Trace(args...)
+	logger.logf(TRACE, nil, args, nil)
 }
 
 // Fatalf cads the default logger's Fatalf method and then os.Exit(1).
 func Fatalf(format string, args ...any) {
-	logger.Fatalf(format, args...)
+	logger.logf(FATAL, &format, args, nil)
 }
 
 // Errorf cads the default logger's Errorf method.
 func Errorf(format string, args ...any) {
-	logger.Errorf(format, args...)
+	logger.logf(ERROR, &format, args, nil)
 }
 
 // Warnf cads the default logger's Warnf method.
 func Warnf(format string, args ...any) {
-	logger.Warnf(format, args...)
+	logger.logf(WARN, &format, args, nil)
 }
 
 // Infof cads the default logger's Infof method.
 func Infof(format string, args ...any) {
-	logger.Infof(format, args...)
+	logger.logf(INFO, &format, args, nil)
 }
 
 // Debugf cads the default logger's Debugf method.
 func Debugf(format string, args ...any) {
-	logger.Debugf(format, args...)
+	logger.logf(DEBUG, &format, args, nil)
 }
 
 // Tracef cads the default logger's Tracef method.
 func Tracef(format string, args ...any) {
-	logger.Tracef(format, args...)
+	logger.logf(TRACE, &format, args, nil)
+}
+
+// Fatalw cads the default logger's Fatalw method and then os.Exit(1).
+func Fatalw(msg string, kv ...any) {
+	logger.logf(FATAL, nil, []any{msg}, kv)
+}
+
+// Errorw cads the default logger's Errorw method.
+func Errorw(msg string, kv ...any) {
+	logger.logf(ERROR, nil, []any{msg}, kv)
+}
+
+// Warnw cads the default logger's Warnw method.
+func Warnw(msg string, kv ...any) {
+	logger.logf(WARN, nil, []any{msg}, kv)
+}
+
+// Infow cads the default logger's Infow method.
+func Infow(msg string, kv ...any) {
+	logger.logf(INFO, nil, []any{msg}, kv)
+}
+
+// Debugw cads the default logger's Debugw method.
+func Debugw(msg string, kv ...any) {
+	logger.logf(DEBUG, nil, []any{msg}, kv)
+}
+
+// Tracew cads the default logger's Tracew method.
+func Tracew(msg string, kv ...any) {
+	logger.logf(TRACE, nil, []any{msg}, kv)
+}
+
+// TraceFn cads the default logger's TraceFn method.
+func TraceFn(fn func() (msg string, kv []any)) {
+	logger.logFn(TRACE, fn)
+}
+
+// DebugFn cads the default logger's DebugFn method.
+func DebugFn(fn func() (msg string, kv []any)) {
+	logger.logFn(DEBUG, fn)
+}
+
+// Err cads the default logger's Err method.
+func Err(err error, msg string, kv ...any) {
+	logger.logErr(err, msg, kv)
+}
+
+// Banner calls the default logger's Banner method.
+func Banner(extra ...any) {
+	logger.banner(extra)
+}
+
+// RecoverAndLog stops a panic and writes it with the standard logger, it must be
+// deferred directly, see Logger.RecoverAndLog.
+func RecoverAndLog(msg string) {
+	if r := recover(); r != nil {
+		logger.logPanic(r, msg, nil)
+		logger.afterPanic(r)
+	}
+}
+
+// HTTPMiddleware returns a handler logging the requests served by next with the standard
+// logger, see Logger.HTTPMiddleware.
+func HTTPMiddleware(next http.Handler) http.Handler {
+	return logger.HTTPMiddleware(next)
 }
@@ -2,8 +2,11 @@ package log
 
 import (
 	"bytes"
+	"fmt"
 	"testing"
 
+	"github.com/stkali/utility/errors"
+
 	"github.com/stretchr/testify/require"
 )
 
@@ -92,6 +95,61 @@ func TestLevelString(t *testing.T) {
 	}
 }
 
+func TestParseLevel(t *testing.T) {
+	cases := []struct {
+		name  string
+		input string
+		want  Level
+		err   bool
+	}{
+		{"lower", "debug", DEBUG, false},
+		{"upper", "TRACE", TRACE, false},
+		{"mixed", "Info", INFO, false},
+		{"warning", "warning", WARN, false},
+		{"err", "err", ERROR, false},
+		{"spaces", " fatal\n", FATAL, false},
+		{"number", "2", INFO, false},
+		{"number fatal", "5", FATAL, false},
+		{"number out of range", "6", 0, true},
+		{"negative number", "-1", 0, true},
+		{"unknown", "verbose", 0, true},
+		{"empty", "", 0, true},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			lv, err := ParseLevel(c.input)
+			if c.err {
+				require.ErrorIs(t, err, InvalidLevelError)
+				require.Contains(t, err.Error(), fmt.Sprintf("%q", c.input))
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, c.want, lv)
+		})
+	}
+}
+
+func TestFatalExit(t *testing.T) {
+	preExit := Exit
+	defer func() { Exit = preExit }()
+	Exit = errors.Exit
+	var codes []int
+	errors.SetExitFunc(func(code int) { codes = append(codes, code) })
+	defer errors.SetExitFunc(nil)
+
+	var buf bytes.Buffer
+	l := New(WithOutput(&buf), WithFlags(0))
+	hooked := false
+	errors.OnExit(func() { hooked = true })
+	// the hooks registered by other tests, e.g. closing rotating files, run too
+	errors.CaptureWarnings(func() {
+		l.Fatalw("stopped", "code", 1)
+	})
+	require.Equal(t, "[FATAL] stopped code=1\n", buf.String())
+	require.Equal(t, []int{1}, codes)
+	require.True(t, hooked)
+}
+
 func TestLevelLimit(t *testing.T) {
 
 	cases := []struct {
@@ -360,7 +418,8 @@ func checkOutput(t *testing.T, level Level, args []any, expectArgs string, forma
 
 func TestConfig(t *testing.T) {
 	require.Equal(t, logger, DefaultLogger())
-	newLog := new(defaultLogger)
+	defer SetLogger(logger)
+	newLog := New()
 	SetLogger(newLog)
 	require.Equal(t, newLog, DefaultLogger())
 }
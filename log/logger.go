@@ -0,0 +1,819 @@
+package log
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"runtime"
+	"sync"
+	"sync/atomic"
+
+	"github.com/stkali/utility/errors"
+)
+
+// noLevel is the level of a logger inheriting the level of its parent.
+const noLevel = math.MinInt32
+
+// sink is the output of a logger with its configuration, shared by the children of the
+// logger until they set their own.
+type sink struct {
+	// entries, levels, writeErrors, dropped, suppressed, muted and truncated are
+	// accessed atomically, see Stats.
+	entries     int64
+	levels      [FATAL + 1]int64
+	writeErrors int64
+	dropped     int64
+	suppressed  int64
+	muted       int64
+	truncated   int64
+	// inWrite is the number of entries being written, see BridgeWarnings
+	inWrite int32
+
+	mtx sync.Mutex
+	out io.Writer
+	// enc is the encoding of the entries, its flags are copied from flags on write
+	enc    encoding
+	format Format
+	// flags is accessed atomically so that the caller is computed without the lock
+	flags int32
+	color ColorMode
+	// outColor is whether the levels written to out are colored, and addedColor whether
+	// the ones written to the added terminals are, see updateColor.
+	outColor   bool
+	addedColor bool
+	// outputs are the outputs added by AddOutput
+	outputs []*output
+	// sampler holds the samplerBox set by SetSampler
+	sampler atomic.Value
+	// async is the queue of the writes set by SetAsync, nil if the writes are synchronous
+	async *asyncQueue
+	// entryHooks are the hooks registered by OnEntry
+	entryHooks []func(lv Level)
+	// hooksCalling is set while the hooks are called, it is shared with the children
+	// calling the same hooks and accessed atomically
+	hooksCalling *int32
+	// maxEntrySize is the limit of SetMaxEntrySize, 0 if the size isn't limited
+	maxEntrySize int
+	// scratch is the buffer of an entry truncated for an output, guarded by mtx
+	scratch []byte
+	// bufs are the buffers of the entry being written by encoding, guarded by mtx
+	bufs [numEncodings][]byte
+	// layout holds the layout copied by storeLayout, read without the lock
+	layout atomic.Value
+}
+
+// layout is the encoding of the entries written to out, see storeLayout.
+type layout struct {
+	enc    encoding
+	format Format
+	color  bool
+}
+
+// storeLayout copies the encoding of the entries written to out so that an entry is
+// encoded without the lock, e.g. by the marker of a rotation written while the lock is
+// held, see SetRotateFile. It must be called with mtx locked after enc, format or
+// outColor changed.
+func (s *sink) storeLayout() {
+	s.layout.Store(layout{enc: s.enc, format: s.format, color: s.outColor})
+}
+
+// loadLayout returns the layout stored by storeLayout, with the current flags.
+func (s *sink) loadLayout() layout {
+	lay, _ := s.layout.Load().(layout)
+	lay.enc.flags = int(atomic.LoadInt32(&s.flags))
+	return lay
+}
+
+// The encodings of an entry written by a sink.
+const (
+	textEncoding = iota
+	colorEncoding
+	jsonEncoding
+	numEncodings
+)
+
+// clone returns a copy of the configuration of s.
+func (s *sink) clone() *sink {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	c := &sink{
+		out:          s.out,
+		enc:          s.enc,
+		format:       s.format,
+		flags:        atomic.LoadInt32(&s.flags),
+		color:        s.color,
+		outColor:     s.outColor,
+		addedColor:   s.addedColor,
+		outputs:      append([]*output(nil), s.outputs...),
+		entryHooks:   s.entryHooks,
+		hooksCalling: s.hooksCalling,
+		// the limit is kept by the children
+		maxEntrySize: s.maxEntrySize,
+		// the children writing to their own outputs keep the order of the queue
+		async: s.async,
+	}
+	if box, ok := s.sampler.Load().(samplerBox); ok {
+		c.sampler.Store(box)
+	}
+	c.storeLayout()
+	return c
+}
+
+// reportCaller reports whether the call site is written with the entries.
+func (s *sink) reportCaller() bool {
+	return atomic.LoadInt32(&s.flags)&(Lshortfile|Llongfile) != 0
+}
+
+// hasOutput reports whether the entries are written to an output.
+func (s *sink) hasOutput() bool {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	return s.out != nil || len(s.outputs) > 0
+}
+
+// updateColor updates whether the levels are colored after the output or the color mode
+// changed, it must be called with mtx locked. The added outputs are colored only if they
+// are terminals, even if ColorAlways is set.
+func (s *sink) updateColor() {
+	s.outColor = colorEnabled(s.color, s.out)
+	s.addedColor = s.color != ColorNever && !noColor()
+}
+
+// encodingOf returns the encoding of the entries written to an output in format.
+func encodingOf(format Format, color bool) int {
+	switch {
+	case format == JSONFormat:
+		return jsonEncoding
+	case color:
+		return colorEncoding
+	}
+	return textEncoding
+}
+
+// write encodes the entry and writes it to the output and to the added outputs. A failed
+// write doesn't prevent the others, the errors are returned.
+func (s *sink) write(e *Entry) []error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	atomic.AddInt64(&s.entries, 1)
+	if atomic.LoadInt32(&sortedFields) != 0 {
+		e.Fields = sortFields(e.Fields)
+	}
+	if e.Level >= TRACE && e.Level <= FATAL {
+		atomic.AddInt64(&s.levels[e.Level], 1)
+	}
+	s.enc.flags = int(atomic.LoadInt32(&s.flags))
+	// the entry is encoded once by encoding
+	var encoded [numEncodings]bool
+	encode := func(i int) []byte {
+		if !encoded[i] {
+			encoded[i] = true
+			s.bufs[i] = s.encodeEntry(s.bufs[i][:0], e, i)
+		}
+		return s.bufs[i]
+	}
+	primary := encodingOf(s.format, s.outColor)
+	if s.maxEntrySize > 0 && len(encode(primary)) > s.maxEntrySize {
+		if t, ok := s.truncate(e, s.maxEntrySize, primary); ok {
+			e, encoded = &t, [numEncodings]bool{}
+		}
+	}
+	var errs []error
+	if s.out != nil {
+		var err error
+		if _, ok := s.out.(EntryWriter); ok {
+			err = s.writeEntryTo(s.out, e, false)
+		} else {
+			err = s.writeTo(s.out, encode(primary), false)
+		}
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	for _, o := range s.outputs {
+		if !o.accept(e) {
+			continue
+		}
+		var err error
+		_, entries := o.w.(EntryWriter)
+		i := encodingOf(o.format, o.tty && s.addedColor)
+		if entries {
+			// the size of the entries is the size of their text
+			i = textEncoding
+		}
+		data, entry := []byte(nil), e
+		if o.maxSize > 0 && len(encode(i)) > o.maxSize {
+			if t, ok := s.truncate(e, o.maxSize, i); ok {
+				entry = &t
+				s.scratch = s.encodeEntry(s.scratch[:0], entry, i)
+				data = s.scratch
+			}
+		}
+		switch {
+		case entries:
+			err = s.writeEntryTo(o.w, entry, true)
+		case data != nil:
+			err = s.writeTo(o.w, data, true)
+		default:
+			err = s.writeTo(o.w, encode(i), true)
+		}
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		atomic.AddInt64(&s.writeErrors, int64(len(errs)))
+	}
+	return errs
+}
+
+// writeTo writes the encoded entry data to w, or queues it if the sink is asynchronous.
+// It must be called with mtx locked.
+func (s *sink) writeTo(w io.Writer, data []byte, added bool) error {
+	// the buffers of the sink are reused by the next entry
+	if s.async != nil && s.async.push(asyncWrite{sink: s, w: w, data: append([]byte(nil), data...), added: added}) {
+		return nil
+	}
+	_, err := w.Write(data)
+	return writeError(err, w, added)
+}
+
+// writeEntryTo writes the entry to w implementing EntryWriter, or queues a copy of it if
+// the sink is asynchronous. It must be called with mtx locked.
+func (s *sink) writeEntryTo(w io.Writer, e *Entry, added bool) error {
+	if s.async != nil {
+		entry := *e
+		if s.async.push(asyncWrite{sink: s, w: w, entry: &entry, added: added}) {
+			return nil
+		}
+	}
+	return writeError(w.(EntryWriter).WriteEntry(*e), w, added)
+}
+
+// writeError returns the error of a failed write of an entry to w.
+func writeError(err error, w io.Writer, added bool) error {
+	if err == nil {
+		return nil
+	}
+	if added {
+		return errors.Wrapf(err, "failed to write log entry to output %T", w)
+	}
+	return errors.Wrap(err, "failed to write log entry")
+}
+
+// Logger writes log entries with levels to an output. The package-level functions use
+// the default logger, see DefaultLogger.
+//
+// The children created by With and Named inherit the level and the output of their
+// parent, including the changes made later on the parent, until they set their own.
+// A Logger is safe for concurrent use.
+type Logger struct {
+	parent *Logger
+	// name is the dotted name of the logger, see Named.
+	name string
+	// level is the level of the logger or noLevel, it is shared by the named loggers
+	// with the same name.
+	level *int32
+	// fields are the fields of the logger, the capacity is the length so that appending
+	// the fields of an entry copies them.
+	fields []Field
+	// callerSkip is the number of extra frames skipped to find the call site, see
+	// WithCallerSkip.
+	callerSkip int
+
+	hookMtx sync.Mutex
+	// levelHooks are the functions registered by OnLevelChange.
+	levelHooks []func(old, new Level)
+
+	sinkMtx sync.Mutex
+	// sink holds the *sink set on the logger itself.
+	sink atomic.Value
+}
+
+// Option configures a Logger created by New.
+type Option func(l *Logger)
+
+// WithLevel sets the level of the logger, default is WARN.
+func WithLevel(lv Level) Option {
+	return func(l *Logger) {
+		atomic.StoreInt32(l.level, int32(lv))
+	}
+}
+
+// WithOutput sets the output of the logger, default is os.Stdout.
+func WithOutput(w io.Writer) Option {
+	return func(l *Logger) {
+		s := l.ownSink()
+		s.out = w
+		s.updateColor()
+	}
+}
+
+// WithPrefix sets the prefix of the logger.
+func WithPrefix(prefix string) Option {
+	return func(l *Logger) {
+		l.ownSink().enc.prefix = prefix
+	}
+}
+
+// WithFlags sets the flags of the logger, default is LstdFlags | Lshortfile | Lmicroseconds.
+func WithFlags(flags int) Option {
+	return func(l *Logger) {
+		atomic.StoreInt32(&l.ownSink().flags, int32(flags))
+	}
+}
+
+// WithFormat sets the format of the logger, default is TextFormat.
+func WithFormat(format Format) Option {
+	return func(l *Logger) {
+		l.ownSink().format = format
+	}
+}
+
+// WithColor sets whether the levels of TextFormat are colored, default is ColorAuto.
+func WithColor(mode ColorMode) Option {
+	return func(l *Logger) {
+		s := l.ownSink()
+		s.color = mode
+		s.updateColor()
+	}
+}
+
+// WithTimeFormat sets the layout of the time of the entries, see Logger.SetTimeFormat.
+func WithTimeFormat(layout string) Option {
+	return func(l *Logger) {
+		l.ownSink().enc.timeFormat = layout
+	}
+}
+
+// WithSampler sets the sampler of the logger, see Logger.SetSampler.
+func WithSampler(sampler Sampler) Option {
+	return func(l *Logger) {
+		l.ownSink().sampler.Store(samplerBox{sampler})
+	}
+}
+
+// WithFields sets the fields written with every entry of the logger, see Logger.With.
+func WithFields(kv ...any) Option {
+	return func(l *Logger) {
+		fields := appendFields(l.fields, kv)
+		l.fields = fields[:len(fields):len(fields)]
+	}
+}
+
+// New returns a new logger configured by opts, independent of the default logger.
+func New(opts ...Option) *Logger {
+	level := int32(defaultLevel)
+	l := &Logger{level: &level}
+	s := &sink{out: os.Stdout, enc: encoding{prefix: defaultPrefix}, flags: int32(defaultFlags)}
+	s.updateColor()
+	l.sink.Store(s)
+	for _, opt := range opts {
+		if opt != nil {
+			opt(l)
+		}
+	}
+	// the options set the sink without the lock
+	s.storeLayout()
+	return l
+}
+
+// child returns a logger inheriting from l.
+func (l *Logger) child() *Logger {
+	level := int32(noLevel)
+	return &Logger{parent: l, name: l.name, level: &level, fields: l.fields, callerSkip: l.callerSkip}
+}
+
+// WithCallerSkip returns a child logger skipping n more frames to find the call site,
+// for the helpers wrapping the logger, e.g.
+//
+//	var logger = log.Named("db").WithCallerSkip(1)
+//
+//	func logQuery(query string) {
+//		// the call site of logQuery is reported
+//		logger.Infow("query", "sql", query)
+//	}
+func (l *Logger) WithCallerSkip(n int) *Logger {
+	c := l.child()
+	c.callerSkip += n
+	return c
+}
+
+// With returns a child logger writing the key-value pairs kv with every entry, e.g.
+//
+//	logger := log.With("request_id", id)
+//	logger.Info("accepted")
+//
+// The pairs are read like log/slog: a value without a string key gets the key "!BADKEY".
+func (l *Logger) With(kv ...any) *Logger {
+	c := l.child()
+	if len(kv) > 0 {
+		fields := append(make([]Field, 0, len(l.fields)+(len(kv)+1)/2), l.fields...)
+		fields = appendFields(fields, kv)
+		c.fields = fields[:len(fields):len(fields)]
+	}
+	return c
+}
+
+// namedLevels holds the *int32 levels of the named loggers by name.
+var namedLevels sync.Map
+
+// namedLevel returns the level shared by the loggers named name.
+func namedLevel(name string) *int32 {
+	if v, ok := namedLevels.Load(name); ok {
+		return v.(*int32)
+	}
+	level := int32(noLevel)
+	v, _ := namedLevels.LoadOrStore(name, &level)
+	return v.(*int32)
+}
+
+// Named returns a child logger for the subsystem name, its entries are prefixed with
+// the name, which is appended to the name of l with a dot, e.g. "rotate.cleanup".
+// Its level can be set by SetLevelFor, otherwise it is the level of l.
+func (l *Logger) Named(name string) *Logger {
+	c := l.child()
+	if l.name != "" {
+		name = l.name + "." + name
+	}
+	c.name = name
+	c.level = namedLevel(name)
+	return c
+}
+
+// Name returns the name of the logger, see Named.
+func (l *Logger) Name() string {
+	return l.name
+}
+
+// SetLevelFor sets the level of the loggers named name, see Logger.Named.
+func SetLevelFor(name string, lv any) {
+	atomic.StoreInt32(namedLevel(name), int32(ToLevel(lv)))
+}
+
+// Level returns the level of the logger. The level check of an entry is an atomic
+// load for a logger setting its level, the message isn't formatted if it fails.
+func (l *Logger) Level() Level {
+	for ; l != nil; l = l.parent {
+		if lv := atomic.LoadInt32(l.level); lv != noLevel {
+			return Level(lv)
+		}
+	}
+	return defaultLevel
+}
+
+// SetLevel sets the level of logs below which logs will not be output. The hooks
+// registered by OnLevelChange are called if the level changes.
+func (l *Logger) SetLevel(lv Level) {
+	prev := atomic.SwapInt32(l.level, int32(lv))
+	old := Level(prev)
+	if prev == noLevel {
+		old = l.parent.Level()
+	}
+	if old == lv {
+		return
+	}
+	l.hookMtx.Lock()
+	hooks := l.levelHooks
+	l.hookMtx.Unlock()
+	for _, hook := range hooks {
+		hook(old, lv)
+	}
+}
+
+// OnLevelChange registers fn to be called with the old and the new level when the
+// level of l is changed by SetLevel, e.g. so that a subsystem enables its costly
+// diagnostics at DEBUG. The levels set by SetLevelFor and on the parents of l don't
+// call it.
+func (l *Logger) OnLevelChange(fn func(old, new Level)) {
+	if fn == nil {
+		return
+	}
+	l.hookMtx.Lock()
+	defer l.hookMtx.Unlock()
+	// the slice is copied so that SetLevel calls the hooks without the lock
+	l.levelHooks = append(l.levelHooks[:len(l.levelHooks):len(l.levelHooks)], fn)
+}
+
+// Enabled reports whether the entries of level lv are written.
+func (l *Logger) Enabled(lv Level) bool {
+	return lv >= l.Level()
+}
+
+// loadSink returns the sink of the logger or of its nearest ancestor setting one.
+func (l *Logger) loadSink() *sink {
+	for ; l != nil; l = l.parent {
+		if s, ok := l.sink.Load().(*sink); ok {
+			return s
+		}
+	}
+	return nil
+}
+
+// ownSink returns the sink set on the logger itself, a copy of the inherited one is set
+// on the first call.
+func (l *Logger) ownSink() *sink {
+	l.sinkMtx.Lock()
+	defer l.sinkMtx.Unlock()
+	if s, ok := l.sink.Load().(*sink); ok {
+		return s
+	}
+	s := l.parent.loadSink().clone()
+	l.sink.Store(s)
+	return s
+}
+
+// SetOutput sets the output destination for the logger, it may implement EntryWriter.
+func (l *Logger) SetOutput(w io.Writer) {
+	s := l.ownSink()
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	s.out = w
+	s.updateColor()
+	s.storeLayout()
+}
+
+// SetPrefix sets the output prefix for the logger.
+func (l *Logger) SetPrefix(prefix string) {
+	s := l.ownSink()
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	s.enc.prefix = prefix
+	s.storeLayout()
+}
+
+// SetFlags sets the output flags for the logger.
+// The flag bits are Ldate, Ltime, and so on.
+func (l *Logger) SetFlags(flags int) {
+	atomic.StoreInt32(&l.ownSink().flags, int32(flags))
+}
+
+// SetColor sets whether the levels of TextFormat are colored:
+//   - ColorAuto colors them if the output is a terminal and NO_COLOR isn't set
+//   - ColorAlways colors them
+//   - ColorNever doesn't color them
+//
+// The outputs added by AddOutput are colored only if they are terminals, whatever mode.
+func (l *Logger) SetColor(mode ColorMode) {
+	s := l.ownSink()
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	s.color = mode
+	s.updateColor()
+	s.storeLayout()
+}
+
+// SetTimeFormat sets the layout of the time of the entries, see time.Layout, e.g.
+// time.RFC3339. The time is written if layout isn't empty, whatever the flags. An empty
+// layout restores the time selected by the flags, and RFC3339Nano for JSONFormat.
+func (l *Logger) SetTimeFormat(layout string) {
+	s := l.ownSink()
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	s.enc.timeFormat = layout
+	s.storeLayout()
+}
+
+// SetOmitTime sets whether the time is dropped from the entries whatever the flags and
+// the layout, e.g. for journald adding its own.
+func (l *Logger) SetOmitTime(omit bool) {
+	s := l.ownSink()
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	s.enc.omitTime = omit
+	s.storeLayout()
+}
+
+// SetSampler sets the sampler of the entries below ERROR, e.g.
+//
+//	logger.SetSampler(log.SampleFirstN(10, time.Second))
+//
+// The entries of ERROR and above are sampled only by a sampler wrapped by
+// SampleAllLevels. A nil sampler writes all the entries.
+func (l *Logger) SetSampler(sampler Sampler) {
+	l.ownSink().sampler.Store(samplerBox{sampler})
+}
+
+// SetReportCaller sets whether the short file name and the line of the call site are
+// written with the entries, like the Lshortfile flag, disabling it clears Llongfile too.
+// Finding the call site costs a runtime.Caller for every entry written, see
+// BenchmarkReportCaller.
+func (l *Logger) SetReportCaller(report bool) {
+	flags := &l.ownSink().flags
+	for {
+		old := atomic.LoadInt32(flags)
+		updated := old &^ (Lshortfile | Llongfile)
+		if report {
+			updated |= Lshortfile
+		}
+		if atomic.CompareAndSwapInt32(flags, old, updated) {
+			return
+		}
+	}
+}
+
+// SetFormat sets the format of the entries, see Format.
+func (l *Logger) SetFormat(format Format) {
+	s := l.ownSink()
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	s.format = format
+	s.storeLayout()
+}
+
+// callerDepth is the number of frames between output and the call site: output, logf
+// and the exported function. The call site is found only for the entries passing the
+// level check.
+const callerDepth = 3
+
+// logf writes an entry of level lv with the message formatted by format or fmt.Sprint,
+// and the fields kv. It must be called directly by the exported functions so that the
+// call site is found at callerDepth.
+func (l *Logger) logf(lv Level, format *string, args []any, kv []any) {
+	if !l.Enabled(lv) {
+		return
+	}
+	var msg string
+	if format != nil {
+		msg = fmt.Sprintf(*format, args...)
+	} else {
+		msg = fmt.Sprint(args...)
+	}
+	l.output(lv, msg, kv)
+	if lv == FATAL {
+		// Exit may be replaced by one not running the errors.OnExit hooks
+		l.Flush()
+		Exit(1)
+	}
+}
+
+// logFn writes an entry of level lv with the message and the fields returned by fn, fn
+// is called only if the entry passes the level check. It must be called directly by the
+// exported functions so that the call site is found at callerDepth.
+func (l *Logger) logFn(lv Level, fn func() (string, []any)) {
+	if !l.Enabled(lv) || fn == nil {
+		return
+	}
+	msg, kv := fn()
+	l.output(lv, msg, kv)
+}
+
+// output writes an entry of level lv with the message msg and the fields kv.
+func (l *Logger) output(lv Level, msg string, kv []any) {
+	s := l.loadSink()
+	e := Entry{Time: timeNow(), Level: lv, Name: l.name, Message: msg, Fields: l.fields}
+	if len(kv) > 0 {
+		// the capacity of l.fields is its length, so appending copies them
+		e.Fields = appendFields(e.Fields, kv)
+	}
+	// the call site is found first for the matchers of Suppress
+	if s.reportCaller() {
+		var ok bool
+		if _, e.File, e.Line, ok = runtime.Caller(callerDepth + l.callerSkip); !ok {
+			e.File, e.Line = "???", 0
+		}
+	}
+	if !l.sampled(s, &e) {
+		return
+	}
+	l.write(&e)
+}
+
+// sampled reports whether the entry is written, it isn't if it's dropped by Suppress or
+// by the sampler of the sink s.
+func (l *Logger) sampled(s *sink, e *Entry) bool {
+	if muted(e) {
+		atomic.AddInt64(&s.muted, 1)
+		return false
+	}
+	box, ok := s.sampler.Load().(samplerBox)
+	if !ok || box.Sampler == nil || l.sample(box.Sampler, e) {
+		return true
+	}
+	atomic.AddInt64(&s.suppressed, 1)
+	return false
+}
+
+// write writes the entry to the sink of the logger.
+func (l *Logger) write(e *Entry) {
+	// a failed write, e.g. to a rotating file on a full disk, is reported as a warning
+	// after the sink is unlocked, so that a warning handler may log
+	s := l.loadSink()
+	atomic.AddInt32(&s.inWrite, 1)
+	errs := s.write(e)
+	atomic.AddInt32(&s.inWrite, -1)
+	for _, err := range errs {
+		errors.Warningt(warningTag, err)
+	}
+	s.callEntryHooks(e.Level)
+}
+
+// Trace writes an entry of level TRACE with the message of fmt.Sprint(args...).
+func (l *Logger) Trace(args ...any) {
+	l.logf(TRACE, nil, args, nil)
+}
+
+// Debug writes an entry of level DEBUG with the message of fmt.Sprint(args...).
+func (l *Logger) Debug(args ...any) {
+	l.logf(DEBUG, nil, args, nil)
+}
+
+// Info writes an entry of level INFO with the message of fmt.Sprint(args...).
+func (l *Logger) Info(args ...any) {
+	l.logf(INFO, nil, args, nil)
+}
+
+// Warn writes an entry of level WARN with the message of fmt.Sprint(args...).
+func (l *Logger) Warn(args ...any) {
+	l.logf(WARN, nil, args, nil)
+}
+
+// Error writes an entry of level ERROR with the message of fmt.Sprint(args...).
+func (l *Logger) Error(args ...any) {
+	l.logf(ERROR, nil, args, nil)
+}
+
+// Fatal writes an entry of level FATAL with the message of fmt.Sprint(args...), then
+// exits the program with code 1.
+func (l *Logger) Fatal(args ...any) {
+	l.logf(FATAL, nil, args, nil)
+}
+
+// Tracef writes an entry of level TRACE with the message formatted by fmt.Sprintf.
+func (l *Logger) Tracef(format string, args ...any) {
+	l.logf(TRACE, &format, args, nil)
+}
+
+// Debugf writes an entry of level DEBUG with the message formatted by fmt.Sprintf.
+func (l *Logger) Debugf(format string, args ...any) {
+	l.logf(DEBUG, &format, args, nil)
+}
+
+// Infof writes an entry of level INFO with the message formatted by fmt.Sprintf.
+func (l *Logger) Infof(format string, args ...any) {
+	l.logf(INFO, &format, args, nil)
+}
+
+// Warnf writes an entry of level WARN with the message formatted by fmt.Sprintf.
+func (l *Logger) Warnf(format string, args ...any) {
+	l.logf(WARN, &format, args, nil)
+}
+
+// Errorf writes an entry of level ERROR with the message formatted by fmt.Sprintf.
+func (l *Logger) Errorf(format string, args ...any) {
+	l.logf(ERROR, &format, args, nil)
+}
+
+// Fatalf writes an entry of level FATAL with the message formatted by fmt.Sprintf, then
+// exits the program with code 1.
+func (l *Logger) Fatalf(format string, args ...any) {
+	l.logf(FATAL, &format, args, nil)
+}
+
+// TraceFn writes an entry of level TRACE with the message and the key-value pairs
+// returned by fn, which is called only if TRACE is enabled, e.g.
+//
+//	logger.TraceFn(func() (string, []any) {
+//		return "state", []any{"dump", dumpState()}
+//	})
+func (l *Logger) TraceFn(fn func() (msg string, kv []any)) {
+	l.logFn(TRACE, fn)
+}
+
+// DebugFn writes an entry of level DEBUG with the message and the key-value pairs
+// returned by fn, which is called only if DEBUG is enabled, see TraceFn.
+func (l *Logger) DebugFn(fn func() (msg string, kv []any)) {
+	l.logFn(DEBUG, fn)
+}
+
+// Tracew writes an entry of level TRACE with the message msg and the key-value pairs kv.
+func (l *Logger) Tracew(msg string, kv ...any) {
+	l.logf(TRACE, nil, []any{msg}, kv)
+}
+
+// Debugw writes an entry of level DEBUG with the message msg and the key-value pairs kv.
+func (l *Logger) Debugw(msg string, kv ...any) {
+	l.logf(DEBUG, nil, []any{msg}, kv)
+}
+
+// Infow writes an entry of level INFO with the message msg and the key-value pairs kv.
+func (l *Logger) Infow(msg string, kv ...any) {
+	l.logf(INFO, nil, []any{msg}, kv)
+}
+
+// Warnw writes an entry of level WARN with the message msg and the key-value pairs kv.
+func (l *Logger) Warnw(msg string, kv ...any) {
+	l.logf(WARN, nil, []any{msg}, kv)
+}
+
+// Errorw writes an entry of level ERROR with the message msg and the key-value pairs kv.
+func (l *Logger) Errorw(msg string, kv ...any) {
+	l.logf(ERROR, nil, []any{msg}, kv)
+}
+
+// Fatalw writes an entry of level FATAL with the message msg and the key-value pairs kv,
+// then exits the program with code 1.
+func (l *Logger) Fatalw(msg string, kv ...any) {
+	l.logf(FATAL, nil, []any{msg}, kv)
+}
@@ -0,0 +1,385 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	cases := []struct {
+		name string
+		opts []Option
+		log  func(l *Logger)
+		want string
+	}{
+		{
+			"default level",
+			nil,
+			func(l *Logger) {
+				l.Info("hidden")
+				l.Warn("shown")
+			},
+			"[WARN ] shown\n",
+		},
+		{
+			"level",
+			[]Option{WithLevel(DEBUG)},
+			func(l *Logger) {
+				l.Trace("hidden")
+				l.Debugf("shown %d", 1)
+			},
+			"[DEBUG] shown 1\n",
+		},
+		{
+			"prefix",
+			[]Option{WithPrefix("app ")},
+			func(l *Logger) { l.Error("failed") },
+			"app [ERROR] failed\n",
+		},
+		{
+			"message prefix",
+			[]Option{WithPrefix("app "), WithFlags(Lmsgprefix)},
+			func(l *Logger) { l.Error("failed") },
+			"app [ERROR] failed\n",
+		},
+		{
+			"fields",
+			[]Option{WithFields("service", "api")},
+			func(l *Logger) { l.Warnw("slow request", "elapsed", 1.5) },
+			"[WARN ] slow request service=api elapsed=1.5\n",
+		},
+		{
+			"json",
+			[]Option{WithFormat(JSONFormat), WithFields("service", "api")},
+			func(l *Logger) { l.Errorw("failed", "code", 500) },
+			`{"level":"ERROR","msg":"failed","service":"api","code":500}` + "\n",
+		},
+		{
+			"nil option",
+			[]Option{nil},
+			func(l *Logger) { l.Warn("shown") },
+			"[WARN ] shown\n",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			opts := append([]Option{WithOutput(&buf), WithFlags(0)}, c.opts...)
+			c.log(New(opts...))
+			require.Equal(t, c.want, buf.String())
+		})
+	}
+}
+
+// lastLine returns the line before the line of its call.
+func lastLine() int {
+	_, _, line, _ := runtime.Caller(1)
+	return line - 1
+}
+
+// warnHelper is a helper wrapping a logger, see Logger.WithCallerSkip.
+func warnHelper(l *Logger, msg string) {
+	l.Warn(msg)
+}
+
+func TestReportCaller(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(WithOutput(&buf), WithFlags(0))
+	defer SetLogger(logger)
+	SetLogger(l)
+
+	cases := []struct {
+		name string
+		// log logs an entry and returns the line of the expected call site
+		log func() int
+	}{
+		{"method", func() int {
+			l.Warn("msg")
+			return lastLine()
+		}},
+		{"formatted", func() int {
+			l.Warnf("%s", "msg")
+			return lastLine()
+		}},
+		{"fields", func() int {
+			l.Warnw("msg", "k", "v")
+			return lastLine()
+		}},
+		{"child", func() int {
+			l.Named("sub").With("k", "v").Warn("msg")
+			return lastLine()
+		}},
+		{"package function", func() int {
+			Warn("msg")
+			return lastLine()
+		}},
+		{"package function with fields", func() int {
+			Warnw("msg", "k", "v")
+			return lastLine()
+		}},
+		{"helper", func() int {
+			warnHelper(l.WithCallerSkip(1), "msg")
+			return lastLine()
+		}},
+	}
+	l.SetReportCaller(true)
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			buf.Reset()
+			line := c.log()
+			want := fmt.Sprintf("logger_test.go:%d: ", line)
+			require.True(t, strings.HasPrefix(buf.String(), want), buf.String())
+		})
+	}
+
+	t.Run("json", func(t *testing.T) {
+		buf.Reset()
+		l.SetFormat(JSONFormat)
+		defer l.SetFormat(TextFormat)
+		l.Warn("msg")
+		line := lastLine()
+		require.Contains(t, buf.String(), fmt.Sprintf(`"caller":"logger_test.go:%d"`, line))
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		buf.Reset()
+		l.SetFlags(Llongfile)
+		l.SetReportCaller(false)
+		l.Warn("msg")
+		require.Equal(t, "[WARN ] msg\n", buf.String())
+	})
+
+	t.Run("package", func(t *testing.T) {
+		buf.Reset()
+		SetReportCaller(true)
+		Error("msg")
+		line := lastLine()
+		require.Equal(t, fmt.Sprintf("logger_test.go:%d: [ERROR] msg\n", line), buf.String())
+	})
+}
+
+// countingWriter counts the writes of the entries.
+type countingWriter struct {
+	n int
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	w.n++
+	return len(p), nil
+}
+
+func BenchmarkReportCaller(b *testing.B) {
+	for _, report := range []bool{false, true} {
+		b.Run(fmt.Sprintf("report=%t", report), func(b *testing.B) {
+			l := New(WithOutput(&countingWriter{}), WithFlags(LstdFlags))
+			l.SetReportCaller(report)
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				l.Warnw("benchmark", "i", i)
+			}
+		})
+	}
+}
+
+func TestLoggerWith(t *testing.T) {
+	var buf bytes.Buffer
+	parent := New(WithOutput(&buf), WithFlags(0))
+	child := parent.With("request_id", 7)
+	grandchild := child.With("user", "bob")
+
+	grandchild.Warn("denied")
+	require.Equal(t, "[WARN ] denied request_id=7 user=bob\n", buf.String())
+
+	// the fields of the parent aren't changed by the children
+	buf.Reset()
+	child.Warnw("done", "status", "ok")
+	parent.Warn("idle")
+	require.Equal(t, "[WARN ] done request_id=7 status=ok\n[WARN ] idle\n", buf.String())
+
+	// the children follow the level and the output of the parent
+	var other bytes.Buffer
+	parent.SetLevel(ERROR)
+	parent.SetOutput(&other)
+	grandchild.Warn("hidden")
+	grandchild.Error("shown")
+	require.Equal(t, "[ERROR] shown request_id=7 user=bob\n", other.String())
+
+	// until they set their own
+	var own bytes.Buffer
+	child.SetOutput(&own)
+	child.SetLevel(DEBUG)
+	grandchild.Info("own")
+	parent.Info("hidden")
+	require.Equal(t, "[INFO ] own request_id=7 user=bob\n", own.String())
+	require.Equal(t, DEBUG, grandchild.Level())
+	require.Equal(t, ERROR, parent.Level())
+}
+
+func TestLoggerNamed(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(WithOutput(&buf), WithFlags(0))
+	rotate := l.Named("rotate")
+	cleanup := rotate.Named("cleanup")
+	require.Equal(t, "rotate", rotate.Name())
+	require.Equal(t, "rotate.cleanup", cleanup.Name())
+
+	cleanup.Warn("removed")
+	require.Equal(t, "[WARN ] rotate.cleanup: removed\n", buf.String())
+
+	// the level is shared by the loggers with the same name
+	t.Cleanup(func() {
+		SetLevelFor("test.rotate", noLevel)
+	})
+	a := New(WithOutput(&buf), WithFlags(0)).Named("test.rotate")
+	b := New(WithOutput(&buf), WithFlags(0)).Named("test").Named("rotate")
+	require.Equal(t, WARN, a.Level())
+	SetLevelFor("test.rotate", "info")
+	require.Equal(t, INFO, a.Level())
+	require.Equal(t, INFO, b.Level())
+	require.True(t, b.Enabled(INFO))
+	require.False(t, b.Enabled(DEBUG))
+
+	buf.Reset()
+	b.Info("shown")
+	b.Debug("hidden")
+	require.Equal(t, "[INFO ] test.rotate: shown\n", buf.String())
+}
+
+// countingStringer counts the calls of String.
+type countingStringer struct {
+	n int32
+}
+
+func (s *countingStringer) String() string {
+	atomic.AddInt32(&s.n, 1)
+	return "formatted"
+}
+
+func TestLevelShortCircuit(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(WithOutput(&buf), WithFlags(0), WithLevel(WARN))
+	child := l.Named("sub").With("k", "v")
+	s := &countingStringer{}
+
+	l.Info(s)
+	l.Debugf("%s", s)
+	child.Infow("msg", "s", s)
+	child.Trace(s)
+	require.Equal(t, int32(0), atomic.LoadInt32(&s.n))
+	require.Empty(t, buf.String())
+
+	l.Warn(s)
+	child.Errorw("msg", "s", s)
+	require.Equal(t, int32(2), atomic.LoadInt32(&s.n))
+	require.Equal(t, "[WARN ] formatted\n[ERROR] sub: msg k=v s=formatted\n", buf.String())
+}
+
+func TestOnLevelChange(t *testing.T) {
+	type change struct {
+		old, new Level
+	}
+	l := New(WithOutput(&bytes.Buffer{}))
+	child := l.With("k", "v")
+	var changes, childChanges []change
+	l.OnLevelChange(nil)
+	l.OnLevelChange(func(old, new Level) { changes = append(changes, change{old, new}) })
+	child.OnLevelChange(func(old, new Level) { childChanges = append(childChanges, change{old, new}) })
+
+	l.SetLevel(DEBUG)
+	// the level doesn't change
+	l.SetLevel(DEBUG)
+	l.SetLevel(ERROR)
+	require.Equal(t, []change{{WARN, DEBUG}, {DEBUG, ERROR}}, changes)
+	// the changes of the parent don't call the hooks of the child
+	require.Empty(t, childChanges)
+
+	// the old level of the child is the inherited one
+	child.SetLevel(TRACE)
+	require.Equal(t, []change{{ERROR, TRACE}}, childChanges)
+
+	defer SetLogger(logger)
+	SetLogger(New(WithOutput(&bytes.Buffer{})))
+	var pkgChanges []change
+	OnLevelChange(func(old, new Level) { pkgChanges = append(pkgChanges, change{old, new}) })
+	SetLevel("info")
+	require.Equal(t, []change{{WARN, INFO}}, pkgChanges)
+}
+
+func TestLoggerFields(t *testing.T) {
+	cases := []struct {
+		name string
+		kv   []any
+		want string
+	}{
+		{"none", nil, "[WARN ] msg\n"},
+		{"pairs", []any{"a", 1, "b", true}, "[WARN ] msg a=1 b=true\n"},
+		{"odd", []any{"a", 1, "b"}, "[WARN ] msg a=1 !BADKEY=b\n"},
+		{"non string key", []any{1, "a"}, "[WARN ] msg !BADKEY=1 !BADKEY=a\n"},
+		{"field", []any{Field{Key: "a", Value: 1}, "b", 2}, "[WARN ] msg a=1 b=2\n"},
+		{"quoted", []any{"path", "a b", "empty", ""}, `[WARN ] msg path="a b" empty=""` + "\n"},
+		{"error", []any{"err", fmt.Errorf("not found")}, `[WARN ] msg err="not found"` + "\n"},
+		{"nil", []any{"v", nil}, "[WARN ] msg v=<nil>\n"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			New(WithOutput(&buf), WithFlags(0)).Warnw("msg", c.kv...)
+			require.Equal(t, c.want, buf.String())
+		})
+	}
+}
+
+func TestLoggerConcurrent(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(WithOutput(&buf), WithFlags(0), WithFormat(JSONFormat))
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			child := l.Named("worker").With("id", i)
+			for j := 0; j < 100; j++ {
+				child.Warnw("tick", "n", j)
+				if j%10 == 0 {
+					l.SetFlags(0)
+					l.SetLevel(WARN)
+					SetLevelFor("worker", WARN)
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	require.Len(t, lines, 800)
+	for _, line := range lines {
+		var entry map[string]any
+		require.NoError(t, json.Unmarshal([]byte(line), &entry), line)
+		require.Equal(t, "worker", entry["logger"])
+	}
+	SetLevelFor("worker", noLevel)
+}
+
+func TestPackageLogger(t *testing.T) {
+	var buf bytes.Buffer
+	defer SetLogger(logger)
+	SetLogger(New(WithOutput(&buf), WithFlags(0), WithLevel(TRACE)))
+
+	Infow("started", "port", 8080)
+	With("id", 1).Debug("accepted")
+	Named("http").Trace("read")
+	require.Equal(t, "[INFO ] started port=8080\n[DEBUG] accepted id=1\n[TRACE] http: read\n", buf.String())
+
+	buf.Reset()
+	SetFormat(JSONFormat)
+	Fatalw("stopped", "code", 1)
+	require.Equal(t, `{"level":"FATAL","msg":"stopped","code":1}`+"\n", buf.String())
+}
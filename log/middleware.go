@@ -0,0 +1,100 @@
+package log
+
+import (
+	"bufio"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/stkali/utility/errors"
+)
+
+// responseWriter records the status and the size of a response written by the handler
+// of HTTPMiddleware.
+type responseWriter struct {
+	http.ResponseWriter
+	status   int
+	bytes    int64
+	hijacked bool
+}
+
+// WriteHeader records the status of the response.
+func (w *responseWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+// Write records the size of the response.
+func (w *responseWriter) Write(p []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(p)
+	w.bytes += int64(n)
+	return n, err
+}
+
+// Flush implements http.Flusher if the wrapped writer does.
+func (w *responseWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		if w.status == 0 {
+			w.status = http.StatusOK
+		}
+		f.Flush()
+	}
+}
+
+// Hijack implements http.Hijacker, it fails if the wrapped writer doesn't.
+func (w *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.Newf("%T doesn't support hijacking", w.ResponseWriter)
+	}
+	conn, rw, err := h.Hijack()
+	if err == nil {
+		w.hijacked = true
+	}
+	return conn, rw, err
+}
+
+// Unwrap returns the wrapped writer, for http.ResponseController.
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// HTTPMiddleware returns a handler serving the requests with next and writing an entry
+// of level INFO per request with its method, path, status, the size of the response
+// body in bytes and the duration of next:
+//
+//	[INFO ] request method=GET path=/users status=200 bytes=1534 duration=1.2ms
+//
+// A panic of next is written as an entry of level ERROR with its stack trace, see
+// RecoverAndLog, and answered with 500 Internal Server Error if no response was written.
+// http.ErrAbortHandler isn't recovered. The status of a hijacked connection is 0.
+func (l *Logger) HTTPMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rw := &responseWriter{ResponseWriter: w}
+		start := time.Now()
+		defer func() {
+			if p := recover(); p != nil {
+				if p == http.ErrAbortHandler {
+					panic(p)
+				}
+				l.logPanic(p, "panic serving request", []any{"method", r.Method, "path", r.URL.Path})
+				if rw.status == 0 && !rw.hijacked {
+					http.Error(rw, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+				}
+			}
+			status := rw.status
+			if status == 0 && !rw.hijacked {
+				// nothing was written, net/http responds 200
+				status = http.StatusOK
+			}
+			l.Infow("request", "method", r.Method, "path", r.URL.Path, "status", status,
+				"bytes", rw.bytes, "duration", time.Since(start))
+		}()
+		next.ServeHTTP(rw, r)
+	})
+}
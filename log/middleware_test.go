@@ -0,0 +1,142 @@
+package log
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTPMiddleware(t *testing.T) {
+	cases := []struct {
+		name    string
+		handler http.HandlerFunc
+		status  int
+		body    string
+		want    string
+	}{
+		{
+			"ok", func(w http.ResponseWriter, r *http.Request) {
+				_, _ = io.WriteString(w, "hello")
+			},
+			http.StatusOK, "hello", `[INFO ] request method=GET path=/users status=200 bytes=5 duration=`,
+		},
+		{
+			"empty", func(w http.ResponseWriter, r *http.Request) {},
+			http.StatusOK, "", `[INFO ] request method=GET path=/users status=200 bytes=0 duration=`,
+		},
+		{
+			"status", func(w http.ResponseWriter, r *http.Request) {
+				http.NotFound(w, r)
+			},
+			http.StatusNotFound, "404 page not found\n", `[INFO ] request method=GET path=/users status=404 bytes=19 duration=`,
+		},
+		{
+			"panic", func(w http.ResponseWriter, r *http.Request) {
+				panic("boom")
+			},
+			http.StatusInternalServerError, "Internal Server Error\n",
+			`[ERROR] panic serving request error="panic: boom" error.stack=`,
+		},
+		{
+			"panic after write", func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusAccepted)
+				panic("boom")
+			},
+			http.StatusAccepted, "", `[INFO ] request method=GET path=/users status=202 bytes=0 duration=`,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var buf syncBuffer
+			l := New(WithOutput(&buf), WithFlags(0), WithLevel(INFO))
+			srv := httptest.NewServer(l.HTTPMiddleware(c.handler))
+			defer srv.Close()
+
+			resp, err := srv.Client().Get(srv.URL + "/users")
+			require.NoError(t, err)
+			body, err := io.ReadAll(resp.Body)
+			require.NoError(t, err)
+			require.NoError(t, resp.Body.Close())
+			require.Equal(t, c.status, resp.StatusCode)
+			require.Equal(t, c.body, string(body))
+			srv.Close()
+			require.Contains(t, buf.String(), c.want)
+			require.Regexp(t, regexp.MustCompile(`request method=GET path=/users status=\d+ bytes=\d+ duration=\S+\n$`), buf.String())
+		})
+	}
+}
+
+func TestHTTPMiddlewarePanicEntry(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(WithOutput(&buf), WithFlags(Lshortfile))
+	var line int
+	handler := l.HTTPMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		line = lastLine() + 2
+		panic(fmt.Errorf("failed"))
+	}))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/jobs", nil))
+	require.Equal(t, http.StatusInternalServerError, rec.Code)
+	entry := strings.SplitAfter(buf.String(), "\n")[0]
+	require.Contains(t, entry, fmt.Sprintf("middleware_test.go:%d: [ERROR] panic serving request error=\"failed <- panic\" error.stack=", line))
+	require.Contains(t, entry, " method=POST path=/jobs\n")
+	// the level is below WARN, the request isn't written
+	require.Equal(t, 1, strings.Count(buf.String(), "\n"))
+
+	require.PanicsWithValue(t, http.ErrAbortHandler, func() {
+		l.HTTPMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			panic(http.ErrAbortHandler)
+		})).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	})
+}
+
+func TestHTTPMiddlewareWriter(t *testing.T) {
+	var buf syncBuffer
+	l := New(WithOutput(&buf), WithFlags(0), WithLevel(INFO))
+	srv := httptest.NewServer(l.HTTPMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/flush" {
+			_, _ = io.WriteString(w, "chunk")
+			w.(http.Flusher).Flush()
+			return
+		}
+		conn, rw, err := w.(http.Hijacker).Hijack()
+		require.NoError(t, err)
+		defer conn.Close()
+		_, _ = rw.WriteString("HTTP/1.1 200 OK\r\nContent-Length: 8\r\nConnection: close\r\n\r\nhijacked")
+		_ = rw.Flush()
+	})))
+	defer srv.Close()
+
+	for _, path := range []string{"/flush", "/hijack"} {
+		resp, err := srv.Client().Get(srv.URL + path)
+		require.NoError(t, err)
+		_, _ = io.Copy(io.Discard, resp.Body)
+		require.NoError(t, resp.Body.Close())
+	}
+	srv.Close()
+	require.Contains(t, buf.String(), "path=/flush status=200 bytes=5 ")
+	require.Contains(t, buf.String(), "path=/hijack status=0 bytes=0 ")
+
+	// the writers not supporting hijacking
+	rw := &responseWriter{ResponseWriter: httptest.NewRecorder()}
+	_, _, err := rw.Hijack()
+	require.Error(t, err)
+	rw.Flush()
+	require.Equal(t, http.StatusOK, rw.status)
+}
+
+func TestPackageHTTPMiddleware(t *testing.T) {
+	var buf bytes.Buffer
+	defer SetLogger(logger)
+	SetLogger(New(WithOutput(&buf), WithFlags(0), WithLevel(INFO)))
+	rec := httptest.NewRecorder()
+	HTTPMiddleware(http.NotFoundHandler()).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/missing", nil))
+	require.Contains(t, buf.String(), "[INFO ] request method=GET path=/missing status=404 ")
+}
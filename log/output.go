@@ -0,0 +1,153 @@
+package log
+
+import (
+	"io"
+	"sync/atomic"
+)
+
+// output is an output added to a logger by AddOutput.
+type output struct {
+	w        io.Writer
+	minLevel Level
+	format   Format
+	filter   func(e *Entry) bool
+	// maxSize is the limit of WithMaxEntrySize, 0 if the size isn't limited
+	maxSize int
+	// tty is whether w is a terminal, see SetColor.
+	tty bool
+}
+
+// accept reports whether the entry is written to the output.
+func (o *output) accept(e *Entry) bool {
+	return e.Level >= o.minLevel && (o.filter == nil || o.filter(e))
+}
+
+// EntryWriter is implemented by the outputs writing the entries themselves instead of
+// their encoding, e.g. to map the level to the severity of syslog, see NewSyslogOutput.
+// WriteEntry is called instead of Write for the entries of a logger, with the logger
+// locked unless it is asynchronous, and it must not log. The format and the color of the
+// output are ignored.
+type EntryWriter interface {
+	WriteEntry(e Entry) error
+}
+
+// OutputOption configures an output added by AddOutput.
+type OutputOption func(o *output)
+
+// WithMinLevel sets the level below which the entries aren't written to the output,
+// in addition to the level of the logger. Default is TRACE, the output receives all the
+// entries of the logger.
+func WithMinLevel(lv Level) OutputOption {
+	return func(o *output) {
+		o.minLevel = lv
+	}
+}
+
+// WithOutputFormat sets the format of the output, default is TextFormat.
+func WithOutputFormat(format Format) OutputOption {
+	return func(o *output) {
+		o.format = format
+	}
+}
+
+// WithFilter sets a filter of the entries written to the output, an entry is written if
+// filter returns true. filter is called with the logger locked, it must not log.
+func WithFilter(filter func(e *Entry) bool) OutputOption {
+	return func(o *output) {
+		o.filter = filter
+	}
+}
+
+// WithMaxEntrySize limits the size of the entries written to the output to bytes, in
+// addition to the limit of the logger, see SetMaxEntrySize. The size of the entries
+// written to an EntryWriter is the size of their TextFormat, e.g. a syslog datagram is a
+// bit longer.
+func WithMaxEntrySize(bytes int) OutputOption {
+	return func(o *output) {
+		o.maxSize = bytes
+	}
+}
+
+// AddOutput adds the output w receiving the entries of the logger too, e.g. the INFO
+// entries to a rotating file as JSON and the WARN ones to stderr:
+//
+//	logger := log.New(log.WithLevel(log.INFO), log.WithOutput(file), log.WithFormat(log.JSONFormat))
+//	logger.AddOutput(os.Stderr, log.WithMinLevel(log.WARN))
+//
+// The output uses the prefix and the flags of the logger. SetOutput still replaces the
+// primary output only. w may implement EntryWriter to receive the entries themselves.
+// A failed write to an output doesn't prevent the others from
+// receiving the entry, it's reported as a warning and counted, see Stats.
+func (l *Logger) AddOutput(w io.Writer, opts ...OutputOption) {
+	if w == nil {
+		return
+	}
+	o := &output{w: w, minLevel: TRACE, tty: isTerminal(w)}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(o)
+		}
+	}
+	s := l.ownSink()
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	s.outputs = append(s.outputs, o)
+}
+
+// RemoveOutput removes the output w added by AddOutput and reports whether it was added.
+// w is compared with ==, so it must be comparable like a pointer. It's safe to call
+// while other goroutines are logging, the entry being written when it returns is the
+// last one written to w.
+func (l *Logger) RemoveOutput(w io.Writer) bool {
+	s := l.ownSink()
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	for i, o := range s.outputs {
+		if o.w == w {
+			// the slice is copied so that the clones of the sink keep their outputs
+			outputs := make([]*output, 0, len(s.outputs)-1)
+			outputs = append(outputs, s.outputs[:i]...)
+			s.outputs = append(outputs, s.outputs[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// OutputStats are the counters of the entries written by a logger, see Logger.Stats.
+type OutputStats struct {
+	// Entries is the number of entries passing the level check and the sampler.
+	Entries int64
+	// Levels are the numbers of entries by level, e.g. Levels[ERROR].
+	Levels [FATAL + 1]int64
+	// WriteErrors is the number of failed writes to the outputs.
+	WriteErrors int64
+	// Dropped is the number of writes dropped because the queue of SetAsync was full.
+	Dropped int64
+	// Suppressed is the number of entries suppressed by the sampler, see SetSampler.
+	Suppressed int64
+	// Muted is the number of entries dropped by the matchers of Suppress.
+	Muted int64
+	// Truncated is the number of entries truncated to their maximum size, see
+	// SetMaxEntrySize, counted per output with WithMaxEntrySize.
+	Truncated int64
+}
+
+// Stats returns the counters of the entries written to the output of the logger, they
+// are shared by the loggers writing to the same output, e.g. the children created by
+// With and Named that don't set their own.
+func (l *Logger) Stats() OutputStats {
+	s := l.loadSink()
+	stats := OutputStats{
+		Entries:     atomic.LoadInt64(&s.entries),
+		WriteErrors: atomic.LoadInt64(&s.writeErrors),
+		Dropped:     atomic.LoadInt64(&s.dropped),
+		Suppressed:  atomic.LoadInt64(&s.suppressed),
+		Muted:       atomic.LoadInt64(&s.muted),
+		Truncated:   atomic.LoadInt64(&s.truncated),
+	}
+	for lv := range stats.Levels {
+		stats.Levels[lv] = atomic.LoadInt64(&s.levels[lv])
+	}
+	return stats
+}
@@ -0,0 +1,168 @@
+package log
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stkali/utility/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAddOutput(t *testing.T) {
+	cases := []struct {
+		name string
+		opts []OutputOption
+		want string
+	}{
+		{
+			"default",
+			nil,
+			"[INFO ] started port=80\n[WARN ] slow port=80\n",
+		},
+		{
+			"min level",
+			[]OutputOption{WithMinLevel(WARN)},
+			"[WARN ] slow port=80\n",
+		},
+		{
+			"format",
+			[]OutputOption{WithOutputFormat(JSONFormat), nil},
+			`{"level":"INFO","msg":"started","port":80}` + "\n" + `{"level":"WARN","msg":"slow","port":80}` + "\n",
+		},
+		{
+			"filter",
+			[]OutputOption{WithFilter(func(e *Entry) bool { return strings.HasPrefix(e.Message, "s") && e.Level == WARN })},
+			"[WARN ] slow port=80\n",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var primary, added bytes.Buffer
+			l := New(WithOutput(&primary), WithFlags(0), WithLevel(INFO), WithFields("port", 80))
+			l.AddOutput(&added, c.opts...)
+			l.AddOutput(nil)
+			l.Debug("hidden")
+			l.Info("started")
+			l.Warn("slow")
+			require.Equal(t, "[INFO ] started port=80\n[WARN ] slow port=80\n", primary.String())
+			require.Equal(t, c.want, added.String())
+		})
+	}
+}
+
+func TestOutputInherited(t *testing.T) {
+	var primary, added bytes.Buffer
+	l := New(WithOutput(&primary), WithFlags(0))
+	child := l.Named("sub")
+	l.AddOutput(&added)
+	child.Warn("both")
+	require.Equal(t, "[WARN ] sub: both\n", added.String())
+
+	// the outputs of the child are its own once it sets its output
+	var other bytes.Buffer
+	child.SetOutput(&other)
+	require.True(t, child.RemoveOutput(&added))
+	child.Warn("child")
+	l.Warn("parent")
+	require.Equal(t, "[WARN ] sub: both\n[WARN ] parent\n", added.String())
+	require.Equal(t, "[WARN ] sub: child\n", other.String())
+}
+
+func TestOutputWriteError(t *testing.T) {
+	var primary, added bytes.Buffer
+	l := New(WithOutput(failedWriter{}), WithFlags(0))
+	l.AddOutput(failedWriter{})
+	l.AddOutput(&added)
+	warnings := errors.CaptureWarnings(func() {
+		l.Warn("kept")
+	})
+	require.Equal(t, []string{
+		"log: failed to write log entry, err: file already closed",
+		"log: failed to write log entry to output log.failedWriter, err: file already closed",
+	}, warnings)
+	// the failed writes don't prevent the other outputs
+	require.Equal(t, "[WARN ] kept\n", added.String())
+	require.Equal(t, OutputStats{Entries: 1, Levels: [FATAL + 1]int64{WARN: 1}, WriteErrors: 2}, l.Stats())
+
+	l.SetOutput(&primary)
+	require.True(t, l.RemoveOutput(failedWriter{}))
+	require.False(t, l.RemoveOutput(failedWriter{}))
+	require.Empty(t, errors.CaptureWarnings(func() {
+		l.Info("hidden")
+		l.Warn("written")
+	}))
+	require.Equal(t, OutputStats{Entries: 2, Levels: [FATAL + 1]int64{WARN: 2}, WriteErrors: 2}, l.Stats())
+	require.Equal(t, "[WARN ] written\n", primary.String())
+}
+
+// syncBuffer is a bytes.Buffer safe for concurrent use.
+type syncBuffer struct {
+	mtx sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	return b.buf.String()
+}
+
+func TestRemoveOutputConcurrent(t *testing.T) {
+	var primary syncBuffer
+	l := New(WithOutput(&primary), WithFlags(0))
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 200; j++ {
+				l.Warnw("tick", "n", j)
+			}
+		}()
+	}
+	var removed int64
+	var mtx sync.Mutex
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				added := &syncBuffer{}
+				l.AddOutput(added, WithOutputFormat(JSONFormat))
+				ok := l.RemoveOutput(added)
+				n := len(added.String())
+				// nothing is written to a removed output
+				l.Warn("after")
+				mtx.Lock()
+				if ok && n == len(added.String()) {
+					removed++
+				}
+				mtx.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	require.Equal(t, int64(200), removed)
+	require.Equal(t, int64(1000), l.Stats().Entries)
+	require.Equal(t, 1000, strings.Count(primary.String(), "\n"))
+}
+
+func TestPackageOutput(t *testing.T) {
+	var primary, added bytes.Buffer
+	defer SetLogger(logger)
+	SetLogger(New(WithOutput(&primary), WithFlags(0)))
+	AddOutput(&added, WithMinLevel(ERROR))
+	Warn("warn")
+	Error("error")
+	require.Equal(t, "[ERROR] error\n", added.String())
+	require.True(t, RemoveOutput(&added))
+	require.Equal(t, OutputStats{Entries: 2, Levels: [FATAL + 1]int64{WARN: 1, ERROR: 1}}, Stats())
+}
@@ -0,0 +1,72 @@
+package log
+
+import (
+	"sync/atomic"
+
+	"github.com/stkali/utility/errors"
+)
+
+// repanic is set by SetRepanic, it is accessed atomically.
+var repanic int32
+
+// SetRepanic sets whether RecoverAndLog panics again with the recovered value after
+// logging it, e.g. so that a crashing worker still stops the program. Default is false,
+// the panic is stopped.
+func SetRepanic(panics bool) {
+	v := int32(0)
+	if panics {
+		v = 1
+	}
+	atomic.StoreInt32(&repanic, v)
+}
+
+// logPanic writes an entry of level ERROR for the recovered value r with the message
+// msg, the fields of the *errors.PanicError of r, stack included, and the fields kv. It
+// must be called directly by the deferred function that recovered r, the call site is
+// where the panic happened.
+func (l *Logger) logPanic(r any, msg string, kv []any) {
+	if !l.Enabled(ERROR) {
+		return
+	}
+	err := errors.PanicErrorOf(r, 1)
+	e := Entry{Time: timeNow(), Level: ERROR, Name: l.name, Message: msg}
+	e.Fields = appendErrorFields(append(make([]Field, 0, len(l.fields)+4+len(kv)), l.fields...), err, true)
+	e.Fields = appendFields(e.Fields, kv)
+	s := l.loadSink()
+	if s.reportCaller() {
+		e.File, e.Line = "???", 0
+		if frames := errors.StackTrace(err); len(frames) > 0 {
+			e.File, e.Line = frames[0].File, frames[0].Line
+		}
+	}
+	if !l.sampled(s, &e) {
+		return
+	}
+	l.write(&e)
+}
+
+// afterPanic panics again with r if SetRepanic is set, after the entries are written.
+func (l *Logger) afterPanic(r any) {
+	if atomic.LoadInt32(&repanic) != 0 {
+		l.Flush()
+		panic(r)
+	}
+}
+
+// RecoverAndLog stops a panic and writes it as an entry of level ERROR with the message
+// msg, the fields of its *errors.PanicError like Err, its stack trace included, and the
+// call site of the panic. It must be deferred directly, e.g. by a goroutine that mustn't
+// crash the program:
+//
+//	go func() {
+//		defer logger.RecoverAndLog("worker crashed")
+//		...
+//	}()
+//
+// The panic goes on after it is written if SetRepanic is set.
+func (l *Logger) RecoverAndLog(msg string) {
+	if r := recover(); r != nil {
+		l.logPanic(r, msg, nil)
+		l.afterPanic(r)
+	}
+}
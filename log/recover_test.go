@@ -0,0 +1,72 @@
+package log
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecoverAndLog(t *testing.T) {
+	cases := []struct {
+		name  string
+		level Level
+		value any
+		want  string
+	}{
+		{"value", INFO, "boom", `: [ERROR] worker crashed error="panic: boom" error.stack=`},
+		{"error", INFO, os.ErrNotExist, `: [ERROR] worker crashed error="file does not exist <- panic" error.code=NotFound error.stack=`},
+		{"filtered", FATAL, "boom", ""},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			l := New(WithOutput(&buf), WithFlags(Lshortfile), WithLevel(c.level))
+			var line int
+			require.NotPanics(t, func() {
+				defer l.RecoverAndLog("worker crashed")
+				line = lastLine() + 2
+				panic(c.value)
+			})
+			if c.want == "" {
+				require.Empty(t, buf.String())
+				return
+			}
+			// the call site is where the panic happened
+			require.Contains(t, buf.String(), fmt.Sprintf("recover_test.go:%d%s", line, c.want))
+			require.Contains(t, buf.String(), "log.TestRecoverAndLog")
+		})
+	}
+
+	var buf bytes.Buffer
+	l := New(WithOutput(&buf))
+	func() {
+		defer l.RecoverAndLog("worker crashed")
+	}()
+	require.Empty(t, buf.String())
+}
+
+func TestRepanic(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(WithOutput(&buf), WithFlags(0))
+	SetRepanic(true)
+	defer SetRepanic(false)
+	require.PanicsWithValue(t, "boom", func() {
+		defer l.RecoverAndLog("worker crashed")
+		panic("boom")
+	})
+	require.Contains(t, buf.String(), `[ERROR] worker crashed error="panic: boom"`)
+}
+
+func TestPackageRecoverAndLog(t *testing.T) {
+	var buf bytes.Buffer
+	defer SetLogger(logger)
+	SetLogger(New(WithOutput(&buf), WithFlags(0)))
+	require.NotPanics(t, func() {
+		defer RecoverAndLog("crashed")
+		panic("boom")
+	})
+	require.Contains(t, buf.String(), `[ERROR] crashed error="panic: boom"`)
+}
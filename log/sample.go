@@ -0,0 +1,152 @@
+package log
+
+import (
+	"math"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+// Sampler decides which entries are written, e.g. to limit the entries of a tight error
+// loop, see SetSampler. It must be safe for concurrent use.
+type Sampler interface {
+	// Sample reports whether the entry e is written, and the number of the entries like e
+	// suppressed since the last one written, which are summarized before e.
+	Sample(e *Entry) (write bool, suppressed int64)
+}
+
+// sampleSlots is the number of counters of a sampler, the entries are spread over them
+// by the hash of their key so that sampling takes no lock.
+const sampleSlots = 4096
+
+// sampleKey returns the slot of the counter of the entry, by the FNV-1a hash of its
+// level and message. Different keys may share a slot.
+func sampleKey(e *Entry) uint32 {
+	const prime = 16777619
+	h := uint32(2166136261)
+	h = (h ^ uint32(e.Level)) * prime
+	for i := 0; i < len(e.Message); i++ {
+		h = (h ^ uint32(e.Message[i])) * prime
+	}
+	return h % sampleSlots
+}
+
+// windowCounter counts the entries of a key within a window.
+type windowCounter struct {
+	// resetAt is the end of the window in nanoseconds
+	resetAt    int64
+	count      int64
+	suppressed int64
+}
+
+// firstNSampler writes the first n entries of a key per window.
+type firstNSampler struct {
+	n        int64
+	per      int64
+	counters [sampleSlots]windowCounter
+}
+
+// SampleFirstN returns a Sampler writing the first n entries with the same level and
+// message within each period per, then suppressing them until the period rolls. The
+// number of the suppressed entries is written before the first entry of the next period
+// like:
+//
+//	[WARN ] suppressed 42 similar messages message="connection refused"
+func SampleFirstN(n int, per time.Duration) Sampler {
+	if n < 1 {
+		n = 1
+	}
+	return &firstNSampler{n: int64(n), per: int64(per)}
+}
+
+// EverySecond returns a Sampler writing the first entry with the same level and message
+// each second.
+func EverySecond() Sampler {
+	return SampleFirstN(1, time.Second)
+}
+
+// Sample implements Sampler.
+func (s *firstNSampler) Sample(e *Entry) (bool, int64) {
+	c := &s.counters[sampleKey(e)]
+	now := timeNow().UnixNano()
+	resetAt := atomic.LoadInt64(&c.resetAt)
+	var n, suppressed int64
+	if resetAt > now || !atomic.CompareAndSwapInt64(&c.resetAt, resetAt, now+s.per) {
+		// in the window, or another entry started the next one
+		n = atomic.AddInt64(&c.count, 1)
+	} else {
+		atomic.StoreInt64(&c.count, 1)
+		n = 1
+		suppressed = atomic.SwapInt64(&c.suppressed, 0)
+	}
+	if n > s.n {
+		atomic.AddInt64(&c.suppressed, 1)
+		return false, 0
+	}
+	return true, suppressed
+}
+
+// rateSampler writes one entry of a key out of every.
+type rateSampler struct {
+	every    int64
+	counters [sampleSlots]int64
+}
+
+// SampleRate returns a Sampler writing a fraction rate of the entries with the same level
+// and message, e.g. SampleRate(1.0/100) writes the first entry and then one out of 100.
+// A rate >= 1 writes all the entries, a rate <= 0 none. The suppressed entries aren't
+// summarized.
+func SampleRate(rate float64) Sampler {
+	every := int64(math.MaxInt64)
+	if rate >= 1 {
+		every = 1
+	} else if rate > 0 {
+		every = int64(math.Round(1 / rate))
+	}
+	return &rateSampler{every: every}
+}
+
+// Sample implements Sampler.
+func (s *rateSampler) Sample(e *Entry) (bool, int64) {
+	if s.every == math.MaxInt64 {
+		return false, 0
+	}
+	n := atomic.AddInt64(&s.counters[sampleKey(e)], 1)
+	return (n-1)%s.every == 0, 0
+}
+
+// allLevelsSampler is a Sampler applied to the entries of all the levels.
+type allLevelsSampler struct {
+	Sampler
+}
+
+// SampleAllLevels returns a Sampler applying s to the entries of all the levels. The
+// entries of ERROR and above aren't sampled otherwise.
+func SampleAllLevels(s Sampler) Sampler {
+	return allLevelsSampler{s}
+}
+
+// samplerBox holds the sampler of a sink in an atomic.Value.
+type samplerBox struct {
+	Sampler
+}
+
+// sample reports whether the entry is written with the sampler s, and writes the summary
+// of the suppressed entries before it.
+func (l *Logger) sample(s Sampler, e *Entry) bool {
+	if _, all := s.(allLevelsSampler); !all && e.Level >= ERROR {
+		return true
+	}
+	write, suppressed := s.Sample(e)
+	if suppressed > 0 {
+		summary := Entry{
+			Time:    e.Time,
+			Level:   e.Level,
+			Name:    e.Name,
+			Message: "suppressed " + strconv.FormatInt(suppressed, 10) + " similar messages",
+			Fields:  []Field{{Key: "message", Value: e.Message}},
+		}
+		l.write(&summary)
+	}
+	return write
+}
@@ -0,0 +1,205 @@
+package log
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// fakeClock is the clock of the samplers in the tests.
+type fakeClock struct {
+	now int64
+}
+
+func (c *fakeClock) Now() time.Time {
+	return time.Unix(0, atomic.LoadInt64(&c.now))
+}
+
+func (c *fakeClock) Add(d time.Duration) {
+	atomic.AddInt64(&c.now, int64(d))
+}
+
+// newFakeClock returns a fake clock.
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2024, 9, 19, 0, 0, 0, 0, time.UTC).UnixNano()}
+}
+
+// setFakeClock sets the clock of the entries and the samplers to a fake one for the test.
+func setFakeClock(t *testing.T) *fakeClock {
+	clock := newFakeClock()
+	SetTimeFunc(clock.Now)
+	t.Cleanup(func() { SetTimeFunc(nil) })
+	return clock
+}
+
+// setSocketClock sets the clock of the socket outputs to a fake one for the test.
+func setSocketClock(t *testing.T) *fakeClock {
+	clock := newFakeClock()
+	socketNow = clock.Now
+	t.Cleanup(func() { socketNow = time.Now })
+	return clock
+}
+
+func TestSampleFirstN(t *testing.T) {
+	clock := setFakeClock(t)
+	s := SampleFirstN(2, time.Second)
+	warn := &Entry{Level: WARN, Message: "refused"}
+	info := &Entry{Level: INFO, Message: "refused"}
+
+	cases := []struct {
+		name       string
+		advance    time.Duration
+		entry      *Entry
+		write      bool
+		suppressed int64
+	}{
+		{"first", 0, warn, true, 0},
+		{"second", 100 * time.Millisecond, warn, true, 0},
+		{"third", 100 * time.Millisecond, warn, false, 0},
+		{"fourth", 100 * time.Millisecond, warn, false, 0},
+		{"other level", 0, info, true, 0},
+		{"next window", time.Second, warn, true, 2},
+		{"next window second", 0, warn, true, 0},
+		{"next window third", 0, warn, false, 0},
+		{"quiet window", 5 * time.Second, warn, true, 1},
+	}
+	for _, c := range cases {
+		clock.Add(c.advance)
+		write, suppressed := s.Sample(c.entry)
+		require.Equal(t, c.write, write, c.name)
+		require.Equal(t, c.suppressed, suppressed, c.name)
+	}
+
+	// at least one entry is written
+	s = SampleFirstN(0, time.Second)
+	write, _ := s.Sample(warn)
+	require.True(t, write)
+	write, _ = s.Sample(warn)
+	require.False(t, write)
+}
+
+func TestSampleRate(t *testing.T) {
+	cases := []struct {
+		name    string
+		rate    float64
+		written int
+	}{
+		{"one percent", 1.0 / 100, 3},
+		{"half", 0.5, 125},
+		{"all", 1, 250},
+		{"more than all", 2, 250},
+		{"none", 0, 0},
+		{"negative", -1, 0},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			s := SampleRate(c.rate)
+			written := 0
+			for i := 0; i < 250; i++ {
+				if write, suppressed := s.Sample(&Entry{Level: WARN, Message: "msg"}); write {
+					require.Zero(t, suppressed)
+					written++
+				}
+			}
+			require.Equal(t, c.written, written)
+		})
+	}
+}
+
+func TestSetSampler(t *testing.T) {
+	clock := setFakeClock(t)
+	var buf bytes.Buffer
+	l := New(WithOutput(&buf), WithFlags(0), WithSampler(EverySecond()))
+	for i := 0; i < 5; i++ {
+		l.Warn("refused")
+		l.Warnw("refused", "n", i)
+		// the entries of ERROR and above aren't sampled
+		l.Error("failed")
+	}
+	clock.Add(time.Second)
+	l.Named("child").Warn("refused")
+	require.Equal(t, "[WARN ] refused\n"+
+		"[ERROR] failed\n"+
+		"[ERROR] failed\n"+
+		"[ERROR] failed\n"+
+		"[ERROR] failed\n"+
+		"[ERROR] failed\n"+
+		"[WARN ] child: suppressed 9 similar messages message=refused\n"+
+		"[WARN ] child: refused\n", buf.String())
+
+	buf.Reset()
+	l.SetSampler(SampleAllLevels(EverySecond()))
+	l.Error("failed")
+	l.Error("failed")
+	require.Equal(t, "[ERROR] failed\n", buf.String())
+
+	buf.Reset()
+	l.SetSampler(nil)
+	l.Warn("refused")
+	l.Warn("refused")
+	require.Equal(t, "[WARN ] refused\n[WARN ] refused\n", buf.String())
+
+	buf.Reset()
+	defer SetLogger(logger)
+	SetLogger(New(WithOutput(&buf), WithFlags(0)))
+	SetSampler(SampleRate(0))
+	Warn("hidden")
+	require.Empty(t, buf.String())
+}
+
+// countSampler is a Sampler writing the entries whose message has no "debug".
+type countSampler struct {
+	calls int32
+}
+
+func (s *countSampler) Sample(e *Entry) (bool, int64) {
+	atomic.AddInt32(&s.calls, 1)
+	return !strings.Contains(e.Message, "debug"), 0
+}
+
+func TestCustomSampler(t *testing.T) {
+	var buf bytes.Buffer
+	s := &countSampler{}
+	l := New(WithOutput(&buf), WithFlags(0), WithSampler(s))
+	l.Warn("debug output")
+	l.Warn("shown")
+	// the sampler isn't called for the entries below the level
+	l.Info("hidden")
+	require.Equal(t, int32(2), s.calls)
+	require.Equal(t, "[WARN ] shown\n", buf.String())
+}
+
+func TestSamplerConcurrent(t *testing.T) {
+	setFakeClock(t)
+	var written int64
+	s := SampleFirstN(100, time.Minute)
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				if write, _ := s.Sample(&Entry{Level: WARN, Message: "loop"}); write {
+					atomic.AddInt64(&written, 1)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	require.Equal(t, int64(100), written)
+}
+
+func BenchmarkSampler(b *testing.B) {
+	l := New(WithOutput(&countingWriter{}), WithFlags(0), WithSampler(SampleFirstN(10, time.Second)))
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			l.Warn("tight loop")
+		}
+	})
+}
@@ -0,0 +1,140 @@
+//go:build go1.21
+
+package log
+
+import (
+	"context"
+	"log/slog"
+	"runtime"
+)
+
+// slogHandler is the slog.Handler writing the records to a Logger.
+type slogHandler struct {
+	l *Logger
+	// group is the dotted prefix of the keys of the attributes, see WithGroup.
+	group string
+}
+
+// SlogHandler returns a slog.Handler writing the records to the default logger, with its
+// level, outputs, formats and sampler, see Logger.SlogHandler.
+func SlogHandler() slog.Handler {
+	return logger.SlogHandler()
+}
+
+// AsSlog returns a slog.Logger writing to the default logger, e.g. for a library
+// logging through log/slog.
+func AsSlog() *slog.Logger {
+	return logger.AsSlog()
+}
+
+// SlogHandler returns a slog.Handler writing the records to l. The slog levels are mapped
+// to the nearest level below them: slog.LevelDebug to DEBUG, slog.LevelInfo to INFO, and
+// so on, the levels below slog.LevelDebug to TRACE and the ones above slog.LevelError to
+// ERROR, a record never exits like FATAL. The attributes are written as fields, the keys
+// of the groups are joined with dots, e.g. "request.id".
+func (l *Logger) SlogHandler() slog.Handler {
+	return &slogHandler{l: l}
+}
+
+// AsSlog returns a slog.Logger writing to l, see SlogHandler.
+func (l *Logger) AsSlog() *slog.Logger {
+	return slog.New(l.SlogHandler())
+}
+
+// fromSlogLevel returns the level of the slog level lv.
+func fromSlogLevel(lv slog.Level) Level {
+	switch {
+	case lv < slog.LevelDebug:
+		return TRACE
+	case lv < slog.LevelInfo:
+		return DEBUG
+	case lv < slog.LevelWarn:
+		return INFO
+	case lv < slog.LevelError:
+		return WARN
+	}
+	return ERROR
+}
+
+// Enabled implements slog.Handler.
+func (h *slogHandler) Enabled(_ context.Context, lv slog.Level) bool {
+	return h.l.Enabled(fromSlogLevel(lv))
+}
+
+// Handle implements slog.Handler.
+func (h *slogHandler) Handle(_ context.Context, r slog.Record) error {
+	lv := fromSlogLevel(r.Level)
+	if !h.l.Enabled(lv) {
+		return nil
+	}
+	e := Entry{Time: r.Time, Level: lv, Name: h.l.name, Message: r.Message, Fields: h.l.fields}
+	if e.Time.IsZero() {
+		e.Time = timeNow()
+	}
+	if r.NumAttrs() > 0 {
+		// the capacity of the fields of the logger is their length, so appending copies them
+		r.Attrs(func(a slog.Attr) bool {
+			e.Fields = appendAttr(e.Fields, h.group, a)
+			return true
+		})
+	}
+	s := h.l.loadSink()
+	if r.PC != 0 && s.reportCaller() {
+		frame, _ := runtime.CallersFrames([]uintptr{r.PC}).Next()
+		e.File, e.Line = frame.File, frame.Line
+	}
+	if !h.l.sampled(s, &e) {
+		return nil
+	}
+	// a failed write is reported as a warning like the entries of the logger
+	h.l.write(&e)
+	return nil
+}
+
+// WithAttrs implements slog.Handler.
+func (h *slogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	var fields []Field
+	for _, a := range attrs {
+		fields = appendAttr(fields, h.group, a)
+	}
+	kv := make([]any, len(fields))
+	for i, f := range fields {
+		kv[i] = f
+	}
+	return &slogHandler{l: h.l.With(kv...), group: h.group}
+}
+
+// WithGroup implements slog.Handler.
+func (h *slogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return &slogHandler{l: h.l, group: h.group + name + "."}
+}
+
+// appendAttr appends the attribute a as fields, with the keys prefixed by group. The
+// groups are flattened, and the empty attributes are ignored like slog.
+func appendAttr(fields []Field, group string, a slog.Attr) []Field {
+	v := a.Value.Resolve()
+	if v.Kind() == slog.KindGroup {
+		attrs := v.Group()
+		if len(attrs) == 0 {
+			return fields
+		}
+		// a group with an empty key is inlined
+		if a.Key != "" {
+			group += a.Key + "."
+		}
+		for _, ga := range attrs {
+			fields = appendAttr(fields, group, ga)
+		}
+		return fields
+	}
+	if a.Key == "" && v.Any() == nil {
+		return fields
+	}
+	return append(fields, Field{Key: group + a.Key, Value: v.Any()})
+}
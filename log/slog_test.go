@@ -0,0 +1,133 @@
+//go:build go1.21
+
+package log
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFromSlogLevel(t *testing.T) {
+	cases := []struct {
+		name  string
+		level slog.Level
+		want  Level
+	}{
+		{"below debug", slog.LevelDebug - 4, TRACE},
+		{"debug", slog.LevelDebug, DEBUG},
+		{"between debug and info", slog.LevelDebug + 2, DEBUG},
+		{"info", slog.LevelInfo, INFO},
+		{"warn", slog.LevelWarn, WARN},
+		{"warn+1", slog.LevelWarn + 1, WARN},
+		{"error", slog.LevelError, ERROR},
+		{"above error", slog.LevelError + 100, ERROR},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			require.Equal(t, c.want, fromSlogLevel(c.level))
+		})
+	}
+}
+
+func TestSlogRoundTrip(t *testing.T) {
+	cases := []struct {
+		name   string
+		native func(l *Logger)
+		slog   func(l *Logger)
+	}{
+		{
+			"attrs",
+			func(l *Logger) { l.Warnw("msg", "a", 1, "b", "x y", "ok", true, "d", time.Second) },
+			func(l *Logger) { l.AsSlog().Warn("msg", "a", 1, "b", "x y", "ok", true, "d", time.Second) },
+		},
+		{
+			"group",
+			func(l *Logger) { l.Errorw("msg", "req.id", 7, "req.user.name", "bob") },
+			func(l *Logger) {
+				l.AsSlog().Error("msg", slog.Group("req", "id", 7, slog.Group("user", "name", "bob")))
+			},
+		},
+		{
+			"empty group and inlined group",
+			func(l *Logger) { l.Warnw("msg", "a", 1) },
+			func(l *Logger) { l.AsSlog().Warn("msg", slog.Group("empty"), slog.Group("", "a", 1), slog.Attr{}) },
+		},
+		{
+			"with attrs and group",
+			func(l *Logger) { l.With("service", "api").Warnw("msg", "http.status", 500, "http.path", "/") },
+			func(l *Logger) {
+				l.AsSlog().With("service", "api").WithGroup("http").WithGroup("").With("status", 500).Warn("msg", "path", "/")
+			},
+		},
+		{
+			"level filtering",
+			func(l *Logger) {
+				l.Debug("hidden")
+				l.Info("hidden")
+				l.Warn("shown")
+			},
+			func(l *Logger) {
+				sl := l.AsSlog()
+				sl.Debug("hidden")
+				sl.Info("hidden")
+				sl.Warn("shown")
+			},
+		},
+		{
+			"named",
+			func(l *Logger) { l.Named("lib").Warn("msg") },
+			func(l *Logger) { l.Named("lib").AsSlog().Warn("msg") },
+		},
+	}
+	for _, format := range []Format{TextFormat, JSONFormat} {
+		for _, c := range cases {
+			t.Run(fmt.Sprintf("%s/%s", format, c.name), func(t *testing.T) {
+				var native, viaSlog bytes.Buffer
+				c.native(New(WithOutput(&native), WithFlags(0), WithFormat(format)))
+				c.slog(New(WithOutput(&viaSlog), WithFlags(0), WithFormat(format)))
+				require.NotEmpty(t, native.String())
+				require.Equal(t, native.String(), viaSlog.String())
+			})
+		}
+	}
+}
+
+func TestSlogHandler(t *testing.T) {
+	var buf bytes.Buffer
+	defer SetLogger(logger)
+	SetLogger(New(WithOutput(&buf), WithFlags(Lshortfile), WithLevel(INFO)))
+
+	h := SlogHandler()
+	require.False(t, h.Enabled(context.Background(), slog.LevelDebug))
+	require.True(t, h.Enabled(context.Background(), slog.LevelInfo))
+	require.Same(t, h, h.WithGroup(""))
+	require.Same(t, h, h.WithAttrs(nil))
+
+	// the call site of the record is written
+	AsSlog().Info("called")
+	line := lastLine()
+	require.Equal(t, fmt.Sprintf("slog_test.go:%d: [INFO ] called\n", line), buf.String())
+
+	// a record without a time or a call site
+	buf.Reset()
+	require.NoError(t, h.Handle(context.Background(), slog.NewRecord(time.Time{}, slog.LevelWarn, "manual", 0)))
+	require.Equal(t, "[WARN ] manual\n", buf.String())
+}
+
+func TestSlogSampler(t *testing.T) {
+	setFakeClock(t)
+	var buf bytes.Buffer
+	l := New(WithOutput(&buf), WithFlags(0), WithSampler(EverySecond()))
+	sl := l.AsSlog()
+	for i := 0; i < 5; i++ {
+		sl.Warn("refused")
+	}
+	require.Equal(t, 1, strings.Count(buf.String(), "\n"))
+}
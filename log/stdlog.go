@@ -0,0 +1,120 @@
+package log
+
+import (
+	"io"
+	stdlog "log"
+	"regexp"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// stdlogTime matches the date and the time written by the log package of the standard
+// library at the beginning of a line.
+var stdlogTime = regexp.MustCompile(`^(\d{4}/\d\d/\d\d )?(\d\d:\d\d:\d\d(\.\d{6})? )?`)
+
+// stdlogWriter is the output of the standard logger set by CaptureStdlog.
+type stdlogWriter struct {
+	level Level
+	mtx   sync.Mutex
+	buf   lineBuffer
+}
+
+// Write writes the complete lines of p, the last line of p is kept until its newline
+// is written.
+func (w *stdlogWriter) Write(p []byte) (int, error) {
+	w.mtx.Lock()
+	lines := w.buf.write(p, 0)
+	w.mtx.Unlock()
+	for _, line := range lines {
+		w.emit(line)
+	}
+	return len(p), nil
+}
+
+// flush writes the partial line.
+func (w *stdlogWriter) flush() {
+	w.mtx.Lock()
+	line := w.buf.flush()
+	w.mtx.Unlock()
+	w.emit(line)
+}
+
+// emit writes the line to the default logger, without the date and the time.
+func (w *stdlogWriter) emit(line string) {
+	line = strings.TrimSuffix(stdlogTime.ReplaceAllString(line, ""), "\r")
+	l := logger
+	if line == "" || !l.Enabled(w.level) {
+		return
+	}
+	e := Entry{Time: timeNow(), Level: w.level, Name: l.name, Message: line}
+	// the capacity of l.fields is its length, so appending copies them
+	e.Fields = append(l.fields, Field{Key: "source", Value: "stdlog"})
+	s := l.loadSink()
+	if s.reportCaller() {
+		e.File, e.Line = stdlogCaller()
+	}
+	if !l.sampled(s, &e) {
+		return
+	}
+	l.write(&e)
+}
+
+// stdlogCaller returns the call site of the log package of the standard library.
+func stdlogCaller() (string, int) {
+	var pcs [32]uintptr
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(3, pcs[:])])
+	inStdlog := false
+	for {
+		frame, more := frames.Next()
+		if strings.HasPrefix(frame.Function, "log.") {
+			inStdlog = true
+		} else if inStdlog {
+			return frame.File, frame.Line
+		}
+		if !more {
+			return "???", 0
+		}
+	}
+}
+
+// stdlogCapture holds the writer set by CaptureStdlog and the output and the flags of
+// the standard logger it replaced.
+var stdlogCapture struct {
+	sync.Mutex
+	w     *stdlogWriter
+	out   io.Writer
+	flags int
+}
+
+// CaptureStdlog redirects the standard logger of the log package of the standard
+// library, used by many third-party packages, to the default logger: every line is
+// written as an entry of level lv with the field source=stdlog, without the date and the
+// time of the standard logger. A multi-line write is written as an entry per line, a
+// partial line when its newline is written. ReleaseStdlog restores the standard logger.
+func CaptureStdlog(lv Level) {
+	stdlogCapture.Lock()
+	defer stdlogCapture.Unlock()
+	if stdlogCapture.w == nil {
+		stdlogCapture.out, stdlogCapture.flags = stdlog.Writer(), stdlog.Flags()
+	} else {
+		stdlogCapture.w.flush()
+	}
+	stdlogCapture.w = &stdlogWriter{level: lv}
+	stdlog.SetOutput(stdlogCapture.w)
+	stdlog.SetFlags(0)
+}
+
+// ReleaseStdlog restores the output and the flags of the standard logger replaced by
+// CaptureStdlog, a partial line is written first.
+func ReleaseStdlog() {
+	stdlogCapture.Lock()
+	defer stdlogCapture.Unlock()
+	if stdlogCapture.w == nil {
+		return
+	}
+	stdlog.SetOutput(stdlogCapture.out)
+	stdlog.SetFlags(stdlogCapture.flags)
+	stdlogCapture.w.flush()
+	stdlogCapture.w, stdlogCapture.out = nil, nil
+}
@@ -0,0 +1,103 @@
+package log
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	stdlog "log"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// captureStdlog captures the standard logger at lv to a logger writing to the returned
+// buffer until the end of the test.
+func captureStdlog(t *testing.T, lv Level, flags int) *bytes.Buffer {
+	var buf bytes.Buffer
+	old := logger
+	SetLogger(New(WithOutput(&buf), WithFlags(flags), WithLevel(DEBUG)))
+	CaptureStdlog(lv)
+	t.Cleanup(func() {
+		ReleaseStdlog()
+		SetLogger(old)
+	})
+	return &buf
+}
+
+func TestCaptureStdlog(t *testing.T) {
+	cases := []struct {
+		name  string
+		level Level
+		log   func()
+		want  string
+	}{
+		{"line", INFO, func() { stdlog.Println("connected") }, "[INFO ] connected source=stdlog\n"},
+		{"level", ERROR, func() { stdlog.Printf("failed: %d", 42) }, "[ERROR] failed: 42 source=stdlog\n"},
+		{"filtered", TRACE, func() { stdlog.Print("verbose") }, ""},
+		{
+			"multi-line", WARN, func() { stdlog.Print("first\nsecond\n\nthird") },
+			"[WARN ] first source=stdlog\n[WARN ] second source=stdlog\n[WARN ] third source=stdlog\n",
+		},
+		{
+			"time stripped", INFO, func() {
+				stdlog.SetFlags(stdlog.LstdFlags | stdlog.Lmicroseconds)
+				stdlog.Print("stamped")
+			},
+			"[INFO ] stamped source=stdlog\n",
+		},
+		{
+			"partial", INFO, func() {
+				_, _ = io.WriteString(stdlog.Writer(), "par")
+				_, _ = io.WriteString(stdlog.Writer(), "tial\nne")
+				_, _ = io.WriteString(stdlog.Writer(), "xt\n")
+			},
+			"[INFO ] partial source=stdlog\n[INFO ] next source=stdlog\n",
+		},
+		{
+			"partial released", INFO, func() {
+				_, _ = io.WriteString(stdlog.Writer(), "unterminated")
+				ReleaseStdlog()
+			},
+			"[INFO ] unterminated source=stdlog\n",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			buf := captureStdlog(t, c.level, 0)
+			c.log()
+			require.Equal(t, c.want, buf.String())
+		})
+	}
+}
+
+func TestCaptureStdlogCaller(t *testing.T) {
+	buf := captureStdlog(t, INFO, Lshortfile)
+	stdlog.Println("here")
+	line := lastLine()
+	require.Equal(t, fmt.Sprintf("stdlog_test.go:%d: [INFO ] here source=stdlog\n", line), buf.String())
+}
+
+func TestReleaseStdlog(t *testing.T) {
+	var out bytes.Buffer
+	oldOut, oldFlags := stdlog.Writer(), stdlog.Flags()
+	stdlog.SetOutput(&out)
+	stdlog.SetFlags(stdlog.Lshortfile)
+	defer func() {
+		stdlog.SetOutput(oldOut)
+		stdlog.SetFlags(oldFlags)
+	}()
+
+	buf := captureStdlog(t, INFO, 0)
+	// capturing again changes the level only
+	CaptureStdlog(WARN)
+	stdlog.Print("captured")
+	require.Equal(t, "[WARN ] captured source=stdlog\n", buf.String())
+
+	ReleaseStdlog()
+	ReleaseStdlog()
+	require.Equal(t, stdlog.Lshortfile, stdlog.Flags())
+	stdlog.Print("released")
+	require.True(t, strings.HasSuffix(out.String(), ": released\n"), out.String())
+	require.NotContains(t, buf.String(), "released")
+}
@@ -0,0 +1,154 @@
+package log
+
+import (
+	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Matcher selects the entries dropped by Suppress, e.g. the noisy entries of a
+// dependency. The entries must match all the criteria set, a Matcher without criteria
+// matches nothing.
+type Matcher struct {
+	// File matches the entries whose call site is in a file starting with File, e.g.
+	// "/go/pkg/mod/github.com/noisy/". The call site is known only if the logger reports
+	// it, see SetReportCaller, the other entries don't match.
+	File string
+	// Message matches the entries whose message matches it.
+	Message *regexp.Regexp
+	// Logger matches the entries of the logger named Logger and of its children, e.g.
+	// "db" matches "db" and "db.pool", see Named.
+	Logger string
+	// Expiry is the duration after which the matcher is removed, 0 never.
+	Expiry time.Duration
+	// Force allows the matcher to drop the entries of ERROR and above.
+	Force bool
+}
+
+// match reports whether the entry matches m.
+func (m *Matcher) match(e *Entry) bool {
+	if m.File == "" && m.Message == nil && m.Logger == "" {
+		return false
+	}
+	if m.File != "" && !strings.HasPrefix(e.File, m.File) {
+		return false
+	}
+	if m.Logger != "" && e.Name != m.Logger && !strings.HasPrefix(e.Name, m.Logger+".") {
+		return false
+	}
+	return m.Message == nil || m.Message.MatchString(e.Message)
+}
+
+// suppression is a Matcher added by Suppress.
+type suppression struct {
+	Matcher
+	// deadline is when the matcher expires, zero if it doesn't
+	deadline time.Time
+}
+
+// expired reports whether s is expired at now.
+func (s *suppression) expired(now time.Time) bool {
+	return !s.deadline.IsZero() && !now.Before(s.deadline)
+}
+
+var (
+	// suppressions holds the []suppression added by Suppress, it's replaced on update so
+	// that the entries are matched without lock.
+	suppressions atomic.Value
+
+	// suppressMtx serializes the updates of suppressions.
+	suppressMtx sync.Mutex
+)
+
+// loadSuppressions returns the matchers added by Suppress.
+func loadSuppressions() []suppression {
+	list, _ := suppressions.Load().([]suppression)
+	return list
+}
+
+// Suppress drops the entries of all the loggers matching one of the matchers before they
+// are sampled and formatted, the entries of ERROR and above only if the matcher sets
+// Force. The dropped entries are counted by Muted of Stats. A matcher with Expiry is
+// removed after it, as measured by the time of the entries, see SetTimeFunc.
+//
+//	log.Suppress(log.Matcher{Message: regexp.MustCompile(`^retrying`), Expiry: time.Hour})
+func Suppress(matchers ...Matcher) {
+	suppressMtx.Lock()
+	defer suppressMtx.Unlock()
+	now := timeNow()
+	list := activeSuppressions(now)
+	for _, m := range matchers {
+		s := suppression{Matcher: m}
+		if m.Expiry > 0 {
+			s.deadline = now.Add(m.Expiry)
+		}
+		list = append(list, s)
+	}
+	suppressions.Store(list)
+}
+
+// Unsuppress removes the matchers equal to the ones added by Suppress, or all of them if
+// none is given.
+func Unsuppress(matchers ...Matcher) {
+	suppressMtx.Lock()
+	defer suppressMtx.Unlock()
+	if len(matchers) == 0 {
+		suppressions.Store([]suppression(nil))
+		return
+	}
+	var list []suppression
+	for _, s := range activeSuppressions(timeNow()) {
+		removed := false
+		for _, m := range matchers {
+			if s.Matcher == m {
+				removed = true
+				break
+			}
+		}
+		if !removed {
+			list = append(list, s)
+		}
+	}
+	suppressions.Store(list)
+}
+
+// activeSuppressions returns a copy of the matchers not expired at now, it must be called
+// with suppressMtx locked.
+func activeSuppressions(now time.Time) []suppression {
+	var list []suppression
+	for _, s := range loadSuppressions() {
+		if !s.expired(now) {
+			list = append(list, s)
+		}
+	}
+	return list
+}
+
+// muted reports whether the entry is dropped by a matcher of Suppress.
+func muted(e *Entry) bool {
+	list := loadSuppressions()
+	if len(list) == 0 {
+		return false
+	}
+	var now time.Time
+	for i := range list {
+		s := &list[i]
+		if e.Level >= ERROR && !s.Force {
+			continue
+		}
+		if !s.deadline.IsZero() {
+			if now.IsZero() {
+				now = timeNow()
+			}
+			if s.expired(now) {
+				continue
+			}
+		}
+		if s.match(e) {
+			return true
+		}
+	}
+	return false
+}
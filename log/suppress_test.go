@@ -0,0 +1,149 @@
+package log
+
+import (
+	"bytes"
+	"io"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// suppress adds the matchers until the end of the test.
+func suppress(t *testing.T, matchers ...Matcher) {
+	Suppress(matchers...)
+	t.Cleanup(func() { Unsuppress() })
+}
+
+func TestSuppress(t *testing.T) {
+	t.Run("message", func(t *testing.T) {
+		var buf bytes.Buffer
+		l := New(WithOutput(&buf), WithFlags(0))
+		suppress(t, Matcher{Message: regexp.MustCompile(`^retrying`)})
+		l.Warn("retrying in 1s")
+		l.Warnf("retrying in %ds", 2)
+		l.Warn("not retrying")
+		require.Equal(t, "[WARN ] not retrying\n", buf.String())
+		require.Equal(t, int64(2), l.Stats().Muted)
+		require.Equal(t, int64(1), l.Stats().Entries)
+	})
+
+	t.Run("logger", func(t *testing.T) {
+		var buf bytes.Buffer
+		l := New(WithOutput(&buf), WithFlags(0))
+		suppress(t, Matcher{Logger: "db"})
+		l.Named("db").Warn("hidden")
+		l.Named("db").Named("pool").Warn("hidden")
+		l.Named("dbx").Warn("shown")
+		l.Warn("shown")
+		require.Equal(t, "[WARN ] dbx: shown\n[WARN ] shown\n", buf.String())
+		require.Equal(t, int64(2), l.Stats().Muted)
+	})
+
+	t.Run("file", func(t *testing.T) {
+		var buf bytes.Buffer
+		l := New(WithOutput(&buf), WithFlags(0))
+		_, file, _, _ := runtime.Caller(0)
+		suppress(t, Matcher{File: filepath.Dir(file) + "/suppress_test"})
+		// the call site isn't known without the report of the caller
+		l.Warn("shown")
+		l.SetReportCaller(true)
+		l.Warn("hidden")
+		require.Equal(t, "[WARN ] shown\n", buf.String())
+		require.Equal(t, int64(1), l.Stats().Muted)
+	})
+
+	t.Run("all criteria", func(t *testing.T) {
+		var buf bytes.Buffer
+		l := New(WithOutput(&buf), WithFlags(0))
+		suppress(t, Matcher{Logger: "db", Message: regexp.MustCompile(`slow`)}, Matcher{})
+		l.Named("db").Warn("slow query")
+		l.Named("db").Warn("refused")
+		l.Warn("slow query")
+		require.Equal(t, "[WARN ] db: refused\n[WARN ] slow query\n", buf.String())
+	})
+
+	t.Run("force", func(t *testing.T) {
+		var buf bytes.Buffer
+		l := New(WithOutput(&buf), WithFlags(0))
+		suppress(t, Matcher{Message: regexp.MustCompile(`failed`)})
+		l.Warn("failed")
+		l.Error("failed")
+		require.Equal(t, "[ERROR] failed\n", buf.String())
+
+		buf.Reset()
+		suppress(t, Matcher{Message: regexp.MustCompile(`failed`), Force: true})
+		l.Error("failed")
+		l.Err(io.EOF, "failed")
+		require.Empty(t, buf.String())
+		require.Equal(t, int64(3), l.Stats().Muted)
+	})
+
+	t.Run("before sampling", func(t *testing.T) {
+		var buf bytes.Buffer
+		s := &countSampler{}
+		l := New(WithOutput(&buf), WithFlags(0), WithSampler(s))
+		suppress(t, Matcher{Message: regexp.MustCompile(`hidden`)})
+		l.Warn("hidden")
+		require.Zero(t, s.calls)
+		require.Zero(t, l.Stats().Suppressed)
+	})
+}
+
+func TestSuppressExpiry(t *testing.T) {
+	clock := setFakeClock(t)
+	var buf bytes.Buffer
+	l := New(WithOutput(&buf), WithFlags(0))
+	hour := Matcher{Message: regexp.MustCompile(`hour`), Expiry: time.Hour}
+	suppress(t, hour, Matcher{Message: regexp.MustCompile(`minute`), Expiry: time.Minute})
+	l.Warn("hour")
+	l.Warn("minute")
+	clock.Add(time.Minute)
+	l.Warn("hour")
+	l.Warn("minute")
+	require.Equal(t, "[WARN ] minute\n", buf.String())
+	// the expired matchers are removed on update
+	Suppress()
+	require.Len(t, loadSuppressions(), 1)
+
+	buf.Reset()
+	clock.Add(time.Hour)
+	l.Warn("hour")
+	require.Equal(t, "[WARN ] hour\n", buf.String())
+}
+
+func TestUnsuppress(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(WithOutput(&buf), WithFlags(0))
+	first := Matcher{Message: regexp.MustCompile(`first`)}
+	second := Matcher{Logger: "second"}
+	suppress(t, first, second)
+	l.Warn("first")
+	l.Named("second").Warn("second")
+	require.Empty(t, buf.String())
+
+	Unsuppress(first)
+	l.Warn("first")
+	l.Named("second").Warn("second")
+	require.Equal(t, "[WARN ] first\n", buf.String())
+
+	buf.Reset()
+	Unsuppress()
+	l.Named("second").Warn("second")
+	require.Equal(t, "[WARN ] second: second\n", buf.String())
+}
+
+func BenchmarkSuppress(b *testing.B) {
+	l := New(WithOutput(&countingWriter{}), WithFlags(0))
+	Suppress(Matcher{Message: regexp.MustCompile(`^noisy`)}, Matcher{Logger: "dep"})
+	defer Unsuppress()
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			l.Warn("tight loop")
+		}
+	})
+}
@@ -0,0 +1,381 @@
+package log
+
+import (
+	"encoding/binary"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/stkali/utility/errors"
+)
+
+const (
+	// minBackoff and maxBackoff bound the delay between the connection attempts of a
+	// SocketOutput whose socket dropped.
+	minBackoff = 100 * time.Millisecond
+	maxBackoff = 30 * time.Second
+	// socketWriteTimeout is the timeout of a write to the socket of a SocketOutput, so
+	// that a stuck peer doesn't block the logger.
+	socketWriteTimeout = time.Second
+)
+
+// socketNow returns the current time of the backoff of the socket outputs, for testing.
+var socketNow = time.Now
+
+// maxPending is the number of entries buffered by a SocketOutput during an outage, the
+// oldest ones are dropped beyond, for testing.
+var maxPending = 1024
+
+// ClosedOutputError is returned by the writes to a closed SocketOutput.
+var ClosedOutputError = errors.WithCode(errors.Error("output is closed"), errors.Unavailable)
+
+func init() {
+	errors.Register("log.closed_output", ClosedOutputError)
+}
+
+// SocketOutput is an output writing the entries to a socket, see NewSyslogOutput and
+// NewJournalOutput. When the socket drops, it reconnects with an exponential backoff and
+// buffers the entries meanwhile, up to 1024 entries, the oldest ones are dropped beyond
+// and counted, see Dropped, like the entries too long for a datagram. It is safe for concurrent use.
+type SocketOutput struct {
+	dial   func() (net.Conn, error)
+	encode func(b []byte, e *Entry) []byte
+
+	mtx     sync.Mutex
+	conn    net.Conn
+	pending [][]byte
+	backoff time.Duration
+	retry   time.Time
+	closed  bool
+	dropped int64
+}
+
+var _ EntryWriter = (*SocketOutput)(nil)
+
+// newSocketOutput returns a SocketOutput connected by dial.
+func newSocketOutput(dial func() (net.Conn, error), encode func(b []byte, e *Entry) []byte) (*SocketOutput, error) {
+	o := &SocketOutput{dial: dial, encode: encode}
+	if err := o.connect(); err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+// connect connects the socket if it isn't connected and the backoff is elapsed. It must
+// be called with mtx locked.
+func (o *SocketOutput) connect() error {
+	if o.conn != nil {
+		return nil
+	}
+	now := socketNow()
+	if now.Before(o.retry) {
+		return errors.Newf("reconnecting in %v", o.retry.Sub(now))
+	}
+	conn, err := o.dial()
+	if err != nil {
+		o.backoff *= 2
+		if o.backoff < minBackoff {
+			o.backoff = minBackoff
+		} else if o.backoff > maxBackoff {
+			o.backoff = maxBackoff
+		}
+		o.retry = now.Add(o.backoff)
+		return err
+	}
+	o.conn, o.backoff = conn, 0
+	return nil
+}
+
+// send writes the message msg to the connected socket, framed by its length on a stream
+// socket, see RFC 6587. The socket is closed if it fails, unless msg is too long.
+func (o *SocketOutput) send(msg []byte) error {
+	_ = o.conn.SetWriteDeadline(time.Now().Add(socketWriteTimeout))
+	var err error
+	switch o.conn.RemoteAddr().Network() {
+	case "tcp", "tcp4", "tcp6", "unix":
+		_, err = o.conn.Write(append(strconv.AppendInt(nil, int64(len(msg)), 10), append([]byte{' '}, msg...)...))
+	default:
+		_, err = o.conn.Write(msg)
+	}
+	if err != nil && !errors.Is(err, syscall.EMSGSIZE) {
+		_ = o.conn.Close()
+		o.conn = nil
+		o.retry = socketNow().Add(minBackoff)
+		o.backoff = minBackoff
+	}
+	return err
+}
+
+// flush sends the buffered messages and then msg if it isn't nil, the messages not sent
+// are buffered. It must be called with mtx locked.
+func (o *SocketOutput) flush(msg []byte) error {
+	if msg != nil {
+		o.buffer(msg)
+	}
+	if err := o.connect(); err != nil {
+		// the entries stay buffered until it reconnects
+		return nil
+	}
+	var dropErr error
+	for len(o.pending) > 0 {
+		if err := o.send(o.pending[0]); err != nil {
+			if o.conn == nil {
+				return errors.Wrap(err, "failed to write to socket, buffering the entries")
+			}
+			// the message doesn't fit in a datagram
+			atomic.AddInt64(&o.dropped, 1)
+			dropErr = errors.Wrap(err, "failed to write to socket, dropping the entry")
+		}
+		o.pending[0] = nil
+		o.pending = o.pending[1:]
+	}
+	return dropErr
+}
+
+// buffer buffers msg, the oldest message is dropped if the buffer is full.
+func (o *SocketOutput) buffer(msg []byte) {
+	if len(o.pending) >= maxPending {
+		n := copy(o.pending, o.pending[1:])
+		o.pending = o.pending[:n]
+		atomic.AddInt64(&o.dropped, 1)
+	}
+	o.pending = append(o.pending, msg)
+}
+
+// WriteEntry writes the entry to the socket, or buffers it during an outage. It returns
+// an error only when the socket drops, the entries are buffered until it reconnects.
+func (o *SocketOutput) WriteEntry(e Entry) error {
+	o.mtx.Lock()
+	defer o.mtx.Unlock()
+	if o.closed {
+		return ClosedOutputError
+	}
+	return o.flush(o.encode(nil, &e))
+}
+
+// Write writes p as the message of an entry of level INFO, so that the output can be used
+// as an io.Writer, e.g. by the log package of the standard library.
+func (o *SocketOutput) Write(p []byte) (int, error) {
+	msg := strings.TrimRight(string(p), "\n")
+	return len(p), o.WriteEntry(Entry{Time: timeNow(), Level: INFO, Message: msg})
+}
+
+// Dropped returns the number of entries dropped because the buffer was full during an
+// outage.
+func (o *SocketOutput) Dropped() int64 {
+	return atomic.LoadInt64(&o.dropped)
+}
+
+// Close sends the buffered entries if the socket is connected and closes it.
+func (o *SocketOutput) Close() error {
+	o.mtx.Lock()
+	defer o.mtx.Unlock()
+	if o.closed {
+		return nil
+	}
+	o.closed = true
+	err := o.flush(nil)
+	if o.conn != nil {
+		if closeErr := o.conn.Close(); err == nil {
+			err = closeErr
+		}
+		o.conn = nil
+	}
+	return err
+}
+
+// syslogSeverity returns the syslog severity of the level, see RFC 5424.
+func syslogSeverity(lv Level) int {
+	switch {
+	case lv <= DEBUG:
+		return 7 // debug
+	case lv == INFO:
+		return 6 // informational
+	case lv == WARN:
+		return 4 // warning
+	case lv == ERROR:
+		return 3 // error
+	}
+	return 2 // critical
+}
+
+// syslogUser is the facility of the messages, see RFC 5424.
+const syslogUser = 1
+
+// localSyslogSockets are the sockets of the local syslog daemon.
+var localSyslogSockets = []string{"/dev/log", "/var/run/syslog", "/var/run/log"}
+
+// appendSyslogHeader appends the header field s of RFC 5424: printable ASCII without
+// space, truncated to limit bytes, or "-" if empty.
+func appendSyslogHeader(b []byte, s string, limit int) []byte {
+	if s == "" {
+		return append(b, '-')
+	}
+	if len(s) > limit {
+		s = s[:limit]
+	}
+	for i := 0; i < len(s); i++ {
+		if c := s[i]; c > ' ' && c < 0x7f {
+			b = append(b, c)
+		} else {
+			b = append(b, '_')
+		}
+	}
+	return b
+}
+
+// defaultTag returns the tag of the program, the base name of its executable.
+func defaultTag() string {
+	return filepath.Base(os.Args[0])
+}
+
+// NewSyslogOutput returns an output writing the entries to the syslog daemon at addr in the
+// format of RFC 5424, with the facility user and the severity mapped from the level,
+// e.g. 4 (warning) for WARN. The name of the logger is the MSGID, the message is followed
+// by the fields in TextFormat:
+//
+//	<12>1 2024-09-19T10:12:03.042117+02:00 web-1 app 4242 db - refused host=db-1
+//
+// network is "udp", "tcp", "unix" or "unixgram", or "" to connect to the local daemon,
+// e.g. at /dev/log. The messages are framed by their length on a stream socket, see
+// RFC 6587. tag identifies the program, default is the name of its executable.
+func NewSyslogOutput(network, addr, tag string) (*SocketOutput, error) {
+	if tag == "" {
+		tag = defaultTag()
+	}
+	hostname, _ := os.Hostname()
+	pid := strconv.Itoa(os.Getpid())
+	dial := func() (net.Conn, error) {
+		return net.Dial(network, addr)
+	}
+	if network == "" {
+		dial = dialLocalSyslog
+	}
+	encode := func(b []byte, e *Entry) []byte {
+		b = append(b, '<')
+		b = strconv.AppendInt(b, int64(syslogUser*8+syslogSeverity(e.Level)), 10)
+		b = append(b, ">1 "...)
+		if e.Time.IsZero() {
+			b = append(b, '-')
+		} else {
+			b = e.Time.AppendFormat(b, "2006-01-02T15:04:05.000000Z07:00")
+		}
+		b = append(b, ' ')
+		b = appendSyslogHeader(b, hostname, 255)
+		b = append(b, ' ')
+		b = appendSyslogHeader(b, tag, 48)
+		b = append(b, ' ')
+		b = appendSyslogHeader(b, pid, 128)
+		b = append(b, ' ')
+		b = appendSyslogHeader(b, e.Name, 32)
+		// no structured data, the fields follow the message
+		b = append(b, " - "...)
+		b = append(b, strings.TrimRight(e.Message, "\n")...)
+		return appendTextFields(b, e.Fields)
+	}
+	o, err := newSocketOutput(dial, encode)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to connect to syslog %s %q", network, addr)
+	}
+	return o, nil
+}
+
+// dialLocalSyslog connects to the socket of the local syslog daemon.
+func dialLocalSyslog() (net.Conn, error) {
+	var err error
+	for _, path := range localSyslogSockets {
+		for _, network := range []string{"unixgram", "unix"} {
+			var conn net.Conn
+			if conn, err = net.Dial(network, path); err == nil {
+				return conn, nil
+			}
+		}
+	}
+	return nil, err
+}
+
+// journalSocket is the socket of journald.
+var journalSocket = "/run/systemd/journal/socket"
+
+// appendJournalName appends the field name key of journald: uppercase letters, digits
+// and underscores, not starting with an underscore or a digit, at most 64 bytes.
+func appendJournalName(b []byte, key string) []byte {
+	key = strings.TrimLeft(key, "_")
+	if key == "" || key[0] >= '0' && key[0] <= '9' {
+		key = "F_" + key
+	}
+	if len(key) > 64 {
+		key = key[:64]
+	}
+	for i := 0; i < len(key); i++ {
+		switch c := key[i]; {
+		case c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+			b = append(b, c)
+		case c >= 'a' && c <= 'z':
+			b = append(b, c-'a'+'A')
+		default:
+			b = append(b, '_')
+		}
+	}
+	return b
+}
+
+// appendJournalField appends the field of the native protocol of journald, a value
+// containing a newline is written with its length.
+func appendJournalField(b []byte, key, value string) []byte {
+	b = appendJournalName(b, key)
+	if !strings.Contains(value, "\n") {
+		b = append(b, '=')
+		b = append(b, value...)
+		return append(b, '\n')
+	}
+	b = append(b, '\n')
+	var size [8]byte
+	binary.LittleEndian.PutUint64(size[:], uint64(len(value)))
+	b = append(b, size[:]...)
+	b = append(b, value...)
+	return append(b, '\n')
+}
+
+// NewJournalOutput returns an output writing the entries to journald with its native
+// protocol: the fields MESSAGE, PRIORITY mapped from the level like NewSyslogOutput,
+// SYSLOG_IDENTIFIER set to tag, LOGGER set to the name of the logger, CODE_FILE and
+// CODE_LINE if the caller is reported, and the fields of the entry with their key in
+// uppercase, e.g. REQUEST_ID for "request_id". tag identifies the program, default is
+// the name of its executable. The entries must fit in a datagram.
+func NewJournalOutput(tag string) (*SocketOutput, error) {
+	if tag == "" {
+		tag = defaultTag()
+	}
+	path := journalSocket
+	dial := func() (net.Conn, error) {
+		return net.Dial("unixgram", path)
+	}
+	encode := func(b []byte, e *Entry) []byte {
+		b = appendJournalField(b, "MESSAGE", strings.TrimRight(e.Message, "\n"))
+		b = appendJournalField(b, "PRIORITY", strconv.Itoa(syslogSeverity(e.Level)))
+		b = appendJournalField(b, "SYSLOG_IDENTIFIER", tag)
+		if e.Name != "" {
+			b = appendJournalField(b, "LOGGER", e.Name)
+		}
+		if e.File != "" {
+			b = appendJournalField(b, "CODE_FILE", e.File)
+			b = appendJournalField(b, "CODE_LINE", strconv.Itoa(e.Line))
+		}
+		for _, f := range e.Fields {
+			b = appendJournalField(b, f.Key, valueString(f.Value))
+		}
+		return b
+	}
+	o, err := newSocketOutput(dial, encode)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to connect to journald %q", path)
+	}
+	return o, nil
+}
@@ -0,0 +1,251 @@
+package log
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// listenUnixgram listens on a unix datagram socket in a temporary directory until the end
+// of the test.
+func listenUnixgram(t *testing.T, path string) *net.UnixConn {
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+	return conn
+}
+
+// readDatagram reads a datagram of conn.
+func readDatagram(t *testing.T, conn *net.UnixConn) string {
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(time.Second)))
+	buf := make([]byte, 1<<16)
+	n, _, err := conn.ReadFrom(buf)
+	require.NoError(t, err)
+	return string(buf[:n])
+}
+
+func TestSyslogOutput(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "log.sock")
+	conn := listenUnixgram(t, path)
+	o, err := NewSyslogOutput("unixgram", path, "app")
+	require.NoError(t, err)
+	defer o.Close()
+	hostname, _ := os.Hostname()
+
+	l := New(WithOutput(nil), WithLevel(TRACE))
+	l.AddOutput(o)
+	cases := []struct {
+		name string
+		log  func()
+		want string
+	}{
+		{"info", func() { l.Info("started") }, "<14>1 %s app %d - - started"},
+		{"debug", func() { l.Debug("state") }, "<15>1 %s app %d - - state"},
+		{"trace", func() { l.Trace("state") }, "<15>1 %s app %d - - state"},
+		{"warn", func() { l.Named("db").Warnw("refused", "host", "db 1") }, `<12>1 %s app %d db - refused host="db 1"`},
+		{"error", func() { l.Error("failed\n") }, "<11>1 %s app %d - - failed"},
+	}
+	stamp := regexp.MustCompile(`^(<\d+>1) \d{4}-\d\d-\d\dT\d\d:\d\d:\d\d\.\d{6}(Z|[+-]\d\d:\d\d) `)
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			c.log()
+			msg := readDatagram(t, conn)
+			require.Regexp(t, stamp, msg)
+			require.Equal(t, fmt.Sprintf(c.want, hostname, os.Getpid()), stamp.ReplaceAllString(msg, "$1 "))
+		})
+	}
+
+	// written as an io.Writer
+	_, err = fmt.Fprintln(o, "plain")
+	require.NoError(t, err)
+	require.True(t, strings.HasSuffix(readDatagram(t, conn), " - plain"))
+}
+
+func TestSyslogOutputStream(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer ln.Close()
+	o, err := NewSyslogOutput("tcp", ln.Addr().String(), "app")
+	require.NoError(t, err)
+	conn, err := ln.Accept()
+	require.NoError(t, err)
+	defer conn.Close()
+
+	l := New(WithOutput(o))
+	l.Warn("first")
+	l.Error("second")
+	require.NoError(t, o.Close())
+
+	// the messages are framed by their length
+	r := bufio.NewReader(conn)
+	for _, want := range []string{"first", "second"} {
+		var size int
+		_, err = fmt.Fscanf(r, "%d ", &size)
+		require.NoError(t, err)
+		msg := make([]byte, size)
+		_, err = r.Read(msg)
+		require.NoError(t, err)
+		require.True(t, strings.HasSuffix(string(msg), " - "+want), string(msg))
+	}
+}
+
+func TestSyslogOutputReconnect(t *testing.T) {
+	clock := setSocketClock(t)
+	oldPending := maxPending
+	maxPending = 3
+	defer func() { maxPending = oldPending }()
+
+	path := filepath.Join(t.TempDir(), "log.sock")
+	conn := listenUnixgram(t, path)
+	o, err := NewSyslogOutput("unixgram", path, "app")
+	require.NoError(t, err)
+	defer o.Close()
+	require.NoError(t, o.WriteEntry(Entry{Level: INFO, Message: "before"}))
+	require.True(t, strings.HasSuffix(readDatagram(t, conn), " - before"))
+
+	// the socket drops, the entries are buffered
+	require.NoError(t, conn.Close())
+	require.NoError(t, os.Remove(path))
+	require.Error(t, o.WriteEntry(Entry{Level: INFO, Message: "m1"}))
+	for _, msg := range []string{"m2", "m3", "m4"} {
+		require.NoError(t, o.WriteEntry(Entry{Level: INFO, Message: msg}))
+	}
+	require.Equal(t, int64(1), o.Dropped())
+
+	// no reconnection before the backoff
+	conn = listenUnixgram(t, path)
+	clock.Add(minBackoff / 2)
+	require.NoError(t, o.WriteEntry(Entry{Level: INFO, Message: "m5"}))
+	require.Equal(t, int64(2), o.Dropped())
+
+	clock.Add(minBackoff)
+	require.NoError(t, o.WriteEntry(Entry{Level: INFO, Message: "after"}))
+	// the oldest entries are dropped
+	require.Equal(t, int64(3), o.Dropped())
+	for _, want := range []string{"m4", "m5", "after"} {
+		require.True(t, strings.HasSuffix(readDatagram(t, conn), " - "+want))
+	}
+
+	require.NoError(t, o.Close())
+	require.ErrorIs(t, o.WriteEntry(Entry{Message: "closed"}), ClosedOutputError)
+}
+
+func TestSyslogOutputBackoff(t *testing.T) {
+	clock := setSocketClock(t)
+	path := filepath.Join(t.TempDir(), "log.sock")
+	conn := listenUnixgram(t, path)
+	o, err := NewSyslogOutput("unixgram", path, "app")
+	require.NoError(t, err)
+	require.NoError(t, conn.Close())
+	require.NoError(t, os.Remove(path))
+
+	dials := 0
+	dial := o.dial
+	o.dial = func() (net.Conn, error) {
+		dials++
+		return dial()
+	}
+	require.Error(t, o.WriteEntry(Entry{Message: "dropped"}))
+	// the delay doubles up to maxBackoff
+	for _, backoff := range []time.Duration{minBackoff, 2 * minBackoff, 4 * minBackoff} {
+		clock.Add(backoff - time.Millisecond)
+		require.NoError(t, o.WriteEntry(Entry{Message: "early"}))
+		clock.Add(time.Millisecond)
+		require.NoError(t, o.WriteEntry(Entry{Message: "retry"}))
+	}
+	require.Equal(t, 3, dials)
+	require.Equal(t, 8*minBackoff, o.backoff)
+	for i := 0; i < 20; i++ {
+		clock.Add(maxBackoff)
+		require.NoError(t, o.WriteEntry(Entry{Message: "retry"}))
+	}
+	require.Equal(t, maxBackoff, o.backoff)
+}
+
+func TestSyslogOutputUnavailable(t *testing.T) {
+	_, err := NewSyslogOutput("unixgram", filepath.Join(t.TempDir(), "none.sock"), "app")
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "failed to connect to syslog")
+}
+
+// journalFields parses the fields of a datagram of the native protocol of journald.
+func journalFields(t *testing.T, msg string) map[string]string {
+	fields := make(map[string]string)
+	for msg != "" {
+		i := strings.IndexAny(msg, "=\n")
+		require.True(t, i > 0, msg)
+		key := msg[:i]
+		if msg[i] == '=' {
+			end := strings.IndexByte(msg, '\n')
+			fields[key] = msg[i+1 : end]
+			msg = msg[end+1:]
+			continue
+		}
+		size := int(binary.LittleEndian.Uint64([]byte(msg[i+1 : i+9])))
+		fields[key] = msg[i+9 : i+9+size]
+		require.Equal(t, byte('\n'), msg[i+9+size])
+		msg = msg[i+10+size:]
+	}
+	return fields
+}
+
+func TestJournalOutput(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal.sock")
+	conn := listenUnixgram(t, path)
+	old := journalSocket
+	journalSocket = path
+	defer func() { journalSocket = old }()
+
+	o, err := NewJournalOutput("app")
+	require.NoError(t, err)
+	defer o.Close()
+	l := New(WithOutput(o), WithFlags(Lshortfile), WithLevel(DEBUG)).Named("db")
+	l.Errorw("refused", "request_id", 42, "_trusted", true, "9lives", "cat", "query", "SELECT 1\nFROM t")
+	line := lastLine()
+
+	fields := journalFields(t, readDatagram(t, conn))
+	require.Equal(t, "syslog_test.go", filepath.Base(fields["CODE_FILE"]))
+	delete(fields, "CODE_FILE")
+	require.Equal(t, map[string]string{
+		"MESSAGE":           "refused",
+		"PRIORITY":          "3",
+		"SYSLOG_IDENTIFIER": "app",
+		"LOGGER":            "db",
+		"CODE_LINE":         fmt.Sprint(line),
+		"REQUEST_ID":        "42",
+		"TRUSTED":           "true",
+		"F_9LIVES":          "cat",
+		"QUERY":             "SELECT 1\nFROM t",
+	}, fields)
+
+	l.Debug("state")
+	fields = journalFields(t, readDatagram(t, conn))
+	require.Equal(t, "state", fields["MESSAGE"])
+	require.Equal(t, "7", fields["PRIORITY"])
+}
+
+func TestEntryWriterAsync(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "log.sock")
+	conn := listenUnixgram(t, path)
+	o, err := NewSyslogOutput("unixgram", path, "app")
+	require.NoError(t, err)
+	defer o.Close()
+
+	l := New(WithOutput(nil))
+	l.AddOutput(o, WithMinLevel(ERROR))
+	l.SetAsync(16)
+	defer l.SetAsync(0)
+	l.Warn("filtered")
+	l.Errorw("queued", "k", "v")
+	l.Flush()
+	require.True(t, strings.HasSuffix(readDatagram(t, conn), " - queued k=v"))
+}
@@ -0,0 +1,15 @@
+//go:build darwin
+
+package log
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+// isTerminalFd reports whether fd is a terminal, by the TIOCGETA ioctl.
+func isTerminalFd(fd uintptr) bool {
+	var termios syscall.Termios
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, fd, syscall.TIOCGETA, uintptr(unsafe.Pointer(&termios)))
+	return errno == 0
+}
@@ -0,0 +1,15 @@
+//go:build linux
+
+package log
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+// isTerminalFd reports whether fd is a terminal, by the TCGETS ioctl.
+func isTerminalFd(fd uintptr) bool {
+	var termios syscall.Termios
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, fd, syscall.TCGETS, uintptr(unsafe.Pointer(&termios)))
+	return errno == 0
+}
@@ -0,0 +1,60 @@
+//go:build linux
+
+package log
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// terminalWriter is a terminal recording the writes.
+type terminalWriter struct {
+	bytes.Buffer
+	tty *os.File
+}
+
+func (w *terminalWriter) Fd() uintptr {
+	return w.tty.Fd()
+}
+
+func TestTerminalOutput(t *testing.T) {
+	// the master of a pseudo-terminal is a terminal
+	tty, err := os.OpenFile("/dev/ptmx", os.O_RDWR, 0)
+	if err != nil {
+		t.Skipf("no pseudo-terminal: %s", err)
+	}
+	defer tty.Close()
+	require.True(t, isTerminal(tty))
+
+	t.Setenv("NO_COLOR", "")
+	var primary bytes.Buffer
+	term := &terminalWriter{tty: tty}
+	l := New(WithOutput(&primary), WithFlags(0))
+	l.AddOutput(term)
+	l.Warn("msg")
+	require.Equal(t, "[WARN ] msg\n", primary.String())
+	require.Equal(t, "\x1b[33m[WARN ]\x1b[0m msg\n", term.String())
+
+	// NO_COLOR disables the colors of ColorAuto
+	t.Setenv("NO_COLOR", "1")
+	term.Reset()
+	l.SetColor(ColorAuto)
+	l.Warn("msg")
+	require.Equal(t, "[WARN ] msg\n", term.String())
+
+	term.Reset()
+	t.Setenv("NO_COLOR", "")
+	l.SetColor(ColorNever)
+	l.Warn("msg")
+	require.Equal(t, "[WARN ] msg\n", term.String())
+
+	// the primary output is colored if it's a terminal
+	term.Reset()
+	l.SetColor(ColorAuto)
+	l.SetOutput(term)
+	l.Error("msg")
+	require.Equal(t, "\x1b[31m[ERROR]\x1b[0m msg\n\x1b[31m[ERROR]\x1b[0m msg\n", term.String())
+}
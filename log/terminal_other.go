@@ -0,0 +1,9 @@
+//go:build !linux && !darwin && !windows
+
+package log
+
+// isTerminalFd returns false, the terminals aren't detected on the other platforms and
+// ColorAlways colors the levels.
+func isTerminalFd(fd uintptr) bool {
+	return false
+}
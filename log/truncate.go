@@ -0,0 +1,112 @@
+package log
+
+import (
+	"sync/atomic"
+	"unicode/utf8"
+
+	"github.com/stkali/utility/lib"
+)
+
+// truncatedEllipsis ends the message and the values cut by SetMaxEntrySize.
+const truncatedEllipsis = "..."
+
+// truncateString returns s cut to at most n bytes, ellipsis included, without splitting
+// a multibyte character.
+func truncateString(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	ellipsis := truncatedEllipsis
+	if n < len(ellipsis) {
+		ellipsis = ""
+	}
+	// the number of the runes fitting in n bytes with the ellipsis
+	runes := 0
+	for i, r := range s {
+		if i+utf8.RuneLen(r) > n-len(ellipsis) {
+			break
+		}
+		runes++
+	}
+	if runes == 0 {
+		return ellipsis
+	}
+	return lib.Truncate(s, runes+utf8.RuneCountInString(ellipsis), ellipsis)
+}
+
+// truncateEntry returns a copy of e cut so that its size returned by size is at most
+// limit, and whether it was cut: the largest of the message and the values of the fields
+// is cut until the entry fits, the message first, the values cut become strings, and the
+// field truncated=true is added. The values are cut before encoding, so the encoding
+// stays valid. The result may still be too long if the keys are.
+func truncateEntry(e *Entry, limit int, size func(e *Entry) int) (Entry, bool) {
+	if size(e) <= limit {
+		return *e, false
+	}
+	t := *e
+	t.Fields = append(make([]Field, 0, len(e.Fields)+1), e.Fields...)
+	t.Fields = append(t.Fields, Field{Key: "truncated", Value: true})
+	values := make([]string, len(e.Fields))
+	for i, f := range e.Fields {
+		values[i] = valueString(f.Value)
+	}
+	for n := size(&t); n > limit; n = size(&t) {
+		// the message is cut first if it is the largest
+		largest, length := -1, len(t.Message)
+		for i, v := range values {
+			if len(v) > length {
+				largest, length = i, len(v)
+			}
+		}
+		if length == 0 {
+			break
+		}
+		// every cut shortens the value, so the loop ends
+		if largest < 0 {
+			t.Message = truncateString(t.Message, length-(n-limit))
+		} else {
+			values[largest] = truncateString(values[largest], length-(n-limit))
+			t.Fields[largest].Value = values[largest]
+		}
+	}
+	return t, true
+}
+
+// encodeEntry appends the entry e in the encoding i to b.
+func (s *sink) encodeEntry(b []byte, e *Entry, i int) []byte {
+	switch i {
+	case jsonEncoding:
+		return encodeJSON(b, e, &s.enc)
+	default:
+		return encodeText(b, e, &s.enc, i == colorEncoding)
+	}
+}
+
+// truncate returns a copy of e cut to at most limit bytes in the encoding i, see
+// truncateEntry, and counts it. It must be called with mtx locked.
+func (s *sink) truncate(e *Entry, limit int, i int) (Entry, bool) {
+	t, ok := truncateEntry(e, limit, func(e *Entry) int {
+		s.scratch = s.encodeEntry(s.scratch[:0], e, i)
+		return len(s.scratch)
+	})
+	if ok {
+		atomic.AddInt64(&s.truncated, 1)
+	}
+	return t, ok
+}
+
+// SetMaxEntrySize limits the size of the entries to bytes, measured in the format of the
+// primary output, e.g. against a caller logging a huge string by mistake. A longer entry
+// is cut, the largest of its message and the values of its fields first, its multibyte
+// characters aren't split, and gets the field truncated=true. The truncated entries are
+// counted, see Stats. Default is 0, the size isn't limited. WithMaxEntrySize sets the
+// limit of an added output.
+func (l *Logger) SetMaxEntrySize(bytes int) {
+	s := l.ownSink()
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	if bytes < 0 {
+		bytes = 0
+	}
+	s.maxEntrySize = bytes
+}
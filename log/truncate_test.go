@@ -0,0 +1,115 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTruncateString(t *testing.T) {
+	cases := []struct {
+		name string
+		s    string
+		n    int
+		want string
+	}{
+		{"short", "hello", 5, "hello"},
+		{"ascii", "hello world", 8, "hello..."},
+		{"multibyte", "héllo wörld", 8, "héll..."},
+		{"rune boundary", "日本語テキスト", 10, "日本..."},
+		{"ellipsis only", "hello", 3, "..."},
+		{"no room for ellipsis", "hello", 2, "he"},
+		{"multibyte no room", "日本", 2, ""},
+		{"zero", "hello", 0, ""},
+		{"negative", "hello", -4, ""},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got := truncateString(c.s, c.n)
+			require.Equal(t, c.want, got)
+			require.True(t, utf8.ValidString(got))
+		})
+	}
+}
+
+func TestSetMaxEntrySize(t *testing.T) {
+	huge := strings.Repeat("é", 1000)
+	cases := []struct {
+		name   string
+		format Format
+		msg    string
+		kv     []any
+		want   string
+	}{
+		{"short", TextFormat, "started", []any{"k", "v"}, "[WARN ] started k=v\n"},
+		{"message", TextFormat, "dump " + huge, []any{"k", "v"}, "[WARN ] dump " + strings.Repeat("é", 14) + "... k=v truncated=true\n"},
+		{"field", TextFormat, "dump", []any{"small", "v", "big", huge, "n", 42}, "[WARN ] dump small=v big=" + strings.Repeat("é", 7) + "... n=42 truncated=true\n"},
+		{"json", JSONFormat, "dump", []any{"big", "\"" + huge}, `{"level":"WARN","msg":"dump","big":"\"` + "é" + `...","truncated":true}` + "\n"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			l := New(WithOutput(&buf), WithFlags(0), WithFormat(c.format))
+			l.SetMaxEntrySize(64)
+			l.Warnw(c.msg, c.kv...)
+			require.Equal(t, c.want, buf.String())
+			require.LessOrEqual(t, buf.Len(), 64)
+			require.True(t, utf8.Valid(buf.Bytes()))
+			if c.format == JSONFormat {
+				require.True(t, json.Valid(buf.Bytes()))
+			}
+		})
+	}
+
+	var buf bytes.Buffer
+	l := New(WithOutput(&buf), WithFlags(0))
+	l.SetMaxEntrySize(32)
+	l.Named("child").Warn(huge)
+	l.Warn("ok")
+	require.Equal(t, int64(1), l.Stats().Truncated)
+	l.SetMaxEntrySize(0)
+	l.Warn(huge)
+	require.Contains(t, buf.String(), huge)
+}
+
+// entryRecorder is an EntryWriter recording the entries.
+type entryRecorder struct {
+	entries []Entry
+}
+
+func (r *entryRecorder) Write(p []byte) (int, error) {
+	return len(p), nil
+}
+
+func (r *entryRecorder) WriteEntry(e Entry) error {
+	r.entries = append(r.entries, e)
+	return nil
+}
+
+func TestWithMaxEntrySize(t *testing.T) {
+	var primary, added bytes.Buffer
+	var recorder entryRecorder
+	l := New(WithOutput(&primary), WithFlags(0))
+	l.AddOutput(&added, WithMaxEntrySize(40))
+	l.AddOutput(&recorder, WithMaxEntrySize(40))
+	l.Warnw("refused", "host", "db-1.eu-west.example.com")
+
+	require.Equal(t, "[WARN ] refused host=db-1.eu-west.example.com\n", primary.String())
+	require.Equal(t, "[WARN ] refused host=... truncated=true\n", added.String())
+	require.Len(t, recorder.entries, 1)
+	require.Equal(t, []Field{{"host", "..."}, {"truncated", true}}, recorder.entries[0].Fields)
+	require.Equal(t, int64(2), l.Stats().Truncated)
+}
+
+func TestPackageSetMaxEntrySize(t *testing.T) {
+	var buf bytes.Buffer
+	defer SetLogger(logger)
+	SetLogger(New(WithOutput(&buf), WithFlags(0)))
+	SetMaxEntrySize(30)
+	Warn("a message longer than twenty bytes")
+	require.Equal(t, "[WARN ] a m... truncated=true\n", buf.String())
+}
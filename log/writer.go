@@ -0,0 +1,130 @@
+package log
+
+import (
+	"bytes"
+	"io"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// lineBuffer splits the writes into lines, see stdlogWriter and WriterAt.
+type lineBuffer struct {
+	// partial is the line being written, without its newline.
+	partial []byte
+	// cut is set when partial reached the limit, the rest of the line is discarded.
+	cut bool
+}
+
+// write appends p and returns the complete lines, without their newline. A line is
+// kept up to limit bytes if limit > 0, the rest is discarded.
+func (b *lineBuffer) write(p []byte, limit int) []string {
+	var lines []string
+	for len(p) > 0 {
+		i := bytes.IndexByte(p, '\n')
+		chunk := p
+		if i >= 0 {
+			chunk = p[:i]
+		}
+		if !b.cut {
+			b.partial = append(b.partial, chunk...)
+			if limit > 0 && len(b.partial) > limit {
+				b.partial, b.cut = b.partial[:limit], true
+			}
+		}
+		if i < 0 {
+			break
+		}
+		lines = append(lines, b.flush())
+		p = p[i+1:]
+	}
+	return lines
+}
+
+// flush returns the partial line and empties the buffer.
+func (b *lineBuffer) flush() string {
+	line := string(b.partial)
+	b.partial, b.cut = nil, false
+	return line
+}
+
+// entryLimit returns the maximum size of the entries, see SetMaxEntrySize.
+func (s *sink) entryLimit() int {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	return s.maxEntrySize
+}
+
+// lineWriter is the writer returned by WriterAt.
+type lineWriter struct {
+	l     *Logger
+	level Level
+	// mtx serializes the writes, the lines are written in order.
+	mtx sync.Mutex
+	buf lineBuffer
+}
+
+// Write writes the complete lines of p as entries, the last line of p is kept until its
+// newline is written.
+func (w *lineWriter) Write(p []byte) (int, error) {
+	w.mtx.Lock()
+	defer w.mtx.Unlock()
+	s := w.l.loadSink()
+	lines := w.buf.write(p, s.entryLimit())
+	if len(lines) > 0 {
+		file, line := w.caller(s)
+		for _, msg := range lines {
+			w.emit(s, msg, file, line)
+		}
+	}
+	return len(p), nil
+}
+
+// Close writes the partial line, the writer can still be used.
+func (w *lineWriter) Close() error {
+	w.mtx.Lock()
+	defer w.mtx.Unlock()
+	s := w.l.loadSink()
+	file, line := w.caller(s)
+	w.emit(s, w.buf.flush(), file, line)
+	return nil
+}
+
+// caller returns the call site of Write or Close if the sink s reports it.
+func (w *lineWriter) caller(s *sink) (string, int) {
+	if !s.reportCaller() {
+		return "", 0
+	}
+	if _, file, line, ok := runtime.Caller(2); ok {
+		return file, line
+	}
+	return "???", 0
+}
+
+// emit writes the line as an entry, without its carriage return. The empty lines are
+// skipped.
+func (w *lineWriter) emit(s *sink, msg string, file string, line int) {
+	msg = strings.TrimSuffix(msg, "\r")
+	if msg == "" || !w.l.Enabled(w.level) {
+		return
+	}
+	e := Entry{Time: timeNow(), Level: w.level, Name: w.l.name, Message: msg, Fields: w.l.fields, File: file, Line: line}
+	if !w.l.sampled(s, &e) {
+		return
+	}
+	w.l.write(&e)
+}
+
+// WriterAt returns a writer for the libraries writing their diagnostics to an
+// io.Writer, e.g. http.Server.ErrorLog with stdlog.New(w, "", 0): every line is written
+// as an entry of level lv by the child of l named name, or l if name is empty. The
+// partial writes are buffered until the newline, which is stripped, and the empty lines
+// are skipped. A line longer than the maximum size of the entries is cut, see
+// SetMaxEntrySize. The writer is safe for concurrent use, Close writes the partial
+// line. An entry of level FATAL doesn't exit.
+func (l *Logger) WriterAt(lv Level, name string) io.WriteCloser {
+	if name != "" {
+		l = l.Named(name)
+	}
+	return &lineWriter{l: l, level: lv}
+}
@@ -0,0 +1,128 @@
+package log
+
+import (
+	"bytes"
+	"fmt"
+	stdlog "log"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLineBuffer(t *testing.T) {
+	cases := []struct {
+		name   string
+		writes []string
+		limit  int
+		lines  []string
+		rest   string
+	}{
+		{"one line", []string{"hello\n"}, 0, []string{"hello"}, ""},
+		{"partial", []string{"hel", "lo", "\nwor", "ld"}, 0, []string{"hello"}, "world"},
+		{"lines", []string{"a\nb\n\nc"}, 0, []string{"a", "b", ""}, "c"},
+		{"limit", []string{"abc", "def\ngh", "ijk\n"}, 4, []string{"abcd", "ghij"}, ""},
+		{"limit partial", []string{"abcdef"}, 4, nil, "abcd"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var b lineBuffer
+			var lines []string
+			for _, w := range c.writes {
+				lines = append(lines, b.write([]byte(w), c.limit)...)
+			}
+			require.Equal(t, c.lines, lines)
+			require.Equal(t, c.rest, b.flush())
+			require.Empty(t, b.flush())
+		})
+	}
+}
+
+func TestWriterAt(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(WithOutput(&buf), WithFlags(0), WithLevel(INFO))
+	w := l.WriterAt(WARN, "http")
+	for _, p := range []string{"tls: ", "handshake ", "error\r\n", "\nfirst\nsec", "ond"} {
+		n, err := w.Write([]byte(p))
+		require.NoError(t, err)
+		require.Equal(t, len(p), n)
+	}
+	require.Equal(t, "[WARN ] http: tls: handshake error\n[WARN ] http: first\n", buf.String())
+	// Close writes the partial line
+	require.NoError(t, w.Close())
+	require.NoError(t, w.Close())
+	require.Equal(t, "[WARN ] http: tls: handshake error\n[WARN ] http: first\n[WARN ] http: second\n", buf.String())
+
+	// the level of the logger applies
+	buf.Reset()
+	_, _ = l.WriterAt(DEBUG, "").Write([]byte("hidden\n"))
+	_, _ = l.WriterAt(INFO, "").Write([]byte("shown\n"))
+	require.Equal(t, "[INFO ] shown\n", buf.String())
+
+	// with the standard logger
+	buf.Reset()
+	std := stdlog.New(l.WriterAt(ERROR, "server"), "", 0)
+	std.Printf("accept: %s", "too many open files")
+	require.Equal(t, "[ERROR] server: accept: too many open files\n", buf.String())
+
+	// the default logger
+	buf.Reset()
+	defer SetLogger(logger)
+	SetLogger(New(WithOutput(&buf), WithFlags(0)))
+	_, _ = WriterAt(WARN, "driver").Write([]byte("reconnecting\n"))
+	require.Equal(t, "[WARN ] driver: reconnecting\n", buf.String())
+}
+
+func TestWriterAtMaxEntrySize(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(WithOutput(&buf), WithFlags(0))
+	l.SetMaxEntrySize(32)
+	w := l.WriterAt(WARN, "")
+	for i := 0; i < 10; i++ {
+		_, _ = w.Write([]byte(strings.Repeat("x", 10)))
+	}
+	// the rest of the line is discarded
+	require.Len(t, w.(*lineWriter).buf.partial, 32)
+	_, _ = w.Write([]byte("\nshort\n"))
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	require.Len(t, lines, 2)
+	require.LessOrEqual(t, len(lines[0]), 32)
+	require.Contains(t, lines[0], "truncated=true")
+	require.Equal(t, "[WARN ] short", lines[1])
+	require.Equal(t, int64(1), l.Stats().Truncated)
+}
+
+func TestWriterAtCaller(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(WithOutput(&buf), WithFlags(Lshortfile))
+	w := l.WriterAt(WARN, "")
+	_, _ = w.Write([]byte("line\n"))
+	require.Regexp(t, `^writer_test\.go:\d+: \[WARN \] line\n$`, buf.String())
+}
+
+func TestWriterAtConcurrent(t *testing.T) {
+	var buf syncBuffer
+	l := New(WithOutput(&buf), WithFlags(0))
+	w := l.WriterAt(WARN, "")
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				// the second line of a write is ended by the next write
+				_, _ = fmt.Fprintf(w, "goroutine %d line %d\ngoroutine %d line %d.", i, 2*j, i, 2*j+1)
+				_, _ = w.Write([]byte("\n"))
+			}
+		}(i)
+	}
+	wg.Wait()
+	require.NoError(t, w.Close())
+	// the entries aren't mixed, a partial line may be continued by another goroutine
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	require.Len(t, lines, 8*100)
+	for _, line := range lines {
+		require.Regexp(t, `^\[WARN \] (goroutine \d line \d+\.?)+$`, line)
+	}
+}
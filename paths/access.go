@@ -0,0 +1,51 @@
+package paths
+
+// AccessMode is a bitmask of the permissions checked by Access.
+// The values are the same as the R_OK, W_OK and X_OK constants of access(2).
+type AccessMode uint32
+
+const (
+	AccessExec  AccessMode = 1 << iota // test for execute or search permission
+	AccessWrite                        // test for write permission
+	AccessRead                         // test for read permission
+)
+
+// String implements fmt.Stringer, it returns the mode in "rwx" notation.
+func (m AccessMode) String() string {
+	b := []byte("---")
+	if m&AccessRead != 0 {
+		b[0] = 'r'
+	}
+	if m&AccessWrite != 0 {
+		b[1] = 'w'
+	}
+	if m&AccessExec != 0 {
+		b[2] = 'x'
+	}
+	return string(b)
+}
+
+// Access checks whether the current process is allowed to access the path with
+// the given mode. Unlike checking the permission bits of os.FileInfo, it asks the
+// operating system, so the effective user, root privileges and ACLs are honored.
+// It returns nil if every requested permission is granted, otherwise an *os.PathError
+// that matches os.ErrPermission or os.ErrNotExist with errors.Is.
+func Access(path string, mode AccessMode) error {
+	return access(path, mode)
+}
+
+// CanRead reports whether the current process is allowed to read the path.
+func CanRead(path string) bool {
+	return access(path, AccessRead) == nil
+}
+
+// CanWrite reports whether the current process is allowed to write the path.
+func CanWrite(path string) bool {
+	return access(path, AccessWrite) == nil
+}
+
+// CanExec reports whether the current process is allowed to execute the path,
+// or to search it if the path is a directory.
+func CanExec(path string) bool {
+	return access(path, AccessExec) == nil
+}
@@ -0,0 +1,18 @@
+//go:build darwin
+
+package paths
+
+import (
+	"os"
+	"syscall"
+)
+
+// access uses access(2), darwin does not expose faccessat through the syscall
+// package, so the check is performed with the real user and group IDs.
+func access(path string, mode AccessMode) error {
+	err := syscall.Access(path, uint32(mode))
+	if err != nil {
+		return &os.PathError{Op: "access", Path: path, Err: err}
+	}
+	return nil
+}
@@ -0,0 +1,26 @@
+//go:build linux
+
+package paths
+
+import (
+	"os"
+	"syscall"
+)
+
+const (
+	// atFdCwd is the AT_FDCWD value, the path is resolved relative to the
+	// current working directory.
+	atFdCwd = -0x64
+	// atEAccess is the AT_EACCESS flag of faccessat(2), the check is performed
+	// using the effective user and group IDs instead of the real ones.
+	atEAccess = 0x200
+)
+
+// access uses faccessat(2) with AT_EACCESS semantics.
+func access(path string, mode AccessMode) error {
+	err := syscall.Faccessat(atFdCwd, path, uint32(mode), atEAccess)
+	if err != nil {
+		return &os.PathError{Op: "access", Path: path, Err: err}
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package paths
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAccessModeString(t *testing.T) {
+	require.Equal(t, "---", AccessMode(0).String())
+	require.Equal(t, "r--", AccessRead.String())
+	require.Equal(t, "rw-", (AccessRead | AccessWrite).String())
+	require.Equal(t, "rwx", (AccessRead | AccessWrite | AccessExec).String())
+}
+
+func TestAccess(t *testing.T) {
+	folder := t.TempDir()
+
+	t.Run("not existed file", func(t *testing.T) {
+		file := filepath.Join(folder, "not-existed-file")
+		require.ErrorIs(t, Access(file, AccessRead), os.ErrNotExist)
+		require.False(t, CanRead(file))
+		require.False(t, CanWrite(file))
+		require.False(t, CanExec(file))
+	})
+
+	t.Run("read write file", func(t *testing.T) {
+		file := filepath.Join(folder, "rw-file")
+		require.NoError(t, os.WriteFile(file, []byte("hello"), 0o644))
+		require.NoError(t, Access(file, AccessRead|AccessWrite))
+		require.True(t, CanRead(file))
+		require.True(t, CanWrite(file))
+		if runtime.GOOS != "windows" {
+			require.False(t, CanExec(file))
+		}
+	})
+
+	t.Run("directory", func(t *testing.T) {
+		require.True(t, CanRead(folder))
+		require.True(t, CanWrite(folder))
+		require.True(t, CanExec(folder))
+	})
+
+	t.Run("no permission file", func(t *testing.T) {
+		if runtime.GOOS == "windows" {
+			t.Skip("permission bits are not supported on windows")
+		}
+		file := filepath.Join(folder, "no-permission-file")
+		require.NoError(t, os.WriteFile(file, []byte("hello"), 0o000))
+		require.NoError(t, os.Chmod(file, 0o000))
+		require.False(t, CanExec(file))
+		require.ErrorIs(t, Access(file, AccessExec), os.ErrPermission)
+		if os.Geteuid() == 0 {
+			// root is allowed to read and write any file
+			require.True(t, CanRead(file))
+			require.True(t, CanWrite(file))
+			return
+		}
+		require.False(t, CanRead(file))
+		require.False(t, CanWrite(file))
+		require.ErrorIs(t, Access(file, AccessRead), os.ErrPermission)
+	})
+}
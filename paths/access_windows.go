@@ -0,0 +1,47 @@
+//go:build windows
+
+package paths
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// access has no faccessat on windows, so it attempts the operations instead:
+// read is checked by opening the file, write by checking the read-only attribute
+// and execute by the file extension.
+func access(path string, mode AccessMode) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if mode&AccessRead != 0 {
+		fd, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		_ = fd.Close()
+	}
+	if mode&AccessWrite != 0 && !info.IsDir() && info.Mode().Perm()&0o200 == 0 {
+		return &os.PathError{Op: "access", Path: path, Err: os.ErrPermission}
+	}
+	if mode&AccessExec != 0 && !info.IsDir() && !hasExecExtension(path) {
+		return &os.PathError{Op: "access", Path: path, Err: os.ErrPermission}
+	}
+	return nil
+}
+
+// hasExecExtension reports whether the path ends with one of the PATHEXT extensions.
+func hasExecExtension(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	if ext == "" {
+		return false
+	}
+	for _, e := range pathExtensions() {
+		if e == ext {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,93 @@
+package paths
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/stkali/utility/errors"
+)
+
+// TimeField selects which timestamp of a file is used to compute its age.
+type TimeField int
+
+const (
+	// ModTime uses the modification time of the file.
+	ModTime TimeField = iota
+	// CreatedTime uses the creation time of the file, see GetFdCreated.
+	CreatedTime
+)
+
+// FindOlderThan walks the tree rooted at root and returns the regular files whose
+// modification time is older than age, sorted oldest first.
+// If filter is not nil, only the paths for which it returns true are returned.
+func FindOlderThan(root string, age time.Duration, filter func(path string) bool) ([]string, error) {
+	return FindOlderThanBy(root, age, ModTime, filter)
+}
+
+// FindOlderThanBy is like FindOlderThan, but uses the timestamp selected by field.
+func FindOlderThanBy(root string, age time.Duration, field TimeField, filter func(path string) bool) ([]string, error) {
+	expired := time.Now().Add(-age)
+	type fileTime struct {
+		path string
+		time time.Time
+	}
+	var files []fileTime
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() || (filter != nil && !filter(path)) {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			// the file was removed after listing
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		t := info.ModTime()
+		if field == CreatedTime {
+			t = GetFdCreated(info)
+		}
+		if t.Before(expired) {
+			files = append(files, fileTime{path: path, time: t})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to walk directory: %q", root)
+	}
+	sort.SliceStable(files, func(i, j int) bool {
+		return files[i].time.Before(files[j].time)
+	})
+	paths := make([]string, len(files))
+	for index := range files {
+		paths[index] = files[index].path
+	}
+	return paths, nil
+}
+
+// RemoveOlderThan removes the files returned by FindOlderThan and returns them.
+// If dryRun is true, nothing is removed and the files that would be removed are returned.
+// A failure to remove one file does not stop the others from being removed, all
+// the failures are joined into the returned error.
+func RemoveOlderThan(root string, age time.Duration, filter func(path string) bool, dryRun bool) ([]string, error) {
+	files, err := FindOlderThan(root, age, filter)
+	if err != nil || dryRun {
+		return files, err
+	}
+	removed := make([]string, 0, len(files))
+	for _, file := range files {
+		if rmErr := os.Remove(file); rmErr != nil {
+			err = errors.Join(err, rmErr)
+			continue
+		}
+		removed = append(removed, file)
+	}
+	return removed, err
+}
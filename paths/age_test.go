@@ -0,0 +1,75 @@
+package paths
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// createAgedFile creates file and sets its modification time to now - age.
+func createAgedFile(t *testing.T, file string, age time.Duration) {
+	require.NoError(t, os.MkdirAll(filepath.Dir(file), 0o755))
+	require.NoError(t, os.WriteFile(file, []byte(file), 0o644))
+	modTime := time.Now().Add(-age)
+	require.NoError(t, os.Chtimes(file, modTime, modTime))
+}
+
+func TestFindOlderThan(t *testing.T) {
+	root := t.TempDir()
+	day := 24 * time.Hour
+	oldest := filepath.Join(root, "a", "oldest.log")
+	older := filepath.Join(root, "older.log")
+	old := filepath.Join(root, "b", "c", "old.txt")
+	fresh := filepath.Join(root, "fresh.log")
+	createAgedFile(t, old, 31*day)
+	createAgedFile(t, oldest, 60*day)
+	createAgedFile(t, fresh, time.Hour)
+	createAgedFile(t, older, 40*day)
+
+	files, err := FindOlderThan(root, 30*day, nil)
+	require.NoError(t, err)
+	require.Equal(t, []string{oldest, older, old}, files)
+
+	files, err = FindOlderThan(root, 30*day, func(path string) bool {
+		return strings.HasSuffix(path, ".log")
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{oldest, older}, files)
+
+	files, err = FindOlderThan(root, 100*day, nil)
+	require.NoError(t, err)
+	require.Empty(t, files)
+
+	// all the files were just created
+	files, err = FindOlderThanBy(root, day, CreatedTime, nil)
+	require.NoError(t, err)
+	require.Empty(t, files)
+
+	_, err = FindOlderThan(filepath.Join(root, "not-existed"), day, nil)
+	require.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestRemoveOlderThan(t *testing.T) {
+	root := t.TempDir()
+	day := 24 * time.Hour
+	old := filepath.Join(root, "old.log")
+	fresh := filepath.Join(root, "fresh.log")
+	createAgedFile(t, old, 31*day)
+	createAgedFile(t, fresh, time.Hour)
+
+	// dry run
+	files, err := RemoveOlderThan(root, 30*day, nil, true)
+	require.NoError(t, err)
+	require.Equal(t, []string{old}, files)
+	require.True(t, IsExisted(old))
+
+	files, err = RemoveOlderThan(root, 30*day, nil, false)
+	require.NoError(t, err)
+	require.Equal(t, []string{old}, files)
+	require.False(t, IsExisted(old))
+	require.True(t, IsExisted(fresh))
+}
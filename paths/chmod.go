@@ -0,0 +1,102 @@
+package paths
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/stkali/utility/errors"
+)
+
+// RecursiveOption configures ChmodRWith and ChownRWith.
+type RecursiveOption struct {
+	// Filter, if not nil, is called for every entry, an entry for which it returns
+	// false is left unchanged, and so is the tree below it for a directory.
+	Filter func(path string, d fs.DirEntry) bool
+	// DryRun returns the paths that would change without changing anything.
+	DryRun bool
+}
+
+// ChmodR changes the permission bits of the directories to dirPerm and of the other
+// files to filePerm, recursively from root. Symbolic links are not followed nor
+// changed. A failure on one entry does not stop the walk, all the failures are
+// joined into the returned error.
+func ChmodR(root string, dirPerm, filePerm os.FileMode) error {
+	_, err := ChmodRWith(root, dirPerm, filePerm, RecursiveOption{})
+	return err
+}
+
+// ChmodRWith is like ChmodR and returns the paths whose permission bits changed.
+func ChmodRWith(root string, dirPerm, filePerm os.FileMode, opt RecursiveOption) ([]string, error) {
+	return walkChange(root, opt, func(path string, d fs.DirEntry, info os.FileInfo) (bool, error) {
+		if d.Type()&os.ModeSymlink != 0 {
+			return false, nil
+		}
+		perm := filePerm
+		if d.IsDir() {
+			perm = dirPerm
+		}
+		if info.Mode().Perm() == perm.Perm() {
+			return false, nil
+		}
+		if opt.DryRun {
+			return true, nil
+		}
+		return true, os.Chmod(path, perm)
+	})
+}
+
+// ChownR changes the owner of every entry to uid and gid recursively from root,
+// a uid or gid of -1 is not changed. Symbolic links are changed themselves, not
+// followed. A failure on one entry does not stop the walk, all the failures are
+// joined into the returned error.
+func ChownR(root string, uid, gid int) error {
+	_, err := ChownRWith(root, uid, gid, RecursiveOption{})
+	return err
+}
+
+// ChownRWith is like ChownR and returns the paths whose owner changed.
+func ChownRWith(root string, uid, gid int, opt RecursiveOption) ([]string, error) {
+	return walkChange(root, opt, func(path string, d fs.DirEntry, info os.FileInfo) (bool, error) {
+		curUid, curGid := getFileOwner(info)
+		if (uid == -1 || uid == curUid) && (gid == -1 || gid == curGid) {
+			return false, nil
+		}
+		if opt.DryRun {
+			return true, nil
+		}
+		return true, os.Lchown(path, uid, gid)
+	})
+}
+
+// walkChange walks root and calls change for every entry accepted by the filter,
+// it collects the changed paths and joins the errors.
+func walkChange(root string, opt RecursiveOption, change func(path string, d fs.DirEntry, info os.FileInfo) (bool, error)) ([]string, error) {
+	var changed []string
+	var errs error
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			errs = errors.Join(errs, err)
+			return nil
+		}
+		if opt.Filter != nil && !opt.Filter(path, d) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			errs = errors.Join(errs, err)
+			return nil
+		}
+		ok, err := change(path, d, info)
+		if err != nil {
+			errs = errors.Join(errs, errors.Wrapf(err, "failed to change %q", path))
+		} else if ok {
+			changed = append(changed, path)
+		}
+		return nil
+	})
+	return changed, errors.Join(errs, err)
+}
@@ -0,0 +1,97 @@
+package paths
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestChmodR(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("permission bits are not supported on windows")
+	}
+	root := t.TempDir()
+	writeTree(t, root, map[string]string{"a.log": "a", "sub/b.log": "b", "skip/c.log": "c"})
+	require.NoError(t, os.Symlink(filepath.Join(root, "a.log"), filepath.Join(root, "link")))
+	require.NoError(t, os.Chmod(root, 0o755))
+	require.NoError(t, os.Chmod(filepath.Join(root, "sub"), 0o700))
+
+	perm := func(name string) os.FileMode {
+		info, err := os.Lstat(filepath.Join(root, name))
+		require.NoError(t, err)
+		return info.Mode().Perm()
+	}
+	opt := RecursiveOption{
+		Filter: func(path string, d fs.DirEntry) bool {
+			return d.Name() != "skip"
+		},
+		DryRun: true,
+	}
+	changed, err := ChmodRWith(root, 0o750, 0o640, opt)
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		root,
+		filepath.Join(root, "a.log"),
+		filepath.Join(root, "sub"),
+		filepath.Join(root, "sub", "b.log"),
+	}, changed)
+	require.Equal(t, os.FileMode(0o644), perm("a.log"))
+
+	opt.DryRun = false
+	changed, err = ChmodRWith(root, 0o750, 0o640, opt)
+	require.NoError(t, err)
+	require.Len(t, changed, 4)
+	require.Equal(t, os.FileMode(0o750), perm("."))
+	require.Equal(t, os.FileMode(0o640), perm("a.log"))
+	require.Equal(t, os.FileMode(0o750), perm("sub"))
+	require.Equal(t, os.FileMode(0o640), perm(filepath.Join("sub", "b.log")))
+	require.Equal(t, os.FileMode(0o644), perm(filepath.Join("skip", "c.log")))
+
+	// unchanged
+	changed, err = ChmodRWith(root, 0o750, 0o640, opt)
+	require.NoError(t, err)
+	require.Empty(t, changed)
+
+	require.NoError(t, ChmodR(root, 0o755, 0o644))
+	require.Equal(t, os.FileMode(0o644), perm("a.log"))
+
+	require.ErrorIs(t, ChmodR(filepath.Join(root, "not-existed"), 0o755, 0o644), os.ErrNotExist)
+}
+
+func TestChownR(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("chown is not supported on windows")
+	}
+	root := t.TempDir()
+	writeTree(t, root, map[string]string{"a.log": "a", "sub/b.log": "b"})
+	require.NoError(t, os.Symlink(filepath.Join(root, "a.log"), filepath.Join(root, "link")))
+
+	// changing to the current owner is a no-op
+	changed, err := ChownRWith(root, os.Getuid(), os.Getgid(), RecursiveOption{})
+	require.NoError(t, err)
+	require.Empty(t, changed)
+	require.NoError(t, ChownR(root, -1, -1))
+
+	other := os.Getuid() + 1
+	changed, err = ChownRWith(root, other, -1, RecursiveOption{DryRun: true})
+	require.NoError(t, err)
+	require.Len(t, changed, 5)
+
+	changed, err = ChownRWith(root, other, -1, RecursiveOption{})
+	if os.Geteuid() != 0 {
+		// only root is allowed to give files away, every failure is reported
+		require.ErrorIs(t, err, os.ErrPermission)
+		require.Empty(t, changed)
+		return
+	}
+	require.NoError(t, err)
+	require.Len(t, changed, 5)
+	info, err := os.Lstat(filepath.Join(root, "link"))
+	require.NoError(t, err)
+	uid, _ := getFileOwner(info)
+	require.Equal(t, other, uid)
+}
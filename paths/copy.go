@@ -0,0 +1,52 @@
+package paths
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/stkali/utility/errors"
+)
+
+// CopyFile copies the content of the regular file src to dst, creating the directory
+// of dst if needed. The permission bits and the modification time of src are kept.
+// The content is written to a temporary file next to dst first and renamed onto
+// dst, so dst is never left partially written.
+func CopyFile(src, dst string) (err error) {
+	in, err := os.Open(src)
+	if err != nil {
+		return errors.Wrapf(err, "failed to open source file: %q", src)
+	}
+	defer in.Close()
+	info, err := in.Stat()
+	if err != nil {
+		return errors.Wrapf(err, "failed to stat source file: %q", src)
+	}
+	if !info.Mode().IsRegular() {
+		return errors.Newf("%q is not a regular file: %s", src, InvalidPathError)
+	}
+	if err = osMakeAll(filepath.Dir(dst), os.ModePerm); err != nil {
+		return errors.Wrapf(err, "failed to create directory of %q", dst)
+	}
+	out, cleanup, err := TempFileNear(dst, ".copy-*")
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+	if _, err = io.Copy(out, in); err != nil {
+		return errors.Wrapf(err, "failed to copy %q to %q", src, dst)
+	}
+	if err = out.Chmod(info.Mode().Perm()); err != nil {
+		return errors.Wrapf(err, "failed to chmod %q", out.Name())
+	}
+	if err = out.Close(); err != nil {
+		return errors.Wrapf(err, "failed to close %q", out.Name())
+	}
+	if err = os.Chtimes(out.Name(), info.ModTime(), info.ModTime()); err != nil {
+		return errors.Wrapf(err, "failed to set times of %q", out.Name())
+	}
+	if err = os.Rename(out.Name(), dst); err != nil {
+		return errors.Wrapf(err, "failed to rename %q to %q", out.Name(), dst)
+	}
+	return nil
+}
@@ -0,0 +1,38 @@
+package paths
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCopyFile(t *testing.T) {
+	folder := t.TempDir()
+	src := filepath.Join(folder, "src.log")
+	require.NoError(t, os.WriteFile(src, []byte("hello world"), 0o640))
+	modTime := time.Now().Add(-time.Hour).Truncate(time.Second)
+	require.NoError(t, os.Chtimes(src, modTime, modTime))
+
+	dst := filepath.Join(folder, "sub", "dir", "dst.log")
+	require.NoError(t, CopyFile(src, dst))
+	content, err := os.ReadFile(dst)
+	require.NoError(t, err)
+	require.Equal(t, "hello world", string(content))
+	info, err := os.Stat(dst)
+	require.NoError(t, err)
+	require.True(t, modTime.Equal(info.ModTime()))
+	require.Equal(t, os.FileMode(0o640), info.Mode().Perm())
+
+	// overwrite
+	require.NoError(t, os.WriteFile(src, []byte("new"), 0o640))
+	require.NoError(t, CopyFile(src, dst))
+	content, err = os.ReadFile(dst)
+	require.NoError(t, err)
+	require.Equal(t, "new", string(content))
+
+	require.ErrorIs(t, CopyFile(filepath.Join(folder, "not-existed"), dst), os.ErrNotExist)
+	require.ErrorIs(t, CopyFile(folder, dst), InvalidPathError)
+}
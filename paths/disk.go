@@ -0,0 +1,25 @@
+package paths
+
+// Usage is the space of the file system holding a path, in bytes.
+type Usage struct {
+	// Total is the size of the file system.
+	Total uint64
+	// Free is the free space, including the blocks reserved for the super-user.
+	Free uint64
+	// Available is the free space usable by the current user, it is less than Free if
+	// the file system reserves blocks.
+	Available uint64
+}
+
+// Used returns the used space, Total - Free.
+func (u Usage) Used() uint64 {
+	return u.Total - u.Free
+}
+
+// DiskUsage returns the space of the file system holding path, which must exist.
+func DiskUsage(path string) (Usage, error) {
+	if path == "" {
+		return Usage{}, InvalidPathError
+	}
+	return diskUsage(path)
+}
@@ -0,0 +1,26 @@
+package paths
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiskUsage(t *testing.T) {
+	dir := t.TempDir()
+	usage, err := DiskUsage(dir)
+	require.NoError(t, err)
+	require.Greater(t, usage.Total, uint64(0))
+	require.LessOrEqual(t, usage.Free, usage.Total)
+	require.LessOrEqual(t, usage.Available, usage.Free)
+	require.Equal(t, usage.Total-usage.Free, usage.Used())
+
+	_, err = DiskUsage("")
+	require.ErrorIs(t, err, InvalidPathError)
+
+	_, err = DiskUsage(filepath.Join(dir, "not-existed"))
+	require.ErrorIs(t, err, os.ErrNotExist)
+	require.ErrorContains(t, err, "failed to get disk usage")
+}
@@ -0,0 +1,23 @@
+//go:build linux || darwin
+
+package paths
+
+import (
+	"syscall"
+
+	"github.com/stkali/utility/errors"
+)
+
+// diskUsage returns the space of the file system holding path through statfs(2).
+func diskUsage(path string) (Usage, error) {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(path, &st); err != nil {
+		return Usage{}, errors.Wrapf(err, "failed to get disk usage of %q", path)
+	}
+	size := uint64(st.Bsize)
+	return Usage{
+		Total:     st.Blocks * size,
+		Free:      st.Bfree * size,
+		Available: st.Bavail * size,
+	}, nil
+}
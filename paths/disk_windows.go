@@ -0,0 +1,31 @@
+//go:build windows
+
+package paths
+
+import (
+	"syscall"
+	"unsafe"
+
+	"github.com/stkali/utility/errors"
+)
+
+var getDiskFreeSpaceEx = syscall.NewLazyDLL("kernel32.dll").NewProc("GetDiskFreeSpaceExW")
+
+// diskUsage returns the space of the volume holding path through GetDiskFreeSpaceExW.
+func diskUsage(path string) (Usage, error) {
+	p, err := syscall.UTF16PtrFromString(path)
+	if err != nil {
+		return Usage{}, errors.Wrapf(err, "failed to get disk usage of %q", path)
+	}
+	var available, total, free uint64
+	ret, _, err := getDiskFreeSpaceEx.Call(
+		uintptr(unsafe.Pointer(p)),
+		uintptr(unsafe.Pointer(&available)),
+		uintptr(unsafe.Pointer(&total)),
+		uintptr(unsafe.Pointer(&free)),
+	)
+	if ret == 0 {
+		return Usage{}, errors.Wrapf(err, "failed to get disk usage of %q", path)
+	}
+	return Usage{Total: total, Free: free, Available: available}, nil
+}
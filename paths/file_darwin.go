@@ -8,8 +8,32 @@ import (
 	"time"
 )
 
+// caseInsensitive reports whether paths are compared case-insensitively,
+// the default APFS and HFS+ volumes are case-insensitive.
+const caseInsensitive = true
+
 // GetFdCreated get the creation time of the file through the fd *os.FileInfo.
 func GetFdCreated(fd os.FileInfo) time.Time {
 	st := fd.Sys().(*syscall.Stat_t)
 	return time.Unix(st.Ctimespec.Sec, st.Ctimespec.Nsec)
 }
+
+// getFileID returns the inode number of the file, it identifies the file
+// across renames.
+func getFileID(fd os.FileInfo) uint64 {
+	if st, ok := fd.Sys().(*syscall.Stat_t); ok {
+		return uint64(st.Ino)
+	}
+	return 0
+}
+
+// getFileOwner returns the user and group IDs owning the file.
+func getFileOwner(fd os.FileInfo) (uid, gid int) {
+	if st, ok := fd.Sys().(*syscall.Stat_t); ok {
+		return int(st.Uid), int(st.Gid)
+	}
+	return -1, -1
+}
+
+// errorCrossDevice is the failure of a rename across devices.
+const errorCrossDevice = syscall.EXDEV
@@ -7,8 +7,25 @@ import (
 	"time"
 )
 
+// caseInsensitive reports whether paths are compared case-insensitively,
+// NTFS and FAT volumes are case-insensitive.
+const caseInsensitive = true
+
 // GetFdCreated get the creation time of the file through the fd *os.FileInfo.
 func GetFdCreated(fd os.FileInfo) time.Time {
 	st := fd.Sys().(*syscall.Win32FileAttributeData)
 	return time.Unix(st.CreationTime.Nanoseconds()/1e9, 0)
 }
+
+// getFileID returns 0, the file index of windows is not exposed by os.FileInfo.
+func getFileID(fd os.FileInfo) uint64 {
+	return 0
+}
+
+// getFileOwner returns -1, files are not owned by numeric IDs on windows.
+func getFileOwner(fd os.FileInfo) (uid, gid int) {
+	return -1, -1
+}
+
+// errorCrossDevice is ERROR_NOT_SAME_DEVICE, the failure of a rename across volumes.
+const errorCrossDevice syscall.Errno = 17
@@ -0,0 +1,83 @@
+package paths
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/stkali/utility/errors"
+)
+
+// fsyncDir syncs the directory dir, for testing.
+var fsyncDir = syncDirectory
+
+// MakeFileSync opens file with flag and perm like OpenFile, the missing directories are
+// created with os.ModePerm, see MakeFileSyncPerm.
+func MakeFileSync(file string, flag int, perm os.FileMode) (*os.File, error) {
+	return MakeFileSyncPerm(file, flag, perm, os.ModePerm)
+}
+
+// MakeFileSyncPerm opens file with flag and perm, the missing directories are created
+// with dirPerm before umask. After the file is opened, the directory of the file, every
+// directory created and the existing parent of the topmost one are synced, the deepest
+// first, so that the entries of the new directories and of the file survive a crash. The
+// directories aren't synced on Windows.
+func MakeFileSyncPerm(file string, flag int, perm, dirPerm os.FileMode) (*os.File, error) {
+	created, err := mkdirAll(filepath.Dir(file), dirPerm)
+	if err != nil {
+		return nil, err
+	}
+	fd, err := os.OpenFile(file, flag, perm)
+	if err != nil {
+		return nil, err
+	}
+	dirs := []string{filepath.Dir(file)}
+	for i := len(created) - 1; i >= 0; i-- {
+		if created[i] != dirs[len(dirs)-1] {
+			dirs = append(dirs, created[i])
+		}
+	}
+	if len(created) > 0 {
+		dirs = append(dirs, filepath.Dir(created[0]))
+	}
+	for _, dir := range dirs {
+		if err = fsyncDir(dir); err != nil {
+			fd.Close()
+			return nil, err
+		}
+	}
+	return fd, nil
+}
+
+// mkdirAll creates the directory dir and its missing parents with perm, it returns the
+// directories it created, the topmost first.
+func mkdirAll(dir string, perm os.FileMode) ([]string, error) {
+	var missing []string
+	for path := dir; ; path = filepath.Dir(path) {
+		info, err := os.Stat(path)
+		if err == nil {
+			if !info.IsDir() {
+				return nil, errors.Newf("failed to create directory: %q: %s is not a directory", dir, path)
+			}
+			break
+		}
+		if !os.IsNotExist(err) {
+			return nil, errors.Wrapf(err, "failed to create directory: %q", dir)
+		}
+		missing = append(missing, path)
+		if parent := filepath.Dir(path); parent == path {
+			break
+		}
+	}
+	var created []string
+	for i := len(missing) - 1; i >= 0; i-- {
+		if err := os.Mkdir(missing[i], perm); err != nil {
+			// created meanwhile by another process
+			if os.IsExist(err) {
+				continue
+			}
+			return created, errors.Wrapf(err, "failed to create directory: %q", dir)
+		}
+		created = append(created, missing[i])
+	}
+	return created, nil
+}
@@ -0,0 +1,77 @@
+package paths
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// countSyncs records the directories synced until the end of the test.
+func countSyncs(t *testing.T) *[]string {
+	var synced []string
+	old := fsyncDir
+	fsyncDir = func(dir string) error {
+		synced = append(synced, dir)
+		return nil
+	}
+	t.Cleanup(func() { fsyncDir = old })
+	return &synced
+}
+
+func TestMakeFileSync(t *testing.T) {
+	folder := t.TempDir()
+
+	cases := []struct {
+		name   string
+		file   string
+		synced []string
+	}{
+		{"existing directory", "app.log", []string{""}},
+		{"new directories", "a/b/c/app.log", []string{"a/b/c", "a/b", "a", ""}},
+		// only the existing parent of the topmost new directory is synced
+		{"partly existing", "a/b/d/app.log", []string{"a/b/d", "a/b"}},
+		{"existing file", "a/b/d/app.log", []string{"a/b/d"}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			synced := countSyncs(t)
+			fd, err := MakeFileSync(filepath.Join(folder, c.file), AppendFlag, 0o600)
+			require.NoError(t, err)
+			_, err = fd.WriteString("hello\n")
+			require.NoError(t, err)
+			require.NoError(t, fd.Close())
+			var expected []string
+			for _, dir := range c.synced {
+				expected = append(expected, filepath.Join(folder, dir))
+			}
+			require.Equal(t, expected, *synced)
+		})
+	}
+
+	t.Run("dir perm", func(t *testing.T) {
+		fd, err := MakeFileSyncPerm(filepath.Join(folder, "private", "app.log"), ExclusiveFlag, 0o600, 0o700)
+		require.NoError(t, err)
+		require.NoError(t, fd.Close())
+		info, err := os.Stat(filepath.Join(folder, "private"))
+		require.NoError(t, err)
+		require.Equal(t, os.FileMode(0o700), info.Mode().Perm())
+	})
+
+	t.Run("failures", func(t *testing.T) {
+		synced := countSyncs(t)
+		// a parent is a file
+		_, err := MakeFileSync(filepath.Join(folder, "app.log", "x", "app.log"), AppendFlag, 0o600)
+		require.Error(t, err)
+		// the file can't be opened
+		_, err = MakeFileSync(filepath.Join(folder, "e", "app.log"), os.O_RDONLY, 0o600)
+		require.ErrorIs(t, err, os.ErrNotExist)
+		require.Empty(t, *synced)
+
+		// the directory of the file can't be synced
+		fsyncDir = func(dir string) error { return os.ErrPermission }
+		_, err = MakeFileSync(filepath.Join(folder, "app.log"), AppendFlag, 0o600)
+		require.ErrorIs(t, err, os.ErrPermission)
+	})
+}
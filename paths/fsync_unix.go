@@ -0,0 +1,22 @@
+//go:build linux || darwin
+
+package paths
+
+import (
+	"os"
+
+	"github.com/stkali/utility/errors"
+)
+
+// syncDirectory syncs the directory dir, so that its entries survive a crash.
+func syncDirectory(dir string) error {
+	fd, err := os.Open(dir)
+	if err != nil {
+		return errors.Wrapf(err, "failed to open directory: %q", dir)
+	}
+	defer fd.Close()
+	if err = fd.Sync(); err != nil {
+		return errors.Wrapf(err, "failed to sync directory: %q", dir)
+	}
+	return nil
+}
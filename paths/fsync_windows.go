@@ -0,0 +1,8 @@
+//go:build windows
+
+package paths
+
+// syncDirectory does nothing, the directories can't be synced on Windows.
+func syncDirectory(dir string) error {
+	return nil
+}
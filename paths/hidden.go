@@ -0,0 +1,53 @@
+package paths
+
+import (
+	"io/fs"
+	"path/filepath"
+)
+
+// WalkOption configures Walk and Glob.
+type WalkOption struct {
+	// IncludeHidden reports whether hidden files and directories are visited,
+	// see IsHidden for what hidden means on each platform.
+	IncludeHidden bool
+}
+
+// Walk is like filepath.WalkDir, but skips hidden files and directories (and the
+// whole tree below a hidden directory) unless opt.IncludeHidden is set.
+// The root itself is always visited.
+func Walk(root string, opt WalkOption, fn fs.WalkDirFunc) error {
+	return filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err == nil && !opt.IncludeHidden && path != root {
+			hidden, hErr := IsHidden(path)
+			if hErr != nil {
+				return fn(path, d, hErr)
+			}
+			if hidden {
+				if d.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+		}
+		return fn(path, d, err)
+	})
+}
+
+// Glob is like filepath.Glob, but drops hidden matches unless opt.IncludeHidden is set.
+func Glob(pattern string, opt WalkOption) ([]string, error) {
+	matches, err := filepath.Glob(pattern)
+	if err != nil || opt.IncludeHidden {
+		return matches, err
+	}
+	visible := matches[:0]
+	for _, match := range matches {
+		hidden, err := IsHidden(match)
+		if err != nil {
+			return nil, err
+		}
+		if !hidden {
+			visible = append(visible, match)
+		}
+	}
+	return visible, nil
+}
@@ -0,0 +1,61 @@
+package paths
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// createHiddenTree creates a tree with a hidden file and a hidden directory in root.
+func createHiddenTree(t *testing.T, root string) {
+	for _, name := range []string{"visible.log", "dir/visible.log", "file.log", "hidden-dir/inner.log"} {
+		file := filepath.Join(root, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(file), 0o755))
+		require.NoError(t, os.WriteFile(file, nil, 0o644))
+	}
+	_, err := Hide(filepath.Join(root, "file.log"))
+	require.NoError(t, err)
+	_, err = Hide(filepath.Join(root, "hidden-dir"))
+	require.NoError(t, err)
+}
+
+func TestWalk(t *testing.T) {
+	root := t.TempDir()
+	createHiddenTree(t, root)
+
+	walk := func(opt WalkOption) []string {
+		var files []string
+		err := Walk(root, opt, func(path string, d fs.DirEntry, err error) error {
+			require.NoError(t, err)
+			if !d.IsDir() {
+				files = append(files, filepath.Base(path))
+			}
+			return nil
+		})
+		require.NoError(t, err)
+		sort.Strings(files)
+		return files
+	}
+	require.Equal(t, []string{"visible.log", "visible.log"}, walk(WalkOption{}))
+	require.Len(t, walk(WalkOption{IncludeHidden: true}), 4)
+}
+
+func TestGlob(t *testing.T) {
+	root := t.TempDir()
+	createHiddenTree(t, root)
+
+	matches, err := Glob(filepath.Join(root, "*"), WalkOption{})
+	require.NoError(t, err)
+	require.Equal(t, []string{filepath.Join(root, "dir"), filepath.Join(root, "visible.log")}, matches)
+
+	matches, err = Glob(filepath.Join(root, "*"), WalkOption{IncludeHidden: true})
+	require.NoError(t, err)
+	require.Len(t, matches, 4)
+
+	_, err = Glob("[", WalkOption{})
+	require.ErrorIs(t, err, filepath.ErrBadPattern)
+}
@@ -0,0 +1,59 @@
+//go:build !windows
+
+package paths
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/stkali/utility/errors"
+)
+
+// IsHidden reports whether the file is hidden, on unix a file is hidden when its
+// name starts with a dot. The check only looks at the name, the file does not need to exist.
+func IsHidden(path string) (bool, error) {
+	if path == "" {
+		return false, InvalidPathError
+	}
+	name := filepath.Base(path)
+	return len(name) > 1 && name[0] == '.' && name != "..", nil
+}
+
+// Hide hides the file by renaming it to a dot-prefixed name and returns the new path.
+// It does nothing if the file is already hidden.
+func Hide(path string) (string, error) {
+	hidden, err := IsHidden(path)
+	if err != nil || hidden {
+		return path, err
+	}
+	dir, name := filepath.Split(path)
+	return rename(path, filepath.Join(dir, "."+name))
+}
+
+// Unhide removes the leading dots from the name of the file and returns the new path.
+// It does nothing if the file is not hidden.
+func Unhide(path string) (string, error) {
+	hidden, err := IsHidden(path)
+	if err != nil || !hidden {
+		return path, err
+	}
+	dir, name := filepath.Split(path)
+	for len(name) > 0 && name[0] == '.' {
+		name = name[1:]
+	}
+	if name == "" {
+		return path, errors.Newf("cannot unhide %q, the name only contains dots: %s", path, InvalidPathError)
+	}
+	return rename(path, filepath.Join(dir, name))
+}
+
+// rename renames src to dst, it refuses to overwrite an existing dst.
+func rename(src, dst string) (string, error) {
+	if _, err := os.Lstat(dst); err == nil {
+		return src, errors.Newf("failed to rename %q, %q already exists: %s", src, dst, os.ErrExist)
+	}
+	if err := os.Rename(src, dst); err != nil {
+		return src, errors.Wrapf(err, "failed to rename %q to %q", src, dst)
+	}
+	return dst, nil
+}
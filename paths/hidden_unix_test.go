@@ -0,0 +1,72 @@
+//go:build !windows
+
+package paths
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsHidden(t *testing.T) {
+	cases := []struct {
+		path   string
+		hidden bool
+	}{
+		{".bashrc", true},
+		{"/home/user/.config", true},
+		{"/home/user/.config/app.toml", false},
+		{"file.log", false},
+		{".", false},
+		{"..", false},
+		{"/", false},
+		{"dir/...", true},
+	}
+	for _, c := range cases {
+		t.Run(c.path, func(t *testing.T) {
+			hidden, err := IsHidden(c.path)
+			require.NoError(t, err)
+			require.Equal(t, c.hidden, hidden)
+		})
+	}
+	_, err := IsHidden("")
+	require.ErrorIs(t, err, InvalidPathError)
+}
+
+func TestHide(t *testing.T) {
+	folder := t.TempDir()
+	file := filepath.Join(folder, "file.log")
+	require.NoError(t, os.WriteFile(file, nil, 0o644))
+
+	hiddenFile, err := Hide(file)
+	require.NoError(t, err)
+	require.Equal(t, filepath.Join(folder, ".file.log"), hiddenFile)
+	require.False(t, IsExisted(file))
+	require.True(t, IsExisted(hiddenFile))
+
+	// already hidden
+	again, err := Hide(hiddenFile)
+	require.NoError(t, err)
+	require.Equal(t, hiddenFile, again)
+
+	visibleFile, err := Unhide(hiddenFile)
+	require.NoError(t, err)
+	require.Equal(t, file, visibleFile)
+	require.True(t, IsExisted(file))
+
+	// not hidden
+	again, err = Unhide(visibleFile)
+	require.NoError(t, err)
+	require.Equal(t, visibleFile, again)
+
+	// collision
+	require.NoError(t, os.WriteFile(hiddenFile, nil, 0o644))
+	_, err = Hide(file)
+	require.ErrorIs(t, err, os.ErrExist)
+
+	// not existed
+	_, err = Hide(filepath.Join(folder, "not-existed"))
+	require.ErrorIs(t, err, os.ErrNotExist)
+}
@@ -0,0 +1,61 @@
+//go:build windows
+
+package paths
+
+import (
+	"syscall"
+
+	"github.com/stkali/utility/errors"
+)
+
+// IsHidden reports whether the file is hidden, on windows a file is hidden when
+// it has the FILE_ATTRIBUTE_HIDDEN attribute, so the file must exist.
+func IsHidden(path string) (bool, error) {
+	attrs, err := getFileAttributes(path)
+	if err != nil {
+		return false, err
+	}
+	return attrs&syscall.FILE_ATTRIBUTE_HIDDEN != 0, nil
+}
+
+// Hide sets the FILE_ATTRIBUTE_HIDDEN attribute of the file, the path is unchanged.
+func Hide(path string) (string, error) {
+	return path, setHidden(path, true)
+}
+
+// Unhide clears the FILE_ATTRIBUTE_HIDDEN attribute of the file, the path is unchanged.
+func Unhide(path string) (string, error) {
+	return path, setHidden(path, false)
+}
+
+func getFileAttributes(path string) (uint32, error) {
+	if path == "" {
+		return 0, InvalidPathError
+	}
+	p, err := syscall.UTF16PtrFromString(path)
+	if err != nil {
+		return 0, errors.Wrapf(err, "invalid path: %q", path)
+	}
+	attrs, err := syscall.GetFileAttributes(p)
+	if err != nil {
+		return 0, errors.Wrapf(err, "failed to get attributes of %q", path)
+	}
+	return attrs, nil
+}
+
+func setHidden(path string, hidden bool) error {
+	attrs, err := getFileAttributes(path)
+	if err != nil {
+		return err
+	}
+	if hidden {
+		attrs |= syscall.FILE_ATTRIBUTE_HIDDEN
+	} else {
+		attrs &^= syscall.FILE_ATTRIBUTE_HIDDEN
+	}
+	p, _ := syscall.UTF16PtrFromString(path)
+	if err = syscall.SetFileAttributes(p, attrs); err != nil {
+		return errors.Wrapf(err, "failed to set attributes of %q", path)
+	}
+	return nil
+}
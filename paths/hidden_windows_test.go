@@ -0,0 +1,44 @@
+//go:build windows
+
+package paths
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsHidden(t *testing.T) {
+	folder := t.TempDir()
+	file := filepath.Join(folder, ".dotfile")
+	require.NoError(t, os.WriteFile(file, nil, 0o644))
+
+	// a leading dot does not hide a file on windows
+	hidden, err := IsHidden(file)
+	require.NoError(t, err)
+	require.False(t, hidden)
+
+	_, err = IsHidden(filepath.Join(folder, "not-existed"))
+	require.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestHide(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "file.log")
+	require.NoError(t, os.WriteFile(file, nil, 0o644))
+
+	hiddenFile, err := Hide(file)
+	require.NoError(t, err)
+	require.Equal(t, file, hiddenFile)
+	hidden, err := IsHidden(file)
+	require.NoError(t, err)
+	require.True(t, hidden)
+
+	visibleFile, err := Unhide(file)
+	require.NoError(t, err)
+	require.Equal(t, file, visibleFile)
+	hidden, err = IsHidden(file)
+	require.NoError(t, err)
+	require.False(t, hidden)
+}
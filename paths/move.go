@@ -0,0 +1,46 @@
+package paths
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/stkali/utility/errors"
+)
+
+// osRename is replaced in tests to simulate a move across devices.
+var osRename = os.Rename
+
+// MoveFile moves the regular file src to dst, creating the directory of dst if needed.
+// It renames src if both are on the same device, otherwise it copies src to dst, see
+// CopyFile, and removes src.
+func MoveFile(src, dst string) error {
+	err := osRename(src, dst)
+	if err == nil {
+		return nil
+	}
+	if os.IsNotExist(err) && IsExisted(src) {
+		// the directory of dst is missing
+		if err = osMakeAll(filepath.Dir(dst), os.ModePerm); err != nil {
+			return errors.Wrapf(err, "failed to create directory of %q", dst)
+		}
+		err = osRename(src, dst)
+		if err == nil {
+			return nil
+		}
+	}
+	if !isCrossDevice(err) {
+		return errors.Wrapf(err, "failed to move %q to %q", src, dst)
+	}
+	if err = CopyFile(src, dst); err != nil {
+		return err
+	}
+	if err = os.Remove(src); err != nil {
+		return errors.Wrapf(err, "failed to remove moved file %q", src)
+	}
+	return nil
+}
+
+// isCrossDevice reports whether err is the failure of a rename across devices.
+func isCrossDevice(err error) bool {
+	return errors.Is(err, errorCrossDevice)
+}
@@ -0,0 +1,64 @@
+package paths
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMoveFile(t *testing.T) {
+	dir := t.TempDir()
+	modTime := time.Now().Add(-time.Hour).Truncate(time.Second)
+	newFile := func(t *testing.T) string {
+		file := filepath.Join(dir, "src-"+t.Name()[len("TestMoveFile/"):])
+		require.NoError(t, os.WriteFile(file, []byte("hello"), 0o600))
+		require.NoError(t, os.Chtimes(file, modTime, modTime))
+		return file
+	}
+	requireMoved := func(t *testing.T, src, dst string) {
+		require.NoFileExists(t, src)
+		content, err := os.ReadFile(dst)
+		require.NoError(t, err)
+		require.Equal(t, "hello", string(content))
+		info, err := os.Stat(dst)
+		require.NoError(t, err)
+		require.True(t, modTime.Equal(info.ModTime()))
+	}
+
+	t.Run("rename", func(t *testing.T) {
+		src := newFile(t)
+		dst := filepath.Join(dir, "rename")
+		require.NoError(t, MoveFile(src, dst))
+		requireMoved(t, src, dst)
+	})
+
+	t.Run("missing directory", func(t *testing.T) {
+		src := newFile(t)
+		dst := filepath.Join(dir, "a", "b", "missing")
+		require.NoError(t, MoveFile(src, dst))
+		requireMoved(t, src, dst)
+	})
+
+	t.Run("cross device", func(t *testing.T) {
+		calls := 0
+		osRename = func(oldpath, newpath string) error {
+			calls++
+			return &os.LinkError{Op: "rename", Old: oldpath, New: newpath, Err: errorCrossDevice}
+		}
+		defer func() { osRename = os.Rename }()
+		src := newFile(t)
+		dst := filepath.Join(dir, "cross", "device")
+		require.NoError(t, MoveFile(src, dst))
+		requireMoved(t, src, dst)
+		require.Equal(t, 1, calls)
+	})
+
+	t.Run("not existed", func(t *testing.T) {
+		err := MoveFile(filepath.Join(dir, "not-existed"), filepath.Join(dir, "dst"))
+		require.ErrorIs(t, err, os.ErrNotExist)
+		require.ErrorContains(t, err, "failed to move")
+	})
+}
@@ -18,21 +18,57 @@ import (
 
 var InvalidPathError = errors.Error("invalid path error")
 
+// AlreadyExistsError is returned by CreateExclusive when the file exists, it wraps the
+// os error so that errors.Is(err, os.ErrExist) holds too.
+var AlreadyExistsError = errors.Error("file already exists")
+
+const (
+	// AppendFlag opens a file for appending, it's created if it doesn't exist.
+	AppendFlag = os.O_CREATE | os.O_WRONLY | os.O_APPEND
+	// ExclusiveFlag creates a file for writing, the open fails if it exists.
+	ExclusiveFlag = os.O_CREATE | os.O_EXCL | os.O_WRONLY
+	// TruncateFlag opens a file for writing, it's created if it doesn't exist and
+	// truncated otherwise.
+	TruncateFlag = os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+)
+
 var (
-	onceUserHome sync.Once
-	userHome     string
+	userHomeMtx sync.Mutex
+	// userHome caches the user home path, a failure isn't cached so that it is retried
+	userHome string
 	// for test
-	osMakeAll = os.MkdirAll
+	osMakeAll     = os.MkdirAll
+	osUserHomeDir = os.UserHomeDir
 )
 
-// UserHome return current user home path string
+func init() {
+	errors.Register("paths.invalid_path", InvalidPathError)
+	errors.Register("paths.executable_not_found", ExecutableNotFoundError)
+	errors.Register("paths.outside_base", OutsideBaseError)
+	errors.Register("paths.already_exists", AlreadyExistsError)
+}
+
+// UserHomeE returns current user home path string, or an error if it can't be determined.
+func UserHomeE() (string, error) {
+	userHomeMtx.Lock()
+	defer userHomeMtx.Unlock()
+	if userHome != "" {
+		return userHome, nil
+	}
+	home, err := osUserHomeDir()
+	if err != nil {
+		return "", errors.Wrap(err, "failed to get user home")
+	}
+	userHome = home
+	return home, nil
+}
+
+// UserHome return current user home path string.
+// If it can't be determined, it writes a warning and returns an empty string, see UserHomeE.
 func UserHome() string {
-	onceUserHome.Do(func() {
-		var err error
-		userHome, err = os.UserHomeDir()
-		errors.CheckErr(err)
-	})
-	return userHome
+	home, err := UserHomeE()
+	errors.Check(err, "")
+	return home
 }
 
 // ToAbsPath convert any style path to posix  absolutely path
@@ -49,14 +85,18 @@ func abs(path string) (string, error) {
 	case "":
 		return "", InvalidPathError
 	case "~":
-		return UserHome(), nil
+		return UserHomeE()
 	case ".":
 		return os.Getwd()
 	}
 
 	path = filepath.Clean(path)
 	if strings.HasPrefix(path, "~/") {
-		path = UserHome() + path[1:]
+		home, err := UserHomeE()
+		if err != nil {
+			return "", err
+		}
+		path = home + path[1:]
 	}
 	if filepath.IsAbs(path) {
 		return path, nil
@@ -66,7 +106,8 @@ func abs(path string) (string, error) {
 }
 
 // Abs(path) returns the absolute path of the given path.
-// If failed to convert path to absolutely, it returns an error.
+// If failed to convert path to absolutely, e.g. "~" can't be expanded because the user
+// home is unknown, it returns an error.
 func Abs(path string) (string, error) {
 	return abs(path)
 }
@@ -75,7 +116,7 @@ func Abs(path string) (string, error) {
 func GetFileCreated(file string) (t time.Time, err error) {
 	info, err := os.Stat(file)
 	if err != nil {
-		return t, errors.Newf("failed to open file: %s, err: %s", file, err)
+		return t, errors.Wrapf(err, "failed to open file: %s", file)
 	}
 	return GetFdCreated(info), nil
 }
@@ -100,6 +141,44 @@ func SplitWithExt(path string) (string, string, string) {
 	return path[:i+1], path[i+1 : etxIndex], path[etxIndex:]
 }
 
+// PathParts contains the components of a path returned by SplitPath.
+type PathParts struct {
+	// Dir is the directory including the trailing separator, empty if the path has no directory.
+	Dir string
+	// Base is the last element of the path.
+	Base string
+	// Name is Base without Ext.
+	Name string
+	// Ext is the last extension of Base including the dot, e.g. ".gz" for "test.tar.gz".
+	Ext string
+	// FullExt is everything after the first dot of Base, e.g. ".tar.gz" for "test.tar.gz".
+	FullExt string
+	// IsDotfile reports whether Base starts with a dot.
+	IsDotfile bool
+}
+
+// SplitPath splits the path into its components. Unlike SplitWithExt, the leading
+// dot of a dotfile does not start an extension: ".bashrc" has no extension and
+// ".eslintrc.json" has the extension ".json".
+func SplitPath(path string) PathParts {
+	dir, base := filepath.Split(path)
+	parts := PathParts{Dir: dir, Base: base, Name: base}
+	stem := base
+	if len(base) > 0 && base[0] == '.' {
+		parts.IsDotfile = true
+		stem = strings.TrimLeft(base, ".")
+	}
+	offset := len(base) - len(stem)
+	if index := strings.IndexByte(stem, '.'); index != -1 {
+		parts.FullExt = base[offset+index:]
+	}
+	if index := strings.LastIndexByte(stem, '.'); index != -1 {
+		parts.Ext = base[offset+index:]
+		parts.Name = base[:offset+index]
+	}
+	return parts
+}
+
 // IsExisted checks if a file or directory exists at the given path.
 // It returns true if the path exists, false otherwise.
 func IsExisted(file string) bool {
@@ -116,7 +195,7 @@ func OpenFile(file string, flag int, perm os.FileMode) (fd *os.File, err error)
 			directory := filepath.Dir(file)
 			err = osMakeAll(directory, os.ModePerm)
 			if err != nil {
-				return nil, errors.Newf("failed to create directory: %q, err: %s", directory, err)
+				return nil, errors.Wrapf(err, "failed to create directory: %q", directory)
 			}
 			return os.OpenFile(file, flag, perm)
 		}
@@ -124,6 +203,23 @@ func OpenFile(file string, flag int, perm os.FileMode) (fd *os.File, err error)
 	return fd, err
 }
 
+// OpenAppend opens file for appending with AppendFlag, the file and its directory are
+// created if they don't exist, see OpenFile.
+func OpenAppend(file string, perm os.FileMode) (*os.File, error) {
+	return OpenFile(file, AppendFlag, perm)
+}
+
+// CreateExclusive creates file for writing with ExclusiveFlag, its directory is created
+// if it doesn't exist, see OpenFile. It returns AlreadyExistsError if the file exists,
+// e.g. for a lock file.
+func CreateExclusive(file string, perm os.FileMode) (*os.File, error) {
+	fd, err := OpenFile(file, ExclusiveFlag, perm)
+	if err != nil && os.IsExist(err) {
+		return nil, errors.Newf("%s: %s", AlreadyExistsError, err)
+	}
+	return fd, err
+}
+
 // Clear removes all files and directories in the specified directory.
 func Clear(dir string) error {
 	fs, err := os.ReadDir(dir)
@@ -1,6 +1,7 @@
 package paths
 
 import (
+	"github.com/stkali/utility/errors"
 	"github.com/stkali/utility/lib"
 	"github.com/stretchr/testify/require"
 	"os"
@@ -84,6 +85,9 @@ func TestGetFileCreated(t *testing.T) {
 	// get not existed file created time
 	_, err := GetFileCreated(testFile)
 	require.ErrorIs(t, err, os.ErrNotExist)
+	// the chain survives another level of wrapping
+	require.ErrorIs(t, errors.Wrap(err, "outer"), os.ErrNotExist)
+	require.Equal(t, errors.NotFound, errors.CodeOf(errors.Wrap(err, "outer")))
 
 	preTime := time.Now().Add(-100 * time.Millisecond)
 	f, err := os.Create(testFile)
@@ -109,6 +113,33 @@ func TestIsExisted(t *testing.T) {
 
 func TestUserHome(t *testing.T) {
 	require.Equal(t, homeDirectory, UserHome())
+	home, err := UserHomeE()
+	require.NoError(t, err)
+	require.Equal(t, homeDirectory, home)
+}
+
+func TestUserHomeFailure(t *testing.T) {
+	userHome = ""
+	osUserHomeDir = func() (string, error) {
+		return "", errors.Error("$HOME is not defined")
+	}
+	defer func() {
+		osUserHomeDir = os.UserHomeDir
+	}()
+
+	_, err := UserHomeE()
+	require.EqualError(t, err, "failed to get user home, err: $HOME is not defined")
+	// degrade to an empty string instead of exiting
+	require.Equal(t, "", UserHome())
+	// the ~ expansion propagates the error
+	for _, path := range []string{"~", "~/hello.go"} {
+		_, err = Abs(path)
+		require.EqualError(t, err, "failed to get user home, err: $HOME is not defined")
+	}
+
+	// the failure isn't cached
+	osUserHomeDir = os.UserHomeDir
+	require.Equal(t, homeDirectory, UserHome())
 }
 
 func TestToAbsPath(t *testing.T) {
@@ -186,6 +217,35 @@ func TestOpenFile(t *testing.T) {
 	require.ErrorIs(t, err, InvalidPathError)
 }
 
+func TestOpenAppend(t *testing.T) {
+	testDir := t.TempDir()
+	file := filepath.Join(testDir, "not-existed-dir", "append.log")
+	for _, content := range []string{"hello\n", "world\n"} {
+		fd, err := OpenAppend(file, 0o644)
+		require.NoError(t, err)
+		_, err = fd.WriteString(content)
+		require.NoError(t, err)
+		require.NoError(t, fd.Close())
+	}
+	content, err := os.ReadFile(file)
+	require.NoError(t, err)
+	require.Equal(t, "hello\nworld\n", string(content))
+}
+
+func TestCreateExclusive(t *testing.T) {
+	testDir := t.TempDir()
+	file := filepath.Join(testDir, "not-existed-dir", "app.lock")
+	fd, err := CreateExclusive(file, 0o600)
+	require.NoError(t, err)
+	require.NoError(t, fd.Close())
+
+	fd, err = CreateExclusive(file, 0o600)
+	require.ErrorIs(t, err, AlreadyExistsError)
+	require.ErrorIs(t, err, os.ErrExist)
+	require.Equal(t, "paths.already_exists", errors.CodeString(err))
+	require.Nil(t, fd)
+}
+
 func TestAbs(t *testing.T) {
 	t.Run("empty", func(t *testing.T) {
 		_, err := Abs("")
@@ -247,3 +307,35 @@ func TestClear(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, 0, len(files))
 }
+
+func TestSplitPath(t *testing.T) {
+	cases := []struct {
+		name   string
+		path   string
+		expect PathParts
+	}{
+		{"empty", "", PathParts{}},
+		{"no extension", "hello", PathParts{Base: "hello", Name: "hello"}},
+		{"extension", "file.txt", PathParts{Base: "file.txt", Name: "file", Ext: ".txt", FullExt: ".txt"}},
+		{"trailing dot", "file.", PathParts{Base: "file.", Name: "file", Ext: ".", FullExt: "."}},
+		{
+			"multi extension",
+			"file/test.tar.gz",
+			PathParts{Dir: "file/", Base: "test.tar.gz", Name: "test.tar", Ext: ".gz", FullExt: ".tar.gz"},
+		},
+		{"dotfile", ".bashrc", PathParts{Base: ".bashrc", Name: ".bashrc", IsDotfile: true}},
+		{"dotfile log", ".log", PathParts{Base: ".log", Name: ".log", IsDotfile: true}},
+		{
+			"dotfile with extension",
+			"/home/user/.eslintrc.json",
+			PathParts{Dir: "/home/user/", Base: ".eslintrc.json", Name: ".eslintrc", Ext: ".json", FullExt: ".json", IsDotfile: true},
+		},
+		{"dots only", "..", PathParts{Base: "..", Name: "..", IsDotfile: true}},
+		{"directory", "/var/log/", PathParts{Dir: "/var/log/"}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			require.Equal(t, c.expect, SplitPath(c.path))
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package paths
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/stkali/utility/errors"
+)
+
+var OutsideBaseError = errors.Error("path is outside of the base directory")
+
+// RelTo returns the clean relative path of target to base. Unlike filepath.Rel, it
+// returns OutsideBaseError instead of a "../x" path when target is not inside base.
+// Both paths are converted to absolute paths first, and on case-insensitive file
+// systems the comparison ignores case.
+func RelTo(base, target string) (string, error) {
+	base, target, err := absPair(base, target)
+	if err != nil {
+		return "", err
+	}
+	rel, ok := relTo(base, target, filepath.Separator, caseInsensitive)
+	if !ok {
+		return "", errors.Newf("%q is not inside %q: %s", target, base, OutsideBaseError)
+	}
+	return rel, nil
+}
+
+// Contains reports whether target is base itself or is located inside base.
+// The comparison is lexical, see ContainsResolved to follow symbolic links.
+func Contains(base, target string) (bool, error) {
+	base, target, err := absPair(base, target)
+	if err != nil {
+		return false, err
+	}
+	_, ok := relTo(base, target, filepath.Separator, caseInsensitive)
+	return ok, nil
+}
+
+// ContainsResolved is like Contains, but resolves symbolic links in both paths
+// first, so a link inside base pointing outside of it is not considered contained.
+// Both paths must exist.
+func ContainsResolved(base, target string) (bool, error) {
+	base, target, err := absPair(base, target)
+	if err != nil {
+		return false, err
+	}
+	resolvedBase, err := filepath.EvalSymlinks(base)
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to resolve base path: %q", base)
+	}
+	resolvedTarget, err := filepath.EvalSymlinks(target)
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to resolve target path: %q", target)
+	}
+	_, ok := relTo(resolvedBase, resolvedTarget, filepath.Separator, caseInsensitive)
+	return ok, nil
+}
+
+// SafeJoin joins any number of path elements to base and returns the absolute
+// result, or OutsideBaseError if the result escapes base through ".." elements.
+func SafeJoin(base string, elem ...string) (string, error) {
+	absBase, err := abs(base)
+	if err != nil {
+		return "", errors.Wrapf(err, "invalid base path: %q", base)
+	}
+	joined := filepath.Join(append([]string{absBase}, elem...)...)
+	if _, ok := relTo(absBase, joined, filepath.Separator, caseInsensitive); !ok {
+		return "", errors.Newf("%q is not inside %q: %s", joined, absBase, OutsideBaseError)
+	}
+	return joined, nil
+}
+
+// absPair converts base and target to absolute paths.
+func absPair(base, target string) (string, string, error) {
+	absBase, err := abs(base)
+	if err != nil {
+		return "", "", errors.Wrapf(err, "invalid base path: %q", base)
+	}
+	absTarget, err := abs(target)
+	if err != nil {
+		return "", "", errors.Wrapf(err, "invalid target path: %q", target)
+	}
+	return absBase, absTarget, nil
+}
+
+// relTo returns the relative path of target to base and whether target is inside base.
+// base and target must be clean absolute paths using sep as separator. When fold is
+// true, the paths (including windows drive letters) are compared case-insensitively.
+func relTo(base, target string, sep byte, fold bool) (string, bool) {
+	equal := func(a, b string) bool {
+		if fold {
+			return strings.EqualFold(a, b)
+		}
+		return a == b
+	}
+	// a root path such as "/" or `C:\` already ends with separator
+	if len(base) > 0 && base[len(base)-1] != sep {
+		if equal(base, target) {
+			return ".", true
+		}
+		base += string(sep)
+	}
+	if len(target) < len(base) || !equal(base, target[:len(base)]) {
+		return "", false
+	}
+	if rel := target[len(base):]; rel != "" {
+		return rel, true
+	}
+	return ".", true
+}
@@ -0,0 +1,121 @@
+package paths
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stkali/utility/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRelTo(t *testing.T) {
+	base := t.TempDir()
+	cases := []struct {
+		name   string
+		target string
+		expect string
+		ok     bool
+	}{
+		{"self", base, ".", true},
+		{"child", filepath.Join(base, "a"), "a", true},
+		{"grandchild", filepath.Join(base, "a", "b.log"), filepath.Join("a", "b.log"), true},
+		{"unclean child", base + "/a/../b/./c", filepath.Join("b", "c"), true},
+		{"parent", filepath.Dir(base), "", false},
+		{"sibling with same prefix", base + "-sibling", "", false},
+		{"escape", filepath.Join(base, "..", "x"), "", false},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			rel, err := RelTo(base, c.target)
+			contained, cErr := Contains(base, c.target)
+			require.NoError(t, cErr)
+			require.Equal(t, c.ok, contained)
+			if !c.ok {
+				require.ErrorIs(t, err, OutsideBaseError)
+				require.Equal(t, "paths.outside_base", errors.CodeString(err))
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, c.expect, rel)
+		})
+	}
+
+	_, err := RelTo("", base)
+	require.ErrorIs(t, err, InvalidPathError)
+	_, err = Contains(base, "")
+	require.ErrorIs(t, err, InvalidPathError)
+}
+
+func TestRelToWithStyle(t *testing.T) {
+	cases := []struct {
+		name   string
+		base   string
+		target string
+		sep    byte
+		fold   bool
+		expect string
+		ok     bool
+	}{
+		{"posix root", "/", "/var/log", '/', false, "var/log", true},
+		{"posix case-sensitive", "/var/Log", "/var/log/a", '/', false, "", false},
+		{"posix case-insensitive", "/var/Log", "/var/log/a", '/', true, "a", true},
+		{"windows drive", `C:\`, `C:\Users\x`, '\\', true, `Users\x`, true},
+		{"windows drive letter case", `c:\users`, `C:\Users\x\a.log`, '\\', true, `x\a.log`, true},
+		{"windows other drive", `C:\Users`, `D:\Users\x`, '\\', true, "", false},
+		{"windows same prefix", `C:\Users\x`, `C:\Users\xy`, '\\', true, "", false},
+		{"windows unc", `\\server\share`, `\\SERVER\share\dir`, '\\', true, "dir", true},
+		{"windows self", `C:\Users`, `c:\users`, '\\', true, ".", true},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			rel, ok := relTo(c.base, c.target, c.sep, c.fold)
+			require.Equal(t, c.ok, ok)
+			require.Equal(t, c.expect, rel)
+		})
+	}
+}
+
+func TestContainsResolved(t *testing.T) {
+	base := t.TempDir()
+	outside := t.TempDir()
+	link := filepath.Join(base, "link")
+	require.NoError(t, os.Symlink(outside, link))
+
+	// lexically the link is inside base
+	ok, err := Contains(base, link)
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	// but it points outside of base
+	ok, err = ContainsResolved(base, link)
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	ok, err = ContainsResolved(base, base)
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	_, err = ContainsResolved(base, filepath.Join(base, "not-existed"))
+	require.ErrorIs(t, err, os.ErrNotExist)
+	// the path failed to be resolved is reported
+	require.ErrorContains(t, err, fmt.Sprintf("failed to resolve target path: %q", filepath.Join(base, "not-existed")))
+}
+
+func TestSafeJoin(t *testing.T) {
+	base := t.TempDir()
+	joined, err := SafeJoin(base, "a", "b.log")
+	require.NoError(t, err)
+	require.Equal(t, filepath.Join(base, "a", "b.log"), joined)
+
+	joined, err = SafeJoin(base, "a", "..", "b.log")
+	require.NoError(t, err)
+	require.Equal(t, filepath.Join(base, "b.log"), joined)
+
+	_, err = SafeJoin(base, "..", "b.log")
+	require.ErrorIs(t, err, OutsideBaseError)
+
+	_, err = SafeJoin("", "a")
+	require.ErrorIs(t, err, InvalidPathError)
+}
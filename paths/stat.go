@@ -0,0 +1,54 @@
+package paths
+
+import (
+	"context"
+	"os"
+	"runtime"
+
+	"github.com/stkali/utility/errors"
+	"github.com/stkali/utility/lib"
+)
+
+// StatResult is the result of the stat of a path by StatAll.
+type StatResult struct {
+	Path string
+	// Info is the FileInfo of Path, nil if Err is not nil.
+	Info os.FileInfo
+	Err  error
+}
+
+// StatAll returns the FileInfo of every file of files, in the order of files, see
+// StatAllN. It uses GOMAXPROCS workers.
+func StatAll(files []string) ([]StatResult, error) {
+	return StatAllN(files, runtime.GOMAXPROCS(0))
+}
+
+// StatAllN returns the FileInfo of every file of files, in the order of files, the
+// stats are run by at most workers goroutines since their latency dominates on network
+// file systems. The error of each file is in its StatResult, the returned error joins
+// them, it's nil if all the stats succeed.
+func StatAllN(files []string, workers int) ([]StatResult, error) {
+	if workers < 1 {
+		workers = 1
+	}
+	// the error of each file is kept in its result, so the pool doesn't stop
+	results, _ := lib.Pool(context.Background(), workers, files, func(_ context.Context, file string) (StatResult, error) {
+		info, err := os.Stat(file)
+		return StatResult{Path: file, Info: info, Err: err}, nil
+	})
+	var c errors.Collector
+	for index := range results {
+		c.Add(results[index].Err)
+	}
+	return results, c.Err()
+}
+
+// ExistAll reports whether every file of files exists, see StatAll.
+func ExistAll(files []string) map[string]bool {
+	results, _ := StatAll(files)
+	existed := make(map[string]bool, len(results))
+	for _, result := range results {
+		existed[result.Path] = result.Err == nil
+	}
+	return existed
+}
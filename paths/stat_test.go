@@ -0,0 +1,82 @@
+package paths
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// createFiles creates n files in dir and returns them.
+func createFiles(tb testing.TB, dir string, n int) []string {
+	files := make([]string, n)
+	for index := range files {
+		files[index] = filepath.Join(dir, strconv.Itoa(index))
+		require.NoError(tb, os.WriteFile(files[index], make([]byte, index), 0o644))
+	}
+	return files
+}
+
+func TestStatAll(t *testing.T) {
+	dir := t.TempDir()
+	files := createFiles(t, dir, 100)
+	missing := filepath.Join(dir, "missing")
+	files = append(files[:50], append([]string{missing}, files[50:]...)...)
+
+	for _, workers := range []int{-1, 0, 1, 4, 1000} {
+		t.Run(strconv.Itoa(workers), func(t *testing.T) {
+			results, err := StatAllN(files, workers)
+			require.ErrorIs(t, err, os.ErrNotExist)
+			require.Len(t, results, len(files))
+			for index, result := range results {
+				// the order of files is kept
+				require.Equal(t, files[index], result.Path)
+				if result.Path == missing {
+					require.ErrorIs(t, result.Err, os.ErrNotExist)
+					require.Nil(t, result.Info)
+					continue
+				}
+				require.NoError(t, result.Err)
+				require.Equal(t, filepath.Base(result.Path), strconv.FormatInt(result.Info.Size(), 10))
+			}
+		})
+	}
+
+	results, err := StatAll(files[:10])
+	require.NoError(t, err)
+	require.Len(t, results, 10)
+
+	results, err = StatAll(nil)
+	require.NoError(t, err)
+	require.Empty(t, results)
+}
+
+func TestExistAll(t *testing.T) {
+	dir := t.TempDir()
+	files := createFiles(t, dir, 3)
+	missing := filepath.Join(dir, "missing")
+	require.Equal(t, map[string]bool{
+		files[0]: true,
+		files[1]: true,
+		files[2]: true,
+		missing:  false,
+	}, ExistAll(append(files, missing)))
+}
+
+func BenchmarkStatAll(b *testing.B) {
+	files := createFiles(b, b.TempDir(), 10000)
+	b.Run("sequential", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			for _, file := range files {
+				_, _ = os.Stat(file)
+			}
+		}
+	})
+	b.Run("pooled", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			_, _ = StatAll(files)
+		}
+	})
+}
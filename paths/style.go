@@ -0,0 +1,160 @@
+package paths
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// Style is the path convention used by Normalize.
+type Style int
+
+const (
+	// Posix uses '/' separators, drive letters are written as "/c/x" (MSYS convention)
+	// and UNC paths as "//server/share/x".
+	Posix Style = iota
+	// Windows uses '\' separators, drive letters are written as `C:\x` and UNC paths
+	// as `\\server\share\x`.
+	Windows
+)
+
+// String implements fmt.Stringer.
+func (s Style) String() string {
+	if s == Windows {
+		return "Windows"
+	}
+	return "Posix"
+}
+
+// pathParts is a lexically cleaned path split into its volume and elements.
+type pathParts struct {
+	// drive is the upper case drive letter, 0 if none.
+	drive byte
+	// server and share are the UNC volume, empty if none.
+	server, share string
+	rooted        bool
+	elems         []string
+}
+
+// parsePath splits path into parts, both '/' and '\' are accepted as separators,
+// "." elements are dropped and ".." elements are resolved lexically. If msys is
+// true, a leading single letter element such as "/c/x" is read as a drive letter.
+func parsePath(path string, msys bool) pathParts {
+	var p pathParts
+	path = strings.ReplaceAll(path, `\`, "/")
+	switch {
+	case len(path) > 2 && path[0] == '/' && path[1] == '/' && path[2] != '/':
+		// UNC: //server/share/rest
+		elems := strings.SplitN(path[2:], "/", 3)
+		p.server = elems[0]
+		if len(elems) > 1 {
+			p.share = elems[1]
+		}
+		path = ""
+		if len(elems) > 2 {
+			path = elems[2]
+		}
+		p.rooted = true
+	case len(path) >= 2 && isLetter(path[0]) && path[1] == ':':
+		p.drive = upper(path[0])
+		path = path[2:]
+		p.rooted = strings.HasPrefix(path, "/")
+	case msys && len(path) >= 2 && path[0] == '/' && isLetter(path[1]) && (len(path) == 2 || path[2] == '/'):
+		p.drive = upper(path[1])
+		path = path[2:]
+		p.rooted = true
+	default:
+		p.rooted = strings.HasPrefix(path, "/")
+	}
+	for _, elem := range strings.Split(path, "/") {
+		switch elem {
+		case "", ".":
+		case "..":
+			if len(p.elems) > 0 && p.elems[len(p.elems)-1] != ".." {
+				p.elems = p.elems[:len(p.elems)-1]
+			} else if !p.rooted {
+				p.elems = append(p.elems, elem)
+			}
+		default:
+			p.elems = append(p.elems, elem)
+		}
+	}
+	return p
+}
+
+// format joins the parts with sep, the volume is written as is when msys is false.
+func (p pathParts) format(sep byte, msys bool) string {
+	var sb strings.Builder
+	s := string(sep)
+	switch {
+	case p.server != "":
+		sb.WriteString(s + s + p.server)
+		if p.share != "" {
+			sb.WriteString(s + p.share)
+		}
+	case p.drive != 0 && msys:
+		sb.WriteString(s)
+		sb.WriteByte(p.drive + 'a' - 'A')
+	case p.drive != 0:
+		sb.WriteByte(p.drive)
+		sb.WriteByte(':')
+		if p.rooted {
+			sb.WriteString(s)
+		}
+	case p.rooted:
+		sb.WriteString(s)
+	}
+	// a rooted volume already ends with sep, a relative drive such as "C:x" has none
+	needSep := p.server != "" || (p.drive != 0 && msys)
+	for index, elem := range p.elems {
+		if index > 0 || needSep {
+			sb.WriteString(s)
+		}
+		sb.WriteString(elem)
+	}
+	if sb.Len() == 0 {
+		return "."
+	}
+	return sb.String()
+}
+
+// Clean returns the shortest path equivalent to path by lexical processing, using
+// the separator of the current operating system. Unlike filepath.Clean, both '/'
+// and '\' are read as separators on every operating system.
+func Clean(path string) string {
+	return parsePath(path, false).format(filepath.Separator, false)
+}
+
+// ToSlash returns the cleaned path using '/' as separator, the volume is kept as is.
+func ToSlash(path string) string {
+	return parsePath(path, false).format('/', false)
+}
+
+// FromSlash returns the cleaned path using the separator of the current operating system.
+func FromSlash(path string) string {
+	return Clean(path)
+}
+
+// Normalize converts path to the given style by pure string manipulation, so it
+// works for paths which do not exist on the local file system:
+//
+//	Normalize(`C:\Users\x\..\y`, Posix)   -> "/c/Users/y"
+//	Normalize("/c/Users/y", Windows)      -> `C:\Users\y`
+//	Normalize(`\\server\share\x`, Posix)  -> "//server/share/x"
+//	Normalize("a//b/./c/", Windows)       -> `a\b\c`
+func Normalize(path string, style Style) string {
+	if style == Windows {
+		return parsePath(path, true).format('\\', false)
+	}
+	return parsePath(path, false).format('/', true)
+}
+
+func isLetter(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z'
+}
+
+func upper(c byte) byte {
+	if 'a' <= c && c <= 'z' {
+		return c - 'a' + 'A'
+	}
+	return c
+}
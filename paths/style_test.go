@@ -0,0 +1,78 @@
+package paths
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalize(t *testing.T) {
+	cases := []struct {
+		name    string
+		path    string
+		posix   string
+		windows string
+	}{
+		{"empty", "", ".", "."},
+		{"dot", ".", ".", "."},
+		{"root", "/", "/", `\`},
+		{"relative", "a/b", "a/b", `a\b`},
+		{"duplicate separators", "a//b///c", "a/b/c", `a\b\c`},
+		{"trailing separator", "a/b/", "a/b", `a\b`},
+		{"dot elements", "./a/./b/.", "a/b", `a\b`},
+		{"dot dot", "a/b/../c", "a/c", `a\c`},
+		{"leading dot dot", "../../a", "../../a", `..\..\a`},
+		{"dot dot beyond relative", "a/../../b", "../b", `..\b`},
+		{"dot dot beyond root", "/../a", "/a", `\a`},
+		{"mixed separators", `a\b/c`, "a/b/c", `a\b\c`},
+		{"drive", `C:\Users\x`, "/c/Users/x", `C:\Users\x`},
+		{"lower drive", `c:/Users/x`, "/c/Users/x", `C:\Users\x`},
+		{"drive root", `C:\`, "/c", `C:\`},
+		{"drive only", `C:`, "/c", `C:`},
+		{"drive dot dot", `C:\a\..\..\b`, "/c/b", `C:\b`},
+		{"relative drive", `C:a\b`, "/c/a/b", `C:a\b`},
+		{"msys drive", "/c/Users/x", "/c/Users/x", `C:\Users\x`},
+		{"msys drive root", "/d", "/d", `D:\`},
+		{"not msys drive", "/cd/x", "/cd/x", `\cd\x`},
+		{"unc", `\\server\share\dir\file`, "//server/share/dir/file", `\\server\share\dir\file`},
+		{"unc slash", "//server/share/dir/../file", "//server/share/file", `\\server\share\file`},
+		{"unc share only", `\\server\share`, "//server/share", `\\server\share`},
+		{"unc dot dot beyond share", `\\server\share\..\x`, "//server/share/x", `\\server\share\x`},
+		{"triple slash", "///a", "/a", `\a`},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			require.Equal(t, c.posix, Normalize(c.path, Posix))
+			require.Equal(t, c.windows, Normalize(c.path, Windows))
+			// converting back and forth is stable
+			require.Equal(t, c.posix, Normalize(c.windows, Posix))
+		})
+	}
+}
+
+func TestToSlash(t *testing.T) {
+	cases := []struct {
+		path   string
+		expect string
+	}{
+		{"", "."},
+		{`a\\b\.\c\..`, "a/b"},
+		{`C:\Users\x`, "C:/Users/x"},
+		{`C:a`, "C:a"},
+		{"/c/x", "/c/x"},
+		{`\\server\share\x`, "//server/share/x"},
+	}
+	for _, c := range cases {
+		t.Run(c.path, func(t *testing.T) {
+			require.Equal(t, c.expect, ToSlash(c.path))
+			require.Equal(t, filepath.FromSlash(c.expect), FromSlash(c.path))
+			require.Equal(t, FromSlash(c.path), Clean(c.path))
+		})
+	}
+}
+
+func TestStyleString(t *testing.T) {
+	require.Equal(t, "Posix", Posix.String())
+	require.Equal(t, "Windows", Windows.String())
+}
@@ -0,0 +1,287 @@
+package paths
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/stkali/utility/errors"
+)
+
+// SymlinkPolicy defines how SyncDir handles symbolic links found in the source.
+type SymlinkPolicy int
+
+const (
+	// SymlinkSkip ignores symbolic links, they are reported as skipped.
+	SymlinkSkip SymlinkPolicy = iota
+	// SymlinkCopy recreates the link itself in the destination.
+	SymlinkCopy
+	// SymlinkFollow copies the file or directory the link points to.
+	SymlinkFollow
+)
+
+// SyncOption configures SyncDir.
+type SyncOption struct {
+	// Delete removes the files and directories of the destination which do not
+	// exist in the source.
+	Delete bool
+	// Hash compares the sha256 of the files whose size and modification time are
+	// equal, instead of considering them unchanged.
+	Hash bool
+	// Exclude contains glob patterns (see filepath.Match) matched against both the
+	// path relative to the source and the base name. Excluded entries are neither
+	// copied nor deleted.
+	Exclude []string
+	// DryRun fills the report without touching the destination.
+	DryRun bool
+	// Symlinks is the policy for symbolic links, default is SymlinkSkip.
+	Symlinks SymlinkPolicy
+}
+
+// SyncReport reports what SyncDir did, all the paths are relative to the source
+// (or the destination for Deleted).
+type SyncReport struct {
+	Copied  []string
+	Skipped []string
+	Deleted []string
+	Errors  []error
+}
+
+// SyncDir mirrors the directory tree src into dst one way: new and changed files
+// (size or modification time differs) are copied, unchanged files are skipped and,
+// if opt.Delete is set, the entries of dst missing in src are removed.
+// A failure on one entry does not stop the others, all the failures are recorded in
+// the report and joined into the returned error.
+func SyncDir(src, dst string, opt SyncOption) (SyncReport, error) {
+	s := &syncer{opt: opt, seen: make(map[string]struct{}), visited: make(map[string]struct{})}
+	info, err := os.Stat(src)
+	if err != nil {
+		return s.report, errors.Wrapf(err, "failed to stat source directory: %q", src)
+	}
+	if !info.IsDir() {
+		return s.report, errors.Newf("source %q is not a directory: %s", src, InvalidPathError)
+	}
+	s.syncTree(src, dst, "")
+	if opt.Delete {
+		s.deleteExtra(dst)
+	}
+	return s.report, errors.Join(s.report.Errors...)
+}
+
+type syncer struct {
+	opt    SyncOption
+	report SyncReport
+	// seen contains the relative paths existing in the source.
+	seen map[string]struct{}
+	// visited contains the resolved directories walked, to avoid symlink cycles.
+	visited map[string]struct{}
+}
+
+func (s *syncer) fail(err error) {
+	s.report.Errors = append(s.report.Errors, err)
+}
+
+// excluded reports whether the relative path matches one of the exclude patterns.
+func (s *syncer) excluded(rel string) bool {
+	for _, pattern := range s.opt.Exclude {
+		if ok, _ := filepath.Match(pattern, rel); ok {
+			return true
+		}
+		if ok, _ := filepath.Match(pattern, filepath.Base(rel)); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// syncTree mirrors srcRoot into dstRoot, prefix is the path of srcRoot relative to
+// the source, it is not empty when following a linked directory.
+func (s *syncer) syncTree(srcRoot, dstRoot, prefix string) {
+	// filepath.WalkDir does not descend into a root which is a link
+	real, err := filepath.EvalSymlinks(srcRoot)
+	if err != nil {
+		s.fail(errors.Wrapf(err, "failed to resolve: %q", srcRoot))
+		return
+	}
+	if _, ok := s.visited[real]; ok {
+		return
+	}
+	s.visited[real] = struct{}{}
+	err = filepath.WalkDir(real, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			s.fail(err)
+			return nil
+		}
+		local, _ := filepath.Rel(real, path)
+		dst := filepath.Join(dstRoot, local)
+		if local == "." {
+			return s.mkdir(dst)
+		}
+		rel := filepath.Join(prefix, local)
+		if s.excluded(rel) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		s.seen[rel] = struct{}{}
+		switch {
+		case d.IsDir():
+			return s.mkdir(dst)
+		case d.Type()&os.ModeSymlink != 0:
+			s.syncLink(path, dst, rel)
+		case d.Type().IsRegular():
+			s.syncFile(path, dst, rel)
+		default:
+			s.report.Skipped = append(s.report.Skipped, rel)
+		}
+		return nil
+	})
+	if err != nil {
+		s.fail(err)
+	}
+}
+
+func (s *syncer) mkdir(dir string) error {
+	if s.opt.DryRun {
+		return nil
+	}
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		s.fail(errors.Wrapf(err, "failed to create directory: %q", dir))
+		return filepath.SkipDir
+	}
+	return nil
+}
+
+func (s *syncer) syncLink(src, dst, rel string) {
+	switch s.opt.Symlinks {
+	case SymlinkCopy:
+		target, err := os.Readlink(src)
+		if err != nil {
+			s.fail(errors.Wrapf(err, "failed to read link: %q", src))
+			return
+		}
+		if current, err := os.Readlink(dst); err == nil && current == target {
+			s.report.Skipped = append(s.report.Skipped, rel)
+			return
+		}
+		s.report.Copied = append(s.report.Copied, rel)
+		if s.opt.DryRun {
+			return
+		}
+		_ = os.Remove(dst)
+		if err = os.Symlink(target, dst); err != nil {
+			s.fail(errors.Wrapf(err, "failed to create link: %q", dst))
+		}
+	case SymlinkFollow:
+		info, err := os.Stat(src)
+		if err != nil {
+			s.fail(errors.Wrapf(err, "failed to follow link: %q", src))
+			return
+		}
+		if info.IsDir() {
+			s.syncTree(src, dst, rel)
+			return
+		}
+		s.syncFile(src, dst, rel)
+	default:
+		s.report.Skipped = append(s.report.Skipped, rel)
+	}
+}
+
+func (s *syncer) syncFile(src, dst, rel string) {
+	changed, err := s.changed(src, dst)
+	if err != nil {
+		s.fail(err)
+		return
+	}
+	if !changed {
+		s.report.Skipped = append(s.report.Skipped, rel)
+		return
+	}
+	s.report.Copied = append(s.report.Copied, rel)
+	if s.opt.DryRun {
+		return
+	}
+	if err = CopyFile(src, dst); err != nil {
+		s.fail(err)
+	}
+}
+
+// changed reports whether dst is missing or differs from src.
+func (s *syncer) changed(src, dst string) (bool, error) {
+	srcInfo, err := os.Stat(src)
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to stat: %q", src)
+	}
+	dstInfo, err := os.Lstat(dst)
+	if err != nil {
+		return true, nil
+	}
+	if !dstInfo.Mode().IsRegular() || srcInfo.Size() != dstInfo.Size() || !srcInfo.ModTime().Equal(dstInfo.ModTime()) {
+		return true, nil
+	}
+	if !s.opt.Hash {
+		return false, nil
+	}
+	srcSum, err := fileSum(src)
+	if err != nil {
+		return false, err
+	}
+	dstSum, err := fileSum(dst)
+	if err != nil {
+		return false, err
+	}
+	return !bytes.Equal(srcSum, dstSum), nil
+}
+
+// deleteExtra removes the entries of dst which were not seen in the source.
+func (s *syncer) deleteExtra(dst string) {
+	err := filepath.WalkDir(dst, func(path string, d fs.DirEntry, err error) error {
+		// dst isn't created by a dry run, it's an empty tree
+		if path == dst && os.IsNotExist(err) {
+			return nil
+		}
+		if err != nil {
+			s.fail(err)
+			return nil
+		}
+		rel, _ := filepath.Rel(dst, path)
+		if rel == "." || s.excluded(rel) {
+			return nil
+		}
+		if _, ok := s.seen[rel]; ok {
+			return nil
+		}
+		s.report.Deleted = append(s.report.Deleted, rel)
+		if !s.opt.DryRun {
+			if err = os.RemoveAll(path); err != nil {
+				s.fail(errors.Wrapf(err, "failed to delete: %q", path))
+			}
+		}
+		if d.IsDir() {
+			return filepath.SkipDir
+		}
+		return nil
+	})
+	if err != nil {
+		s.fail(err)
+	}
+}
+
+// fileSum returns the sha256 of the file content.
+func fileSum(file string) ([]byte, error) {
+	fd, err := os.Open(file)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to open: %q", file)
+	}
+	defer fd.Close()
+	h := sha256.New()
+	if _, err = io.Copy(h, fd); err != nil {
+		return nil, errors.Wrapf(err, "failed to read: %q", file)
+	}
+	return h.Sum(nil), nil
+}
@@ -0,0 +1,170 @@
+package paths
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// writeTree creates the files with their content in root.
+func writeTree(t *testing.T, root string, files map[string]string) {
+	for name, content := range files {
+		file := filepath.Join(root, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(file), 0o755))
+		require.NoError(t, os.WriteFile(file, []byte(content), 0o644))
+	}
+}
+
+func sorted(s []string) []string {
+	sort.Strings(s)
+	return s
+}
+
+func TestSyncDir(t *testing.T) {
+	src := t.TempDir()
+	dst := t.TempDir()
+	writeTree(t, src, map[string]string{
+		"a.log":       "a",
+		"sub/b.log":   "b",
+		"sub/c.tmp":   "c",
+		"cache/d.log": "d",
+	})
+	writeTree(t, dst, map[string]string{
+		"extra.log":     "extra",
+		"old/extra.log": "extra",
+		"keep.tmp":      "keep",
+	})
+	opt := SyncOption{Delete: true, Exclude: []string{"*.tmp", "cache"}}
+
+	// dry run does not touch the destination
+	report, err := SyncDir(src, dst, SyncOption{Delete: true, Exclude: opt.Exclude, DryRun: true})
+	require.NoError(t, err)
+	require.Equal(t, []string{"a.log", filepath.Join("sub", "b.log")}, sorted(report.Copied))
+	require.Equal(t, []string{"extra.log", "old"}, sorted(report.Deleted))
+	require.False(t, IsExisted(filepath.Join(dst, "a.log")))
+	require.True(t, IsExisted(filepath.Join(dst, "extra.log")))
+
+	report, err = SyncDir(src, dst, opt)
+	require.NoError(t, err)
+	require.Equal(t, []string{"a.log", filepath.Join("sub", "b.log")}, sorted(report.Copied))
+	require.Equal(t, []string{"extra.log", "old"}, sorted(report.Deleted))
+	require.True(t, IsExisted(filepath.Join(dst, "sub", "b.log")))
+	require.False(t, IsExisted(filepath.Join(dst, "sub", "c.tmp")))
+	require.False(t, IsExisted(filepath.Join(dst, "cache")))
+	require.False(t, IsExisted(filepath.Join(dst, "old")))
+	// excluded entries are not deleted
+	require.True(t, IsExisted(filepath.Join(dst, "keep.tmp")))
+
+	// nothing changed
+	report, err = SyncDir(src, dst, opt)
+	require.NoError(t, err)
+	require.Empty(t, report.Copied)
+	require.Empty(t, report.Deleted)
+	require.Equal(t, []string{"a.log", filepath.Join("sub", "b.log")}, sorted(report.Skipped))
+
+	// changed size
+	writeTree(t, src, map[string]string{"a.log": "changed"})
+	report, err = SyncDir(src, dst, opt)
+	require.NoError(t, err)
+	require.Equal(t, []string{"a.log"}, report.Copied)
+	content, err := os.ReadFile(filepath.Join(dst, "a.log"))
+	require.NoError(t, err)
+	require.Equal(t, "changed", string(content))
+}
+
+func TestSyncDirHash(t *testing.T) {
+	src := t.TempDir()
+	dst := t.TempDir()
+	writeTree(t, src, map[string]string{"a.log": "aaaa"})
+	_, err := SyncDir(src, dst, SyncOption{})
+	require.NoError(t, err)
+
+	// same size and modification time, but different content
+	info, err := os.Stat(filepath.Join(src, "a.log"))
+	require.NoError(t, err)
+	writeTree(t, dst, map[string]string{"a.log": "bbbb"})
+	require.NoError(t, os.Chtimes(filepath.Join(dst, "a.log"), info.ModTime(), info.ModTime()))
+
+	report, err := SyncDir(src, dst, SyncOption{})
+	require.NoError(t, err)
+	require.Equal(t, []string{"a.log"}, report.Skipped)
+
+	report, err = SyncDir(src, dst, SyncOption{Hash: true})
+	require.NoError(t, err)
+	require.Equal(t, []string{"a.log"}, report.Copied)
+}
+
+func TestSyncDirSymlinks(t *testing.T) {
+	src := t.TempDir()
+	outside := t.TempDir()
+	writeTree(t, outside, map[string]string{"target.log": "target", "dir/inner.log": "inner"})
+	require.NoError(t, os.Symlink(filepath.Join(outside, "target.log"), filepath.Join(src, "file-link")))
+	require.NoError(t, os.Symlink(filepath.Join(outside, "dir"), filepath.Join(src, "dir-link")))
+	// a cycle
+	require.NoError(t, os.Symlink(src, filepath.Join(src, "self-link")))
+
+	t.Run("skip", func(t *testing.T) {
+		dst := t.TempDir()
+		report, err := SyncDir(src, dst, SyncOption{})
+		require.NoError(t, err)
+		require.Equal(t, []string{"dir-link", "file-link", "self-link"}, sorted(report.Skipped))
+		require.False(t, IsExisted(filepath.Join(dst, "file-link")))
+	})
+
+	t.Run("copy", func(t *testing.T) {
+		dst := t.TempDir()
+		report, err := SyncDir(src, dst, SyncOption{Symlinks: SymlinkCopy})
+		require.NoError(t, err)
+		require.Len(t, report.Copied, 3)
+		target, err := os.Readlink(filepath.Join(dst, "file-link"))
+		require.NoError(t, err)
+		require.Equal(t, filepath.Join(outside, "target.log"), target)
+
+		report, err = SyncDir(src, dst, SyncOption{Symlinks: SymlinkCopy})
+		require.NoError(t, err)
+		require.Len(t, report.Skipped, 3)
+	})
+
+	t.Run("follow", func(t *testing.T) {
+		dst := t.TempDir()
+		report, err := SyncDir(src, dst, SyncOption{Symlinks: SymlinkFollow, Delete: true})
+		require.NoError(t, err)
+		require.Equal(t, []string{filepath.Join("dir-link", "inner.log"), "file-link"}, sorted(report.Copied))
+		require.Empty(t, report.Deleted)
+		content, err := os.ReadFile(filepath.Join(dst, "dir-link", "inner.log"))
+		require.NoError(t, err)
+		require.Equal(t, "inner", string(content))
+	})
+}
+
+func TestSyncDirMissingDst(t *testing.T) {
+	src := t.TempDir()
+	writeTree(t, src, map[string]string{"a.log": "a", "sub/b.log": "b"})
+	dst := filepath.Join(t.TempDir(), "dst")
+
+	// the dry run doesn't create dst, there's nothing to delete
+	report, err := SyncDir(src, dst, SyncOption{Delete: true, DryRun: true})
+	require.NoError(t, err)
+	require.Equal(t, []string{"a.log", filepath.Join("sub", "b.log")}, sorted(report.Copied))
+	require.Empty(t, report.Deleted)
+	require.False(t, IsExisted(dst))
+
+	report, err = SyncDir(src, dst, SyncOption{Delete: true})
+	require.NoError(t, err)
+	require.Equal(t, []string{"a.log", filepath.Join("sub", "b.log")}, sorted(report.Copied))
+	require.True(t, IsExisted(filepath.Join(dst, "sub", "b.log")))
+}
+
+func TestSyncDirError(t *testing.T) {
+	dst := t.TempDir()
+	_, err := SyncDir(filepath.Join(dst, "not-existed"), dst, SyncOption{})
+	require.ErrorIs(t, err, os.ErrNotExist)
+
+	file := filepath.Join(dst, "file")
+	require.NoError(t, os.WriteFile(file, nil, 0o644))
+	_, err = SyncDir(file, dst, SyncOption{})
+	require.ErrorIs(t, err, InvalidPathError)
+}
@@ -0,0 +1,207 @@
+package paths
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"time"
+
+	"github.com/stkali/utility/errors"
+)
+
+var (
+	// tailChunkSize is the size of the chunks read backwards by TailLines.
+	tailChunkSize int64 = 4096
+	// followInterval is the interval Follow polls the file at.
+	followInterval = 200 * time.Millisecond
+	// openFollowed opens the file replacing the one followed by Follow, for testing.
+	openFollowed = os.Open
+)
+
+// TailBytes returns the last n bytes of the file, or the whole file if it is smaller.
+func TailBytes(path string, n int64) ([]byte, error) {
+	if n < 0 {
+		return nil, errors.Newf("invalid tail size: %d", n)
+	}
+	fd, err := os.Open(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to open file: %q", path)
+	}
+	defer fd.Close()
+	info, err := fd.Stat()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to stat file: %q", path)
+	}
+	if size := info.Size(); n > size {
+		n = size
+	}
+	buf := make([]byte, n)
+	if _, err = fd.ReadAt(buf, info.Size()-n); err != nil && err != io.EOF {
+		return nil, errors.Wrapf(err, "failed to read file: %q", path)
+	}
+	return buf, nil
+}
+
+// TailLines returns the last n lines of the file without their line terminators
+// ("\n" or "\r\n"). The file is read backwards in fixed-size chunks, so only the
+// end of a huge file is read. A last line without a trailing newline is returned too.
+func TailLines(path string, n int) ([][]byte, error) {
+	if n < 0 {
+		return nil, errors.Newf("invalid tail lines: %d", n)
+	}
+	if n == 0 {
+		return nil, nil
+	}
+	fd, err := os.Open(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to open file: %q", path)
+	}
+	defer fd.Close()
+	info, err := fd.Stat()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to stat file: %q", path)
+	}
+	if info.Size() == 0 {
+		return nil, nil
+	}
+
+	var chunks [][]byte
+	offset := info.Size()
+	newlines := 0
+	for offset > 0 && newlines <= n {
+		size := tailChunkSize
+		if size > offset {
+			size = offset
+		}
+		offset -= size
+		chunk := make([]byte, size)
+		if _, err = fd.ReadAt(chunk, offset); err != nil && err != io.EOF {
+			return nil, errors.Wrapf(err, "failed to read file: %q", path)
+		}
+		newlines += bytes.Count(chunk, []byte{'\n'})
+		chunks = append(chunks, chunk)
+	}
+	// chunks were read from the end
+	for i, j := 0, len(chunks)-1; i < j; i, j = i+1, j-1 {
+		chunks[i], chunks[j] = chunks[j], chunks[i]
+	}
+	data := bytes.TrimSuffix(bytes.Join(chunks, nil), []byte{'\n'})
+	lines := bytes.Split(data, []byte{'\n'})
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	for index := range lines {
+		lines[index] = bytes.TrimSuffix(lines[index], []byte{'\r'})
+	}
+	return lines, nil
+}
+
+// Follow behaves like `tail -f`: it sends the lines appended to the file after
+// the call to the returned channel until ctx is done. When the file is replaced
+// (its inode changes, e.g. after a rotation) or truncated, it is reopened and read
+// from the beginning. The channel is closed when ctx is done.
+func Follow(ctx context.Context, path string) (<-chan []byte, error) {
+	fd, err := os.Open(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to open file: %q", path)
+	}
+	info, err := fd.Stat()
+	if err != nil {
+		_ = fd.Close()
+		return nil, errors.Wrapf(err, "failed to stat file: %q", path)
+	}
+	f := &follower{fd: fd, offset: info.Size(), id: getFileID(info), ch: make(chan []byte, 64)}
+	if _, err = fd.Seek(f.offset, io.SeekStart); err != nil {
+		_ = fd.Close()
+		return nil, errors.Wrapf(err, "failed to seek file: %q", path)
+	}
+	go f.run(ctx, path)
+	return f.ch, nil
+}
+
+type follower struct {
+	fd      *os.File
+	offset  int64
+	id      uint64
+	partial []byte
+	ch      chan []byte
+}
+
+func (f *follower) run(ctx context.Context, path string) {
+	defer close(f.ch)
+	defer func() {
+		if f.fd != nil {
+			_ = f.fd.Close()
+		}
+	}()
+	ticker := time.NewTicker(followInterval)
+	defer ticker.Stop()
+	for {
+		if !f.read(ctx) {
+			return
+		}
+		info, err := os.Stat(path)
+		if err == nil && (f.fd == nil || (f.id != 0 && getFileID(info) != f.id) || info.Size() < f.offset) {
+			// read the new file at once, retry at the next tick if it can't be opened
+			if f.reopen(path) {
+				continue
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// read sends the complete lines available, it returns false if ctx is done.
+func (f *follower) read(ctx context.Context) bool {
+	if f.fd == nil {
+		return true
+	}
+	buf := make([]byte, 32*1024)
+	for {
+		n, err := f.fd.Read(buf)
+		f.offset += int64(n)
+		f.partial = append(f.partial, buf[:n]...)
+		for {
+			index := bytes.IndexByte(f.partial, '\n')
+			if index == -1 {
+				break
+			}
+			line := make([]byte, index)
+			copy(line, f.partial[:index])
+			f.partial = f.partial[index+1:]
+			select {
+			case f.ch <- bytes.TrimSuffix(line, []byte{'\r'}):
+			case <-ctx.Done():
+				return false
+			}
+		}
+		if err != nil || n == 0 {
+			return true
+		}
+	}
+}
+
+// reopen replaces the followed file by the file currently at path, it returns false
+// if the file can't be opened.
+func (f *follower) reopen(path string) bool {
+	if f.fd != nil {
+		_ = f.fd.Close()
+		f.fd = nil
+	}
+	f.partial = f.partial[:0]
+	f.offset = 0
+	fd, err := openFollowed(path)
+	if err != nil {
+		return false
+	}
+	if info, err := fd.Stat(); err == nil {
+		f.id = getFileID(info)
+	}
+	f.fd = fd
+	return true
+}
@@ -0,0 +1,177 @@
+package paths
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTailBytes(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "file.log")
+	require.NoError(t, os.WriteFile(file, []byte("hello world"), 0o644))
+
+	data, err := TailBytes(file, 5)
+	require.NoError(t, err)
+	require.Equal(t, "world", string(data))
+
+	data, err = TailBytes(file, 100)
+	require.NoError(t, err)
+	require.Equal(t, "hello world", string(data))
+
+	data, err = TailBytes(file, 0)
+	require.NoError(t, err)
+	require.Empty(t, data)
+
+	_, err = TailBytes(file, -1)
+	require.Error(t, err)
+	_, err = TailBytes(filepath.Join(t.TempDir(), "not-existed"), 1)
+	require.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestTailLines(t *testing.T) {
+	originChunkSize := tailChunkSize
+	defer func() { tailChunkSize = originChunkSize }()
+
+	toStrings := func(lines [][]byte) []string {
+		s := make([]string, len(lines))
+		for index := range lines {
+			s[index] = string(lines[index])
+		}
+		return s
+	}
+	cases := []struct {
+		name    string
+		content string
+		n       int
+		expect  []string
+	}{
+		{"empty file", "", 3, []string{}},
+		{"fewer lines", "a\nb\n", 3, []string{"a", "b"}},
+		{"last lines", "a\nb\nc\nd\n", 2, []string{"c", "d"}},
+		{"no trailing newline", "a\nb\nc", 2, []string{"b", "c"}},
+		{"crlf", "a\r\nb\r\nc\r\n", 2, []string{"b", "c"}},
+		{"empty lines", "a\n\n\nb\n", 3, []string{"", "", "b"}},
+		{"single newline", "\n", 2, []string{""}},
+		{"long lines", strings.Repeat("x", 50) + "\n" + strings.Repeat("y", 50) + "\n", 1, []string{strings.Repeat("y", 50)}},
+		{"zero", "a\n", 0, []string{}},
+	}
+	for _, chunkSize := range []int64{1, 3, 4096} {
+		tailChunkSize = chunkSize
+		for _, c := range cases {
+			t.Run(c.name, func(t *testing.T) {
+				file := filepath.Join(t.TempDir(), "file.log")
+				require.NoError(t, os.WriteFile(file, []byte(c.content), 0o644))
+				lines, err := TailLines(file, c.n)
+				require.NoError(t, err)
+				require.Equal(t, c.expect, toStrings(lines))
+			})
+		}
+	}
+
+	_, err := TailLines(filepath.Join(t.TempDir(), "not-existed"), 1)
+	require.ErrorIs(t, err, os.ErrNotExist)
+	_, err = TailLines("file", -1)
+	require.Error(t, err)
+}
+
+func TestFollow(t *testing.T) {
+	originInterval := followInterval
+	followInterval = 10 * time.Millisecond
+	defer func() { followInterval = originInterval }()
+
+	file := filepath.Join(t.TempDir(), "file.log")
+	require.NoError(t, os.WriteFile(file, []byte("old line\n"), 0o644))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ch, err := Follow(ctx, file)
+	require.NoError(t, err)
+
+	next := func() string {
+		select {
+		case line := <-ch:
+			return string(line)
+		case <-time.After(2 * time.Second):
+			t.Fatal("timeout waiting for line")
+		}
+		return ""
+	}
+
+	fd, err := os.OpenFile(file, os.O_WRONLY|os.O_APPEND, 0o644)
+	require.NoError(t, err)
+	_, err = fd.WriteString("first\r\nsec")
+	require.NoError(t, err)
+	require.Equal(t, "first", next())
+	_, err = fd.WriteString("ond\n")
+	require.NoError(t, err)
+	require.Equal(t, "second", next())
+	require.NoError(t, fd.Close())
+
+	if runtime.GOOS != "windows" {
+		// rotation
+		require.NoError(t, os.Rename(file, file+".1"))
+		require.NoError(t, os.WriteFile(file, []byte("rotated\n"), 0o644))
+		require.Equal(t, "rotated", next())
+	}
+
+	// truncation
+	require.NoError(t, os.WriteFile(file, []byte("t\n"), 0o644))
+	require.Equal(t, "t", next())
+
+	cancel()
+	for range ch {
+	}
+
+	_, err = Follow(ctx, filepath.Join(t.TempDir(), "not-existed"))
+	require.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestFollowReopenFailure(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the followed file can't be replaced while it's open")
+	}
+	originInterval := followInterval
+	followInterval = 10 * time.Millisecond
+	// the replacement can't be opened until failing is reset
+	var opens, failing int32 = 0, 1
+	openFollowed = func(name string) (*os.File, error) {
+		atomic.AddInt32(&opens, 1)
+		if atomic.LoadInt32(&failing) == 1 {
+			return nil, os.ErrPermission
+		}
+		return os.Open(name)
+	}
+	defer func() { followInterval, openFollowed = originInterval, os.Open }()
+
+	file := filepath.Join(t.TempDir(), "file.log")
+	require.NoError(t, os.WriteFile(file, nil, 0o644))
+	ctx, cancel := context.WithCancel(context.Background())
+	ch, err := Follow(ctx, file)
+	require.NoError(t, err)
+	defer func() {
+		cancel()
+		for range ch {
+		}
+	}()
+
+	require.NoError(t, os.Rename(file, file+".1"))
+	require.NoError(t, os.WriteFile(file, []byte("replaced\n"), 0o644))
+	time.Sleep(100 * time.Millisecond)
+	// the open is retried at every tick, not in a busy loop
+	require.Less(t, atomic.LoadInt32(&opens), int32(20))
+	require.Greater(t, atomic.LoadInt32(&opens), int32(1))
+
+	atomic.StoreInt32(&failing, 0)
+	select {
+	case line := <-ch:
+		require.Equal(t, "replaced", string(line))
+	case <-time.After(2 * time.Second):
+		t.Fatal("timeout waiting for line")
+	}
+}
@@ -0,0 +1,78 @@
+package paths
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/stkali/utility/errors"
+)
+
+// TempFileNear creates a new temporary file in the directory of target, so that
+// it can be renamed onto target atomically. The pattern is used as os.CreateTemp.
+// It returns the opened file and a cleanup function closing and removing it, the
+// cleanup can be called many times and is safe to call after the file was renamed.
+func TempFileNear(target string, pattern string) (*os.File, func(), error) {
+	dir := filepath.Dir(target)
+	fd, err := os.CreateTemp(dir, pattern)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "failed to create temporary file in %q", dir)
+	}
+	var once sync.Once
+	cleanup := func() {
+		once.Do(func() {
+			_ = fd.Close()
+			if err := os.Remove(fd.Name()); err != nil && !os.IsNotExist(err) {
+				errors.Warningf("failed to remove temporary file %q, err: %s", fd.Name(), err)
+			}
+		})
+	}
+	return fd, cleanup, nil
+}
+
+// TempDirIn creates a new temporary directory in parent, see os.MkdirTemp.
+// It returns the directory and an idempotent cleanup function removing it with
+// all its content.
+func TempDirIn(parent, pattern string) (string, func(), error) {
+	dir, err := os.MkdirTemp(parent, pattern)
+	if err != nil {
+		return "", nil, errors.Wrapf(err, "failed to create temporary directory in %q", parent)
+	}
+	var once sync.Once
+	cleanup := func() {
+		once.Do(func() {
+			if err := os.RemoveAll(dir); err != nil {
+				errors.Warningf("failed to remove temporary directory %q, err: %s", dir, err)
+			}
+		})
+	}
+	return dir, cleanup, nil
+}
+
+// AtomicWriteFile writes data to a temporary file next to file and renames it onto
+// file, so readers observe either the old or the new content, never a partial write.
+// The data and the directory are synced, so that the new content survives a crash, see
+// MakeFileSync.
+func AtomicWriteFile(file string, data []byte, perm os.FileMode) error {
+	fd, cleanup, err := TempFileNear(file, "."+filepath.Base(file)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+	if _, err = fd.Write(data); err != nil {
+		return errors.Wrapf(err, "failed to write temporary file %q", fd.Name())
+	}
+	if err = fd.Chmod(perm); err != nil {
+		return errors.Wrapf(err, "failed to chmod temporary file %q", fd.Name())
+	}
+	if err = fd.Sync(); err != nil {
+		return errors.Wrapf(err, "failed to sync temporary file %q", fd.Name())
+	}
+	if err = fd.Close(); err != nil {
+		return errors.Wrapf(err, "failed to close temporary file %q", fd.Name())
+	}
+	if err = os.Rename(fd.Name(), file); err != nil {
+		return errors.Wrapf(err, "failed to rename %q to %q", fd.Name(), file)
+	}
+	return fsyncDir(filepath.Dir(file))
+}
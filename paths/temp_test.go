@@ -0,0 +1,79 @@
+package paths
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stkali/utility/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTempFileNear(t *testing.T) {
+	folder := t.TempDir()
+	target := filepath.Join(folder, "target.log")
+
+	fd, cleanup, err := TempFileNear(target, "temp-*")
+	require.NoError(t, err)
+	require.Equal(t, folder, filepath.Dir(fd.Name()))
+	require.True(t, IsExisted(fd.Name()))
+	cleanup()
+	require.False(t, IsExisted(fd.Name()))
+	// idempotent
+	cleanup()
+
+	// cleanup after rename
+	buf := &bytes.Buffer{}
+	errors.SetWarningOutput(buf)
+	defer errors.SetWarningOutput(os.Stderr)
+	fd, cleanup, err = TempFileNear(target, "temp-*")
+	require.NoError(t, err)
+	require.NoError(t, os.Rename(fd.Name(), target))
+	cleanup()
+	require.True(t, IsExisted(target))
+	require.Empty(t, buf.String())
+
+	_, _, err = TempFileNear(filepath.Join(folder, "not-existed", "target"), "temp-*")
+	require.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestTempDirIn(t *testing.T) {
+	parent := t.TempDir()
+	dir, cleanup, err := TempDirIn(parent, "temp-*")
+	require.NoError(t, err)
+	require.Equal(t, parent, filepath.Dir(dir))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "file"), nil, 0o644))
+	cleanup()
+	require.False(t, IsExisted(dir))
+	cleanup()
+
+	_, _, err = TempDirIn(filepath.Join(parent, "not-existed"), "temp-*")
+	require.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestAtomicWriteFile(t *testing.T) {
+	folder := t.TempDir()
+	file := filepath.Join(folder, "config.json")
+
+	// the directory is synced after the rename
+	synced := countSyncs(t)
+	require.NoError(t, AtomicWriteFile(file, []byte("first"), 0o600))
+	require.Equal(t, []string{folder}, *synced)
+	content, err := os.ReadFile(file)
+	require.NoError(t, err)
+	require.Equal(t, "first", string(content))
+
+	require.NoError(t, AtomicWriteFile(file, []byte("second"), 0o600))
+	content, err = os.ReadFile(file)
+	require.NoError(t, err)
+	require.Equal(t, "second", string(content))
+
+	// no temporary file is left
+	entries, err := os.ReadDir(folder)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+
+	err = AtomicWriteFile(filepath.Join(folder, "not-existed", "file"), nil, 0o600)
+	require.ErrorIs(t, err, os.ErrNotExist)
+}
@@ -0,0 +1,101 @@
+package paths
+
+import (
+	"os"
+
+	"github.com/stkali/utility/errors"
+)
+
+// PathType is the type of file a path refers to.
+type PathType int
+
+const (
+	NotExist PathType = iota
+	File
+	Dir
+	Symlink
+	NamedPipe
+	Socket
+	Device
+	// Other is an irregular file unknown to PathType.
+	Other
+)
+
+// String implements fmt.Stringer.
+func (t PathType) String() string {
+	switch t {
+	case NotExist:
+		return "NotExist"
+	case File:
+		return "File"
+	case Dir:
+		return "Dir"
+	case Symlink:
+		return "Symlink"
+	case NamedPipe:
+		return "NamedPipe"
+	case Socket:
+		return "Socket"
+	case Device:
+		return "Device"
+	default:
+		return "Other"
+	}
+}
+
+// TypeOf returns the type of the file at path, without following a symbolic link.
+// It returns NotExist and a nil error if nothing exists at path.
+func TypeOf(path string) (PathType, error) {
+	info, err := os.Lstat(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return NotExist, nil
+		}
+		return NotExist, errors.Wrapf(err, "failed to stat %q", path)
+	}
+	return modeType(info.Mode()), nil
+}
+
+// modeType converts the type bits of mode to a PathType.
+func modeType(mode os.FileMode) PathType {
+	switch {
+	case mode.IsRegular():
+		return File
+	case mode.IsDir():
+		return Dir
+	case mode&os.ModeSymlink != 0:
+		return Symlink
+	case mode&os.ModeNamedPipe != 0:
+		return NamedPipe
+	case mode&os.ModeSocket != 0:
+		return Socket
+	case mode&os.ModeDevice != 0:
+		return Device
+	default:
+		return Other
+	}
+}
+
+// statType returns the type of the file at path following symbolic links.
+func statType(path string) PathType {
+	info, err := os.Stat(path)
+	if err != nil {
+		return NotExist
+	}
+	return modeType(info.Mode())
+}
+
+// IsFile reports whether path is a regular file, following symbolic links.
+func IsFile(path string) bool {
+	return statType(path) == File
+}
+
+// IsDir reports whether path is a directory, following symbolic links.
+func IsDir(path string) bool {
+	return statType(path) == Dir
+}
+
+// IsPipe reports whether path is a named pipe, following symbolic links.
+func IsPipe(path string) bool {
+	return statType(path) == NamedPipe
+}
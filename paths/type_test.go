@@ -0,0 +1,39 @@
+package paths
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTypeOf(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "file")
+	require.NoError(t, os.WriteFile(file, nil, 0o644))
+
+	typ, err := TypeOf(file)
+	require.NoError(t, err)
+	require.Equal(t, File, typ)
+	require.True(t, IsFile(file))
+	require.False(t, IsDir(file))
+
+	typ, err = TypeOf(dir)
+	require.NoError(t, err)
+	require.Equal(t, Dir, typ)
+	require.True(t, IsDir(dir))
+	require.False(t, IsFile(dir))
+
+	typ, err = TypeOf(filepath.Join(dir, "not-existed"))
+	require.NoError(t, err)
+	require.Equal(t, NotExist, typ)
+	require.False(t, IsFile(filepath.Join(dir, "not-existed")))
+}
+
+func TestPathTypeString(t *testing.T) {
+	names := []string{"NotExist", "File", "Dir", "Symlink", "NamedPipe", "Socket", "Device", "Other"}
+	for index, name := range names {
+		require.Equal(t, name, PathType(index).String())
+	}
+}
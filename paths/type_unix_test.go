@@ -0,0 +1,47 @@
+//go:build !windows
+
+package paths
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTypeOfSpecialFiles(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "file")
+	require.NoError(t, os.WriteFile(file, nil, 0o644))
+
+	link := filepath.Join(dir, "link")
+	require.NoError(t, os.Symlink(file, link))
+	typ, err := TypeOf(link)
+	require.NoError(t, err)
+	require.Equal(t, Symlink, typ)
+	// the helpers follow the link
+	require.True(t, IsFile(link))
+
+	pipe := filepath.Join(dir, "pipe")
+	require.NoError(t, syscall.Mkfifo(pipe, 0o644))
+	typ, err = TypeOf(pipe)
+	require.NoError(t, err)
+	require.Equal(t, NamedPipe, typ)
+	require.True(t, IsPipe(pipe))
+	require.False(t, IsPipe(file))
+
+	socket := filepath.Join(dir, "socket")
+	listener, err := net.Listen("unix", socket)
+	require.NoError(t, err)
+	defer listener.Close()
+	typ, err = TypeOf(socket)
+	require.NoError(t, err)
+	require.Equal(t, Socket, typ)
+
+	typ, err = TypeOf(os.DevNull)
+	require.NoError(t, err)
+	require.Equal(t, Device, typ)
+}
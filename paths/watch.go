@@ -0,0 +1,221 @@
+package paths
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/stkali/utility/errors"
+)
+
+// readWatchedDir lists the entries of the directory watched by Watch, for testing.
+var readWatchedDir = os.ReadDir
+
+// EventKind is the kind of change reported by Watch.
+type EventKind int
+
+const (
+	Created EventKind = iota + 1
+	Modified
+	Removed
+	Renamed
+)
+
+// String implements fmt.Stringer.
+func (k EventKind) String() string {
+	switch k {
+	case Created:
+		return "Created"
+	case Modified:
+		return "Modified"
+	case Removed:
+		return "Removed"
+	case Renamed:
+		return "Renamed"
+	default:
+		return "Unknown"
+	}
+}
+
+// Event is a change of a watched path.
+type Event struct {
+	// Path is the changed path, for a directory watch it is the path of the entry.
+	Path string
+	// OldPath is the previous path of a Renamed directory entry. For a watched file
+	// replaced by another one (e.g. rotated), Kind is Renamed and OldPath is empty.
+	OldPath string
+	Kind    EventKind
+}
+
+// Watch polls path every interval and sends its changes to the returned channel
+// until ctx is done, then the channel is closed. Changes are detected by comparing
+// the size, modification time and inode (except on windows) of the file, so several
+// changes in the same interval are coalesced into one event. If path is a directory,
+// the events are reported for its entries by diffing the directory listings.
+// The path does not need to exist yet, its creation is reported as Created.
+func Watch(ctx context.Context, path string, interval time.Duration) (<-chan Event, error) {
+	if path == "" {
+		return nil, InvalidPathError
+	}
+	if interval <= 0 {
+		return nil, errors.Newf("invalid watch interval: %s", interval)
+	}
+	prev := takeSnapshot(path)
+	ch := make(chan Event, 16)
+	go func() {
+		defer close(ch)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			cur := takeSnapshot(path)
+			// the entries of the directory failed to be listed, prev is diffed with the
+			// next complete snapshot instead of reporting them all removed
+			if cur.incomplete {
+				continue
+			}
+			if prev.incomplete {
+				prev = cur
+				continue
+			}
+			for _, event := range diffSnapshot(path, prev, cur) {
+				select {
+				case ch <- event:
+				case <-ctx.Done():
+					return
+				}
+			}
+			prev = cur
+		}
+	}()
+	return ch, nil
+}
+
+// fileState is the state of a file compared between two polls.
+type fileState struct {
+	exists  bool
+	isDir   bool
+	size    int64
+	modTime time.Time
+	id      uint64
+}
+
+func newFileState(info os.FileInfo) fileState {
+	return fileState{
+		exists:  true,
+		isDir:   info.IsDir(),
+		size:    info.Size(),
+		modTime: info.ModTime(),
+		id:      getFileID(info),
+	}
+}
+
+// changed reports whether the content of the file changed.
+func (s fileState) changed(o fileState) bool {
+	return s.size != o.size || !s.modTime.Equal(o.modTime)
+}
+
+type snapshot struct {
+	fileState
+	// entries are the states of the directory entries by name.
+	entries map[string]fileState
+	// incomplete is set if the entries of the directory failed to be listed.
+	incomplete bool
+}
+
+func takeSnapshot(path string) snapshot {
+	var snap snapshot
+	info, err := os.Stat(path)
+	if err != nil {
+		return snap
+	}
+	snap.fileState = newFileState(info)
+	if !snap.isDir {
+		return snap
+	}
+	dirEntries, err := readWatchedDir(path)
+	if err != nil {
+		snap.incomplete = true
+		return snap
+	}
+	snap.entries = make(map[string]fileState, len(dirEntries))
+	for _, entry := range dirEntries {
+		if info, err := entry.Info(); err == nil {
+			snap.entries[entry.Name()] = newFileState(info)
+		}
+	}
+	return snap
+}
+
+// diffSnapshot returns the events changing prev into cur.
+func diffSnapshot(path string, prev, cur snapshot) []Event {
+	switch {
+	case !prev.exists && !cur.exists:
+		return nil
+	case !prev.exists:
+		return []Event{{Path: path, Kind: Created}}
+	case !cur.exists:
+		return []Event{{Path: path, Kind: Removed}}
+	case prev.id != 0 && prev.id != cur.id:
+		return []Event{{Path: path, Kind: Renamed}}
+	case prev.isDir != cur.isDir || (!cur.isDir && prev.changed(cur.fileState)):
+		return []Event{{Path: path, Kind: Modified}}
+	case !cur.isDir:
+		return nil
+	}
+
+	var events []Event
+	// removed entries matched by inode with a created entry are renamed
+	removed := make(map[uint64]string)
+	for _, name := range sortedNames(prev.entries) {
+		if _, ok := cur.entries[name]; !ok {
+			if id := prev.entries[name].id; id != 0 {
+				removed[id] = name
+			} else {
+				events = append(events, Event{Path: filepath.Join(path, name), Kind: Removed})
+			}
+		}
+	}
+	for _, name := range sortedNames(cur.entries) {
+		state := cur.entries[name]
+		old, ok := prev.entries[name]
+		switch {
+		case ok && (old.changed(state) || old.id != state.id):
+			events = append(events, Event{Path: filepath.Join(path, name), Kind: Modified})
+		case ok:
+		case state.id != 0 && removed[state.id] != "":
+			events = append(events, Event{
+				Path:    filepath.Join(path, name),
+				OldPath: filepath.Join(path, removed[state.id]),
+				Kind:    Renamed,
+			})
+			delete(removed, state.id)
+		default:
+			events = append(events, Event{Path: filepath.Join(path, name), Kind: Created})
+		}
+	}
+	names := make([]string, 0, len(removed))
+	for _, name := range removed {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		events = append(events, Event{Path: filepath.Join(path, name), Kind: Removed})
+	}
+	return events
+}
+
+func sortedNames(entries map[string]fileState) []string {
+	names := make([]string, 0, len(entries))
+	for name := range entries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,166 @@
+package paths
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+const watchInterval = 10 * time.Millisecond
+
+// nextEvent waits for the next event of ch.
+func nextEvent(t *testing.T, ch <-chan Event) Event {
+	select {
+	case event, ok := <-ch:
+		require.True(t, ok, "channel is closed")
+		return event
+	case <-time.After(2 * time.Second):
+		t.Fatal("timeout waiting for event")
+	}
+	return Event{}
+}
+
+// placeFile creates file atomically, it is written in another directory and moved
+// into place, so a poll never observes a partially written file.
+func placeFile(t *testing.T, file string, content string) {
+	tmp := filepath.Join(t.TempDir(), filepath.Base(file))
+	require.NoError(t, os.WriteFile(tmp, []byte(content), 0o644))
+	require.NoError(t, os.Rename(tmp, file))
+}
+
+// appendFile appends content to file with a single write, the size change makes
+// the modification visible even on file systems with a coarse time resolution.
+func appendFile(t *testing.T, file string, content string) {
+	fd, err := os.OpenFile(file, os.O_WRONLY|os.O_APPEND, 0o644)
+	require.NoError(t, err)
+	_, err = fd.WriteString(content)
+	require.NoError(t, err)
+	require.NoError(t, fd.Close())
+}
+
+func TestWatchFile(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "config.toml")
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ch, err := Watch(ctx, file, watchInterval)
+	require.NoError(t, err)
+
+	placeFile(t, file, "a")
+	require.Equal(t, Event{Path: file, Kind: Created}, nextEvent(t, ch))
+
+	appendFile(t, file, "b")
+	require.Equal(t, Event{Path: file, Kind: Modified}, nextEvent(t, ch))
+
+	if runtime.GOOS != "windows" {
+		// replaced by another file, like a rotation
+		placeFile(t, file, "ab")
+		require.Equal(t, Event{Path: file, Kind: Renamed}, nextEvent(t, ch))
+	}
+
+	require.NoError(t, os.Remove(file))
+	require.Equal(t, Event{Path: file, Kind: Removed}, nextEvent(t, ch))
+
+	cancel()
+	for range ch {
+	}
+}
+
+func TestWatchDir(t *testing.T) {
+	dir := t.TempDir()
+	kept := filepath.Join(dir, "kept.log")
+	placeFile(t, kept, "kept")
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ch, err := Watch(ctx, dir, watchInterval)
+	require.NoError(t, err)
+
+	created := filepath.Join(dir, "a.log")
+	placeFile(t, created, "a")
+	require.Equal(t, Event{Path: created, Kind: Created}, nextEvent(t, ch))
+
+	appendFile(t, kept, "modified")
+	require.Equal(t, Event{Path: kept, Kind: Modified}, nextEvent(t, ch))
+
+	renamed := filepath.Join(dir, "b.log")
+	require.NoError(t, os.Rename(created, renamed))
+	if runtime.GOOS == "windows" {
+		require.Equal(t, Event{Path: created, Kind: Removed}, nextEvent(t, ch))
+		require.Equal(t, Event{Path: renamed, Kind: Created}, nextEvent(t, ch))
+	} else {
+		require.Equal(t, Event{Path: renamed, OldPath: created, Kind: Renamed}, nextEvent(t, ch))
+	}
+
+	require.NoError(t, os.Remove(renamed))
+	require.Equal(t, Event{Path: renamed, Kind: Removed}, nextEvent(t, ch))
+}
+
+func TestWatchDirReadFailure(t *testing.T) {
+	dir := t.TempDir()
+	kept := filepath.Join(dir, "kept.log")
+	placeFile(t, kept, "kept")
+	// the listings fail until failing is reset
+	var failures, failing int32 = 0, 0
+	readWatchedDir = func(name string) ([]os.DirEntry, error) {
+		if atomic.LoadInt32(&failing) == 1 {
+			atomic.AddInt32(&failures, 1)
+			return nil, os.ErrPermission
+		}
+		return os.ReadDir(name)
+	}
+	defer func() { readWatchedDir = os.ReadDir }()
+	ctx, cancel := context.WithCancel(context.Background())
+	ch, err := Watch(ctx, dir, watchInterval)
+	require.NoError(t, err)
+	defer func() {
+		cancel()
+		for range ch {
+		}
+	}()
+
+	atomic.StoreInt32(&failing, 1)
+	for atomic.LoadInt32(&failures) < 3 {
+		time.Sleep(watchInterval)
+	}
+	atomic.StoreInt32(&failing, 0)
+	// the failed listings don't report kept removed then created again
+	created := filepath.Join(dir, "a.log")
+	placeFile(t, created, "a")
+	require.Equal(t, Event{Path: created, Kind: Created}, nextEvent(t, ch))
+	select {
+	case event := <-ch:
+		t.Fatalf("unexpected event %v", event)
+	case <-time.After(5 * watchInterval):
+	}
+}
+
+func TestWatchContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	ch, err := Watch(ctx, t.TempDir(), watchInterval)
+	require.NoError(t, err)
+	cancel()
+	select {
+	case _, ok := <-ch:
+		require.False(t, ok)
+	case <-time.After(2 * time.Second):
+		t.Fatal("channel is not closed")
+	}
+
+	_, err = Watch(context.Background(), "", watchInterval)
+	require.ErrorIs(t, err, InvalidPathError)
+	_, err = Watch(context.Background(), "file", 0)
+	require.Error(t, err)
+}
+
+func TestEventKindString(t *testing.T) {
+	require.Equal(t, "Created", Created.String())
+	require.Equal(t, "Modified", Modified.String())
+	require.Equal(t, "Removed", Removed.String())
+	require.Equal(t, "Renamed", Renamed.String())
+	require.Equal(t, "Unknown", EventKind(0).String())
+}
@@ -0,0 +1,61 @@
+package paths
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/stkali/utility/errors"
+)
+
+var ExecutableNotFoundError = errors.Error("executable file not found in $PATH")
+
+// Which searches for an executable named name in the directories of the PATH
+// environment variable like the shell does, and returns the first match.
+// If name contains a path separator, it is checked directly without searching PATH.
+// On windows, the extensions of the PATHEXT environment variable are tried too.
+func Which(name string) (string, error) {
+	files := which(name, true)
+	if len(files) == 0 {
+		return "", errors.Newf("%q: %s", name, ExecutableNotFoundError)
+	}
+	return files[0], nil
+}
+
+// WhichAll is like Which, but returns every match in PATH order.
+func WhichAll(name string) []string {
+	return which(name, false)
+}
+
+func which(name string, first bool) []string {
+	if name == "" {
+		return nil
+	}
+	if strings.ContainsAny(name, pathSeparators) {
+		for _, file := range executableCandidates(name) {
+			if IsExecutableFile(file) {
+				return []string{file}
+			}
+		}
+		return nil
+	}
+	var files []string
+	seen := make(map[string]struct{})
+	for _, dir := range filepath.SplitList(os.Getenv("PATH")) {
+		if dir == "" {
+			// unix shell semantics, an empty element means the current directory
+			dir = "."
+		}
+		for _, file := range executableCandidates(filepath.Join(dir, name)) {
+			if _, ok := seen[file]; ok || !IsExecutableFile(file) {
+				continue
+			}
+			if first {
+				return []string{file}
+			}
+			seen[file] = struct{}{}
+			files = append(files, file)
+		}
+	}
+	return files
+}
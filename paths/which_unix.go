@@ -0,0 +1,23 @@
+//go:build !windows
+
+package paths
+
+import "os"
+
+// pathSeparators are the characters that make a name a path instead of a command.
+const pathSeparators = "/"
+
+// IsExecutableFile reports whether path is a regular file (following symbolic
+// links) that the effective user is allowed to execute.
+func IsExecutableFile(path string) bool {
+	info, err := os.Stat(path)
+	if err != nil || !info.Mode().IsRegular() {
+		return false
+	}
+	return CanExec(path)
+}
+
+// executableCandidates returns the files to try for file.
+func executableCandidates(file string) []string {
+	return []string{file}
+}
@@ -0,0 +1,58 @@
+//go:build !windows
+
+package paths
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWhich(t *testing.T) {
+	dir1 := t.TempDir()
+	dir2 := t.TempDir()
+	dir3 := t.TempDir()
+	tool1 := filepath.Join(dir1, "tool")
+	tool2 := filepath.Join(dir2, "tool")
+	require.NoError(t, os.WriteFile(tool1, []byte("#!/bin/sh\n"), 0o755))
+	require.NoError(t, os.WriteFile(tool2, []byte("#!/bin/sh\n"), 0o755))
+	// not executable
+	require.NoError(t, os.WriteFile(filepath.Join(dir1, "data"), nil, 0o644))
+	// a directory is never executable
+	require.NoError(t, os.Mkdir(filepath.Join(dir3, "tool"), 0o755))
+	t.Setenv("PATH", strings.Join([]string{dir3, dir1, dir2, dir1}, string(os.PathListSeparator)))
+
+	file, err := Which("tool")
+	require.NoError(t, err)
+	require.Equal(t, tool1, file)
+	require.Equal(t, []string{tool1, tool2}, WhichAll("tool"))
+
+	_, err = Which("data")
+	require.ErrorIs(t, err, ExecutableNotFoundError)
+	require.Empty(t, WhichAll("data"))
+
+	_, err = Which("")
+	require.ErrorIs(t, err, ExecutableNotFoundError)
+
+	// a path is not searched in PATH
+	file, err = Which(tool2)
+	require.NoError(t, err)
+	require.Equal(t, tool2, file)
+	_, err = Which(filepath.Join(dir1, "data"))
+	require.ErrorIs(t, err, ExecutableNotFoundError)
+}
+
+func TestIsExecutableFile(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "tool")
+	require.NoError(t, os.WriteFile(file, nil, 0o755))
+	require.True(t, IsExecutableFile(file))
+	require.False(t, IsExecutableFile(dir))
+	require.False(t, IsExecutableFile(filepath.Join(dir, "not-existed")))
+
+	require.NoError(t, os.Chmod(file, 0o644))
+	require.False(t, IsExecutableFile(file))
+}
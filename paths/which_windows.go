@@ -0,0 +1,54 @@
+//go:build windows
+
+package paths
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// pathSeparators are the characters that make a name a path instead of a command.
+const pathSeparators = `/\:`
+
+// IsExecutableFile reports whether path is a regular file with one of the
+// PATHEXT extensions.
+func IsExecutableFile(path string) bool {
+	info, err := os.Stat(path)
+	if err != nil || !info.Mode().IsRegular() {
+		return false
+	}
+	return hasExecExtension(path)
+}
+
+// executableCandidates returns the files to try for file: the file itself if it
+// already has an executable extension, followed by file with each PATHEXT extension.
+func executableCandidates(file string) []string {
+	var candidates []string
+	if hasExecExtension(file) {
+		candidates = append(candidates, file)
+	}
+	for _, ext := range pathExtensions() {
+		candidates = append(candidates, file+ext)
+	}
+	return candidates
+}
+
+// pathExtensions returns the lower case extensions of the PATHEXT environment variable.
+func pathExtensions() []string {
+	pathExt := os.Getenv("PATHEXT")
+	if pathExt == "" {
+		pathExt = ".com;.exe;.bat;.cmd"
+	}
+	var exts []string
+	for _, ext := range filepath.SplitList(strings.ToLower(pathExt)) {
+		if ext == "" {
+			continue
+		}
+		if ext[0] != '.' {
+			ext = "." + ext
+		}
+		exts = append(exts, ext)
+	}
+	return exts
+}
@@ -0,0 +1,39 @@
+//go:build windows
+
+package paths
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWhich(t *testing.T) {
+	dir := t.TempDir()
+	bat := filepath.Join(dir, "tool.bat")
+	require.NoError(t, os.WriteFile(bat, nil, 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "tool.txt"), nil, 0o644))
+	t.Setenv("PATH", dir)
+	t.Setenv("PATHEXT", ".EXE;.BAT")
+
+	file, err := Which("tool")
+	require.NoError(t, err)
+	require.Equal(t, bat, file)
+
+	file, err = Which("tool.bat")
+	require.NoError(t, err)
+	require.Equal(t, bat, file)
+
+	_, err = Which("tool.txt")
+	require.ErrorIs(t, err, ExecutableNotFoundError)
+}
+
+func TestIsExecutableFile(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "tool.exe")
+	require.NoError(t, os.WriteFile(file, nil, 0o644))
+	require.True(t, IsExecutableFile(file))
+	require.False(t, IsExecutableFile(dir))
+}
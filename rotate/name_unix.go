@@ -0,0 +1,8 @@
+//go:build !windows
+
+package rotate
+
+// invalidNameChar reports whether r can't be in a file name: the separator and NUL.
+func invalidNameChar(r rune) bool {
+	return r == '/' || r == 0
+}
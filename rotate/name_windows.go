@@ -0,0 +1,11 @@
+//go:build windows
+
+package rotate
+
+import "strings"
+
+// invalidNameChar reports whether r can't be in a file name: the reserved characters
+// and the control characters.
+func invalidNameChar(r rune) bool {
+	return r < ' ' || strings.ContainsRune(`<>:"/\|?*`, r)
+}
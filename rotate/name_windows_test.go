@@ -0,0 +1,16 @@
+//go:build windows
+
+package rotate
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateTimeFormatWindows(t *testing.T) {
+	err := validateTimeFormat("2006-01-02T15:04:05")
+	require.ErrorIs(t, err, InvalidTimeFormatError)
+	require.ErrorContains(t, err, "invalid character ':'")
+	require.NoError(t, validateTimeFormat(DefaultBackupTimeFormat))
+}
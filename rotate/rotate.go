@@ -8,15 +8,20 @@
 package rotate
 
 import (
+	"bytes"
 	"compress/gzip"
+	"container/heap"
+	"context"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
 	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"sync/atomic"
+	"syscall"
 	"time"
 	"unicode"
 
@@ -26,29 +31,50 @@ import (
 )
 
 const (
-	writeMode                = 0o200
-	saltWidth                = 8
-	compressExtension        = ".gz"
-	noCleaning        uint32 = 0
-	cleaning          uint32 = 1
+	writeMode         = 0o200
+	saltWidth         = 8
+	compressExtension = ".gz"
+	maxStampedBuffer  = 64 << 10
+	// backupBatch is the number of directory entries read, and of backups deleted, at
+	// once by the cleanup.
+	backupBatch = 256
 )
 
 var (
 	// define errors for the package.
-	ModePermissionError          = errors.Error("invalid mode permission")
-	InvalidBackupPrefixError     = errors.Error("invalid backup prefix")
-	InvalidCompressionLevelError = errors.Error("invalid compression level")
+	ModePermissionError          = errors.WithCode(errors.Error("invalid mode permission"), errors.InvalidInput)
+	InvalidBackupPrefixError     = errors.WithCode(errors.Error("invalid backup prefix"), errors.InvalidInput)
+	InvalidCompressionLevelError = errors.WithCode(errors.Error("invalid compression level"), errors.InvalidInput)
+	NotRegularFileError          = errors.WithCode(errors.Error("rotating file is not a regular file"), errors.InvalidInput)
+	InvalidTimeFormatError       = errors.WithCode(errors.Error("invalid backup time format"), errors.InvalidInput)
+	DiskFullError                = errors.WithCode(errors.Error("not enough free disk space"), errors.Unavailable)
 
 	// for testing, we override the default functions used by the package.
 	osOpen     = os.Open
 	osOpenFile = os.OpenFile
 	osRemove   = os.Remove
 	osRename   = os.Rename
-	osReadDir  = os.ReadDir
+	osOpenDir  = openDir
 	osMkdirAll = os.MkdirAll
+	osStat     = os.Stat
 	ioCopy     = io.Copy
+	diskUsage  = paths.DiskUsage
+	moveFile   = paths.MoveFile
+	// findOlderThan selects the expired backups whose names aren't parsed
+	findOlderThan = paths.FindOlderThan
+	// makeFileSync is used instead of osOpenFile if SyncDirs is set
+	makeFileSync = paths.MakeFileSyncPerm
 )
 
+func init() {
+	errors.Register("rotate.bad_perm", ModePermissionError)
+	errors.Register("rotate.bad_backup_prefix", InvalidBackupPrefixError)
+	errors.Register("rotate.bad_compress_level", InvalidCompressionLevelError)
+	errors.Register("rotate.not_regular_file", NotRegularFileError)
+	errors.Register("rotate.bad_time_format", InvalidTimeFormatError)
+	errors.Register("rotate.disk_full", DiskFullError)
+}
+
 // Option is a configuration option for rotating files. default is `defaultOption`
 type Option struct {
 
@@ -89,6 +115,66 @@ type Option struct {
 
 	// BackupPrefix(default: "rotating-") is the prefix to use when creating backup files.
 	BackupPrefix string
+
+	// BackupTimeFormat(default: "") is the time layout naming the backup files, e.g.
+	// DefaultBackupTimeFormat gives "rotating-2024-09-19T20-24-31.123-app.log".
+	// "" means a random salt instead of the time of the rotation.
+	BackupTimeFormat string
+
+	// MinFreeSpace(default: 0) is the free disk space, in bytes, kept after every write.
+	// When a write would leave less, the oldest backups are deleted whatever Backups and
+	// MaxAge until the space is restored, and the write fails with DiskFullError if
+	// deleting all of them is not enough.
+	// <= 0 means no check of the free disk space.
+	MinFreeSpace int64
+
+	// RecheckInterval(default: 10 seconds) is the interval between two checks, by a
+	// write, that the rotating file wasn't deleted or truncated by another process, and
+	// between two queries of the free disk space when MinFreeSpace is set, the space used
+	// by the writes in between is counted from the previous query.
+	RecheckInterval time.Duration
+
+	// PrefixTimestamp(default: "") is the time layout of the timestamp written, followed
+	// by a space, at the start of every line, e.g. time.RFC3339. A line continued by the
+	// next write is stamped once.
+	// "" means no timestamp.
+	PrefixTimestamp string
+
+	// BackupDir(default: "") is the directory of the backup files, e.g. on an archive
+	// volume, it is created with DirPerm on the first rotation. The rotated file is
+	// moved there, copied and removed if the directory is on another device.
+	// "" means the directory of the rotating file.
+	BackupDir string
+
+	// BackupDirFallback(default: false) keeps the backup in the directory of the rotating
+	// file, with a warning, if it can't be moved to BackupDir, e.g. on a read-only mount.
+	// The rotation fails otherwise.
+	BackupDirFallback bool
+
+	// DirPerm(default: os.ModePerm) is the permission bits, before umask, of the
+	// directories created for the rotating file and the backups.
+	DirPerm os.FileMode
+
+	// SyncDirs(default: false) syncs the directory of the rotating file and the directories
+	// created for it once it's created, so that the new file survives a crash, see
+	// paths.MakeFileSyncPerm.
+	SyncDirs bool
+
+	// OnReopen(default: nil) is called when the rotating file is reopened with the
+	// reason and the size of the file before, e.g. so that the readers tracking offsets
+	// into the file reset them. It's called outside the lock of the file, so it can
+	// write to it.
+	OnReopen func(reason ReopenReason, oldSize int64)
+
+	// Header(default: nil) returns the data written at the start of every new rotating
+	// file, e.g. a line describing the file. backup is the path of the backup of the
+	// previous file for a rotation, "" if it isn't backed up or for a new file opened.
+	// It's called with the lock of the file held, so it must not write to it.
+	Header func(backup string) []byte
+
+	// CollectDeleteErrors(default: false) reports the failures of deleting the expired
+	// backup files as one error after trying all of them, instead of a warning per file.
+	CollectDeleteErrors bool
 }
 
 var defaultOption = &Option{
@@ -97,12 +183,82 @@ var defaultOption = &Option{
 	Backups:      30,
 	MaxAge:       lib.Month,
 	ModePerm:     0o644,
+	DirPerm:      os.ModePerm,
 	BackupPrefix: "rotating-",
+	// query the free disk space at most every 10 seconds
+	RecheckInterval: 10 * time.Second,
 	// Available compression levels are 1-9, 9 is highest compression.
 	// I think 6 is a good compromise between speed and compression ratio.
 	CompressLevel: 6,
 }
 
+// DefaultBackupTimeFormat is a BackupTimeFormat safe on every OS with millisecond precision.
+const DefaultBackupTimeFormat = "2006-01-02T15-04-05.000"
+
+// probeTime is rendered through BackupTimeFormat by validate, none of its components is
+// ambiguous, e.g. the hour 21 is "09" in a 12-hour clock.
+var probeTime = time.Date(2021, time.November, 23, 21, 34, 56, 0, time.UTC)
+
+// validate checks the combination of the options set by the SetOption functions.
+func (o *Option) validate() error {
+	return validateTimeFormat(o.BackupTimeFormat)
+}
+
+// validateTimeFormat checks that the layout renders filesystem-safe names, distinct for
+// the times a second apart, which are parsed back to the time they were rendered from.
+// The empty layout is valid, it means salted names.
+func validateTimeFormat(layout string) error {
+	if layout == "" {
+		return nil
+	}
+	name := probeTime.Format(layout)
+	if i := strings.IndexFunc(name, invalidNameChar); i >= 0 {
+		return errors.Newf("%s: %q renders %q with the invalid character %q",
+			InvalidTimeFormatError, layout, name, name[i])
+	}
+	if name == probeTime.Add(time.Second).Format(layout) {
+		return errors.Newf("%s: %q renders the same name for times a second apart",
+			InvalidTimeFormatError, layout)
+	}
+	parsed, err := time.Parse(layout, name)
+	if err != nil || !parsed.Equal(probeTime) {
+		return errors.Newf("%s: %q renders %q which isn't parsed back to the same time",
+			InvalidTimeFormatError, layout, name)
+	}
+	return nil
+}
+
+// ReopenReason is the reason why the rotating file is reopened, see Option.OnReopen.
+type ReopenReason int
+
+const (
+	// ExternalDeletion is the reopen of the file deleted or replaced by another process.
+	ExternalDeletion ReopenReason = iota
+	// ExternalTruncation is the file truncated by another process, it isn't reopened but
+	// counted from its new size.
+	ExternalTruncation
+	// ManualReopen is the reopen by RotatingFile.Reopen.
+	ManualReopen
+	// Rotation is the new file created by a rotation.
+	Rotation
+)
+
+var reopenReasons = []string{"ExternalDeletion", "ExternalTruncation", "ManualReopen", "Rotation"}
+
+// String implements fmt.Stringer.
+func (r ReopenReason) String() string {
+	if r >= ExternalDeletion && r <= Rotation {
+		return reopenReasons[r]
+	}
+	return fmt.Sprintf("ReopenReason(%d)", int(r))
+}
+
+// reopenEvent is a reopen recorded for OnReopen.
+type reopenEvent struct {
+	reason  ReopenReason
+	oldSize int64
+}
+
 // clone returns a copy of the Option.
 func (o *Option) clone() *Option {
 	cp := *o
@@ -121,31 +277,89 @@ func (b backupFile) String() string {
 	return fmt.Sprintf("backupFile(%s created at %s)", b.file, b.modTime)
 }
 
-// deleteFile deletes the specified file.
-// It prints a warning if the deletion fails.
-func deleteFile(file string) {
-	err := osRemove(file)
+// WarningTag tags the warnings of the package, see errors.Warningt.
+const WarningTag = "rotate"
+
+// warningTag is WarningTag.
+const warningTag = WarningTag
+
+// the operations removing backup files, see removeFile.
+const (
+	opCleanup  = "cleanup"
+	opCompress = "compress"
+	// opEmergency is the cleanup freeing disk space for MinFreeSpace
+	opEmergency = "emergency"
+)
+
+// warnf writes a formatted warning tagged with warningTag.
+func warnf(format string, a ...any) {
+	errors.Warningt(warningTag, errors.Newf(format, a...))
+}
+
+// warnt writes the warning err tagged with warningTag with ctx, so that it's collected
+// by the scope of ctx, see errors.WarningCtx. A nil err is ignored.
+func warnt(ctx context.Context, err error) {
 	if err != nil {
-		errors.Warningf("failed to remove file %q, err: %s", file, err)
+		errors.WarningCtx(ctx, &errors.TaggedError{Tag: warningTag, Err: err})
+	}
+}
+
+// noticef writes a formatted notice tagged with warningTag with ctx, e.g. a cleanup summary.
+func noticef(ctx context.Context, format string, a ...any) {
+	errors.NoticeCtx(ctx, &errors.TaggedError{Tag: warningTag, Err: errors.Newf(format, a...)})
+}
+
+// backupsText returns "1 backup" or "<n> backups" for the cleanup summaries.
+func backupsText(n int) string {
+	if n == 1 {
+		return "1 backup"
 	}
+	return strconv.Itoa(n) + " backups"
 }
 
-// deleteBackupFiles deletes the specified backup files.
-// It prints a warning if any deletion fails.
-func deleteBackupFiles(files []backupFile) {
+// removeFile removes the specified file, the error has the file and the operation op
+// removing it as fields, see errors.WithFields.
+func removeFile(file, op string) error {
+	if err := osRemove(file); err != nil {
+		return errors.WithFields(errors.Wrap(err, "failed to remove backup file"), "file", file, "op", op)
+	}
+	return nil
+}
+
+// deleteFile deletes the specified file.
+// It prints a warning with ctx if the deletion fails.
+func deleteFile(ctx context.Context, file, op string) {
+	warnt(ctx, removeFile(file, op))
+}
+
+// deleteBackupFiles deletes the specified backup files and keeps going after a failure,
+// it returns the number of deleted files. If collect is false the failed deletions are
+// added to the warning group, otherwise the failures are returned as one error.
+func deleteBackupFiles(files []backupFile, collect bool, group *errors.Group) (int, error) {
+	var c errors.Collector
+	removed := 0
 	for index := range files {
-		deleteFile(files[index].file)
+		err := removeFile(files[index].file, opCleanup)
+		switch {
+		case err == nil:
+			removed++
+		case collect:
+			c.Add(err)
+		default:
+			group.Add(err)
+		}
 	}
+	return removed, c.Err()
 }
 
 // compressFile uses gzip to compress the specified file and delete the original file.
-// If compression or deletion fails, it prints a warning and retains the source file
-// as much as possible
-func compressFile(src, dst string, level int) (err error) {
+// If compression or deletion fails, it prints a warning with ctx and retains the source
+// file as much as possible
+func compressFile(ctx context.Context, src, dst string, level int) (err error) {
 
 	f, err := osOpen(src)
 	if err != nil {
-		errors.Warningf("failed to read source file %q, err: %s", src, err)
+		warnt(ctx, errors.Newf("failed to read source file %q, err: %s", src, err))
 		return nil
 	}
 
@@ -153,32 +367,33 @@ func compressFile(src, dst string, level int) (err error) {
 		f.Close()
 		// if no error occurred, delete source file
 		if err == nil {
-			deleteFile(src)
+			deleteFile(ctx, src, opCompress)
 		}
 	}()
 
 	info, err := f.Stat()
 	if err != nil {
-		return errors.Newf("failed to get backup file %q info, err: %s", src, err)
+		return errors.Wrapf(err, "failed to get backup file %q info", src)
 	}
 
-	// os.O_TRUNC ensure file is truncated before writing to it.
-	gzipFile, err := osOpenFile(dst, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, info.Mode())
+	// the file is truncated before writing to it
+	gzipFile, err := osOpenFile(dst, paths.TruncateFlag, info.Mode())
 	if err != nil {
-		return errors.Newf("failed to open compressed backup file %q, err: %s", src, err)
+		return errors.Wrapf(err, "failed to open compressed backup file %q", src)
 	}
 
-	defer gzipFile.Close()
+	// a failure to flush the compressed data on close keeps the source file
+	defer errors.DeferClose(&err, gzipFile)
 
 	writer, err := gzip.NewWriterLevel(gzipFile, level)
 	if err != nil {
 		return errors.Newf("failed to create gzip level writer: %s", err)
 	}
 
-	defer writer.Close()
+	defer errors.DeferClose(&err, writer)
 
 	if _, err = ioCopy(writer, f); err != nil {
-		return errors.Newf("failed to compress rotating file %q, err: %s", src, err)
+		return errors.Wrapf(err, "failed to compress rotating file %q", src)
 	}
 	os.Chtimes(dst, info.ModTime(), info.ModTime())
 	return err
@@ -207,6 +422,8 @@ type RotatingFile struct {
 	file string
 	// folder is the abs path of the folder where the rotating files are stored.
 	folder string
+	// backupFolder is the abs path of BackupDir, it is folder if BackupDir is not set.
+	backupFolder string
 	// filename is the name of the rotating file with extension.
 	filename string
 
@@ -215,10 +432,36 @@ type RotatingFile struct {
 	timer        *time.Timer
 	rotatingTime time.Time
 
-	// cleaning (using an underscore prefix to avoid accidental use as a public field)
-	// is an atomic.Bool that indicates whether a garbage collection (cleanup) task
-	// is currently being executed.
-	cleaning uint32
+	// midLine is true if the last write didn't end with a newline, so that the next one
+	// continues the line without a timestamp, see PrefixTimestamp.
+	midLine bool
+	// prefix and stamped are the buffers of the timestamp and of the data with the
+	// timestamps.
+	prefix  []byte
+	stamped []byte
+
+	// size is the size of the current file, verifiedTime is the time it was last checked
+	// for an external deletion or truncation, see verify.
+	size         int64
+	verifiedTime time.Time
+	// reopens counts the reopens by reason, it is accessed atomically. pendingReopens are
+	// the reopens for OnReopen, it is called by unlock.
+	reopens        [Rotation + 1]int64
+	pendingReopens []reopenEvent
+
+	// free is the free disk space left by MinFreeSpace, it is queried at most once per
+	// RecheckInterval, at checkedTime, and decreased by the writes in between.
+	free        int64
+	checkedTime time.Time
+
+	// emergencyCleanups counts the cleanups run because of MinFreeSpace, it is accessed
+	// atomically.
+	emergencyCleanups int64
+
+	// cleanMtx is held by the running cleanup of the backups, see tidyBackups. It's
+	// never waited for with mtx held by Close, the warnings of the cleanup may be written
+	// to the file, e.g. by a logger bridging them.
+	cleanMtx sync.Mutex
 }
 
 // String implements the Stringer interface for RotatingFile.
@@ -242,34 +485,208 @@ func (r *RotatingFile) String() string {
 // in practice, we usually don't want this to happen. Therefore, we choose to make the
 // determination after the write so that at least one super-massive write can be performed,
 // both to avoid unnecessary errors and for more extreme cases.
+//
+// If PrefixTimestamp is set, the timestamps count in the size of the file but not in
+// the returned number of bytes, which is len(b) on success as required by io.Writer.
 func (r *RotatingFile) Write(b []byte) (int, error) {
 
 	r.mtx.Lock()
-	defer r.mtx.Unlock()
+	defer r.unlock()
 	// ensure the writer is open
 	if r.writer == nil {
 		if err := r.openWriter(); err != nil {
 			return 0, err
 		}
+		errors.Assert(r.writer != nil, "rotate: writer must be non-nil after openWriter")
+	} else if err := r.verify(); err != nil {
+		return 0, err
+	}
+	data := b
+	if r.option.PrefixTimestamp != "" {
+		data = r.stamp(b)
+	}
+	if r.option.MinFreeSpace > 0 {
+		if err := r.reserveSpace(int64(len(data))); err != nil {
+			return 0, err
+		}
 	}
-	n, err := r.writer.Write(b)
+	n, err := r.writer.Write(data)
+	r.size += int64(n)
 	if err != nil {
-		return n, errors.Newf("failed to write %s to file: %s, err: %s",
-			lib.ToString(b), r.filename, err)
+		// the bytes of the timestamps aren't counted
+		return lib.Max(n-(len(data)-len(b)), 0), errors.Wrapf(err, "failed to write %s to file: %s", lib.ToString(b), r.filename)
 	}
 	// update used space if MaxSize is set
 	if r.option.MaxSize > 0 {
 		r.used += int64(n)
+		if r.used < 0 {
+			// guarded so that the arguments aren't boxed on every write
+			errors.Assert(false, "rotate: used must never be negative, got %d", r.used)
+		}
 		if r.used > r.option.MaxSize {
 			if err = r.rotate(); err != nil {
 				return 0, err
 			}
 		}
 	}
-	return n, nil
+	return len(b), nil
+}
+
+// stamp returns b with the current time formatted by PrefixTimestamp at the start of
+// every line, the line continued from the previous write excepted.
+func (r *RotatingFile) stamp(b []byte) []byte {
+	if len(b) == 0 {
+		return b
+	}
+	r.prefix = time.Now().AppendFormat(r.prefix[:0], r.option.PrefixTimestamp)
+	r.prefix = append(r.prefix, ' ')
+	buf := r.stamped[:0]
+	if !r.midLine {
+		buf = append(buf, r.prefix...)
+	}
+	for {
+		index := bytes.IndexByte(b, '\n')
+		// a trailing newline ends the line, the next write stamps the new one
+		if index < 0 || index == len(b)-1 {
+			buf = append(buf, b...)
+			break
+		}
+		buf = append(buf, b[:index+1]...)
+		buf = append(buf, r.prefix...)
+		b = b[index+1:]
+	}
+	r.midLine = buf[len(buf)-1] != '\n'
+	// don't retain the buffer of a huge write
+	if cap(buf) <= maxStampedBuffer {
+		r.stamped = buf
+	}
+	return buf
+}
+
+// reserveSpace ensures that writing size bytes leaves MinFreeSpace on the disk, it
+// deletes the oldest backups if not. A failure to query the free space is reported as a
+// warning and the write is allowed.
+func (r *RotatingFile) reserveSpace(size int64) error {
+	now := time.Now()
+	if r.checkedTime.IsZero() || now.Sub(r.checkedTime) >= r.option.RecheckInterval {
+		usage, err := diskUsage(r.folder)
+		if err != nil {
+			errors.Warningt(warningTag, err)
+			return nil
+		}
+		r.free = int64(usage.Available)
+		r.checkedTime = now
+	}
+	if r.free-size < r.option.MinFreeSpace {
+		if err := r.emergencyCleanup(size); err != nil {
+			return err
+		}
+	}
+	r.free -= size
+	return nil
+}
+
+// emergencyCleanup deletes the oldest backups, whatever Backups and MaxAge, until
+// writing size bytes leaves MinFreeSpace on the disk. It returns DiskFullError, which
+// wraps ENOSPC, if no backup is left to delete. The backups are streamed by EachBackup,
+// only the backupBatch oldest ones are held at once. It's called with mtx held, so it
+// doesn't wait for the running cleanup of the backups, the backups deleted meanwhile by
+// the cleanup are skipped.
+func (r *RotatingFile) emergencyCleanup(size int64) error {
+	atomic.AddInt64(&r.emergencyCleanups, 1)
+	if r.cleanMtx.TryLock() {
+		defer r.cleanMtx.Unlock()
+	}
+	removed := 0
+	defer func() {
+		if removed > 0 {
+			noticef(context.Background(), "emergency cleanup removed %s of %s, %d bytes are free", backupsText(removed), r.filename, r.free)
+		}
+	}()
+	// the backups failed to delete are skipped by the next batches
+	failed := make(map[string]bool)
+	for {
+		backups, err := r.oldestBackups(failed)
+		if err != nil {
+			return err
+		}
+		for index := 0; ; index++ {
+			usage, err := diskUsage(r.folder)
+			if err != nil {
+				return err
+			}
+			r.free = int64(usage.Available)
+			r.checkedTime = time.Now()
+			if r.free-size >= r.option.MinFreeSpace {
+				return nil
+			}
+			if index == len(backups) {
+				break
+			}
+			err = removeFile(backups[index].file, opEmergency)
+			switch {
+			case err == nil:
+				removed++
+			case !errors.Is(err, os.ErrNotExist):
+				failed[backups[index].file] = true
+				errors.Warningt(warningTag, err)
+			}
+		}
+		if len(backups) == 0 {
+			return errors.Newf("%s: %s: %d bytes are free, %d bytes are required",
+				DiskFullError, syscall.ENOSPC, r.free, r.option.MinFreeSpace+size)
+		}
+	}
+}
+
+// oldestBackups returns the backupBatch oldest backups but skip, sorted by modification
+// time.
+func (r *RotatingFile) oldestBackups(skip map[string]bool) ([]backupFile, error) {
+	// oldest holds the oldest backups, the newest of them on top
+	oldest := &newestBackupHeap{}
+	err := r.EachBackup(func(info BackupInfo) bool {
+		if skip[info.Path] {
+			return true
+		}
+		heap.Push(oldest, backupFile{file: info.Path, modTime: info.ModTime})
+		if oldest.Len() > backupBatch {
+			heap.Pop(oldest)
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	backups := oldest.backupHeap
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].modTime.Before(backups[j].modTime)
+	})
+	return backups, nil
+}
+
+// Stats is the statistics of a RotatingFile, see RotatingFile.Stats.
+type Stats struct {
+	// EmergencyCleanups is the number of cleanups run because of MinFreeSpace.
+	EmergencyCleanups int64
+	// Reopens are the numbers of reopens of the rotating file by reason, e.g.
+	// Reopens[Rotation].
+	Reopens [Rotation + 1]int64
+}
+
+// Stats returns the statistics of the rotating file.
+func (r *RotatingFile) Stats() Stats {
+	stats := Stats{
+		EmergencyCleanups: atomic.LoadInt64(&r.emergencyCleanups),
+	}
+	for reason := range stats.Reopens {
+		stats.Reopens[reason] = atomic.LoadInt64(&r.reopens[reason])
+	}
+	return stats
 }
 
 // WriteString writes the specified string to the rotating file.
+// The string is passed to Write without copying, it relies on the underlying *os.File
+// neither modifying nor retaining the slice after Write returns.
 func (r *RotatingFile) WriteString(s string) (int, error) {
 	return r.Write(lib.ToBytes(s))
 }
@@ -277,20 +694,26 @@ func (r *RotatingFile) WriteString(s string) (int, error) {
 // Close implements the io.Closer interface.
 // It closes the rotating file and releases any associated resources.
 func (r *RotatingFile) Close() error {
-	r.mtx.Lock()
-	defer r.mtx.Unlock()
+	return r.CloseContext(context.Background())
+}
+
+// CloseContext is like Close, but the warnings of the final cleanup of the backups are
+// written with ctx, e.g. to collect them with errors.Scope:
+//
+//	err, warnings := errors.Scope(ctx, f.CloseContext)
+func (r *RotatingFile) CloseContext(ctx context.Context) error {
 	// close the current writer
+	r.mtx.Lock()
 	err := r.close()
+	r.mtx.Unlock()
 	if err != nil {
 		return err
 	}
-	// wait for the cleanup goroutine to finish
-	for atomic.LoadUint32(&r.cleaning) == cleaning {
-	}
-	// ensure backup files is tidied up
-	r.tidyBackups()
-	for atomic.LoadUint32(&r.cleaning) == cleaning {
-	}
+	// wait for the cleanup goroutine to finish without the lock of the file, then ensure
+	// backup files is tidied up
+	r.cleanMtx.Lock()
+	defer r.cleanMtx.Unlock()
+	r.tidyWarn(ctx)
 	return nil
 }
 
@@ -299,11 +722,12 @@ func (r *RotatingFile) Close() error {
 func (r *RotatingFile) close() error {
 	if closer, ok := r.writer.(io.Closer); ok {
 		if err := closer.Close(); err != nil {
-			return errors.Newf("failed to close writer: %s, err: %s", r.writer, err)
+			return errors.Wrapf(err, "failed to close writer: %s", r.writer)
 		}
 	}
 	r.writer = nil
 	r.used = 0
+	r.size = 0
 	if r.timer != nil {
 		r.timer.Stop()
 	}
@@ -315,18 +739,25 @@ func (r *RotatingFile) close() error {
 // If the file already exists, it will be opened for appending.
 func (r *RotatingFile) openWriter() error {
 
-	writer, err := r.createFile(r.file, os.O_WRONLY|os.O_APPEND|os.O_CREATE, r.option.ModePerm)
+	writer, err := r.createFile(r.file, paths.AppendFlag, r.option.ModePerm)
+	if err != nil {
+		return errors.Wrapf(err, "failed to open rotating file: %q", r.file)
+	}
+	info, err := writer.Stat()
 	if err != nil {
-		return errors.Newf("failed to open rotating file: %q, err: %s", r.file, err)
+		writer.Close()
+		return errors.Wrapf(err, "failed to stat rotating file: %q", r.file)
+	}
+	// the writer is set first so that a rotation closes it
+	r.writer = writer
+	r.size = info.Size()
+	r.verifiedTime = time.Now()
+	if r.size == 0 {
+		r.writeHeader("")
 	}
 	// update used space if MaxSize is set
 	if r.option.MaxSize > 0 {
-		var info os.FileInfo
-		info, err = writer.Stat()
-		if err != nil {
-			return errors.Newf("failed to stat rotating file: %q, err: %s", r.file, err)
-		}
-		r.used = info.Size()
+		r.used = r.size
 		// determines whether the left file meets the rotation condition
 		if r.used > r.option.MaxSize {
 			if err = r.rotate(); err != nil {
@@ -334,19 +765,107 @@ func (r *RotatingFile) openWriter() error {
 			}
 		}
 	}
-	r.writer = writer
+	return nil
+}
+
+// verify reopens the rotating file if it was deleted or replaced since it was opened,
+// and counts it from its size if it was truncated. The file is checked at most once per
+// RecheckInterval, a failure to check it is reported as a warning.
+func (r *RotatingFile) verify() error {
+	fd, ok := r.writer.(*os.File)
+	now := time.Now()
+	if !ok || now.Sub(r.verifiedTime) < r.option.RecheckInterval {
+		return nil
+	}
+	r.verifiedTime = now
+	opened, err := fd.Stat()
+	if err != nil {
+		errors.Warningt(warningTag, errors.Wrapf(err, "failed to stat rotating file: %q", r.file))
+		return nil
+	}
+	info, err := osStat(r.file)
+	if err != nil && !os.IsNotExist(err) {
+		errors.Warningt(warningTag, errors.Wrapf(err, "failed to stat rotating file: %q", r.file))
+		return nil
+	}
+	oldSize := r.size
+	if err != nil || !os.SameFile(opened, info) {
+		if err = r.reopen(); err != nil {
+			return err
+		}
+		r.reopened(ExternalDeletion, oldSize)
+		return nil
+	}
+	if info.Size() < oldSize {
+		r.size = info.Size()
+		if r.option.MaxSize > 0 {
+			r.used = r.size
+		}
+		r.reopened(ExternalTruncation, oldSize)
+	}
+	return nil
+}
+
+// reopen closes the writer and opens the rotating file again, unlike close the timer
+// of the time-based rotation keeps running.
+func (r *RotatingFile) reopen() error {
+	if closer, ok := r.writer.(io.Closer); ok {
+		if err := closer.Close(); err != nil {
+			return errors.Wrapf(err, "failed to close writer: %s", r.writer)
+		}
+	}
+	r.writer = nil
+	return r.openWriter()
+}
+
+// reopened counts a reopen and records it for OnReopen, which is called by unlock.
+func (r *RotatingFile) reopened(reason ReopenReason, oldSize int64) {
+	atomic.AddInt64(&r.reopens[reason], 1)
+	if r.option.OnReopen != nil {
+		r.pendingReopens = append(r.pendingReopens, reopenEvent{reason: reason, oldSize: oldSize})
+	}
+}
+
+// unlock unlocks mtx and calls OnReopen for the reopens recorded meanwhile, outside the
+// mutex so that the callback can use the rotating file.
+func (r *RotatingFile) unlock() {
+	events := r.pendingReopens
+	r.pendingReopens = nil
+	r.mtx.Unlock()
+	for _, e := range events {
+		r.option.OnReopen(e.reason, e.oldSize)
+	}
+}
+
+// Reopen closes and reopens the rotating file, e.g. after it was moved by an external
+// tool, OnReopen is called with ManualReopen.
+func (r *RotatingFile) Reopen() error {
+	r.mtx.Lock()
+	defer r.unlock()
+	oldSize := r.size
+	if err := r.reopen(); err != nil {
+		return err
+	}
+	r.reopened(ManualReopen, oldSize)
 	return nil
 }
 
 // createFile creates a new file with the specified name and permission bits.
-// It creates the folder if it does not exist.
+// It creates the folder if it does not exist, durably if SyncDirs is set.
 func (r *RotatingFile) createFile(file string, flag int, perm os.FileMode) (fd *os.File, err error) {
+	if r.option.SyncDirs {
+		fd, err = makeFileSync(file, flag, perm, r.option.DirPerm)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to create rotating file: %s", file)
+		}
+		return fd, nil
+	}
 	fd, err = osOpenFile(file, flag, perm)
 	if err != nil {
 		if os.IsNotExist(err) {
-			err = osMkdirAll(r.folder, os.ModePerm)
+			err = osMkdirAll(r.folder, r.option.DirPerm)
 			if err != nil {
-				return nil, errors.Newf("failed to create rotating folder: %s, err: %s", r.folder, err)
+				return nil, errors.Wrapf(err, "failed to create rotating folder: %s", r.folder)
 			}
 			return osOpenFile(file, flag, perm)
 		}
@@ -354,33 +873,43 @@ func (r *RotatingFile) createFile(file string, flag int, perm os.FileMode) (fd *
 	return fd, err
 }
 
+// Rotate rotates the file now regardless of MaxSize and Duration: the current file is
+// backed up and a new empty file is created.
+func (r *RotatingFile) Rotate() error {
+	r.mtx.Lock()
+	defer r.unlock()
+	return r.rotate()
+}
+
 // rotate closes the current file descriptor and creates a new rotated file.
 // It also attempts to clean up and compress the backups files asynchronously.
 func (r *RotatingFile) rotate() error {
+	oldSize := r.size
 	err := r.close()
 	if err != nil {
-		return errors.Newf("failed to close file: %s, err: %s", r.file, err)
+		return errors.Wrapf(err, "failed to close file: %s", r.file)
 	}
 	// when both Backups and MaxAge are not equal to 0, a new file is created.
+	var backupFile string
 	if r.option.Backups != 0 && r.option.MaxAge != 0 {
-		backupFile := filepath.Join(r.folder, r.nextBackupFilename())
-		err = osRename(r.file, backupFile)
+		backupFile, err = r.backup()
 		if err != nil {
-			if errors.Is(err, os.ErrNotExist) {
-				errors.Warningf("failed to backup file: %q, err: %s", r.file, err)
-			} else {
-				return errors.Newf("failed to backup file: %q, err: %s", backupFile, err)
+			if !errors.Is(err, os.ErrNotExist) {
+				return errors.Wrapf(err, "failed to backup file: %q", backupFile)
 			}
+			warnf("failed to backup file: %q, err: %s", r.file, err)
+			backupFile = ""
 		}
 		// cleanup expired backups and compress backup files
-		r.tidyBackups()
+		r.tidyBackups(context.Background())
 	}
 	// ensure the file is truncated before writing to it.
-	fd, err := r.createFile(r.file, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, r.option.ModePerm)
+	fd, err := r.createFile(r.file, paths.TruncateFlag, r.option.ModePerm)
 	if err != nil {
 		return errors.Newf("failed to open rotating file: %s", err)
 	}
 	r.writer = fd
+	r.reopened(Rotation, oldSize)
 	// update rotatingTime and reset timer if used time-based rotation is enabled
 	if r.option.Duration > 0 {
 		r.rotatingTime = time.Now()
@@ -389,128 +918,412 @@ func (r *RotatingFile) rotate() error {
 	if r.option.MaxSize > 0 {
 		r.used = 0
 	}
+	r.writeHeader(backupFile)
 	return nil
 }
 
+// writeHeader writes the Header at the start of the new rotating file, backup is the
+// backup of the previous one. A failed write is reported as a warning.
+func (r *RotatingFile) writeHeader(backup string) {
+	if r.option.Header == nil {
+		return
+	}
+	data := r.option.Header(backup)
+	if len(data) == 0 {
+		return
+	}
+	n, err := r.writer.Write(data)
+	r.size += int64(n)
+	if r.option.MaxSize > 0 {
+		r.used += int64(n)
+	}
+	if err != nil {
+		warnf("failed to write header to file: %s, err: %s", r.filename, err)
+	}
+}
+
 // nextBackupFilename returns the name of the next backup file.
 func (r *RotatingFile) nextBackupFilename() string {
 	sb := &strings.Builder{}
 	sb.Grow(len(r.option.BackupPrefix) + saltWidth + 1 + len(r.filename))
 	sb.WriteString(r.option.BackupPrefix)
-	text := lib.RandString(saltWidth)
+	if r.option.BackupTimeFormat != "" {
+		sb.WriteString(time.Now().Format(r.option.BackupTimeFormat))
+		sb.WriteByte('-')
+		sb.WriteString(r.filename)
+		return sb.String()
+	}
+	// the global math/rand source is not seeded before go1.20, restarted processes
+	// would repeat the same salts, prefer crypto/rand
+	text, err := lib.SecureRandString(saltWidth, lib.Alphanumeric)
+	if err != nil {
+		text = lib.RandStringCharset(saltWidth, lib.Alphanumeric)
+	}
 	sb.WriteString(text)
 	sb.WriteByte('-')
 	sb.WriteString(r.filename)
 	return sb.String()
 }
 
-// tidyBackups deletes the expired backups and compresses backup files
-func (r *RotatingFile) tidyBackups() {
+// tidyBackups deletes the expired backups and compresses backup files in a goroutine,
+// the warnings are written with ctx.
+func (r *RotatingFile) tidyBackups(ctx context.Context) {
 	// existed a running cleanup goroutine
-	if !atomic.CompareAndSwapUint32(&r.cleaning, noCleaning, cleaning) {
+	if !r.cleanMtx.TryLock() {
 		return
 	}
 	// start a cleanup goroutine to delete the expired backups
 	go func() {
-		defer atomic.StoreUint32(&r.cleaning, noCleaning)
-		bks, err := r.cleanBackups()
-		errors.Warning(err)
-		// compress backup files if compressLevel > 0
-		if r.option.CompressLevel <= 0 {
-			return
-		}
-		for _, bk := range bks {
-			// avoid compressed file
-			if !strings.HasSuffix(bk.file, compressExtension) {
-				errors.Warning(compressFile(
-					bk.file,
-					bk.file+compressExtension,
-					r.option.CompressLevel))
-			}
-		}
+		defer r.cleanMtx.Unlock()
+		r.tidyWarn(ctx)
 	}()
 }
 
-// cleanBackups performs garbage collection (cleanup) of old backup files.
-// It deletes the oldest backup files until the maximum number of backup files is reached.
-func (r *RotatingFile) cleanBackups() ([]backupFile, error) {
+// tidyWarn runs tidy, its failure is written as a warning with ctx, and a panic too
+// instead of crashing the process.
+func (r *RotatingFile) tidyWarn(ctx context.Context) {
+	err := errors.Safely(func() error {
+		warnt(ctx, r.tidy(ctx))
+		return nil
+	})
+	warnt(ctx, errors.Wrapf(err, "failed to tidy backups of %s", r.filename))
+}
+
+// CleanBackups deletes the expired backups and compresses the remaining ones like the
+// cleanup run after a rotation, but it waits for the running cleanup and returns when
+// it's done. It returns the failure of the cleanup, e.g. the backups can't be listed,
+// the other failures are warnings written with ctx, e.g. to collect them with errors.Scope:
+//
+//	err, warnings := errors.Scope(ctx, f.CleanBackups)
+func (r *RotatingFile) CleanBackups(ctx context.Context) error {
+	// wait for the running cleanup goroutine, they would delete the same files
+	r.cleanMtx.Lock()
+	defer r.cleanMtx.Unlock()
+	err := errors.Safely(func() error {
+		return r.tidy(ctx)
+	})
+	return errors.Wrapf(err, "failed to clean backups of %s", r.filename)
+}
 
-	backups, err := r.sortBackups()
+// tidy deletes the expired backups and compresses the remaining ones if CompressLevel
+// is set, it returns the error of the cleanup, the failed compressions are warnings
+// written with ctx.
+func (r *RotatingFile) tidy(ctx context.Context) error {
+	// the backups to compress if compressLevel > 0
+	bks, err := r.cleanBackups(ctx)
+	for _, bk := range bks {
+		warnt(ctx, compressFile(ctx, bk.file, bk.file+compressExtension, r.option.CompressLevel))
+	}
+	return err
+}
+
+// cleanBackups performs garbage collection (cleanup) of old backup files: it deletes
+// the backups older than MaxAge and the oldest ones beyond Backups. The backups are
+// streamed by EachBackup and deleted by batches, so that only the Backups newest ones are
+// held in memory. The age of a backup is given by the time of its name if it's parsed
+// with BackupTimeFormat, else by its modification time, see expiredBackups. It returns
+// the remaining backups to compress, sorted by modification time, if CompressLevel is
+// set. The failed deletions and the summary of the cleanup are written with ctx.
+func (r *RotatingFile) cleanBackups(ctx context.Context) ([]backupFile, error) {
+	var (
+		expired  time.Time
+		unparsed map[string]bool
+	)
+	if r.option.MaxAge > 0 {
+		expired = time.Now().Add(-r.option.MaxAge)
+		var err error
+		if unparsed, err = r.expiredBackups(); err != nil {
+			return nil, err
+		}
+	}
+	// report the failed deletions of the run as one warning
+	group := errors.NewGroup(warningTag + ": cleanup backups of " + r.filename)
+	defer group.FlushCtx(ctx)
+	var (
+		c       errors.Collector
+		removed int
+		// newest holds the Backups newest backups not expired, the oldest on top
+		newest  backupHeap
+		kept    []backupFile
+		pending []backupFile
+	)
+	defer func() {
+		if removed > 0 {
+			noticef(ctx, "cleanup removed %s of %s", backupsText(removed), r.filename)
+		}
+	}()
+	deletePending := func() {
+		n, err := deleteBackupFiles(pending, r.option.CollectDeleteErrors, group)
+		removed += n
+		c.Add(err)
+		pending = pending[:0]
+	}
+	err := r.EachBackup(func(info BackupInfo) bool {
+		bk := backupFile{file: info.Path, modTime: info.ModTime}
+		switch {
+		case !expired.IsZero() && r.backupExpired(info.Path, expired, unparsed):
+			pending = append(pending, bk)
+		case r.option.Backups > 0:
+			heap.Push(&newest, bk)
+			if newest.Len() > r.option.Backups {
+				pending = append(pending, heap.Pop(&newest).(backupFile))
+			}
+		case r.option.CompressLevel > 0 && !info.Compressed:
+			kept = append(kept, bk)
+		}
+		if len(pending) >= backupBatch {
+			deletePending()
+		}
+		return true
+	})
 	if err != nil {
 		return nil, err
 	}
+	deletePending()
+	if r.option.CompressLevel > 0 {
+		for _, bk := range newest {
+			if !strings.HasSuffix(bk.file, compressExtension) {
+				kept = append(kept, bk)
+			}
+		}
+		sort.Slice(kept, func(i, j int) bool {
+			return kept[i].modTime.Before(kept[j].modTime)
+		})
+	}
+	return kept, c.Err()
+}
 
-	length := len(backups)
-	if length == 0 {
-		return nil, nil
+// expiredBackups returns the set of the backups older than MaxAge whose names aren't
+// parsed with BackupTimeFormat, e.g. the salted names, selected by their modification
+// time with paths.FindOlderThan.
+func (r *RotatingFile) expiredBackups() (map[string]bool, error) {
+	expired := map[string]bool{}
+	for _, folder := range r.backupFolders() {
+		dir := filepath.Clean(folder)
+		files, err := findOlderThan(folder, r.option.MaxAge, func(path string) bool {
+			if filepath.Dir(path) != dir {
+				return false
+			}
+			name := filepath.Base(path)
+			_, parsed := r.backupNameTime(name)
+			return r.isBackupName(name) && !parsed
+		})
+		if err != nil {
+			// BackupDir is created on the first rotation
+			if folder != r.folder && errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+			return nil, errors.Wrap(err, "failed to list backup files")
+		}
+		for _, file := range files {
+			expired[file] = true
+		}
+	}
+	return expired, nil
+}
+
+// backupExpired reports whether the backup file is older than expired, by the time of
+// its name if it's parsed, else by its presence in unparsed, see expiredBackups.
+func (r *RotatingFile) backupExpired(file string, expired time.Time, unparsed map[string]bool) bool {
+	if t, ok := r.backupNameTime(filepath.Base(file)); ok {
+		return t.Before(expired)
 	}
+	return unparsed[file]
+}
 
-	deleteIndex := 0
-	// calculate the index of the oldest backup file to delete based on Backups
-	if r.option.Backups > 0 {
-		if left := length - r.option.Backups; left > 0 {
-			deleteIndex = left
+// backupNameTime returns the time of the rotation rendered with BackupTimeFormat in the
+// backup name, false if BackupTimeFormat is empty or the name isn't parsed. The counter
+// appended by freeBackupFile is ignored.
+func (r *RotatingFile) backupNameTime(name string) (time.Time, bool) {
+	if r.option.BackupTimeFormat == "" {
+		return time.Time{}, false
+	}
+	stamp := strings.TrimPrefix(name, r.option.BackupPrefix)
+	stamp = strings.TrimSuffix(stamp, compressExtension)
+	stamp = strings.TrimSuffix(stamp, "-"+r.filename)
+	t, err := time.ParseInLocation(r.option.BackupTimeFormat, stamp, time.Local)
+	if err == nil {
+		return t, true
+	}
+	if i := strings.LastIndexByte(stamp, '.'); i >= 0 {
+		if _, convErr := strconv.Atoi(stamp[i+1:]); convErr == nil {
+			t, err = time.ParseInLocation(r.option.BackupTimeFormat, stamp[:i], time.Local)
 		}
 	}
+	return t, err == nil
+}
 
-	// calculate the index of the oldest backup file to delete based on MaxAge
-	if r.option.MaxAge > 0 {
-		expired := time.Now().Add(-r.option.MaxAge)
-		index := findExpiredIndex(backups, expired)
-		if index == -1 {
-			deleteIndex = length
-		} else {
-			deleteIndex = lib.Max(index, deleteIndex)
+// backup moves the rotating file to a new backup file in backupFolder, or renames it in
+// folder if it can't and BackupDirFallback is set. It returns the backup file.
+func (r *RotatingFile) backup() (string, error) {
+	name := r.nextBackupFilename()
+	if r.backupFolder == r.folder {
+		backupFile := r.freeBackupFile(r.folder, name)
+		return backupFile, osRename(r.file, backupFile)
+	}
+	backupFile := r.freeBackupFile(r.backupFolder, name)
+	err := osMkdirAll(r.backupFolder, r.option.DirPerm)
+	if err == nil {
+		err = moveFile(r.file, backupFile)
+		if err == nil || errors.Is(err, os.ErrNotExist) {
+			return backupFile, err
 		}
 	}
-	if deleteIndex > 0 {
-		deleteBackupFiles(backups[:deleteIndex])
+	if !r.option.BackupDirFallback {
+		return backupFile, err
+	}
+	warnf("failed to move backup to %q, keep it in %q, err: %s", r.backupFolder, r.folder, err)
+	backupFile = r.freeBackupFile(r.folder, name)
+	return backupFile, osRename(r.file, backupFile)
+}
+
+// freeBackupFile returns the path of the backup name in folder, a counter is appended
+// to the stamp of name while the backup, or its compressed file, exists. Two rotations
+// within the resolution of BackupTimeFormat give the same name, the rename would
+// overwrite the first backup.
+func (r *RotatingFile) freeBackupFile(folder, name string) string {
+	stamp := strings.TrimSuffix(name, "-"+r.filename)
+	backupFile := filepath.Join(folder, name)
+	for count := 1; r.backupExists(backupFile); count++ {
+		backupFile = filepath.Join(folder, stamp+"."+strconv.Itoa(count)+"-"+r.filename)
 	}
-	return backups[deleteIndex:], nil
+	return backupFile
 }
 
-// findExpiredIndex returns the index of the first backup file that is expired.
-func findExpiredIndex(backups []backupFile, expired time.Time) int {
-	for index := range backups {
-		if expired.Equal(backups[index].modTime) || expired.Before(backups[index].modTime) {
-			return index
+// backupExists reports whether the backup file, or its compressed file, exists.
+func (r *RotatingFile) backupExists(backupFile string) bool {
+	for _, file := range []string{backupFile, backupFile + compressExtension} {
+		if _, err := osStat(file); err == nil {
+			return true
 		}
 	}
-	return -1
+	return false
+}
+
+// backupFolders returns the folders of the backup files, folder holds the fallback
+// backups if BackupDirFallback is set.
+func (r *RotatingFile) backupFolders() []string {
+	if r.backupFolder != r.folder && r.option.BackupDirFallback {
+		return []string{r.backupFolder, r.folder}
+	}
+	return []string{r.backupFolder}
+}
+
+// dirReader is a directory read by batches, see osOpenDir.
+type dirReader interface {
+	ReadDir(n int) ([]os.DirEntry, error)
+	Close() error
 }
 
-// sortBackups returns a list of backup files sorted by modification time.
-func (r *RotatingFile) sortBackups() ([]backupFile, error) {
-	files, err := osReadDir(r.folder)
+// openDir opens the directory name to read it by batches.
+func openDir(name string) (dirReader, error) {
+	fd, err := os.Open(name)
 	if err != nil {
-		return nil, errors.Newf("failed to list backup files, err: %s", err)
+		return nil, err
 	}
-	backups := make([]backupFile, 0, len(files))
-	var info os.FileInfo
-	for index := range files {
-		name := files[index].Name()
+	return fd, nil
+}
+
+// BackupInfo describes a backup file, see RotatingFile.EachBackup.
+type BackupInfo struct {
+	// Path is the path of the backup file.
+	Path string
+	// ModTime is the modification time of the backup file.
+	ModTime time.Time
+	// Size is the size of the backup file in bytes.
+	Size int64
+	// Compressed reports whether the backup file is compressed, see CompressLevel.
+	Compressed bool
+}
 
-		if files[index].IsDir() ||
-			!strings.HasPrefix(name, r.option.BackupPrefix) ||
-			// backup file and compressed file
-			!(strings.HasSuffix(name, r.filename) || strings.HasSuffix(name, r.filename+compressExtension)) {
-			continue
+// EachBackup calls fn for every backup file, in the order of the directories, until fn
+// returns false. The directories are read by batches, so that the memory stays bounded
+// with huge numbers of backups, e.g. when Backups < 0. The backups created or deleted
+// meanwhile may or may not be passed to fn.
+func (r *RotatingFile) EachBackup(fn func(BackupInfo) bool) error {
+	for _, folder := range r.backupFolders() {
+		more, err := r.eachBackup(folder, fn)
+		if err != nil || !more {
+			return err
 		}
-		info, err = files[index].Info()
-		if err != nil {
-			return nil, errors.Newf("failed to get file: %q, err: %s", name, err)
+	}
+	return nil
+}
+
+// eachBackup calls fn for every backup file of folder, it returns false if fn stopped.
+func (r *RotatingFile) eachBackup(folder string, fn func(BackupInfo) bool) (bool, error) {
+	dir, err := osOpenDir(folder)
+	if err != nil {
+		// BackupDir is created on the first rotation
+		if folder != r.folder && os.IsNotExist(err) {
+			return true, nil
+		}
+		return false, errors.Wrap(err, "failed to list backup files")
+	}
+	defer dir.Close()
+	for {
+		files, readErr := dir.ReadDir(backupBatch)
+		for index := range files {
+			info, ok, err := r.backupInfo(folder, files[index])
+			if err != nil {
+				return false, err
+			}
+			if ok && !fn(info) {
+				return false, nil
+			}
+		}
+		if readErr == io.EOF {
+			return true, nil
 		}
-		bk := backupFile{
-			file:    filepath.Join(r.folder, name),
-			modTime: info.ModTime(),
+		if readErr != nil {
+			return false, errors.Wrap(readErr, "failed to list backup files")
 		}
-		backups = append(backups, bk)
 	}
-	// sort backups by modification time
-	sort.Slice(backups, func(i, j int) bool {
-		return backups[i].modTime.Before(backups[j].modTime)
-	})
-	return backups, nil
+}
+
+// backupHeap is a heap of backup files, the oldest one on top.
+type backupHeap []backupFile
+
+func (h backupHeap) Len() int           { return len(h) }
+func (h backupHeap) Less(i, j int) bool { return h[i].modTime.Before(h[j].modTime) }
+func (h backupHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *backupHeap) Push(x any)        { *h = append(*h, x.(backupFile)) }
+func (h *backupHeap) Pop() any {
+	old := *h
+	bk := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return bk
+}
+
+// newestBackupHeap is a heap of backup files, the newest one on top.
+type newestBackupHeap struct{ backupHeap }
+
+func (h newestBackupHeap) Less(i, j int) bool { return h.backupHeap.Less(j, i) }
+
+// backupInfo returns the BackupInfo of the entry of folder, false if it isn't a backup
+// file.
+func (r *RotatingFile) backupInfo(folder string, entry os.DirEntry) (BackupInfo, bool, error) {
+	name := entry.Name()
+	if entry.IsDir() || !r.isBackupName(name) {
+		return BackupInfo{}, false, nil
+	}
+	compressed := strings.HasSuffix(name, r.filename+compressExtension)
+	info, err := entry.Info()
+	if err != nil {
+		return BackupInfo{}, false, errors.Wrapf(err, "failed to get file: %q", name)
+	}
+	return BackupInfo{
+		Path:       filepath.Join(folder, name),
+		ModTime:    info.ModTime(),
+		Size:       info.Size(),
+		Compressed: compressed,
+	}, true, nil
+}
+
+// isBackupName reports whether name is the name of a backup file or of a compressed one.
+func (r *RotatingFile) isBackupName(name string) bool {
+	return strings.HasPrefix(name, r.option.BackupPrefix) &&
+		(strings.HasSuffix(name, r.filename) || strings.HasSuffix(name, r.filename+compressExtension))
 }
 
 // SetOption is configuring rotating file function types
@@ -519,7 +1332,7 @@ type SetOption func(*Option) error
 func WithMaxSize(size int64) SetOption {
 	return func(opt *Option) error {
 		if size > 0 && size < 1<<12 {
-			errors.Warningf("too small max size:%d, it may cause frequent rotation", size)
+			warnf("too small max size:%d, it may cause frequent rotation", size)
 		}
 		opt.MaxSize = size
 		return nil
@@ -529,7 +1342,7 @@ func WithMaxSize(size int64) SetOption {
 func WithMaxAge(age time.Duration) SetOption {
 	return func(opt *Option) error {
 		if age < 0 {
-			errors.Warningf("max age:%s is less than zero, not limited by max age", age)
+			warnf("max age:%s is less than zero, not limited by max age", age)
 		}
 		opt.MaxAge = age
 		return nil
@@ -539,7 +1352,7 @@ func WithMaxAge(age time.Duration) SetOption {
 func WithBackups(backups int) SetOption {
 	return func(opt *Option) error {
 		if backups < 0 {
-			errors.Warningf("backups:%d is less than zero, not limited by backups", backups)
+			warnf("backups:%d is less than zero, not limited by backups", backups)
 		}
 		opt.Backups = backups
 		return nil
@@ -586,13 +1399,113 @@ func WithCompressLevel(level int) SetOption {
 func WithDuration(duration time.Duration) SetOption {
 	return func(opt *Option) error {
 		if duration > 0 && duration < time.Hour {
-			errors.Warningf("too short duration:%s, it may cause frequent rotation", duration)
+			warnf("too short duration:%s, it may cause frequent rotation", duration)
 		}
 		opt.Duration = duration
 		return nil
 	}
 }
 
+// WithCollectDeleteErrors sets whether the failures of deleting backup files are
+// collected into one error, see Option.CollectDeleteErrors.
+func WithCollectDeleteErrors(collect bool) SetOption {
+	return func(opt *Option) error {
+		opt.CollectDeleteErrors = collect
+		return nil
+	}
+}
+
+// WithBackupTimeFormat sets the time layout naming the backup files, see
+// Option.BackupTimeFormat. The layout is checked by NewRotatingFile, it fails with
+// InvalidTimeFormatError if the names aren't filesystem-safe, unique per second or
+// parseable.
+func WithBackupTimeFormat(layout string) SetOption {
+	return func(opt *Option) error {
+		opt.BackupTimeFormat = layout
+		return nil
+	}
+}
+
+// WithMinFreeSpace sets the free disk space kept after every write, see
+// Option.MinFreeSpace.
+func WithMinFreeSpace(size int64) SetOption {
+	return func(opt *Option) error {
+		opt.MinFreeSpace = size
+		return nil
+	}
+}
+
+// WithRecheckInterval sets the interval between two queries of the free disk space, see
+// Option.RecheckInterval.
+func WithRecheckInterval(interval time.Duration) SetOption {
+	return func(opt *Option) error {
+		if interval < 0 {
+			warnf("recheck interval:%s is less than zero, the free space is queried every write", interval)
+		}
+		opt.RecheckInterval = interval
+		return nil
+	}
+}
+
+// WithBackupDir sets the directory of the backup files, see Option.BackupDir. If
+// fallback is true, the backups are kept in the directory of the rotating file when
+// they can't be moved to dir.
+func WithBackupDir(dir string, fallback bool) SetOption {
+	return func(opt *Option) error {
+		opt.BackupDir = dir
+		opt.BackupDirFallback = fallback
+		return nil
+	}
+}
+
+// WithDirPerm sets the permission bits of the created directories, see Option.DirPerm.
+func WithDirPerm(perm os.FileMode) SetOption {
+	return func(opt *Option) error {
+		// the directories must be writable and searchable by the owner
+		if perm&0o300 != 0o300 {
+			return ModePermissionError
+		}
+		opt.DirPerm = perm
+		return nil
+	}
+}
+
+// WithSyncDirs sets whether the directories created for the rotating file are synced,
+// see Option.SyncDirs.
+func WithSyncDirs(sync bool) SetOption {
+	return func(opt *Option) error {
+		opt.SyncDirs = sync
+		return nil
+	}
+}
+
+// WithOnReopen sets the callback called when the rotating file is reopened, see
+// Option.OnReopen.
+func WithOnReopen(fn func(reason ReopenReason, oldSize int64)) SetOption {
+	return func(opt *Option) error {
+		opt.OnReopen = fn
+		return nil
+	}
+}
+
+// WithHeader sets the function returning the data written at the start of every new
+// rotating file, see Option.Header.
+func WithHeader(fn func(backup string) []byte) SetOption {
+	return func(opt *Option) error {
+		opt.Header = fn
+		return nil
+	}
+}
+
+// WithPrefixTimestamp sets the time layout of the timestamp written at the start of
+// every line, see Option.PrefixTimestamp.
+func WithPrefixTimestamp(layout string) SetOption {
+	return func(opt *Option) error {
+		opt.PrefixTimestamp = layout
+		return nil
+	}
+}
+
 // NewRotatingFile creates a new rotating file with the specified options.
 func NewRotatingFile(file string, opts ...SetOption) (*RotatingFile, error) {
 
@@ -600,6 +1513,27 @@ func NewRotatingFile(file string, opts ...SetOption) (*RotatingFile, error) {
 	if err != nil {
 		return nil, err
 	}
+	// reject a directory or a special file upfront instead of failing on the first write
+	typ, err := paths.TypeOf(absFile)
+	if err != nil {
+		return nil, err
+	}
+	if typ != paths.NotExist && typ != paths.File && typ != paths.Symlink {
+		return nil, errors.Newf("%q is a %s: %s", absFile, typ, NotRegularFileError)
+	}
+	// a dangling symlink is accepted, its target is created by the first open
+	if typ == paths.Symlink {
+		target, err := filepath.EvalSymlinks(absFile)
+		if err == nil {
+			typ, err = paths.TypeOf(target)
+		}
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+		if err == nil && typ != paths.File {
+			return nil, errors.Newf("%q is a symlink to a %s: %s", absFile, typ, NotRegularFileError)
+		}
+	}
 
 	folder, filename := filepath.Split(absFile)
 	r := &RotatingFile{
@@ -615,8 +1549,22 @@ func NewRotatingFile(file string, opts ...SetOption) (*RotatingFile, error) {
 			err = errors.Join(err, opt(r.option))
 		}
 	}
+	if err == nil {
+		err = r.option.validate()
+	}
 	if err != nil {
-		return nil, errors.Newf("failed to set option, err: %s", err)
+		return nil, errors.Wrap(err, "failed to set option")
+	}
+
+	r.backupFolder = folder
+	if r.option.BackupDir != "" {
+		backupFolder, err := paths.Abs(r.option.BackupDir)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to set option")
+		}
+		if filepath.Clean(backupFolder) != filepath.Clean(folder) {
+			r.backupFolder = backupFolder
+		}
 	}
 
 	// active daemon goroutine
@@ -628,9 +1576,9 @@ func NewRotatingFile(file string, opts ...SetOption) (*RotatingFile, error) {
 				case now := <-r.timer.C:
 					func() {
 						r.mtx.Lock()
-						defer r.mtx.Unlock()
+						defer r.unlock()
 						if r.writer != nil && now.Sub(r.rotatingTime) > r.option.Duration {
-							errors.Warning(r.rotate())
+							errors.Warningt(warningTag, r.rotate())
 						}
 					}()
 				default:
@@ -3,12 +3,16 @@ package rotate
 import (
 	"bytes"
 	"compress/gzip"
+	"context"
 	"fmt"
 	"io"
 	"math/rand"
 	"os"
 	"path/filepath"
+	"regexp"
+	"sort"
 	"strings"
+	"syscall"
 	"testing"
 	"time"
 
@@ -40,18 +44,56 @@ func TestDeleteBackupFiles(t *testing.T) {
 		err = file.Close()
 		require.True(t, paths.IsExisted(absFile))
 		require.NoError(t, err)
-		buf := &bytes.Buffer{}
-		deleteBackupFiles([]backupFile{{file: absFile}})
-		errors.SetWarningOutput(buf)
-		warningText := buf.String()
-		require.True(t, len(warningText) == 0)
+		warnings := errors.CaptureWarnings(func() {
+			group := errors.NewGroup("cleanup")
+			removed, err := deleteBackupFiles([]backupFile{{file: absFile}}, false, group)
+			require.NoError(t, err)
+			require.Equal(t, 1, removed)
+			group.Flush()
+		})
+		require.Empty(t, warnings)
 	})
 
 	t.Run("delete not existed file", func(t *testing.T) {
+		warnings := errors.CaptureWarnings(func() {
+			group := errors.NewGroup("rotate: cleanup")
+			removed, err := deleteBackupFiles([]backupFile{{file: lib.RandString(8)}, {file: lib.RandString(8)}}, false, group)
+			require.NoError(t, err)
+			require.Zero(t, removed)
+			require.Equal(t, 2, group.Len())
+			group.Flush()
+		})
+		// one warning summarizing the failed deletions
+		require.Len(t, warnings, 1)
+		require.Contains(t, warnings[0], "rotate: cleanup: 2 warnings\n\tfailed to remove backup file")
+	})
+
+	t.Run("error fields", func(t *testing.T) {
+		missing := filepath.Join(folder, lib.RandString(8))
+		err := removeFile(missing, opCompress)
+		require.ErrorIs(t, err, os.ErrNotExist)
+		require.Equal(t, map[string]any{"file": missing, "op": opCompress}, errors.Fields(err))
+		require.NoError(t, os.WriteFile(missing, nil, 0o644))
+		require.NoError(t, removeFile(missing, opCompress))
+	})
+
+	t.Run("collect errors", func(t *testing.T) {
 		buf := &bytes.Buffer{}
 		errors.SetWarningOutput(buf)
-		deleteBackupFiles([]backupFile{{file: lib.RandString(8)}, {file: lib.RandString(8)}})
-		require.Contains(t, buf.String(), "failed to remove")
+		absFile := filepath.Join(folder, lib.RandString(6))
+		require.NoError(t, os.WriteFile(absFile, nil, 0o644))
+		missing := []string{filepath.Join(folder, lib.RandString(8)), filepath.Join(folder, lib.RandString(8))}
+		removed, err := deleteBackupFiles([]backupFile{{file: missing[0]}, {file: absFile}, {file: missing[1]}}, true, nil)
+		require.ErrorIs(t, err, os.ErrNotExist)
+		require.Equal(t, 1, removed)
+		require.Equal(t, 2, strings.Count(err.Error(), "failed to remove"))
+		for _, file := range missing {
+			require.Contains(t, err.Error(), file)
+		}
+		require.False(t, paths.IsExisted(absFile))
+		require.Empty(t, buf.String())
+		_, err = deleteBackupFiles([]backupFile{}, true, nil)
+		require.NoError(t, err)
 	})
 }
 
@@ -70,7 +112,7 @@ func TestCompressFile(t *testing.T) {
 	t.Run("successfully compress file", func(t *testing.T) {
 		dstFile := srcFile + ".gz"
 		require.NoError(t, err)
-		err = compressFile(srcFile, dstFile, 6)
+		err = compressFile(context.Background(), srcFile, dstFile, 6)
 		require.NoError(t, err)
 		require.False(t, paths.IsExisted(srcFile))
 		fd, err := os.Open(dstFile)
@@ -89,7 +131,7 @@ func TestCompressFile(t *testing.T) {
 		buf := &bytes.Buffer{}
 		errors.SetWarningOutput(buf)
 		defer errors.SetWarningOutput(os.Stderr)
-		err := compressFile("not-existed-file", "not-existed-file.gz", 6)
+		err := compressFile(context.Background(), "not-existed-file", "not-existed-file.gz", 6)
 		require.NoError(t, err)
 		require.Contains(t, buf.String(), "no such file or directory")
 
@@ -97,7 +139,7 @@ func TestCompressFile(t *testing.T) {
 		osOpen = func(name string) (*os.File, error) {
 			return nil, nil
 		}
-		err = compressFile(srcFile, srcFile+".gz", 6)
+		err = compressFile(context.Background(), srcFile, srcFile+".gz", 6)
 		require.ErrorIs(t, err, os.ErrInvalid)
 		osOpen = os.Open
 
@@ -111,18 +153,18 @@ func TestCompressFile(t *testing.T) {
 		dstDir := filepath.Join(folder, lib.RandString(6))
 		err = os.Mkdir(dstDir, 0o000)
 		require.NoError(t, err)
-		err = compressFile(srcFile, filepath.Join(dstDir, "not-existed-file.gz"), 6)
+		err = compressFile(context.Background(), srcFile, filepath.Join(dstDir, "not-existed-file.gz"), 6)
 		require.ErrorIs(t, err, os.ErrPermission)
 
 		// invalid compression level
-		err = compressFile(srcFile, filepath.Join(folder, "not-existed-file.gz"), 10)
+		err = compressFile(context.Background(), srcFile, filepath.Join(folder, "not-existed-file.gz"), 10)
 		require.Errorf(t, err, "invalid compression level:")
 
 		// copy error
 		ioCopy = func(dst io.Writer, src io.Reader) (written int64, err error) {
 			return 0, io.ErrUnexpectedEOF
 		}
-		err = compressFile(srcFile, filepath.Join(folder, "not-existed-file.gz"), 6)
+		err = compressFile(context.Background(), srcFile, filepath.Join(folder, "not-existed-file.gz"), 6)
 		require.ErrorIs(t, err, io.ErrUnexpectedEOF)
 		ioCopy = io.Copy
 	})
@@ -287,6 +329,182 @@ func TestClose(t *testing.T) {
 	})
 }
 
+// entriesDir is a dirReader of entries, the batches are followed by err or io.EOF.
+type entriesDir struct {
+	entries []os.DirEntry
+	err     error
+	// batches are the sizes of the batches read
+	batches []int
+}
+
+func (d *entriesDir) ReadDir(n int) ([]os.DirEntry, error) {
+	d.batches = append(d.batches, n)
+	if len(d.entries) == 0 {
+		if d.err != nil {
+			return nil, d.err
+		}
+		return nil, io.EOF
+	}
+	n = lib.Min(n, len(d.entries))
+	batch := d.entries[:n]
+	d.entries = d.entries[n:]
+	return batch, nil
+}
+
+func (d *entriesDir) Close() error {
+	return nil
+}
+
+// fakeEntry is a synthetic regular file, it's its own os.FileInfo.
+type fakeEntry struct {
+	name    string
+	modTime time.Time
+}
+
+func (e fakeEntry) Name() string               { return e.name }
+func (e fakeEntry) IsDir() bool                { return false }
+func (e fakeEntry) Type() os.FileMode          { return 0 }
+func (e fakeEntry) Info() (os.FileInfo, error) { return e, nil }
+func (e fakeEntry) Size() int64                { return 1 }
+func (e fakeEntry) Mode() os.FileMode          { return 0o644 }
+func (e fakeEntry) ModTime() time.Time         { return e.modTime }
+func (e fakeEntry) Sys() any                   { return nil }
+
+// syntheticBackups returns n synthetic backups of f, the newest first.
+func syntheticBackups(f *RotatingFile, n int) []os.DirEntry {
+	entries := make([]os.DirEntry, n)
+	for i := range entries {
+		name := fmt.Sprintf("%s%08d-%s", f.option.BackupPrefix, i, f.filename)
+		entries[i] = fakeEntry{name: name, modTime: probeTime.Add(-time.Duration(i) * time.Second)}
+	}
+	return entries
+}
+
+// syntheticOlderThan selects the synthetic backups of f in root like paths.FindOlderThan.
+func syntheticOlderThan(f *RotatingFile, n int, root string, age time.Duration, filter func(string) bool) []string {
+	var files []string
+	entries := syntheticBackups(f, n)
+	for i := len(entries) - 1; i >= 0; i-- {
+		path := filepath.Join(root, entries[i].Name())
+		if entries[i].(fakeEntry).modTime.Before(time.Now().Add(-age)) && filter(path) {
+			files = append(files, path)
+		}
+	}
+	return files
+}
+
+// sortedBackups returns the backups of f listed by EachBackup, the oldest first.
+func sortedBackups(f *RotatingFile) ([]backupFile, error) {
+	var backups []backupFile
+	err := f.EachBackup(func(info BackupInfo) bool {
+		backups = append(backups, backupFile{file: info.Path, modTime: info.ModTime})
+		return true
+	})
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].modTime.Before(backups[j].modTime)
+	})
+	return backups, err
+}
+
+func TestEachBackup(t *testing.T) {
+	testDir := t.TempDir()
+	f, err := NewRotatingFile(filepath.Join(testDir, "app.log"), WithDuration(-1))
+	require.NoError(t, err)
+	defer f.Close()
+	backup := filepath.Join(testDir, f.nextBackupFilename())
+	require.NoError(t, os.WriteFile(backup, []byte("hello\n"), 0o644))
+	compressed := filepath.Join(testDir, f.nextBackupFilename()+compressExtension)
+	require.NoError(t, os.WriteFile(compressed, nil, 0o644))
+	// neither backups
+	require.NoError(t, os.WriteFile(filepath.Join(testDir, "other.log"), nil, 0o644))
+	require.NoError(t, os.Mkdir(filepath.Join(testDir, f.nextBackupFilename()), 0o755))
+
+	infos := map[string]BackupInfo{}
+	require.NoError(t, f.EachBackup(func(info BackupInfo) bool {
+		infos[info.Path] = info
+		return true
+	}))
+	require.Len(t, infos, 2)
+	require.Equal(t, int64(6), infos[backup].Size)
+	require.False(t, infos[backup].Compressed)
+	require.True(t, infos[compressed].Compressed)
+
+	// stop
+	calls := 0
+	require.NoError(t, f.EachBackup(func(BackupInfo) bool {
+		calls++
+		return false
+	}))
+	require.Equal(t, 1, calls)
+
+	// the directory is read by batches
+	dir := &entriesDir{entries: syntheticBackups(f, 2*backupBatch+1)}
+	osOpenDir = func(name string) (dirReader, error) {
+		return dir, nil
+	}
+	defer func() { osOpenDir = openDir }()
+	calls = 0
+	require.NoError(t, f.EachBackup(func(BackupInfo) bool {
+		calls++
+		return true
+	}))
+	require.Equal(t, 2*backupBatch+1, calls)
+	require.Equal(t, []int{backupBatch, backupBatch, backupBatch, backupBatch}, dir.batches)
+
+	// BackupDir not created yet
+	osOpenDir = openDir
+	f2, err := NewRotatingFile(filepath.Join(testDir, "app.log"), WithBackupDir(filepath.Join(testDir, "archive"), false), WithDuration(-1))
+	require.NoError(t, err)
+	defer f2.Close()
+	require.NoError(t, f2.EachBackup(func(BackupInfo) bool {
+		t.Fatal("no backup expected")
+		return true
+	}))
+}
+
+func TestCleanBackupsStreaming(t *testing.T) {
+	testDir := t.TempDir()
+	f, err := NewRotatingFile(filepath.Join(testDir, "app.log"), WithDuration(-1), WithBackups(3), WithCompressLevel(0))
+	require.NoError(t, err)
+	defer f.Close()
+	var removedFiles []string
+	osRemove = func(name string) error {
+		removedFiles = append(removedFiles, filepath.Base(name))
+		return nil
+	}
+	defer func() { osRemove = os.Remove }()
+	n := 3*backupBatch + 10
+	osOpenDir = func(name string) (dirReader, error) {
+		return &entriesDir{entries: syntheticBackups(f, n)}, nil
+	}
+	defer func() { osOpenDir = openDir }()
+	findOlderThan = func(root string, age time.Duration, filter func(string) bool) ([]string, error) {
+		return syntheticOlderThan(f, n, root, age, filter), nil
+	}
+	defer func() { findOlderThan = paths.FindOlderThan }()
+
+	// the 3 newest are kept, the synthetic backups are older than MaxAge
+	f.option.MaxAge = 0
+	kept, err := f.cleanBackups(context.Background())
+	require.NoError(t, err)
+	require.Empty(t, kept)
+	require.Len(t, removedFiles, n-3)
+	for _, name := range removedFiles {
+		require.NotContains(t, []string{"00000000", "00000001", "00000002"}, name[len(f.option.BackupPrefix):len(f.option.BackupPrefix)+8])
+	}
+
+	// by age, the kept backups are returned to be compressed, the oldest first
+	removedFiles = nil
+	f.option.Backups = -1
+	f.option.MaxAge = time.Since(probeTime) + 5*time.Second
+	f.option.CompressLevel = 6
+	kept, err = f.cleanBackups(context.Background())
+	require.NoError(t, err)
+	require.Len(t, removedFiles, n-5)
+	require.Len(t, kept, 5)
+	require.True(t, sort.SliceIsSorted(kept, func(i, j int) bool { return kept[i].modTime.Before(kept[j].modTime) }))
+}
+
 func TestRotatingFileCleanBackups(t *testing.T) {
 	testDir := t.TempDir()
 	defer os.RemoveAll(testDir)
@@ -296,13 +514,19 @@ func TestRotatingFileCleanBackups(t *testing.T) {
 	defer f.Close()
 
 	t.Run("cannot read directory", func(t *testing.T) {
-		osReadDir = func(name string) ([]os.DirEntry, error) {
+		osOpenDir = func(name string) (dirReader, error) {
 			return nil, os.ErrInvalid
 		}
 		defer func() {
-			osReadDir = os.ReadDir
+			osOpenDir = openDir
 		}()
-		_, err = f.cleanBackups()
+		_, err = f.cleanBackups(context.Background())
+		require.ErrorIs(t, err, os.ErrInvalid)
+
+		osOpenDir = func(name string) (dirReader, error) {
+			return &entriesDir{err: os.ErrInvalid}, nil
+		}
+		_, err = f.cleanBackups(context.Background())
 		require.ErrorIs(t, err, os.ErrInvalid)
 	})
 
@@ -315,16 +539,26 @@ func TestRotatingFileCleanBackups(t *testing.T) {
 		entry.EXPECT().IsDir().Return(false)
 		entry.EXPECT().Info().Return(nil, os.ErrInvalid)
 
-		osReadDir = func(name string) ([]os.DirEntry, error) {
-			return []os.DirEntry{entry}, nil
+		osOpenDir = func(name string) (dirReader, error) {
+			return &entriesDir{entries: []os.DirEntry{entry}}, nil
 		}
 		defer func() {
-			osReadDir = os.ReadDir
+			osOpenDir = openDir
 		}()
-		_, err = f.cleanBackups()
+		_, err = f.cleanBackups(context.Background())
 		require.ErrorIs(t, err, os.ErrInvalid)
 	})
 
+	t.Run("not existed folder", func(t *testing.T) {
+		folder := f.folder
+		defer func() { f.folder, f.backupFolder = folder, folder }()
+		f.folder = filepath.Join(folder, lib.RandString(8))
+		f.backupFolder = f.folder
+		_, err = f.cleanBackups(context.Background())
+		require.ErrorIs(t, err, os.ErrNotExist)
+		require.ErrorIs(t, errors.Wrap(err, "failed to tidy backups"), os.ErrNotExist)
+	})
+
 	t.Run("clean by max age", func(t *testing.T) {
 		// delete all backups by max age
 		err = paths.Clear(f.folder)
@@ -335,7 +569,7 @@ func TestRotatingFileCleanBackups(t *testing.T) {
 			err = file.Close()
 			require.NoError(t, err)
 		}
-		fs, err := f.sortBackups()
+		fs, err := sortedBackups(f)
 		require.NoError(t, err)
 		require.Equal(t, 5, len(fs))
 		err = f.Close()
@@ -344,11 +578,24 @@ func TestRotatingFileCleanBackups(t *testing.T) {
 		// set max age to 100ms
 		f.option.MaxAge = 100 * time.Millisecond
 		time.Sleep(1000 * time.Millisecond)
-		bks, err := f.cleanBackups()
+		bks, err := f.cleanBackups(context.Background())
 		require.NoError(t, err)
 		require.Equal(t, 0, len(bks))
 	})
 
+	t.Run("cannot find expired backups", func(t *testing.T) {
+		file, err := os.Create(filepath.Join(f.folder, f.nextBackupFilename()))
+		require.NoError(t, err)
+		require.NoError(t, file.Close())
+		findOlderThan = func(root string, age time.Duration, filter func(string) bool) ([]string, error) {
+			return nil, os.ErrInvalid
+		}
+		defer func() {
+			findOlderThan = paths.FindOlderThan
+		}()
+		_, err = f.cleanBackups(context.Background())
+		require.ErrorIs(t, err, os.ErrInvalid)
+	})
 }
 
 func TestRotatingFileRotate(t *testing.T) {
@@ -364,14 +611,13 @@ func TestRotatingFileRotate(t *testing.T) {
 	osRename = func(oldpath, newpath string) error {
 		return os.ErrNotExist
 	}
-	buf := &bytes.Buffer{}
-	errors.SetWarningOutput(buf)
-	//defer errors.SetWarningOutput(os.Stderr)
-	err = f.rotate()
+	warnings := errors.CaptureWarnings(func() {
+		err = f.rotate()
+	})
 	require.NoError(t, err)
-	require.Contains(t, buf.String(), "failed to backup file")
+	require.Len(t, warnings, 1)
+	require.Contains(t, warnings[0], "failed to backup file")
 	osRename = os.Rename
-	errors.SetWarningOutput(os.Stderr)
 
 	// failed to rename (unknown error)
 	osRename = func(oldpath, newpath string) error {
@@ -389,6 +635,18 @@ func TestRotatingFileRotate(t *testing.T) {
 	require.ErrorIs(t, err, os.ErrPermission)
 	osOpenFile = os.OpenFile
 
+	// rotate on demand
+	_, err = f.WriteString("first segment")
+	require.NoError(t, err)
+	require.NoError(t, f.Rotate())
+	_, err = f.WriteString("second segment")
+	require.NoError(t, err)
+	content, err := os.ReadFile(testFile)
+	require.NoError(t, err)
+	require.Equal(t, "second segment", string(content))
+	backups, err := sortedBackups(f)
+	require.NoError(t, err)
+	require.NotEmpty(t, backups)
 }
 
 func TestRotatingFileOpenWriter(t *testing.T) {
@@ -475,6 +733,33 @@ func TestNewRotatingFile(t *testing.T) {
 		require.NoError(t, err)
 		require.Equal(t, "test-", f.option.BackupPrefix)
 	})
+	t.Run("directory", func(t *testing.T) {
+		f, err := NewRotatingFile(testDir)
+		require.ErrorIs(t, err, NotRegularFileError)
+		require.ErrorContains(t, err, "is a Dir")
+		require.Equal(t, errors.InvalidInput, errors.CodeOf(err))
+		require.Nil(t, f)
+	})
+	t.Run("symlink", func(t *testing.T) {
+		dir := filepath.Join(testDir, lib.RandString(6))
+		require.NoError(t, os.Symlink(testDir, dir))
+		f, err := NewRotatingFile(dir)
+		require.ErrorIs(t, err, NotRegularFileError)
+		require.ErrorContains(t, err, "is a symlink to a Dir")
+		require.Nil(t, f)
+
+		// to a regular file, or dangling
+		for _, target := range []string{lib.RandString(6), "not-existed-file"} {
+			link := filepath.Join(testDir, lib.RandString(6))
+			if target != "not-existed-file" {
+				require.NoError(t, os.WriteFile(filepath.Join(testDir, target), nil, 0o644))
+			}
+			require.NoError(t, os.Symlink(filepath.Join(testDir, target), link))
+			f, err = NewRotatingFile(link)
+			require.NoError(t, err)
+			require.NoError(t, f.Close())
+		}
+	})
 	t.Run("no specify file", func(t *testing.T) {
 		f, err := NewRotatingFile("", nil)
 		require.ErrorIs(t, err, paths.InvalidPathError)
@@ -487,34 +772,752 @@ func TestNewRotatingFile(t *testing.T) {
 		require.Equal(t, -1, f.option.CompressLevel)
 	})
 
+	t.Run("collect delete errors", func(t *testing.T) {
+		f, err := NewRotatingFile(filepath.Join(testDir, lib.RandString(6)), WithCollectDeleteErrors(true))
+		require.NoError(t, err)
+		require.True(t, f.option.CollectDeleteErrors)
+		require.False(t, defaultOption.CollectDeleteErrors)
+	})
+
 	t.Run("invalid compress level", func(t *testing.T) {
 		f, err := NewRotatingFile(filepath.Join(testDir, lib.RandString(6)), WithCompressLevel(11))
 		require.ErrorIs(t, err, InvalidCompressionLevelError)
+		require.Equal(t, errors.InvalidInput, errors.CodeOf(err))
 		require.Nil(t, f)
 	})
 
 	t.Run("invalid mode perm", func(t *testing.T) {
 		f, err := NewRotatingFile(filepath.Join(testDir, lib.RandString(6)), WithModePerm(0o001))
 		require.ErrorIs(t, err, ModePermissionError)
+		require.Equal(t, "rotate.bad_perm", errors.CodeString(err))
 		require.Nil(t, f)
 	})
 
 	t.Run("not limit backups", func(t *testing.T) {
-		buf := &bytes.Buffer{}
-		errors.SetWarningOutput(buf)
-		defer errors.SetWarningOutput(os.Stderr)
-		f, err := NewRotatingFile(filepath.Join(testDir, lib.RandString(6)), WithBackups(-1))
+		var f *RotatingFile
+		var err error
+		warnings := errors.CaptureWarnings(func() {
+			f, err = NewRotatingFile(filepath.Join(testDir, lib.RandString(6)), WithBackups(-1))
+		})
 		require.NoError(t, err)
 		require.Equal(t, -1, f.option.Backups)
-		require.Contains(t, buf.String(), "not limited by backups")
+		require.Equal(t, []string{"rotate: backups:-1 is less than zero, not limited by backups"}, warnings)
+	})
+}
+
+func TestValidateTimeFormat(t *testing.T) {
+	cases := []struct {
+		name   string
+		layout string
+		err    string
+	}{
+		{"salted", "", ""},
+		{"default", DefaultBackupTimeFormat, ""},
+		{"compact", "20060102150405", ""},
+		{"separator", "2006/01/02T15-04-05", "invalid character '/'"},
+		{"no seconds", "2006-01-02T15-04", "same name for times a second apart"},
+		{"no date", "15-04-05", "isn't parsed back to the same time"},
+		{"12-hour clock", "2006-01-02T03-04-05", "isn't parsed back to the same time"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			err := validateTimeFormat(c.layout)
+			if c.err == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.ErrorIs(t, err, InvalidTimeFormatError)
+			require.ErrorContains(t, err, c.err)
+			require.Equal(t, "rotate.bad_time_format", errors.CodeString(err))
+		})
+	}
+}
+
+func TestBackupTimeFormat(t *testing.T) {
+	testDir := t.TempDir()
+	t.Run("invalid", func(t *testing.T) {
+		f, err := NewRotatingFile(filepath.Join(testDir, lib.RandString(6)), WithBackupTimeFormat("15-04-05"), WithDuration(-1))
+		require.ErrorIs(t, err, InvalidTimeFormatError)
+		require.Nil(t, f)
+	})
+	t.Run("backup name", func(t *testing.T) {
+		filename := lib.RandString(6)
+		f, err := NewRotatingFile(filepath.Join(testDir, filename), WithBackupTimeFormat(DefaultBackupTimeFormat), WithDuration(-1))
+		require.NoError(t, err)
+		defer f.Close()
+		name := f.nextBackupFilename()
+		require.True(t, strings.HasPrefix(name, defaultOption.BackupPrefix))
+		require.True(t, strings.HasSuffix(name, "-"+filename))
+		stamp := strings.TrimSuffix(strings.TrimPrefix(name, defaultOption.BackupPrefix), "-"+filename)
+		_, err = time.Parse(DefaultBackupTimeFormat, stamp)
+		require.NoError(t, err)
+	})
+	t.Run("same stamp", func(t *testing.T) {
+		folder := t.TempDir()
+		f, err := NewRotatingFile(filepath.Join(folder, "app.log"), WithBackupTimeFormat("20060102150405"),
+			WithDuration(-1), WithCompressLevel(0))
+		require.NoError(t, err)
+		// the rotations within a second don't overwrite the first backup
+		for _, line := range []string{"first\n", "second\n", "third\n"} {
+			_, err = f.WriteString(line)
+			require.NoError(t, err)
+			require.NoError(t, f.Rotate())
+		}
+		require.NoError(t, f.Close())
+		entries, err := os.ReadDir(folder)
+		require.NoError(t, err)
+		contents := make([]string, 0, len(entries))
+		for _, entry := range entries {
+			if entry.Name() == "app.log" {
+				continue
+			}
+			data, err := os.ReadFile(filepath.Join(folder, entry.Name()))
+			require.NoError(t, err)
+			contents = append(contents, string(data))
+		}
+		require.ElementsMatch(t, []string{"first\n", "second\n", "third\n"}, contents)
+	})
+	t.Run("max age", func(t *testing.T) {
+		folder := t.TempDir()
+		f, err := NewRotatingFile(filepath.Join(folder, "app.log"), WithBackupTimeFormat(DefaultBackupTimeFormat),
+			WithDuration(-1), WithCompressLevel(0), WithBackups(-1), WithMaxAge(time.Hour))
+		require.NoError(t, err)
+		defer f.Close()
+		old := time.Now().Add(-2 * time.Hour)
+		create := func(name string, modTime time.Time) string {
+			file := filepath.Join(folder, name)
+			require.NoError(t, os.WriteFile(file, nil, 0o644))
+			require.NoError(t, os.Chtimes(file, modTime, modTime))
+			return file
+		}
+		prefix := f.option.BackupPrefix
+		// expired by the time of their names, whatever their modification times
+		stampedOld := create(prefix+old.Format(DefaultBackupTimeFormat)+".1-app.log", time.Now())
+		stampedNew := create(f.nextBackupFilename(), old)
+		// the unparsed names are expired by their modification times
+		unparsedOld := create(prefix+"renamed-app.log", old)
+		unparsedNew := create(prefix+"copied-app.log", time.Now())
+
+		var found []string
+		findOlderThan = func(root string, age time.Duration, filter func(string) bool) ([]string, error) {
+			files, err := paths.FindOlderThan(root, age, filter)
+			found = append(found, files...)
+			return files, err
+		}
+		defer func() { findOlderThan = paths.FindOlderThan }()
+		require.NoError(t, f.CleanBackups(context.Background()))
+		require.Equal(t, []string{unparsedOld}, found)
+		for _, file := range []string{stampedOld, unparsedOld} {
+			require.NoFileExists(t, file)
+		}
+		for _, file := range []string{stampedNew, unparsedNew} {
+			require.FileExists(t, file)
+		}
 	})
 }
 
+// fakeDiskUsage replaces diskUsage with a disk of capacity bytes holding the files of
+// dir, it returns the number of queries.
+func fakeDiskUsage(t *testing.T, dir string, capacity uint64) *int {
+	calls := 0
+	diskUsage = func(path string) (paths.Usage, error) {
+		calls++
+		entries, err := os.ReadDir(dir)
+		require.NoError(t, err)
+		used := uint64(0)
+		for _, entry := range entries {
+			info, err := entry.Info()
+			require.NoError(t, err)
+			used += uint64(info.Size())
+		}
+		return paths.Usage{Total: capacity, Free: capacity - used, Available: capacity - used}, nil
+	}
+	t.Cleanup(func() { diskUsage = paths.DiskUsage })
+	return &calls
+}
+
+// createBackups creates n backups of f of size bytes, oldest first.
+func createBackups(t *testing.T, f *RotatingFile, n, size int) []string {
+	files := make([]string, n)
+	for index := range files {
+		files[index] = filepath.Join(f.folder, f.nextBackupFilename())
+		require.NoError(t, os.WriteFile(files[index], make([]byte, size), 0o644))
+		modTime := time.Now().Add(time.Duration(index-n) * time.Hour)
+		require.NoError(t, os.Chtimes(files[index], modTime, modTime))
+	}
+	return files
+}
+
+func TestMinFreeSpace(t *testing.T) {
+	newFile := func(t *testing.T, opts ...SetOption) *RotatingFile {
+		testFile := filepath.Join(t.TempDir(), lib.RandString(6))
+		opts = append([]SetOption{WithMinFreeSpace(100), WithRecheckInterval(0), WithCompressLevel(0), WithDuration(-1)}, opts...)
+		f, err := NewRotatingFile(testFile, opts...)
+		require.NoError(t, err)
+		t.Cleanup(func() { f.Close() })
+		return f
+	}
+
+	t.Run("enough space", func(t *testing.T) {
+		f := newFile(t)
+		fakeDiskUsage(t, f.folder, 1000)
+		backups := createBackups(t, f, 2, 100)
+		n, err := f.WriteString("hello")
+		require.NoError(t, err)
+		require.Equal(t, 5, n)
+		require.FileExists(t, backups[0])
+		require.Equal(t, Stats{}, f.Stats())
+	})
+
+	t.Run("delete oldest backups", func(t *testing.T) {
+		f := newFile(t)
+		fakeDiskUsage(t, f.folder, 1000)
+		backups := createBackups(t, f, 4, 200)
+		// 200 bytes are free, 300 bytes are required
+		n, err := f.Write(make([]byte, 200))
+		require.NoError(t, err)
+		require.Equal(t, 200, n)
+		require.NoFileExists(t, backups[0])
+		require.FileExists(t, backups[1])
+		require.Equal(t, Stats{EmergencyCleanups: 1}, f.Stats())
+
+		// the two oldest remaining backups are deleted next, a notice reports them
+		warnings := errors.CaptureWarnings(func() {
+			_, err = f.Write(make([]byte, 450))
+		})
+		require.NoError(t, err)
+		require.Equal(t, []string{"rotate: emergency cleanup removed 2 backups of " + f.filename + ", 600 bytes are free"}, warnings)
+		require.NoFileExists(t, backups[1])
+		require.NoFileExists(t, backups[2])
+		require.FileExists(t, backups[3])
+		require.Equal(t, Stats{EmergencyCleanups: 2}, f.Stats())
+	})
+
+	t.Run("disk full", func(t *testing.T) {
+		f := newFile(t)
+		fakeDiskUsage(t, f.folder, 1000)
+		backups := createBackups(t, f, 2, 100)
+		n, err := f.Write(make([]byte, 950))
+		require.ErrorIs(t, err, DiskFullError)
+		require.ErrorIs(t, err, syscall.ENOSPC)
+		require.Equal(t, errors.Unavailable, errors.CodeOf(err))
+		require.Equal(t, 0, n)
+		for _, bk := range backups {
+			require.NoFileExists(t, bk)
+		}
+		require.Equal(t, Stats{EmergencyCleanups: 1}, f.Stats())
+	})
+
+	t.Run("several batches", func(t *testing.T) {
+		f := newFile(t)
+		fakeDiskUsage(t, f.folder, 3100)
+		backups := createBackups(t, f, backupBatch+44, 10)
+		// 100 bytes are free, the backupBatch+34 oldest backups are deleted
+		_, err := f.Write(make([]byte, (backupBatch+34)*10))
+		require.NoError(t, err)
+		require.NoFileExists(t, backups[backupBatch+33])
+		require.FileExists(t, backups[backupBatch+34])
+	})
+
+	t.Run("failed deletion", func(t *testing.T) {
+		f := newFile(t)
+		fakeDiskUsage(t, f.folder, 1000)
+		backups := createBackups(t, f, 4, 200)
+		osRemove = func(name string) error {
+			if name == backups[0] {
+				return os.ErrPermission
+			}
+			return os.Remove(name)
+		}
+		defer func() { osRemove = os.Remove }()
+		var err error
+		warnings := errors.CaptureWarnings(func() {
+			_, err = f.Write(make([]byte, 200))
+		})
+		require.NoError(t, err)
+		require.Len(t, warnings, 2)
+		require.Contains(t, warnings[0], "permission denied")
+		require.FileExists(t, backups[0])
+		require.NoFileExists(t, backups[1])
+		require.FileExists(t, backups[2])
+	})
+
+	t.Run("running cleanup", func(t *testing.T) {
+		f := newFile(t)
+		fakeDiskUsage(t, f.folder, 1000)
+		backups := createBackups(t, f, 4, 200)
+		// the cleanup of the backups isn't waited for with the lock of the file
+		f.cleanMtx.Lock()
+		defer f.cleanMtx.Unlock()
+		done := make(chan error)
+		go func() {
+			_, err := f.Write(make([]byte, 200))
+			done <- err
+		}()
+		select {
+		case err := <-done:
+			require.NoError(t, err)
+		case <-time.After(10 * time.Second):
+			t.Fatal("the emergency cleanup waits for the running cleanup")
+		}
+		require.NoFileExists(t, backups[0])
+		require.FileExists(t, backups[1])
+	})
+
+	t.Run("recheck interval", func(t *testing.T) {
+		f := newFile(t, WithRecheckInterval(time.Hour))
+		calls := fakeDiskUsage(t, f.folder, 1000)
+		for i := 0; i < 3; i++ {
+			_, err := f.WriteString("hello")
+			require.NoError(t, err)
+		}
+		require.Equal(t, 1, *calls)
+		// the writes since the query are counted
+		require.Equal(t, int64(1000-15), f.free)
+	})
+
+	t.Run("failed to query", func(t *testing.T) {
+		f := newFile(t)
+		diskUsage = func(path string) (paths.Usage, error) {
+			return paths.Usage{}, errors.Error("mock error")
+		}
+		t.Cleanup(func() { diskUsage = paths.DiskUsage })
+		var err error
+		warnings := errors.CaptureWarnings(func() {
+			_, err = f.WriteString("hello")
+		})
+		require.NoError(t, err)
+		require.Equal(t, []string{"rotate: mock error"}, warnings)
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		f := newFile(t, WithMinFreeSpace(0))
+		calls := fakeDiskUsage(t, f.folder, 0)
+		_, err := f.WriteString("hello")
+		require.NoError(t, err)
+		require.Equal(t, 0, *calls)
+	})
+}
+
+func TestPrefixTimestamp(t *testing.T) {
+	const layout = "2006-01-02T15:04:05"
+	stamp := regexp.MustCompile(`^\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2} `)
+	cases := []struct {
+		name   string
+		writes []string
+		lines  []string
+	}{
+		{"lines", []string{"hello\n", "world\n"}, []string{"hello", "world"}},
+		{"continued line", []string{"hello ", "world\n", "again\n"}, []string{"hello world", "again"}},
+		{"multiple lines", []string{"hello\nworld\n"}, []string{"hello", "world"}},
+		{"no trailing newline", []string{"hello\nwor", "ld"}, []string{"hello", "world"}},
+		{"empty write", []string{"", "hello\n", ""}, []string{"hello"}},
+		{"empty lines", []string{"\n\n"}, []string{"", ""}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			testFile := filepath.Join(t.TempDir(), lib.RandString(6))
+			f, err := NewRotatingFile(testFile, WithPrefixTimestamp(layout), WithDuration(-1))
+			require.NoError(t, err)
+			size := 0
+			for _, s := range c.writes {
+				n, err := f.WriteString(s)
+				require.NoError(t, err)
+				// the timestamps aren't counted
+				require.Equal(t, len(s), n)
+				size += len(s)
+			}
+			// the timestamps are counted in the size of the file
+			require.Equal(t, int64(size+len(c.lines)*(len(layout)+1)), f.used)
+			require.NoError(t, f.Close())
+
+			content, err := os.ReadFile(testFile)
+			require.NoError(t, err)
+			lines := strings.Split(strings.TrimSuffix(string(content), "\n"), "\n")
+			require.Len(t, lines, len(c.lines))
+			for index, line := range lines {
+				require.Regexp(t, stamp, line)
+				require.Equal(t, c.lines[index], stamp.ReplaceAllString(line, ""))
+			}
+		})
+	}
+}
+
+func TestBackupDir(t *testing.T) {
+	// listBackups returns the names of the backups of f in dir.
+	listBackups := func(t *testing.T, f *RotatingFile, dir string) []string {
+		entries, err := os.ReadDir(dir)
+		require.NoError(t, err)
+		var names []string
+		for _, entry := range entries {
+			if strings.HasPrefix(entry.Name(), f.option.BackupPrefix) {
+				names = append(names, entry.Name())
+			}
+		}
+		return names
+	}
+
+	t.Run("archive", func(t *testing.T) {
+		testDir := t.TempDir()
+		backupDir := filepath.Join(testDir, "archive", "logs")
+		f, err := NewRotatingFile(filepath.Join(testDir, "app.log"), WithDuration(-1),
+			WithBackupDir(backupDir, false), WithBackups(2), WithCompressLevel(0), WithDirPerm(0o750))
+		require.NoError(t, err)
+		require.Equal(t, backupDir, f.backupFolder)
+		for i := 0; i < 3; i++ {
+			_, err = f.WriteString("hello\n")
+			require.NoError(t, err)
+			require.NoError(t, f.Rotate())
+		}
+		require.NoError(t, f.Close())
+		info, err := os.Stat(backupDir)
+		require.NoError(t, err)
+		require.Equal(t, os.FileMode(0o750), info.Mode().Perm())
+		// the backups are cleaned in the backup directory
+		require.Len(t, listBackups(t, f, backupDir), 2)
+		require.Empty(t, listBackups(t, f, testDir))
+		require.FileExists(t, filepath.Join(testDir, "app.log"))
+	})
+
+	t.Run("same directory", func(t *testing.T) {
+		testDir := t.TempDir()
+		f, err := NewRotatingFile(filepath.Join(testDir, "app.log"), WithBackupDir(testDir+string(filepath.Separator), false), WithDuration(-1))
+		require.NoError(t, err)
+		require.Equal(t, f.folder, f.backupFolder)
+		_, err = f.WriteString("hello\n")
+		require.NoError(t, err)
+		require.NoError(t, f.Rotate())
+		require.NoError(t, f.Close())
+		require.Len(t, listBackups(t, f, testDir), 1)
+	})
+
+	t.Run("not existed backup directory", func(t *testing.T) {
+		testDir := t.TempDir()
+		f, err := NewRotatingFile(filepath.Join(testDir, "app.log"), WithBackupDir(filepath.Join(testDir, "archive"), false), WithDuration(-1))
+		require.NoError(t, err)
+		defer f.Close()
+		backups, err := f.cleanBackups(context.Background())
+		require.NoError(t, err)
+		require.Empty(t, backups)
+	})
+
+	readOnly := func(t *testing.T) {
+		osMkdirAll = func(path string, perm os.FileMode) error {
+			return &os.PathError{Op: "mkdir", Path: path, Err: syscall.EROFS}
+		}
+		t.Cleanup(func() { osMkdirAll = os.MkdirAll })
+	}
+
+	t.Run("read-only", func(t *testing.T) {
+		testDir := t.TempDir()
+		f, err := NewRotatingFile(filepath.Join(testDir, "app.log"), WithBackupDir(filepath.Join(testDir, "archive"), false), WithDuration(-1))
+		require.NoError(t, err)
+		defer f.Close()
+		_, err = f.WriteString("hello\n")
+		require.NoError(t, err)
+		readOnly(t)
+		err = f.Rotate()
+		require.ErrorIs(t, err, syscall.EROFS)
+		require.ErrorContains(t, err, "failed to backup file")
+	})
+
+	t.Run("read-only fallback", func(t *testing.T) {
+		testDir := t.TempDir()
+		backupDir := filepath.Join(testDir, "archive")
+		f, err := NewRotatingFile(filepath.Join(testDir, "app.log"), WithBackupDir(backupDir, true), WithBackups(1), WithCompressLevel(0), WithDuration(-1))
+		require.NoError(t, err)
+		_, err = f.WriteString("hello\n")
+		require.NoError(t, err)
+		readOnly(t)
+		warnings := errors.CaptureWarnings(func() {
+			require.NoError(t, f.Rotate())
+			require.NoError(t, f.Rotate())
+			require.NoError(t, f.Close())
+		})
+		require.Len(t, warnings, 3)
+		require.Contains(t, warnings[0], "failed to move backup to")
+		// the fallback backups are cleaned too
+		require.Equal(t, "rotate: cleanup removed 1 backup of app.log", warnings[2])
+		require.Len(t, listBackups(t, f, testDir), 1)
+		require.NoDirExists(t, backupDir)
+	})
+}
+
+func TestCleanBackupsScope(t *testing.T) {
+	newFile := func(t *testing.T) *RotatingFile {
+		f, err := NewRotatingFile(filepath.Join(t.TempDir(), "app.log"), WithBackups(1), WithCompressLevel(0), WithDuration(-1))
+		require.NoError(t, err)
+		return f
+	}
+
+	t.Run("clean backups", func(t *testing.T) {
+		f := newFile(t)
+		defer f.Close()
+		backups := createBackups(t, f, 4, 10)
+		remove := osRemove
+		osRemove = func(name string) error {
+			if name == backups[0] {
+				return os.ErrPermission
+			}
+			return remove(name)
+		}
+		defer func() { osRemove = remove }()
+
+		var err error
+		var warnings []error
+		escaped := errors.CaptureWarnings(func() {
+			err, warnings = errors.Scope(context.Background(), f.CleanBackups)
+		})
+		require.NoError(t, err)
+		require.Empty(t, escaped)
+		require.Len(t, warnings, 2)
+		require.EqualError(t, warnings[0], "rotate: cleanup removed 2 backups of app.log")
+		require.ErrorContains(t, warnings[1], "rotate: cleanup backups of app.log: 1 warning")
+		left, err := sortedBackups(f)
+		require.NoError(t, err)
+		require.Len(t, left, 2)
+	})
+
+	t.Run("close", func(t *testing.T) {
+		f := newFile(t)
+		createBackups(t, f, 3, 10)
+		err, warnings := errors.Scope(context.Background(), f.CloseContext)
+		require.NoError(t, err)
+		require.Len(t, warnings, 1)
+		require.EqualError(t, warnings[0], "rotate: cleanup removed 2 backups of app.log")
+	})
+
+	t.Run("failure", func(t *testing.T) {
+		f := newFile(t)
+		defer f.Close()
+		require.NoError(t, os.RemoveAll(f.folder))
+		err := f.CleanBackups(context.Background())
+		require.ErrorContains(t, err, "failed to clean backups of app.log")
+		require.ErrorIs(t, err, os.ErrNotExist)
+	})
+}
+
+func TestReopenReasonString(t *testing.T) {
+	require.Equal(t, "ExternalDeletion", ExternalDeletion.String())
+	require.Equal(t, "Rotation", Rotation.String())
+	require.Equal(t, "ReopenReason(9)", ReopenReason(9).String())
+}
+
+func TestOnReopen(t *testing.T) {
+	type reopen struct {
+		reason  ReopenReason
+		oldSize int64
+	}
+	newFile := func(t *testing.T, opts ...SetOption) (*RotatingFile, *[]reopen) {
+		var reopens []reopen
+		var f *RotatingFile
+		testFile := filepath.Join(t.TempDir(), "app.log")
+		opts = append([]SetOption{WithRecheckInterval(0), WithDuration(-1), WithOnReopen(func(reason ReopenReason, oldSize int64) {
+			reopens = append(reopens, reopen{reason, oldSize})
+			// called outside the lock of the file
+			_, err := f.WriteString("reopened\n")
+			require.NoError(t, err)
+		})}, opts...)
+		f, err := NewRotatingFile(testFile, opts...)
+		require.NoError(t, err)
+		t.Cleanup(func() { f.Close() })
+		_, err = f.WriteString("hello\n")
+		require.NoError(t, err)
+		return f, &reopens
+	}
+	readFile := func(t *testing.T, f *RotatingFile) string {
+		content, err := os.ReadFile(f.file)
+		require.NoError(t, err)
+		return string(content)
+	}
+
+	t.Run("external deletion", func(t *testing.T) {
+		f, reopens := newFile(t)
+		require.NoError(t, os.Remove(f.file))
+		_, err := f.WriteString("world\n")
+		require.NoError(t, err)
+		require.Equal(t, []reopen{{ExternalDeletion, 6}}, *reopens)
+		require.Equal(t, "world\nreopened\n", readFile(t, f))
+		require.Equal(t, int64(1), f.Stats().Reopens[ExternalDeletion])
+	})
+
+	t.Run("external replacement", func(t *testing.T) {
+		f, reopens := newFile(t)
+		require.NoError(t, os.Rename(f.file, f.file+".1"))
+		require.NoError(t, os.WriteFile(f.file, []byte("new\n"), 0o644))
+		_, err := f.WriteString("world\n")
+		require.NoError(t, err)
+		require.Equal(t, []reopen{{ExternalDeletion, 6}}, *reopens)
+		require.Equal(t, "new\nworld\nreopened\n", readFile(t, f))
+	})
+
+	t.Run("external truncation", func(t *testing.T) {
+		f, reopens := newFile(t, WithMaxSize(lib.KB))
+		require.NoError(t, os.Truncate(f.file, 0))
+		_, err := f.WriteString("world\n")
+		require.NoError(t, err)
+		require.Equal(t, []reopen{{ExternalTruncation, 6}}, *reopens)
+		require.Equal(t, "world\nreopened\n", readFile(t, f))
+		require.Equal(t, int64(15), f.used)
+		require.Equal(t, int64(1), f.Stats().Reopens[ExternalTruncation])
+	})
+
+	t.Run("recheck interval", func(t *testing.T) {
+		f, reopens := newFile(t, WithRecheckInterval(time.Hour))
+		require.NoError(t, os.Truncate(f.file, 0))
+		_, err := f.WriteString("world\n")
+		require.NoError(t, err)
+		require.Empty(t, *reopens)
+	})
+
+	t.Run("manual reopen", func(t *testing.T) {
+		f, reopens := newFile(t)
+		require.NoError(t, os.Rename(f.file, f.file+".1"))
+		require.NoError(t, f.Reopen())
+		require.Equal(t, []reopen{{ManualReopen, 6}}, *reopens)
+		require.Equal(t, "reopened\n", readFile(t, f))
+	})
+
+	t.Run("rotation", func(t *testing.T) {
+		f, reopens := newFile(t)
+		require.NoError(t, f.Rotate())
+		require.Equal(t, []reopen{{Rotation, 6}}, *reopens)
+		require.Equal(t, "reopened\n", readFile(t, f))
+		require.Equal(t, Stats{Reopens: [Rotation + 1]int64{Rotation: 1}}, f.Stats())
+	})
+
+	t.Run("left file over max size", func(t *testing.T) {
+		testFile := filepath.Join(t.TempDir(), "app.log")
+		require.NoError(t, os.WriteFile(testFile, []byte("hello world\n"), 0o644))
+		var reopens []reopen
+		f, err := NewRotatingFile(testFile, WithMaxSize(10), WithDuration(-1), WithOnReopen(func(reason ReopenReason, oldSize int64) {
+			reopens = append(reopens, reopen{reason, oldSize})
+		}))
+		require.NoError(t, err)
+		defer f.Close()
+		_, err = f.WriteString("hello\n")
+		require.NoError(t, err)
+		require.Equal(t, []reopen{{Rotation, 12}}, reopens)
+		// the write goes to the new file, not to the backup
+		require.Equal(t, "hello\n", readFile(t, f))
+	})
+}
+
+func TestSyncDirs(t *testing.T) {
+	var created []string
+	makeFileSync = func(file string, flag int, perm, dirPerm os.FileMode) (*os.File, error) {
+		created = append(created, file)
+		return paths.MakeFileSyncPerm(file, flag, perm, dirPerm)
+	}
+	defer func() { makeFileSync = paths.MakeFileSyncPerm }()
+
+	testFile := filepath.Join(t.TempDir(), "logs", "app.log")
+	f, err := NewRotatingFile(testFile, WithSyncDirs(true), WithDirPerm(0o700), WithDuration(-1))
+	require.NoError(t, err)
+	defer f.Close()
+	_, err = f.WriteString("hello\n")
+	require.NoError(t, err)
+	require.NoError(t, f.Rotate())
+	require.Equal(t, []string{testFile, testFile}, created)
+	info, err := os.Stat(filepath.Dir(testFile))
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0o700), info.Mode().Perm())
+
+	// without SyncDirs
+	created = nil
+	f2, err := NewRotatingFile(filepath.Join(t.TempDir(), "app.log"), WithDuration(-1))
+	require.NoError(t, err)
+	defer f2.Close()
+	_, err = f2.WriteString("hello\n")
+	require.NoError(t, err)
+	require.Empty(t, created)
+}
+
+func TestHeader(t *testing.T) {
+	testFile := filepath.Join(t.TempDir(), "app.log")
+	var backups []string
+	f, err := NewRotatingFile(testFile, WithMaxSize(20), WithDuration(-1), WithHeader(func(backup string) []byte {
+		backups = append(backups, backup)
+		return []byte("header " + filepath.Base(backup) + "\n")
+	}))
+	require.NoError(t, err)
+	defer f.Close()
+	readFile := func(name string) string {
+		content, err := os.ReadFile(name)
+		require.NoError(t, err)
+		return string(content)
+	}
+
+	// the new file and the files of the rotations start with the header, which counts
+	// in the size
+	_, err = f.WriteString("hello\n")
+	require.NoError(t, err)
+	require.Equal(t, []string{""}, backups)
+	require.Equal(t, "header .\nhello\n", readFile(testFile))
+	require.Equal(t, int64(15), f.used)
+	_, err = f.WriteString("world\n")
+	require.NoError(t, err)
+	require.Len(t, backups, 2)
+	require.Equal(t, "header .\nhello\nworld\n", readFile(backups[1]))
+	require.Equal(t, "header "+filepath.Base(backups[1])+"\n", readFile(testFile))
+	require.Equal(t, int64(len(readFile(testFile))), f.size)
+
+	require.NoError(t, f.Close())
+
+	// the file opened with data has no header, the rotation without backup passes ""
+	backups = nil
+	f, err = NewRotatingFile(testFile, WithBackups(0), WithDuration(-1), WithHeader(func(backup string) []byte {
+		backups = append(backups, backup)
+		return nil
+	}))
+	require.NoError(t, err)
+	defer f.Close()
+	_, err = f.WriteString("again\n")
+	require.NoError(t, err)
+	require.Empty(t, backups)
+	require.NoError(t, f.Rotate())
+	require.Equal(t, []string{""}, backups)
+	require.Equal(t, "", readFile(testFile))
+}
+
 // -·-·-·-·-·-·--·-·-·-·-
 //
 //	BENCHMARK TEST
 //
 // -·-·-·-·-·-·--·-·-·-·-
+// BenchmarkCleanBackups streams 100k synthetic backups, the memory of the cleanup is
+// bounded by the batches and Backups.
+func BenchmarkCleanBackups(b *testing.B) {
+	f, err := NewRotatingFile(filepath.Join(b.TempDir(), "app.log"), WithDuration(-1), WithBackups(30), WithCompressLevel(0))
+	require.NoError(b, err)
+	defer f.Close()
+	// the synthetic backups are older than MaxAge
+	f.option.MaxAge = 0
+	entries := syntheticBackups(f, 100000)
+	osOpenDir = func(name string) (dirReader, error) {
+		return &entriesDir{entries: entries}, nil
+	}
+	osRemove = func(name string) error { return nil }
+	defer func() { osOpenDir, osRemove = openDir, os.Remove }()
+	// the notices of the cleanups
+	errors.SetWarningOutput(io.Discard)
+	defer errors.SetWarningOutput(os.Stderr)
+
+	b.Run("EachBackup", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			require.NoError(b, f.EachBackup(func(BackupInfo) bool { return true }))
+		}
+	})
+	b.Run("cleanBackups", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_, err := f.cleanBackups(context.Background())
+			require.NoError(b, err)
+		}
+	})
+}
+
 func BenchmarkWrite(b *testing.B) {
 	testDir := b.TempDir()
 	defer os.RemoveAll(testDir)
@@ -527,6 +1530,7 @@ func BenchmarkWrite(b *testing.B) {
 		n, err := f.WriteString("hello world!\n")
 		require.Equal(b, 13, n)
 		require.NoError(b, err)
+		b.ReportAllocs()
 		for i := 0; i < b.N; i++ {
 			n, err := f.WriteString("hello world!\n")
 			require.Equal(b, 13, n)
@@ -591,7 +1595,7 @@ func TestLogicTidyBackups(t *testing.T) {
 			require.Equal(t, 8, n)
 			require.NoError(t, err)
 		}
-		files, err := f.sortBackups()
+		files, err := sortedBackups(f)
 		require.NoError(t, err)
 		f.Close()
 		require.Equal(t, 0, len(files))
@@ -612,7 +1616,7 @@ func TestLogicTidyBackups(t *testing.T) {
 			require.NoError(t, err)
 		}
 
-		files, err := f.sortBackups()
+		files, err := sortedBackups(f)
 		require.NoError(t, err)
 		f.Close()
 		require.Equal(t, 0, len(files))
@@ -626,8 +1630,8 @@ func TestLogicTidyBackups(t *testing.T) {
 		f, err := NewRotatingFile(testFile, WithMaxSize(10), WithBackups(0), WithMaxAge(-1))
 		require.NoError(t, err)
 		defer f.Close()
-		f.tidyBackups()
-		files, err := f.sortBackups()
+		f.tidyBackups(context.Background())
+		files, err := sortedBackups(f)
 		require.NoError(t, err)
 		require.Equal(t, 0, len(files))
 	})
@@ -649,10 +1653,10 @@ func TestLogicTidyBackups(t *testing.T) {
 		err = f.Close()
 		require.NoError(t, err)
 		// ensure all backups has been compressed
-		f.tidyBackups()
+		f.tidyBackups(context.Background())
 		err = f.Close()
 		require.NoError(t, err)
-		files, err := f.sortBackups()
+		files, err := sortedBackups(f)
 		require.NoError(t, err)
 		require.True(t, len(files) <= 2)
 	})
@@ -677,10 +1681,10 @@ func TestLogicTidyBackups(t *testing.T) {
 		require.NoError(t, err)
 
 		// ensure all backups has been compressed
-		f.tidyBackups()
+		f.tidyBackups(context.Background())
 		err = f.Close()
 		require.NoError(t, err)
-		files, err := f.sortBackups()
+		files, err := sortedBackups(f)
 		require.NoError(t, err)
 		require.Equal(t, number, len(files))
 		for index := range files {
@@ -705,7 +1709,7 @@ func TestLogicTidyBackups(t *testing.T) {
 		// wait for rotate
 		err = f.Close()
 		require.NoError(t, err)
-		files, err := f.sortBackups()
+		files, err := sortedBackups(f)
 		require.NoError(t, err)
 		require.Equal(t, number, len(files))
 		for index := range files {
@@ -747,7 +1751,7 @@ func TestLogicRotate(t *testing.T) {
 		require.Equal(t, int64(0), f.used)
 		err = f.Close()
 		require.NoError(t, err)
-		files, err := f.sortBackups()
+		files, err := sortedBackups(f)
 		require.NoError(t, err)
 		require.Equal(t, 1, len(files))
 	})
@@ -771,7 +1775,7 @@ func TestLogicRotate(t *testing.T) {
 		time.Sleep(time.Duration(float64(duration) * 1.5))
 		err = f.Close()
 		require.NoError(t, err)
-		files, err := f.sortBackups()
+		files, err := sortedBackups(f)
 		require.NoError(t, err)
 		require.Equal(t, 0, len(files))
 
@@ -783,7 +1787,7 @@ func TestLogicRotate(t *testing.T) {
 		require.Equal(t, int64(0), f.used)
 		time.Sleep(time.Duration(float64(duration) * 1.5))
 		err = f.Close()
-		files, err = f.sortBackups()
+		files, err = sortedBackups(f)
 		require.NoError(t, err)
 		require.Equal(t, 1, len(files))
 	})
@@ -811,7 +1815,7 @@ func TestLogicRotate(t *testing.T) {
 		time.Sleep(time.Duration(float64(duration) * 1.5))
 		err = f.Close()
 		require.NoError(t, err)
-		files, err := f.sortBackups()
+		files, err := sortedBackups(f)
 		require.NoError(t, err)
 		require.Equal(t, 0, len(files))
 
@@ -825,7 +1829,7 @@ func TestLogicRotate(t *testing.T) {
 		time.Sleep(time.Duration(float64(duration) * 1.5))
 		err = f.Close()
 		require.False(t, f.rotatingTime.IsZero())
-		files, err = f.sortBackups()
+		files, err = sortedBackups(f)
 		require.NoError(t, err)
 		require.Equal(t, 1, len(files))
 		durationRotateTime := f.rotatingTime